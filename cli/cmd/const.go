@@ -72,6 +72,12 @@ const (
 	CliFlagDelBatchCount      = "delete-batch-count"
 	CliFlagDelWorkerSleepMs   = "delete-worker-sleep-ms"
 	CliFlagMarkDelRate        = "mark-delete-rate"
+	CliFlagMaxBytes           = "max-bytes"
+	CliFlagMaxFiles           = "max-files"
+	CliFlagMaxCapacity        = "max-capacity"
+	CliFlagMaxInodes          = "max-inodes"
+	CliFlagMaxVols            = "max-vols"
+	CliFlagTrashInterval      = "trash-interval"
 
 	//CliFlagSetDataPartitionCount	= "count" use dp-count instead
 
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/meta"
 )
 
 func formatClusterView(cv *proto.ClusterView) string {
@@ -100,6 +101,7 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  Authenticate         : %v\n", formatEnabledDisabled(svv.Authenticate)))
 	sb.WriteString(fmt.Sprintf("  Follower read        : %v\n", formatEnabledDisabled(svv.FollowerRead)))
 	sb.WriteString(fmt.Sprintf("  Cross zone           : %v\n", formatEnabledDisabled(svv.CrossZone)))
+	sb.WriteString(fmt.Sprintf("  Trash interval       : %v\n", formatTrashInterval(svv.TrashInterval)))
 	sb.WriteString(fmt.Sprintf("  Inode count          : %v\n", svv.InodeCount))
 	sb.WriteString(fmt.Sprintf("  Dentry count         : %v\n", svv.DentryCount))
 	sb.WriteString(fmt.Sprintf("  Max metaPartition ID : %v\n", svv.MaxMetaPartitionID))
@@ -110,6 +112,13 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	return sb.String()
 }
 
+func formatTrashInterval(interval uint32) string {
+	if interval == 0 {
+		return "Disabled"
+	}
+	return fmt.Sprintf("%v min", interval)
+}
+
 func formatVolumeStatus(status uint8) string {
 	switch status {
 	case 0:
@@ -132,6 +141,65 @@ func formatVolInfoTableRow(vi *proto.VolInfo) string {
 		formatVolumeStatus(vi.Status), time.Unix(vi.CreateTime, 0).Local().Format(time.RFC1123))
 }
 
+var (
+	volSnapshotTablePattern = "%-8v    %-24v    %-10v    %-10v"
+	volSnapshotTableHeader  = fmt.Sprintf(volSnapshotTablePattern, "ID", "NAME", "STATUS", "CREATE TIME")
+)
+
+func formatVolSnapshotTableRow(snapshot *proto.VolSnapshotInfo) string {
+	return fmt.Sprintf(volSnapshotTablePattern,
+		snapshot.SnapshotId, snapshot.Name, snapshot.Status, time.Unix(snapshot.CreateTime, 0).Local().Format(time.RFC1123))
+}
+
+var (
+	changelogTablePattern = "%-10v    %-10v    %-19v    %-14v    %-10v    %-10v    %-12v    %v"
+	changelogTableHeader  = fmt.Sprintf(changelogTablePattern,
+		"PARTITION", "SEQ", "TIME", "TYPE", "INODE", "PARENT", "SIZE", "NAME")
+)
+
+func formatChangelogTableRow(partitionID uint64, event *proto.ChangelogEvent) string {
+	var name = event.Name
+	if len(event.Keys) != 0 {
+		name = strings.Join(event.Keys, ",")
+	}
+	return fmt.Sprintf(changelogTablePattern, partitionID, event.Seq, formatTime(event.Time),
+		proto.ChangelogTypeString(event.Type), event.Inode, event.ParentId, event.Size, name)
+}
+
+var (
+	quotaInfoTablePattern = "%-8v    %-10v    %-24v    %-12v    %-12v    %-12v    %-12v"
+	quotaInfoTableHeader  = fmt.Sprintf(quotaInfoTablePattern,
+		"ID", "INODE", "PATH", "USED BYTES", "MAX BYTES", "USED FILES", "MAX FILES")
+)
+
+func formatQuotaInfoTableRow(quota *proto.QuotaInfo) string {
+	var maxBytes, maxFiles = "unlimited", "unlimited"
+	if quota.MaxBytes > 0 {
+		maxBytes = formatSize(quota.MaxBytes)
+	}
+	if quota.MaxFiles > 0 {
+		maxFiles = strconv.FormatUint(quota.MaxFiles, 10)
+	}
+	return fmt.Sprintf(quotaInfoTablePattern,
+		quota.QuotaId, quota.Inode, quota.Path, formatSize(quota.UsedBytes), maxBytes, quota.UsedFiles, maxFiles)
+}
+
+var (
+	trashEntryTablePattern = "%-32v    %-10v    %-6v    %-19v    %-10v    %v"
+	trashEntryTableHeader  = fmt.Sprintf(trashEntryTablePattern, "TRASH NAME", "INODE", "TYPE", "DELETE TIME", "PARENT", "NAME")
+)
+
+func formatTrashEntryTableRow(entry *meta.TrashEntry) string {
+	var entryType, deleteTime = "file", "unknown"
+	if proto.IsDir(entry.Mode) {
+		entryType = "dir"
+	}
+	if !entry.DeleteTime.IsZero() {
+		deleteTime = entry.DeleteTime.Format(proto.TimeFormat)
+	}
+	return fmt.Sprintf(trashEntryTablePattern, entry.Name, entry.Inode, entryType, deleteTime, entry.ParentID, entry.OrigName)
+}
+
 var (
 	dataPartitionTablePattern = "%-8v    %-8v    %-10v    %-10v     %-18v    %-18v"
 	dataPartitionTableHeader  = fmt.Sprintf(dataPartitionTablePattern,
@@ -0,0 +1,228 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdQuotaUse   = "quota [COMMAND]"
+	cmdQuotaShort = "Manage directory quotas of volumes"
+)
+
+func newQuotaCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdQuotaUse,
+		Short: cmdQuotaShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newQuotaSetCmd(client),
+		newQuotaListCmd(client),
+		newQuotaDeleteCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdQuotaSetUse   = "set [VOLUME NAME] [PATH]"
+	cmdQuotaSetShort = "Set quota on the bytes and the inode count of the directory"
+)
+
+func newQuotaSetCmd(client *master.MasterClient) *cobra.Command {
+	var optMaxBytes uint64
+	var optMaxFiles uint64
+	var cmd = &cobra.Command{
+		Use:   cmdQuotaSetUse,
+		Short: cmdQuotaSetShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			var path = args[1]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			var mw *meta.MetaWrapper
+			if mw, err = newVolMetaWrapper(client, volumeName, svv.Owner); err != nil {
+				return
+			}
+			defer func() { _ = mw.Close() }()
+
+			var inode uint64
+			if inode, err = mw.LookupPath(path); err != nil {
+				err = fmt.Errorf("Lookup path [%v] failed:\n%v\n", path, err)
+				return
+			}
+			var info *proto.InodeInfo
+			if info, err = mw.InodeGet_ll(inode); err != nil {
+				return
+			}
+			if !proto.IsDir(info.Mode) {
+				err = fmt.Errorf("Path [%v] is not a directory.\n", path)
+				return
+			}
+
+			var quota *proto.QuotaInfo
+			if quota, err = client.AdminAPI().SetQuota(volumeName, calcAuthKey(svv.Owner), inode, path, optMaxBytes, optMaxFiles); err != nil {
+				err = fmt.Errorf("Set quota failed:\n%v\n", err)
+				return
+			}
+			// Tag the existing inodes under the directory, so that their usage is counted. The
+			// meta nodes tag the new inodes by the quotas they receive on the next heartbeat, so
+			// the command should be run again if the volume had no quota before.
+			var count int
+			if count, err = tagQuota(mw, inode, quota.QuotaId); err != nil {
+				err = fmt.Errorf("Tag inodes with quota [%v] failed, run the command again to retry:\n%v\n", quota.QuotaId, err)
+				return
+			}
+			stdout("Set quota [%v] on [%v] success, %v inodes tagged.\n", quota.QuotaId, path, count)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint64Var(&optMaxBytes, CliFlagMaxBytes, 0, "Specify the limit on the bytes, 0 means unlimited")
+	cmd.Flags().Uint64Var(&optMaxFiles, CliFlagMaxFiles, 0, "Specify the limit on the inode count, 0 means unlimited")
+	return cmd
+}
+
+// tagQuota tags the directory and the inodes under it with the quota ID. The directories are
+// tagged before their children, so that the inodes created during the walk inherit the quota.
+func tagQuota(mw *meta.MetaWrapper, dir uint64, quotaId uint64) (count int, err error) {
+	if err = tagInodeQuota(mw, dir, quotaId); err != nil {
+		return
+	}
+	count++
+	var dentries []proto.Dentry
+	if dentries, err = mw.ReadDir_ll(dir); err != nil {
+		return
+	}
+	for _, dentry := range dentries {
+		if proto.IsDir(dentry.Type) {
+			var n int
+			n, err = tagQuota(mw, dentry.Inode, quotaId)
+			count += n
+			if err != nil {
+				return
+			}
+			continue
+		}
+		if err = tagInodeQuota(mw, dentry.Inode, quotaId); err != nil {
+			return
+		}
+		count++
+	}
+	return
+}
+
+func tagInodeQuota(mw *meta.MetaWrapper, inode uint64, quotaId uint64) (err error) {
+	return mw.TagQuota_ll(inode, quotaId)
+}
+
+const (
+	cmdQuotaListUse   = "list [VOLUME NAME]"
+	cmdQuotaListShort = "List quotas of the volume with their usage"
+)
+
+func newQuotaListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     cmdQuotaListUse,
+		Short:   cmdQuotaListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var quotas []*proto.QuotaInfo
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if quotas, err = client.AdminAPI().ListQuota(args[0]); err != nil {
+				return
+			}
+			stdout("%v\n", quotaInfoTableHeader)
+			for _, quota := range quotas {
+				stdout("%v\n", formatQuotaInfoTableRow(quota))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdQuotaDeleteUse   = "delete [VOLUME NAME] [QUOTA ID]"
+	cmdQuotaDeleteShort = "Delete the quota"
+)
+
+func newQuotaDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdQuotaDeleteUse,
+		Short: cmdQuotaDeleteShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var quotaId uint64
+			if quotaId, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				return
+			}
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			if err = client.AdminAPI().DeleteQuota(volumeName, calcAuthKey(svv.Owner), quotaId); err != nil {
+				err = fmt.Errorf("Delete quota failed:\n%v\n", err)
+				return
+			}
+			stdout("Delete quota [%v] success.\n", quotaId)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,228 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdTrashUse   = "trash [COMMAND]"
+	cmdTrashShort = "Manage the trash of volumes"
+)
+
+func newTrashCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdTrashUse,
+		Short: cmdTrashShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newTrashListCmd(client),
+		newTrashRestoreCmd(client),
+		newTrashPurgeCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdTrashListUse   = "list [VOLUME NAME]"
+	cmdTrashListShort = "List the deleted files and directories in the trash"
+)
+
+func newTrashListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     cmdTrashListUse,
+		Short:   cmdTrashListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var entries []*meta.TrashEntry
+			if entries, err = listTrash(client, args[0]); err != nil {
+				return
+			}
+			stdout("%v\n", trashEntryTableHeader)
+			for _, entry := range entries {
+				stdout("%v\n", formatTrashEntryTableRow(entry))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdTrashRestoreUse   = "restore [VOLUME NAME] [PATH | TRASH NAME]"
+	cmdTrashRestoreShort = "Restore the last deleted file or directory of the path, or the entry of the name in the trash"
+)
+
+func newTrashRestoreCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdTrashRestoreUse,
+		Short: cmdTrashRestoreShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			var target = args[1]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var mw *meta.MetaWrapper
+			if mw, err = newTrashMetaWrapper(client, volumeName); err != nil {
+				return
+			}
+			defer func() { _ = mw.Close() }()
+			var entries []*meta.TrashEntry
+			if entries, err = mw.ListTrash_ll(); err != nil {
+				return
+			}
+
+			// The path is matched by the original parent directory and name, and the directory
+			// is restored with the entries deleted from it.
+			var parentID uint64
+			var name string
+			if strings.HasPrefix(target, "/") {
+				var dir string
+				dir, name = path.Split(path.Clean(target))
+				if parentID, err = mw.LookupPath(dir); err != nil {
+					err = fmt.Errorf("Lookup path [%v] failed:\n%v\n", dir, err)
+					return
+				}
+			}
+			var matched *meta.TrashEntry
+			for _, entry := range entries {
+				if entry.Name != target && (entry.ParentID != parentID || entry.OrigName != name) {
+					continue
+				}
+				if matched == nil || entry.DeleteTime.After(matched.DeleteTime) {
+					matched = entry
+				}
+			}
+			if matched == nil {
+				err = fmt.Errorf("No entry of [%v] in the trash.\n", target)
+				return
+			}
+			if err = mw.RestoreTrash_ll(matched); err != nil {
+				err = fmt.Errorf("Restore [%v] failed:\n%v\n", matched.Name, err)
+				return
+			}
+			stdout("Restore [%v] success.\n", matched.Name)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdTrashPurgeUse   = "purge [VOLUME NAME]"
+	cmdTrashPurgeShort = "Delete all the entries in the trash permanently"
+)
+
+func newTrashPurgeCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdTrashPurgeUse,
+		Short: cmdTrashPurgeShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !optYes {
+				stdout("All the entries in the trash of volume [%v] will be deleted permanently.\n", volumeName)
+				stdout("\nConfirm (yes/no)[no]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			var mw *meta.MetaWrapper
+			if mw, err = newTrashMetaWrapper(client, volumeName); err != nil {
+				return
+			}
+			defer func() { _ = mw.Close() }()
+			var count int
+			if count, err = mw.PurgeTrash_ll(time.Now().Add(time.Second)); err != nil {
+				return
+			}
+			stdout("%v entries purged.\n", count)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newTrashMetaWrapper(client *master.MasterClient, volumeName string) (mw *meta.MetaWrapper, err error) {
+	var svv *proto.SimpleVolView
+	if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+		return
+	}
+	return newVolMetaWrapper(client, volumeName, svv.Owner)
+}
+
+func listTrash(client *master.MasterClient, volumeName string) (entries []*meta.TrashEntry, err error) {
+	var mw *meta.MetaWrapper
+	if mw, err = newTrashMetaWrapper(client, volumeName); err != nil {
+		return
+	}
+	defer func() { _ = mw.Close() }()
+	if entries, err = mw.ListTrash_ll(); err != nil {
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeleteTime.Before(entries[j].DeleteTime)
+	})
+	return
+}
@@ -40,6 +40,7 @@ func newUserCmd(client *master.MasterClient) *cobra.Command {
 		newUserListCmd(client),
 		newUserPermCmd(client),
 		newUserUpdateCmd(client),
+		newUserQuotaCmd(client),
 		newUserDeleteCmd(client),
 	)
 	return cmd
@@ -219,6 +220,55 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 	return cmd
 }
 
+const (
+	cmdUserQuotaUse   = "quota [USER ID]"
+	cmdUserQuotaShort = "Set quota on the total capacity, inodes and volumes which the user owns"
+)
+
+func newUserQuotaCmd(client *master.MasterClient) *cobra.Command {
+	var optMaxCapacity uint64
+	var optMaxInodes uint64
+	var optMaxVols uint64
+	var cmd = &cobra.Command{
+		Use:   cmdUserQuotaUse,
+		Short: cmdUserQuotaShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var userID = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var param = proto.UserUpdateParam{
+				UserID: userID,
+				Quota: &proto.UserQuota{
+					MaxCapacity: optMaxCapacity,
+					MaxInodes:   optMaxInodes,
+					MaxVolCount: optMaxVols,
+				},
+			}
+			var userInfo *proto.UserInfo
+			if userInfo, err = client.UserAPI().UpdateUser(&param); err != nil {
+				err = fmt.Errorf("Set user quota failed:\n%v\n", err)
+				return
+			}
+			stdout("Set quota of user [%v] success.\n", userInfo.UserID)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint64Var(&optMaxCapacity, CliFlagMaxCapacity, 0, "Specify the limit on the total capacity (GB), 0 means unlimited")
+	cmd.Flags().Uint64Var(&optMaxInodes, CliFlagMaxInodes, 0, "Specify the limit on the total inodes, 0 means unlimited")
+	cmd.Flags().Uint64Var(&optMaxVols, CliFlagMaxVols, 0, "Specify the limit on the volume count, 0 means unlimited")
+	return cmd
+}
+
 const (
 	cmdUserDeleteUse   = "delete [USER ID]"
 	cmdUserDeleteShort = "Delete specified user"
@@ -425,6 +475,21 @@ func printUserInfo(userInfo *proto.UserInfo) {
 	stdout("  Secret Key : %v\n", userInfo.SecretKey)
 	stdout("  Type       : %v\n", userInfo.UserType)
 	stdout("  Create Time: %v\n", userInfo.CreateTime)
+	if userInfo.Quota != nil || userInfo.Usage != nil {
+		var quota = userInfo.Quota
+		if quota == nil {
+			quota = &proto.UserQuota{}
+		}
+		var usage = userInfo.Usage
+		if usage == nil {
+			usage = &proto.UserUsage{}
+		}
+		stdout("[Quota]\n")
+		stdout("%-20v    %-12v    %-12v\n", "ITEM", "USED", "LIMIT")
+		stdout("%-20v    %-12v    %-12v\n", "Capacity (GB)", usage.Capacity, formatUserQuotaLimit(quota.MaxCapacity))
+		stdout("%-20v    %-12v    %-12v\n", "Inodes", usage.Inodes, formatUserQuotaLimit(quota.MaxInodes))
+		stdout("%-20v    %-12v    %-12v\n", "Volumes", usage.VolCount, formatUserQuotaLimit(quota.MaxVolCount))
+	}
 	if userInfo.Policy == nil {
 		return
 	}
@@ -437,3 +502,10 @@ func printUserInfo(userInfo *proto.UserInfo) {
 		stdout("%-20v    %-12v\n", vol, strings.Join(perms, ","))
 	}
 }
+
+func formatUserQuotaLimit(limit uint64) string {
+	if limit == 0 {
+		return "Unlimited"
+	}
+	return fmt.Sprintf("%v", limit)
+}
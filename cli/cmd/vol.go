@@ -21,9 +21,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/sdk/meta"
 	"github.com/spf13/cobra"
 )
 
@@ -49,6 +51,9 @@ func newVolCmd(client *master.MasterClient) *cobra.Command {
 		newVolDeleteCmd(client),
 		newVolTransferCmd(client),
 		newVolAddDPCmd(client),
+		newVolDirStatCmd(client),
+		newVolSnapshotCmd(client),
+		newVolChangelogCmd(client),
 	)
 	return cmd
 }
@@ -168,6 +173,7 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 	var optAuthenticate string
 	var optEnableToken string
 	var optZoneName string
+	var optTrashInterval string
 	var optYes bool
 	var confirmString = strings.Builder{}
 	var vv *proto.SimpleVolView
@@ -244,6 +250,17 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 			} else {
 				confirmString.WriteString(fmt.Sprintf("  ZoneName            : %v\n", vv.ZoneName))
 			}
+			if optTrashInterval != "" {
+				isChange = true
+				var interval uint64
+				if interval, err = strconv.ParseUint(optTrashInterval, 10, 32); err != nil {
+					return
+				}
+				confirmString.WriteString(fmt.Sprintf("  Trash interval      : %v -> %v\n", formatTrashInterval(vv.TrashInterval), formatTrashInterval(uint32(interval))))
+				vv.TrashInterval = uint32(interval)
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Trash interval      : %v\n", formatTrashInterval(vv.TrashInterval)))
+			}
 			if vv.CrossZone == true && "" != optZoneName {
 				err = fmt.Errorf("Can not set zone name of the volume that cross zone\n")
 			}
@@ -266,7 +283,7 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 				}
 			}
 			err = client.AdminAPI().UpdateVolume(vv.Name, vv.Capacity, int(vv.DpReplicaNum),
-				vv.FollowerRead, vv.Authenticate, vv.EnableToken, calcAuthKey(vv.Owner), vv.ZoneName)
+				vv.FollowerRead, vv.Authenticate, vv.EnableToken, calcAuthKey(vv.Owner), vv.ZoneName, vv.TrashInterval)
 			if err != nil {
 				return
 			}
@@ -285,6 +302,7 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&optFollowerRead, CliFlagEnableFollowerRead, "", "Enable read form replica follower")
 	cmd.Flags().StringVar(&optAuthenticate, CliFlagAuthenticate, "", "Enable authenticate")
 	cmd.Flags().StringVar(&optZoneName, CliFlagZoneName, "", "Specify volume zone name")
+	cmd.Flags().StringVar(&optTrashInterval, CliFlagTrashInterval, "", "Specify minutes the deleted files stay in the trash, 0 disables the trash")
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
@@ -584,3 +602,74 @@ func calcAuthKey(key string) (authKey string) {
 	cipherStr := h.Sum(nil)
 	return strings.ToLower(hex.EncodeToString(cipherStr))
 }
+
+const (
+	cmdVolDirStatUse   = "dir-stat [VOLUME NAME] [PATH]"
+	cmdVolDirStatShort = "Show recursive statistics of the directory"
+)
+
+func newVolDirStatCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     cmdVolDirStatUse,
+		Short:   cmdVolDirStatShort,
+		Aliases: []string{"du"},
+		Args:    cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			var path = args[1]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			var mw *meta.MetaWrapper
+			if mw, err = newVolMetaWrapper(client, volumeName, svv.Owner); err != nil {
+				return
+			}
+			defer func() { _ = mw.Close() }()
+
+			var inode uint64
+			if inode, err = mw.LookupPath(path); err != nil {
+				err = fmt.Errorf("Lookup path [%v] failed:\n%v\n", path, err)
+				return
+			}
+			var stat *proto.DirStat
+			if stat, err = mw.GetDirStat_ll(inode); err == syscall.ENODATA {
+				err = fmt.Errorf("Directory [%v] is created before the statistics are counted\n", path)
+				return
+			}
+			if err != nil {
+				err = fmt.Errorf("Get statistics of [%v] failed:\n%v\n", path, err)
+				return
+			}
+			stdout("[Summary]\n")
+			stdout("  Path     : %v\n", path)
+			// the statistics may be negative for a while before the changes from the
+			// other meta partitions arrive
+			if stat.Bytes >= 0 {
+				stdout("  Bytes    : %v\n", formatSize(uint64(stat.Bytes)))
+			} else {
+				stdout("  Bytes    : %v\n", stat.Bytes)
+			}
+			stdout("  Files    : %v\n", stat.Files)
+			stdout("  Subdirs  : %v\n", stat.Subdirs)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+// newVolMetaWrapper returns a client of the meta partitions of the volume.
+func newVolMetaWrapper(client *master.MasterClient, volumeName, owner string) (*meta.MetaWrapper, error) {
+	return meta.NewMetaWrapper(&meta.MetaConfig{Volume: volumeName, Owner: owner, Masters: client.Nodes()})
+}
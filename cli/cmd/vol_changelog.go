@@ -0,0 +1,136 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdVolChangelogUse   = "changelog [COMMAND]"
+	cmdVolChangelogShort = "Read the metadata changes of the volume"
+)
+
+func newVolChangelogCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdVolChangelogUse,
+		Short: cmdVolChangelogShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newVolChangelogTailCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdVolChangelogTailUse   = "tail [VOLUME NAME]"
+	cmdVolChangelogTailShort = "Show the metadata changes of the volume from the cursor"
+)
+
+func newVolChangelogTailCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optCursor string
+		optLimit  int
+		optFollow bool
+	)
+	var cmd = &cobra.Command{
+		Use:   cmdVolChangelogTailUse,
+		Short: cmdVolChangelogTailShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var cursor proto.ChangelogCursor
+			if cursor, err = proto.ParseChangelogCursor(optCursor); err != nil {
+				return
+			}
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			var mw *meta.MetaWrapper
+			if mw, err = newVolMetaWrapper(client, volumeName, svv.Owner); err != nil {
+				return
+			}
+			defer mw.Close()
+			stdout("%v\n", changelogTableHeader)
+			for {
+				if err = tailChangelog(mw, cursor, optLimit); err != nil {
+					return
+				}
+				if !optFollow {
+					break
+				}
+				time.Sleep(time.Second)
+			}
+			stdout("Cursor: %v\n", cursor)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optCursor, "cursor", "", "Start from the cursor printed by the last run, or from the oldest changes if not set")
+	cmd.Flags().IntVar(&optLimit, "limit", proto.ChangelogReadDefaultLimit, "Specify the number of changes read from a meta partition at a time")
+	cmd.Flags().BoolVarP(&optFollow, "follow", "f", false, "Keep showing the new changes")
+	return cmd
+}
+
+// tailChangelog prints the changes of each meta partition after the cursor, and moves the cursor
+// forward.
+func tailChangelog(mw *meta.MetaWrapper, cursor proto.ChangelogCursor, limit int) error {
+	pids := mw.ChangelogPartitions()
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	for _, pid := range pids {
+		for {
+			next, ok := cursor[pid]
+			resp, err := mw.ReadChangelog(pid, next, limit)
+			if err != nil {
+				return fmt.Errorf("Read changelog of partition [%v] failed:\n%v\n", pid, err)
+			}
+			if ok && next < resp.FirstSeq {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: changes [%v-%v] of partition [%v] are discarded\n",
+					next, resp.FirstSeq-1, pid)
+			}
+			for _, event := range resp.Events {
+				stdout("%v\n", formatChangelogTableRow(pid, event))
+			}
+			if len(resp.Events) == 0 {
+				break
+			}
+			cursor[pid] = resp.Events[len(resp.Events)-1].Seq + 1
+			if resp.Events[len(resp.Events)-1].Seq >= resp.LastSeq {
+				break
+			}
+		}
+	}
+	return nil
+}
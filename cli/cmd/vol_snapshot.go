@@ -0,0 +1,248 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdVolSnapshotUse   = "snapshot [COMMAND]"
+	cmdVolSnapshotShort = "Manage read-only point-in-time snapshots of the volume"
+)
+
+func newVolSnapshotCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdVolSnapshotUse,
+		Short: cmdVolSnapshotShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newVolSnapshotCreateCmd(client),
+		newVolSnapshotListCmd(client),
+		newVolSnapshotDeleteCmd(client),
+		newVolSnapshotMountCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdVolSnapshotCreateUse   = "create [VOLUME NAME] [SNAPSHOT NAME]"
+	cmdVolSnapshotCreateShort = "Create a snapshot of the volume"
+)
+
+func newVolSnapshotCreateCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdVolSnapshotCreateUse,
+		Short: cmdVolSnapshotCreateShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			var snapshot *proto.VolSnapshotInfo
+			if snapshot, err = client.AdminAPI().CreateVolSnapshot(volumeName, calcAuthKey(svv.Owner), args[1]); err != nil {
+				err = fmt.Errorf("Create snapshot failed:\n%v\n", err)
+				return
+			}
+			stdout("Create snapshot [%v] of volume [%v] success, ID [%v].\n", snapshot.Name, volumeName, snapshot.SnapshotId)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdVolSnapshotListUse   = "list [VOLUME NAME]"
+	cmdVolSnapshotListShort = "List snapshots of the volume"
+)
+
+func newVolSnapshotListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     cmdVolSnapshotListUse,
+		Short:   cmdVolSnapshotListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var snapshots []*proto.VolSnapshotInfo
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if snapshots, err = client.AdminAPI().ListVolSnapshot(args[0]); err != nil {
+				return
+			}
+			stdout("%v\n", volSnapshotTableHeader)
+			for _, snapshot := range snapshots {
+				stdout("%v\n", formatVolSnapshotTableRow(snapshot))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdVolSnapshotDeleteUse   = "delete [VOLUME NAME] [SNAPSHOT NAME | SNAPSHOT ID]"
+	cmdVolSnapshotDeleteShort = "Delete the snapshot and release the extents only referenced by it"
+)
+
+func newVolSnapshotDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdVolSnapshotDeleteUse,
+		Short: cmdVolSnapshotDeleteShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var snapshot *proto.VolSnapshotInfo
+			if snapshot, err = findVolSnapshot(client, volumeName, args[1]); err != nil {
+				return
+			}
+			if !optYes {
+				var confirm string
+				stdout("Delete snapshot [%v] of volume [%v] (yes/no)[no]:", snapshot.Name, volumeName)
+				_, _ = fmt.Scanln(&confirm)
+				if confirm != "yes" {
+					stdout("Abort by user.\n")
+					return
+				}
+			}
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			if err = client.AdminAPI().DeleteVolSnapshot(volumeName, calcAuthKey(svv.Owner), snapshot.SnapshotId); err != nil {
+				err = fmt.Errorf("Delete snapshot failed:\n%v\n", err)
+				return
+			}
+			stdout("Delete snapshot [%v] success.\n", snapshot.Name)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+const (
+	cmdVolSnapshotMountUse   = "mount [VOLUME NAME] [SNAPSHOT NAME | SNAPSHOT ID] [MOUNT POINT]"
+	cmdVolSnapshotMountShort = "Generate the client config which mounts the snapshot as readonly"
+)
+
+func newVolSnapshotMountCmd(client *master.MasterClient) *cobra.Command {
+	var optLogDir string
+	var cmd = &cobra.Command{
+		Use:   cmdVolSnapshotMountUse,
+		Short: cmdVolSnapshotMountShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var snapshot *proto.VolSnapshotInfo
+			if snapshot, err = findVolSnapshot(client, volumeName, args[1]); err != nil {
+				return
+			}
+			if !snapshot.IsReady() {
+				err = fmt.Errorf("Snapshot [%v] is not ready.\n", snapshot.Name)
+				return
+			}
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				return
+			}
+			var config = map[string]interface{}{
+				"mountPoint": args[2],
+				"volName":    volumeName,
+				"owner":      svv.Owner,
+				"masterAddr": strings.Join(client.Nodes(), ","),
+				"snapshot":   strconv.FormatUint(snapshot.SnapshotId, 10),
+				"rdonly":     true,
+			}
+			if optLogDir != "" {
+				config["logDir"] = optLogDir
+			}
+			var data []byte
+			if data, err = json.MarshalIndent(config, "", "  "); err != nil {
+				return
+			}
+			stdout("%v\n", string(data))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optLogDir, "log-dir", "", "Specify the log directory of the client")
+	return cmd
+}
+
+// findVolSnapshot finds the snapshot of the volume by the name or the ID.
+func findVolSnapshot(client *master.MasterClient, volumeName, snapshot string) (*proto.VolSnapshotInfo, error) {
+	snapshots, err := client.AdminAPI().ListVolSnapshot(volumeName)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snapshots {
+		if s.Name == snapshot || strconv.FormatUint(s.SnapshotId, 10) == snapshot {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("Snapshot [%v] of volume [%v] not found.\n", snapshot, volumeName)
+}
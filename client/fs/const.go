@@ -66,6 +66,15 @@ func ParseError(err error) fuse.Errno {
 	}
 }
 
+// ParseIOError returns the error type of the write and flush requests. The errors other than
+// exceeding the quota and writing a locked file are returned as EIO.
+func ParseIOError(err error) fuse.Errno {
+	if err == syscall.EDQUOT || err == syscall.EPERM {
+		return ParseError(err)
+	}
+	return fuse.EIO
+}
+
 // ParseType returns the dentry type.
 func ParseType(t uint32) fuse.DirentType {
 	if proto.IsDir(t) {
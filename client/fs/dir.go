@@ -16,6 +16,8 @@ package fs
 
 import (
 	"os"
+	"path"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -33,6 +35,7 @@ type Dir struct {
 	super  *Super
 	info   *proto.InodeInfo
 	dcache *DentryCache
+	path   string // path in the volume when the directory is looked up, which tells whether it is in the trash
 }
 
 // Functions that Dir needs to implement
@@ -56,10 +59,11 @@ var (
 )
 
 // NewDir returns a new directory.
-func NewDir(s *Super, i *proto.InodeInfo) fs.Node {
+func NewDir(s *Super, i *proto.InodeInfo, dirPath string) fs.Node {
 	return &Dir{
 		super: s,
 		info:  i,
+		path:  dirPath,
 	}
 }
 
@@ -143,7 +147,7 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 	}
 
 	d.super.ic.Put(info)
-	child := NewDir(d.super, info)
+	child := NewDir(d.super, info, path.Join(d.path, req.Name))
 
 	d.super.fslock.Lock()
 	d.super.nodeCache[info.Inode] = child
@@ -167,7 +171,13 @@ func (d *Dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: d.super.volname})
 	}()
 
-	info, err := d.super.mw.Delete_ll(d.info.Inode, req.Name, req.Dir)
+	// The entries are moved into the trash if it is enabled, except those already in the trash.
+	var info *proto.InodeInfo
+	if proto.IsTrashPath(d.path) {
+		info, err = d.super.mw.DeletePermanently_ll(d.info.Inode, req.Name, req.Dir)
+	} else {
+		info, err = d.super.mw.Delete_ll(d.info.Inode, req.Name, req.Dir)
+	}
 	if err != nil {
 		log.LogErrorf("Remove: parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
 		return ParseError(err)
@@ -222,7 +232,7 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	child, ok := d.super.nodeCache[ino]
 	if !ok {
 		if mode.IsDir() {
-			child = NewDir(d.super, info)
+			child = NewDir(d.super, info, path.Join(d.path, req.Name))
 		} else {
 			child = NewFile(d.super, info)
 		}
@@ -244,34 +254,48 @@ func (d *Dir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: d.super.volname})
 	}()
 
-	children, err := d.super.mw.ReadDir_ll(d.info.Inode)
-	if err != nil {
-		log.LogErrorf("Readdir: ino(%v) err(%v)", d.info.Inode, err)
-		return make([]fuse.Dirent, 0), ParseError(err)
-	}
-
-	inodes := make([]uint64, 0, len(children))
-	dirents := make([]fuse.Dirent, 0, len(children))
+	dirents := make([]fuse.Dirent, 0)
 
 	var dcache *DentryCache
 	if !d.super.disableDcache {
 		dcache = NewDentryCache()
 	}
 
-	for _, child := range children {
-		dentry := fuse.Dirent{
-			Inode: child.Inode,
-			Type:  ParseType(child.Type),
-			Name:  child.Name,
+	// read the dentries page by page, so that a large directory is not replied in one packet
+	var from string
+	for {
+		var children []proto.Dentry
+		children, err = d.super.mw.ReadDirLimit_ll(d.info.Inode, from, proto.ReadDirPageSize)
+		if err != nil {
+			log.LogErrorf("Readdir: ino(%v) from(%v) err(%v)", d.info.Inode, from, err)
+			return make([]fuse.Dirent, 0), ParseError(err)
+		}
+		more := len(children) == proto.ReadDirPageSize
+		// the first dentry of a page is the last one of the previous page
+		if from != "" && len(children) > 0 && children[0].Name == from {
+			children = children[1:]
 		}
-		inodes = append(inodes, child.Inode)
-		dirents = append(dirents, dentry)
-		dcache.Put(child.Name, child.Inode)
-	}
 
-	infos := d.super.mw.BatchInodeGet(inodes)
-	for _, info := range infos {
-		d.super.ic.Put(info)
+		inodes := make([]uint64, 0, len(children))
+		for _, child := range children {
+			dentry := fuse.Dirent{
+				Inode: child.Inode,
+				Type:  ParseType(child.Type),
+				Name:  child.Name,
+			}
+			inodes = append(inodes, child.Inode)
+			dirents = append(dirents, dentry)
+			dcache.Put(child.Name, child.Inode)
+		}
+
+		infos := d.super.mw.BatchInodeGet(inodes)
+		for _, info := range infos {
+			d.super.ic.Put(info)
+		}
+		if !more || len(children) == 0 {
+			break
+		}
+		from = children[len(children)-1].Name
 	}
 	d.dcache = dcache
 
@@ -440,9 +464,45 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 	return newFile, nil
 }
 
-// Getxattr has not been implemented yet.
+// Getxattr returns the virtual extended attributes of the recursive statistics of the directory.
 func (d *Dir) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
-	return fuse.ENOSYS
+	ino := d.info.Inode
+	name := req.Name
+	switch name {
+	case proto.XAttrKeyRBytes, proto.XAttrKeyRFiles, proto.XAttrKeyRSubdirs:
+	default:
+		return fuse.ErrNoXattr
+	}
+	stat, err := d.super.mw.GetDirStat_ll(ino)
+	if err == syscall.ENODATA {
+		// the directory is created before the statistics are counted
+		return fuse.ErrNoXattr
+	}
+	if err != nil {
+		log.LogErrorf("Getxattr: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	var value []byte
+	switch name {
+	case proto.XAttrKeyRBytes:
+		value = []byte(strconv.FormatInt(stat.Bytes, 10))
+	case proto.XAttrKeyRFiles:
+		value = []byte(strconv.FormatInt(stat.Files, 10))
+	case proto.XAttrKeyRSubdirs:
+		value = []byte(strconv.FormatInt(stat.Subdirs, 10))
+	}
+	if pos := req.Position; pos > 0 {
+		if pos > uint32(len(value)) {
+			pos = uint32(len(value))
+		}
+		value = value[pos:]
+	}
+	if size := req.Size; size > 0 && size < uint32(len(value)) {
+		value = value[:size]
+	}
+	resp.Xattr = value
+	log.LogDebugf("TRACE Getxattr: ino(%v) name(%v) stat(%v)", ino, name, stat)
+	return nil
 }
 
 // Listxattr has not been implemented yet.
@@ -35,6 +35,8 @@ type File struct {
 	super *Super
 	info  *proto.InodeInfo
 	sync.RWMutex
+
+	lockOwners map[uint64]struct{} // owners which have acquired the POSIX locks of the file
 }
 
 // Functions that File needs to implement
@@ -47,6 +49,7 @@ var (
 	_ fs.HandleReader      = (*File)(nil)
 	_ fs.HandleWriter      = (*File)(nil)
 	_ fs.HandleFlusher     = (*File)(nil)
+	_ fs.HandleLocker      = (*File)(nil)
 	_ fs.NodeFsyncer       = (*File)(nil)
 	_ fs.NodeSetattrer     = (*File)(nil)
 	_ fs.NodeReadlinker    = (*File)(nil)
@@ -120,6 +123,14 @@ func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenR
 	ino := f.info.Inode
 	start := time.Now()
 
+	// a locked file can not be modified by any client
+	if req.Flags.IsWriteOnly() || req.Flags.IsReadWrite() || req.Flags&fuse.OpenTruncate != 0 {
+		if err = f.super.mw.CheckObjectLock(ino); err != nil {
+			log.LogErrorf("Open: check object lock ino(%v) flags(%v) err(%v)", ino, req.Flags, err)
+			return nil, ParseError(err)
+		}
+	}
+
 	f.super.ec.OpenStream(ino)
 
 	f.super.ec.RefreshExtentsCache(ino)
@@ -142,10 +153,14 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 
 	//log.LogDebugf("TRACE Release close stream: ino(%v) req(%v)", ino, req)
 
+	if f.super.enableLock && req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		f.releaseLocks(req.LockOwner, true)
+	}
+
 	err = f.super.ec.CloseStream(ino)
 	if err != nil {
 		log.LogErrorf("Release: close writer failed, ino(%v) req(%v) err(%v)", ino, req, err)
-		return fuse.EIO
+		return ParseIOError(err)
 	}
 
 	f.super.ic.Delete(ino)
@@ -238,7 +253,7 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	if err != nil {
 		msg := fmt.Sprintf("Write: ino(%v) offset(%v) len(%v) err(%v)", ino, req.Offset, reqlen, err)
 		f.super.handleError("Write", msg)
-		return fuse.EIO
+		return ParseIOError(err)
 	}
 
 	resp.Size = size
@@ -250,7 +265,7 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 		if err = f.super.ec.Flush(ino); err != nil {
 			msg := fmt.Sprintf("Write: failed to wait for flush, ino(%v) offset(%v) len(%v) err(%v) req(%v)", ino, req.Offset, reqlen, err, req)
 			f.super.handleError("Wrtie", msg)
-			return fuse.EIO
+			return ParseIOError(err)
 		}
 	}
 
@@ -260,9 +275,20 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	return nil
 }
 
-// Flush only when fsyncOnClose is enabled.
+// Flush only when fsyncOnClose is enabled, and releases the POSIX locks of the owner which
+// closes the file.
 func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
+	if f.super.enableLock && f.hasLockOwner(req.LockOwner) {
+		if err = f.releaseLocks(req.LockOwner, false); err != nil {
+			return
+		}
+		f.removeLockOwner(req.LockOwner)
+	}
 	if !f.super.fsyncOnClose {
+		if f.super.enableLock {
+			// the kernel stops sending flushes on ENOSYS, which release the POSIX locks on close
+			return nil
+		}
 		return fuse.ENOSYS
 	}
 	log.LogDebugf("TRACE Flush enter: ino(%v)", f.info.Inode)
@@ -277,7 +303,7 @@ func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 	if err != nil {
 		msg := fmt.Sprintf("Flush: ino(%v) err(%v)", f.info.Inode, err)
 		f.super.handleError("Flush", msg)
-		return fuse.EIO
+		return ParseIOError(err)
 	}
 	f.super.ic.Delete(f.info.Inode)
 	elapsed := time.Since(start)
@@ -293,7 +319,7 @@ func (f *File) Fsync(ctx context.Context, req *fuse.FsyncRequest) (err error) {
 	if err != nil {
 		msg := fmt.Sprintf("Fsync: ino(%v) err(%v)", f.info.Inode, err)
 		f.super.handleError("Fsync", msg)
-		return fuse.EIO
+		return ParseIOError(err)
 	}
 	f.super.ic.Delete(f.info.Inode)
 	elapsed := time.Since(start)
@@ -306,6 +332,10 @@ func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 	ino := f.info.Inode
 	start := time.Now()
 	if req.Valid.Size() {
+		if err := f.super.mw.CheckObjectLock(ino); err != nil {
+			log.LogErrorf("Setattr: check object lock ino(%v) size(%v) err(%v)", ino, req.Size, err)
+			return ParseError(err)
+		}
 		if err := f.super.ec.Flush(ino); err != nil {
 			log.LogErrorf("Setattr: truncate wait for flush ino(%v) size(%v) err(%v)", ino, req.Size, err)
 			return ParseError(err)
@@ -361,7 +391,9 @@ func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string,
 // Getxattr has not been implemented yet.
 func (f *File) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
 	if !f.super.enableXattr {
-		return fuse.ENOSYS
+		// ENOSYS makes the kernel stop sending getxattr for the whole mount, including the
+		// virtual extended attributes of the directories.
+		return fuse.ErrNoXattr
 	}
 	ino := f.info.Inode
 	name := req.Name
@@ -413,6 +445,11 @@ func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
 	ino := f.info.Inode
 	name := req.Name
 	value := req.Xattr
+	// the quota IDs are managed by cfs-cli, and the object lock is managed by the ObjectNode,
+	// so they can not be changed by the users.
+	if isReservedXAttr(name) {
+		return fuse.EPERM
+	}
 	// TODO： implement flag to improve compatible (Mofei Zhang)
 	if err := f.super.mw.XAttrSet_ll(ino, []byte(name), []byte(value)); err != nil {
 		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, name, err)
@@ -429,6 +466,9 @@ func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) er
 	}
 	ino := f.info.Inode
 	name := req.Name
+	if isReservedXAttr(name) {
+		return fuse.EPERM
+	}
 	if err := f.super.mw.XAttrDel_ll(ino, name); err != nil {
 		log.LogErrorf("Removexattr: ino(%v) name(%v) err(%v)", ino, name, err)
 		return ParseError(err)
@@ -449,3 +489,12 @@ func (f *File) fileSize(ino uint64) (size int, gen uint64) {
 	}
 	return
 }
+
+// isReservedXAttr returns whether the extended attribute is managed by the system.
+func isReservedXAttr(name string) bool {
+	switch name {
+	case proto.XAttrKeyQuota, proto.XAttrKeyOSSRetention, proto.XAttrKeyOSSLegalHold:
+		return true
+	}
+	return false
+}
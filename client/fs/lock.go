@@ -0,0 +1,174 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"syscall"
+	"time"
+
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	lockRetryMinInterval = 10 * time.Millisecond
+	lockRetryMaxInterval = time.Second
+)
+
+// SetLock handles the POSIX and flock lock requests. The locks are held by the meta partition of
+// the inode, so they are shared by all the clients. A blocking request polls the meta partition
+// until the lock is acquired or the request is interrupted.
+func (f *File) SetLock(ctx context.Context, req *fuse.LockRequest) (err error) {
+	ino := f.info.Inode
+	lock, err := newLockInfo(ino, req.LockOwner, req.Lock, req.LockFlags)
+	if err != nil {
+		log.LogErrorf("SetLock: ino(%v) req(%v) err(%v)", ino, req, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE SetLock enter: ino(%v) req(%v)", ino, req)
+	start := time.Now()
+
+	interval := lockRetryMinInterval
+	for {
+		err = f.super.mw.SetLock(lock)
+		if err != syscall.EAGAIN || !req.Wait {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			log.LogDebugf("SetLock: interrupted, ino(%v) req(%v)", ino, req)
+			return fuse.EINTR
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > lockRetryMaxInterval {
+			interval = lockRetryMaxInterval
+		}
+	}
+	if err != nil {
+		if err != syscall.EAGAIN {
+			log.LogErrorf("SetLock: ino(%v) req(%v) err(%v)", ino, req, err)
+		}
+		return ParseError(err)
+	}
+	if !lock.Flock && lock.Type != proto.LockTypeUnlock {
+		f.addLockOwner(lock.Owner)
+	}
+
+	elapsed := time.Since(start)
+	log.LogDebugf("TRACE SetLock: ino(%v) req(%v) (%v)ns", ino, req, elapsed.Nanoseconds())
+	return nil
+}
+
+// QueryLock handles the getlk request, and replies a lock held by the other owners which
+// conflicts with the lock in the request.
+func (f *File) QueryLock(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) (err error) {
+	ino := f.info.Inode
+	lock, err := newLockInfo(ino, req.LockOwner, req.Lock, req.LockFlags)
+	if err != nil {
+		log.LogErrorf("QueryLock: ino(%v) req(%v) err(%v)", ino, req, err)
+		return ParseError(err)
+	}
+	conflict, err := f.super.mw.GetLock(lock)
+	if err != nil {
+		log.LogErrorf("QueryLock: ino(%v) req(%v) err(%v)", ino, req, err)
+		return ParseError(err)
+	}
+	if conflict != nil {
+		resp.Lock = fuse.FileLock{
+			Start: conflict.Start,
+			End:   conflict.End,
+			Type:  fuse.LockRead,
+			PID:   int32(conflict.Pid),
+		}
+		if conflict.Type == proto.LockTypeWrite {
+			resp.Lock.Type = fuse.LockWrite
+		}
+	}
+	log.LogDebugf("TRACE QueryLock: ino(%v) req(%v) resp(%v)", ino, req, resp)
+	return nil
+}
+
+// addLockOwner records the owner of the POSIX locks, whose locks are released when the owner
+// closes the file.
+func (f *File) addLockOwner(owner uint64) {
+	f.Lock()
+	if f.lockOwners == nil {
+		f.lockOwners = make(map[uint64]struct{})
+	}
+	f.lockOwners[owner] = struct{}{}
+	f.Unlock()
+}
+
+func (f *File) hasLockOwner(owner uint64) bool {
+	f.RLock()
+	defer f.RUnlock()
+	_, ok := f.lockOwners[owner]
+	return ok
+}
+
+func (f *File) removeLockOwner(owner uint64) {
+	f.Lock()
+	delete(f.lockOwners, owner)
+	f.Unlock()
+}
+
+// releaseLocks releases the POSIX or the flock locks of the owner, as the kernel does not send
+// the unlock requests when the file is closed.
+func (f *File) releaseLocks(owner uint64, flock bool) error {
+	lock := &proto.LockInfo{
+		Inode: f.info.Inode,
+		Owner: owner,
+		Flock: flock,
+		Start: 0,
+		End:   proto.LockRangeMax,
+		Type:  proto.LockTypeUnlock,
+	}
+	if err := f.super.mw.SetLock(lock); err != nil {
+		log.LogErrorf("releaseLocks: ino(%v) owner(%v) flock(%v) err(%v)", f.info.Inode, owner, flock, err)
+		return ParseError(err)
+	}
+	return nil
+}
+
+func newLockInfo(ino, owner uint64, fl fuse.FileLock, flags fuse.LockFlags) (*proto.LockInfo, error) {
+	lock := &proto.LockInfo{
+		Inode: ino,
+		Owner: owner,
+		Flock: flags&fuse.LockFlock != 0,
+		Start: fl.Start,
+		End:   fl.End,
+		Pid:   uint32(fl.PID),
+	}
+	switch fl.Type {
+	case fuse.LockRead:
+		lock.Type = proto.LockTypeRead
+	case fuse.LockWrite:
+		lock.Type = proto.LockTypeWrite
+	case fuse.LockUnlock:
+		lock.Type = proto.LockTypeUnlock
+	default:
+		return nil, syscall.EINVAL
+	}
+	if lock.End > proto.LockRangeMax {
+		lock.End = proto.LockRangeMax
+	}
+	if lock.Start > lock.End {
+		return nil, syscall.EINVAL
+	}
+	return lock, nil
+}
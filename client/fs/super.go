@@ -17,6 +17,7 @@ package fs
 import (
 	"fmt"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -53,7 +54,9 @@ type Super struct {
 	disableDcache bool
 	fsyncOnClose  bool
 	enableXattr   bool
+	enableLock    bool
 	rootIno       uint64
+	rootPath      string
 }
 
 // Functions that Super needs to implement
@@ -73,6 +76,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		Authenticate:  opt.Authenticate,
 		TicketMess:    opt.TicketMess,
 		ValidateOwner: opt.Authenticate || opt.AccessKey == "",
+		Snapshot:      opt.Snapshot,
 	}
 	s.mw, err = meta.NewMetaWrapper(metaConfig)
 	if err != nil {
@@ -102,6 +106,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	s.disableDcache = opt.DisableDcache
 	s.fsyncOnClose = opt.FsyncOnClose
 	s.enableXattr = opt.EnableXattr
+	s.enableLock = opt.EnableLock
 
 	var extentConfig = &stream.ExtentConfig{
 		Volume:            opt.Volname,
@@ -114,6 +119,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		OnGetExtents:      s.mw.GetExtents,
 		OnTruncate:        s.mw.Truncate,
 		OnEvictIcache:     s.ic.Delete,
+		OnCheckOverwrite:  s.mw.CheckObjectLock,
 	}
 	s.ec, err = stream.NewExtentClient(extentConfig)
 	if err != nil {
@@ -123,6 +129,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	if s.rootIno, err = s.mw.GetRootIno(opt.SubDir); err != nil {
 		return nil, err
 	}
+	s.rootPath = path.Join("/", opt.SubDir)
 
 	log.LogInfof("NewSuper: cluster(%v) volname(%v) icacheExpiration(%v) LookupValidDuration(%v) AttrValidDuration(%v)", s.cluster, s.volname, inodeExpiration, LookupValidDuration, AttrValidDuration)
 	return s, nil
@@ -134,7 +141,7 @@ func (s *Super) Root() (fs.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	root := NewDir(s, inode)
+	root := NewDir(s, inode, s.rootPath)
 	return root, nil
 }
 
@@ -154,6 +154,8 @@ type file struct {
 type dirStream struct {
 	pos     int
 	dirents []proto.Dentry
+	marker  string // name of the last dentry read from the meta partition
+	eof     bool
 }
 
 type client struct {
@@ -543,15 +545,35 @@ func cfs_readdir(id C.int64_t, fd C.int, dirents []C.struct_cfs_dirent, count C.
 
 	if f.dirp == nil {
 		f.dirp = &dirStream{}
-		dentries, err := c.mw.ReadDir_ll(f.ino)
-		if err != nil {
-			return errorToStatus(err)
-		}
-		f.dirp.dirents = dentries
 	}
 
 	dirp := f.dirp
-	for dirp.pos < len(dirp.dirents) && n < count {
+	for n < count {
+		if dirp.pos >= len(dirp.dirents) {
+			if dirp.eof {
+				break
+			}
+			// read the next page of the directory
+			dentries, err := c.mw.ReadDirLimit_ll(f.ino, dirp.marker, proto.ReadDirPageSize)
+			if err != nil {
+				if n > 0 {
+					break
+				}
+				return errorToStatus(err)
+			}
+			dirp.eof = len(dentries) < proto.ReadDirPageSize
+			if dirp.marker != "" && len(dentries) > 0 && dentries[0].Name == dirp.marker {
+				dentries = dentries[1:]
+			}
+			if len(dentries) == 0 {
+				dirp.eof = true
+				break
+			}
+			dirp.dirents = dentries
+			dirp.pos = 0
+			dirp.marker = dentries[len(dentries)-1].Name
+		}
+
 		// fill up ino
 		dirents[n].ino = C.uint64_t(dirp.dirents[dirp.pos].Inode)
 
@@ -633,7 +655,7 @@ func cfs_rmdir(id C.int64_t, path *C.char) C.int {
 		return errorToStatus(err)
 	}
 
-	_, err = c.mw.Delete_ll(dirInfo.Inode, name, true)
+	_, err = c.delete(dirpath, dirInfo.Inode, name, true)
 	return errorToStatus(err)
 }
 
@@ -656,12 +678,14 @@ func cfs_unlink(id C.int64_t, path *C.char) C.int {
 		return statusEISDIR
 	}
 
-	info, err := c.mw.Delete_ll(dirInfo.Inode, name, false)
+	info, err := c.delete(dirpath, dirInfo.Inode, name, false)
 	if err != nil {
 		return errorToStatus(err)
 	}
 
-	_ = c.mw.Evict(info.Inode)
+	if info != nil {
+		_ = c.mw.Evict(info.Inode)
+	}
 	return 0
 }
 
@@ -748,6 +772,7 @@ func (c *client) start() (err error) {
 		OnAppendExtentKey: mw.AppendExtentKey,
 		OnGetExtents:      mw.GetExtents,
 		OnTruncate:        mw.Truncate,
+		OnCheckOverwrite:  mw.CheckObjectLock,
 	}); err != nil {
 		return
 	}
@@ -801,6 +826,15 @@ func (c *client) lookupPath(path string) (*proto.InodeInfo, error) {
 	return info, nil
 }
 
+// delete deletes the entry, or moves it into the trash if the trash is enabled and the parent
+// directory is not in the trash.
+func (c *client) delete(dirpath string, parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
+	if proto.IsTrashPath(gopath.Clean(dirpath)) {
+		return c.mw.DeletePermanently_ll(parentID, name, isDir)
+	}
+	return c.mw.Delete_ll(parentID, name, isDir)
+}
+
 func (c *client) setattr(info *proto.InodeInfo, valid uint32, mode, uid, gid uint32, atime, mtime int64) error {
 	// Only rwx mode bit can be set
 	if valid&proto.AttrMode != 0 {
@@ -120,6 +120,25 @@ func (m *Server) setupAutoAllocation(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set DisableAutoAllocate to %v successfully", status)))
 }
 
+// Set the cluster-wide settings which block the public access to the buckets of ObjectNode. The
+// settings apply to all buckets in addition to the settings of each bucket, and the settings
+// which are not specified are turned off.
+func (m *Server) setPublicAccessBlock(w http.ResponseWriter, r *http.Request) {
+	var (
+		block *proto.PublicAccessBlock
+		err   error
+	)
+	if block, err = parseRequestToSetPublicAccessBlock(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setPublicAccessBlock(block); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set public access block to %v successfully", *block)))
+}
+
 // View the topology of the cluster.
 func (m *Server) getTopology(w http.ResponseWriter, r *http.Request) {
 	tv := &TopologyView{
@@ -215,6 +234,7 @@ func (m *Server) getCluster(w http.ResponseWriter, r *http.Request) {
 		VolStatInfo:         make([]*proto.VolStatInfo, 0),
 		BadPartitionIDs:     make([]proto.BadPartitionView, 0),
 		BadMetaPartitionIDs: make([]proto.BadPartitionView, 0),
+		PublicAccessBlock:   m.cluster.PublicAccessBlock,
 	}
 
 	vols := m.cluster.allVolNames()
@@ -248,6 +268,7 @@ func (m *Server) getIPAddr(w http.ResponseWriter, r *http.Request) {
 		MetaNodeDeleteWorkerSleepMs: deleteSleepMs,
 		DataNodeDeleteLimitRate:     limitRate,
 		DataNodeAutoRepairLimitRate: autoRepairRate,
+		PublicAccessBlock:           m.cluster.PublicAccessBlock,
 		Ip:                          strings.Split(r.RemoteAddr, ":")[0],
 	}
 	sendOkReply(w, r, newSuccessHTTPReply(cInfo))
@@ -581,6 +602,7 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 		description    string
 		dpSelectorName string
 		dpSelectorParm string
+		trashInterval  uint32
 		vol            *Vol
 	)
 
@@ -607,6 +629,17 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if trashInterval, err = parseTrashIntervalToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if capacity > vol.Capacity {
+		if err = m.checkUserQuota(vol.Owner, capacity-vol.Capacity, 0, 0); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+	}
 
 	newArgs := getVolVarargs(vol)
 
@@ -617,6 +650,7 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.authenticate = authenticate
 	newArgs.dpSelectorName = dpSelectorName
 	newArgs.dpSelectorParm = dpSelectorParm
+	newArgs.trashInterval = trashInterval
 
 	if err = m.cluster.updateVol(name, authKey, newArgs); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
@@ -626,6 +660,154 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+// setVolQoS sets the limits on the requests which ObjectNode serves to the volume. The limits
+// which are not specified or zero are removed.
+func (m *Server) setVolQoS(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		qos     *proto.QoSLimit
+		err     error
+	)
+	if name, authKey, qos, err = parseRequestToSetVolQoS(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setVolQoS(name, authKey, qos); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("set QoS of vol[%v] successfully", name)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// setQuota sets the quota on the bytes and the inode count of the directory, and replies the quota
+// with its ID. The limits which are not specified or zero are unlimited.
+func (m *Server) setQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		authKey  string
+		inode    uint64
+		path     string
+		maxBytes uint64
+		maxFiles uint64
+		quota    *proto.QuotaInfo
+		err      error
+	)
+	if name, authKey, inode, path, maxBytes, maxFiles, err = parseRequestToSetQuota(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if quota, err = m.cluster.setQuota(name, authKey, inode, path, maxBytes, maxFiles); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(quota))
+}
+
+func (m *Server) deleteQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		quotaId uint64
+		err     error
+	)
+	if name, authKey, quotaId, err = parseRequestToDeleteQuota(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.deleteQuota(name, authKey, quotaId); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("delete quota[%v] of vol[%v] successfully", quotaId, name)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// listQuota replies the quotas of the volume with their usage.
+func (m *Server) listQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	quotas := vol.getQuotasWithUsage()
+	if quotas == nil {
+		quotas = make([]*proto.QuotaInfo, 0)
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(quotas))
+}
+
+// createVolSnapshot creates a read-only point-in-time snapshot of the volume, and replies the
+// snapshot with its ID.
+func (m *Server) createVolSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		name         string
+		authKey      string
+		snapshotName string
+		snapshot     *proto.VolSnapshotInfo
+		err          error
+	)
+	if name, authKey, snapshotName, err = parseRequestToCreateVolSnapshot(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if snapshot, err = m.cluster.createVolSnapshot(name, authKey, snapshotName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(snapshot))
+}
+
+func (m *Server) deleteVolSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		name       string
+		authKey    string
+		snapshotId uint64
+		err        error
+	)
+	if name, authKey, snapshotId, err = parseRequestToDeleteVolSnapshot(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.deleteVolSnapshot(name, authKey, snapshotId); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("delete snapshot[%v] of vol[%v] successfully", snapshotId, name)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// listVolSnapshot replies the snapshots of the volume.
+func (m *Server) listVolSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	snapshots := vol.getSnapshots()
+	if snapshots == nil {
+		snapshots = make([]*proto.VolSnapshotInfo, 0)
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(snapshots))
+}
+
 func (m *Server) volExpand(w http.ResponseWriter, r *http.Request) {
 	var (
 		name     string
@@ -648,6 +830,10 @@ func (m *Server) volExpand(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if err = m.checkUserQuota(vol.Owner, uint64(capacity)-vol.Capacity, 0, 0); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
 
 	newArgs := getVolVarargs(vol)
 	newArgs.capacity = uint64(capacity)
@@ -721,6 +907,10 @@ func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if err = m.checkUserQuota(owner, uint64(capacity), 0, 1); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
 	if vol, err = m.cluster.createVol(name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -786,6 +976,8 @@ func newSimpleView(vol *Vol) *proto.SimpleVolView {
 		Description:        vol.description,
 		DpSelectorName:     vol.dpSelectorName,
 		DpSelectorParm:     vol.dpSelectorParm,
+		QoS:                vol.qos,
+		TrashInterval:      vol.trashInterval,
 	}
 }
 
@@ -1448,6 +1640,206 @@ func parseBoolFieldToUpdateVol(r *http.Request, vol *Vol) (followerRead, authent
 	return
 }
 
+// parseTrashIntervalToUpdateVol parses the minutes the deleted files stay in the trash, 0 disables the trash.
+func parseTrashIntervalToUpdateVol(r *http.Request, vol *Vol) (trashInterval uint32, err error) {
+	var trashIntervalStr string
+	if trashIntervalStr = r.FormValue(trashIntervalKey); trashIntervalStr == "" {
+		trashInterval = vol.trashInterval
+		return
+	}
+	var value uint64
+	if value, err = strconv.ParseUint(trashIntervalStr, 10, 32); err != nil {
+		err = unmatchedKey(trashIntervalKey)
+		return
+	}
+	trashInterval = uint32(value)
+	return
+}
+
+// parseRequestToSetVolQoS parses the QoS limits, in which the QPS limits by action are in the
+// form of "ListObjects:100,GetObject:1000".
+func parseRequestToSetVolQoS(r *http.Request) (name, authKey string, qos *proto.QoSLimit, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	qos = &proto.QoSLimit{}
+	var parseUint = func(key string, value *uint64) error {
+		if str := r.FormValue(key); str != "" {
+			var parsed uint64
+			if parsed, err = strconv.ParseUint(str, 10, 64); err != nil {
+				return unmatchedKey(key)
+			}
+			*value = parsed
+		}
+		return nil
+	}
+	if err = parseUint(qpsKey, &qos.QPS); err != nil {
+		return
+	}
+	if err = parseUint(readBandwidthKey, &qos.ReadBandwidth); err != nil {
+		return
+	}
+	if err = parseUint(writeBandwidthKey, &qos.WriteBandwidth); err != nil {
+		return
+	}
+	if actionQPS := r.FormValue(actionQPSKey); actionQPS != "" {
+		qos.ActionQPS = make(map[string]uint64)
+		for _, item := range strings.Split(actionQPS, ",") {
+			var parts = strings.Split(item, ":")
+			if len(parts) != 2 {
+				err = unmatchedKey(actionQPSKey)
+				return
+			}
+			var qps uint64
+			if qps, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+				err = unmatchedKey(actionQPSKey)
+				return
+			}
+			qos.ActionQPS[strings.TrimSpace(parts[0])] = qps
+		}
+	}
+	if err = qos.Validate(); err != nil {
+		return
+	}
+	return
+}
+
+func parseRequestToSetPublicAccessBlock(r *http.Request) (block *proto.PublicAccessBlock, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	block = &proto.PublicAccessBlock{}
+	var parseBool = func(key string, value *bool) error {
+		if str := r.FormValue(key); str != "" {
+			var parsed bool
+			if parsed, err = strconv.ParseBool(str); err != nil {
+				return unmatchedKey(key)
+			}
+			*value = parsed
+		}
+		return nil
+	}
+	if err = parseBool(blockPublicAclsKey, &block.BlockPublicAcls); err != nil {
+		return
+	}
+	if err = parseBool(ignorePublicAclsKey, &block.IgnorePublicAcls); err != nil {
+		return
+	}
+	if err = parseBool(blockPublicPolicyKey, &block.BlockPublicPolicy); err != nil {
+		return
+	}
+	if err = parseBool(restrictPublicBucketsKey, &block.RestrictPublicBuckets); err != nil {
+		return
+	}
+	return
+}
+
+func parseRequestToSetQuota(r *http.Request) (name, authKey string, inode uint64, path string, maxBytes, maxFiles uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	var value string
+	if value = r.FormValue(inodeKey); value == "" {
+		err = keyNotFound(inodeKey)
+		return
+	}
+	if inode, err = strconv.ParseUint(value, 10, 64); err != nil {
+		err = unmatchedKey(inodeKey)
+		return
+	}
+	path = r.FormValue(pathKey)
+	var parseUint = func(key string, value *uint64) error {
+		if str := r.FormValue(key); str != "" {
+			var parsed uint64
+			if parsed, err = strconv.ParseUint(str, 10, 64); err != nil {
+				return unmatchedKey(key)
+			}
+			*value = parsed
+		}
+		return nil
+	}
+	if err = parseUint(maxBytesKey, &maxBytes); err != nil {
+		return
+	}
+	if err = parseUint(maxFilesKey, &maxFiles); err != nil {
+		return
+	}
+	return
+}
+
+func parseRequestToDeleteQuota(r *http.Request) (name, authKey string, quotaId uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	var value string
+	if value = r.FormValue(idKey); value == "" {
+		err = keyNotFound(idKey)
+		return
+	}
+	if quotaId, err = strconv.ParseUint(value, 10, 64); err != nil {
+		err = unmatchedKey(idKey)
+		return
+	}
+	return
+}
+
+func parseRequestToCreateVolSnapshot(r *http.Request) (name, authKey, snapshotName string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	if snapshotName = r.FormValue(snapshotNameKey); snapshotName == "" {
+		err = keyNotFound(snapshotNameKey)
+		return
+	}
+	return
+}
+
+func parseRequestToDeleteVolSnapshot(r *http.Request) (name, authKey string, snapshotId uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	var value string
+	if value = r.FormValue(idKey); value == "" {
+		err = keyNotFound(idKey)
+		return
+	}
+	if snapshotId, err = strconv.ParseUint(value, 10, 64); err != nil {
+		err = unmatchedKey(idKey)
+		return
+	}
+	return
+}
+
 func parseRequestToSetVolCapacity(r *http.Request) (name, authKey string, capacity int, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
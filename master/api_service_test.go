@@ -213,6 +213,23 @@ func TestSetDisableAutoAlloc(t *testing.T) {
 	server.cluster.DisableAutoAllocate = false
 }
 
+func TestSetPublicAccessBlock(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?blockPublicAcls=true&restrictPublicBuckets=true", hostAddr, proto.AdminSetPublicAccessBlock)
+	process(reqURL, t)
+	block := server.cluster.PublicAccessBlock
+	if block == nil || !block.BlockPublicAcls || block.IgnorePublicAcls || block.BlockPublicPolicy || !block.RestrictPublicBuckets {
+		t.Errorf("expect public access block is set, but is %v", block)
+		return
+	}
+
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminSetPublicAccessBlock)
+	process(reqURL, t)
+	if server.cluster.PublicAccessBlock != nil {
+		t.Errorf("expect public access block is removed, but is %v", server.cluster.PublicAccessBlock)
+		return
+	}
+}
+
 func TestGetCluster(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.AdminGetCluster)
 	fmt.Println(reqURL)
@@ -338,6 +355,142 @@ func TestUpdateVol(t *testing.T) {
 
 }
 
+func TestSetVolQoS(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&qps=100&writeBandwidth=1048576&actionQPS=ListObjects:10,GetObject:50",
+		hostAddr, proto.AdminSetVolQoS, commonVol.Name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if vol.qos == nil || vol.qos.QPS != 100 || vol.qos.WriteBandwidth != 1048576 || vol.qos.ActionQPS["ListObjects"] != 10 {
+		t.Errorf("expect qos is set, but is %v", vol.qos)
+		return
+	}
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminSetVolQoS, commonVol.Name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	if vol.qos != nil {
+		t.Errorf("expect qos is removed, but is %v", vol.qos)
+		return
+	}
+}
+
+func TestUpdateVolTrashInterval(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&trashInterval=1440",
+		hostAddr, proto.AdminUpdateVol, commonVol.Name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if vol.trashInterval != 1440 {
+		t.Errorf("expect trashInterval is 1440, but is %v", vol.trashInterval)
+		return
+	}
+
+	// the trash interval is kept if not specified
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminUpdateVol, commonVol.Name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	if vol.trashInterval != 1440 {
+		t.Errorf("expect trashInterval is 1440, but is %v", vol.trashInterval)
+		return
+	}
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&trashInterval=0",
+		hostAddr, proto.AdminUpdateVol, commonVol.Name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	if vol.trashInterval != 0 {
+		t.Errorf("expect trash is disabled, but trashInterval is %v", vol.trashInterval)
+		return
+	}
+}
+
+func TestSetQuota(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&inode=1&path=/&maxFiles=10",
+		hostAddr, proto.AdminSetQuota, commonVol.Name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	quotas := vol.getQuotas()
+	if len(quotas) != 1 || quotas[0].Inode != 1 || quotas[0].MaxFiles != 10 {
+		t.Errorf("expect quota is set, but is %v", quotas)
+		return
+	}
+	quotaId := quotas[0].QuotaId
+
+	// The usage reported by the leaders of all the meta partitions is summed up.
+	mps := vol.cloneMetaPartitionMap()
+	for _, mp := range mps {
+		mp.Lock()
+		mp.quotaUsages = []*proto.QuotaUsage{{QuotaId: quotaId, UsedFiles: 10, UsedBytes: 100}}
+		mp.Unlock()
+	}
+	quotas = vol.getQuotasWithUsage()
+	if quotas[0].UsedFiles != uint64(10*len(mps)) || quotas[0].UsedBytes != uint64(100*len(mps)) {
+		t.Errorf("expect usage is summed up, but is %v", *quotas[0])
+		return
+	}
+	infos := server.cluster.getQuotaHeartbeatInfos()[commonVolName]
+	if len(infos) != 1 || !infos[0].LimitedFiles || infos[0].LimitedBytes {
+		t.Errorf("expect files of quota are limited, but is %v", infos)
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminListQuota, commonVol.Name)
+	process(reqURL, t)
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&id=%v",
+		hostAddr, proto.AdminDeleteQuota, commonVol.Name, buildAuthKey("cfs"), quotaId)
+	process(reqURL, t)
+	if len(vol.getQuotas()) != 0 {
+		t.Errorf("expect quota is deleted, but is %v", vol.getQuotas())
+		return
+	}
+	for _, mp := range mps {
+		mp.Lock()
+		mp.quotaUsages = nil
+		mp.Unlock()
+	}
+}
+
+func TestVolSnapshot(t *testing.T) {
+	// the snapshot is frozen by the leaders of the meta partitions, which are reported by the heartbeats
+	server.cluster.checkMetaNodeHeartbeat()
+	time.Sleep(2 * time.Second)
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&snapshotName=snap1",
+		hostAddr, proto.AdminCreateVolSnapshot, commonVol.Name, buildAuthKey("cfs"))
+	process(reqURL, t)
+	vol, err := server.cluster.getVol(commonVolName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	snapshots := vol.getSnapshots()
+	if len(snapshots) != 1 || snapshots[0].Name != "snap1" || !snapshots[0].IsReady() {
+		t.Errorf("expect snapshot is ready, but is %v", snapshots)
+		return
+	}
+	if _, err = server.cluster.createVolSnapshot(commonVolName, buildAuthKey("cfs"), "snap1"); err != proto.ErrVolSnapshotExists {
+		t.Errorf("expect duplicated snapshot name is rejected, but err is %v", err)
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminListVolSnapshot, commonVol.Name)
+	process(reqURL, t)
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&authKey=%v&id=%v",
+		hostAddr, proto.AdminDeleteVolSnapshot, commonVol.Name, buildAuthKey("cfs"), snapshots[0].SnapshotId)
+	process(reqURL, t)
+	if len(vol.getSnapshots()) != 0 {
+		t.Errorf("expect snapshot is deleted, but is %v", vol.getSnapshots())
+		return
+	}
+}
+
 func setVolCapacity(capacity uint64, url string, t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?name=%v&capacity=%v&authKey=%v",
 		hostAddr, url, commonVol.Name, capacity, buildAuthKey("cfs"))
@@ -651,6 +804,64 @@ func TestGetAKInfo(t *testing.T) {
 	process(reqURL, t)
 }
 
+func TestUpdateUserQoS(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.UserUpdate)
+	param := &proto.UserUpdateParam{UserID: testUserID, QoS: &proto.QoSLimit{QPS: 100, ActionQPS: map[string]uint64{"ListObjects": 10}}}
+	data, err := json.Marshal(param)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	post(reqURL, data, t)
+	userInfo, err := server.user.getUserInfo(testUserID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if userInfo.QoS == nil || userInfo.QoS.QPS != 100 || userInfo.QoS.ActionQPS["ListObjects"] != 10 {
+		t.Errorf("expect qos is set, but is %v", userInfo.QoS)
+		return
+	}
+}
+
+func TestUpdateUserQuota(t *testing.T) {
+	userInfo, err := server.user.getUserInfo(testUserID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	usage := server.getUserUsage(userInfo)
+	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.UserUpdate)
+	quota := &proto.UserQuota{MaxCapacity: usage.Capacity + 100, MaxVolCount: usage.VolCount + 1}
+	data, err := json.Marshal(&proto.UserUpdateParam{UserID: testUserID, Quota: quota})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	post(reqURL, data, t)
+	if err = server.checkUserQuota(testUserID, 100, 0, 1); err != nil {
+		t.Errorf("expect quota is not exceeded, but is %v", err)
+		return
+	}
+	if err = server.checkUserQuota(testUserID, 101, 0, 1); err != proto.ErrUserQuotaExceeded {
+		t.Errorf("expect capacity quota is exceeded, but is %v", err)
+		return
+	}
+	if err = server.checkUserQuota(testUserID, 0, 0, 2); err != proto.ErrUserQuotaExceeded {
+		t.Errorf("expect vol count quota is exceeded, but is %v", err)
+		return
+	}
+	if data, err = json.Marshal(&proto.UserUpdateParam{UserID: testUserID, Quota: &proto.UserQuota{}}); err != nil {
+		t.Error(err)
+		return
+	}
+	post(reqURL, data, t)
+	if userInfo.Quota != nil {
+		t.Errorf("expect quota is removed, but is %v", userInfo.Quota)
+		return
+	}
+}
+
 func TestUpdatePolicy(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.UserUpdatePolicy)
 	param := &proto.UserPermUpdateParam{UserID: testUserID, Volume: commonVolName, Policy: []string{proto.BuiltinPermissionWritable.String()}}
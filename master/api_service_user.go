@@ -116,7 +116,59 @@ func (m *Server) getUserInfo(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
-	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
+	sendOkReply(w, r, newSuccessHTTPReply(&userInfoWithUsage{UserInfo: userInfo, Usage: m.getUserUsage(userInfo)}))
+}
+
+// userInfoWithUsage replies the user information with the usage, which is calculated on request
+// rather than kept in the user information.
+type userInfoWithUsage struct {
+	*proto.UserInfo
+	Usage *proto.UserUsage `json:"usage"`
+}
+
+// getUserUsage returns the total consumption of the volumes which the user owns.
+func (m *Server) getUserUsage(userInfo *proto.UserInfo) (usage *proto.UserUsage) {
+	usage = new(proto.UserUsage)
+	userInfo.Mu.RLock()
+	ownVols := append([]string(nil), userInfo.Policy.OwnVols...)
+	userInfo.Mu.RUnlock()
+	for _, volName := range ownVols {
+		vol, err := m.cluster.getVol(volName)
+		if err != nil {
+			continue
+		}
+		usage.VolCount++
+		usage.Capacity += vol.Capacity
+		usage.Inodes += vol.totalInodeCount()
+	}
+	return
+}
+
+// checkUserQuota returns ErrUserQuotaExceeded if the volumes which the user owns exceed the quota
+// of the user after the capacity, the inodes and the volumes are added to the user. The inodes are
+// created through the clients rather than allocated by the master, so the user can not create or
+// expand volumes either once the inodes reach the limit.
+func (m *Server) checkUserQuota(userID string, capacity, inodes, volCount uint64) (err error) {
+	var userInfo *proto.UserInfo
+	if userInfo, err = m.user.getUserInfo(userID); err != nil {
+		if err == proto.ErrUserNotExists {
+			return nil
+		}
+		return
+	}
+	quota := userInfo.Quota
+	if quota.IsEmpty() {
+		return
+	}
+	usage := m.getUserUsage(userInfo)
+	if (quota.MaxCapacity > 0 && usage.Capacity+capacity > quota.MaxCapacity) ||
+		(quota.MaxInodes > 0 && (usage.Inodes >= quota.MaxInodes || usage.Inodes+inodes > quota.MaxInodes)) ||
+		(quota.MaxVolCount > 0 && usage.VolCount+volCount > quota.MaxVolCount) {
+		log.LogWarnf("action[checkUserQuota] userID[%v] quota[%+v] usage[%+v] capacity[%v] inodes[%v] volCount[%v] exceeded",
+			userID, quota, usage, capacity, inodes, volCount)
+		return proto.ErrUserQuotaExceeded
+	}
+	return
 }
 
 func (m *Server) updateUserPolicy(w http.ResponseWriter, r *http.Request) {
@@ -213,6 +265,12 @@ func (m *Server) transferUserVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrHaveNoPolicy))
 		return
 	}
+	if vol.Owner != param.UserDst {
+		if err = m.checkUserQuota(param.UserDst, vol.Capacity, vol.totalInodeCount(), 1); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+	}
 	if userInfo, err = m.user.transferVol(&param); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -50,6 +50,7 @@ type Cluster struct {
 	BadDataPartitionIds       *sync.Map
 	BadMetaPartitionIds       *sync.Map
 	DisableAutoAllocate       bool
+	PublicAccessBlock         *proto.PublicAccessBlock // cluster-wide settings of ObjectNode
 	fsm                       *MetadataFsm
 	partition                 raftstore.Partition
 	MasterSecretKey           []byte
@@ -88,6 +89,7 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckMetaPartitionRecoveryProgress()
 	c.scheduleToLoadMetaPartitions()
 	c.scheduleToReduceReplicaNum()
+	c.scheduleToPurgeTrash()
 }
 
 func (c *Cluster) masterAddr() (addr string) {
@@ -260,10 +262,13 @@ func (c *Cluster) checkDataNodeHeartbeat() {
 
 func (c *Cluster) checkMetaNodeHeartbeat() {
 	tasks := make([]*proto.AdminTask, 0)
+	// read the allocated ID before the quotas, so that a quota created in between is not taken as deleted
+	quotaIdMark := atomic.LoadUint64(&c.idAlloc.commonID)
+	quotas := c.getQuotaHeartbeatInfos()
 	c.metaNodes.Range(func(addr, metaNode interface{}) bool {
 		node := metaNode.(*MetaNode)
 		node.checkHeartbeat()
-		task := node.createHeartbeatTask(c.masterAddr())
+		task := node.createHeartbeatTask(c.masterAddr(), quotas, quotaIdMark)
 		tasks = append(tasks, task)
 		return true
 	})
@@ -1496,6 +1501,7 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 		oldDescription    string
 		oldDpSelectorName string
 		oldDpSelectorParm string
+		oldTrashInterval  uint32
 		volUsedSpace      uint64
 	)
 	if vol, err = c.getVol(name); err != nil {
@@ -1540,6 +1546,7 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 	oldDescription = vol.description
 	oldDpSelectorName = vol.dpSelectorName
 	oldDpSelectorParm = vol.dpSelectorParm
+	oldTrashInterval = vol.trashInterval
 
 	vol.zoneName = newArgs.zoneName
 	vol.Capacity = newArgs.capacity
@@ -1554,6 +1561,7 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 	}
 	vol.dpSelectorName = newArgs.dpSelectorName
 	vol.dpSelectorParm = newArgs.dpSelectorParm
+	vol.trashInterval = newArgs.trashInterval
 
 	if err = c.syncUpdateVol(vol); err != nil {
 		vol.Capacity = oldCapacity
@@ -1564,6 +1572,7 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 		vol.description = oldDescription
 		vol.dpSelectorName = oldDpSelectorName
 		vol.dpSelectorParm = oldDpSelectorParm
+		vol.trashInterval = oldTrashInterval
 
 		log.LogErrorf("action[updateVol] vol[%v] err[%v]", name, err)
 		err = proto.ErrPersistenceByRaft
@@ -1577,6 +1586,285 @@ errHandler:
 	return
 }
 
+// setVolQoS sets the limits on the requests which ObjectNode serves to the volume. An empty limit
+// removes the limits.
+func (c *Cluster) setVolQoS(name, authKey string, qos *proto.QoSLimit) (err error) {
+	var (
+		vol    *Vol
+		oldQoS *proto.QoSLimit
+	)
+	if vol, err = c.getVol(name); err != nil {
+		log.LogErrorf("action[setVolQoS] err[%v]", err)
+		return proto.ErrVolNotExists
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	if !matchKey(vol.Owner, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	if qos.IsEmpty() {
+		qos = nil
+	}
+	oldQoS = vol.qos
+	vol.qos = qos
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.qos = oldQoS
+		log.LogErrorf("action[setVolQoS] vol[%v] err[%v]", name, err)
+		return proto.ErrPersistenceByRaft
+	}
+	log.LogInfof("action[setVolQoS] vol[%v] qos[%v]", name, qos)
+	return
+}
+
+// setQuota sets the quota on the directory. The limits of the existing quota of the directory are
+// updated, otherwise a new quota is created with a new quota ID.
+func (c *Cluster) setQuota(name, authKey string, inode uint64, path string, maxBytes, maxFiles uint64) (quota *proto.QuotaInfo, err error) {
+	var (
+		vol       *Vol
+		oldQuotas map[uint64]*proto.QuotaInfo
+	)
+	if vol, err = c.getVol(name); err != nil {
+		log.LogErrorf("action[setQuota] err[%v]", err)
+		return nil, proto.ErrVolNotExists
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	if !matchKey(vol.Owner, authKey) {
+		return nil, proto.ErrVolAuthKeyNotMatch
+	}
+	quota = &proto.QuotaInfo{Inode: inode, Path: path, MaxBytes: maxBytes, MaxFiles: maxFiles}
+	oldQuotas = vol.quotas
+	quotas := make(map[uint64]*proto.QuotaInfo, len(oldQuotas)+1)
+	for id, existing := range oldQuotas {
+		if existing.Inode == inode {
+			quota.QuotaId = id
+			continue
+		}
+		quotas[id] = existing
+	}
+	if quota.QuotaId == 0 {
+		if quota.QuotaId, err = c.idAlloc.allocateCommonID(); err != nil {
+			log.LogErrorf("action[setQuota] vol[%v] err[%v]", name, err)
+			return nil, err
+		}
+	}
+	quotas[quota.QuotaId] = quota
+	vol.quotas = quotas
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.quotas = oldQuotas
+		log.LogErrorf("action[setQuota] vol[%v] err[%v]", name, err)
+		return nil, proto.ErrPersistenceByRaft
+	}
+	log.LogInfof("action[setQuota] vol[%v] quota[%v]", name, *quota)
+	return
+}
+
+// deleteQuota deletes the quota. The quota IDs of the inodes are left behind, and they are
+// ignored by the meta nodes since the quota does not exist.
+func (c *Cluster) deleteQuota(name, authKey string, quotaId uint64) (err error) {
+	var (
+		vol       *Vol
+		oldQuotas map[uint64]*proto.QuotaInfo
+	)
+	if vol, err = c.getVol(name); err != nil {
+		log.LogErrorf("action[deleteQuota] err[%v]", err)
+		return proto.ErrVolNotExists
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	if !matchKey(vol.Owner, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	oldQuotas = vol.quotas
+	if _, ok := oldQuotas[quotaId]; !ok {
+		return proto.ErrQuotaNotExists
+	}
+	quotas := make(map[uint64]*proto.QuotaInfo, len(oldQuotas))
+	for id, existing := range oldQuotas {
+		if id != quotaId {
+			quotas[id] = existing
+		}
+	}
+	vol.quotas = quotas
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.quotas = oldQuotas
+		log.LogErrorf("action[deleteQuota] vol[%v] err[%v]", name, err)
+		return proto.ErrPersistenceByRaft
+	}
+	log.LogInfof("action[deleteQuota] vol[%v] quotaId[%v]", name, quotaId)
+	return
+}
+
+// getQuotaHeartbeatInfos returns the quotas of the volumes, which are sent to the meta nodes with the heartbeat.
+func (c *Cluster) getQuotaHeartbeatInfos() (quotas map[string][]*proto.QuotaHeartbeatInfo) {
+	quotas = make(map[string][]*proto.QuotaHeartbeatInfo)
+	for name, vol := range c.allVols() {
+		if infos := vol.getQuotaHeartbeatInfos(); len(infos) > 0 {
+			quotas[name] = infos
+		}
+	}
+	return
+}
+
+// createVolSnapshot creates a read-only point-in-time snapshot of the volume. The snapshot is
+// recorded as creating before the leaders of the meta partitions freeze it, and marked as ready
+// after all of them succeed. If any of them fails, the snapshot is dropped by all of them.
+func (c *Cluster) createVolSnapshot(name, authKey, snapshotName string) (snapshot *proto.VolSnapshotInfo, err error) {
+	var vol *Vol
+	if vol, err = c.getVol(name); err != nil {
+		log.LogErrorf("action[createVolSnapshot] err[%v]", err)
+		return nil, proto.ErrVolNotExists
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return nil, proto.ErrVolAuthKeyNotMatch
+	}
+	snapshot = &proto.VolSnapshotInfo{
+		Name:       snapshotName,
+		CreateTime: time.Now().Unix(),
+		Status:     proto.VolSnapshotCreating,
+	}
+	if snapshot.SnapshotId, err = c.idAlloc.allocateCommonID(); err != nil {
+		log.LogErrorf("action[createVolSnapshot] vol[%v] err[%v]", name, err)
+		return nil, err
+	}
+	if err = c.putVolSnapshot(vol, snapshot, true); err != nil {
+		return nil, err
+	}
+	if err = c.syncSendVolSnapshotTasks(vol, proto.OpCreateMetaSnapshot, snapshot.SnapshotId); err != nil {
+		log.LogErrorf("action[createVolSnapshot] vol[%v] snapshot[%v] err[%v]", name, snapshot.SnapshotId, err)
+		if deleteErr := c.syncSendVolSnapshotTasks(vol, proto.OpDeleteMetaSnapshot, snapshot.SnapshotId); deleteErr == nil {
+			_ = c.removeVolSnapshot(vol, snapshot.SnapshotId)
+		}
+		return nil, err
+	}
+	ready := *snapshot
+	ready.Status = proto.VolSnapshotReady
+	if err = c.putVolSnapshot(vol, &ready, false); err != nil {
+		return nil, err
+	}
+	log.LogInfof("action[createVolSnapshot] vol[%v] snapshot[%v]", name, ready)
+	return &ready, nil
+}
+
+// deleteVolSnapshot drops the snapshot from the meta partitions and deletes its record, so that
+// the extents kept for the snapshot are deleted. Deleting a snapshot which failed to be created
+// is retried the same way.
+func (c *Cluster) deleteVolSnapshot(name, authKey string, snapshotId uint64) (err error) {
+	var vol *Vol
+	if vol, err = c.getVol(name); err != nil {
+		log.LogErrorf("action[deleteVolSnapshot] err[%v]", err)
+		return proto.ErrVolNotExists
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	vol.RLock()
+	_, ok := vol.snapshots[snapshotId]
+	vol.RUnlock()
+	if !ok {
+		return proto.ErrVolSnapshotNotExists
+	}
+	if err = c.syncSendVolSnapshotTasks(vol, proto.OpDeleteMetaSnapshot, snapshotId); err != nil {
+		log.LogErrorf("action[deleteVolSnapshot] vol[%v] snapshot[%v] err[%v]", name, snapshotId, err)
+		return
+	}
+	if err = c.removeVolSnapshot(vol, snapshotId); err != nil {
+		return
+	}
+	log.LogInfof("action[deleteVolSnapshot] vol[%v] snapshotId[%v]", name, snapshotId)
+	return
+}
+
+// putVolSnapshot adds or replaces the snapshot of the volume, and rejects the name of another
+// snapshot if checkName is set.
+func (c *Cluster) putVolSnapshot(vol *Vol, snapshot *proto.VolSnapshotInfo, checkName bool) (err error) {
+	vol.Lock()
+	defer vol.Unlock()
+	oldSnapshots := vol.snapshots
+	snapshots := make(map[uint64]*proto.VolSnapshotInfo, len(oldSnapshots)+1)
+	for id, existing := range oldSnapshots {
+		if checkName && existing.Name == snapshot.Name {
+			return proto.ErrVolSnapshotExists
+		}
+		snapshots[id] = existing
+	}
+	snapshots[snapshot.SnapshotId] = snapshot
+	vol.snapshots = snapshots
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.snapshots = oldSnapshots
+		log.LogErrorf("action[putVolSnapshot] vol[%v] err[%v]", vol.Name, err)
+		return proto.ErrPersistenceByRaft
+	}
+	return
+}
+
+func (c *Cluster) removeVolSnapshot(vol *Vol, snapshotId uint64) (err error) {
+	vol.Lock()
+	defer vol.Unlock()
+	oldSnapshots := vol.snapshots
+	snapshots := make(map[uint64]*proto.VolSnapshotInfo, len(oldSnapshots))
+	for id, existing := range oldSnapshots {
+		if id != snapshotId {
+			snapshots[id] = existing
+		}
+	}
+	vol.snapshots = snapshots
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.snapshots = oldSnapshots
+		log.LogErrorf("action[removeVolSnapshot] vol[%v] err[%v]", vol.Name, err)
+		return proto.ErrPersistenceByRaft
+	}
+	return
+}
+
+// syncSendVolSnapshotTasks sends the task to create or delete the snapshot to the leaders of all
+// the meta partitions of the volume, and waits for them to apply the task.
+func (c *Cluster) syncSendVolSnapshotTasks(vol *Vol, opCode uint8, snapshotId uint64) (err error) {
+	var wg sync.WaitGroup
+	partitions := vol.cloneMetaPartitionMap()
+	errChannel := make(chan error, len(partitions))
+	defer close(errChannel)
+	for _, mp := range partitions {
+		wg.Add(1)
+		go func(mp *MetaPartition) {
+			defer wg.Done()
+			if err1 := c.syncSendVolSnapshotTask(vol.Name, mp, opCode, snapshotId); err1 != nil {
+				errChannel <- fmt.Errorf("meta partition[%v]: %v", mp.PartitionID, err1)
+			}
+		}(mp)
+	}
+	wg.Wait()
+	select {
+	case err = <-errChannel:
+		return
+	default:
+	}
+	return
+}
+
+func (c *Cluster) syncSendVolSnapshotTask(volName string, mp *MetaPartition, opCode uint8, snapshotId uint64) (err error) {
+	mp.RLock()
+	mr, err := mp.getMetaReplicaLeader()
+	mp.RUnlock()
+	if err != nil {
+		return
+	}
+	var req interface{}
+	if opCode == proto.OpCreateMetaSnapshot {
+		req = &proto.CreateMetaSnapshotRequest{PartitionID: mp.PartitionID, VolName: volName, SnapshotId: snapshotId}
+	} else {
+		req = &proto.DeleteMetaSnapshotRequest{PartitionID: mp.PartitionID, VolName: volName, SnapshotId: snapshotId}
+	}
+	task := proto.NewAdminTask(opCode, mr.Addr, req)
+	resetMetaPartitionTaskID(task, mp.PartitionID)
+	metaNode, err := c.metaNode(mr.Addr)
+	if err != nil {
+		return
+	}
+	_, err = metaNode.Sender.syncSendAdminTask(task)
+	return
+}
+
 // Create a new volume.
 // By default we create 3 meta partitions and 10 data partitions during initialization.
 func (c *Cluster) createVol(name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone bool) (vol *Vol, err error) {
@@ -1948,6 +2236,24 @@ func (c *Cluster) setDisableAutoAllocate(disableAutoAllocate bool) (err error) {
 	return
 }
 
+// setPublicAccessBlock sets the cluster-wide settings which block the public access to the
+// buckets of ObjectNode. Empty settings remove the cluster-wide settings.
+func (c *Cluster) setPublicAccessBlock(block *proto.PublicAccessBlock) (err error) {
+	if block.IsEmpty() {
+		block = nil
+	}
+	oldBlock := c.PublicAccessBlock
+	c.PublicAccessBlock = block
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setPublicAccessBlock] err[%v]", err)
+		c.PublicAccessBlock = oldBlock
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogInfof("action[setPublicAccessBlock] block[%v]", block)
+	return
+}
+
 func (c *Cluster) clearVols() {
 	c.volMutex.Lock()
 	defer c.volMutex.Unlock()
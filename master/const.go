@@ -52,6 +52,22 @@ const (
 	descriptionKey          = "description"
 	dpSelectorNameKey       = "dpSelectorName"
 	dpSelectorParmKey       = "dpSelectorParm"
+	qpsKey                  = "qps"
+	readBandwidthKey        = "readBandwidth"
+	writeBandwidthKey       = "writeBandwidth"
+	actionQPSKey            = "actionQPS"
+	trashIntervalKey        = "trashInterval"
+	inodeKey                = "inode"
+	pathKey                 = "path"
+	maxBytesKey             = "maxBytes"
+	maxFilesKey             = "maxFiles"
+	snapshotNameKey         = "snapshotName"
+
+	// keys of the cluster-wide public access block settings of ObjectNode
+	blockPublicAclsKey       = "blockPublicAcls"
+	ignorePublicAclsKey      = "ignorePublicAcls"
+	blockPublicPolicyKey     = "blockPublicPolicy"
+	restrictPublicBucketsKey = "restrictPublicBuckets"
 )
 
 const (
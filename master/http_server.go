@@ -123,6 +123,27 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminUpdateVol).
 		HandlerFunc(m.updateVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetVolQoS).
+		HandlerFunc(m.setVolQoS)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetQuota).
+		HandlerFunc(m.setQuota)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDeleteQuota).
+		HandlerFunc(m.deleteQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListQuota).
+		HandlerFunc(m.listQuota)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminCreateVolSnapshot).
+		HandlerFunc(m.createVolSnapshot)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDeleteVolSnapshot).
+		HandlerFunc(m.deleteVolSnapshot)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListVolSnapshot).
+		HandlerFunc(m.listVolSnapshot)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminVolShrink).
 		HandlerFunc(m.volShrink)
@@ -244,6 +265,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminGetNodeInfo).
 		HandlerFunc(m.getNodeInfoHandler)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetPublicAccessBlock).
+		HandlerFunc(m.setPublicAccessBlock)
 
 	// user management APIs
 	router.NewRoute().Methods(http.MethodPost).
@@ -60,6 +60,7 @@ type MetaPartition struct {
 	OfflinePeerID uint64
 	MissNodes     map[string]int64
 	LoadResponse  []*proto.MetaPartitionLoadResponse
+	quotaUsages   []*proto.QuotaUsage // usage of the directory quotas reported by the leader
 	offlineMutex  sync.RWMutex
 	sync.RWMutex
 }
@@ -349,6 +350,9 @@ func (mp *MetaPartition) updateMetaPartition(mgr *proto.MetaPartitionReport, met
 		mp.addReplica(mr)
 	}
 	mr.updateMetric(mgr)
+	if mgr.IsLeader {
+		mp.quotaUsages = mgr.QuotaUsages
+	}
 	mp.setMaxInodeID()
 	mp.setInodeCount()
 	mp.setDentryCount()
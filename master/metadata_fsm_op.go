@@ -39,6 +39,7 @@ type clusterValue struct {
 	MetaNodeDeleteBatchCount    uint64
 	MetaNodeDeleteWorkerSleepMs uint64
 	DataNodeAutoRepairLimitRate uint64
+	PublicAccessBlock           *bsProto.PublicAccessBlock `json:",omitempty"`
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
@@ -50,6 +51,7 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		MetaNodeDeleteWorkerSleepMs: c.cfg.MetaNodeDeleteWorkerSleepMs,
 		DataNodeAutoRepairLimitRate: c.cfg.DataNodeAutoRepairLimitRate,
 		DisableAutoAllocate:         c.DisableAutoAllocate,
+		PublicAccessBlock:           c.PublicAccessBlock,
 	}
 	return cv
 }
@@ -142,6 +144,10 @@ type volValue struct {
 	Description       string
 	DpSelectorName    string
 	DpSelectorParm    string
+	QoS               *bsProto.QoSLimit          `json:",omitempty"`
+	Quotas            []*bsProto.QuotaInfo       `json:",omitempty"`
+	TrashInterval     uint32                     `json:",omitempty"`
+	Snapshots         []*bsProto.VolSnapshotInfo `json:",omitempty"`
 }
 
 func (v *volValue) Bytes() (raw []byte, err error) {
@@ -169,6 +175,10 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		Description:       vol.description,
 		DpSelectorName:    vol.dpSelectorName,
 		DpSelectorParm:    vol.dpSelectorParm,
+		QoS:               vol.qos,
+		Quotas:            vol.getQuotas(),
+		TrashInterval:     vol.trashInterval,
+		Snapshots:         vol.getSnapshots(),
 	}
 	return
 }
@@ -523,6 +533,7 @@ func (c *Cluster) loadClusterValue() (err error) {
 		}
 		c.cfg.MetaNodeThreshold = cv.Threshold
 		c.DisableAutoAllocate = cv.DisableAutoAllocate
+		c.PublicAccessBlock = cv.PublicAccessBlock
 		c.updateMetaNodeDeleteBatchCount(cv.MetaNodeDeleteBatchCount)
 		c.updateMetaNodeDeleteWorkerSleepMs(cv.MetaNodeDeleteWorkerSleepMs)
 		c.updateDataNodeDeleteLimitRate(cv.DataNodeDeleteLimitRate)
@@ -0,0 +1,73 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	trashPurgeInterval = 10 * time.Minute // interval the leader purges the expired entries of the trash
+)
+
+// scheduleToPurgeTrash purges the trash of the volumes on the leader, so that the deleted entries
+// are removed after the trash interval whether any client mounts the volume or not.
+func (c *Cluster) scheduleToPurgeTrash() {
+	go func() {
+		for {
+			time.Sleep(trashPurgeInterval)
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.purgeTrash()
+			}
+		}
+	}()
+}
+
+func (c *Cluster) purgeTrash() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("purgeTrash occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"purgeTrash occurred panic")
+		}
+	}()
+	for _, vol := range c.allVols() {
+		if vol.trashInterval == 0 {
+			continue
+		}
+		if err := c.purgeVolTrash(vol.Name, time.Duration(vol.trashInterval)*time.Minute); err != nil {
+			log.LogWarnf("action[purgeTrash] vol[%v] err[%v]", vol.Name, err)
+		}
+	}
+}
+
+// purgeVolTrash deletes the entries which stay in the trash of the volume longer than the interval,
+// through a meta client of the volume which gets the meta partitions from the leader itself.
+func (c *Cluster) purgeVolTrash(volName string, interval time.Duration) (err error) {
+	mw, err := meta.NewMetaWrapper(&meta.MetaConfig{Volume: volName, Masters: []string{c.masterAddr()}})
+	if err != nil {
+		return
+	}
+	defer mw.Close()
+	count, err := mw.PurgeTrash_ll(time.Now().Add(-interval))
+	if count > 0 {
+		log.LogInfof("action[purgeVolTrash] vol[%v] purged[%v]", volName, count)
+	}
+	return
+}
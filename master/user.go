@@ -168,7 +168,7 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 		return
 	}
 	var formerAK = userInfo.AccessKey
-	var akMark, skMark, typeMark, describeMark int
+	var akMark, skMark, typeMark, describeMark, qosMark, quotaMark int
 	if param.AccessKey != "" {
 		if !proto.IsValidAK(param.AccessKey) {
 			err = proto.ErrInvalidAccessKey
@@ -199,6 +199,15 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 	if param.Description != "" {
 		describeMark = 1
 	}
+	if param.QoS != nil {
+		if err = param.QoS.Validate(); err != nil {
+			return
+		}
+		qosMark = 1
+	}
+	if param.Quota != nil {
+		quotaMark = 1
+	}
 
 	var akUserBef *proto.AKUser
 	var akUserAft *proto.AKUser
@@ -221,6 +230,18 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 	if describeMark == 1 {
 		userInfo.Description = param.Description
 	}
+	if qosMark == 1 {
+		userInfo.QoS = param.QoS
+		if param.QoS.IsEmpty() {
+			userInfo.QoS = nil
+		}
+	}
+	if quotaMark == 1 {
+		userInfo.Quota = param.Quota
+		if param.Quota.IsEmpty() {
+			userInfo.Quota = nil
+		}
+	}
 
 	if len(strings.TrimSpace(param.Password)) != 0 {
 		akUserBef.Password = encodingPassword(param.Password)
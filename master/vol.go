@@ -17,6 +17,7 @@ package master
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/chubaofs/chubaofs/proto"
@@ -34,6 +35,7 @@ type VolVarargs struct {
 	authenticate   bool
 	dpSelectorName string
 	dpSelectorParm string
+	trashInterval  uint32 //minutes
 }
 
 // Vol represents a set of meta partitionMap and data partitionMap
@@ -65,6 +67,10 @@ type Vol struct {
 	description        string
 	dpSelectorName     string
 	dpSelectorParm     string
+	qos                *proto.QoSLimit // limits on the requests served by ObjectNode
+	trashInterval      uint32          // minutes the deleted files stay in the trash, 0 disables the trash
+	quotas             map[uint64]*proto.QuotaInfo
+	snapshots          map[uint64]*proto.VolSnapshotInfo
 	sync.RWMutex
 }
 
@@ -97,6 +103,8 @@ func newVol(id uint64, name, owner, zoneName string, dpSize, capacity uint64, dp
 	vol.mpsCache = make([]byte, 0)
 	vol.createTime = createTime
 	vol.description = description
+	vol.quotas = make(map[uint64]*proto.QuotaInfo)
+	vol.snapshots = make(map[uint64]*proto.VolSnapshotInfo)
 	return
 }
 
@@ -120,6 +128,14 @@ func newVolFromVolValue(vv *volValue) (vol *Vol) {
 	vol.Status = vv.Status
 	vol.dpSelectorName = vv.DpSelectorName
 	vol.dpSelectorParm = vv.DpSelectorParm
+	vol.qos = vv.QoS
+	vol.trashInterval = vv.TrashInterval
+	for _, quota := range vv.Quotas {
+		vol.quotas[quota.QuotaId] = quota
+	}
+	for _, snapshot := range vv.Snapshots {
+		vol.snapshots[snapshot.SnapshotId] = snapshot
+	}
 	return vol
 }
 
@@ -428,10 +444,82 @@ func (vol *Vol) totalUsedSpace() uint64 {
 	return vol.dataPartitions.totalUsedSpace()
 }
 
+func (vol *Vol) totalInodeCount() (count uint64) {
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		count += mp.InodeCount
+	}
+	return
+}
+
+// getQuotas returns the directory quotas sorted by the quota ID. The quotas are replaced as a whole
+// when they are changed, so they can be read without the lock of the volume.
+func (vol *Vol) getQuotas() (quotas []*proto.QuotaInfo) {
+	for _, quota := range vol.quotas {
+		quotas = append(quotas, quota)
+	}
+	sort.Slice(quotas, func(i, j int) bool {
+		return quotas[i].QuotaId < quotas[j].QuotaId
+	})
+	return
+}
+
+// getSnapshots returns the volume snapshots sorted by the snapshot ID. The snapshots are replaced
+// as a whole when they are changed, like the quotas.
+func (vol *Vol) getSnapshots() (snapshots []*proto.VolSnapshotInfo) {
+	for _, snapshot := range vol.snapshots {
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].SnapshotId < snapshots[j].SnapshotId
+	})
+	return
+}
+
+// getQuotasWithUsage returns the copies of the quotas with the usage summed up from the reports
+// of the leaders of the meta partitions.
+func (vol *Vol) getQuotasWithUsage() (quotas []*proto.QuotaInfo) {
+	var usages = make(map[uint64]*proto.QuotaUsage)
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mp.RLock()
+		for _, usage := range mp.quotaUsages {
+			if sum, ok := usages[usage.QuotaId]; ok {
+				sum.UsedBytes += usage.UsedBytes
+				sum.UsedFiles += usage.UsedFiles
+			} else {
+				usages[usage.QuotaId] = &proto.QuotaUsage{QuotaId: usage.QuotaId, UsedBytes: usage.UsedBytes, UsedFiles: usage.UsedFiles}
+			}
+		}
+		mp.RUnlock()
+	}
+	for _, quota := range vol.getQuotas() {
+		var info = *quota
+		if usage, ok := usages[quota.QuotaId]; ok {
+			info.UsedBytes = usage.UsedBytes
+			info.UsedFiles = usage.UsedFiles
+		}
+		quotas = append(quotas, &info)
+	}
+	return
+}
+
+func (vol *Vol) getQuotaHeartbeatInfos() (infos []*proto.QuotaHeartbeatInfo) {
+	for _, quota := range vol.getQuotasWithUsage() {
+		infos = append(infos, &proto.QuotaHeartbeatInfo{
+			QuotaId:      quota.QuotaId,
+			Inode:        quota.Inode,
+			LimitedBytes: quota.LimitedBytes(),
+			LimitedFiles: quota.LimitedFiles(),
+		})
+	}
+	return
+}
+
 func (vol *Vol) updateViewCache(c *Cluster) {
 	view := proto.NewVolView(vol.Name, vol.Status, vol.FollowerRead, vol.createTime)
 	view.SetOwner(vol.Owner)
 	view.SetOSSSecure(vol.OSSAccessKey, vol.OSSSecretKey)
+	view.QoS = vol.qos
+	view.TrashInterval = vol.trashInterval
 	mpViews := vol.getMetaPartitionsView()
 	view.MetaPartitions = mpViews
 	mpViewsReply := newSuccessHTTPReply(mpViews)
@@ -784,5 +872,6 @@ func getVolVarargs(vol *Vol) *VolVarargs {
 		authenticate:   vol.authenticate,
 		dpSelectorName: vol.dpSelectorName,
 		dpSelectorParm: vol.dpSelectorParm,
+		trashInterval:  vol.trashInterval,
 	}
 }
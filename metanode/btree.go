@@ -164,6 +164,14 @@ func (b *BTree) Len() (size int) {
 	return
 }
 
+// MinItem returns the smallest item in the btree.
+func (b *BTree) MinItem() BtreeItem {
+	b.RLock()
+	item := b.tree.Min()
+	b.RUnlock()
+	return item
+}
+
 // MaxItem returns the largest item in the btree.
 func (b *BTree) MaxItem() BtreeItem {
 	b.RLock()
@@ -61,6 +61,20 @@ type (
 	ReadDirReq = proto.ReadDirRequest
 	// MetaNode -> Client read dir response
 	ReadDirResp = proto.ReadDirResponse
+	// Client -> MetaNode read dir by page request
+	ReadDirLimitReq = proto.ReadDirLimitRequest
+	// MetaNode -> Client read dir by page response
+	ReadDirLimitResp = proto.ReadDirLimitResponse
+	// Client -> MetaNode list prefix request
+	ListPrefixReq = proto.ListPrefixRequest
+	// MetaNode -> Client list prefix response
+	ListPrefixResp = proto.ListPrefixResponse
+	// Client -> MetaNode get dir stat request
+	GetDirStatReq = proto.GetDirStatRequest
+	// MetaNode -> Client get dir stat response
+	GetDirStatResp = proto.GetDirStatResponse
+	// MetaNode -> MetaNode add dir stat request
+	AddDirStatReq = proto.AddDirStatRequest
 	// MetaNode -> Client lookup
 	LookupReq = proto.LookupRequest
 	// Client -> MetaNode lookup
@@ -119,6 +133,28 @@ const (
 	opFSMEvictInodeBatch
 
 	opFSMExtentsAddWithCheck
+	opFSMCreateInodeQuota
+	opFSMAddDirStat
+	opFSMCreateVolSnapshot
+	opFSMDeleteVolSnapshot
+	opFSMTxPrepare
+	opFSMTxCommit
+	opFSMTxRollback
+	opFSMTxFinish
+	opFSMTxDelete
+	opFSMSetLock
+	opFSMLockHeartbeat
+	opFSMExpireLock
+	opChangelogSnapshot
+	opFSMSetXAttrAllowLockChange
+	opFSMRemoveXAttrAllowLockChange
+	opFSMTagQuota
+	opFSMFlushDirStat
+	opFSMAckDirStat
+	opDirStatSnapshot
+	opFSMKeepVolSnapshotExtents
+	opVolSnapshotSnapshot
+	opVolSnapshotItemSnapshot
 )
 
 var (
@@ -155,6 +191,7 @@ const (
 	cfgSmuxMaxConn       = "smuxMaxConn"       //int
 	cfgSmuxStreamPerConn = "smuxStreamPerConn" //int
 	cfgSmuxMaxBuffer     = "smuxMaxBuffer"     //int
+	cfgObjectLockKeyFile = "objectLockKeyFile"
 
 	metaNodeDeleteBatchCountKey = "batchCount"
 )
@@ -68,6 +68,8 @@ type metadataManager struct {
 	partitions         map[uint64]MetaPartition // Key: metaRangeId, Val: metaPartition
 	metaNode           *MetaNode
 	flDeleteBatchCount atomic.Value
+	quotas             *volQuotas     // quotas of the volumes sent by the master
+	volPartitions      *volPartitions // meta partitions of the volumes fetched from the master
 }
 
 func (m *metadataManager) getPacketLabels(p *Packet) (labels map[string]string) {
@@ -133,6 +135,30 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opUpdateDentry(conn, p, remoteAddr)
 	case proto.OpMetaReadDir:
 		err = m.opReadDir(conn, p, remoteAddr)
+	case proto.OpMetaReadDirLimit:
+		err = m.opReadDirLimit(conn, p, remoteAddr)
+	case proto.OpMetaListPrefix:
+		err = m.opListPrefix(conn, p, remoteAddr)
+	case proto.OpMetaGetDirStat:
+		err = m.opGetDirStat(conn, p, remoteAddr)
+	case proto.OpMetaAddDirStat:
+		err = m.opAddDirStat(conn, p, remoteAddr)
+	case proto.OpMetaTxPrepare:
+		err = m.opTxPrepare(conn, p, remoteAddr)
+	case proto.OpMetaTxCommit:
+		err = m.opTxCommit(conn, p, remoteAddr)
+	case proto.OpMetaTxRollback:
+		err = m.opTxRollback(conn, p, remoteAddr)
+	case proto.OpMetaTxGetState:
+		err = m.opTxGetState(conn, p, remoteAddr)
+	case proto.OpMetaSetLock:
+		err = m.opSetLock(conn, p, remoteAddr)
+	case proto.OpMetaGetLock:
+		err = m.opGetLock(conn, p, remoteAddr)
+	case proto.OpMetaLockHeartbeat:
+		err = m.opLockHeartbeat(conn, p, remoteAddr)
+	case proto.OpMetaReadChangelog:
+		err = m.opReadChangelog(conn, p, remoteAddr)
 	case proto.OpCreateMetaPartition:
 		err = m.opCreateMetaPartition(conn, p, remoteAddr)
 	case proto.OpMetaNodeHeartbeat:
@@ -163,6 +189,10 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opRemoveMetaPartitionRaftMember(conn, p, remoteAddr)
 	case proto.OpMetaPartitionTryToLeader:
 		err = m.opMetaPartitionTryToLeader(conn, p, remoteAddr)
+	case proto.OpCreateMetaSnapshot:
+		err = m.opCreateMetaSnapshot(conn, p, remoteAddr)
+	case proto.OpDeleteMetaSnapshot:
+		err = m.opDeleteMetaSnapshot(conn, p, remoteAddr)
 	case proto.OpMetaBatchInodeGet:
 		err = m.opMetaBatchInodeGet(conn, p, remoteAddr)
 	case proto.OpMetaDeleteInode:
@@ -182,6 +212,8 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opMetaRemoveXAttr(conn, p, remoteAddr)
 	case proto.OpMetaListXAttr:
 		err = m.opMetaListXAttr(conn, p, remoteAddr)
+	case proto.OpMetaTagQuota:
+		err = m.opMetaTagQuota(conn, p, remoteAddr)
 	// operations for multipart session
 	case proto.OpCreateMultipart:
 		err = m.opCreateMultipart(conn, p, remoteAddr)
@@ -478,12 +510,14 @@ func (m *metadataManager) MarshalJSON() (data []byte, err error) {
 // NewMetadataManager returns a new metadata manager.
 func NewMetadataManager(conf MetadataManagerConfig, metaNode *MetaNode) MetadataManager {
 	return &metadataManager{
-		nodeId:     conf.NodeID,
-		zoneName:   conf.ZoneName,
-		rootDir:    conf.RootDir,
-		raftStore:  conf.RaftStore,
-		partitions: make(map[uint64]MetaPartition),
-		metaNode:   metaNode,
+		nodeId:        conf.NodeID,
+		zoneName:      conf.ZoneName,
+		rootDir:       conf.RootDir,
+		raftStore:     conf.RaftStore,
+		partitions:    make(map[uint64]MetaPartition),
+		metaNode:      metaNode,
+		quotas:        newVolQuotas(),
+		volPartitions: newVolPartitions(),
 	}
 }
 
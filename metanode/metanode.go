@@ -15,6 +15,8 @@
 package metanode
 
 import (
+	"bytes"
+	"io/ioutil"
 	syslog "log"
 	"os"
 	"smux"
@@ -40,6 +42,7 @@ var (
 	clusterInfo    *proto.ClusterInfo
 	masterClient   *masterSDK.MasterClient
 	configTotalMem uint64
+	objectLockKey  []byte // key shared with the ObjectNodes to verify the object lock changes
 	serverPort     string
 	smuxPortShift  int
 	smuxPool       *util.SmuxConnectPool
@@ -196,6 +199,16 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 		return fmt.Errorf("bad totalMem config,Recommended to be configured as 80 percent of physical machine memory")
 	}
 
+	if keyFile := cfg.GetString(cfgObjectLockKeyFile); keyFile != "" {
+		var data []byte
+		if data, err = ioutil.ReadFile(keyFile); err != nil {
+			return fmt.Errorf("bad objectLockKeyFile config: %v", err)
+		}
+		if objectLockKey = bytes.TrimSpace(data); len(objectLockKey) == 0 {
+			return fmt.Errorf("bad objectLockKeyFile config: empty key")
+		}
+	}
+
 	deleteBatchCount := cfg.GetInt64(cfgDeleteBatchCount)
 	if deleteBatchCount > 1 {
 		updateDeleteBatchCount(uint64(deleteBatchCount))
@@ -136,6 +136,10 @@ type OpDentry interface {
 	DeleteDentryBatch(req *BatchDeleteDentryReq, p *Packet) (err error)
 	UpdateDentry(req *UpdateDentryReq, p *Packet) (err error)
 	ReadDir(req *ReadDirReq, p *Packet) (err error)
+	ReadDirLimit(req *ReadDirLimitReq, p *Packet) (err error)
+	ListPrefix(req *ListPrefixReq, p *Packet) (err error)
+	GetDirStat(req *GetDirStatReq, p *Packet) (err error)
+	AddDirStat(req *AddDirStatReq, p *Packet) (err error)
 	Lookup(req *LookupReq, p *Packet) (err error)
 	GetDentryTree() *BTree
 }
@@ -157,6 +161,39 @@ type OpMultipart interface {
 	ListMultipart(req *proto.ListMultipartRequest, p *Packet) (err error)
 }
 
+// OpQuota defines the interface for the quota operations.
+type OpQuota interface {
+	GetQuotaUsages() []*proto.QuotaUsage
+	TagQuota(req *proto.TagQuotaRequest, p *Packet) (err error)
+}
+
+// OpVolSnapshot defines the interface for the volume snapshot operations.
+type OpVolSnapshot interface {
+	CreateVolSnapshot(req *proto.CreateMetaSnapshotRequest, p *Packet) (err error)
+	DeleteVolSnapshot(req *proto.DeleteMetaSnapshotRequest, p *Packet) (err error)
+	GetVolSnapshotView(snapshotId uint64) (MetaPartition, error)
+}
+
+// OpTransaction defines the interface for the metadata transaction operations.
+type OpTransaction interface {
+	TxPrepare(req *proto.TxPrepareRequest, p *Packet) (err error)
+	TxCommit(req *proto.TxCommitRequest, p *Packet) (err error)
+	TxRollback(req *proto.TxRollbackRequest, p *Packet) (err error)
+	TxGetState(req *proto.TxGetStateRequest, p *Packet) (err error)
+}
+
+// OpLock defines the interface for the file lock operations.
+type OpLock interface {
+	SetLock(req *proto.SetLockRequest, p *Packet) (err error)
+	GetLock(req *proto.GetLockRequest, p *Packet) (err error)
+	LockHeartbeat(req *proto.LockHeartbeatRequest, p *Packet) (err error)
+}
+
+// OpChangelog defines the interface for the changelog operations.
+type OpChangelog interface {
+	ReadChangelog(req *proto.ReadChangelogRequest, p *Packet) (err error)
+}
+
 // OpMeta defines the interface for the metadata operations.
 type OpMeta interface {
 	OpInode
@@ -165,6 +202,11 @@ type OpMeta interface {
 	OpPartition
 	OpExtend
 	OpMultipart
+	OpQuota
+	OpVolSnapshot
+	OpTransaction
+	OpLock
+	OpChangelog
 }
 
 // OpPartition defines the interface for the partition operations.
@@ -204,10 +246,15 @@ type metaPartition struct {
 	config                 *MetaPartitionConfig
 	size                   uint64 // For partition all file size
 	applyID                uint64 // Inode/Dentry max applyID, this index will be update after restoring from the dumped data.
+	applyTime              int64  // time of the raft log being applied
 	dentryTree             *BTree
 	inodeTree              *BTree // btree for inodes
 	extendTree             *BTree // btree for inode extend (XAttr) management
 	multipartTree          *BTree // collection for multipart management
+	txTree                 *BTree // transactions prepared by the partition
+	lockTree               *BTree // file locks of the inodes held by the client sessions
+	changelogTree          *BTree // latest metadata changes applied by the partition
+	dirStatTree            *BTree // statistics of the directories and the changes to be sent to the others
 	raftPartition          raftstore.Partition
 	stopC                  chan bool
 	storeChan              chan *storeMsg
@@ -219,6 +266,12 @@ type metaPartition struct {
 	vol                    *Vol
 	manager                *metadataManager
 	isLoadingMetaPartition bool
+	volSnapshots           *volSnapshots          // volume snapshots frozen by the partition
+	txDentryLocks          map[txDentryKey]string // dentries locked by the prepared transactions
+	changelogAppends       map[uint64]uint64      // latest append-extents events of the inodes in the changelog
+	changelogPaused        bool                   // the changes are not recorded while the snapshot is loaded
+	quotaUsages            quotaUsages            // usage of the quotas by the inodes of the partition
+	dirQuotaCache          dirQuotaCache          // quota tags of the directories in the other partitions
 }
 
 func (mp *metaPartition) ForceSetMetaPartitionToLoadding() {
@@ -294,6 +347,9 @@ func (mp *metaPartition) onStart() (err error) {
 			mp.config.PartitionId, err.Error())
 		return
 	}
+	go mp.txWorker()
+	go mp.lockWorker()
+	go mp.dirStatWorker()
 	return
 }
 
@@ -381,6 +437,10 @@ func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaP
 		inodeTree:     NewBtree(),
 		extendTree:    NewBtree(),
 		multipartTree: NewBtree(),
+		txTree:        NewBtree(),
+		lockTree:      NewBtree(),
+		changelogTree: NewBtree(),
+		dirStatTree:   NewBtree(),
 		stopC:         make(chan bool),
 		storeChan:     make(chan *storeMsg, 100),
 		freeList:      newFreeList(),
@@ -388,6 +448,7 @@ func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaP
 		extReset:      make(chan struct{}),
 		vol:           NewVol(),
 		manager:       manager,
+		volSnapshots:  newVolSnapshots(),
 	}
 	return mp
 }
@@ -435,6 +496,8 @@ func (mp *metaPartition) PersistMetadata() (err error) {
 }
 
 func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
+	// The trees are filled by the fsm operations, which must not record the loaded items as changes.
+	mp.changelogPaused = true
 	if err = mp.loadInode(snapshotPath); err != nil {
 		return
 	}
@@ -447,6 +510,19 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 	if err = mp.loadMultipart(snapshotPath); err != nil {
 		return
 	}
+	if err = mp.loadTransaction(snapshotPath); err != nil {
+		return
+	}
+	if err = mp.loadLock(snapshotPath); err != nil {
+		return
+	}
+	if err = mp.loadChangelog(snapshotPath); err != nil {
+		return
+	}
+	mp.changelogPaused = false
+	if err = mp.loadDirStat(snapshotPath); err != nil {
+		return
+	}
 	err = mp.loadApplyID(snapshotPath)
 	return
 }
@@ -456,6 +532,8 @@ func (mp *metaPartition) load() (err error) {
 		return
 	}
 	snapshotPath := path.Join(mp.config.RootDir, snapshotDir)
+	// The trees are filled by the fsm operations, which must not record the loaded items as changes.
+	mp.changelogPaused = true
 	if err = mp.loadInode(snapshotPath); err != nil {
 		return
 	}
@@ -468,7 +546,26 @@ func (mp *metaPartition) load() (err error) {
 	if err = mp.loadMultipart(snapshotPath); err != nil {
 		return
 	}
-	err = mp.loadApplyID(snapshotPath)
+	if err = mp.loadTransaction(snapshotPath); err != nil {
+		return
+	}
+	if err = mp.loadLock(snapshotPath); err != nil {
+		return
+	}
+	if err = mp.loadChangelog(snapshotPath); err != nil {
+		return
+	}
+	mp.changelogPaused = false
+	if err = mp.loadDirStat(snapshotPath); err != nil {
+		return
+	}
+	if err = mp.loadApplyID(snapshotPath); err != nil {
+		return
+	}
+	if err = mp.loadVolSnapshots(snapshotPath); err != nil {
+		return
+	}
+	mp.rebuildQuotaUsages()
 	return
 }
 
@@ -495,6 +592,11 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		mp.storeDentry,
 		mp.storeExtend,
 		mp.storeMultipart,
+		mp.storeTransaction,
+		mp.storeLock,
+		mp.storeChangelog,
+		mp.storeDirStat,
+		mp.storeVolSnapshots,
 	}
 	for _, storeFunc := range storeFuncs {
 		var crc uint32
@@ -535,7 +637,10 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		_ = os.Rename(backupDir, snapshotDir)
 		return
 	}
-	err = os.RemoveAll(backupDir)
+	if err = os.RemoveAll(backupDir); err != nil {
+		return
+	}
+	mp.removeVolSnapshotDirs(sm.volSnapshots)
 	return
 }
 
@@ -0,0 +1,147 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The changelog of a meta partition records the metadata changes applied by the fsm, so that the
+// indexing and the sync tools track the changes of a volume incrementally instead of scanning the
+// namespace. Since the events are appended when the raft log is applied, all the replicas assign
+// the same sequence numbers and times to them. The changelog keeps the latest events only, and it
+// is stored with the partition snapshots.
+//
+// Each write appends extents to the inode, so an append-extents event replaces the previous one of
+// the inode if no other event of the inode is recorded after it. Thus a file being written takes
+// one event instead of filling the changelog, and the sequence numbers of the replaced events are
+// skipped.
+
+const (
+	changelogCapacity = 10000 // number of the latest events kept by each partition
+)
+
+// changelogItem is an event in the changelog, indexed by the sequence number.
+type changelogItem struct {
+	proto.ChangelogEvent
+}
+
+func newChangelogItemFromBytes(raw []byte) (*changelogItem, error) {
+	item := &changelogItem{}
+	if err := json.Unmarshal(raw, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Less tests whether the sequence number is less than the given one.
+func (c *changelogItem) Less(than BtreeItem) bool {
+	other, ok := than.(*changelogItem)
+	return ok && c.Seq < other.Seq
+}
+
+// Copy returns a copy of the event.
+func (c *changelogItem) Copy() BtreeItem {
+	newItem := *c
+	return &newItem
+}
+
+func (c *changelogItem) Bytes() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// appendChangelog assigns the next sequence number to the event and appends it to the changelog,
+// discarding the oldest event if the changelog is full. It must be called by the fsm only.
+func (mp *metaPartition) appendChangelog(event *proto.ChangelogEvent) {
+	if mp.changelogTree == nil || mp.changelogPaused {
+		return
+	}
+	if mp.changelogAppends == nil {
+		mp.rebuildChangelogAppends()
+	}
+	event.Seq = 1
+	if last := mp.changelogTree.MaxItem(); last != nil {
+		event.Seq = last.(*changelogItem).Seq + 1
+	}
+	event.Time = mp.fsmTime()
+	if seq, ok := mp.changelogAppends[event.Inode]; ok {
+		delete(mp.changelogAppends, event.Inode)
+		if event.Type == proto.ChangelogAppendExtents {
+			mp.changelogTree.Delete(&changelogItem{ChangelogEvent: proto.ChangelogEvent{Seq: seq}})
+		}
+	}
+	if event.Type == proto.ChangelogAppendExtents {
+		mp.changelogAppends[event.Inode] = event.Seq
+	}
+	mp.changelogTree.ReplaceOrInsert(&changelogItem{ChangelogEvent: *event}, true)
+	for mp.changelogTree.Len() > changelogCapacity {
+		oldest := mp.changelogTree.MinItem().(*changelogItem)
+		mp.changelogTree.Delete(oldest)
+		if seq, ok := mp.changelogAppends[oldest.Inode]; ok && seq == oldest.Seq {
+			delete(mp.changelogAppends, oldest.Inode)
+		}
+	}
+}
+
+// rebuildChangelogAppends finds the latest append-extents events which can be replaced, after the
+// changelog is loaded or applied from the raft snapshot.
+func (mp *metaPartition) rebuildChangelogAppends() {
+	mp.changelogAppends = make(map[uint64]uint64)
+	mp.changelogTree.Ascend(func(i BtreeItem) bool {
+		event := i.(*changelogItem)
+		if event.Type == proto.ChangelogAppendExtents {
+			mp.changelogAppends[event.Inode] = event.Seq
+		} else {
+			delete(mp.changelogAppends, event.Inode)
+		}
+		return true
+	})
+}
+
+func (mp *metaPartition) readChangelog(cursor uint64, limit int) (resp *proto.ReadChangelogResponse) {
+	resp = &proto.ReadChangelogResponse{}
+	tree := mp.changelogTree.GetTree()
+	if tree.Len() == 0 {
+		return
+	}
+	resp.FirstSeq = tree.MinItem().(*changelogItem).Seq
+	resp.LastSeq = tree.MaxItem().(*changelogItem).Seq
+	if limit <= 0 {
+		limit = proto.ChangelogReadDefaultLimit
+	} else if limit > proto.ChangelogReadMaxLimit {
+		limit = proto.ChangelogReadMaxLimit
+	}
+	pivot := &changelogItem{ChangelogEvent: proto.ChangelogEvent{Seq: cursor}}
+	tree.AscendGreaterOrEqual(pivot, func(i BtreeItem) bool {
+		event := i.(*changelogItem).ChangelogEvent
+		resp.Events = append(resp.Events, &event)
+		return len(resp.Events) < limit
+	})
+	return
+}
+
+// ReadChangelog replies the changelog events from the cursor.
+func (mp *metaPartition) ReadChangelog(req *proto.ReadChangelogRequest, p *Packet) (err error) {
+	resp := mp.readChangelog(req.Cursor, req.Limit)
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
@@ -0,0 +1,204 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartition_Changelog(t *testing.T) {
+	mp := newTestPartition(1)
+	mp.fsmCreateInode(NewInode(1, proto.Mode(os.ModeDir|0755)))
+	mp.fsmCreateInode(NewInode(2, proto.Mode(0644)))
+	mp.fsmCreateDentry(&Dentry{ParentId: 1, Name: "a", Inode: 2, Type: proto.Mode(0644)}, false)
+	extend := NewExtend(2)
+	extend.Put([]byte("user.tag"), []byte("v"))
+	mp.fsmSetXAttr(extend)
+	// failed changes are not recorded
+	mp.fsmCreateInode(NewInode(2, proto.Mode(0644)))
+	mp.fsmDeleteDentry(&Dentry{ParentId: 1, Name: "b"}, false)
+	mp.fsmDeleteDentry(&Dentry{ParentId: 1, Name: "a"}, false)
+	mp.fsmUnlinkInode(NewInode(2, 0))
+
+	expected := []struct {
+		typ   uint8
+		inode uint64
+	}{
+		{proto.ChangelogCreateInode, 1},
+		{proto.ChangelogCreateInode, 2},
+		{proto.ChangelogCreateDentry, 2},
+		{proto.ChangelogSetXAttr, 2},
+		{proto.ChangelogDeleteDentry, 2},
+		{proto.ChangelogUnlinkInode, 2},
+	}
+	resp := mp.readChangelog(0, 0)
+	if len(resp.Events) != len(expected) || resp.FirstSeq != 1 || resp.LastSeq != uint64(len(expected)) {
+		t.Fatalf("changelog mismatch: first(%v) last(%v) events(%v)", resp.FirstSeq, resp.LastSeq, len(resp.Events))
+	}
+	for i, e := range expected {
+		event := resp.Events[i]
+		if event.Seq != uint64(i+1) || event.Type != e.typ || event.Inode != e.inode {
+			t.Fatalf("event %v mismatch: %v", i, event)
+		}
+	}
+	if event := resp.Events[2]; event.ParentId != 1 || event.Name != "a" {
+		t.Fatalf("dentry event mismatch: %v", event)
+	}
+	if event := resp.Events[3]; len(event.Keys) != 1 || event.Keys[0] != "user.tag" {
+		t.Fatalf("xattr event mismatch: %v", event)
+	}
+
+	// read from the cursor with the limit
+	resp = mp.readChangelog(3, 2)
+	if len(resp.Events) != 2 || resp.Events[0].Seq != 3 || resp.Events[1].Seq != 4 {
+		t.Fatalf("read from cursor mismatch: %v", resp.Events)
+	}
+
+	// the oldest events are discarded
+	for i := 0; i < changelogCapacity; i++ {
+		mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogSetAttr, Inode: 1})
+	}
+	resp = mp.readChangelog(1, 1)
+	if resp.FirstSeq != uint64(len(expected)+1) || resp.LastSeq != uint64(len(expected)+changelogCapacity) ||
+		mp.changelogTree.Len() != changelogCapacity || resp.Events[0].Seq != resp.FirstSeq {
+		t.Fatalf("bounded changelog mismatch: first(%v) last(%v) len(%v)", resp.FirstSeq, resp.LastSeq, mp.changelogTree.Len())
+	}
+}
+
+func TestMetaPartition_ChangelogAppendExtents(t *testing.T) {
+	mp := newTestPartition(1)
+	mp.applyTime = 100
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: 2, Size: 1})
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: 3, Size: 1})
+	mp.applyTime = 200
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: 2, Size: 2})
+
+	// the latest append replaces the previous one of the inode
+	resp := mp.readChangelog(0, 0)
+	if len(resp.Events) != 2 || resp.FirstSeq != 2 || resp.LastSeq != 3 {
+		t.Fatalf("coalesced changelog mismatch: first(%v) last(%v) events(%v)", resp.FirstSeq, resp.LastSeq, resp.Events)
+	}
+	if event := resp.Events[1]; event.Seq != 3 || event.Inode != 2 || event.Size != 2 || event.Time != 200 {
+		t.Fatalf("coalesced event mismatch: %v", event)
+	}
+
+	// an append after another event of the inode is kept
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogSetAttr, Inode: 2})
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: 2, Size: 3})
+	if resp = mp.readChangelog(0, 0); len(resp.Events) != 4 || resp.LastSeq != 5 {
+		t.Fatalf("changelog after setattr mismatch: %v", resp.Events)
+	}
+
+	// the appends are found again after the changelog is replaced
+	mp.changelogAppends = nil
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: 2, Size: 4})
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: 3, Size: 4})
+	resp = mp.readChangelog(0, 0)
+	if len(resp.Events) != 4 || resp.FirstSeq != 3 || resp.Events[2].Seq != 6 || resp.Events[3].Seq != 7 {
+		t.Fatalf("changelog after rebuild mismatch: %v", resp.Events)
+	}
+}
+
+func TestMetaPartition_StoreChangelog(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "changelog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+	mp := newTestPartition(1)
+	mp.fsmCreateInode(NewInode(1, proto.Mode(os.ModeDir|0755)))
+	mp.fsmCreateDentry(&Dentry{ParentId: 1, Name: "a", Inode: 2, Type: proto.Mode(0644)}, false)
+	if _, err = mp.storeChangelog(rootDir, &storeMsg{changelogTree: mp.changelogTree.GetTree()}); err != nil {
+		t.Fatalf("store changelog fail: err(%v)", err)
+	}
+	loaded := newTestPartition(1)
+	if err = loaded.loadChangelog(rootDir); err != nil {
+		t.Fatalf("load changelog fail: err(%v)", err)
+	}
+	resp := loaded.readChangelog(0, 0)
+	if len(resp.Events) != 2 || resp.Events[1].Type != proto.ChangelogCreateDentry || resp.Events[1].Name != "a" {
+		t.Fatalf("loaded changelog mismatch: %v", resp.Events)
+	}
+	// the sequence continues after loading
+	loaded.fsmCreateInode(NewInode(2, proto.Mode(0644)))
+	if resp = loaded.readChangelog(3, 0); len(resp.Events) != 1 || resp.Events[0].Seq != 3 {
+		t.Fatalf("sequence after loading mismatch: %v", resp.Events)
+	}
+}
+
+func TestMetaPartition_LoadChangelog(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "changelog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+	mp := newTestPartition(1)
+	mp.config.RootDir = rootDir
+	mp.config.Peers = []proto.Peer{{ID: 1, Addr: "127.0.0.1:17210"}}
+	mp.fsmCreateInode(NewInode(1, proto.Mode(os.ModeDir|0755)))
+	mp.fsmCreateInode(NewInode(2, proto.Mode(0644)))
+	mp.fsmCreateDentry(&Dentry{ParentId: 1, Name: "a", Inode: 2, Type: proto.Mode(0644)}, false)
+	extend := NewExtend(2)
+	extend.Put([]byte("user.tag"), []byte("v"))
+	mp.fsmSetXAttr(extend)
+	// the oldest events are discarded as if the changelog was full
+	for i := 0; i < 2; i++ {
+		mp.changelogTree.Delete(mp.changelogTree.MinItem())
+	}
+	expected := mp.readChangelog(0, 0)
+	if err = mp.PersistMetadata(); err != nil {
+		t.Fatalf("persist metadata fail: err(%v)", err)
+	}
+	err = mp.store(&storeMsg{
+		applyIndex:    1,
+		inodeTree:     mp.inodeTree.GetTree(),
+		dentryTree:    mp.dentryTree.GetTree(),
+		extendTree:    mp.extendTree.GetTree(),
+		multipartTree: mp.multipartTree.GetTree(),
+		txTree:        mp.txTree.GetTree(),
+		lockTree:      mp.lockTree.GetTree(),
+		changelogTree: mp.changelogTree.GetTree(),
+		dirStatTree:   mp.dirStatTree.GetTree(),
+		volSnapshots:  mp.volSnapshots.list(),
+	})
+	if err != nil {
+		t.Fatalf("store partition fail: err(%v)", err)
+	}
+
+	// The trees are rebuilt without recording the loaded items as changes.
+	loaded := newTestPartition(1)
+	loaded.config.RootDir = rootDir
+	if err = loaded.load(); err != nil {
+		t.Fatalf("load partition fail: err(%v)", err)
+	}
+	if loaded.inodeTree.Len() != 2 || loaded.dentryTree.Len() != 1 || loaded.extendTree.Len() != 1 {
+		t.Fatalf("loaded trees mismatch: inodes(%v) dentries(%v) extends(%v)",
+			loaded.inodeTree.Len(), loaded.dentryTree.Len(), loaded.extendTree.Len())
+	}
+	if resp := loaded.readChangelog(0, 0); !reflect.DeepEqual(resp, expected) {
+		t.Fatalf("loaded changelog mismatch: expect(%v-%v) actual(%v-%v) events(%v)",
+			expected.FirstSeq, expected.LastSeq, resp.FirstSeq, resp.LastSeq, len(resp.Events))
+	}
+	// the changes are recorded again after loading
+	loaded.fsmCreateInode(NewInode(3, proto.Mode(0644)))
+	if resp := loaded.readChangelog(0, 0); len(resp.Events) != len(expected.Events)+1 || resp.LastSeq != expected.LastSeq+1 {
+		t.Fatalf("changelog after loading mismatch: %v", resp.Events)
+	}
+}
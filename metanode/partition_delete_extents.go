@@ -278,6 +278,7 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 		buff := bytes.NewBuffer(buf)
 		cursor += uint64(n)
 		var deleteCnt uint64
+		var keeps []*volSnapshotKeep
 		for {
 			if buff.Len() == 0 {
 				break
@@ -305,6 +306,14 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 					panic(err)
 				}
 			}
+			// keep the extent referenced by the volume snapshots until the snapshot is deleted
+			if id := mp.volSnapshots.referencedBy(&ek); id != 0 {
+				if len(keeps) == 0 || keeps[len(keeps)-1].SnapshotId != id {
+					keeps = append(keeps, &volSnapshotKeep{SnapshotId: id})
+				}
+				keeps[len(keeps)-1].Extents = append(keeps[len(keeps)-1].Extents, ek)
+				continue
+			}
 			// delete dataPartition
 			if err = mp.doDeleteMarkedInodes(&ek); err != nil {
 				eks := make([]proto.ExtentKey, 0)
@@ -315,6 +324,11 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 			}
 			deleteCnt++
 		}
+		if err = mp.keepVolSnapshotExtents(keeps); err != nil {
+			log.LogWarnf("[deleteExtentsFromList] partitionId=%d, keep extents: %s",
+				mp.config.PartitionId, err.Error())
+			continue
+		}
 		buff.Reset()
 		buff.WriteString(fmt.Sprintf("%s %d", fileName, cursor))
 		if _, err = mp.submit(opFSMInternalDelExtentCursor, buff.Bytes()); err != nil {
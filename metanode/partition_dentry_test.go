@@ -0,0 +1,60 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartition_ReadDirLimit(t *testing.T) {
+	mp := newTestPartition(1)
+	fileType := proto.Mode(0644)
+	for _, d := range []*Dentry{
+		{ParentId: 1, Name: "a", Inode: 3, Type: fileType},
+		{ParentId: 1, Name: "b", Inode: 4, Type: fileType},
+		{ParentId: 1, Name: "c", Inode: 5, Type: fileType},
+		{ParentId: 1, Name: "d", Inode: 6, Type: fileType},
+		{ParentId: 2, Name: "a", Inode: 7, Type: fileType},
+	} {
+		mp.dentryTree.ReplaceOrInsert(d, true)
+	}
+	names := func(resp *ReadDirLimitResp) (names []string) {
+		names = make([]string, 0)
+		for _, child := range resp.Children {
+			names = append(names, child.Name)
+		}
+		return
+	}
+	for _, c := range []struct {
+		marker string
+		limit  uint64
+		expect []string
+	}{
+		{"", 2, []string{"a", "b"}},
+		{"b", 2, []string{"b", "c"}},
+		{"bb", 2, []string{"c", "d"}},
+		{"c", 10, []string{"c", "d"}},
+		{"", 0, []string{"a", "b", "c", "d"}},
+		{"e", 2, []string{}},
+	} {
+		resp := mp.readDirLimit(&ReadDirLimitReq{ParentID: 1, Marker: c.marker, Limit: c.limit})
+		if actual := names(resp); !reflect.DeepEqual(actual, c.expect) {
+			t.Fatalf("marker(%v) limit(%v) mismatch: expect(%v) actual(%v)", c.marker, c.limit, c.expect, actual)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The meta partitions keep the recursive statistics of the directories when the raft log is
+// applied. Each directory created through the meta partition and each regular file created
+// under a directory record the parent, which is updated when the inode is renamed into another
+// directory. The partition of a directory counts the dentries created and deleted under it, and
+// the partition of a regular file counts the size changes into its parent. The changes of a
+// directory are added to its ancestors at once while the ancestors are in the same partition,
+// and are sent lazily to the first ancestor in another partition by the leader.
+//
+// The changes sent to a directory are kept in the partition until the partition of the directory
+// acknowledges them, and they are applied once by the sequence of the sender, so the statistics
+// do not drift. A removed directory keeps its statistics until the changes of the files under it
+// are all received, and passes them to its parent. The directories created before the upgrade are
+// not counted.
+
+const dirStatFlushInterval = 5 * time.Second
+
+// dirStatItem is the statistics of an inode of the partition, or the changes to be sent to a
+// directory in another partition.
+type dirStatItem struct {
+	Inode   uint64            `json:"ino"`
+	Parent  uint64            `json:"pino,omitempty"`    // directory which the inode is counted in
+	Stat    proto.DirStat     `json:"stat"`              // statistics of the directory, or the changes to be sent
+	Removed bool              `json:"removed,omitempty"` // the directory is removed
+	Sending proto.DirStat     `json:"sending"`           // changes being sent to the directory
+	Seq     uint64            `json:"seq,omitempty"`     // sequence of the changes being sent
+	Seqs    map[uint64]uint64 `json:"seqs,omitempty"`    // sequence of the last changes received from each partition
+}
+
+func newDirStatItemFromBytes(raw []byte) (*dirStatItem, error) {
+	item := &dirStatItem{}
+	if err := json.Unmarshal(raw, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Less tests whether the item is less than the given one.
+func (s *dirStatItem) Less(than BtreeItem) bool {
+	other, ok := than.(*dirStatItem)
+	return ok && s.Inode < other.Inode
+}
+
+// Copy returns a copy of the item.
+func (s *dirStatItem) Copy() BtreeItem {
+	newItem := *s
+	if s.Seqs != nil {
+		newItem.Seqs = make(map[uint64]uint64, len(s.Seqs))
+		for pid, seq := range s.Seqs {
+			newItem.Seqs[pid] = seq
+		}
+	}
+	return &newItem
+}
+
+func (s *dirStatItem) Bytes() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// dirStatChange is the raft log to add the changes from another partition to a directory.
+type dirStatChange struct {
+	Inode  uint64        `json:"ino"`
+	Stat   proto.DirStat `json:"stat"`
+	Source uint64        `json:"src"`
+	Seq    uint64        `json:"seq"`
+}
+
+// dirStatAck is the raft log to drop the changes acknowledged by the partitions of the directories.
+type dirStatAck struct {
+	Inode uint64 `json:"ino"`
+	Seq   uint64 `json:"seq"`
+}
+
+// getDirStatItem returns a copy of the item of the inode, which is put back after the changes.
+func (mp *metaPartition) getDirStatItem(ino uint64) *dirStatItem {
+	item := mp.dirStatTree.Get(&dirStatItem{Inode: ino})
+	if item == nil {
+		return nil
+	}
+	return item.Copy().(*dirStatItem)
+}
+
+// putDirStatItem puts the item into the tree, or drops it if it keeps nothing.
+func (mp *metaPartition) putDirStatItem(item *dirStatItem) {
+	var drop bool
+	if mp.isInoInRange(item.Inode) {
+		drop = item.Removed && item.Stat.IsZero()
+	} else {
+		drop = item.Stat.IsZero() && item.Seq == 0
+	}
+	if drop {
+		mp.dirStatTree.Delete(item)
+		return
+	}
+	mp.dirStatTree.ReplaceOrInsert(item, true)
+}
+
+// addDirStat adds the changes to the directory and its ancestors in the partition, and keeps the
+// changes to the first ancestor in another partition to be sent.
+func (mp *metaPartition) addDirStat(dir uint64, stat proto.DirStat) {
+	if mp.dirStatTree == nil {
+		return
+	}
+	for dir != 0 && !stat.IsZero() {
+		item := mp.getDirStatItem(dir)
+		if !mp.isInoInRange(dir) {
+			if item == nil {
+				item = &dirStatItem{Inode: dir}
+			}
+			item.Stat.Add(&stat)
+			mp.putDirStatItem(item)
+			return
+		}
+		if item == nil {
+			// the directory is not counted
+			return
+		}
+		item.Stat.Add(&stat)
+		mp.putDirStatItem(item)
+		dir = item.Parent
+	}
+}
+
+// createDirStat starts counting the directory, or the regular file in its parent.
+func (mp *metaPartition) createDirStat(ino *Inode, parentId uint64) {
+	if proto.IsDir(ino.Type) || (proto.IsRegular(ino.Type) && parentId != 0) {
+		mp.putDirStatItem(&dirStatItem{Inode: ino.Inode, Parent: parentId})
+	}
+}
+
+// removeDirStat stops counting the inode which is removed from the directory tree. The bytes of the
+// regular file are removed from its parent.
+func (mp *metaPartition) removeDirStat(ino *Inode) {
+	if mp.dirStatTree == nil {
+		return
+	}
+	item := mp.getDirStatItem(ino.Inode)
+	if item == nil || item.Removed {
+		return
+	}
+	if proto.IsDir(ino.Type) {
+		item.Removed = true
+		mp.putDirStatItem(item)
+		return
+	}
+	mp.dirStatTree.Delete(item)
+	mp.addDirStat(item.Parent, proto.DirStat{Bytes: -int64(ino.Size)})
+}
+
+// moveDirStat moves the statistics of the inode renamed into another directory.
+func (mp *metaPartition) moveDirStat(ino uint64, parentId uint64) {
+	if mp.dirStatTree == nil {
+		return
+	}
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil {
+		return
+	}
+	inode := item.(*Inode)
+	var stat proto.DirStat
+	statItem := mp.getDirStatItem(ino)
+	switch {
+	case proto.IsDir(inode.Type):
+		if statItem == nil {
+			// the directory created before the upgrade is not counted
+			return
+		}
+		stat = statItem.Stat
+	case proto.IsRegular(inode.Type):
+		if statItem == nil {
+			statItem = &dirStatItem{Inode: ino}
+		}
+		stat = proto.DirStat{Bytes: int64(inode.Size)}
+	default:
+		return
+	}
+	oldParentId := statItem.Parent
+	statItem.Parent = parentId
+	mp.putDirStatItem(statItem)
+	mp.addDirStat(oldParentId, stat.Neg())
+	mp.addDirStat(parentId, stat)
+}
+
+// updateDirStatBytes adds the size change of the regular file to its parent.
+func (mp *metaPartition) updateDirStatBytes(inode *Inode, oldSize uint64) {
+	if mp.dirStatTree == nil || !proto.IsRegular(inode.Type) || inode.Size == oldSize {
+		return
+	}
+	if item := mp.dirStatTree.Get(&dirStatItem{Inode: inode.Inode}); item != nil {
+		mp.addDirStat(item.(*dirStatItem).Parent, proto.DirStat{Bytes: int64(inode.Size) - int64(oldSize)})
+	}
+}
+
+// dentryDirStat returns the changes of the parent by creating the dentry.
+func dentryDirStat(dentry *Dentry) proto.DirStat {
+	if proto.IsDir(dentry.Type) {
+		return proto.DirStat{Subdirs: 1}
+	}
+	return proto.DirStat{Files: 1}
+}
+
+// fsmAddDirStat adds the changes sent by another partition to the directory.
+func (mp *metaPartition) fsmAddDirStat(change *dirStatChange) (status uint8) {
+	item := mp.getDirStatItem(change.Inode)
+	if item == nil || !mp.isInoInRange(change.Inode) {
+		// the directory is not counted, or has been removed with all the changes received
+		return proto.OpOk
+	}
+	if item.Seqs[change.Source] >= change.Seq {
+		return proto.OpOk
+	}
+	if item.Seqs == nil {
+		item.Seqs = make(map[uint64]uint64)
+	}
+	item.Seqs[change.Source] = change.Seq
+	mp.dirStatTree.ReplaceOrInsert(item, true)
+	mp.addDirStat(change.Inode, change.Stat)
+	return proto.OpOk
+}
+
+// fsmFlushDirStat starts sending the changes to the directories in other partitions, which are
+// not being sent, with the raft index as the sequence.
+func (mp *metaPartition) fsmFlushDirStat(index uint64) (status uint8) {
+	var items []*dirStatItem
+	mp.dirStatTree.Ascend(func(i BtreeItem) bool {
+		item := i.(*dirStatItem)
+		if !mp.isInoInRange(item.Inode) && item.Seq == 0 && !item.Stat.IsZero() {
+			items = append(items, item.Copy().(*dirStatItem))
+		}
+		return true
+	})
+	for _, item := range items {
+		item.Sending, item.Stat, item.Seq = item.Stat, proto.DirStat{}, index
+		mp.putDirStatItem(item)
+	}
+	return proto.OpOk
+}
+
+// fsmAckDirStat drops the changes received by the partitions of the directories.
+func (mp *metaPartition) fsmAckDirStat(acks []*dirStatAck) (status uint8) {
+	for _, ack := range acks {
+		item := mp.getDirStatItem(ack.Inode)
+		if item == nil || item.Seq != ack.Seq {
+			continue
+		}
+		item.Sending, item.Seq = proto.DirStat{}, 0
+		mp.putDirStatItem(item)
+	}
+	return proto.OpOk
+}
+
+// dirStatWorker sends the changes to the directories in other partitions on the leader.
+func (mp *metaPartition) dirStatWorker() {
+	t := time.NewTicker(dirStatFlushInterval)
+	for {
+		select {
+		case <-mp.stopC:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if _, ok := mp.IsLeader(); !ok || mp.dirStatTree.Len() == 0 {
+			continue
+		}
+		if err := mp.flushDirStat(); err != nil {
+			log.LogWarnf("dirStatWorker: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+		}
+	}
+}
+
+func (mp *metaPartition) flushDirStat() (err error) {
+	var pending bool
+	mp.dirStatTree.Ascend(func(i BtreeItem) bool {
+		item := i.(*dirStatItem)
+		pending = !mp.isInoInRange(item.Inode) && item.Seq == 0 && !item.Stat.IsZero()
+		return !pending
+	})
+	if pending {
+		if _, err = mp.submit(opFSMFlushDirStat, nil); err != nil {
+			return
+		}
+	}
+	var sending []*dirStatItem
+	mp.dirStatTree.Ascend(func(i BtreeItem) bool {
+		if item := i.(*dirStatItem); !mp.isInoInRange(item.Inode) && item.Seq != 0 {
+			sending = append(sending, item)
+		}
+		return true
+	})
+	var acks []*dirStatAck
+	for _, item := range sending {
+		if err = mp.sendDirStat(item); err != nil {
+			log.LogWarnf("flushDirStat: send changes of directory(%v) fail: partitionID(%v) err(%v)",
+				item.Inode, mp.config.PartitionId, err)
+			continue
+		}
+		acks = append(acks, &dirStatAck{Inode: item.Inode, Seq: item.Seq})
+	}
+	if len(acks) == 0 {
+		return nil
+	}
+	val, err := json.Marshal(acks)
+	if err != nil {
+		return
+	}
+	_, err = mp.submit(opFSMAckDirStat, val)
+	return
+}
+
+// sendDirStat sends the changes to the partition of the directory.
+func (mp *metaPartition) sendDirStat(item *dirStatItem) (err error) {
+	participant, err := mp.locatePartition(item.Inode)
+	if err != nil {
+		return
+	}
+	req := &proto.AddDirStatRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: participant.PartitionID,
+		Inode:       item.Inode,
+		Stat:        item.Sending,
+		Source:      mp.config.PartitionId,
+		Seq:         item.Seq,
+	}
+	p, err := mp.sendTxPacket(participant, proto.OpMetaAddDirStat, req)
+	if err != nil {
+		return
+	}
+	if p.ResultCode != proto.OpOk {
+		err = fmt.Errorf("result(%v)", p.GetResultMsg())
+	}
+	return
+}
+
+// GetDirStat returns the recursive statistics of the directory.
+func (mp *metaPartition) GetDirStat(req *GetDirStatReq, p *Packet) (err error) {
+	if !mp.isInoInRange(req.Inode) {
+		err = fmt.Errorf("inode %v out of partition range", req.Inode)
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	item := mp.inodeTree.Get(NewInode(req.Inode, 0))
+	if item == nil || item.(*Inode).ShouldDelete() {
+		p.PacketErrorWithBody(proto.OpNotExistErr, nil)
+		return
+	}
+	if !proto.IsDir(item.(*Inode).Type) {
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, nil)
+		return
+	}
+	resp := &GetDirStatResp{}
+	if statItem := mp.dirStatTree.Get(&dirStatItem{Inode: req.Inode}); statItem != nil {
+		stat := statItem.(*dirStatItem).Stat
+		resp.Stat = &stat
+	}
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// AddDirStat adds the changes sent by another partition to the directory.
+func (mp *metaPartition) AddDirStat(req *AddDirStatReq, p *Packet) (err error) {
+	if !mp.isInoInRange(req.Inode) {
+		err = fmt.Errorf("inode %v out of partition range", req.Inode)
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	val, err := json.Marshal(&dirStatChange{Inode: req.Inode, Stat: req.Stat, Source: req.Source, Seq: req.Seq})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMAddDirStat, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
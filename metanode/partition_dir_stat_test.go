@@ -0,0 +1,162 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func createDirStatTestInode(mp *metaPartition, ino, parentId uint64, mode uint32, size uint64) {
+	inode := NewInode(ino, mode)
+	mp.fsmCreateInode(inode)
+	mp.createDirStat(inode, parentId)
+	if size != 0 {
+		setDirStatTestSize(mp, ino, size)
+	}
+}
+
+func setDirStatTestSize(mp *metaPartition, ino, size uint64) {
+	inode := mp.inodeTree.Get(NewInode(ino, 0)).(*Inode)
+	oldSize := inode.Size
+	inode.Size = size
+	mp.updateDirStatBytes(inode, oldSize)
+}
+
+func checkDirStat(t *testing.T, mp *metaPartition, ino uint64, expect proto.DirStat) {
+	item := mp.getDirStatItem(ino)
+	if item == nil {
+		t.Fatalf("dir stat of inode(%v) not found", ino)
+	}
+	if item.Stat != expect {
+		t.Fatalf("dir stat of inode(%v) mismatch: expect(%v) actual(%v)", ino, expect, item.Stat)
+	}
+}
+
+func TestMetaPartition_DirStat(t *testing.T) {
+	mp := newTestPartition(1)
+	const (
+		root uint64 = iota + 1
+		dir
+		file
+		subdir
+		other
+		otherFile
+		remote uint64 = 200
+	)
+	dirMode, fileMode := proto.Mode(os.ModeDir|0755), proto.Mode(0644)
+	createDirStatTestInode(mp, root, 0, dirMode, 0)
+	createDirStatTestInode(mp, dir, root, dirMode, 0)
+	createDirStatTestInode(mp, subdir, dir, dirMode, 0)
+	createDirStatTestInode(mp, file, dir, fileMode, 100)
+	createDirStatTestInode(mp, other, remote, dirMode, 0)
+	createDirStatTestInode(mp, otherFile, other, fileMode, 10)
+	mp.fsmCreateDentry(&Dentry{ParentId: root, Name: "dir", Inode: dir, Type: dirMode}, false)
+	mp.fsmCreateDentry(&Dentry{ParentId: dir, Name: "subdir", Inode: subdir, Type: dirMode}, false)
+	mp.fsmCreateDentry(&Dentry{ParentId: dir, Name: "file", Inode: file, Type: fileMode}, false)
+	mp.fsmCreateDentry(&Dentry{ParentId: other, Name: "file", Inode: otherFile, Type: fileMode}, false)
+
+	// The changes are added to the ancestors in the partition at once.
+	checkDirStat(t, mp, root, proto.DirStat{Files: 1, Subdirs: 2, Bytes: 100})
+	checkDirStat(t, mp, dir, proto.DirStat{Files: 1, Subdirs: 1, Bytes: 100})
+	checkDirStat(t, mp, other, proto.DirStat{Files: 1, Bytes: 10})
+	checkDirStat(t, mp, remote, proto.DirStat{Files: 1, Bytes: 10})
+
+	// The changes to the directory in another partition are kept until acknowledged.
+	mp.fsmFlushDirStat(7)
+	setDirStatTestSize(mp, otherFile, 15)
+	mp.fsmFlushDirStat(8)
+	item := mp.getDirStatItem(remote)
+	if item.Seq != 7 || item.Sending != (proto.DirStat{Files: 1, Bytes: 10}) || item.Stat != (proto.DirStat{Bytes: 5}) {
+		t.Fatalf("flushed dir stat mismatch: %v", item)
+	}
+	mp.fsmAckDirStat([]*dirStatAck{{Inode: remote, Seq: 6}})
+	if item = mp.getDirStatItem(remote); item.Seq != 7 {
+		t.Fatalf("stale ack should be ignored: %v", item)
+	}
+	mp.fsmAckDirStat([]*dirStatAck{{Inode: remote, Seq: 7}})
+	if item = mp.getDirStatItem(remote); item.Seq != 0 || !item.Sending.IsZero() || item.Stat != (proto.DirStat{Bytes: 5}) {
+		t.Fatalf("acknowledged dir stat mismatch: %v", item)
+	}
+
+	// The changes from another partition are added once.
+	change := &dirStatChange{Inode: dir, Stat: proto.DirStat{Bytes: 7}, Source: 9, Seq: 3}
+	mp.fsmAddDirStat(change)
+	mp.fsmAddDirStat(change)
+	mp.fsmAddDirStat(&dirStatChange{Inode: dir, Stat: proto.DirStat{Bytes: 7}, Source: 9, Seq: 2})
+	checkDirStat(t, mp, dir, proto.DirStat{Files: 1, Subdirs: 1, Bytes: 107})
+	checkDirStat(t, mp, root, proto.DirStat{Files: 1, Subdirs: 2, Bytes: 107})
+
+	// The bytes of the renamed file are moved with it.
+	mp.fsmDeleteDentry(&Dentry{ParentId: dir, Name: "file"}, false)
+	mp.fsmCreateDentry(&Dentry{ParentId: other, Name: "moved", Inode: file, Type: fileMode}, false)
+	mp.moveDirStat(file, other)
+	checkDirStat(t, mp, dir, proto.DirStat{Subdirs: 1, Bytes: 7})
+	checkDirStat(t, mp, root, proto.DirStat{Subdirs: 2, Bytes: 7})
+	checkDirStat(t, mp, other, proto.DirStat{Files: 2, Bytes: 115})
+	checkDirStat(t, mp, remote, proto.DirStat{Files: 1, Bytes: 105})
+
+	// The bytes of the removed file are removed from its parent.
+	mp.fsmDeleteDentry(&Dentry{ParentId: other, Name: "file"}, false)
+	mp.removeDirStat(mp.inodeTree.Get(NewInode(otherFile, 0)).(*Inode))
+	checkDirStat(t, mp, other, proto.DirStat{Files: 1, Bytes: 100})
+	if item = mp.getDirStatItem(otherFile); item != nil {
+		t.Fatalf("removed file should not be counted: %v", item)
+	}
+
+	// The removed directory is kept until the changes under it are all received.
+	mp.fsmDeleteDentry(&Dentry{ParentId: dir, Name: "subdir"}, false)
+	mp.removeDirStat(mp.inodeTree.Get(NewInode(subdir, 0)).(*Inode))
+	mp.fsmDeleteDentry(&Dentry{ParentId: root, Name: "dir"}, false)
+	mp.removeDirStat(mp.inodeTree.Get(NewInode(dir, 0)).(*Inode))
+	if item = mp.getDirStatItem(subdir); item != nil {
+		t.Fatalf("removed empty directory should be dropped: %v", item)
+	}
+	checkDirStat(t, mp, dir, proto.DirStat{Bytes: 7})
+	mp.fsmAddDirStat(&dirStatChange{Inode: dir, Stat: proto.DirStat{Bytes: -7}, Source: 9, Seq: 4})
+	if item = mp.getDirStatItem(dir); item != nil {
+		t.Fatalf("removed directory should be dropped: %v", item)
+	}
+	checkDirStat(t, mp, root, proto.DirStat{})
+}
+
+func TestMetaPartition_StoreDirStat(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "dirstat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+	mp := newTestPartition(1)
+	createDirStatTestInode(mp, 1, 0, proto.Mode(os.ModeDir|0755), 0)
+	createDirStatTestInode(mp, 2, 1, proto.Mode(0644), 10)
+	mp.fsmAddDirStat(&dirStatChange{Inode: 1, Stat: proto.DirStat{Files: 1}, Source: 9, Seq: 3})
+	if _, err = mp.storeDirStat(rootDir, &storeMsg{dirStatTree: mp.dirStatTree.GetTree()}); err != nil {
+		t.Fatalf("store dir stat fail: err(%v)", err)
+	}
+	loaded := newTestPartition(1)
+	if err = loaded.loadDirStat(rootDir); err != nil {
+		t.Fatalf("load dir stat fail: err(%v)", err)
+	}
+	checkDirStat(t, loaded, 1, proto.DirStat{Files: 1, Bytes: 10})
+	if item := loaded.getDirStatItem(1); item.Seqs[9] != 3 {
+		t.Fatalf("loaded sequences mismatch: %v", item.Seqs)
+	}
+	if item := loaded.getDirStatItem(2); item == nil || item.Parent != 1 {
+		t.Fatalf("loaded parent mismatch: %v", item)
+	}
+}
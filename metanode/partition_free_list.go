@@ -151,6 +151,11 @@ func (mp *metaPartition) deleteWorker() {
 					delayDeleteInos = append(delayDeleteInos, ino)
 					continue
 				}
+				if mp.volSnapshots.isInodeReferenced(inode) {
+					log.LogDebugf("[metaPartition] deleteWorker delay to remove inode: %v as referenced by volume snapshots", inode)
+					delayDeleteInos = append(delayDeleteInos, ino)
+					continue
+				}
 			}
 
 			buffSlice = append(buffSlice, ino)
@@ -160,6 +165,9 @@ func (mp *metaPartition) deleteWorker() {
 		for _, delayDeleteIno := range delayDeleteInos {
 			mp.freeList.Push(delayDeleteIno)
 		}
+		if len(buffSlice) == 0 && len(delayDeleteInos) > 0 {
+			time.Sleep(AsyncDeleteInterval)
+		}
 
 		mp.persistDeletedInodes(buffSlice)
 		mp.deleteMarkedInodes(buffSlice)
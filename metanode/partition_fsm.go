@@ -45,6 +45,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 	if err = msg.UnmarshalJson(command); err != nil {
 		return
 	}
+	mp.applyTime = msg.T
 
 	switch msg.Op {
 	case opFSMCreateInode:
@@ -55,13 +56,109 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if mp.config.Cursor < ino.Inode {
 			mp.config.Cursor = ino.Inode
 		}
-		resp = mp.fsmCreateInode(ino)
+		if resp = mp.fsmCreateInode(ino); resp == proto.OpOk {
+			mp.createDirStat(ino, 0)
+		}
+	case opFSMCreateInodeQuota:
+		iq := &inodeQuota{}
+		if err = json.Unmarshal(msg.V, iq); err != nil {
+			return
+		}
+		ino := NewInode(0, 0)
+		if err = ino.Unmarshal(iq.Inode); err != nil {
+			return
+		}
+		if mp.config.Cursor < ino.Inode {
+			mp.config.Cursor = ino.Inode
+		}
+		if resp = mp.fsmCreateInodeQuota(ino, iq.QuotaIds); resp == proto.OpOk {
+			mp.createDirStat(ino, iq.ParentId)
+		}
+	case opFSMTagQuota:
+		tag := &quotaTag{}
+		if err = json.Unmarshal(msg.V, tag); err != nil {
+			return
+		}
+		resp = mp.fsmTagQuota(tag)
+	case opFSMAddDirStat:
+		change := &dirStatChange{}
+		if err = json.Unmarshal(msg.V, change); err != nil {
+			return
+		}
+		resp = mp.fsmAddDirStat(change)
+	case opFSMFlushDirStat:
+		resp = mp.fsmFlushDirStat(index)
+	case opFSMAckDirStat:
+		var acks []*dirStatAck
+		if err = json.Unmarshal(msg.V, &acks); err != nil {
+			return
+		}
+		resp = mp.fsmAckDirStat(acks)
+	case opFSMCreateVolSnapshot:
+		op := &volSnapshotOp{}
+		if err = json.Unmarshal(msg.V, op); err != nil {
+			return
+		}
+		resp = mp.fsmCreateVolSnapshot(op.SnapshotId, index)
+	case opFSMDeleteVolSnapshot:
+		op := &volSnapshotOp{}
+		if err = json.Unmarshal(msg.V, op); err != nil {
+			return
+		}
+		resp = mp.fsmDeleteVolSnapshot(op.SnapshotId)
+	case opFSMKeepVolSnapshotExtents:
+		op := &volSnapshotKeepOp{}
+		if err = json.Unmarshal(msg.V, op); err != nil {
+			return
+		}
+		resp = mp.fsmKeepVolSnapshotExtents(op)
+	case opFSMTxPrepare:
+		var tx *txItem
+		if tx, err = newTxItemFromBytes(msg.V); err != nil {
+			return
+		}
+		resp = mp.fsmTxPrepare(tx)
+	case opFSMTxCommit, opFSMTxRollback, opFSMTxFinish, opFSMTxDelete:
+		op := &txDecisionOp{}
+		if err = json.Unmarshal(msg.V, op); err != nil {
+			return
+		}
+		switch msg.Op {
+		case opFSMTxCommit:
+			resp = mp.fsmTxCommit(op.TxId)
+		case opFSMTxRollback:
+			resp = mp.fsmTxRollback(op.TxId)
+		case opFSMTxFinish:
+			resp = mp.fsmTxFinish(op.TxId)
+		default:
+			resp = mp.fsmTxDelete(op.TxId)
+		}
+	case opFSMSetLock:
+		var lock *lockItem
+		if lock, err = newLockItemFromBytes(msg.V); err != nil {
+			return
+		}
+		resp = mp.fsmSetLock(lock)
+	case opFSMLockHeartbeat, opFSMExpireLock:
+		op := &lockLeaseOp{}
+		if err = json.Unmarshal(msg.V, op); err != nil {
+			return
+		}
+		if msg.Op == opFSMLockHeartbeat {
+			resp = mp.fsmLockHeartbeat(op)
+		} else {
+			resp = mp.fsmExpireLock(op)
+		}
 	case opFSMUnlinkInode:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmUnlinkInode(ino)
+		if mp.isUnlinkDenied(ino.Inode, mp.fsmTime()) {
+			resp = &InodeResponse{Status: proto.OpNotPerm, Msg: ino}
+		} else {
+			resp = mp.fsmUnlinkInode(ino)
+		}
 	case opFSMUnlinkInodeBatch:
 		inodes, err := InodeBatchUnmarshal(msg.V)
 		if err != nil {
@@ -110,7 +207,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = den.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmDeleteDentry(den, false)
+		if mp.isDentryRemovalDenied(den.ParentId, den.Name, 0, mp.fsmTime()) {
+			resp = &DentryResponse{Status: proto.OpNotPerm}
+		} else {
+			resp = mp.fsmDeleteDentry(den, false)
+		}
 	case opFSMDeleteDentryBatch:
 		db, err := DentryBatchUnmarshal(msg.V)
 		if err != nil {
@@ -122,7 +223,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = den.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmUpdateDentry(den)
+		if mp.isDentryRemovalDenied(den.ParentId, den.Name, den.Inode, mp.fsmTime()) {
+			resp = &DentryResponse{Status: proto.OpNotPerm}
+		} else {
+			resp = mp.fsmUpdateDentry(den)
+		}
 	case opFSMUpdatePartition:
 		req := &UpdatePartitionReq{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -146,6 +251,10 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		dentryTree := mp.getDentryTree()
 		extendTree := mp.extendTree.GetTree()
 		multipartTree := mp.multipartTree.GetTree()
+		txTree := mp.txTree.GetTree()
+		lockTree := mp.lockTree.GetTree()
+		changelogTree := mp.changelogTree.GetTree()
+		dirStatTree := mp.dirStatTree.GetTree()
 		msg := &storeMsg{
 			command:       opFSMStoreTick,
 			applyIndex:    index,
@@ -153,6 +262,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			dentryTree:    dentryTree,
 			extendTree:    extendTree,
 			multipartTree: multipartTree,
+			txTree:        txTree,
+			lockTree:      lockTree,
+			changelogTree: changelogTree,
+			dirStatTree:   dirStatTree,
+			volSnapshots:  mp.volSnapshots.list(),
 		}
 		mp.storeChan <- msg
 	case opFSMInternalDeleteInode:
@@ -163,18 +277,28 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		err = mp.delOldExtentFile(msg.V)
 	case opFSMInternalDelExtentCursor:
 		err = mp.setExtentDeleteFileCursor(msg.V)
-	case opFSMSetXAttr:
+	case opFSMSetXAttr, opFSMSetXAttrAllowLockChange:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
+		if mp.isExtendUpdateDenied(extend, false, msg.Op == opFSMSetXAttrAllowLockChange, mp.fsmTime()) {
+			resp = proto.OpNotPerm
+			break
+		}
 		err = mp.fsmSetXAttr(extend)
-	case opFSMRemoveXAttr:
+		resp = proto.OpOk
+	case opFSMRemoveXAttr, opFSMRemoveXAttrAllowLockChange:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
+		if mp.isExtendUpdateDenied(extend, true, msg.Op == opFSMRemoveXAttrAllowLockChange, mp.fsmTime()) {
+			resp = proto.OpNotPerm
+			break
+		}
 		err = mp.fsmRemoveXAttr(extend)
+		resp = proto.OpOk
 	case opFSMCreateMultipart:
 		var multipart *Multipart
 		multipart = MultipartFromBytes(msg.V)
@@ -255,6 +379,11 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		dentryTree    = NewBtree()
 		extendTree    = NewBtree()
 		multipartTree = NewBtree()
+		txTree        = NewBtree()
+		lockTree      = NewBtree()
+		changelogTree = NewBtree()
+		dirStatTree   = NewBtree()
+		volSnapshots  = newVolSnapshots()
 	)
 	defer func() {
 		if err == io.EOF {
@@ -263,7 +392,15 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			mp.dentryTree = dentryTree
 			mp.extendTree = extendTree
 			mp.multipartTree = multipartTree
+			mp.txTree = txTree
+			mp.lockTree = lockTree
+			mp.changelogTree = changelogTree
+			mp.changelogAppends = nil
+			mp.dirStatTree = dirStatTree
+			mp.volSnapshots.reset(volSnapshots)
 			mp.config.Cursor = cursor
+			mp.rebuildTxDentryLocks()
+			mp.rebuildQuotaUsages()
 			err = nil
 			// store message
 			mp.storeChan <- &storeMsg{
@@ -273,6 +410,11 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				dentryTree:    mp.dentryTree,
 				extendTree:    mp.extendTree,
 				multipartTree: mp.multipartTree,
+				txTree:        mp.txTree,
+				lockTree:      mp.lockTree,
+				changelogTree: mp.changelogTree,
+				dirStatTree:   mp.dirStatTree,
+				volSnapshots:  mp.volSnapshots.list(),
 			}
 			mp.extReset <- struct{}{}
 			log.LogDebugf("ApplySnapshot: finish with EOF: partitionID(%v) applyID(%v)", mp.config.PartitionId, mp.applyID)
@@ -329,6 +471,36 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			var multipart = MultipartFromBytes(snap.V)
 			multipartTree.ReplaceOrInsert(multipart, true)
 			log.LogDebugf("ApplySnapshot: create multipart: partitionID(%v) multipart(%v)", mp.config.PartitionId, multipart)
+		case opFSMTxPrepare:
+			var tx *txItem
+			if tx, err = newTxItemFromBytes(snap.V); err != nil {
+				return
+			}
+			txTree.ReplaceOrInsert(tx, true)
+			log.LogDebugf("ApplySnapshot: create transaction: partitionID(%v) txID(%v)", mp.config.PartitionId, tx.Tx.TxId)
+		case opFSMSetLock:
+			var lock *lockItem
+			if lock, err = newLockItemFromBytes(snap.V); err != nil {
+				return
+			}
+			lockTree.ReplaceOrInsert(lock, true)
+			log.LogDebugf("ApplySnapshot: set lock: partitionID(%v) lock(%v)", mp.config.PartitionId, lock)
+		case opChangelogSnapshot:
+			var event *changelogItem
+			if event, err = newChangelogItemFromBytes(snap.V); err != nil {
+				return
+			}
+			changelogTree.ReplaceOrInsert(event, true)
+		case opDirStatSnapshot:
+			var item *dirStatItem
+			if item, err = newDirStatItemFromBytes(snap.V); err != nil {
+				return
+			}
+			dirStatTree.ReplaceOrInsert(item, true)
+		case opVolSnapshotSnapshot, opVolSnapshotItemSnapshot:
+			if err = applyVolSnapshotItem(volSnapshots, snap); err != nil {
+				return
+			}
 		case opExtentFileSnapshot:
 			fileName := string(snap.K)
 			fileName = path.Join(mp.config.RootDir, fileName)
@@ -392,6 +564,7 @@ func (mp *metaPartition) HandleLeaderChange(leader uint64) {
 func (mp *metaPartition) submit(op uint32, data []byte) (resp interface{}, err error) {
 	snap := NewMetaItem(0, nil, nil)
 	snap.Op = op
+	snap.T = Now.GetCurrentTime().Unix()
 	if data != nil {
 		snap.V = data
 	}
@@ -405,6 +578,15 @@ func (mp *metaPartition) submit(op uint32, data []byte) (resp interface{}, err e
 	return
 }
 
+// fsmTime returns the time of the raft log being applied, which is the same on all the replicas.
+// The local time is used for the logs submitted by the old versions.
+func (mp *metaPartition) fsmTime() int64 {
+	if mp.applyTime > 0 {
+		return mp.applyTime
+	}
+	return Now.GetCurrentTime().Unix()
+}
+
 func (mp *metaPartition) uploadApplyID(applyId uint64) {
 	atomic.StoreUint64(&mp.applyID, applyId)
 }
@@ -37,6 +37,10 @@ func NewDentryResponse() *DentryResponse {
 func (mp *metaPartition) fsmCreateDentry(dentry *Dentry,
 	forceUpdate bool) (status uint8) {
 	status = proto.OpOk
+	if mp.isDentryLockedByTx(dentry.ParentId, dentry.Name) {
+		status = proto.OpAgain
+		return
+	}
 	item := mp.inodeTree.CopyGet(NewInode(dentry.ParentId, 0))
 	var parIno *Inode
 	if !forceUpdate {
@@ -72,7 +76,10 @@ func (mp *metaPartition) fsmCreateDentry(dentry *Dentry,
 		if !forceUpdate {
 			parIno.IncNLink()
 			parIno.SetMtime()
+			mp.addDirStat(dentry.ParentId, dentryDirStat(dentry))
 		}
+		mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogCreateDentry, Inode: dentry.Inode,
+			ParentId: dentry.ParentId, Name: dentry.Name, Mode: dentry.Type})
 	}
 
 	return
@@ -95,6 +102,10 @@ func (mp *metaPartition) fsmDeleteDentry(dentry *Dentry, checkInode bool) (
 	resp *DentryResponse) {
 	resp = NewDentryResponse()
 	resp.Status = proto.OpOk
+	if mp.isDentryLockedByTx(dentry.ParentId, dentry.Name) {
+		resp.Status = proto.OpAgain
+		return
+	}
 
 	var item interface{}
 	if checkInode {
@@ -128,6 +139,9 @@ func (mp *metaPartition) fsmDeleteDentry(dentry *Dentry, checkInode bool) (
 			})
 	}
 	resp.Msg = item.(*Dentry)
+	mp.addDirStat(dentry.ParentId, dentryDirStat(resp.Msg).Neg())
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogDeleteDentry, Inode: resp.Msg.Inode,
+		ParentId: resp.Msg.ParentId, Name: resp.Msg.Name, Mode: resp.Msg.Type})
 	return
 }
 
@@ -135,6 +149,10 @@ func (mp *metaPartition) fsmDeleteDentry(dentry *Dentry, checkInode bool) (
 func (mp *metaPartition) fsmBatchDeleteDentry(db DentryBatch) []*DentryResponse {
 	result := make([]*DentryResponse, 0, len(db))
 	for _, dentry := range db {
+		if mp.isDentryRemovalDenied(dentry.ParentId, dentry.Name, 0, mp.fsmTime()) {
+			result = append(result, &DentryResponse{Status: proto.OpNotPerm, Msg: dentry})
+			continue
+		}
 		result = append(result, mp.fsmDeleteDentry(dentry, true))
 	}
 	return result
@@ -144,6 +162,10 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 	resp *DentryResponse) {
 	resp = NewDentryResponse()
 	resp.Status = proto.OpOk
+	if mp.isDentryLockedByTx(dentry.ParentId, dentry.Name) {
+		resp.Status = proto.OpAgain
+		return
+	}
 	mp.dentryTree.CopyFind(dentry, func(item BtreeItem) {
 		if item == nil {
 			resp.Status = proto.OpNotExistErr
@@ -152,6 +174,8 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 		d := item.(*Dentry)
 		d.Inode, dentry.Inode = dentry.Inode, d.Inode
 		resp.Msg = dentry
+		mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogUpdateDentry, Inode: d.Inode,
+			ParentId: d.ParentId, Name: d.Name, Mode: d.Type})
 	})
 	return
 }
@@ -179,3 +203,30 @@ func (mp *metaPartition) readDir(req *ReadDirReq) (resp *ReadDirResp) {
 	})
 	return
 }
+
+// readDirLimit reads at most req.Limit dentries of the directory, starting from the dentry
+// named req.Marker.
+func (mp *metaPartition) readDirLimit(req *ReadDirLimitReq) (resp *ReadDirLimitResp) {
+	resp = &ReadDirLimitResp{}
+	limit := req.Limit
+	if limit == 0 || limit > proto.ReadDirLimitMax {
+		limit = proto.ReadDirLimitMax
+	}
+	begDentry := &Dentry{
+		ParentId: req.ParentID,
+		Name:     req.Marker,
+	}
+	endDentry := &Dentry{
+		ParentId: req.ParentID + 1,
+	}
+	mp.dentryTree.AscendRange(begDentry, endDentry, func(i BtreeItem) bool {
+		d := i.(*Dentry)
+		resp.Children = append(resp.Children, proto.Dentry{
+			Inode: d.Inode,
+			Type:  d.Type,
+			Name:  d.Name,
+		})
+		return uint64(len(resp.Children)) < limit
+	})
+	return
+}
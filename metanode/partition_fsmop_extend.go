@@ -14,6 +14,8 @@
 
 package metanode
 
+import "github.com/chubaofs/chubaofs/proto"
+
 type ExtendOpResult struct {
 	Status uint8
 	Extend *Extend
@@ -29,6 +31,7 @@ func (mp *metaPartition) fsmSetXAttr(extend *Extend) (err error) {
 		e = treeItem.(*Extend)
 	}
 	e.Merge(extend, true)
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogSetXAttr, Inode: extend.inode, Keys: extendKeys(extend)})
 	return
 }
 
@@ -42,5 +45,14 @@ func (mp *metaPartition) fsmRemoveXAttr(extend *Extend) (err error) {
 		e.Remove(key)
 		return true
 	})
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogRemoveXAttr, Inode: extend.inode, Keys: extendKeys(extend)})
+	return
+}
+
+func extendKeys(extend *Extend) (keys []string) {
+	extend.Range(func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
 	return
 }
@@ -38,7 +38,9 @@ func (mp *metaPartition) fsmCreateInode(ino *Inode) (status uint8) {
 	status = proto.OpOk
 	if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
 		status = proto.OpExistErr
+		return
 	}
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogCreateInode, Inode: ino.Inode, Mode: ino.Type})
 	return
 }
 
@@ -57,6 +59,7 @@ func (mp *metaPartition) fsmCreateLinkInode(ino *Inode) (resp *InodeResponse) {
 	}
 	i.IncNLink()
 	resp.Msg = i
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogLinkInode, Inode: i.Inode})
 	return
 }
 
@@ -125,12 +128,16 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode) (resp *InodeResponse) {
 
 	if inode.IsEmptyDir() {
 		mp.inodeTree.Delete(inode)
+		mp.updateQuotaUsage(inode.Inode, -1, 0)
+		mp.removeDirStat(inode)
 	}
 
 	inode.DecNLink()
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogUnlinkInode, Inode: inode.Inode})
 
 	//Fix#760: when nlink == 0, push into freeList and delay delete inode after 7 days
 	if inode.IsTempFile() {
+		mp.removeDirStat(inode)
 		inode.DoWriteFunc(func() {
 			if inode.NLink == 0 {
 				inode.AccessTime = time.Now().Unix()
@@ -145,6 +152,10 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode) (resp *InodeResponse) {
 // fsmUnlinkInode delete the specified inode from inode tree.
 func (mp *metaPartition) fsmUnlinkInodeBatch(ib InodeBatch) (resp []*InodeResponse) {
 	for _, ino := range ib {
+		if mp.isUnlinkDenied(ino.Inode, mp.fsmTime()) {
+			resp = append(resp, &InodeResponse{Status: proto.OpNotPerm, Msg: ino})
+			continue
+		}
 		resp = append(resp, mp.fsmUnlinkInode(ino))
 	}
 	return
@@ -171,6 +182,10 @@ func (mp *metaPartition) internalDelete(val []byte) (err error) {
 		}
 		log.LogDebugf("internalDelete: received internal delete: partitionID(%v) inode(%v)",
 			mp.config.PartitionId, ino.Inode)
+		if mp.isDeleteDenied(ino.Inode, mp.fsmTime()) {
+			log.LogWarnf("internalDelete: skip locked inode: partitionID(%v) inode(%v)", mp.config.PartitionId, ino.Inode)
+			continue
+		}
 		mp.internalDeleteInode(ino)
 	}
 }
@@ -187,6 +202,10 @@ func (mp *metaPartition) internalDeleteBatch(val []byte) error {
 	for _, ino := range inodes {
 		log.LogDebugf("internalDelete: received internal delete: partitionID(%v) inode(%v)",
 			mp.config.PartitionId, ino.Inode)
+		if mp.isDeleteDenied(ino.Inode, mp.fsmTime()) {
+			log.LogWarnf("internalDelete: skip locked inode: partitionID(%v) inode(%v)", mp.config.PartitionId, ino.Inode)
+			continue
+		}
 		mp.internalDeleteInode(ino)
 	}
 
@@ -194,6 +213,10 @@ func (mp *metaPartition) internalDeleteBatch(val []byte) error {
 }
 
 func (mp *metaPartition) internalDeleteInode(ino *Inode) {
+	if item := mp.inodeTree.Get(ino); item != nil && !item.(*Inode).ShouldDelete() {
+		mp.updateQuotaUsage(ino.Inode, -1, -quotaBytes(item.(*Inode)))
+		mp.removeDirStat(item.(*Inode))
+	}
 	mp.inodeTree.Delete(ino)
 	mp.freeList.Remove(ino.Inode)
 	mp.extendTree.Delete(&Extend{inode: ino.Inode}) // Also delete extend attribute.
@@ -212,10 +235,18 @@ func (mp *metaPartition) fsmAppendExtents(ino *Inode) (status uint8) {
 		status = proto.OpNotExistErr
 		return
 	}
+	if mp.isObjectLocked(ino2.Inode, mp.fsmTime()) {
+		status = proto.OpNotPerm
+		return
+	}
 	eks := ino.Extents.CopyExtents()
+	oldSize := ino2.Size
 	delExtents := ino2.AppendExtents(eks, ino.ModifyTime)
+	mp.updateQuotaBytes(ino2, oldSize)
+	mp.updateDirStatBytes(ino2, oldSize)
 	log.LogInfof("fsmAppendExtents inode(%v) deleteExtents(%v)", ino2.Inode, delExtents)
 	mp.extDelCh <- delExtents
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: ino2.Inode, Size: ino2.Size})
 	return
 }
 
@@ -231,6 +262,10 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode) (status uint8) {
 		status = proto.OpNotExistErr
 		return
 	}
+	if mp.isObjectLocked(ino2.Inode, mp.fsmTime()) {
+		status = proto.OpNotPerm
+		return
+	}
 	var (
 		discardExtentKey []proto.ExtentKey
 	)
@@ -241,9 +276,13 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode) (status uint8) {
 	if len(eks) > 1 {
 		discardExtentKey = eks[1:]
 	}
+	oldSize := ino2.Size
 	delExtents, status := ino2.AppendExtentWithCheck(eks[0], ino.ModifyTime, discardExtentKey)
 	if status == proto.OpOk {
+		mp.updateQuotaBytes(ino2, oldSize)
+		mp.updateDirStatBytes(ino2, oldSize)
 		mp.extDelCh <- delExtents
+		mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogAppendExtents, Inode: ino2.Inode, Size: ino2.Size})
 	}
 	log.LogInfof("fsmAppendExtentWithCheck inode(%v) ek(%v) deleteExtents(%v) discardExtents(%v) status(%v)", ino2.Inode, eks[0], delExtents, discardExtentKey, status)
 	return
@@ -267,12 +306,20 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 		resp.Status = proto.OpArgMismatchErr
 		return
 	}
+	if mp.isObjectLocked(i.Inode, mp.fsmTime()) {
+		resp.Status = proto.OpNotPerm
+		return
+	}
 
+	oldSize := i.Size
 	delExtents := i.ExtentsTruncate(ino.Size, ino.ModifyTime)
+	mp.updateQuotaBytes(i, oldSize)
+	mp.updateDirStatBytes(i, oldSize)
 
 	// now we should delete the extent
 	log.LogInfof("fsmExtentsTruncate inode(%v) exts(%v)", i.Inode, delExtents)
 	mp.extDelCh <- delExtents
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogTruncate, Inode: i.Inode, Size: i.Size})
 	return
 }
 
@@ -292,13 +339,19 @@ func (mp *metaPartition) fsmEvictInode(ino *Inode) (resp *InodeResponse) {
 	if proto.IsDir(i.Type) {
 		if i.IsEmptyDir() {
 			i.SetDeleteMark()
+			mp.updateQuotaUsage(i.Inode, -1, 0)
+			mp.removeDirStat(i)
+			mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogEvictInode, Inode: i.Inode})
 		}
 		return
 	}
 
 	if i.IsTempFile() {
 		i.SetDeleteMark()
+		mp.updateQuotaUsage(i.Inode, -1, -quotaBytes(i))
+		mp.removeDirStat(i)
 		mp.freeList.Push(i.Inode)
+		mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogEvictInode, Inode: i.Inode})
 	}
 	return
 }
@@ -333,5 +386,6 @@ func (mp *metaPartition) fsmSetAttr(req *SetattrRequest) (err error) {
 		return
 	}
 	ino.SetAttr(req)
+	mp.appendChangelog(&proto.ChangelogEvent{Type: proto.ChangelogSetAttr, Inode: ino.Inode, Mode: ino.Type})
 	return
 }
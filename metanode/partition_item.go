@@ -33,6 +33,7 @@ type MetaItem struct {
 	Op uint32 `json:"op"`
 	K  []byte `json:"k"`
 	V  []byte `json:"v"`
+	T  int64  `json:"t,omitempty"` // unix time of the leader on submitting, used by the time dependent operations
 }
 
 // MarshalJson
@@ -129,6 +130,11 @@ type MetaItemIterator struct {
 	dentryTree    *BTree
 	extendTree    *BTree
 	multipartTree *BTree
+	txTree        *BTree
+	lockTree      *BTree
+	changelogTree *BTree
+	dirStatTree   *BTree
+	volSnapshots  []*volSnapshotMeta
 
 	filenames []string
 
@@ -148,6 +154,11 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	si.dentryTree = mp.dentryTree.GetTree()
 	si.extendTree = mp.extendTree.GetTree()
 	si.multipartTree = mp.multipartTree.GetTree()
+	si.txTree = mp.txTree.GetTree()
+	si.lockTree = mp.lockTree.GetTree()
+	si.changelogTree = mp.changelogTree.GetTree()
+	si.dirStatTree = mp.dirStatTree.GetTree()
+	si.volSnapshots = mp.volSnapshots.list()
 	si.dataCh = make(chan interface{})
 	si.errorCh = make(chan error, 1)
 	si.closeCh = make(chan struct{})
@@ -228,6 +239,48 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 		if checkClose() {
 			return
 		}
+		// process transactions
+		iter.txTree.Ascend(func(i BtreeItem) bool {
+			return produceItem(i)
+		})
+		if checkClose() {
+			return
+		}
+		// process locks
+		iter.lockTree.Ascend(func(i BtreeItem) bool {
+			return produceItem(i)
+		})
+		if checkClose() {
+			return
+		}
+		// process changelog
+		iter.changelogTree.Ascend(func(i BtreeItem) bool {
+			return produceItem(i)
+		})
+		if checkClose() {
+			return
+		}
+		// process directory statistics
+		iter.dirStatTree.Ascend(func(i BtreeItem) bool {
+			return produceItem(i)
+		})
+		if checkClose() {
+			return
+		}
+		// process volume snapshots
+		for _, meta := range iter.volSnapshots {
+			if !produceItem(meta) {
+				return
+			}
+			for _, tree := range []*BTree{meta.snapshot.inodeTree, meta.snapshot.dentryTree, meta.snapshot.extendTree} {
+				tree.Ascend(func(i BtreeItem) bool {
+					return produceItem(&volSnapshotTreeItem{snapshotId: meta.Id, item: i})
+				})
+				if checkClose() {
+					return
+				}
+			}
+		}
 		// process extent del files
 		var err error
 		var raw []byte
@@ -309,6 +362,50 @@ func (si *MetaItemIterator) Next() (data []byte, err error) {
 			return
 		}
 		snap = NewMetaItem(opFSMCreateMultipart, nil, raw)
+	case *txItem:
+		var raw []byte
+		if raw, err = typedItem.Bytes(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opFSMTxPrepare, nil, raw)
+	case *lockItem:
+		var raw []byte
+		if raw, err = typedItem.Bytes(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opFSMSetLock, nil, raw)
+	case *changelogItem:
+		var raw []byte
+		if raw, err = typedItem.Bytes(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opChangelogSnapshot, nil, raw)
+	case *dirStatItem:
+		var raw []byte
+		if raw, err = typedItem.Bytes(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opDirStatSnapshot, nil, raw)
+	case *volSnapshotMeta:
+		if snap, err = typedItem.metaItem(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+	case *volSnapshotTreeItem:
+		if snap, err = typedItem.metaItem(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
 	case *fileData:
 		snap = NewMetaItem(opExtentFileSnapshot, []byte(typedItem.filename), typedItem.data)
 	default:
@@ -0,0 +1,131 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+const (
+	// The most dentries scanned by one prefix listing request, so that the request with a far
+	// marker or a prefix matching few dentries does not hold the partition for long.
+	listPrefixScanLimit = 10000
+)
+
+func (mp *metaPartition) isInoInRange(ino uint64) bool {
+	return ino >= mp.config.Start && ino <= mp.config.End
+}
+
+// listPrefix scans the directories of the request depth-first in the order of dentry names, and
+// lists the dentries whose paths match the prefix and are not less than the marker. The paths
+// containing the delimiter after the prefix are rolled up into common prefixes.
+// The scan stops when the limit of items is reached, when the scan limit of dentries is reached,
+// or when it comes to a directory of another partition. The directories which are not finished
+// are returned as the frames to continue with.
+func (mp *metaPartition) listPrefix(req *ListPrefixReq) (resp *ListPrefixResp) {
+	resp = &ListPrefixResp{Items: make([]*proto.ListPrefixItem, 0)}
+	var (
+		frames   = req.Frames
+		skipped  = make(map[string]struct{}, len(req.Skipped))
+		prefixes = make(map[string]struct{})
+		scanned  int
+	)
+	for _, name := range req.Skipped {
+		skipped[name] = struct{}{}
+	}
+	for len(frames) > 0 {
+		var (
+			frame = frames[len(frames)-1]
+			next  *proto.ListPrefixFrame // sub directory to scan before the rest of the frame
+			full  bool
+		)
+		begDentry := &Dentry{
+			ParentId: frame.Inode,
+			Name:     frame.After,
+		}
+		endDentry := &Dentry{
+			ParentId: frame.Inode + 1,
+		}
+		mp.dentryTree.AscendRange(begDentry, endDentry, func(i BtreeItem) bool {
+			d := i.(*Dentry)
+			if d.Name == frame.After {
+				return true
+			}
+			if scanned >= listPrefixScanLimit {
+				full = true
+				return false
+			}
+			scanned++
+			frame.After = d.Name
+
+			if frame.Inode == proto.RootIno {
+				if _, exist := skipped[d.Name]; exist {
+					return true
+				}
+			}
+			var isDir = proto.IsDir(d.Type)
+			var path = frame.Path + d.Name
+			if isDir {
+				path += "/"
+			}
+			if req.Prefix != "" && !strings.HasPrefix(path, req.Prefix) {
+				return true
+			}
+			if req.Marker != "" && path < req.Marker {
+				// The directory less than the marker may have dentries greater than the marker.
+				if isDir {
+					next = &proto.ListPrefixFrame{Inode: d.Inode, Path: path}
+					return false
+				}
+				return true
+			}
+			if req.Delimiter != "" {
+				var nonPrefixPart = strings.Replace(path, req.Prefix, "", 1)
+				if idx := strings.Index(nonPrefixPart, req.Delimiter); idx >= 0 {
+					var commonPrefix = req.Prefix + nonPrefixPart[:idx] + req.Delimiter
+					if _, exist := prefixes[commonPrefix]; exist {
+						return true
+					}
+					prefixes[commonPrefix] = struct{}{}
+					resp.Items = append(resp.Items, &proto.ListPrefixItem{Path: commonPrefix, IsPrefix: true})
+					full = uint64(len(resp.Items)) >= req.Limit
+					return !full
+				}
+			}
+			resp.Items = append(resp.Items, &proto.ListPrefixItem{Inode: d.Inode, Type: d.Type, Path: path})
+			full = uint64(len(resp.Items)) >= req.Limit
+			if isDir {
+				next = &proto.ListPrefixFrame{Inode: d.Inode, Path: path}
+				return false
+			}
+			return !full
+		})
+		if next != nil {
+			frames = append(frames, next)
+			if full || !mp.isInoInRange(next.Inode) {
+				break
+			}
+			continue
+		}
+		if full {
+			break
+		}
+		frames = frames[:len(frames)-1]
+	}
+	resp.Frames = frames
+	return
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartition_ListPrefix(t *testing.T) {
+	mp := newTestPartition(1)
+	dirType := proto.Mode(os.ModeDir | 0755)
+	fileType := proto.Mode(0644)
+	// The directory "a/y/" belongs to another partition.
+	for _, d := range []*Dentry{
+		{ParentId: proto.RootIno, Name: ".versions", Inode: 2, Type: dirType},
+		{ParentId: proto.RootIno, Name: "a", Inode: 3, Type: dirType},
+		{ParentId: proto.RootIno, Name: "a-b", Inode: 4, Type: fileType},
+		{ParentId: proto.RootIno, Name: "b", Inode: 5, Type: fileType},
+		{ParentId: 2, Name: "a", Inode: 6, Type: dirType},
+		{ParentId: 3, Name: "x", Inode: 7, Type: fileType},
+		{ParentId: 3, Name: "y", Inode: 200, Type: dirType},
+	} {
+		mp.dentryTree.ReplaceOrInsert(d, true)
+	}
+	root := func() []*proto.ListPrefixFrame {
+		return []*proto.ListPrefixFrame{{Inode: proto.RootIno}}
+	}
+	paths := func(items []*proto.ListPrefixItem) (paths []string) {
+		paths = make([]string, 0)
+		for _, item := range items {
+			if item.IsPrefix {
+				paths = append(paths, "prefix:"+item.Path)
+				continue
+			}
+			paths = append(paths, item.Path)
+		}
+		return
+	}
+	check := func(resp *ListPrefixResp, expectPaths []string, expectFrames []proto.ListPrefixFrame) {
+		if actual := paths(resp.Items); !reflect.DeepEqual(actual, expectPaths) {
+			t.Fatalf("items mismatch: expect(%v) actual(%v)", expectPaths, actual)
+		}
+		var frames = make([]proto.ListPrefixFrame, 0)
+		for _, frame := range resp.Frames {
+			frames = append(frames, *frame)
+		}
+		if !reflect.DeepEqual(frames, expectFrames) {
+			t.Fatalf("frames mismatch: expect(%v) actual(%v)", expectFrames, frames)
+		}
+	}
+	skipped := []string{".versions"}
+
+	// The scan stops at the directory of another partition, and continues with the rest frames.
+	resp := mp.listPrefix(&ListPrefixReq{Frames: root(), Skipped: skipped, Limit: 100})
+	check(resp, []string{"a/", "a/x", "a/y/"}, []proto.ListPrefixFrame{
+		{Inode: proto.RootIno, After: "a"},
+		{Inode: 3, Path: "a/", After: "y"},
+		{Inode: 200, Path: "a/y/"},
+	})
+	resp = mp.listPrefix(&ListPrefixReq{Frames: resp.Frames[:2], Skipped: skipped, Limit: 100})
+	check(resp, []string{"a-b", "b"}, []proto.ListPrefixFrame{})
+
+	// The scan stops when the limit is reached.
+	resp = mp.listPrefix(&ListPrefixReq{Frames: root(), Skipped: skipped, Limit: 2})
+	check(resp, []string{"a/", "a/x"}, []proto.ListPrefixFrame{
+		{Inode: proto.RootIno, After: "a"},
+		{Inode: 3, Path: "a/", After: "x"},
+	})
+
+	// The paths containing the delimiter are rolled up into common prefixes.
+	resp = mp.listPrefix(&ListPrefixReq{Frames: root(), Skipped: skipped, Delimiter: "/", Limit: 100})
+	check(resp, []string{"prefix:a/", "a-b", "b"}, []proto.ListPrefixFrame{})
+
+	// The directories less than the marker are scanned for the dentries after the marker.
+	resp = mp.listPrefix(&ListPrefixReq{Frames: root(), Skipped: skipped, Marker: "a/y", Limit: 100})
+	check(resp, []string{"a/y/"}, []proto.ListPrefixFrame{
+		{Inode: proto.RootIno, After: "a"},
+		{Inode: 3, Path: "a/", After: "y"},
+		{Inode: 200, Path: "a/y/"},
+	})
+
+	// Only the dentries matching the prefix are listed.
+	resp = mp.listPrefix(&ListPrefixReq{Frames: root(), Skipped: skipped, Prefix: "a-", Limit: 100})
+	check(resp, []string{"a-b"}, []proto.ListPrefixFrame{})
+
+	// The skipped names are listed under the directories other than the root.
+	resp = mp.listPrefix(&ListPrefixReq{Frames: []*proto.ListPrefixFrame{{Inode: 2, Path: ".versions/"}}, Skipped: skipped, Limit: 100})
+	check(resp, []string{".versions/a/"}, []proto.ListPrefixFrame{})
+}
@@ -0,0 +1,309 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The POSIX and flock locks of an inode are held by the meta partition of the inode, so that
+// all the clients mounting the volume see the same locks. Each lock is a lease of the client
+// session which holds it: the leader sets the expiration time when the lock is acquired, and the
+// client renews the leases of its session by heartbeats. The leader of the partition releases
+// the expired locks periodically, so the locks of a crashed client do not block the others.
+//
+// The locks are kept in the lock tree of the partition, which is changed through the raft log and
+// stored with the partition snapshots.
+
+const (
+	lockCheckInterval = 5 * time.Second
+)
+
+// lockItem is a lock of a byte range of an inode held by the owner of a client session.
+type lockItem struct {
+	proto.LockInfo
+	Expire int64 `json:"expire"` // unix time the lease expires at
+}
+
+func newLockItemFromBytes(raw []byte) (*lockItem, error) {
+	lock := &lockItem{}
+	if err := json.Unmarshal(raw, lock); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Less tests whether the lock is less than the given one. The locks are sorted by the inode,
+// the kind, the owner and the start of the range.
+func (l *lockItem) Less(than BtreeItem) bool {
+	other, ok := than.(*lockItem)
+	if !ok {
+		return false
+	}
+	if l.Inode != other.Inode {
+		return l.Inode < other.Inode
+	}
+	if l.Flock != other.Flock {
+		return !l.Flock
+	}
+	if l.Session != other.Session {
+		return l.Session < other.Session
+	}
+	if l.Owner != other.Owner {
+		return l.Owner < other.Owner
+	}
+	return l.Start < other.Start
+}
+
+// Copy returns a copy of the lock.
+func (l *lockItem) Copy() BtreeItem {
+	newLock := *l
+	return &newLock
+}
+
+func (l *lockItem) Bytes() ([]byte, error) {
+	return json.Marshal(l)
+}
+
+func (l *lockItem) String() string {
+	return fmt.Sprintf("lock(ino[%v] session[%v] owner[%v] flock[%v] range[%v-%v] type[%v] expire[%v])",
+		l.Inode, l.Session, l.Owner, l.Flock, l.Start, l.End, l.Type, l.Expire)
+}
+
+// lockLeaseOp is the raft log to renew the leases of a session, or to release the expired locks.
+type lockLeaseOp struct {
+	Session string `json:"session,omitempty"`
+	Time    int64  `json:"time"`
+}
+
+// rangeInodeLocks calls the function on each lock of the inode until it returns false.
+func (mp *metaPartition) rangeInodeLocks(ino uint64, fn func(lock *lockItem) bool) {
+	begin := &lockItem{LockInfo: proto.LockInfo{Inode: ino}}
+	end := &lockItem{LockInfo: proto.LockInfo{Inode: ino + 1}}
+	mp.lockTree.AscendRange(begin, end, func(i BtreeItem) bool {
+		return fn(i.(*lockItem))
+	})
+}
+
+// getConflictLock returns a lock which conflicts with the given lock, nil if there is none.
+func (mp *metaPartition) getConflictLock(info *proto.LockInfo) (conflict *proto.LockInfo) {
+	mp.rangeInodeLocks(info.Inode, func(lock *lockItem) bool {
+		if lock.Conflict(info) {
+			conflict = &lock.LockInfo
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// hasOwnedLock returns true if the owner of the lock holds a lock overlapping the range.
+func (mp *metaPartition) hasOwnedLock(info *proto.LockInfo) (owned bool) {
+	mp.rangeInodeLocks(info.Inode, func(lock *lockItem) bool {
+		if lock.Flock == info.Flock && lock.SameOwner(info) && lock.Start <= info.End && info.Start <= lock.End {
+			owned = true
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// fsmSetLock acquires or releases the lock. Like fcntl(2), the locks of the same owner which
+// overlap the range are replaced or split by the new lock.
+func (mp *metaPartition) fsmSetLock(lock *lockItem) (status uint8) {
+	var (
+		owned    []*lockItem
+		conflict bool
+	)
+	mp.rangeInodeLocks(lock.Inode, func(item *lockItem) bool {
+		if item.Flock == lock.Flock && item.SameOwner(&lock.LockInfo) {
+			if item.Start <= lock.End && lock.Start <= item.End {
+				owned = append(owned, item)
+			}
+			return true
+		}
+		if lock.Type != proto.LockTypeUnlock && item.Conflict(&lock.LockInfo) {
+			conflict = true
+			return false
+		}
+		return true
+	})
+	if conflict {
+		return proto.OpLockConflictErr
+	}
+	for _, item := range owned {
+		mp.lockTree.Delete(item)
+		if item.Start < lock.Start {
+			left := item.Copy().(*lockItem)
+			left.End = lock.Start - 1
+			mp.lockTree.ReplaceOrInsert(left, true)
+		}
+		if item.End > lock.End {
+			right := item.Copy().(*lockItem)
+			right.Start = lock.End + 1
+			mp.lockTree.ReplaceOrInsert(right, true)
+		}
+	}
+	if lock.Type != proto.LockTypeUnlock {
+		mp.lockTree.ReplaceOrInsert(lock, true)
+	}
+	return proto.OpOk
+}
+
+// fsmLockHeartbeat renews the leases of the locks held by the session.
+func (mp *metaPartition) fsmLockHeartbeat(op *lockLeaseOp) (status uint8) {
+	var locks []*lockItem
+	mp.lockTree.Ascend(func(i BtreeItem) bool {
+		if lock := i.(*lockItem); lock.Session == op.Session {
+			locks = append(locks, lock)
+		}
+		return true
+	})
+	if len(locks) == 0 {
+		return proto.OpNotExistErr
+	}
+	for _, lock := range locks {
+		renewed := lock.Copy().(*lockItem)
+		renewed.Expire = op.Time
+		mp.lockTree.ReplaceOrInsert(renewed, true)
+	}
+	return proto.OpOk
+}
+
+// fsmExpireLock releases the locks whose leases expire before the time.
+func (mp *metaPartition) fsmExpireLock(op *lockLeaseOp) (status uint8) {
+	var locks []*lockItem
+	mp.lockTree.Ascend(func(i BtreeItem) bool {
+		if lock := i.(*lockItem); lock.Expire < op.Time {
+			locks = append(locks, lock)
+		}
+		return true
+	})
+	for _, lock := range locks {
+		mp.lockTree.Delete(lock)
+		log.LogWarnf("fsmExpireLock: release expired lock: partitionID(%v) %v", mp.config.PartitionId, lock)
+	}
+	return proto.OpOk
+}
+
+func (mp *metaPartition) submitLockLease(op uint32, session string, time int64) (status uint8, err error) {
+	val, err := json.Marshal(&lockLeaseOp{Session: session, Time: time})
+	if err != nil {
+		return
+	}
+	resp, err := mp.submit(op, val)
+	if err != nil {
+		return
+	}
+	status = resp.(uint8)
+	return
+}
+
+// SetLock acquires or releases the lock through the raft log. It fails with OpLockConflictErr
+// without blocking if a conflicting lock is held.
+func (mp *metaPartition) SetLock(req *proto.SetLockRequest, p *Packet) (err error) {
+	info := req.Lock
+	if info == nil || info.Session == "" || info.Start > info.End ||
+		info.Type < proto.LockTypeRead || info.Type > proto.LockTypeUnlock {
+		err = fmt.Errorf("invalid lock")
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	if info.Type != proto.LockTypeUnlock && mp.getConflictLock(info) != nil {
+		p.PacketErrorWithBody(proto.OpLockConflictErr, nil)
+		return
+	}
+	if info.Type == proto.LockTypeUnlock && !mp.hasOwnedLock(info) {
+		// nothing to release, which is common when the files are closed
+		p.PacketOkReply()
+		return
+	}
+	lock := &lockItem{LockInfo: *info, Expire: time.Now().Unix() + proto.LockLeaseTimeout}
+	val, err := lock.Bytes()
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMSetLock, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
+// GetLock replies a lock which conflicts with the given lock.
+func (mp *metaPartition) GetLock(req *proto.GetLockRequest, p *Packet) (err error) {
+	if req.Lock == nil {
+		err = fmt.Errorf("invalid lock")
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	resp := &proto.GetLockResponse{Lock: mp.getConflictLock(req.Lock)}
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// LockHeartbeat renews the leases of the locks held by the session. It replies OpNotExistErr if
+// the session holds no locks in the partition.
+func (mp *metaPartition) LockHeartbeat(req *proto.LockHeartbeatRequest, p *Packet) (err error) {
+	status, err := mp.submitLockLease(opFSMLockHeartbeat, req.Session, time.Now().Unix()+proto.LockLeaseTimeout)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(status, nil)
+	return
+}
+
+// lockWorker releases the expired locks on the leader.
+func (mp *metaPartition) lockWorker() {
+	t := time.NewTicker(lockCheckInterval)
+	for {
+		select {
+		case <-mp.stopC:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if _, ok := mp.IsLeader(); !ok || mp.lockTree.Len() == 0 {
+			continue
+		}
+		var expired bool
+		now := time.Now().Unix()
+		mp.lockTree.GetTree().Ascend(func(i BtreeItem) bool {
+			expired = i.(*lockItem).Expire < now
+			return !expired
+		})
+		if !expired {
+			continue
+		}
+		if _, err := mp.submitLockLease(opFSMExpireLock, "", now); err != nil {
+			log.LogErrorf("lockWorker: release expired locks fail: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func newTestLock(session string, start, end uint64, typ uint8, expire int64) *lockItem {
+	return &lockItem{
+		LockInfo: proto.LockInfo{Inode: 1, Session: session, Owner: 1, Start: start, End: end, Type: typ},
+		Expire:   expire,
+	}
+}
+
+func TestMetaPartition_Lock(t *testing.T) {
+	mp := newTestPartition(1)
+	if status := mp.fsmSetLock(newTestLock("a", 0, 99, proto.LockTypeRead, 100)); status != proto.OpOk {
+		t.Fatalf("read lock fail: status(%v)", status)
+	}
+	if status := mp.fsmSetLock(newTestLock("b", 50, 149, proto.LockTypeRead, 100)); status != proto.OpOk {
+		t.Fatalf("shared read lock fail: status(%v)", status)
+	}
+	if status := mp.fsmSetLock(newTestLock("b", 0, 9, proto.LockTypeWrite, 100)); status != proto.OpLockConflictErr {
+		t.Fatalf("conflict write lock mismatch: status(%v)", status)
+	}
+	// flock locks never conflict with POSIX locks
+	flock := newTestLock("b", 0, proto.LockRangeMax, proto.LockTypeWrite, 100)
+	flock.Flock = true
+	if status := mp.fsmSetLock(flock); status != proto.OpOk {
+		t.Fatalf("flock fail: status(%v)", status)
+	}
+	if conflict := mp.getConflictLock(&newTestLock("c", 120, 200, proto.LockTypeWrite, 0).LockInfo); conflict == nil || conflict.Session != "b" {
+		t.Fatalf("get conflict lock mismatch: %v", conflict)
+	}
+
+	// unlock the middle of the range splits the lock
+	if status := mp.fsmSetLock(newTestLock("a", 10, 19, proto.LockTypeUnlock, 100)); status != proto.OpOk {
+		t.Fatalf("unlock fail: status(%v)", status)
+	}
+	var ranges [][2]uint64
+	mp.rangeInodeLocks(1, func(lock *lockItem) bool {
+		if lock.Session == "a" {
+			ranges = append(ranges, [2]uint64{lock.Start, lock.End})
+		}
+		return true
+	})
+	if len(ranges) != 2 || ranges[0] != [2]uint64{0, 9} || ranges[1] != [2]uint64{20, 99} {
+		t.Fatalf("split lock mismatch: %v", ranges)
+	}
+	if status := mp.fsmSetLock(newTestLock("c", 10, 19, proto.LockTypeWrite, 100)); status != proto.OpOk {
+		t.Fatalf("write lock on unlocked range fail: status(%v)", status)
+	}
+
+	// the locks of the session without heartbeats expire
+	if status := mp.fsmLockHeartbeat(&lockLeaseOp{Session: "a", Time: 200}); status != proto.OpOk {
+		t.Fatalf("heartbeat fail: status(%v)", status)
+	}
+	if status := mp.fsmLockHeartbeat(&lockLeaseOp{Session: "d", Time: 200}); status != proto.OpNotExistErr {
+		t.Fatalf("heartbeat without locks mismatch: status(%v)", status)
+	}
+	mp.fsmExpireLock(&lockLeaseOp{Time: 150})
+	if mp.lockTree.Len() != 2 {
+		t.Fatalf("locks after expiration mismatch: %v", mp.lockTree.Len())
+	}
+	if status := mp.fsmSetLock(newTestLock("b", 100, 149, proto.LockTypeWrite, 300)); status != proto.OpOk {
+		t.Fatalf("write lock after expiration fail: status(%v)", status)
+	}
+}
+
+func TestMetaPartition_StoreLock(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+	mp := newTestPartition(1)
+	mp.fsmSetLock(newTestLock("a", 0, proto.LockRangeMax, proto.LockTypeWrite, 100))
+	if _, err = mp.storeLock(rootDir, &storeMsg{lockTree: mp.lockTree.GetTree()}); err != nil {
+		t.Fatalf("store lock fail: err(%v)", err)
+	}
+	loaded := newTestPartition(1)
+	if err = loaded.loadLock(rootDir); err != nil {
+		t.Fatalf("load lock fail: err(%v)", err)
+	}
+	conflict := loaded.getConflictLock(&newTestLock("b", 10, 10, proto.LockTypeRead, 0).LockInfo)
+	if conflict == nil || conflict.Session != "a" || conflict.End != proto.LockRangeMax {
+		t.Fatalf("loaded lock mismatch: %v", conflict)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"crypto/hmac"
+	"errors"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+var errObjectLocked = errors.New("object locked")
+
+// The object lock (WORM) is enforced by the meta partition for all clients. A locked inode
+// is under legal hold or its retention period has not expired, and it cannot be destroyed,
+// truncated or appended with extents. The leader checks the lock state before the operation is
+// submitted to fail fast, and the checks are repeated when the raft log is applied, with the time
+// carried by the log, so that the result is the same on all the replicas.
+// The dentries and the extended attributes which would destroy a locked inode or weaken its lock
+// are refused when applied. A dentry only protects the inode of the same partition, and the dentry
+// of an inode in another partition is removed together with the unlinking of the inode in a
+// transaction by the clients, which is refused by the partition of the inode.
+// The overwrite of the existing extents goes to the data nodes directly, so the clients check the
+// lock of the inode before opening it for writing, truncating it or overwriting its extents.
+
+func (mp *metaPartition) getXAttrValue(ino uint64, key string) (value []byte) {
+	item := mp.extendTree.Get(NewExtend(ino))
+	if item == nil {
+		return nil
+	}
+	value, _ = item.(*Extend).Get([]byte(key))
+	return
+}
+
+// isLockChangeAllowed returns whether the update of the object lock attribute, which releases the
+// legal hold or shortens the retention in GOVERNANCE mode, is signed by an ObjectNode with the
+// object lock key of the meta node. The other clients are never allowed to weaken the lock.
+func (mp *metaPartition) isLockChangeAllowed(ino uint64, key, value, sign string, timestamp int64) bool {
+	if sign == "" || len(objectLockKey) == 0 {
+		return false
+	}
+	now := Now.GetCurrentTime().Unix()
+	if timestamp < now-proto.ObjectLockChangeExpiration || timestamp > now+proto.ObjectLockChangeExpiration {
+		return false
+	}
+	expected := proto.SignObjectLockChange(objectLockKey, mp.config.VolName, ino, key, value, timestamp)
+	return hmac.Equal([]byte(sign), []byte(expected))
+}
+
+// isObjectLocked returns whether the inode is locked at now.
+func (mp *metaPartition) isObjectLocked(ino uint64, now int64) bool {
+	item := mp.extendTree.Get(NewExtend(ino))
+	if item == nil {
+		return false
+	}
+	extend := item.(*Extend)
+	legalHold, _ := extend.Get([]byte(proto.XAttrKeyOSSLegalHold))
+	retention, _ := extend.Get([]byte(proto.XAttrKeyOSSRetention))
+	return proto.IsObjectLocked(legalHold, retention, now)
+}
+
+// isUnlinkDenied returns whether unlinking the inode would destroy a locked file.
+// Unlinking the extra links of the file, such as the one left behind by rename, is allowed.
+func (mp *metaPartition) isUnlinkDenied(ino uint64, now int64) bool {
+	if !mp.isObjectLocked(ino, now) {
+		return false
+	}
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil {
+		return false
+	}
+	inode := item.(*Inode)
+	return proto.IsRegular(inode.Type) && inode.GetNLink() <= 1
+}
+
+// isDeleteDenied returns whether deleting the inode would destroy a locked file. The inodes which
+// have been unlinked or evicted are being reclaimed, and are deleted as usual.
+func (mp *metaPartition) isDeleteDenied(ino uint64, now int64) bool {
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil {
+		return false
+	}
+	inode := item.(*Inode)
+	if inode.ShouldDelete() || inode.GetNLink() == 0 {
+		return false
+	}
+	return mp.isObjectLocked(ino, now)
+}
+
+// isDentryRemovalDenied returns whether removing the dentry, or pointing it to the new inode,
+// would destroy a locked file of the partition. The new inode is zero for the removal.
+func (mp *metaPartition) isDentryRemovalDenied(parentID uint64, name string, newInode uint64, now int64) bool {
+	d, status := mp.getDentry(&Dentry{ParentId: parentID, Name: name})
+	if status != proto.OpOk || d.Inode == newInode {
+		return false
+	}
+	return mp.isUnlinkDenied(d.Inode, now)
+}
+
+// isXAttrUpdateDenied returns whether the update of the extended attribute would weaken the lock
+// of the inode, or the value is invalid. An empty value means the removal of the attribute.
+// The legal hold can only be released, and the retention in GOVERNANCE mode can only be shortened,
+// if allowLockChange is set after verifying the signature of the ObjectNode by isLockChangeAllowed.
+// The active retention in COMPLIANCE mode can only be extended.
+func (mp *metaPartition) isXAttrUpdateDenied(ino uint64, key string, value []byte, allowLockChange bool, now int64) bool {
+	switch key {
+	case proto.XAttrKeyOSSLegalHold:
+		if len(value) > 0 && string(value) != proto.LegalHoldStatusOn && string(value) != proto.LegalHoldStatusOff {
+			return true
+		}
+		if allowLockChange || string(value) == proto.LegalHoldStatusOn {
+			return false
+		}
+		return string(mp.getXAttrValue(ino, key)) == proto.LegalHoldStatusOn
+	case proto.XAttrKeyOSSRetention:
+		// a malformed retention would lock the inode forever
+		if len(value) > 0 {
+			if _, err := proto.ParseObjectRetention(value); err != nil {
+				return true
+			}
+		}
+		return !proto.AllowRetentionUpdate(mp.getXAttrValue(ino, key), value, now, allowLockChange)
+	}
+	return false
+}
+
+// isExtendUpdateDenied checks all the attributes of the extend by isXAttrUpdateDenied.
+func (mp *metaPartition) isExtendUpdateDenied(extend *Extend, remove, allowLockChange bool, now int64) (denied bool) {
+	extend.Range(func(key, value []byte) bool {
+		if remove {
+			value = nil
+		}
+		denied = mp.isXAttrUpdateDenied(extend.inode, string(key), value, allowLockChange, now)
+		return !denied
+	})
+	return
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartition_ObjectLock(t *testing.T) {
+	mp := newTestPartition(1)
+	now := Now.GetCurrentTime().Unix()
+
+	const (
+		unlocked uint64 = iota + 1
+		held
+		governance
+		compliance
+		expired
+	)
+	for ino := unlocked; ino <= expired; ino++ {
+		mp.inodeTree.ReplaceOrInsert(NewInode(ino, proto.Mode(0644)), true)
+		mp.extendTree.ReplaceOrInsert(NewExtend(ino), true)
+	}
+	putXAttr := func(ino uint64, key string, value []byte) {
+		mp.extendTree.Get(NewExtend(ino)).(*Extend).Put([]byte(key), value)
+	}
+	putXAttr(held, proto.XAttrKeyOSSLegalHold, []byte(proto.LegalHoldStatusOn))
+	putXAttr(governance, proto.XAttrKeyOSSRetention,
+		(&proto.ObjectRetention{Mode: proto.ObjectLockModeGovernance, RetainUntil: now + 3600}).Encode())
+	putXAttr(compliance, proto.XAttrKeyOSSRetention,
+		(&proto.ObjectRetention{Mode: proto.ObjectLockModeCompliance, RetainUntil: now + 3600}).Encode())
+	putXAttr(expired, proto.XAttrKeyOSSRetention,
+		(&proto.ObjectRetention{Mode: proto.ObjectLockModeCompliance, RetainUntil: now - 1}).Encode())
+
+	expects := map[uint64]bool{unlocked: false, held: true, governance: true, compliance: true, expired: false}
+	for ino, locked := range expects {
+		if mp.isObjectLocked(ino, now) != locked {
+			t.Fatalf("inode(%v) locked mismatch: expect(%v)", ino, locked)
+		}
+		if mp.isUnlinkDenied(ino, now) != locked {
+			t.Fatalf("inode(%v) unlink denied mismatch: expect(%v)", ino, locked)
+		}
+	}
+
+	// The extra link of a locked file can be removed.
+	mp.inodeTree.Get(NewInode(compliance, 0)).(*Inode).IncNLink()
+	if mp.isUnlinkDenied(compliance, now) {
+		t.Fatalf("unlink of the extra link should be allowed")
+	}
+
+	// The retention in COMPLIANCE mode can only be extended.
+	extended := (&proto.ObjectRetention{Mode: proto.ObjectLockModeCompliance, RetainUntil: now + 7200}).Encode()
+	shortened := (&proto.ObjectRetention{Mode: proto.ObjectLockModeCompliance, RetainUntil: now + 60}).Encode()
+	toGovernance := (&proto.ObjectRetention{Mode: proto.ObjectLockModeGovernance, RetainUntil: now + 7200}).Encode()
+	if mp.isXAttrUpdateDenied(compliance, proto.XAttrKeyOSSRetention, extended, false, now) {
+		t.Fatalf("extending retention should be allowed")
+	}
+	for _, value := range [][]byte{shortened, toGovernance, nil} {
+		if !mp.isXAttrUpdateDenied(compliance, proto.XAttrKeyOSSRetention, value, true, now) {
+			t.Fatalf("weakening retention should be denied: value(%s)", value)
+		}
+	}
+
+	// The retention in GOVERNANCE mode and the legal hold can only be weakened through the ObjectNode.
+	if !mp.isXAttrUpdateDenied(governance, proto.XAttrKeyOSSRetention, nil, false, now) {
+		t.Fatalf("removing retention in GOVERNANCE mode should be denied")
+	}
+	if mp.isXAttrUpdateDenied(governance, proto.XAttrKeyOSSRetention, nil, true, now) {
+		t.Fatalf("removing retention in GOVERNANCE mode should be allowed with permission")
+	}
+	if mp.isXAttrUpdateDenied(governance, proto.XAttrKeyOSSRetention, extended, false, now) {
+		t.Fatalf("changing retention from GOVERNANCE to COMPLIANCE should be allowed")
+	}
+	for _, value := range [][]byte{nil, []byte(proto.LegalHoldStatusOff)} {
+		if !mp.isXAttrUpdateDenied(held, proto.XAttrKeyOSSLegalHold, value, false, now) {
+			t.Fatalf("releasing legal hold should be denied: value(%s)", value)
+		}
+	}
+	if mp.isXAttrUpdateDenied(held, proto.XAttrKeyOSSLegalHold, nil, true, now) {
+		t.Fatalf("releasing legal hold should be allowed with permission")
+	}
+	if mp.isXAttrUpdateDenied(unlocked, proto.XAttrKeyOSSLegalHold, []byte(proto.LegalHoldStatusOn), false, now) {
+		t.Fatalf("placing legal hold should be allowed")
+	}
+	if mp.isXAttrUpdateDenied(expired, proto.XAttrKeyOSSRetention, nil, false, now) {
+		t.Fatalf("removing expired retention should be allowed")
+	}
+	if mp.isXAttrUpdateDenied(compliance, "user.key", nil, false, now) {
+		t.Fatalf("other attributes should not be restricted")
+	}
+
+	// The dentry of a locked file can not be removed or pointed to another inode.
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "held", Inode: held, Type: proto.Mode(0644)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "unlocked", Inode: unlocked, Type: proto.Mode(0644)}, true)
+	if !mp.isDentryRemovalDenied(1, "held", 0, now) || !mp.isDentryRemovalDenied(1, "held", unlocked, now) {
+		t.Fatalf("removing the dentry of a locked file should be denied")
+	}
+	if mp.isDentryRemovalDenied(1, "held", held, now) || mp.isDentryRemovalDenied(1, "unlocked", 0, now) {
+		t.Fatalf("removing the dentry of an unlocked file should be allowed")
+	}
+
+	// The extents and the inode of a locked file are protected when the raft log is applied.
+	appended := NewInode(held, 0)
+	appended.Extents.Append(proto.ExtentKey{PartitionId: 1, ExtentId: 1, Size: 10})
+	if mp.fsmAppendExtents(appended) != proto.OpNotPerm || mp.fsmAppendExtentsWithCheck(appended) != proto.OpNotPerm {
+		t.Fatalf("appending extents to a locked file should be denied")
+	}
+	if resp := mp.fsmExtentsTruncate(NewInode(held, 0)); resp.Status != proto.OpNotPerm {
+		t.Fatalf("truncating a locked file should be denied: status(%v)", resp.Status)
+	}
+	mp.freeList = newFreeList()
+	encoded, err := InodeBatch{NewInode(held, 0), NewInode(unlocked, 0)}.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.internalDeleteBatch(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if mp.inodeTree.Get(NewInode(held, 0)) == nil || mp.inodeTree.Get(NewInode(unlocked, 0)) != nil {
+		t.Fatalf("only the unlocked inode should be deleted")
+	}
+}
+
+func TestMetaPartition_ObjectLockChangeSign(t *testing.T) {
+	mp := newTestPartition(1)
+	key, value := proto.XAttrKeyOSSLegalHold, proto.LegalHoldStatusOff
+	now := Now.GetCurrentTime().Unix()
+	sign := proto.SignObjectLockChange([]byte("secret"), "vol", 1, key, value, now)
+
+	// The changes are refused if the meta node has no key.
+	if mp.isLockChangeAllowed(1, key, value, sign, now) {
+		t.Fatalf("lock change should be refused without the key")
+	}
+	defer func(saved []byte) { objectLockKey = saved }(objectLockKey)
+	objectLockKey = []byte("secret")
+	if !mp.isLockChangeAllowed(1, key, value, sign, now) {
+		t.Fatalf("signed lock change should be allowed")
+	}
+	if mp.isLockChangeAllowed(2, key, value, sign, now) || mp.isLockChangeAllowed(1, key, "", sign, now) ||
+		mp.isLockChangeAllowed(1, key, value, "", now) {
+		t.Fatalf("lock change with mismatched signature should be refused")
+	}
+	stale := now - proto.ObjectLockChangeExpiration - 1
+	if mp.isLockChangeAllowed(1, key, value, proto.SignObjectLockChange(objectLockKey, "vol", 1, key, value, stale), stale) {
+		t.Fatalf("expired lock change should be refused")
+	}
+}
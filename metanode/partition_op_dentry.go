@@ -190,6 +190,37 @@ func (mp *metaPartition) ReadDir(req *ReadDirReq, p *Packet) (err error) {
 	return
 }
 
+// ReadDirLimit reads a page of the directory based on the given request.
+func (mp *metaPartition) ReadDirLimit(req *ReadDirLimitReq, p *Packet) (err error) {
+	resp := mp.readDirLimit(req)
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// ListPrefix lists the dentries under the directories of the request by prefix.
+func (mp *metaPartition) ListPrefix(req *ListPrefixReq, p *Packet) (err error) {
+	for _, frame := range req.Frames {
+		if !mp.isInoInRange(frame.Inode) {
+			err = fmt.Errorf("inode %v out of partition range", frame.Inode)
+			p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+			return
+		}
+	}
+	resp := mp.listPrefix(req)
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
 // Lookup looks up the given dentry from the request.
 func (mp *metaPartition) Lookup(req *LookupReq, p *Packet) (err error) {
 	dentry := &Dentry{
@@ -21,12 +21,30 @@ import (
 )
 
 func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err error) {
+	if req.Key == proto.XAttrKeyQuota {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errQuotaXAttr.Error()))
+		return
+	}
+	allowLockChange := mp.isLockChangeAllowed(req.Inode, req.Key, req.Value, req.LockChangeSign, req.LockChangeTime)
+	if mp.isXAttrUpdateDenied(req.Inode, req.Key, []byte(req.Value), allowLockChange, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
+	var op uint32 = opFSMSetXAttr
+	if allowLockChange {
+		op = opFSMSetXAttrAllowLockChange
+	}
 	var extend = NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), []byte(req.Value))
-	if _, err = mp.putExtend(opFSMSetXAttr, extend); err != nil {
+	var resp interface{}
+	if resp, err = mp.putExtend(op, extend); err != nil {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
 	}
+	if status := resp.(uint8); status != proto.OpOk {
+		p.PacketErrorWithBody(status, []byte(errObjectLocked.Error()))
+		return
+	}
 	p.PacketOkReply()
 	return
 }
@@ -87,12 +105,30 @@ func (mp *metaPartition) BatchGetXAttr(req *proto.BatchGetXAttrRequest, p *Packe
 }
 
 func (mp *metaPartition) RemoveXAttr(req *proto.RemoveXAttrRequest, p *Packet) (err error) {
+	if req.Key == proto.XAttrKeyQuota {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errQuotaXAttr.Error()))
+		return
+	}
+	allowLockChange := mp.isLockChangeAllowed(req.Inode, req.Key, "", req.LockChangeSign, req.LockChangeTime)
+	if mp.isXAttrUpdateDenied(req.Inode, req.Key, nil, allowLockChange, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
+	var op uint32 = opFSMRemoveXAttr
+	if allowLockChange {
+		op = opFSMRemoveXAttrAllowLockChange
+	}
 	var extend = NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), nil)
-	if _, err = mp.putExtend(opFSMRemoveXAttr, extend); err != nil {
+	var resp interface{}
+	if resp, err = mp.putExtend(op, extend); err != nil {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
 	}
+	if status := resp.(uint8); status != proto.OpOk {
+		p.PacketErrorWithBody(status, []byte(errObjectLocked.Error()))
+		return
+	}
 	p.PacketOkReply()
 	return
 }
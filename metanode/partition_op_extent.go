@@ -23,6 +23,14 @@ import (
 
 // ExtentAppend appends an extent.
 func (mp *metaPartition) ExtentAppend(req *proto.AppendExtentKeyRequest, p *Packet) (err error) {
+	if mp.isObjectLocked(req.Inode, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
+	if mp.isBytesQuotaExceeded(req.Inode) {
+		p.PacketErrorWithBody(proto.OpQuotaExceededErr, []byte(errQuotaExceeded.Error()))
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	ext := req.Extent
 	ino.Extents.Append(ext)
@@ -43,6 +51,14 @@ func (mp *metaPartition) ExtentAppend(req *proto.AppendExtentKeyRequest, p *Pack
 // ExtentAppendWithCheck appends an extent with discard extents check.
 // Format: one valid extent key followed by non or several discard keys.
 func (mp *metaPartition) ExtentAppendWithCheck(req *proto.AppendExtentKeyWithCheckRequest, p *Packet) (err error) {
+	if mp.isObjectLocked(req.Inode, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
+	if mp.isBytesQuotaExceeded(req.Inode) {
+		p.PacketErrorWithBody(proto.OpQuotaExceededErr, []byte(errQuotaExceeded.Error()))
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	ext := req.Extent
 	ino.Extents.Append(ext)
@@ -96,6 +112,14 @@ func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (e
 
 // ExtentsTruncate truncates an extent.
 func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (err error) {
+	if mp.isObjectLocked(req.Inode, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
+	if mp.isTruncateGrowing(req.Inode, req.Size) && mp.isBytesQuotaExceeded(req.Inode) {
+		p.PacketErrorWithBody(proto.OpQuotaExceededErr, []byte(errQuotaExceeded.Error()))
+		return
+	}
 	ino := NewInode(req.Inode, proto.Mode(os.ModePerm))
 	ino.Size = req.Size
 	val, err := ino.Marshal()
@@ -114,6 +138,14 @@ func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (er
 }
 
 func (mp *metaPartition) BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error) {
+	if mp.isObjectLocked(req.Inode, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
+	if mp.isBytesQuotaExceeded(req.Inode) {
+		p.PacketErrorWithBody(proto.OpQuotaExceededErr, []byte(errQuotaExceeded.Error()))
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	extents := req.Extents
 	for _, extent := range extents {
@@ -47,6 +47,15 @@ func replyInfo(info *proto.InodeInfo, ino *Inode) bool {
 
 // CreateInode returns a new inode.
 func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet) (err error) {
+	quotaIds, err := mp.getDirQuotaIds(req.ParentId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if mp.isFilesQuotaExceeded(quotaIds) {
+		p.PacketErrorWithBody(proto.OpQuotaExceededErr, []byte(errQuotaExceeded.Error()))
+		return
+	}
 	inoID, err := mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
@@ -61,7 +70,17 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet) (err error) {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
 	}
-	resp, err := mp.submit(opFSMCreateInode, val)
+	var resp interface{}
+	if len(quotaIds) > 0 || req.ParentId != 0 {
+		var data []byte
+		if data, err = json.Marshal(&inodeQuota{Inode: val, QuotaIds: quotaIds, ParentId: req.ParentId}); err != nil {
+			p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+			return
+		}
+		resp, err = mp.submit(opFSMCreateInodeQuota, data)
+	} else {
+		resp, err = mp.submit(opFSMCreateInode, val)
+	}
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
 		return
@@ -89,6 +108,10 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet) (err error) {
 
 // DeleteInode deletes an inode.
 func (mp *metaPartition) UnlinkInode(req *UnlinkInoReq, p *Packet) (err error) {
+	if mp.isUnlinkDenied(req.Inode, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -127,6 +150,10 @@ func (mp *metaPartition) UnlinkInodeBatch(req *BatchUnlinkInoReq, p *Packet) (er
 	var inodes InodeBatch
 
 	for _, id := range req.Inodes {
+		if mp.isUnlinkDenied(id, Now.GetCurrentTime().Unix()) {
+			p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+			return
+		}
 		inodes = append(inodes, NewInode(id, 0))
 	}
 
@@ -321,6 +348,10 @@ func (mp *metaPartition) GetInodeTree() *BTree {
 }
 
 func (mp *metaPartition) DeleteInode(req *proto.DeleteInodeRequest, p *Packet) (err error) {
+	if mp.isObjectLocked(req.Inode, Now.GetCurrentTime().Unix()) {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+		return
+	}
 	var bytes = make([]byte, 8)
 	binary.BigEndian.PutUint64(bytes, req.Inode)
 	_, err = mp.submit(opFSMInternalDeleteInode, bytes)
@@ -340,6 +371,10 @@ func (mp *metaPartition) DeleteInodeBatch(req *proto.DeleteInodeBatchRequest, p
 	var inodes InodeBatch
 
 	for _, id := range req.Inodes {
+		if mp.isObjectLocked(id, Now.GetCurrentTime().Unix()) {
+			p.PacketErrorWithBody(proto.OpNotPerm, []byte(errObjectLocked.Error()))
+			return
+		}
 		inodes = append(inodes, NewInode(id, 0))
 	}
 
@@ -0,0 +1,440 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+var (
+	errQuotaExceeded = errors.New("quota exceeded")
+	errQuotaXAttr    = errors.New("quota tags are kept by the meta node")
+)
+
+// The directory quotas are enforced by the meta partitions. The inodes under a directory with
+// quotas are tagged with the quota IDs in their extended attributes. The leader of the partition
+// creating an inode reads the quotas of the parent directory, from the partition of the directory
+// if it is not local, and tags the new inode with them. A file moved into another directory by a
+// rename is retagged with the quotas of the directory when the transaction commits, while the
+// directories and the hard links are not allowed to cross the quotas, since the inodes under the
+// directory and the other links could not be retagged.
+//
+// Each partition counts the usage of the quotas by its inodes when the raft log is applied, and
+// rebuilds the usage when it is loaded. The leader reports the usage to the master with the
+// heartbeat, and the master sums up the usage of all the meta partitions of the volume and tells
+// the meta nodes whether the usage reaches the limits with the next heartbeat. The leader rejects
+// creating inodes, appending extents and growing files by truncate under the quotas which reach
+// the limits, so the usage may exceed the limits by the amount written within a heartbeat interval.
+
+// volQuotas keeps the quotas of the volumes, which are sent by the master with the heartbeat.
+type volQuotas struct {
+	sync.RWMutex
+	quotas map[string]map[uint64]*proto.QuotaHeartbeatInfo
+	idMark uint64 // the quotas with the IDs up to it are deleted if not sent
+}
+
+func newVolQuotas() *volQuotas {
+	return &volQuotas{quotas: make(map[string]map[uint64]*proto.QuotaHeartbeatInfo)}
+}
+
+func (q *volQuotas) update(quotas map[string][]*proto.QuotaHeartbeatInfo, idMark uint64) {
+	var updated = make(map[string]map[uint64]*proto.QuotaHeartbeatInfo, len(quotas))
+	for volName, infos := range quotas {
+		updated[volName] = make(map[uint64]*proto.QuotaHeartbeatInfo, len(infos))
+		for _, info := range infos {
+			updated[volName][info.QuotaId] = info
+		}
+	}
+	q.Lock()
+	q.quotas = updated
+	q.idMark = idMark
+	q.Unlock()
+}
+
+func (q *volQuotas) get(volName string) (quotas map[uint64]*proto.QuotaHeartbeatInfo, idMark uint64) {
+	q.RLock()
+	defer q.RUnlock()
+	return q.quotas[volName], q.idMark
+}
+
+// quotaUsages counts the inodes of the partition tagged with each quota, and the bytes of the
+// regular files among them.
+type quotaUsages struct {
+	sync.Mutex
+	usages map[uint64]*proto.QuotaUsage
+}
+
+func (u *quotaUsages) add(quotaIds []uint64, files, bytes int64) {
+	if len(quotaIds) == 0 || (files == 0 && bytes == 0) {
+		return
+	}
+	u.Lock()
+	defer u.Unlock()
+	if u.usages == nil {
+		u.usages = make(map[uint64]*proto.QuotaUsage)
+	}
+	for _, id := range quotaIds {
+		usage, ok := u.usages[id]
+		if !ok {
+			usage = &proto.QuotaUsage{QuotaId: id}
+			u.usages[id] = usage
+		}
+		usage.UsedFiles += uint64(files)
+		usage.UsedBytes += uint64(bytes)
+	}
+}
+
+func (u *quotaUsages) isEmpty() bool {
+	u.Lock()
+	defer u.Unlock()
+	return len(u.usages) == 0
+}
+
+func (u *quotaUsages) reset(usages map[uint64]*proto.QuotaUsage) {
+	u.Lock()
+	u.usages = usages
+	u.Unlock()
+}
+
+const dirQuotaCacheExpiration = 10 // seconds
+
+// dirQuotaCache caches the quota tags of the directories in the other partitions, which are read
+// by each creation under them. The cache is dropped when the master reports a new quota ID mark,
+// so the inodes are tagged with a new quota after the next heartbeat at the latest.
+type dirQuotaCache struct {
+	sync.Mutex
+	entries map[uint64]*dirQuotaEntry
+	idMark  uint64
+}
+
+type dirQuotaEntry struct {
+	value    []byte
+	expireAt int64
+}
+
+func (c *dirQuotaCache) get(dir, idMark uint64, now int64) (value []byte, ok bool) {
+	c.Lock()
+	defer c.Unlock()
+	if c.idMark != idMark {
+		return nil, false
+	}
+	entry, ok := c.entries[dir]
+	if !ok || entry.expireAt < now {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *dirQuotaCache) put(dir, idMark uint64, value []byte, now int64) {
+	c.Lock()
+	defer c.Unlock()
+	if c.entries == nil || c.idMark != idMark {
+		c.entries = make(map[uint64]*dirQuotaEntry)
+		c.idMark = idMark
+	}
+	for ino, entry := range c.entries {
+		if entry.expireAt < now {
+			delete(c.entries, ino)
+		}
+	}
+	c.entries[dir] = &dirQuotaEntry{value: value, expireAt: now + dirQuotaCacheExpiration}
+}
+
+// inodeQuota is the raft log to create an inode under the directory, tagged with the quota IDs.
+type inodeQuota struct {
+	Inode    []byte   `json:"ino"`
+	QuotaIds []uint64 `json:"qids"`
+	ParentId uint64   `json:"pino,omitempty"`
+}
+
+// quotaTag is the raft log to tag an inode with a quota.
+type quotaTag struct {
+	Inode   uint64 `json:"ino"`
+	QuotaId uint64 `json:"qid"`
+}
+
+// getQuotas returns the quotas of the volume, and the mark of the quota IDs sent by the master.
+func (mp *metaPartition) getQuotas() (map[uint64]*proto.QuotaHeartbeatInfo, uint64) {
+	if mp.manager == nil || mp.manager.quotas == nil {
+		return nil, 0
+	}
+	return mp.manager.quotas.get(mp.config.VolName)
+}
+
+func (mp *metaPartition) getInodeQuotaIds(ino uint64) []uint64 {
+	return proto.DecodeQuotaIds(mp.getXAttrValue(ino, proto.XAttrKeyQuota))
+}
+
+// filterQuotaIds drops the IDs of the quotas which have been deleted. The IDs of the quotas created
+// after the last heartbeat are kept.
+func (mp *metaPartition) filterQuotaIds(quotaIds []uint64) (ids []uint64) {
+	quotas, idMark := mp.getQuotas()
+	for _, id := range quotaIds {
+		if _, ok := quotas[id]; ok || id > idMark {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
+// getDirQuotaIds returns the IDs of the quotas of the directory, which the inodes created or
+// moved under it are tagged with. The directory may be in another partition, so it is not read if
+// the volume has no quotas, and the inodes are not tagged with a new quota of such a volume before
+// the next heartbeat. The quota tags of the directories in the other partitions are cached.
+func (mp *metaPartition) getDirQuotaIds(dir uint64) (ids []uint64, err error) {
+	quotas, idMark := mp.getQuotas()
+	if dir == 0 || len(quotas) == 0 {
+		return nil, nil
+	}
+	var value []byte
+	if mp.isInoInRange(dir) {
+		value = mp.getXAttrValue(dir, proto.XAttrKeyQuota)
+	} else {
+		now := Now.GetCurrentTime().Unix()
+		var ok bool
+		if value, ok = mp.dirQuotaCache.get(dir, idMark, now); !ok {
+			if value, err = mp.getRemoteXAttr(dir, proto.XAttrKeyQuota); err != nil {
+				return nil, fmt.Errorf("get quotas of directory(%v) fail: %v", dir, err)
+			}
+			mp.dirQuotaCache.put(dir, idMark, value, now)
+		}
+	}
+	return mp.filterQuotaIds(proto.DecodeQuotaIds(value)), nil
+}
+
+// isFilesQuotaExceeded returns whether any of the quotas reaches the limit on the inode count.
+func (mp *metaPartition) isFilesQuotaExceeded(quotaIds []uint64) bool {
+	if len(quotaIds) == 0 {
+		return false
+	}
+	quotas, _ := mp.getQuotas()
+	for _, id := range quotaIds {
+		if info, ok := quotas[id]; ok && info.LimitedFiles {
+			return true
+		}
+	}
+	return false
+}
+
+// isBytesQuotaExceeded returns whether any of the quotas of the inode reaches the limit on the bytes.
+func (mp *metaPartition) isBytesQuotaExceeded(ino uint64) bool {
+	quotas, _ := mp.getQuotas()
+	if len(quotas) == 0 {
+		return false
+	}
+	for _, id := range mp.getInodeQuotaIds(ino) {
+		if info, ok := quotas[id]; ok && info.LimitedBytes {
+			return true
+		}
+	}
+	return false
+}
+
+// isTruncateGrowing returns whether the truncate grows the file.
+func (mp *metaPartition) isTruncateGrowing(ino uint64, size uint64) bool {
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil {
+		return false
+	}
+	return size > item.(*Inode).Size
+}
+
+// resolveTxQuotas resolves the quotas of the directories which the inodes of the transaction are
+// moved or linked into, before the transaction is prepared through the raft log. A file moved into
+// a directory with other quotas is retagged when the transaction commits, while a directory or a
+// hard link crossing the quotas is refused. A directory with quotas keeps its own quotas.
+func (mp *metaPartition) resolveTxQuotas(ops []*proto.TxOp) (status uint8, err error) {
+	for _, op := range ops {
+		op.QuotaIds = nil
+		if op.Type != proto.TxOpMoveInode && (op.Type != proto.TxOpLinkInode || op.ParentId == 0) {
+			continue
+		}
+		if op.Type == proto.TxOpMoveInode && op.KeepQuota {
+			// the deleted inode is counted in its quotas until it is purged from the trash
+			op.QuotaIds = mp.getInodeQuotaIds(op.Inode)
+			continue
+		}
+		item := mp.inodeTree.Get(NewInode(op.Inode, 0))
+		if item == nil {
+			// refused when the transaction is prepared
+			continue
+		}
+		inode := item.(*Inode)
+		var ids []uint64
+		if ids, err = mp.getDirQuotaIds(op.ParentId); err != nil {
+			return
+		}
+		quotas, _ := mp.getQuotas()
+		if proto.IsDir(inode.Type) {
+			for id, info := range quotas {
+				if info.Inode == op.Inode {
+					ids = proto.MergeQuotaIds(ids, id)
+				}
+			}
+		}
+		current := mp.filterQuotaIds(mp.getInodeQuotaIds(op.Inode))
+		if !proto.EqualQuotaIds(current, ids) {
+			if op.Type == proto.TxOpLinkInode || !proto.IsRegular(inode.Type) {
+				return proto.OpQuotaCrossErr, nil
+			}
+			for _, id := range ids {
+				info, ok := quotas[id]
+				if ok && !proto.ContainsQuotaId(current, id) && (info.LimitedFiles || (info.LimitedBytes && inode.Size > 0)) {
+					return proto.OpQuotaExceededErr, nil
+				}
+			}
+		}
+		op.QuotaIds = ids
+	}
+	return proto.OpOk, nil
+}
+
+// GetQuotaUsages returns the usage of the quotas of the volume in this meta partition. An inode
+// is counted as a file of each quota it is tagged with, and the size of a regular file is counted
+// as the used bytes.
+func (mp *metaPartition) GetQuotaUsages() (usages []*proto.QuotaUsage) {
+	quotas, _ := mp.getQuotas()
+	if len(quotas) == 0 {
+		return nil
+	}
+	mp.quotaUsages.Lock()
+	defer mp.quotaUsages.Unlock()
+	for id, usage := range mp.quotaUsages.usages {
+		if _, ok := quotas[id]; ok {
+			copied := *usage
+			usages = append(usages, &copied)
+		}
+	}
+	return
+}
+
+func quotaBytes(inode *Inode) int64 {
+	if !proto.IsRegular(inode.Type) {
+		return 0
+	}
+	return int64(inode.Size)
+}
+
+// rebuildQuotaUsages counts the usage of the quotas from the inodes after the partition is loaded.
+func (mp *metaPartition) rebuildQuotaUsages() {
+	var (
+		usages    quotaUsages
+		inodeTree = mp.getInodeTree()
+	)
+	mp.extendTree.GetTree().Ascend(func(i BtreeItem) bool {
+		extend := i.(*Extend)
+		value, ok := extend.Get([]byte(proto.XAttrKeyQuota))
+		if !ok {
+			return true
+		}
+		item := inodeTree.Get(NewInode(extend.inode, 0))
+		if item == nil || item.(*Inode).ShouldDelete() {
+			return true
+		}
+		usages.add(proto.DecodeQuotaIds(value), 1, quotaBytes(item.(*Inode)))
+		return true
+	})
+	mp.quotaUsages.reset(usages.usages)
+}
+
+// updateQuotaUsage adds the changes of the inode to the usage of its quotas.
+func (mp *metaPartition) updateQuotaUsage(ino uint64, files, bytes int64) {
+	if (files == 0 && bytes == 0) || mp.quotaUsages.isEmpty() {
+		// no inode of the partition is tagged
+		return
+	}
+	mp.quotaUsages.add(mp.getInodeQuotaIds(ino), files, bytes)
+}
+
+// updateQuotaBytes adds the size change of the regular file to the usage of its quotas.
+func (mp *metaPartition) updateQuotaBytes(inode *Inode, oldSize uint64) {
+	if proto.IsRegular(inode.Type) {
+		mp.updateQuotaUsage(inode.Inode, 0, int64(inode.Size)-int64(oldSize))
+	}
+}
+
+// setInodeQuotaIds retags the inode, and moves it from the usage of the old quotas to the new ones.
+func (mp *metaPartition) setInodeQuotaIds(inode *Inode, quotaIds []uint64) {
+	oldIds := mp.getInodeQuotaIds(inode.Inode)
+	if proto.EqualQuotaIds(oldIds, quotaIds) {
+		return
+	}
+	bytes := quotaBytes(inode)
+	mp.quotaUsages.add(oldIds, -1, -bytes)
+	extend := NewExtend(inode.Inode)
+	if len(quotaIds) == 0 {
+		extend.Put([]byte(proto.XAttrKeyQuota), nil)
+		_ = mp.fsmRemoveXAttr(extend)
+	} else {
+		extend.Put([]byte(proto.XAttrKeyQuota), proto.EncodeQuotaIds(quotaIds))
+		_ = mp.fsmSetXAttr(extend)
+	}
+	mp.quotaUsages.add(quotaIds, 1, bytes)
+}
+
+func (mp *metaPartition) fsmCreateInodeQuota(ino *Inode, quotaIds []uint64) (status uint8) {
+	if status = mp.fsmCreateInode(ino); status != proto.OpOk {
+		return
+	}
+	mp.setInodeQuotaIds(ino, quotaIds)
+	return
+}
+
+// fsmMoveInodeQuota retags the inode moved into another directory by a transaction.
+func (mp *metaPartition) fsmMoveInodeQuota(ino uint64, quotaIds []uint64) (status uint8) {
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil || item.(*Inode).ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	mp.setInodeQuotaIds(item.(*Inode), quotaIds)
+	return proto.OpOk
+}
+
+func (mp *metaPartition) fsmTagQuota(tag *quotaTag) (status uint8) {
+	item := mp.inodeTree.Get(NewInode(tag.Inode, 0))
+	if item == nil || item.(*Inode).ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	ids := mp.getInodeQuotaIds(tag.Inode)
+	mp.setInodeQuotaIds(item.(*Inode), proto.MergeQuotaIds(ids, tag.QuotaId))
+	return proto.OpOk
+}
+
+// TagQuota tags the inode with the quota. The tags can only be added through the request, which
+// is used to apply a new quota to the inodes already under the directory.
+func (mp *metaPartition) TagQuota(req *proto.TagQuotaRequest, p *Packet) (err error) {
+	if !mp.isInoInRange(req.Inode) {
+		err = fmt.Errorf("inode %v out of partition range", req.Inode)
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	val, err := json.Marshal(&quotaTag{Inode: req.Inode, QuotaId: req.QuotaId})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMTagQuota, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
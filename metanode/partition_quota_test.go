@@ -0,0 +1,147 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartition_Quota(t *testing.T) {
+	mp := newTestPartition(1)
+	mp.manager = &metadataManager{quotas: newVolQuotas()}
+	mp.manager.quotas.update(map[string][]*proto.QuotaHeartbeatInfo{
+		"vol": {{QuotaId: 1, Inode: 1}, {QuotaId: 2}},
+	}, 3)
+
+	const (
+		dir uint64 = iota + 1
+		file
+		nested
+		untagged
+		other
+	)
+	if status := mp.fsmCreateInodeQuota(NewInode(dir, proto.Mode(os.ModeDir|0755)), []uint64{1}); status != proto.OpOk {
+		t.Fatalf("create inode fail: status(%v)", status)
+	}
+	fileInode := NewInode(file, proto.Mode(0644))
+	fileInode.Size = 100
+	mp.fsmCreateInodeQuota(fileInode, []uint64{1})
+	nestedInode := NewInode(nested, proto.Mode(0644))
+	nestedInode.Size = 50
+	mp.fsmCreateInodeQuota(nestedInode, []uint64{1, 2, 3})
+	untaggedInode := NewInode(untagged, proto.Mode(0644))
+	untaggedInode.Size = 1000
+	mp.fsmCreateInode(untaggedInode)
+	mp.fsmCreateInode(NewInode(other, proto.Mode(os.ModeDir|0755)))
+
+	// The quota which has been deleted is not counted.
+	checkQuotaUsages(t, mp, map[uint64]proto.QuotaUsage{
+		1: {QuotaId: 1, UsedFiles: 3, UsedBytes: 150},
+		2: {QuotaId: 2, UsedFiles: 1, UsedBytes: 50},
+	})
+
+	mp.fsmMoveInodeQuota(file, []uint64{2})
+	mp.fsmTagQuota(&quotaTag{Inode: untagged, QuotaId: 2})
+	expects := map[uint64]proto.QuotaUsage{
+		1: {QuotaId: 1, UsedFiles: 2, UsedBytes: 50},
+		2: {QuotaId: 2, UsedFiles: 3, UsedBytes: 1150},
+	}
+	checkQuotaUsages(t, mp, expects)
+	mp.rebuildQuotaUsages()
+	checkQuotaUsages(t, mp, expects)
+
+	// The directory with the quota keeps it when moved out, while the others cannot cross quotas.
+	ops := []*proto.TxOp{{Type: proto.TxOpMoveInode, ParentId: other, Inode: dir}}
+	if status, err := mp.resolveTxQuotas(ops); err != nil || status != proto.OpOk || !proto.EqualQuotaIds(ops[0].QuotaIds, []uint64{1}) {
+		t.Fatalf("resolve directory quotas mismatch: status(%v) err(%v) quotas(%v)", status, err, ops[0].QuotaIds)
+	}
+	ops = []*proto.TxOp{{Type: proto.TxOpLinkInode, ParentId: other, Inode: file}}
+	if status, _ := mp.resolveTxQuotas(ops); status != proto.OpQuotaCrossErr {
+		t.Fatalf("link across quotas should be refused: status(%v)", status)
+	}
+	ops = []*proto.TxOp{{Type: proto.TxOpMoveInode, ParentId: dir, Inode: file}}
+	if status, _ := mp.resolveTxQuotas(ops); status != proto.OpOk || !proto.EqualQuotaIds(ops[0].QuotaIds, []uint64{1}) {
+		t.Fatalf("resolve file quotas mismatch: status(%v) quotas(%v)", status, ops[0].QuotaIds)
+	}
+	// The inode moved into the trash keeps its quotas.
+	ops = []*proto.TxOp{{Type: proto.TxOpMoveInode, ParentId: untagged, Inode: nested, KeepQuota: true}}
+	if status, _ := mp.resolveTxQuotas(ops); status != proto.OpOk || !proto.EqualQuotaIds(ops[0].QuotaIds, []uint64{1, 2, 3}) {
+		t.Fatalf("resolve trash quotas mismatch: status(%v) quotas(%v)", status, ops[0].QuotaIds)
+	}
+
+	if mp.isFilesQuotaExceeded([]uint64{1}) || mp.isBytesQuotaExceeded(file) {
+		t.Fatalf("quota should not be exceeded")
+	}
+	mp.manager.quotas.update(map[string][]*proto.QuotaHeartbeatInfo{
+		"vol": {{QuotaId: 1, Inode: 1, LimitedFiles: true}, {QuotaId: 2, LimitedBytes: true}},
+	}, 3)
+	if !mp.isFilesQuotaExceeded([]uint64{1}) || mp.isFilesQuotaExceeded([]uint64{2}) {
+		t.Fatalf("files quota exceeded mismatch")
+	}
+	if !mp.isBytesQuotaExceeded(file) || !mp.isBytesQuotaExceeded(nested) || mp.isBytesQuotaExceeded(dir) {
+		t.Fatalf("bytes quota exceeded mismatch")
+	}
+	if !mp.isTruncateGrowing(nested, 51) || mp.isTruncateGrowing(nested, 50) {
+		t.Fatalf("truncate growing mismatch")
+	}
+	ops = []*proto.TxOp{{Type: proto.TxOpMoveInode, ParentId: dir, Inode: file}}
+	if status, _ := mp.resolveTxQuotas(ops); status != proto.OpQuotaExceededErr {
+		t.Fatalf("move into the quota reaching the limit should be refused: status(%v)", status)
+	}
+}
+
+func TestMetaPartition_DirQuotaCache(t *testing.T) {
+	mp := newTestPartition(1)
+	mp.manager = &metadataManager{quotas: newVolQuotas()}
+	const remote uint64 = 200
+
+	// The directory in another partition is not read if the volume has no quotas.
+	if ids, err := mp.getDirQuotaIds(remote); err != nil || len(ids) != 0 {
+		t.Fatalf("volume without quotas mismatch: ids(%v) err(%v)", ids, err)
+	}
+
+	mp.manager.quotas.update(map[string][]*proto.QuotaHeartbeatInfo{"vol": {{QuotaId: 1}}}, 2)
+	now := Now.GetCurrentTime().Unix()
+	mp.dirQuotaCache.put(remote, 2, proto.EncodeQuotaIds([]uint64{1, 2}), now)
+	if ids, err := mp.getDirQuotaIds(remote); err != nil || !proto.EqualQuotaIds(ids, []uint64{1}) {
+		t.Fatalf("cached quotas mismatch: ids(%v) err(%v)", ids, err)
+	}
+	// The cache is dropped by a new quota ID mark or after expiration.
+	if _, ok := mp.dirQuotaCache.get(remote, 3, now); ok {
+		t.Fatalf("cache should be dropped by a new quota ID mark")
+	}
+	if _, ok := mp.dirQuotaCache.get(remote, 2, now+dirQuotaCacheExpiration+1); ok {
+		t.Fatalf("cache should expire")
+	}
+	mp.manager.quotas.update(map[string][]*proto.QuotaHeartbeatInfo{"vol": {{QuotaId: 1}}}, 3)
+	if _, err := mp.getDirQuotaIds(remote); err == nil {
+		t.Fatalf("directory should be read from its partition after the cache is dropped")
+	}
+}
+
+func checkQuotaUsages(t *testing.T, mp *metaPartition, expects map[uint64]proto.QuotaUsage) {
+	usages := mp.GetQuotaUsages()
+	if len(usages) != len(expects) {
+		t.Fatalf("quota usages mismatch: expect(%v) actual(%v)", len(expects), len(usages))
+	}
+	for _, usage := range usages {
+		if *usage != expects[usage.QuotaId] {
+			t.Fatalf("quota usage mismatch: expect(%v) actual(%v)", expects[usage.QuotaId], *usage)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The leader of a meta partition reads the metadata kept by the other partitions of the volume,
+// such as the quotas of the parent directory, through the members of the partition which the inode
+// belongs to. The partitions of the volumes are fetched from the master and cached by the meta
+// node, and fetched again when the inode is allocated after the partitions are cached, since the
+// range of the last partition may be cut by a new partition.
+
+const volPartitionsRefreshInterval = 10 // seconds
+
+// volPartitions keeps the meta partitions of the volumes fetched from the master.
+type volPartitions struct {
+	sync.RWMutex
+	views      map[string][]*proto.MetaPartitionView
+	updateTime map[string]int64
+}
+
+func newVolPartitions() *volPartitions {
+	return &volPartitions{
+		views:      make(map[string][]*proto.MetaPartitionView),
+		updateTime: make(map[string]int64),
+	}
+}
+
+// find returns the cached partition which the inode belongs to, and whether the partitions should
+// be fetched again.
+func (v *volPartitions) find(volName string, ino uint64) (*proto.MetaPartitionView, bool) {
+	v.RLock()
+	defer v.RUnlock()
+	expired := Now.GetCurrentTime().Unix()-v.updateTime[volName] > volPartitionsRefreshInterval
+	for _, view := range v.views[volName] {
+		if ino >= view.Start && ino <= view.End {
+			return view, ino > view.MaxInodeID && expired
+		}
+	}
+	return nil, expired
+}
+
+func (v *volPartitions) update(volName string, views []*proto.MetaPartitionView) {
+	v.Lock()
+	v.views[volName] = views
+	v.updateTime[volName] = Now.GetCurrentTime().Unix()
+	v.Unlock()
+}
+
+// locatePartition returns the meta partition of the volume which the inode belongs to.
+func (mp *metaPartition) locatePartition(ino uint64) (participant *proto.TxParticipant, err error) {
+	if mp.manager == nil || mp.manager.volPartitions == nil {
+		return nil, fmt.Errorf("no partitions of volume(%v)", mp.config.VolName)
+	}
+	partitions := mp.manager.volPartitions
+	view, refresh := partitions.find(mp.config.VolName, ino)
+	if refresh {
+		var views []*proto.MetaPartitionView
+		if views, err = masterClient.ClientAPI().GetMetaPartitions(mp.config.VolName); err != nil {
+			return
+		}
+		partitions.update(mp.config.VolName, views)
+		view, _ = partitions.find(mp.config.VolName, ino)
+	}
+	if view == nil {
+		return nil, fmt.Errorf("no partition of inode(%v) in volume(%v)", ino, mp.config.VolName)
+	}
+	return &proto.TxParticipant{PartitionID: view.PartitionID, Members: view.Members}, nil
+}
+
+// getRemoteXAttr reads the extended attribute of the inode kept by another partition.
+func (mp *metaPartition) getRemoteXAttr(ino uint64, key string) (value []byte, err error) {
+	participant, err := mp.locatePartition(ino)
+	if err != nil {
+		return
+	}
+	req := &proto.GetXAttrRequest{VolName: mp.config.VolName, PartitionId: participant.PartitionID, Inode: ino, Key: key}
+	p, err := mp.sendTxPacket(participant, proto.OpMetaGetXAttr, req)
+	if err != nil {
+		return
+	}
+	if p.ResultCode != proto.OpOk {
+		err = fmt.Errorf("get xattr of inode(%v) fail: result(%v)", ino, p.GetResultMsg())
+		return
+	}
+	resp := &proto.GetXAttrResponse{}
+	if err = p.UnmarshalData(resp); err != nil {
+		return
+	}
+	return []byte(resp.Value), nil
+}
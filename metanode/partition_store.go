@@ -16,6 +16,7 @@ package metanode
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -42,6 +43,11 @@ const (
 	dentryFile      = "dentry"
 	extendFile      = "extend"
 	multipartFile   = "multipart"
+	txFile          = "transaction"
+	lockFile        = "lock"
+	changelogFile   = "changelog"
+	dirStatFile     = "dirstat"
+	volSnapshotFile = "volsnapshot"
 	applyIDFile     = "apply"
 	SnapshotSign    = ".sign"
 	metadataFile    = "meta"
@@ -295,6 +301,137 @@ func (mp *metaPartition) loadMultipart(rootDir string) error {
 	return nil
 }
 
+func (mp *metaPartition) loadTransaction(rootDir string) error {
+	var err error
+	filename := path.Join(rootDir, txFile)
+	if _, err = os.Stat(filename); err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var offset, n int
+	// read number of transactions
+	var numTxs uint64
+	numTxs, n = binary.Uvarint(data)
+	offset += n
+	for i := uint64(0); i < numTxs; i++ {
+		// read length
+		var numBytes uint64
+		numBytes, n = binary.Uvarint(data[offset:])
+		offset += n
+		var tx *txItem
+		if tx, err = newTxItemFromBytes(data[offset : offset+int(numBytes)]); err != nil {
+			return err
+		}
+		mp.txTree.ReplaceOrInsert(tx, true)
+		if tx.State == proto.TxStatePrepared {
+			mp.lockTxDentries(tx, true)
+		}
+		offset += int(numBytes)
+	}
+	log.LogInfof("loadTransaction: load complete: partitionID(%v) numTxs(%v) filename(%v)",
+		mp.config.PartitionId, numTxs, filename)
+	return nil
+}
+
+func (mp *metaPartition) loadLock(rootDir string) error {
+	var err error
+	filename := path.Join(rootDir, lockFile)
+	if _, err = os.Stat(filename); err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var offset, n int
+	// read number of locks
+	var numLocks uint64
+	numLocks, n = binary.Uvarint(data)
+	offset += n
+	for i := uint64(0); i < numLocks; i++ {
+		// read length
+		var numBytes uint64
+		numBytes, n = binary.Uvarint(data[offset:])
+		offset += n
+		var lock *lockItem
+		if lock, err = newLockItemFromBytes(data[offset : offset+int(numBytes)]); err != nil {
+			return err
+		}
+		mp.lockTree.ReplaceOrInsert(lock, true)
+		offset += int(numBytes)
+	}
+	log.LogInfof("loadLock: load complete: partitionID(%v) numLocks(%v) filename(%v)",
+		mp.config.PartitionId, numLocks, filename)
+	return nil
+}
+
+func (mp *metaPartition) loadChangelog(rootDir string) error {
+	var err error
+	filename := path.Join(rootDir, changelogFile)
+	if _, err = os.Stat(filename); err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var offset, n int
+	// read number of events
+	var numEvents uint64
+	numEvents, n = binary.Uvarint(data)
+	offset += n
+	for i := uint64(0); i < numEvents; i++ {
+		// read length
+		var numBytes uint64
+		numBytes, n = binary.Uvarint(data[offset:])
+		offset += n
+		var event *changelogItem
+		if event, err = newChangelogItemFromBytes(data[offset : offset+int(numBytes)]); err != nil {
+			return err
+		}
+		mp.changelogTree.ReplaceOrInsert(event, true)
+		offset += int(numBytes)
+	}
+	log.LogInfof("loadChangelog: load complete: partitionID(%v) numEvents(%v) filename(%v)",
+		mp.config.PartitionId, numEvents, filename)
+	return nil
+}
+
+func (mp *metaPartition) loadDirStat(rootDir string) error {
+	var err error
+	filename := path.Join(rootDir, dirStatFile)
+	if _, err = os.Stat(filename); err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var offset, n int
+	// read number of items
+	var numItems uint64
+	numItems, n = binary.Uvarint(data)
+	offset += n
+	for i := uint64(0); i < numItems; i++ {
+		// read length
+		var numBytes uint64
+		numBytes, n = binary.Uvarint(data[offset:])
+		offset += n
+		var item *dirStatItem
+		if item, err = newDirStatItemFromBytes(data[offset : offset+int(numBytes)]); err != nil {
+			return err
+		}
+		mp.dirStatTree.ReplaceOrInsert(item, true)
+		offset += int(numBytes)
+	}
+	log.LogInfof("loadDirStat: load complete: partitionID(%v) numItems(%v) filename(%v)",
+		mp.config.PartitionId, numItems, filename)
+	return nil
+}
+
 func (mp *metaPartition) loadApplyID(rootDir string) (err error) {
 	filename := path.Join(rootDir, applyIDFile)
 	if _, err = os.Stat(filename); err != nil {
@@ -604,3 +741,179 @@ func (mp *metaPartition) storeMultipart(rootDir string, sm *storeMsg) (crc uint3
 		mp.config.PartitionId, mp.config.VolName, multipartTree.Len(), crc)
 	return
 }
+
+func (mp *metaPartition) storeTransaction(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	var txTree = sm.txTree
+	var buff = bytes.NewBuffer(make([]byte, 0))
+	var varintTmp = make([]byte, binary.MaxVarintLen64)
+	var n int
+	// write number of transactions
+	n = binary.PutUvarint(varintTmp, uint64(txTree.Len()))
+	buff.Write(varintTmp[:n])
+	txTree.Ascend(func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = i.(*txItem).Bytes(); err != nil {
+			return false
+		}
+		// write length and raw
+		n = binary.PutUvarint(varintTmp, uint64(len(raw)))
+		buff.Write(varintTmp[:n])
+		buff.Write(raw)
+		return true
+	})
+	if err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.OpenFile(path.Join(rootDir, txFile), os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0755); err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	if _, err = f.Write(buff.Bytes()); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	crc = crc32.ChecksumIEEE(buff.Bytes())
+	log.LogInfof("storeTransaction: store complete: partitionID(%v) volume(%v) numTxs(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, txTree.Len(), crc)
+	return
+}
+
+func (mp *metaPartition) storeLock(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	var lockTree = sm.lockTree
+	var buff = bytes.NewBuffer(make([]byte, 0))
+	var varintTmp = make([]byte, binary.MaxVarintLen64)
+	var n int
+	// write number of locks
+	n = binary.PutUvarint(varintTmp, uint64(lockTree.Len()))
+	buff.Write(varintTmp[:n])
+	lockTree.Ascend(func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = i.(*lockItem).Bytes(); err != nil {
+			return false
+		}
+		// write length and raw
+		n = binary.PutUvarint(varintTmp, uint64(len(raw)))
+		buff.Write(varintTmp[:n])
+		buff.Write(raw)
+		return true
+	})
+	if err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.OpenFile(path.Join(rootDir, lockFile), os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0755); err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	if _, err = f.Write(buff.Bytes()); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	crc = crc32.ChecksumIEEE(buff.Bytes())
+	log.LogInfof("storeLock: store complete: partitionID(%v) volume(%v) numLocks(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, lockTree.Len(), crc)
+	return
+}
+
+func (mp *metaPartition) storeChangelog(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	var changelogTree = sm.changelogTree
+	var buff = bytes.NewBuffer(make([]byte, 0))
+	var varintTmp = make([]byte, binary.MaxVarintLen64)
+	var n int
+	// write number of events
+	n = binary.PutUvarint(varintTmp, uint64(changelogTree.Len()))
+	buff.Write(varintTmp[:n])
+	changelogTree.Ascend(func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = i.(*changelogItem).Bytes(); err != nil {
+			return false
+		}
+		// write length and raw
+		n = binary.PutUvarint(varintTmp, uint64(len(raw)))
+		buff.Write(varintTmp[:n])
+		buff.Write(raw)
+		return true
+	})
+	if err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.OpenFile(path.Join(rootDir, changelogFile), os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0755); err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	if _, err = f.Write(buff.Bytes()); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	crc = crc32.ChecksumIEEE(buff.Bytes())
+	log.LogInfof("storeChangelog: store complete: partitionID(%v) volume(%v) numEvents(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, changelogTree.Len(), crc)
+	return
+}
+
+func (mp *metaPartition) storeDirStat(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	var dirStatTree = sm.dirStatTree
+	var buff = bytes.NewBuffer(make([]byte, 0))
+	var varintTmp = make([]byte, binary.MaxVarintLen64)
+	var n int
+	// write number of items
+	n = binary.PutUvarint(varintTmp, uint64(dirStatTree.Len()))
+	buff.Write(varintTmp[:n])
+	dirStatTree.Ascend(func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = i.(*dirStatItem).Bytes(); err != nil {
+			return false
+		}
+		// write length and raw
+		n = binary.PutUvarint(varintTmp, uint64(len(raw)))
+		buff.Write(varintTmp[:n])
+		buff.Write(raw)
+		return true
+	})
+	if err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.OpenFile(path.Join(rootDir, dirStatFile), os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0755); err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	if _, err = f.Write(buff.Bytes()); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	crc = crc32.ChecksumIEEE(buff.Bytes())
+	log.LogInfof("storeDirStat: store complete: partitionID(%v) volume(%v) numItems(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, dirStatTree.Len(), crc)
+	return
+}
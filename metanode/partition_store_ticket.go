@@ -31,6 +31,11 @@ type storeMsg struct {
 	dentryTree    *BTree
 	extendTree    *BTree
 	multipartTree *BTree
+	txTree        *BTree
+	lockTree      *BTree
+	changelogTree *BTree
+	dirStatTree   *BTree
+	volSnapshots  []*volSnapshotMeta
 }
 
 func (mp *metaPartition) startSchedule(curIndex uint64) {
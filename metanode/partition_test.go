@@ -0,0 +1,22 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+// newTestPartition creates a partition of the volume "vol" owning the inodes
+// in [1, 100] the same way the meta node does, without a raft partition.
+func newTestPartition(id uint64) *metaPartition {
+	conf := &MetaPartitionConfig{PartitionId: id, VolName: "vol", Start: 1, End: 100}
+	return NewMetaPartition(conf, nil).(*metaPartition)
+}
@@ -0,0 +1,624 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// A metadata transaction changes the dentries and the inodes of several meta partitions
+// atomically with two phases. The client prepares the operations on the coordinator first, which
+// is the first participant of the transaction, and then on the other participants. Each
+// participant validates its operations, links the inodes in advance, and locks the dentries to
+// change, so that the other operations on the dentries are retried until the transaction ends.
+// After all the participants are prepared, the client asks the coordinator to commit, and the
+// coordinator records the decision and sends it to the other participants.
+//
+// The prepared transactions are kept in the transaction tree of the partition, which is stored
+// with the partition snapshots. The leader of each partition checks the expired transactions
+// periodically: the coordinator rolls back the undecided ones and resends the decisions, and the
+// other participants ask the coordinator for the decisions.
+
+const (
+	txCheckInterval = 5 * time.Second
+	txRetainTime    = 5 * 60 // seconds the coordinator keeps the decision after the participants finish
+)
+
+// txItem is a transaction prepared by the meta partition.
+type txItem struct {
+	Tx         *proto.TxInfo `json:"tx"`
+	Ops        []*proto.TxOp `json:"ops"`
+	State      uint8         `json:"state"`
+	CreateTime int64         `json:"ctime"`              // set by the leader which proposes the prepare
+	Finished   bool          `json:"finished,omitempty"` // all the participants have applied the decision
+}
+
+func newTxItemFromBytes(raw []byte) (*txItem, error) {
+	tx := &txItem{}
+	if err := json.Unmarshal(raw, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Less tests whether the transaction ID is less than the given one.
+func (tx *txItem) Less(than BtreeItem) bool {
+	other, ok := than.(*txItem)
+	return ok && tx.Tx.TxId < other.Tx.TxId
+}
+
+// Copy returns a copy of the transaction.
+func (tx *txItem) Copy() BtreeItem {
+	newTx := *tx
+	return &newTx
+}
+
+func (tx *txItem) Bytes() ([]byte, error) {
+	return json.Marshal(tx)
+}
+
+func (tx *txItem) isCoordinator(partitionID uint64) bool {
+	return tx.Tx.Coordinator() == partitionID
+}
+
+func (tx *txItem) isExpired(now int64) bool {
+	return now > tx.CreateTime+tx.Tx.Timeout
+}
+
+func newTxKey(txId string) *txItem {
+	return &txItem{Tx: &proto.TxInfo{TxId: txId}}
+}
+
+// txDecisionOp is the raft log to commit, roll back, finish or delete a transaction.
+type txDecisionOp struct {
+	TxId string `json:"id"`
+}
+
+type txPrepareResp struct {
+	Status uint8
+	Inodes []*proto.InodeInfo
+}
+
+func isTxDentryOp(op *proto.TxOp) bool {
+	return op.Type == proto.TxOpCreateDentry || op.Type == proto.TxOpUpdateDentry || op.Type == proto.TxOpDeleteDentry
+}
+
+// txDentryKey is the dentry locked by a prepared transaction.
+type txDentryKey struct {
+	parentId uint64
+	name     string
+}
+
+// isDentryLockedByTx returns whether the dentry is going to be changed by a prepared transaction.
+func (mp *metaPartition) isDentryLockedByTx(parentId uint64, name string) bool {
+	_, locked := mp.txDentryLocks[txDentryKey{parentId: parentId, name: name}]
+	return locked
+}
+
+// lockTxDentries locks the dentries changed by the transaction, or unlocks them.
+func (mp *metaPartition) lockTxDentries(tx *txItem, lock bool) {
+	if mp.txDentryLocks == nil {
+		mp.txDentryLocks = make(map[txDentryKey]string)
+	}
+	for _, op := range tx.Ops {
+		if !isTxDentryOp(op) {
+			continue
+		}
+		key := txDentryKey{parentId: op.ParentId, name: op.Name}
+		if lock {
+			mp.txDentryLocks[key] = tx.Tx.TxId
+		} else if mp.txDentryLocks[key] == tx.Tx.TxId {
+			delete(mp.txDentryLocks, key)
+		}
+	}
+}
+
+// rebuildTxDentryLocks locks the dentries of the prepared transactions applied from the raft snapshot.
+func (mp *metaPartition) rebuildTxDentryLocks() {
+	mp.txDentryLocks = make(map[txDentryKey]string)
+	mp.txTree.Ascend(func(i BtreeItem) bool {
+		if tx := i.(*txItem); tx.State == proto.TxStatePrepared {
+			mp.lockTxDentries(tx, true)
+		}
+		return true
+	})
+}
+
+func (mp *metaPartition) getTx(txId string) *txItem {
+	item := mp.txTree.Get(newTxKey(txId))
+	if item == nil {
+		return nil
+	}
+	return item.(*txItem)
+}
+
+// validateTxOp checks whether the operation of the transaction can be applied. The dentries
+// locked by the transaction itself are not regarded as locked.
+func (mp *metaPartition) validateTxOp(txId string, op *proto.TxOp) uint8 {
+	if isTxDentryOp(op) {
+		if owner, locked := mp.txDentryLocks[txDentryKey{parentId: op.ParentId, name: op.Name}]; locked && owner != txId {
+			return proto.OpAgain
+		}
+	}
+	switch op.Type {
+	case proto.TxOpCreateDentry:
+		item := mp.inodeTree.Get(NewInode(op.ParentId, 0))
+		if item == nil || item.(*Inode).ShouldDelete() {
+			return proto.OpNotExistErr
+		}
+		if !proto.IsDir(item.(*Inode).Type) {
+			return proto.OpArgMismatchErr
+		}
+		if d, _ := mp.getDentry(&Dentry{ParentId: op.ParentId, Name: op.Name}); d != nil {
+			return proto.OpExistErr
+		}
+	case proto.TxOpUpdateDentry:
+		d, status := mp.getDentry(&Dentry{ParentId: op.ParentId, Name: op.Name})
+		if status != proto.OpOk {
+			return status
+		}
+		if d.Inode != op.OldInode || proto.OsModeType(d.Type) != proto.OsModeType(op.Mode) {
+			return proto.OpArgMismatchErr
+		}
+	case proto.TxOpDeleteDentry:
+		d, status := mp.getDentry(&Dentry{ParentId: op.ParentId, Name: op.Name})
+		if status != proto.OpOk {
+			return status
+		}
+		if d.Inode != op.Inode {
+			return proto.OpNotExistErr
+		}
+	case proto.TxOpLinkInode, proto.TxOpUnlinkInode, proto.TxOpMoveInode:
+		item := mp.inodeTree.Get(NewInode(op.Inode, 0))
+		if item == nil || item.(*Inode).ShouldDelete() {
+			return proto.OpNotExistErr
+		}
+		if op.Type == proto.TxOpUnlinkInode && mp.isUnlinkDenied(op.Inode, mp.fsmTime()) {
+			return proto.OpNotPerm
+		}
+	default:
+		return proto.OpArgMismatchErr
+	}
+	return proto.OpOk
+}
+
+// fsmTxPrepare validates the operations of the transaction, links the inodes and locks the dentries.
+func (mp *metaPartition) fsmTxPrepare(tx *txItem) (resp *txPrepareResp) {
+	resp = &txPrepareResp{Status: proto.OpOk}
+	if mp.getTx(tx.Tx.TxId) == nil {
+		for _, op := range tx.Ops {
+			if resp.Status = mp.validateTxOp(tx.Tx.TxId, op); resp.Status != proto.OpOk {
+				return
+			}
+		}
+		for _, op := range tx.Ops {
+			if op.Type == proto.TxOpLinkInode {
+				mp.fsmCreateLinkInode(NewInode(op.Inode, 0))
+			}
+		}
+		tx.State = proto.TxStatePrepared
+		mp.txTree.ReplaceOrInsert(tx, true)
+		mp.lockTxDentries(tx, true)
+	}
+	for _, op := range tx.Ops {
+		if op.Type != proto.TxOpLinkInode {
+			continue
+		}
+		info := &proto.InodeInfo{}
+		if item := mp.inodeTree.Get(NewInode(op.Inode, 0)); item != nil && replyInfo(info, item.(*Inode)) {
+			resp.Inodes = append(resp.Inodes, info)
+		}
+	}
+	return
+}
+
+// fsmTxCommit applies the operations of the prepared transaction. The operations are validated
+// again before any of them is applied. If any of them fails, the coordinator rolls back the
+// transaction, and the other participants keep it prepared and fail the commit, since the
+// decision has been made by the coordinator.
+func (mp *metaPartition) fsmTxCommit(txId string) (status uint8) {
+	tx := mp.getTx(txId)
+	if tx == nil {
+		return proto.OpNotExistErr
+	}
+	switch tx.State {
+	case proto.TxStateCommitted:
+		return proto.OpOk
+	case proto.TxStateRolledBack:
+		return proto.OpTxAbortedErr
+	}
+	for _, op := range tx.Ops {
+		if status = mp.validateTxOp(txId, op); status == proto.OpOk {
+			continue
+		}
+		log.LogWarnf("fsmTxCommit: validate op fail: partitionID(%v) txID(%v) op(%v) status(%v)",
+			mp.config.PartitionId, txId, op, status)
+		if tx.isCoordinator(mp.config.PartitionId) {
+			mp.fsmTxRollback(txId)
+			return proto.OpTxAbortedErr
+		}
+		return
+	}
+	// unlock the dentries before applying the operations
+	mp.updateTxState(tx, proto.TxStateCommitted)
+	var result = proto.OpOk
+	for _, op := range tx.Ops {
+		switch op.Type {
+		case proto.TxOpCreateDentry:
+			status = mp.fsmCreateDentry(&Dentry{ParentId: op.ParentId, Name: op.Name, Inode: op.Inode, Type: op.Mode}, false)
+		case proto.TxOpUpdateDentry:
+			status = mp.fsmUpdateDentry(&Dentry{ParentId: op.ParentId, Name: op.Name, Inode: op.Inode}).Status
+		case proto.TxOpDeleteDentry:
+			status = mp.fsmDeleteDentry(&Dentry{ParentId: op.ParentId, Name: op.Name, Inode: op.Inode}, true).Status
+		case proto.TxOpUnlinkInode:
+			status = mp.fsmUnlinkInode(NewInode(op.Inode, 0)).Status
+		case proto.TxOpMoveInode:
+			if status = mp.fsmMoveInodeQuota(op.Inode, op.QuotaIds); status == proto.OpOk {
+				mp.moveDirStat(op.Inode, op.ParentId)
+			}
+		default:
+			continue
+		}
+		if status != proto.OpOk {
+			log.LogErrorf("fsmTxCommit: apply op fail: partitionID(%v) txID(%v) op(%v) status(%v)",
+				mp.config.PartitionId, txId, op, status)
+			if result == proto.OpOk {
+				result = status
+			}
+		}
+	}
+	return result
+}
+
+// fsmTxRollback drops the prepared transaction and unlinks the inodes linked by it.
+func (mp *metaPartition) fsmTxRollback(txId string) (status uint8) {
+	tx := mp.getTx(txId)
+	if tx == nil {
+		return proto.OpNotExistErr
+	}
+	switch tx.State {
+	case proto.TxStateRolledBack:
+		return proto.OpOk
+	case proto.TxStateCommitted:
+		return proto.OpArgMismatchErr
+	}
+	mp.updateTxState(tx, proto.TxStateRolledBack)
+	for _, op := range tx.Ops {
+		if op.Type == proto.TxOpLinkInode {
+			mp.fsmUnlinkInode(NewInode(op.Inode, 0))
+		}
+	}
+	return proto.OpOk
+}
+
+// updateTxState keeps the decision in the coordinator, and drops the transaction from the other participants.
+func (mp *metaPartition) updateTxState(tx *txItem, state uint8) {
+	mp.lockTxDentries(tx, false)
+	if !tx.isCoordinator(mp.config.PartitionId) {
+		mp.txTree.Delete(tx)
+		return
+	}
+	newTx := tx.Copy().(*txItem)
+	newTx.State = state
+	mp.txTree.ReplaceOrInsert(newTx, true)
+}
+
+// fsmTxFinish marks that all the participants have applied the decision of the transaction.
+func (mp *metaPartition) fsmTxFinish(txId string) (status uint8) {
+	tx := mp.getTx(txId)
+	if tx == nil {
+		return proto.OpNotExistErr
+	}
+	if tx.State == proto.TxStatePrepared {
+		return proto.OpArgMismatchErr
+	}
+	newTx := tx.Copy().(*txItem)
+	newTx.Finished = true
+	mp.txTree.ReplaceOrInsert(newTx, true)
+	return proto.OpOk
+}
+
+// fsmTxDelete drops the finished transaction from the coordinator.
+func (mp *metaPartition) fsmTxDelete(txId string) (status uint8) {
+	tx := mp.getTx(txId)
+	if tx == nil {
+		return proto.OpOk
+	}
+	if !tx.Finished {
+		return proto.OpArgMismatchErr
+	}
+	mp.txTree.Delete(tx)
+	return proto.OpOk
+}
+
+func (mp *metaPartition) submitTxDecision(op uint32, txId string) (status uint8, err error) {
+	val, err := json.Marshal(&txDecisionOp{TxId: txId})
+	if err != nil {
+		return
+	}
+	resp, err := mp.submit(op, val)
+	if err != nil {
+		return
+	}
+	status = resp.(uint8)
+	return
+}
+
+// TxPrepare prepares the operations of the transaction through the raft log.
+func (mp *metaPartition) TxPrepare(req *proto.TxPrepareRequest, p *Packet) (err error) {
+	if req.Tx == nil || req.Tx.TxId == "" || len(req.Tx.Participants) == 0 {
+		err = fmt.Errorf("invalid transaction")
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	if req.Tx.Timeout <= 0 {
+		req.Tx.Timeout = proto.TxDefaultTimeout
+	}
+	status, err := mp.resolveTxQuotas(req.Ops)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	tx := &txItem{Tx: req.Tx, Ops: req.Ops, State: proto.TxStatePrepared, CreateTime: time.Now().Unix()}
+	val, err := tx.Bytes()
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	r, err := mp.submit(opFSMTxPrepare, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	resp := r.(*txPrepareResp)
+	if resp.Status != proto.OpOk {
+		p.PacketErrorWithBody(resp.Status, nil)
+		return
+	}
+	reply, err := json.Marshal(&proto.TxPrepareResponse{Inodes: resp.Inodes})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// TxCommit commits the transaction. The coordinator sends the decision to the other participants.
+func (mp *metaPartition) TxCommit(req *proto.TxCommitRequest, p *Packet) (err error) {
+	status, err := mp.submitTxDecision(opFSMTxCommit, req.TxId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if req.Coordinator == mp.config.PartitionId {
+		if status == proto.OpNotExistErr {
+			status = proto.OpTxAbortedErr
+		}
+		if status == proto.OpOk || status == proto.OpTxAbortedErr {
+			mp.finishTx(req.TxId)
+		}
+	} else if status == proto.OpNotExistErr {
+		// the decision has been applied
+		status = proto.OpOk
+	}
+	p.PacketErrorWithBody(status, nil)
+	return
+}
+
+// TxRollback rolls back the transaction. The coordinator sends the decision to the other participants.
+func (mp *metaPartition) TxRollback(req *proto.TxRollbackRequest, p *Packet) (err error) {
+	status, err := mp.submitTxDecision(opFSMTxRollback, req.TxId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if status == proto.OpNotExistErr {
+		// not prepared or the decision has been applied
+		status = proto.OpOk
+	}
+	if req.Coordinator == mp.config.PartitionId && status == proto.OpOk {
+		mp.finishTx(req.TxId)
+	}
+	p.PacketErrorWithBody(status, nil)
+	return
+}
+
+// TxGetState replies the state of the transaction kept by the coordinator.
+func (mp *metaPartition) TxGetState(req *proto.TxGetStateRequest, p *Packet) (err error) {
+	resp := &proto.TxGetStateResponse{}
+	if tx := mp.getTx(req.TxId); tx != nil {
+		resp.State = tx.State
+	}
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// finishTx sends the decision of the transaction to the other participants, and marks the
+// transaction finished if all of them apply the decision.
+func (mp *metaPartition) finishTx(txId string) {
+	tx := mp.getTx(txId)
+	if tx == nil || tx.Finished || tx.State == proto.TxStatePrepared {
+		return
+	}
+	var opcode = proto.OpMetaTxCommit
+	if tx.State == proto.TxStateRolledBack {
+		opcode = proto.OpMetaTxRollback
+	}
+	for _, participant := range tx.Tx.Participants[1:] {
+		if participant.PartitionID == mp.config.PartitionId {
+			continue
+		}
+		var req interface{}
+		if opcode == proto.OpMetaTxCommit {
+			req = &proto.TxCommitRequest{VolName: mp.config.VolName, PartitionID: participant.PartitionID, TxId: txId}
+		} else {
+			req = &proto.TxRollbackRequest{VolName: mp.config.VolName, PartitionID: participant.PartitionID, TxId: txId}
+		}
+		packet, err := mp.sendTxPacket(participant, opcode, req)
+		if err == nil && packet.ResultCode != proto.OpOk {
+			err = fmt.Errorf("result(%v)", packet.GetResultMsg())
+		}
+		if err != nil {
+			log.LogWarnf("finishTx: send decision fail: partitionID(%v) txID(%v) participant(%v) err(%v)",
+				mp.config.PartitionId, txId, participant.PartitionID, err)
+			return
+		}
+	}
+	if _, err := mp.submitTxDecision(opFSMTxFinish, txId); err != nil {
+		log.LogWarnf("finishTx: partitionID(%v) txID(%v) err(%v)", mp.config.PartitionId, txId, err)
+	}
+}
+
+// sendTxPacket sends the request to the members of the participant, which forward it to the leader.
+func (mp *metaPartition) sendTxPacket(participant *proto.TxParticipant, opcode uint8, req interface{}) (p *proto.Packet, err error) {
+	members := participant.Members
+	for round := 0; round < 2; round++ {
+		for _, addr := range members {
+			p = proto.NewPacketReqID()
+			p.Opcode = opcode
+			p.PartitionID = participant.PartitionID
+			if err = p.MarshalData(req); err != nil {
+				return
+			}
+			if err = mp.sendPacket(addr, p); err == nil && !p.ShouldRetry() {
+				return
+			}
+		}
+		// the members may be changed since the transaction is prepared
+		var partition *proto.MetaPartitionInfo
+		if partition, err = masterClient.ClientAPI().GetMetaPartition(participant.PartitionID); err != nil {
+			return
+		}
+		members = partition.Hosts
+	}
+	if err == nil {
+		err = fmt.Errorf("send to partition(%v) fail: result(%v)", participant.PartitionID, p.GetResultMsg())
+	}
+	return
+}
+
+func (mp *metaPartition) sendPacket(addr string, p *proto.Packet) (err error) {
+	var conn *net.TCPConn
+	if conn, err = mp.config.ConnPool.GetConnect(addr); err != nil {
+		return
+	}
+	defer func() {
+		mp.config.ConnPool.PutConnect(conn, err != nil)
+	}()
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	err = p.ReadFromConn(conn, proto.ReadDeadlineTime)
+	return
+}
+
+// getTxState asks the coordinator for the state of the transaction.
+func (mp *metaPartition) getTxState(tx *txItem) (state uint8, err error) {
+	coordinator := tx.Tx.Participants[0]
+	req := &proto.TxGetStateRequest{VolName: mp.config.VolName, PartitionID: coordinator.PartitionID, TxId: tx.Tx.TxId}
+	p, err := mp.sendTxPacket(coordinator, proto.OpMetaTxGetState, req)
+	if err != nil {
+		return
+	}
+	if p.ResultCode != proto.OpOk {
+		err = fmt.Errorf("result(%v)", p.GetResultMsg())
+		return
+	}
+	resp := &proto.TxGetStateResponse{}
+	if err = p.UnmarshalData(resp); err != nil {
+		return
+	}
+	state = resp.State
+	return
+}
+
+// txWorker recovers the expired transactions left by the clients.
+func (mp *metaPartition) txWorker() {
+	t := time.NewTicker(txCheckInterval)
+	for {
+		select {
+		case <-mp.stopC:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if _, ok := mp.IsLeader(); !ok || mp.txTree.Len() == 0 {
+			continue
+		}
+		var txs []*txItem
+		now := time.Now().Unix()
+		mp.txTree.GetTree().Ascend(func(i BtreeItem) bool {
+			if tx := i.(*txItem); tx.isExpired(now) {
+				txs = append(txs, tx)
+			}
+			return true
+		})
+		for _, tx := range txs {
+			mp.recoverTx(tx, now)
+		}
+	}
+}
+
+func (mp *metaPartition) recoverTx(tx *txItem, now int64) {
+	var (
+		txId   = tx.Tx.TxId
+		status uint8
+		err    error
+	)
+	if tx.isCoordinator(mp.config.PartitionId) {
+		switch {
+		case tx.State == proto.TxStatePrepared:
+			log.LogWarnf("recoverTx: roll back expired transaction: partitionID(%v) txID(%v)", mp.config.PartitionId, txId)
+			if status, err = mp.submitTxDecision(opFSMTxRollback, txId); err == nil && status == proto.OpOk {
+				mp.finishTx(txId)
+			}
+		case !tx.Finished:
+			mp.finishTx(txId)
+		case now > tx.CreateTime+txRetainTime:
+			_, err = mp.submitTxDecision(opFSMTxDelete, txId)
+		}
+	} else {
+		var state uint8
+		if state, err = mp.getTxState(tx); err == nil {
+			switch state {
+			case proto.TxStateCommitted:
+				status, err = mp.submitTxDecision(opFSMTxCommit, txId)
+			case proto.TxStatePrepared:
+				// wait for the decision of the coordinator
+			default:
+				status, err = mp.submitTxDecision(opFSMTxRollback, txId)
+			}
+		}
+	}
+	if err != nil {
+		log.LogWarnf("recoverTx: partitionID(%v) txID(%v) status(%v) err(%v)", mp.config.PartitionId, txId, status, err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartition_Transaction(t *testing.T) {
+	const (
+		dstDir uint64 = iota + 1
+		srcDir
+		file
+	)
+	dstMP, srcMP := newTestPartition(1), newTestPartition(2)
+	dstMP.fsmCreateInode(NewInode(dstDir, proto.Mode(os.ModeDir|0755)))
+	srcMP.fsmCreateInode(NewInode(srcDir, proto.Mode(os.ModeDir|0755)))
+	srcMP.fsmCreateInode(NewInode(file, proto.Mode(0644)))
+	srcMP.fsmCreateDentry(&Dentry{ParentId: srcDir, Name: "src", Inode: file, Type: proto.Mode(0644)}, false)
+
+	// rename srcDir/src to dstDir/dst
+	info := &proto.TxInfo{TxId: "tx1", Timeout: proto.TxDefaultTimeout, Participants: []*proto.TxParticipant{{PartitionID: 1}, {PartitionID: 2}}}
+	dstTx := &txItem{Tx: info, Ops: []*proto.TxOp{{Type: proto.TxOpCreateDentry, ParentId: dstDir, Name: "dst", Inode: file, Mode: proto.Mode(0644)}}}
+	srcTx := &txItem{Tx: info, Ops: []*proto.TxOp{{Type: proto.TxOpDeleteDentry, ParentId: srcDir, Name: "src", Inode: file}}}
+	if resp := dstMP.fsmTxPrepare(dstTx); resp.Status != proto.OpOk {
+		t.Fatalf("prepare coordinator fail: status(%v)", resp.Status)
+	}
+	if resp := srcMP.fsmTxPrepare(srcTx); resp.Status != proto.OpOk {
+		t.Fatalf("prepare participant fail: status(%v)", resp.Status)
+	}
+
+	// the prepared dentries are locked
+	if status := dstMP.fsmCreateDentry(&Dentry{ParentId: dstDir, Name: "dst", Inode: 100, Type: proto.Mode(0644)}, false); status != proto.OpAgain {
+		t.Fatalf("create locked dentry mismatch: status(%v)", status)
+	}
+	if resp := srcMP.fsmDeleteDentry(&Dentry{ParentId: srcDir, Name: "src"}, false); resp.Status != proto.OpAgain {
+		t.Fatalf("delete locked dentry mismatch: status(%v)", resp.Status)
+	}
+	other := &txItem{Tx: &proto.TxInfo{TxId: "tx2", Participants: info.Participants}, Ops: dstTx.Ops}
+	if resp := dstMP.fsmTxPrepare(other); resp.Status != proto.OpAgain {
+		t.Fatalf("prepare conflict transaction mismatch: status(%v)", resp.Status)
+	}
+
+	if status := dstMP.fsmTxCommit("tx1"); status != proto.OpOk {
+		t.Fatalf("commit coordinator fail: status(%v)", status)
+	}
+	if status := srcMP.fsmTxCommit("tx1"); status != proto.OpOk {
+		t.Fatalf("commit participant fail: status(%v)", status)
+	}
+	if d, status := dstMP.getDentry(&Dentry{ParentId: dstDir, Name: "dst"}); status != proto.OpOk || d.Inode != file {
+		t.Fatalf("dst dentry mismatch: status(%v) dentry(%v)", status, d)
+	}
+	if _, status := srcMP.getDentry(&Dentry{ParentId: srcDir, Name: "src"}); status != proto.OpNotExistErr {
+		t.Fatalf("src dentry should be deleted: status(%v)", status)
+	}
+	// the coordinator keeps the decision, the participant drops the transaction
+	if tx := dstMP.getTx("tx1"); tx == nil || tx.State != proto.TxStateCommitted {
+		t.Fatalf("coordinator transaction mismatch: %v", tx)
+	}
+	if status := srcMP.fsmTxCommit("tx1"); status != proto.OpNotExistErr {
+		t.Fatalf("commit finished participant mismatch: status(%v)", status)
+	}
+	if status := dstMP.fsmTxDelete("tx1"); status != proto.OpArgMismatchErr {
+		t.Fatalf("delete unfinished transaction mismatch: status(%v)", status)
+	}
+	if status := dstMP.fsmTxFinish("tx1"); status != proto.OpOk {
+		t.Fatalf("finish transaction fail: status(%v)", status)
+	}
+	if status := dstMP.fsmTxDelete("tx1"); status != proto.OpOk || dstMP.getTx("tx1") != nil {
+		t.Fatalf("delete transaction fail: status(%v)", status)
+	}
+
+	// link the file, and roll back
+	info = &proto.TxInfo{TxId: "tx3", Timeout: proto.TxDefaultTimeout, Participants: []*proto.TxParticipant{{PartitionID: 2}, {PartitionID: 1}}}
+	resp := srcMP.fsmTxPrepare(&txItem{Tx: info, Ops: []*proto.TxOp{{Type: proto.TxOpLinkInode, Inode: file}}})
+	if resp.Status != proto.OpOk || len(resp.Inodes) != 1 || resp.Inodes[0].Nlink != 2 {
+		t.Fatalf("prepare link fail: status(%v) inodes(%v)", resp.Status, resp.Inodes)
+	}
+	if resp = dstMP.fsmTxPrepare(&txItem{Tx: info, Ops: []*proto.TxOp{{Type: proto.TxOpCreateDentry, ParentId: dstDir, Name: "dst", Inode: file}}}); resp.Status != proto.OpExistErr {
+		t.Fatalf("prepare existing dentry mismatch: status(%v)", resp.Status)
+	}
+	if dstMP.getTx("tx3") != nil {
+		t.Fatalf("failed transaction should not be kept")
+	}
+	if status := srcMP.fsmTxRollback("tx3"); status != proto.OpOk {
+		t.Fatalf("rollback fail: status(%v)", status)
+	}
+	if ino := srcMP.inodeTree.Get(NewInode(file, 0)).(*Inode); ino.NLink != 1 {
+		t.Fatalf("nlink after rollback mismatch: %v", ino.NLink)
+	}
+	if status := srcMP.fsmTxCommit("tx3"); status != proto.OpTxAbortedErr {
+		t.Fatalf("commit rolled back transaction mismatch: status(%v)", status)
+	}
+
+	// the operations are validated again when the transaction commits
+	info = &proto.TxInfo{TxId: "tx4", Timeout: proto.TxDefaultTimeout, Participants: []*proto.TxParticipant{{PartitionID: 1}, {PartitionID: 2}}}
+	dstTx = &txItem{Tx: info, Ops: []*proto.TxOp{{Type: proto.TxOpCreateDentry, ParentId: dstDir, Name: "new", Inode: file, Mode: proto.Mode(0644)}}}
+	srcTx = &txItem{Tx: info, Ops: []*proto.TxOp{{Type: proto.TxOpUnlinkInode, Inode: file}}}
+	if resp = dstMP.fsmTxPrepare(dstTx); resp.Status != proto.OpOk {
+		t.Fatalf("prepare coordinator fail: status(%v)", resp.Status)
+	}
+	if resp = srcMP.fsmTxPrepare(srcTx); resp.Status != proto.OpOk {
+		t.Fatalf("prepare participant fail: status(%v)", resp.Status)
+	}
+	srcMP.inodeTree.Get(NewInode(file, 0)).(*Inode).SetDeleteMark()
+	if status := srcMP.fsmTxCommit("tx4"); status != proto.OpNotExistErr {
+		t.Fatalf("commit invalid participant mismatch: status(%v)", status)
+	}
+	if tx := srcMP.getTx("tx4"); tx == nil || tx.State != proto.TxStatePrepared {
+		t.Fatalf("failed participant should keep the transaction: %v", tx)
+	}
+	dstMP.inodeTree.Get(NewInode(dstDir, 0)).(*Inode).SetDeleteMark()
+	if status := dstMP.fsmTxCommit("tx4"); status != proto.OpTxAbortedErr {
+		t.Fatalf("commit invalid coordinator mismatch: status(%v)", status)
+	}
+	if tx := dstMP.getTx("tx4"); tx == nil || tx.State != proto.TxStateRolledBack || dstMP.isDentryLockedByTx(dstDir, "new") {
+		t.Fatalf("invalid coordinator should roll back: %v", tx)
+	}
+	if _, status := dstMP.getDentry(&Dentry{ParentId: dstDir, Name: "new"}); status != proto.OpNotExistErr {
+		t.Fatalf("rolled back dentry should not be created: status(%v)", status)
+	}
+}
+
+func TestMetaPartition_StoreTransaction(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "transaction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+	mp := newTestPartition(1)
+	mp.fsmCreateInode(NewInode(1, proto.Mode(os.ModeDir|0755)))
+	info := &proto.TxInfo{TxId: "tx1", Timeout: proto.TxDefaultTimeout, Participants: []*proto.TxParticipant{{PartitionID: 1, Members: []string{"127.0.0.1:17210"}}}}
+	tx := &txItem{Tx: info, Ops: []*proto.TxOp{{Type: proto.TxOpCreateDentry, ParentId: 1, Name: "a", Inode: 2}}, CreateTime: 100}
+	if resp := mp.fsmTxPrepare(tx); resp.Status != proto.OpOk {
+		t.Fatalf("prepare fail: status(%v)", resp.Status)
+	}
+	if _, err = mp.storeTransaction(rootDir, &storeMsg{txTree: mp.txTree.GetTree()}); err != nil {
+		t.Fatalf("store transaction fail: err(%v)", err)
+	}
+	loaded := newTestPartition(1)
+	if err = loaded.loadTransaction(rootDir); err != nil {
+		t.Fatalf("load transaction fail: err(%v)", err)
+	}
+	got := loaded.getTx("tx1")
+	if got == nil || got.State != proto.TxStatePrepared || got.CreateTime != 100 || len(got.Ops) != 1 || got.Tx.Participants[0].Members[0] != "127.0.0.1:17210" {
+		t.Fatalf("loaded transaction mismatch: %v", got)
+	}
+	if !loaded.isDentryLockedByTx(1, "a") {
+		t.Fatalf("dentry should be locked after load")
+	}
+	loaded.txDentryLocks = nil
+	loaded.rebuildTxDentryLocks()
+	if !loaded.isDentryLockedByTx(1, "a") || loaded.isDentryLockedByTx(1, "b") {
+		t.Fatalf("dentry locks mismatch after rebuild: %v", loaded.txDentryLocks)
+	}
+}
@@ -0,0 +1,642 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// A volume snapshot is frozen by each meta partition of the volume when the raft log to create the
+// snapshot is applied, so that all the replicas freeze the same copy of the inodes, the dentries
+// and the extended attributes. The copy shares the nodes of the b-trees with the live trees by
+// copy-on-write, so the raft log is applied without copying or writing anything. The copy is
+// stored into its own directory under the partition directory by the next store of the partition,
+// and the list of the snapshots is stored with the partition snapshot, so that the snapshots are
+// loaded with the same apply ID as the partition. The raft snapshot of the partition carries the
+// snapshots as well.
+//
+// The extents referenced by the inodes of a snapshot are not deleted until the snapshot is
+// deleted. The leader keeps such extents for the snapshot through the raft log instead of deleting
+// them, and all the replicas append the kept extents to the extent delete files again when the
+// snapshot is deleted.
+//
+// The meta partitions of a volume freeze the snapshot at different moments, so a snapshot is only
+// crash-consistent across the meta partitions.
+
+const (
+	volSnapshotDirPrefix    = "volsnapshot_"
+	volSnapshotDirTmpPrefix = ".volsnapshot_"
+)
+
+var errVolSnapshotNotExists = errors.New("volume snapshot does not exist")
+
+// volSnapshotOp is the raft log to create or delete a volume snapshot.
+type volSnapshotOp struct {
+	SnapshotId uint64 `json:"id"`
+}
+
+// volSnapshotKeepOp is the raft log to keep the extents referenced by the volume snapshots.
+type volSnapshotKeepOp struct {
+	Keeps []*volSnapshotKeep `json:"keeps"`
+}
+
+type volSnapshotKeep struct {
+	SnapshotId uint64            `json:"id"`
+	Extents    []proto.ExtentKey `json:"eks"`
+}
+
+type volSnapshotExtentKey struct {
+	PartitionId uint64
+	ExtentId    uint64
+}
+
+type volSnapshot struct {
+	id         uint64
+	applyID    uint64
+	inodeTree  *BTree
+	dentryTree *BTree
+	extendTree *BTree
+	// extents referenced by the inodes of the snapshot, with the ranges of the tiny extents since
+	// the ranges of a tiny extent are deleted separately. They are collected at the first check.
+	extentsOnce sync.Once
+	extents     map[volSnapshotExtentKey][]proto.ExtentKey
+	// extents kept for the snapshot instead of being deleted, protected by volSnapshots.
+	kept []proto.ExtentKey
+	// whether the trees are stored into the snapshot directory.
+	stored int32
+}
+
+func newVolSnapshot(id, applyID uint64, inodeTree, dentryTree, extendTree *BTree) *volSnapshot {
+	return &volSnapshot{
+		id:         id,
+		applyID:    applyID,
+		inodeTree:  inodeTree,
+		dentryTree: dentryTree,
+		extendTree: extendTree,
+	}
+}
+
+func (s *volSnapshot) collectExtents() {
+	s.extents = make(map[volSnapshotExtentKey][]proto.ExtentKey)
+	s.inodeTree.Ascend(func(i BtreeItem) bool {
+		ino := i.(*Inode)
+		if ino.ShouldDelete() {
+			return true
+		}
+		ino.Extents.Range(func(ek proto.ExtentKey) bool {
+			key := volSnapshotExtentKey{PartitionId: ek.PartitionId, ExtentId: ek.ExtentId}
+			if storage.IsTinyExtent(ek.ExtentId) {
+				s.extents[key] = append(s.extents[key], ek)
+			} else if _, ok := s.extents[key]; !ok {
+				s.extents[key] = nil
+			}
+			return true
+		})
+		return true
+	})
+}
+
+func (s *volSnapshot) isExtentReferenced(ek *proto.ExtentKey) bool {
+	s.extentsOnce.Do(s.collectExtents)
+	refs, ok := s.extents[volSnapshotExtentKey{PartitionId: ek.PartitionId, ExtentId: ek.ExtentId}]
+	if !ok {
+		return false
+	}
+	if !storage.IsTinyExtent(ek.ExtentId) {
+		return true
+	}
+	for _, ref := range refs {
+		if ref.ExtentOffset < ek.ExtentOffset+uint64(ek.Size) && ek.ExtentOffset < ref.ExtentOffset+uint64(ref.Size) {
+			return true
+		}
+	}
+	return false
+}
+
+// volSnapshotMeta is the snapshot recorded in the partition snapshot and the raft snapshot.
+type volSnapshotMeta struct {
+	Id       uint64            `json:"id"`
+	ApplyID  uint64            `json:"apply"`
+	Kept     []proto.ExtentKey `json:"kept,omitempty"`
+	snapshot *volSnapshot
+}
+
+// volSnapshotTreeItem is an item of the trees of a snapshot in the raft snapshot.
+type volSnapshotTreeItem struct {
+	snapshotId uint64
+	item       BtreeItem
+}
+
+func volSnapshotIdKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+func (m *volSnapshotMeta) metaItem() (snap *MetaItem, err error) {
+	var raw []byte
+	if raw, err = json.Marshal(m); err != nil {
+		return
+	}
+	return NewMetaItem(opVolSnapshotSnapshot, volSnapshotIdKey(m.Id), raw), nil
+}
+
+// metaItem wraps the item of the tree as the item of the partition snapshot.
+func (i *volSnapshotTreeItem) metaItem() (snap *MetaItem, err error) {
+	var inner *MetaItem
+	switch typedItem := i.item.(type) {
+	case *Inode:
+		inner = NewMetaItem(opFSMCreateInode, typedItem.MarshalKey(), typedItem.MarshalValue())
+	case *Dentry:
+		inner = NewMetaItem(opFSMCreateDentry, typedItem.MarshalKey(), typedItem.MarshalValue())
+	case *Extend:
+		var raw []byte
+		if raw, err = typedItem.Bytes(); err != nil {
+			return
+		}
+		inner = NewMetaItem(opFSMSetXAttr, nil, raw)
+	default:
+		return nil, fmt.Errorf("unknown volume snapshot item: %T", i.item)
+	}
+	var raw []byte
+	if raw, err = inner.MarshalBinary(); err != nil {
+		return
+	}
+	return NewMetaItem(opVolSnapshotItemSnapshot, volSnapshotIdKey(i.snapshotId), raw), nil
+}
+
+// applyVolSnapshotItem rebuilds the snapshots from the items of the raft snapshot.
+func applyVolSnapshotItem(snapshots *volSnapshots, snap *MetaItem) (err error) {
+	if len(snap.K) != 8 {
+		return fmt.Errorf("invalid volume snapshot key: %v", snap.K)
+	}
+	if snap.Op == opVolSnapshotSnapshot {
+		meta := &volSnapshotMeta{}
+		if err = json.Unmarshal(snap.V, meta); err != nil {
+			return
+		}
+		snapshot := newVolSnapshot(meta.Id, meta.ApplyID, NewBtree(), NewBtree(), NewBtree())
+		snapshot.kept = meta.Kept
+		snapshots.put(snapshot)
+		return
+	}
+	snapshot := snapshots.get(binary.BigEndian.Uint64(snap.K))
+	if snapshot == nil {
+		return fmt.Errorf("volume snapshot %v does not exist", binary.BigEndian.Uint64(snap.K))
+	}
+	inner := NewMetaItem(0, nil, nil)
+	if err = inner.UnmarshalBinary(snap.V); err != nil {
+		return
+	}
+	switch inner.Op {
+	case opFSMCreateInode:
+		ino := NewInode(0, 0)
+		if err = ino.UnmarshalKey(inner.K); err != nil {
+			return
+		}
+		if err = ino.UnmarshalValue(inner.V); err != nil {
+			return
+		}
+		snapshot.inodeTree.ReplaceOrInsert(ino, true)
+	case opFSMCreateDentry:
+		dentry := &Dentry{}
+		if err = dentry.UnmarshalKey(inner.K); err != nil {
+			return
+		}
+		if err = dentry.UnmarshalValue(inner.V); err != nil {
+			return
+		}
+		snapshot.dentryTree.ReplaceOrInsert(dentry, true)
+	case opFSMSetXAttr:
+		var extend *Extend
+		if extend, err = NewExtendFromBytes(inner.V); err != nil {
+			return
+		}
+		snapshot.extendTree.ReplaceOrInsert(extend, true)
+	default:
+		return fmt.Errorf("unknown volume snapshot item op=%d", inner.Op)
+	}
+	return
+}
+
+// volSnapshots keeps the volume snapshots frozen by the meta partition.
+type volSnapshots struct {
+	sync.RWMutex
+	snapshots map[uint64]*volSnapshot
+}
+
+func newVolSnapshots() *volSnapshots {
+	return &volSnapshots{snapshots: make(map[uint64]*volSnapshot)}
+}
+
+func (vs *volSnapshots) get(id uint64) *volSnapshot {
+	if vs == nil {
+		return nil
+	}
+	vs.RLock()
+	defer vs.RUnlock()
+	return vs.snapshots[id]
+}
+
+func (vs *volSnapshots) put(snapshot *volSnapshot) {
+	vs.Lock()
+	vs.snapshots[snapshot.id] = snapshot
+	vs.Unlock()
+}
+
+func (vs *volSnapshots) delete(id uint64) *volSnapshot {
+	vs.Lock()
+	defer vs.Unlock()
+	snapshot := vs.snapshots[id]
+	delete(vs.snapshots, id)
+	return snapshot
+}
+
+// reset replaces the snapshots with the ones rebuilt from the raft snapshot.
+func (vs *volSnapshots) reset(other *volSnapshots) {
+	vs.Lock()
+	vs.snapshots = other.snapshots
+	vs.Unlock()
+}
+
+// keep adds the extents to the kept extents of the snapshot, and returns false if the snapshot
+// does not exist.
+func (vs *volSnapshots) keep(id uint64, eks []proto.ExtentKey) bool {
+	vs.Lock()
+	defer vs.Unlock()
+	snapshot, ok := vs.snapshots[id]
+	if !ok {
+		return false
+	}
+	snapshot.kept = append(snapshot.kept, eks...)
+	return true
+}
+
+// list returns the snapshots sorted by the ID, with the copy of the kept extents.
+func (vs *volSnapshots) list() []*volSnapshotMeta {
+	if vs == nil {
+		return nil
+	}
+	vs.RLock()
+	metas := make([]*volSnapshotMeta, 0, len(vs.snapshots))
+	for _, snapshot := range vs.snapshots {
+		metas = append(metas, &volSnapshotMeta{
+			Id:       snapshot.id,
+			ApplyID:  snapshot.applyID,
+			Kept:     append([]proto.ExtentKey(nil), snapshot.kept...),
+			snapshot: snapshot,
+		})
+	}
+	vs.RUnlock()
+	sort.Slice(metas, func(i, j int) bool { return metas[i].Id < metas[j].Id })
+	return metas
+}
+
+// referencedBy returns the least ID of the snapshots which reference the extent, or zero if the
+// extent is not referenced. The extents of the snapshots are collected without holding the lock,
+// so that the raft logs are not blocked.
+func (vs *volSnapshots) referencedBy(ek *proto.ExtentKey) (id uint64) {
+	if vs == nil {
+		return 0
+	}
+	vs.RLock()
+	snapshots := make([]*volSnapshot, 0, len(vs.snapshots))
+	for _, snapshot := range vs.snapshots {
+		snapshots = append(snapshots, snapshot)
+	}
+	vs.RUnlock()
+	for _, snapshot := range snapshots {
+		if snapshot.isExtentReferenced(ek) && (id == 0 || snapshot.id < id) {
+			id = snapshot.id
+		}
+	}
+	return
+}
+
+// isExtentReferenced returns whether the extent is referenced by any of the snapshots.
+func (vs *volSnapshots) isExtentReferenced(ek *proto.ExtentKey) bool {
+	return vs.referencedBy(ek) != 0
+}
+
+// isInodeReferenced returns whether any extent of the inode is referenced by the snapshots.
+func (vs *volSnapshots) isInodeReferenced(ino *Inode) (referenced bool) {
+	if vs == nil {
+		return false
+	}
+	ino.Extents.Range(func(ek proto.ExtentKey) bool {
+		referenced = vs.isExtentReferenced(&ek)
+		return !referenced
+	})
+	return
+}
+
+// CreateVolSnapshot freezes the volume snapshot through the raft log.
+func (mp *metaPartition) CreateVolSnapshot(req *proto.CreateMetaSnapshotRequest, p *Packet) (err error) {
+	val, err := json.Marshal(&volSnapshotOp{SnapshotId: req.SnapshotId})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMCreateVolSnapshot, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
+// DeleteVolSnapshot drops the volume snapshot through the raft log.
+func (mp *metaPartition) DeleteVolSnapshot(req *proto.DeleteMetaSnapshotRequest, p *Packet) (err error) {
+	val, err := json.Marshal(&volSnapshotOp{SnapshotId: req.SnapshotId})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMDeleteVolSnapshot, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
+// GetVolSnapshotView returns the read-only view of the meta partition at the volume snapshot, or
+// the partition itself if the snapshot ID is zero. Only the read operations are served by the view.
+func (mp *metaPartition) GetVolSnapshotView(snapshotId uint64) (MetaPartition, error) {
+	if snapshotId == 0 {
+		return mp, nil
+	}
+	snapshot := mp.volSnapshots.get(snapshotId)
+	if snapshot == nil {
+		return nil, errVolSnapshotNotExists
+	}
+	return &metaPartition{
+		config:        mp.config,
+		applyID:       snapshot.applyID,
+		inodeTree:     snapshot.inodeTree,
+		dentryTree:    snapshot.dentryTree,
+		extendTree:    snapshot.extendTree,
+		multipartTree: NewBtree(),
+		vol:           mp.vol,
+		manager:       mp.manager,
+	}, nil
+}
+
+func (mp *metaPartition) volSnapshotDir(id uint64) string {
+	return path.Join(mp.config.RootDir, volSnapshotDirPrefix+strconv.FormatUint(id, 10))
+}
+
+func (mp *metaPartition) fsmCreateVolSnapshot(id, index uint64) (status uint8) {
+	if mp.volSnapshots.get(id) != nil {
+		return proto.OpOk
+	}
+	snapshot := newVolSnapshot(id, index, mp.inodeTree.GetTree(), mp.dentryTree.GetTree(), mp.extendTree.GetTree())
+	mp.volSnapshots.put(snapshot)
+	log.LogInfof("fsmCreateVolSnapshot: partitionID(%v) snapshotID(%v) applyID(%v) numInodes(%v) numDentries(%v)",
+		mp.config.PartitionId, id, index, snapshot.inodeTree.Len(), snapshot.dentryTree.Len())
+	return proto.OpOk
+}
+
+// fsmDeleteVolSnapshot drops the snapshot and deletes the extents kept for it again. The snapshot
+// directory is removed by the next store of the partition.
+func (mp *metaPartition) fsmDeleteVolSnapshot(id uint64) (status uint8) {
+	snapshot := mp.volSnapshots.delete(id)
+	if snapshot != nil && len(snapshot.kept) > 0 {
+		mp.extDelCh <- snapshot.kept
+	}
+	log.LogInfof("fsmDeleteVolSnapshot: partitionID(%v) snapshotID(%v)", mp.config.PartitionId, id)
+	return proto.OpOk
+}
+
+// fsmKeepVolSnapshotExtents keeps the extents for the snapshots, or deletes them again if the
+// snapshot has been deleted.
+func (mp *metaPartition) fsmKeepVolSnapshotExtents(op *volSnapshotKeepOp) (status uint8) {
+	for _, keep := range op.Keeps {
+		if !mp.volSnapshots.keep(keep.SnapshotId, keep.Extents) {
+			mp.extDelCh <- keep.Extents
+		}
+	}
+	return proto.OpOk
+}
+
+// keepVolSnapshotExtents submits the extents referenced by the snapshots, which are kept instead
+// of being deleted.
+func (mp *metaPartition) keepVolSnapshotExtents(keeps []*volSnapshotKeep) (err error) {
+	if len(keeps) == 0 {
+		return
+	}
+	val, err := json.Marshal(&volSnapshotKeepOp{Keeps: keeps})
+	if err != nil {
+		return
+	}
+	_, err = mp.submit(opFSMKeepVolSnapshotExtents, val)
+	return
+}
+
+// storeVolSnapshots stores the trees of the snapshots which are not stored yet, and the list of
+// the snapshots into the partition snapshot.
+func (mp *metaPartition) storeVolSnapshots(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	for _, meta := range sm.volSnapshots {
+		if atomic.LoadInt32(&meta.snapshot.stored) != 0 {
+			continue
+		}
+		if err = mp.storeVolSnapshot(meta.snapshot); err != nil {
+			return
+		}
+		atomic.StoreInt32(&meta.snapshot.stored, 1)
+	}
+	metas := sm.volSnapshots
+	if metas == nil {
+		metas = make([]*volSnapshotMeta, 0)
+	}
+	data, err := json.Marshal(metas)
+	if err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.OpenFile(path.Join(rootDir, volSnapshotFile), os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0755); err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	crc = crc32.ChecksumIEEE(data)
+	log.LogInfof("storeVolSnapshots: store complete: partitionID(%v) volume(%v) numSnapshots(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, len(metas), crc)
+	return
+}
+
+// storeVolSnapshot stores the trees of the snapshot into a temporary directory and renames it, so
+// that a snapshot directory is always complete. The directory stored before is kept since the
+// trees of a snapshot never change.
+func (mp *metaPartition) storeVolSnapshot(snapshot *volSnapshot) (err error) {
+	if _, err = os.Stat(mp.volSnapshotDir(snapshot.id)); err == nil {
+		return
+	}
+	tmpDir := path.Join(mp.config.RootDir, volSnapshotDirTmpPrefix+strconv.FormatUint(snapshot.id, 10))
+	if err = os.RemoveAll(tmpDir); err != nil {
+		return
+	}
+	if err = os.MkdirAll(tmpDir, 0775); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(tmpDir)
+		}
+	}()
+	sm := &storeMsg{
+		applyIndex: snapshot.applyID,
+		inodeTree:  snapshot.inodeTree,
+		dentryTree: snapshot.dentryTree,
+		extendTree: snapshot.extendTree,
+	}
+	var storeFuncs = []func(dir string, sm *storeMsg) (uint32, error){
+		mp.storeInode,
+		mp.storeDentry,
+		mp.storeExtend,
+	}
+	for _, storeFunc := range storeFuncs {
+		if _, err = storeFunc(tmpDir, sm); err != nil {
+			return
+		}
+	}
+	if err = mp.storeApplyID(tmpDir, sm); err != nil {
+		return
+	}
+	return os.Rename(tmpDir, mp.volSnapshotDir(snapshot.id))
+}
+
+// removeVolSnapshotDirs removes the directories of the snapshots which are not in the list, and
+// the snapshots which were not completely stored.
+func (mp *metaPartition) removeVolSnapshotDirs(metas []*volSnapshotMeta) {
+	fileInfos, err := ioutil.ReadDir(mp.config.RootDir)
+	if err != nil {
+		log.LogErrorf("removeVolSnapshotDirs: read dir fail: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+		return
+	}
+	ids := make(map[uint64]bool, len(metas))
+	for _, meta := range metas {
+		ids[meta.Id] = true
+	}
+	for _, info := range fileInfos {
+		name := info.Name()
+		if !info.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, volSnapshotDirPrefix) {
+			id, parseErr := strconv.ParseUint(strings.TrimPrefix(name, volSnapshotDirPrefix), 10, 64)
+			if parseErr == nil && ids[id] {
+				continue
+			}
+		} else if !strings.HasPrefix(name, volSnapshotDirTmpPrefix) {
+			continue
+		}
+		if err = os.RemoveAll(path.Join(mp.config.RootDir, name)); err != nil {
+			log.LogErrorf("removeVolSnapshotDirs: remove fail: partitionID(%v) name(%v) err(%v)",
+				mp.config.PartitionId, name, err)
+		}
+	}
+}
+
+// loadVolSnapshots loads the snapshots in the list stored with the partition snapshot, and removes
+// the other snapshot directories.
+func (mp *metaPartition) loadVolSnapshots(rootDir string) (err error) {
+	filename := path.Join(rootDir, volSnapshotFile)
+	var metas []*volSnapshotMeta
+	if _, err = os.Stat(filename); err == nil {
+		var data []byte
+		if data, err = ioutil.ReadFile(filename); err != nil {
+			return
+		}
+		if err = json.Unmarshal(data, &metas); err != nil {
+			return
+		}
+	}
+	err = nil
+	for _, meta := range metas {
+		var snapshot *volSnapshot
+		if snapshot, err = mp.loadVolSnapshot(meta.Id); err != nil {
+			err = fmt.Errorf("load volume snapshot %v: %v", meta.Id, err)
+			return
+		}
+		snapshot.kept = meta.Kept
+		snapshot.stored = 1
+		mp.volSnapshots.put(snapshot)
+	}
+	mp.removeVolSnapshotDirs(metas)
+	log.LogInfof("loadVolSnapshots: load complete: partitionID(%v) numSnapshots(%v) filename(%v)",
+		mp.config.PartitionId, len(metas), filename)
+	return
+}
+
+func (mp *metaPartition) loadVolSnapshot(id uint64) (snapshot *volSnapshot, err error) {
+	// Load the snapshot with a partition of the copied config, so that the cursor of the
+	// partition is not affected.
+	config := *mp.config
+	loader := &metaPartition{
+		config:     &config,
+		inodeTree:  NewBtree(),
+		dentryTree: NewBtree(),
+		extendTree: NewBtree(),
+		freeList:   newFreeList(),
+	}
+	dir := mp.volSnapshotDir(id)
+	if _, err = os.Stat(dir); err != nil {
+		return
+	}
+	if err = loader.loadInode(dir); err != nil {
+		return
+	}
+	if err = loader.loadDentry(dir); err != nil {
+		return
+	}
+	if err = loader.loadExtend(dir); err != nil {
+		return
+	}
+	if err = loader.loadApplyID(dir); err != nil {
+		return
+	}
+	snapshot = newVolSnapshot(id, loader.applyID, loader.inodeTree, loader.dentryTree, loader.extendTree)
+	log.LogInfof("loadVolSnapshot: partitionID(%v) snapshotID(%v) applyID(%v)", mp.config.PartitionId, id, snapshot.applyID)
+	return
+}
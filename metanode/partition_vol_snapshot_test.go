@@ -0,0 +1,163 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartition_VolSnapshot(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "volsnapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+	mp := newTestPartition(1)
+	mp.config.RootDir = rootDir
+
+	const (
+		dir uint64 = iota + 1
+		file
+	)
+	mp.fsmCreateInode(NewInode(dir, proto.Mode(os.ModeDir|0755)))
+	fileInode := NewInode(file, proto.Mode(0644))
+	fileInode.Extents.Append(proto.ExtentKey{PartitionId: 10, ExtentId: 1025, Size: 100})
+	fileInode.Extents.Append(proto.ExtentKey{FileOffset: 100, PartitionId: 10, ExtentId: 1, ExtentOffset: 4096, Size: 100})
+	fileInode.Size = 200
+	mp.fsmCreateInode(fileInode)
+	mp.fsmCreateDentry(&Dentry{ParentId: dir, Name: "file", Inode: file, Type: fileInode.Type}, false)
+
+	if status := mp.fsmCreateVolSnapshot(100, 10); status != proto.OpOk {
+		t.Fatalf("create snapshot fail: status(%v)", status)
+	}
+
+	// The changes after the snapshot are not seen through the snapshot.
+	mp.fsmDeleteDentry(&Dentry{ParentId: dir, Name: "file"}, false)
+	mp.fsmUnlinkInode(NewInode(file, 0))
+	view, err := mp.GetVolSnapshotView(100)
+	if err != nil {
+		t.Fatalf("get snapshot view fail: err(%v)", err)
+	}
+	if dentry, status := view.(*metaPartition).getDentry(&Dentry{ParentId: dir, Name: "file"}); status != proto.OpOk || dentry.Inode != file {
+		t.Fatalf("lookup in snapshot mismatch: status(%v) dentry(%v)", status, dentry)
+	}
+	if _, status := mp.getDentry(&Dentry{ParentId: dir, Name: "file"}); status != proto.OpNotExistErr {
+		t.Fatalf("lookup in live partition mismatch: status(%v)", status)
+	}
+	if _, err = mp.GetVolSnapshotView(200); err != errVolSnapshotNotExists {
+		t.Fatalf("get unknown snapshot mismatch: err(%v)", err)
+	}
+
+	// The extents of the snapshot are kept, and only the overlapped ranges of the tiny extents.
+	if !mp.volSnapshots.isInodeReferenced(fileInode) {
+		t.Fatalf("inode should be referenced by the snapshot")
+	}
+	cases := []struct {
+		ek         proto.ExtentKey
+		referenced bool
+	}{
+		{proto.ExtentKey{PartitionId: 10, ExtentId: 1025, ExtentOffset: 1000, Size: 10}, true},
+		{proto.ExtentKey{PartitionId: 10, ExtentId: 1026, Size: 10}, false},
+		{proto.ExtentKey{PartitionId: 11, ExtentId: 1025, Size: 10}, false},
+		{proto.ExtentKey{PartitionId: 10, ExtentId: 1, ExtentOffset: 4000, Size: 100}, true},
+		{proto.ExtentKey{PartitionId: 10, ExtentId: 1, ExtentOffset: 4196, Size: 100}, false},
+	}
+	for _, c := range cases {
+		if referenced := mp.volSnapshots.isExtentReferenced(&c.ek); referenced != c.referenced {
+			t.Fatalf("extent referenced mismatch: ek(%v) expect(%v) actual(%v)", c.ek, c.referenced, referenced)
+		}
+	}
+
+	// The extents are kept for the snapshot, or deleted again if the snapshot has been deleted.
+	kept := proto.ExtentKey{PartitionId: 10, ExtentId: 1025, Size: 100}
+	if id := mp.volSnapshots.referencedBy(&kept); id != 100 {
+		t.Fatalf("referencing snapshot mismatch: %v", id)
+	}
+	mp.fsmKeepVolSnapshotExtents(&volSnapshotKeepOp{Keeps: []*volSnapshotKeep{
+		{SnapshotId: 100, Extents: []proto.ExtentKey{kept}},
+		{SnapshotId: 200, Extents: []proto.ExtentKey{{PartitionId: 10, ExtentId: 1026}}},
+	}})
+	if eks := <-mp.extDelCh; len(eks) != 1 || eks[0].ExtentId != 1026 {
+		t.Fatalf("extents of unknown snapshot should be deleted: %v", eks)
+	}
+
+	// The snapshot is stored by the store of the partition, and loaded after restarts.
+	snapshotPath := path.Join(rootDir, snapshotDir)
+	if err = os.MkdirAll(snapshotPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.storeVolSnapshots(snapshotPath, &storeMsg{volSnapshots: mp.volSnapshots.list()}); err != nil {
+		t.Fatalf("store snapshots fail: err(%v)", err)
+	}
+	loaded := newTestPartition(1)
+	loaded.config.RootDir = rootDir
+	if err = loaded.loadVolSnapshots(snapshotPath); err != nil {
+		t.Fatalf("load snapshots fail: err(%v)", err)
+	}
+	snapshot := loaded.volSnapshots.get(100)
+	if snapshot == nil || snapshot.applyID != 10 || snapshot.inodeTree.Len() != 2 || snapshot.dentryTree.Len() != 1 ||
+		len(snapshot.kept) != 1 || snapshot.kept[0] != kept {
+		t.Fatalf("loaded snapshot mismatch: %v", snapshot)
+	}
+
+	// The snapshot is rebuilt from the raft snapshot.
+	rebuilt := newVolSnapshots()
+	items := []interface{ metaItem() (*MetaItem, error) }{mp.volSnapshots.list()[0]}
+	for _, tree := range []*BTree{snapshot.inodeTree, snapshot.dentryTree, snapshot.extendTree} {
+		tree.Ascend(func(i BtreeItem) bool {
+			items = append(items, &volSnapshotTreeItem{snapshotId: 100, item: i})
+			return true
+		})
+	}
+	for _, item := range items {
+		snap, err := item.metaItem()
+		if err != nil {
+			t.Fatalf("marshal snapshot item fail: err(%v)", err)
+		}
+		data, err := snap.MarshalBinary()
+		if err != nil {
+			t.Fatalf("marshal snapshot item fail: err(%v)", err)
+		}
+		snap = NewMetaItem(0, nil, nil)
+		if err = snap.UnmarshalBinary(data); err != nil {
+			t.Fatalf("unmarshal snapshot item fail: err(%v)", err)
+		}
+		if err = applyVolSnapshotItem(rebuilt, snap); err != nil {
+			t.Fatalf("apply snapshot item fail: err(%v)", err)
+		}
+	}
+	if snapshot = rebuilt.get(100); snapshot == nil || snapshot.applyID != 10 || snapshot.inodeTree.Len() != 2 ||
+		snapshot.dentryTree.Len() != 1 || len(snapshot.kept) != 1 || !snapshot.isExtentReferenced(&kept) {
+		t.Fatalf("rebuilt snapshot mismatch: %v", snapshot)
+	}
+
+	// The kept extents are deleted with the snapshot, and the directory by the next store.
+	mp.fsmDeleteVolSnapshot(100)
+	if mp.volSnapshots.isInodeReferenced(fileInode) {
+		t.Fatalf("inode should not be referenced after the snapshot is deleted")
+	}
+	if eks := <-mp.extDelCh; len(eks) != 1 || eks[0] != kept {
+		t.Fatalf("kept extents should be deleted with the snapshot: %v", eks)
+	}
+	mp.removeVolSnapshotDirs(mp.volSnapshots.list())
+	if _, err = os.Stat(mp.volSnapshotDir(100)); !os.IsNotExist(err) {
+		t.Fatalf("snapshot dir should be removed: err(%v)", err)
+	}
+}
@@ -74,6 +74,7 @@ var (
 		ReadPermission: {
 			proto.OSSGetObjectAction,
 			proto.OSSGetObjectTorrentAction,
+			proto.OSSSelectObjectContentAction,
 		},
 		WritePermission: {},
 		ReadACPPermission: {
@@ -84,6 +85,7 @@ var (
 		FullControlPermission: {
 			proto.OSSGetObjectAction,
 			proto.OSSGetObjectTorrentAction,
+			proto.OSSSelectObjectContentAction,
 			proto.OSSGetObjectAclAction,
 			proto.OSSPutObjectAclAction,
 		},
@@ -189,7 +191,19 @@ func (acp *AccessControlPolicy) Validate(bucket string) (bool, error) {
 	return true, nil
 }
 
-func (acp *AccessControlPolicy) IsAllowed(param *RequestParam, isOwner bool) bool {
+// IsPublic returns whether the ACL grants any permission to all users.
+func (acp *AccessControlPolicy) IsPublic() bool {
+	for _, grant := range acp.Acl.Grants {
+		if grant.IsPublic() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAllowed checks whether the request is allowed by the grants of the ACL. The grants to all
+// users are ignored if ignorePublic is set by the public access block settings of the bucket.
+func (acp *AccessControlPolicy) IsAllowed(param *RequestParam, isOwner, ignorePublic bool) bool {
 	log.LogDebugf("acl is allowed: %v param: %v", acp, param)
 	if len(acp.Acl.Grants) == 0 {
 		return true
@@ -198,6 +212,9 @@ func (acp *AccessControlPolicy) IsAllowed(param *RequestParam, isOwner bool) boo
 		return true
 	}
 	for _, grant := range acp.Acl.Grants {
+		if ignorePublic && grant.IsPublic() {
+			continue
+		}
 		if grant.IsAllowed(param) {
 			return true
 		}
@@ -219,6 +236,8 @@ var (
 	}
 )
 
+const authenticatedUsersURI = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketAcl.html
 func (acp *AccessControlPolicy) SetBucketStandardACL(param *RequestParam, acl string) {
 	sacl := StandardACL(acl)
@@ -306,8 +325,13 @@ func (g Grant) Validate() bool {
 	return true
 }
 
+// IsPublic returns whether the grantee of the grant is all users or all authenticated users.
+func (g *Grant) IsPublic() bool {
+	return g.Grantee.URI == aclRoleURIMap[allUsersRole] || g.Grantee.URI == authenticatedUsersURI
+}
+
 func (g *Grant) IsAllowed(param *RequestParam) bool {
-	if param.accessKey != g.Grantee.Id {
+	if !g.IsPublic() && param.accessKey != g.Grantee.Id {
 		return false
 	}
 	actions := aclBucketPermissionActions[g.Permission]
@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
@@ -137,6 +138,21 @@ func (o *ObjectNode) putBucketACLHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// The public ACL is rejected if it is blocked by the public access block settings.
+	var block *proto.PublicAccessBlock
+	if block, err = o.publicAccessBlock(vol); err != nil {
+		log.LogErrorf("putBucketACLHandler: load public access block fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if block.BlockPublicAcls && acp.IsPublic() {
+		log.LogWarnf("putBucketACLHandler: public ACL blocked: requestID(%v) volume(%v)",
+			GetRequestID(r), vol.Name())
+		_ = PublicAccessBlocked.ServeResponse(w, r)
+		return
+	}
+
 	var newBytes []byte
 	if newBytes, err = acp.Marshal(); err != nil {
 		return
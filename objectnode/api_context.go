@@ -26,8 +26,12 @@ import (
 const (
 	ContextKeyRequestID     = "ctx_request_id"
 	ContextKeyRequestAction = "ctx_request_action"
+	ContextKeyAccessKey     = "ctx_access_key"
+	ContextKeySessionKey    = "ctx_session_access_key"
+	ContextKeySessionPolicy = "ctx_session_policy"
 	ContextKeyStatusCode    = "status_code"
 	ContextKeyErrorMessage  = "error_message"
+	ContextKeyErrorCode     = "error_code"
 )
 
 func SetRequestID(r *http.Request, requestID string) {
@@ -46,6 +50,29 @@ func GetActionFromContext(r *http.Request) (action proto.Action) {
 	return proto.ParseAction(mux.Vars(r)[ContextKeyRequestAction])
 }
 
+// SetRequestAccessKey stores the access key of the user the request is performed as, which takes
+// precedence over the access key in the authorization header or URL parameters. It is used by the
+// POST Object request, which is authenticated by the form, and by the request signed with temporary
+// credentials, which is performed as the user who assumed the credentials.
+func SetRequestAccessKey(r *http.Request, accessKey string) {
+	mux.Vars(r)[ContextKeyAccessKey] = accessKey
+}
+
+func GetAccessKeyFromContext(r *http.Request) string {
+	return mux.Vars(r)[ContextKeyAccessKey]
+}
+
+// SetRequestSession stores the access key and session policy of the temporary credentials which
+// the request is signed with.
+func SetRequestSession(r *http.Request, sessionAccessKey, sessionPolicy string) {
+	mux.Vars(r)[ContextKeySessionKey] = sessionAccessKey
+	mux.Vars(r)[ContextKeySessionPolicy] = sessionPolicy
+}
+
+func GetSessionFromContext(r *http.Request) (sessionAccessKey, sessionPolicy string) {
+	return mux.Vars(r)[ContextKeySessionKey], mux.Vars(r)[ContextKeySessionPolicy]
+}
+
 func SetResponseStatusCode(r *http.Request, code ErrorCode) {
 	mux.Vars(r)[ContextKeyStatusCode] = strconv.Itoa(code.StatusCode)
 }
@@ -65,3 +92,11 @@ func SetResponseErrorMessage(r *http.Request, message string) {
 func getResponseErrorMessage(r *http.Request) string {
 	return mux.Vars(r)[ContextKeyErrorMessage]
 }
+
+func SetResponseErrorCode(r *http.Request, code string) {
+	mux.Vars(r)[ContextKeyErrorCode] = code
+}
+
+func getResponseErrorCode(r *http.Request) string {
+	return mux.Vars(r)[ContextKeyErrorCode]
+}
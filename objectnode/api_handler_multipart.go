@@ -97,12 +97,23 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		CacheControl: cacheControl,
 		Expires:      expires,
 	}
+	if opt.Encryption, errorCode = parseSSEOption(r, vol); errorCode != nil {
+		return
+	}
+	if errorCode = parseObjectLockOption(r, vol, opt); errorCode != nil {
+		return
+	}
+	if errorCode = parseReplicationOption(r, vol, param.Object(), opt); errorCode != nil {
+		return
+	}
 
 	var uploadID string
 	if uploadID, err = vol.InitMultipart(param.Object(), opt); err != nil {
 		log.LogErrorf("createMultipleUploadHandler:  init multipart fail, requestID(%v) err(%v)",
 			GetRequestID(r), err)
-		errorCode = InternalErrorCode(err)
+		if errorCode = sseErrorCode(err); errorCode == nil {
+			errorCode = InternalErrorCode(err)
+		}
 		return
 	}
 
@@ -124,6 +135,9 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 	// set response header
 	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
 	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+	if opt.Encryption != nil {
+		setSSEResponseHeaders(w, &FSFileInfo{SSEAlgorithm: opt.Encryption.Algorithm, SSEKeyMD5: opt.Encryption.CustomerKeyMD5})
+	}
 	if _, err = w.Write(bytes); err != nil {
 		log.LogErrorf("createMultipleUploadHandler: write response body fail, requestID(%v) err(%v)",
 			GetRequestID(r), err)
@@ -167,6 +181,12 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = InvalidArgument
 		return
 	}
+	if partNumberInt < MinPartNumber || partNumberInt > MaxPartNumber {
+		log.LogErrorf("uploadPartHandler: part number out of range, requestID(%v) partNumber(%v)",
+			GetRequestID(r), partNumberInt)
+		errorCode = InvalidArgument
+		return
+	}
 
 	if param.Bucket() == "" {
 		errorCode = InvalidBucketName
@@ -185,13 +205,40 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The customer key must be specified for each part of the upload encrypted with SSE-C.
+	var sseOption *SSEOption
+	if sseOption, errorCode = parseSSECustomerKey(r, HeaderNameXAmzSSECustomerAlgorithm,
+		HeaderNameXAmzSSECustomerKey, HeaderNameXAmzSSECustomerKeyMD5); errorCode != nil {
+		return
+	}
+
+	var body io.Reader = r.Body
+	if isStreamingSignedPayload(r) {
+		if body, err = o.newSignedChunkedReaderV4(r); err != nil {
+			log.LogWarnf("uploadPartHandler: init signed chunked reader fail: requestID(%v) volume(%v) path(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), err)
+			errorCode = InvalidArgument
+			return
+		}
+	}
+
 	// handle exception
 	var fsFileInfo *FSFileInfo
-	fsFileInfo, err = vol.WritePart(param.Object(), uploadId, uint16(partNumberInt), r.Body)
+	fsFileInfo, err = vol.WritePart(param.Object(), uploadId, uint16(partNumberInt), body, sseOption)
 	if err == syscall.ENOENT {
 		errorCode = NoSuchUpload
 		return
 	}
+	if sseCode := sseErrorCode(err); sseCode != nil {
+		errorCode = sseCode
+		return
+	}
+	if chunkCode := chunkErrorCode(err); chunkCode != nil {
+		log.LogWarnf("uploadPartHandler: read chunked payload fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) remote(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, getRequestIP(r), err)
+		errorCode = chunkCode
+		return
+	}
 	if err == io.ErrUnexpectedEOF {
 		log.LogWarnf("uploadPartHandler: write part fail cause unexpected EOF: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) remote(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, getRequestIP(r), err)
@@ -215,6 +262,206 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// Upload part copy
+// Uploads a part by copying data from an existing object as data source.
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_UploadPartCopy.html .
+func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+			return
+		}
+	}()
+
+	// check args
+	var param = ParseRequestParam(r)
+
+	// get upload id and part number
+	uploadId := param.GetVar(ParamUploadId)
+	partNumber := param.GetVar(ParamPartNumber)
+	if uploadId == "" || partNumber == "" {
+		log.LogErrorf("uploadPartCopyHandler: illegal uploadID or partNumber, requestID(%v)", GetRequestID(r))
+		errorCode = InvalidArgument
+		return
+	}
+
+	var partNumberInt uint64
+	if partNumberInt, err = strconv.ParseUint(partNumber, 10, 64); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: parse part number fail, requestID(%v) raw(%v) err(%v)",
+			GetRequestID(r), partNumber, err)
+		errorCode = InvalidArgument
+		return
+	}
+	if partNumberInt < MinPartNumber || partNumberInt > MaxPartNumber {
+		log.LogErrorf("uploadPartCopyHandler: part number out of range, requestID(%v) partNumber(%v)",
+			GetRequestID(r), partNumberInt)
+		errorCode = InvalidArgument
+		return
+	}
+
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	sourceBucket, sourceObject := parseCopySourceInfo(r)
+
+	// check permission, must have read permission to source bucket
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKey(param.AccessKey()); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: get user info from master error: requestID(%v), accessKey(%v), err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if !userInfo.Policy.IsAuthorized(sourceBucket, "", proto.OSSUploadPartCopyAction) {
+		log.LogErrorf("uploadPartCopyHandler: no permission to copy from source bucket, requestID(%v), source bucket(%v), source file(%v), target bucket(%v), target file(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, param.Bucket(), param.Object())
+		errorCode = AccessDenied
+		return
+	}
+
+	var sourceVol *Volume
+	if sourceVol, err = o.getVol(sourceBucket); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: load source volume fail: vol(%v) requestID(%v) err(%v)",
+			sourceBucket, GetRequestID(r), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	// get source object meta
+	var fileInfo *FSFileInfo
+	if fileInfo, err = sourceVol.ObjectMeta(sourceObject); err != nil {
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+			return
+		}
+		log.LogErrorf("uploadPartCopyHandler: volume get file info fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if fileInfo.Mode.IsDir() {
+		errorCode = InvalidArgument
+		return
+	}
+
+	// check the preconditions of the source object
+	if errorCode = checkCopySourcePrecondition(r, fileInfo); errorCode != nil {
+		return
+	}
+
+	// The whole source object is copied if no range specified.
+	var offset, size = uint64(0), uint64(fileInfo.Size)
+	if copyRange := r.Header.Get(HeaderNameXAmzCopySourceRange); copyRange != "" {
+		if offset, size, errorCode = parseCopySourceRange(copyRange, uint64(fileInfo.Size)); errorCode != nil {
+			log.LogWarnf("uploadPartCopyHandler: invalid copy source range: requestID(%v) range(%v) size(%v)",
+				GetRequestID(r), copyRange, fileInfo.Size)
+			return
+		}
+	}
+
+	// The customer keys of the source object and the multipart upload if they are encrypted with SSE-C.
+	var sourceSSE, sseOption *SSEOption
+	if sourceSSE, errorCode = parseSSECustomerKey(r, HeaderNameXAmzCopySourceSSECustomerAlgorithm,
+		HeaderNameXAmzCopySourceSSECustomerKey, HeaderNameXAmzCopySourceSSECustomerKeyMD5); errorCode != nil {
+		return
+	}
+	if sseOption, errorCode = parseSSECustomerKey(r, HeaderNameXAmzSSECustomerAlgorithm,
+		HeaderNameXAmzSSECustomerKey, HeaderNameXAmzSSECustomerKeyMD5); errorCode != nil {
+		return
+	}
+
+	var fsFileInfo *FSFileInfo
+	fsFileInfo, err = vol.CopyPart(sourceVol, sourceObject, param.Object(), uploadId, uint16(partNumberInt),
+		offset, size, sourceSSE, sseOption)
+	if err == syscall.ENOENT {
+		errorCode = NoSuchUpload
+		return
+	}
+	if err == syscall.EINVAL {
+		errorCode = InvalidArgument
+		return
+	}
+	if err == syscall.EFBIG {
+		errorCode = CopySourceSizeTooLarge
+		return
+	}
+	if sseCode := sseErrorCode(err); sseCode != nil {
+		errorCode = sseCode
+		return
+	}
+	if err != nil {
+		log.LogErrorf("uploadPartCopyHandler: copy part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) source volume(%v) source path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, sourceBucket, sourceObject, err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	log.LogDebugf("uploadPartCopyHandler: copy part success: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) fsFileInfo(%v)",
+		GetRequestID(r), vol.Name(), param.Object(), uploadId, partNumberInt, fsFileInfo)
+
+	copyResult := CopyPartResult{
+		ETag:         wrapUnescapedQuot(fsFileInfo.ETag),
+		LastModified: formatTimeISO(fsFileInfo.ModifyTime),
+	}
+
+	var bytes []byte
+	if bytes, err = MarshalXMLEntity(copyResult); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: marshal xml entity fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	// set response header
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+	_, _ = w.Write(bytes)
+	return
+}
+
+// parseCopySourceRange parses the value of header 'x-amz-copy-source-range' in format 'bytes=first-last',
+// and returns the offset and size of the range in the source object with the specified size.
+func parseCopySourceRange(value string, objectSize uint64) (offset, size uint64, errorCode *ErrorCode) {
+	if !strings.HasPrefix(value, "bytes=") {
+		return 0, 0, InvalidArgument
+	}
+	var bounds = strings.SplitN(strings.TrimPrefix(value, "bytes="), "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, InvalidArgument
+	}
+	var first, last uint64
+	var err error
+	if first, err = strconv.ParseUint(bounds[0], 10, 64); err != nil {
+		return 0, 0, InvalidArgument
+	}
+	if last, err = strconv.ParseUint(bounds[1], 10, 64); err != nil {
+		return 0, 0, InvalidArgument
+	}
+	if first > last {
+		return 0, 0, InvalidArgument
+	}
+	if last >= objectSize {
+		return 0, 0, InvalidRange
+	}
+	return first, last - first + 1, nil
+}
+
 // List parts
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListParts.html
 func (o *ObjectNode) listPartsHandler(w http.ResponseWriter, r *http.Request) {
@@ -466,6 +713,10 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		errorCode = ObjectModeConflict
 		return
 	}
+	if err == syscall.EPERM {
+		errorCode = ObjectLocked
+		return
+	}
 	if err != nil {
 		log.LogErrorf("completeMultipartUploadHandler: complete multipart fail, requestID(%v) uploadID(%v) err(%v)",
 			GetRequestID(r), uploadId, err)
@@ -474,6 +725,9 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 	}
 	log.LogDebugf("completeMultipartUploadHandler: complete multipart, requestID(%v) uploadID(%v) path(%v)",
 		GetRequestID(r), uploadId, param.Object())
+	o.submitReplication(vol, param.Object(), multipartInfo.Extend[XAttrKeyOSSReplStatus])
+	o.notifyEvent(r, vol, EventObjectCreatedCompleteMultipartUpload, EventObject{Key: param.Object(),
+		Size: fsFileInfo.Size, ETag: fsFileInfo.ETag, VersionID: fsFileInfo.VersionID})
 
 	// write response
 	completeResult := CompleteMultipartResult{
@@ -493,6 +747,10 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 	// set response header
 	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
 	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+	if len(fsFileInfo.VersionID) > 0 {
+		w.Header()[HeaderNameXAmzVersionId] = []string{fsFileInfo.VersionID}
+	}
+	setSSEResponseHeaders(w, fsFileInfo)
 	if _, err = w.Write(bytes); err != nil {
 		log.LogErrorf("completeMultipartUploadHandler: write response body fail, requestID(%v) err(%v)", GetRequestID(r), err)
 		return
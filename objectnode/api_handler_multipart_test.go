@@ -0,0 +1,55 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+)
+
+func TestParseCopySourceRange(t *testing.T) {
+	var valids = []struct {
+		value  string
+		offset uint64
+		size   uint64
+	}{
+		{"bytes=0-0", 0, 1},
+		{"bytes=0-99", 0, 100},
+		{"bytes=10-19", 10, 10},
+	}
+	for _, valid := range valids {
+		offset, size, errorCode := parseCopySourceRange(valid.value, 100)
+		if errorCode != nil {
+			t.Fatalf("parse copy source range fail: value(%v) err(%v)", valid.value, errorCode.ErrorCode)
+		}
+		if offset != valid.offset || size != valid.size {
+			t.Fatalf("range mismatch: value(%v) expect(%v,%v) actual(%v,%v)",
+				valid.value, valid.offset, valid.size, offset, size)
+		}
+	}
+
+	var invalids = map[string]*ErrorCode{
+		"0-10":        InvalidArgument,
+		"bytes=10":    InvalidArgument,
+		"bytes=-10":   InvalidArgument,
+		"bytes=10-":   InvalidArgument,
+		"bytes=20-10": InvalidArgument,
+		"bytes=0-100": InvalidRange,
+	}
+	for value, expect := range invalids {
+		if _, _, errorCode := parseCopySourceRange(value, 100); errorCode != expect {
+			t.Fatalf("invalid range should be rejected: value(%v) expect(%v) actual(%v)", value, expect, errorCode)
+		}
+	}
+}
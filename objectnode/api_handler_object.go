@@ -131,20 +131,41 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	responseContentType := r.URL.Query().Get(ParamResponseContentType)
 	responseContentDisposition := r.URL.Query().Get(ParamResponseContentDisposition)
+	versionId := r.URL.Query().Get(ParamVersionId)
 
 	// get object meta
 	var fileInfo *FSFileInfo
-	fileInfo, err = vol.ObjectMeta(param.Object())
+	var deleteMarker bool
+	fileInfo, deleteMarker, err = vol.ObjectVersionMeta(param.Object(), versionId)
 	if err == syscall.ENOENT {
 		errorCode = NoSuchKey
+		if versionId != "" {
+			errorCode = NoSuchVersion
+		}
 		return
 	}
 	if err != nil {
-		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		errorCode = InternalErrorCode(err)
 		return
 	}
+	if deleteMarker {
+		w.Header()[HeaderNameXAmzDeleteMarker] = []string{"true"}
+		w.Header()[HeaderNameXAmzVersionId] = []string{fileInfo.VersionID}
+		errorCode = MethodNotAllowed
+		return
+	}
+
+	// The customer key must be specified to access the object encrypted with SSE-C.
+	var sseOption *SSEOption
+	if sseOption, errorCode = parseSSECustomerKey(r, HeaderNameXAmzSSECustomerAlgorithm,
+		HeaderNameXAmzSSECustomerKey, HeaderNameXAmzSSECustomerKeyMD5); errorCode != nil {
+		return
+	}
+	if errorCode = checkSSECustomerKey(fileInfo, sseOption); errorCode != nil {
+		return
+	}
 
 	// parse request header
 	match := r.Header.Get(HeaderNameIfMatch)
@@ -218,7 +239,7 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object tagging size
 	var xattrInfo *proto.XAttrInfo
-	if xattrInfo, err = vol.GetXAttr(param.object, XAttrKeyOSSTagging); err != nil && err != syscall.ENOENT {
+	if xattrInfo, err = vol.mw.XAttrGet_ll(fileInfo.Inode, XAttrKeyOSSTagging); err != nil && err != syscall.ENOENT {
 		log.LogErrorf("getObjectHandler: Volume get XAttr fail: requestID(%v) err(%v)", GetRequestID(r), err)
 		errorCode = InternalErrorCode(err)
 		return
@@ -256,6 +277,13 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 	} else if len(fileInfo.Expires) > 0 {
 		w.Header()[HeaderNameExpires] = []string{fileInfo.Expires}
 	}
+	if len(fileInfo.VersionID) > 0 {
+		w.Header()[HeaderNameXAmzVersionId] = []string{fileInfo.VersionID}
+	}
+	if len(fileInfo.ReplicationStatus) > 0 {
+		w.Header()[HeaderNameXAmzReplicationStatus] = []string{fileInfo.ReplicationStatus}
+	}
+	setSSEResponseHeaders(w, fileInfo)
 
 	//check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -314,7 +342,7 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 			size = rangeUpper - rangeLower + 1
 		}
 	}
-	err = vol.ReadFile(param.Object(), w, offset, size)
+	err = vol.ReadFileVersion(param.Object(), versionId, w, offset, size, sseOption)
 	if err == syscall.ENOENT {
 		errorCode = NoSuchKey
 		return
@@ -364,17 +392,38 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	var fileInfo *FSFileInfo
-	fileInfo, err = vol.ObjectMeta(param.Object())
+	var deleteMarker bool
+	var versionId = r.URL.Query().Get(ParamVersionId)
+	fileInfo, deleteMarker, err = vol.ObjectVersionMeta(param.Object(), versionId)
 	if err == syscall.ENOENT {
 		errorCode = NoSuchKey
+		if versionId != "" {
+			errorCode = NoSuchVersion
+		}
 		return
 	}
 	if err != nil {
-		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v)err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		errorCode = InternalErrorCode(err)
 		return
 	}
+	if deleteMarker {
+		w.Header()[HeaderNameXAmzDeleteMarker] = []string{"true"}
+		w.Header()[HeaderNameXAmzVersionId] = []string{fileInfo.VersionID}
+		errorCode = MethodNotAllowed
+		return
+	}
+
+	// The customer key must be specified to access the object encrypted with SSE-C.
+	var sseOption *SSEOption
+	if sseOption, errorCode = parseSSECustomerKey(r, HeaderNameXAmzSSECustomerAlgorithm,
+		HeaderNameXAmzSSECustomerKey, HeaderNameXAmzSSECustomerKeyMD5); errorCode != nil {
+		return
+	}
+	if errorCode = checkSSECustomerKey(fileInfo, sseOption); errorCode != nil {
+		return
+	}
 
 	// parse request header
 	match := r.Header.Get(HeaderNameIfMatch)
@@ -453,6 +502,13 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 	if len(fileInfo.Expires) > 0 {
 		w.Header()[HeaderNameExpires] = []string{fileInfo.Expires}
 	}
+	if len(fileInfo.VersionID) > 0 {
+		w.Header()[HeaderNameXAmzVersionId] = []string{fileInfo.VersionID}
+	}
+	if len(fileInfo.ReplicationStatus) > 0 {
+		w.Header()[HeaderNameXAmzReplicationStatus] = []string{fileInfo.ReplicationStatus}
+	}
+	setSSEResponseHeaders(w, fileInfo)
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -565,18 +621,37 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		return deleteReq.Objects[i].Key > deleteReq.Objects[j].Key
 	})
 
+	var bypassGovernance = o.bypassGovernanceRetention(r, param)
 	var objectKeys = make([]string, 0, len(deleteReq.Objects))
 	for _, object := range deleteReq.Objects {
 		objectKeys = append(objectKeys, object.Key)
-		err = vol.DeletePath(object.Key)
-		log.LogWarnf("deleteObjectsHandler: delete: requestID(%v) volume(%v) path(%v)",
-			GetRequestID(r), vol.Name(), object.Key)
-		if err != nil {
-			deletedErrors = append(deletedErrors, Error{Key: object.Key, Message: err.Error()})
-			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) err(%v)",
-				GetRequestID(r), vol.Name(), object.Key, err)
+		var deletedVersionId string
+		var deleteMarker bool
+		deletedVersionId, deleteMarker, err = vol.DeleteObject(object.Key, object.VersionId)
+		if err == syscall.EPERM && object.VersionId != "" && bypassGovernance {
+			deletedVersionId, deleteMarker, err = vol.DeleteObjectBypassGovernance(object.Key, object.VersionId)
+		}
+		log.LogWarnf("deleteObjectsHandler: delete: requestID(%v) volume(%v) path(%v) versionId(%v)",
+			GetRequestID(r), vol.Name(), object.Key, object.VersionId)
+		if err == syscall.EPERM {
+			deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId,
+				Code: ObjectLocked.ErrorCode, Message: ObjectLocked.ErrorMessage})
+			log.LogWarnf("deleteObjectsHandler: object locked: requestID(%v) volume(%v) path(%v) versionId(%v)",
+				GetRequestID(r), vol.Name(), object.Key, object.VersionId)
+		} else if err != nil {
+			deletedErrors = append(deletedErrors, Error{Key: object.Key, VersionId: object.VersionId, Message: err.Error()})
+			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+				GetRequestID(r), vol.Name(), object.Key, object.VersionId, err)
 		} else {
-			deletedObjects = append(deletedObjects, Deleted{Key: object.Key})
+			var deleted = Deleted{Key: object.Key, VersionId: object.VersionId}
+			if deleteMarker {
+				deleted.DeleteMarker = "true"
+				if object.VersionId == "" {
+					deleted.DeleteMarkerVersionId = deletedVersionId
+				}
+			}
+			deletedObjects = append(deletedObjects, deleted)
+			o.notifyDeleteEvent(r, vol, object.Key, deletedVersionId, deleteMarker)
 			log.LogDebugf("deleteObjectsHandler: delete object success: requestID(%v) volume(%v) path(%v)", GetRequestID(r),
 				vol.Name(), object.Key)
 		}
@@ -629,6 +704,49 @@ func parseCopySourceInfo(r *http.Request) (sourceBucket, sourceObject string) {
 	return
 }
 
+// checkCopySourcePrecondition checks the conditional headers of the copy source.
+func checkCopySourcePrecondition(r *http.Request, fileInfo *FSFileInfo) *ErrorCode {
+	copyMatch := r.Header.Get(HeaderNameXAmzCopyMatch)
+	noneMatch := r.Header.Get(HeaderNameXAmzCopyNoneMatch)
+	modified := r.Header.Get(HeaderNameXAmzCopyModified)
+	unModified := r.Header.Get(HeaderNameXAmzCopyUnModified)
+
+	// response 412
+	if modified != "" {
+		fileModTime := fileInfo.ModifyTime
+		modifiedTime, err := parseTimeRFC1123(modified)
+		if err != nil {
+			log.LogErrorf("checkCopySourcePrecondition: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
+			return InvalidArgument
+		}
+		if fileModTime.Before(modifiedTime) {
+			log.LogInfof("checkCopySourcePrecondition: file modified time not after than specified time: requestID(%v)", GetRequestID(r))
+			return PreconditionFailed
+		}
+	}
+	if unModified != "" {
+		fileModTime := fileInfo.ModifyTime
+		unmodifiedTime, err := parseTimeRFC1123(unModified)
+		if err != nil {
+			log.LogErrorf("checkCopySourcePrecondition: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
+			return InvalidArgument
+		}
+		if fileModTime.After(unmodifiedTime) {
+			log.LogInfof("checkCopySourcePrecondition: file modified time not before than specified time: requestID(%v)", GetRequestID(r))
+			return PreconditionFailed
+		}
+	}
+	if copyMatch != "" && fileInfo.ETag != copyMatch {
+		log.LogInfof("checkCopySourcePrecondition: eTag mismatched with specified: requestID(%v)", GetRequestID(r))
+		return PreconditionFailed
+	}
+	if noneMatch != "" && fileInfo.ETag == noneMatch {
+		log.LogInfof("checkCopySourcePrecondition: eTag same with specified: requestID(%v)", GetRequestID(r))
+		return PreconditionFailed
+	}
+	return nil
+}
+
 // Copy object
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_CopyObject.html .
 func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
@@ -689,6 +807,18 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		CacheControl: cacheControl,
 		Expires:      expires,
 	}
+	if opt.Encryption, errorCode = parseSSEOption(r, vol); errorCode != nil {
+		return
+	}
+	if errorCode = parseObjectLockOption(r, vol, opt); errorCode != nil {
+		return
+	}
+	// The customer key of the source object if it is encrypted with SSE-C.
+	var sourceSSE *SSEOption
+	if sourceSSE, errorCode = parseSSECustomerKey(r, HeaderNameXAmzCopySourceSSECustomerAlgorithm,
+		HeaderNameXAmzCopySourceSSECustomerKey, HeaderNameXAmzCopySourceSSECustomerKeyMD5); errorCode != nil {
+		return
+	}
 
 	sourceBucket, sourceObject := parseCopySourceInfo(r)
 
@@ -721,49 +851,8 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// get header
-	copyMatch := r.Header.Get(HeaderNameXAmzCopyMatch)
-	noneMatch := r.Header.Get(HeaderNameXAmzCopyNoneMatch)
-	modified := r.Header.Get(HeaderNameXAmzCopyModified)
-	unModified := r.Header.Get(HeaderNameXAmzCopyUnModified)
-
-	// response 412
-	if modified != "" {
-		fileModTime := fileInfo.ModifyTime
-		modifiedTime, err := parseTimeRFC1123(modified)
-		if err != nil {
-			log.LogErrorf("copyObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if fileModTime.Before(modifiedTime) {
-			log.LogInfof("copyObjectHandler: file modified time not after than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = PreconditionFailed
-			return
-		}
-	}
-	if unModified != "" {
-		fileModTime := fileInfo.ModifyTime
-		unmodifiedTime, err := parseTimeRFC1123(unModified)
-		if err != nil {
-			log.LogErrorf("copyObjectHandler: parse RFC1123 time fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			errorCode = InvalidArgument
-			return
-		}
-		if fileModTime.After(unmodifiedTime) {
-			log.LogInfof("copyObjectHandler: file modified time not before than specified time: requestID(%v)", GetRequestID(r))
-			errorCode = PreconditionFailed
-			return
-		}
-	}
-	if copyMatch != "" && fileInfo.ETag != copyMatch {
-		log.LogInfof("copyObjectHandler: eTag mismatched with specified: requestID(%v)", GetRequestID(r))
-		errorCode = PreconditionFailed
-		return
-	}
-	if noneMatch != "" && fileInfo.ETag == noneMatch {
-		log.LogInfof("copyObjectHandler: eTag same with specified: requestID(%v)", GetRequestID(r))
-		errorCode = PreconditionFailed
+	// check the preconditions of the source object
+	if errorCode = checkCopySourcePrecondition(r, fileInfo); errorCode != nil {
 		return
 	}
 
@@ -776,7 +865,31 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, param.Object(), metadataDirective, opt)
+	// The tags of the source object are copied unless the metadata is replaced, so they decide
+	// whether the target object is replicated.
+	if metadataDirective != MetadataDirectiveReplace {
+		var sourceInfo *FSFileInfo
+		if sourceInfo, err = sourceVol.ObjectMeta(sourceObject); err == nil {
+			opt.Tagging, err = sourceVol.loadObjectTagging(sourceInfo.Inode)
+		}
+		if err != nil {
+			log.LogErrorf("copyObjectHandler: load source tagging fail: requestID(%v) volume(%v) source(%v) err(%v)",
+				GetRequestID(r), sourceBucket, sourceObject, err)
+			errorCode = InternalErrorCode(err)
+			return
+		}
+	}
+	if errorCode = parseReplicationOption(r, vol, param.Object(), opt); errorCode != nil {
+		return
+	}
+
+	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, param.Object(), metadataDirective, opt, sourceSSE)
+	if sseCode := sseErrorCode(err); sseCode != nil {
+		log.LogWarnf("copyObjectHandler: encryption check fail: requestID(%v) Volume(%v) source(%v) target(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), sourceObject, param.Object(), err)
+		errorCode = sseCode
+		return
+	}
 	if err != nil && err != syscall.EINVAL && err != syscall.EFBIG {
 		log.LogErrorf("copyObjectHandler: Volume copy file fail: requestID(%v) Volume(%v) source(%v) target(%v) err(%v)",
 			GetRequestID(r), param.Bucket(), sourceObject, param.Object(), err)
@@ -796,6 +909,10 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	o.submitReplication(vol, param.Object(), opt.ReplicationStatus)
+	o.notifyEvent(r, vol, EventObjectCreatedCopy, EventObject{Key: param.Object(), Size: fsFileInfo.Size,
+		ETag: fsFileInfo.ETag, VersionID: fsFileInfo.VersionID})
+
 	copyResult := CopyResult{
 		ETag:         fsFileInfo.ETag,
 		LastModified: formatTimeISO(fsFileInfo.ModifyTime),
@@ -811,6 +928,10 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 	// set response header
 	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
 	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+	if len(fsFileInfo.VersionID) > 0 {
+		w.Header()[HeaderNameXAmzVersionId] = []string{fsFileInfo.VersionID}
+	}
+	setSSEResponseHeaders(w, fsFileInfo)
 	_, _ = w.Write(bytes)
 	return
 }
@@ -1178,11 +1299,39 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		CacheControl: cacheControl,
 		Expires:      expires,
 	}
-	fsFileInfo, err = vol.PutObject(param.Object(), r.Body, opt)
+	if opt.Encryption, errorCode = parseSSEOption(r, vol); errorCode != nil {
+		return
+	}
+	if errorCode = parseObjectLockOption(r, vol, opt); errorCode != nil {
+		return
+	}
+	if errorCode = parseReplicationOption(r, vol, param.Object(), opt); errorCode != nil {
+		return
+	}
+	var body io.Reader = r.Body
+	if isStreamingSignedPayload(r) {
+		if body, err = o.newSignedChunkedReaderV4(r); err != nil {
+			log.LogWarnf("putObjectHandler: init signed chunked reader fail: requestID(%v) volume(%v) path(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), err)
+			errorCode = InvalidArgument
+			return
+		}
+	}
+	fsFileInfo, err = vol.PutObject(param.Object(), body, opt)
 	if err == syscall.EINVAL {
 		errorCode = ObjectModeConflict
 		return
 	}
+	if sseCode := sseErrorCode(err); sseCode != nil {
+		errorCode = sseCode
+		return
+	}
+	if chunkCode := chunkErrorCode(err); chunkCode != nil {
+		log.LogWarnf("putObjectHandler: read chunked payload fail: requestID(%v) volume(%v) path(%v) remote(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), getRequestIP(r), err)
+		errorCode = chunkCode
+		return
+	}
 	if err == io.ErrUnexpectedEOF {
 		log.LogWarnf("putObjectHandler: put object fail cause unexpected EOF: requestID(%v) volume(%v) path(%v) remote(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), getRequestIP(r), err)
@@ -1216,9 +1365,16 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = BadDigest
 		return
 	}
+	o.submitReplication(vol, param.Object(), opt.ReplicationStatus)
+	o.notifyEvent(r, vol, EventObjectCreatedPut, EventObject{Key: param.Object(), Size: fsFileInfo.Size,
+		ETag: fsFileInfo.ETag, VersionID: fsFileInfo.VersionID})
 
 	// set response header
 	w.Header()[HeaderNameETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
+	if len(fsFileInfo.VersionID) > 0 {
+		w.Header()[HeaderNameXAmzVersionId] = []string{fsFileInfo.VersionID}
+	}
+	setSSEResponseHeaders(w, fsFileInfo)
 	w.Header()[HeaderNameContentLength] = []string{"0"}
 	return
 }
@@ -1258,18 +1414,39 @@ func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	var versionId = r.URL.Query().Get(ParamVersionId)
+
 	// Audit deletion
-	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v)",
-		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
+	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v) versionId(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionId)
 
-	err = vol.DeletePath(param.Object())
+	var deletedVersionId string
+	var deleteMarker bool
+	deletedVersionId, deleteMarker, err = vol.DeleteObject(param.Object(), versionId)
+	if err == syscall.EPERM && versionId != "" && o.bypassGovernanceRetention(r, param) {
+		deletedVersionId, deleteMarker, err = vol.DeleteObjectBypassGovernance(param.Object(), versionId)
+	}
+	if err == syscall.EPERM {
+		log.LogWarnf("deleteObjectHandler: object locked: requestID(%v) volume(%v) path(%v) versionId(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId)
+		errorCode = ObjectLocked
+		return
+	}
 	if err != nil {
 		log.LogErrorf("deleteObjectHandler: Volume delete file fail: "+
-			"requestID(%v) volume(%v) path(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), err)
+			"requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		errorCode = InternalErrorCode(err)
 		return
 	}
 
+	o.notifyDeleteEvent(r, vol, param.Object(), deletedVersionId, deleteMarker)
+
+	if len(deletedVersionId) > 0 {
+		w.Header()[HeaderNameXAmzVersionId] = []string{deletedVersionId}
+	}
+	if deleteMarker {
+		w.Header()[HeaderNameXAmzDeleteMarker] = []string{"true"}
+	}
 	w.WriteHeader(http.StatusNoContent)
 	return
 }
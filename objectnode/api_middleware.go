@@ -27,6 +27,7 @@ import (
 	"github.com/chubaofs/chubaofs/util/log"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
 )
 
 var (
@@ -34,7 +35,8 @@ var (
 )
 
 func IsMonitoredStatusCode(code int) bool {
-	if code > http.StatusInternalServerError {
+	// The SlowDown responses of throttled requests are expected.
+	if code > http.StatusInternalServerError && code != http.StatusServiceUnavailable {
 		return true
 	}
 	return false
@@ -95,6 +97,14 @@ func (o *ObjectNode) traceMiddleware(next http.Handler) http.Handler {
 
 		var action = ActionFromRouteName(mux.CurrentRoute(r).GetName())
 		SetRequestAction(r, action)
+
+		// record the response for the access log if the logging of the bucket is enabled
+		var logWriter *loggingResponseWriter
+		var logVol, logTarget = o.loggingTarget(r)
+		if logTarget != nil {
+			logWriter = &loggingResponseWriter{ResponseWriter: w}
+			w = logWriter
+		}
 		// ===== pre-handle finish =====
 
 		var startTime = time.Now()
@@ -121,6 +131,15 @@ func (o *ObjectNode) traceMiddleware(next http.Handler) http.Handler {
 		}
 
 		// ===== post-handle start =====
+		if logWriter != nil {
+			var requester = parseRequestAuthInfo(r).accessKey
+			if userInfo, err := o.getUserInfoByAccessKey(requester); err == nil {
+				requester = userInfo.UserID
+			}
+			o.logWorker.Submit(logTarget, formatAccessLog(r, logWriter, logVol.Owner(), requester,
+				startTime, time.Since(startTime)))
+		}
+
 		var headerToString = func(header http.Header) string {
 			var sb = strings.Builder{}
 			for k := range header {
@@ -151,6 +170,12 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 				next.ServeHTTP(w, r)
 				return
 			}
+			// The POST Object request is authenticated by the signature of the policy in the
+			// form, which is verified by the handler after the form is parsed.
+			if currentAction == proto.OSSPostObjectAction {
+				next.ServeHTTP(w, r)
+				return
+			}
 
 			var (
 				pass bool
@@ -176,6 +201,14 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 					_ = NoSuchBucket.ServeResponse(w, r)
 					return
 				}
+				if err == errExpiredToken {
+					_ = ExpiredToken.ServeResponse(w, r)
+					return
+				}
+				if err == errInvalidToken {
+					_ = InvalidToken.ServeResponse(w, r)
+					return
+				}
 				_ = InternalErrorCode(err).ServeResponse(w, r)
 				return
 			}
@@ -185,6 +218,55 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 				return
 			}
 
+			// The request signed with temporary credentials is performed as the user who assumed
+			// the credentials, and limited by the session policy.
+			if token := getSecurityToken(r); token != "" {
+				var claims *sessionClaims
+				if claims, _, err = o.stsKeys.parse(token); err != nil {
+					_ = InvalidToken.ServeResponse(w, r)
+					return
+				}
+				SetRequestAccessKey(r, claims.ParentAccessKey)
+				SetRequestSession(r, claims.AccessKey, claims.Policy)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+}
+
+// QoSMiddleware returns a pre-handle middleware handler to enforce the QoS limits of the user and
+// the volume of the request. The request exceeding the QPS limits is rejected with SlowDown, and
+// the request and response bodies are slowed down to the bandwidth limits.
+func (o *ObjectNode) qosMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var limiters = o.qosLimiters(r)
+			if len(limiters) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var action = GetActionFromContext(r).Name()
+			var readLimiters, writeLimiters []*rate.Limiter
+			for _, limiter := range limiters {
+				if !limiter.allow(action) {
+					log.LogDebugf("qosMiddleware: request throttled: requestID(%v) action(%v) limit(%v)",
+						GetRequestID(r), action, limiter.limit)
+					_ = SlowDown.ServeResponse(w, r)
+					return
+				}
+				if limiter.read != nil {
+					readLimiters = append(readLimiters, limiter.read)
+				}
+				if limiter.write != nil {
+					writeLimiters = append(writeLimiters, limiter.write)
+				}
+			}
+			if len(writeLimiters) > 0 && r.Body != nil {
+				r.Body = &qosReader{ctx: r.Context(), reader: r.Body, limiters: writeLimiters}
+			}
+			if len(readLimiters) > 0 {
+				w = &qosResponseWriter{ResponseWriter: w, ctx: r.Context(), limiters: readLimiters}
+			}
 			next.ServeHTTP(w, r)
 		})
 }
@@ -199,6 +281,11 @@ func (o *ObjectNode) policyCheckMiddleware(next http.Handler) http.Handler {
 				next.ServeHTTP(w, r)
 				return
 			}
+			// The policy of the POST Object request is checked by the handler.
+			if action == proto.OSSPostObjectAction {
+				next.ServeHTTP(w, r)
+				return
+			}
 			wrappedNext := o.policyCheck(next.ServeHTTP)
 			wrappedNext.ServeHTTP(w, r)
 			return
@@ -208,10 +295,17 @@ func (o *ObjectNode) policyCheckMiddleware(next http.Handler) http.Handler {
 // ContentMiddleware returns a middleware handler to process reader for content.
 // If the request contains the "X-amz-Decoded-Content-Length" header, it means that the data
 // in the request body is chunked. Use ChunkedReader to parse the data.
+// The signed chunks of the put object and upload part requests are decoded and verified by
+// the handlers.
 // Workflow:
 //   request → [pre-handle] → [next handler] → response
 func (o *ObjectNode) contentMiddleware(next http.Handler) http.Handler {
 	var handlerFunc http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+		if action := GetActionFromContext(r); isStreamingSignedPayload(r) &&
+			(action == proto.OSSPutObjectAction || action == proto.OSSUploadPartAction) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		if len(r.Header) > 0 && len(r.Header.Get(http.CanonicalHeaderKey(HeaderNameXAmzDecodeContentLength))) > 0 {
 			r.Body = NewClosableChunkedReader(r.Body)
 			log.LogDebugf("contentMiddleware: chunk reader inited: requestID(%v)", GetRequestID(r))
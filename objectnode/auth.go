@@ -59,6 +59,9 @@ func parseRequestAuthInfo(r *http.Request) *RequestAuthInfo {
 			auth.accessKey = ai.Credential.AccessKey
 		}
 	}
+	if accessKey := GetAccessKeyFromContext(r); accessKey != "" {
+		auth.accessKey = accessKey
+	}
 
 	return auth
 }
@@ -29,7 +29,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/log"
 	"github.com/gorilla/mux"
@@ -180,27 +179,8 @@ func (o *ObjectNode) validateHeaderBySignatureAlgorithmV2(r *http.Request) (bool
 
 	var accessKey = authInfo.accessKeyId
 	var secretKey string
-	var bucket = mux.Vars(r)["bucket"]
-	if userInfo, err := o.getUserInfoByAccessKey(accessKey); err == nil {
-		secretKey = userInfo.SecretKey
-	} else if (err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists) &&
-		len(bucket) > 0 && GetActionFromContext(r) != proto.OSSCreateBucketAction {
-		// In order to be directly compatible with the signature verification of version 1.5
-		// (each volume has its own access key and secret key), if the user does not exist and
-		// the request specifies a volume, try to use the access key and secret key bound in the
-		// volume information for verification.
-		var volume *Volume
-		if volume, err = o.getVol(bucket); err != nil {
-			return false, err
-		}
-		if ak, sk := volume.OSSSecure(); ak == accessKey {
-			secretKey = sk
-		} else {
-			return false, nil
-		}
-	} else {
-		log.LogErrorf("validateHeaderBySignatureAlgorithmV4: get secretKey from master fail: accessKey(%v) err(%v)",
-			accessKey, err)
+	var found bool
+	if secretKey, found, err = o.getSecretKey(r, accessKey); err != nil || !found {
 		return false, err
 	}
 
@@ -294,27 +274,9 @@ func (o *ObjectNode) validateUrlBySignatureAlgorithmV2(r *http.Request) (bool, e
 	// Checking access key
 
 	var secretKey string
-	var bucket = mux.Vars(r)["bucket"]
-	if userInfo, err := o.getUserInfoByAccessKey(accessKey); err == nil {
-		secretKey = userInfo.SecretKey
-	} else if (err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists) &&
-		len(bucket) > 0 && GetActionFromContext(r) != proto.OSSCreateBucketAction {
-		// In order to be directly compatible with the signature verification of version 1.5
-		// (each volume has its own access key and secret key), if the user does not exist and
-		// the request specifies a volume, try to use the access key and secret key bound in the
-		// volume information for verification.
-		var volume *Volume
-		if volume, err = o.getVol(bucket); err != nil {
-			return false, err
-		}
-		if ak, sk := volume.OSSSecure(); ak == accessKey {
-			secretKey = sk
-		} else {
-			return false, nil
-		}
-	} else {
-		log.LogErrorf("validateHeaderBySignatureAlgorithmV4: get secretKey from master fail: accessKey(%v) err(%v)",
-			accessKey, err)
+	var found bool
+	var err error
+	if secretKey, found, err = o.getSecretKey(r, accessKey); err != nil || !found {
 		return false, err
 	}
 
@@ -20,6 +20,7 @@ package objectnode
 import (
 	"encoding/hex"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
 	"sort"
@@ -51,6 +52,9 @@ const (
 	SignatureV4Request   = "aws4-request"
 	SignedHeaderHost     = "host"
 	UnsignedPayload      = "UNSIGNED-PAYLOAD"
+
+	StreamingSignedPayload    = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	SignatureV4ChunkAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
 	PresignedV4QueryAuth = "Authorization"
 
 	credentialFlag    = "Credential="
@@ -111,29 +115,9 @@ func (o *ObjectNode) validateHeaderBySignatureAlgorithmV4(r *http.Request) (bool
 		return false, nil
 	}
 
-	var accessKey = req.Credential.AccessKey
 	var secretKey string
-	var bucket = mux.Vars(r)["bucket"]
-	if userInfo, err := o.getUserInfoByAccessKey(accessKey); err == nil {
-		secretKey = userInfo.SecretKey
-	} else if (err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists) &&
-		len(bucket) > 0 && GetActionFromContext(r) != proto.OSSCreateBucketAction {
-		// In order to be directly compatible with the signature verification of version 1.5
-		// (each volume has its own access key and secret key), if the user does not exist and
-		// the request specifies a volume, try to use the accesskey and secret key bound in the
-		// volume information for verification.
-		var volume *Volume
-		if volume, err = o.getVol(bucket); err != nil {
-			return false, err
-		}
-		if ak, sk := volume.OSSSecure(); ak == accessKey {
-			secretKey = sk
-		} else {
-			return false, nil
-		}
-	} else {
-		log.LogErrorf("validateHeaderBySignatureAlgorithmV4: get secretKey from master fail: accessKey(%v) err(%v)",
-			accessKey, err)
+	var found bool
+	if secretKey, found, err = o.getSecretKey(r, req.Credential.AccessKey); err != nil || !found {
 		return false, err
 	}
 
@@ -171,29 +155,9 @@ func (o *ObjectNode) validateUrlBySignatureAlgorithmV4(r *http.Request) (pass bo
 		return false, nil
 	}
 
-	var accessKey = req.Credential.AccessKey
 	var secretKey string
-	var bucket = mux.Vars(r)["bucket"]
-	if userInfo, err := o.getUserInfoByAccessKey(accessKey); err == nil {
-		secretKey = userInfo.SecretKey
-	} else if (err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists) &&
-		len(bucket) > 0 && GetActionFromContext(r) != proto.OSSCreateBucketAction {
-		// In order to be directly compatible with the signature verification of version 1.5
-		// (each volume has its own access key and secret key), if the user does not exist and
-		// the request specifies a volume, try to use the accesskey and secret key bound in the
-		// volume information for verification.
-		var volume *Volume
-		if volume, err = o.getVol(bucket); err != nil {
-			return false, err
-		}
-		if ak, sk := volume.OSSSecure(); ak == accessKey {
-			secretKey = sk
-		} else {
-			return false, nil
-		}
-	} else {
-		log.LogErrorf("validateHeaderBySignatureAlgorithmV4: get secretKey from master fail: accessKey(%v) err(%v)",
-			accessKey, err)
+	var found bool
+	if secretKey, found, err = o.getSecretKey(r, req.Credential.AccessKey); err != nil || !found {
 		return false, err
 	}
 
@@ -228,6 +192,126 @@ func (o *ObjectNode) validateUrlBySignatureAlgorithmV4(r *http.Request) (pass bo
 	return
 }
 
+// getSecretKey returns the secret key of the access key. If the request carries a session token,
+// the access key is of the temporary credentials and the secret key is derived from the token.
+// If the user of the access key does not exist, the access key and secret key bound in the volume
+// are used.
+func (o *ObjectNode) getSecretKey(r *http.Request, accessKey string) (secretKey string, found bool, err error) {
+	if token := getSecurityToken(r); token != "" {
+		return o.getSessionSecretKey(r, token, accessKey)
+	}
+	var bucket = mux.Vars(r)["bucket"]
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKey(accessKey); err == nil {
+		return userInfo.SecretKey, true, nil
+	} else if (err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists) &&
+		len(bucket) > 0 && GetActionFromContext(r) != proto.OSSCreateBucketAction {
+		// In order to be directly compatible with the signature verification of version 1.5
+		// (each volume has its own access key and secret key), if the user does not exist and
+		// the request specifies a volume, try to use the accesskey and secret key bound in the
+		// volume information for verification.
+		var volume *Volume
+		if volume, err = o.getVol(bucket); err != nil {
+			return "", false, err
+		}
+		if ak, sk := volume.OSSSecure(); ak == accessKey {
+			return sk, true, nil
+		}
+		return "", false, nil
+	}
+	log.LogErrorf("getSecretKey: get secretKey from master fail: accessKey(%v) err(%v)",
+		accessKey, err)
+	return "", false, err
+}
+
+// getSessionSecretKey returns the secret key of the temporary credentials issued by AssumeRole.
+func (o *ObjectNode) getSessionSecretKey(r *http.Request, token, accessKey string) (secretKey string, found bool, err error) {
+	if o.stsKeys == nil {
+		return "", false, errInvalidToken
+	}
+	var claims *sessionClaims
+	if claims, secretKey, err = o.stsKeys.parse(token); err != nil {
+		log.LogDebugf("getSessionSecretKey: parse session token fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), accessKey, err)
+		return "", false, err
+	}
+	if claims.AccessKey != accessKey {
+		return "", false, errInvalidToken
+	}
+	return secretKey, true, nil
+}
+
+// validatePostPolicySignatureV4 checks the signature of the policy in the form of the POST Object
+// request, which is the HMAC of the base64-encoded policy with the signing key. The names of the
+// form fields are in lower case.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-authentication-HTTPPOST.html
+func (o *ObjectNode) validatePostPolicySignatureV4(r *http.Request, form map[string]string) (accessKey string, pass bool, err error) {
+	if form[strings.ToLower(XAmzAlgorithm)] != SignatureV4Algorithm {
+		return "", false, nil
+	}
+	var req = &signatureRequestV4{r: r}
+	if err = req.parseCredential(form[strings.ToLower(XAmzCredential)]); err != nil {
+		return "", false, nil
+	}
+	var timestamp time.Time
+	if timestamp, err = time.Parse(DateFormatISO8601, form[strings.ToLower(XAmzDate)]); err != nil {
+		return "", false, nil
+	}
+	if timestamp.Format("20060102") != req.Credential.Date {
+		return "", false, nil
+	}
+	accessKey = req.Credential.AccessKey
+
+	var secretKey string
+	var found bool
+	if secretKey, found, err = o.getSecretKey(r, accessKey); err != nil || !found {
+		return
+	}
+
+	signingKey := buildSigningKey(SCHEME, secretKey, req.Credential.Date, req.Credential.Region, req.Credential.Service, req.Credential.Request)
+	newSignature := hex.EncodeToString(sign(form["policy"], signingKey))
+	if form[strings.ToLower(XAmzSignature)] != newSignature {
+		log.LogDebugf("validatePostPolicySignatureV4: invalid signature: requestID(%v) client(%v) server(%v)",
+			GetRequestID(r), form[strings.ToLower(XAmzSignature)], newSignature)
+		return accessKey, false, nil
+	}
+	return accessKey, true, nil
+}
+
+// isStreamingSignedPayload checks if the payload of the request is signed in chunks.
+func isStreamingSignedPayload(r *http.Request) bool {
+	return r.Header.Get(XAmzContentSha256) == StreamingSignedPayload
+}
+
+// newSignedChunkedReaderV4 returns the reader which decodes the chunked payload of the request and
+// verifies the signature of each chunk. The signature in the authorization header, which has been
+// verified by the auth middleware, is the seed signature of the chunks.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+func (o *ObjectNode) newSignedChunkedReaderV4(r *http.Request) (io.ReadCloser, error) {
+	if !isHeaderUsingSignatureAlgorithmV4(r) {
+		return nil, errors.New("streaming payload is only supported with signature V4 in header")
+	}
+	var decodedLength, err = strconv.ParseInt(r.Header.Get(HeaderNameXAmzDecodeContentLength), 10, 64)
+	if err != nil || decodedLength < 0 {
+		return nil, errors.New("invalid decoded content length")
+	}
+	var req *signatureRequestV4
+	if req, err = parseRequestV4(r); err != nil {
+		return nil, err
+	}
+	var secretKey string
+	var found bool
+	if secretKey, found, err = o.getSecretKey(r, req.Credential.AccessKey); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("access key not found")
+	}
+	signingKey := buildSigningKey(SCHEME, secretKey, req.Credential.Date, req.Credential.Region, SERVICE, TERMINATOR)
+	scope := buildScope(req.Credential.Date, req.Credential.Region, SERVICE, TERMINATOR)
+	return newSignedChunkedReader(r.Body, signingKey, getStartTime(r.Header), scope, req.Signature, decodedLength), nil
+}
+
 type credential struct {
 	AccessKey string
 	Date      string
@@ -488,13 +572,16 @@ func calculateSignatureV4(r *http.Request, cred credential, secretKey string, si
 	canonicalHeaderString := buildCanonicalHeaderString(r.Host, headers, signedHeaders)
 	headerNames := getCanonicalHeaderNames(signedHeaders)
 	contentHash := getContentHash(headers)
+	if contentHash == "" && cred.Service == STSService {
+		contentHash = getSTSPayloadHash(r)
+	}
 	encodeQuery := getEncodeQuery(r)
 	canonicalURI := getCanonicalURI(r)
 	canonicalRequest := createCanonicalRequestString(
 		r.Method, canonicalURI, encodeQuery, canonicalHeaderString, headerNames, contentHash)
 
-	signingKey := buildSigningKey(SCHEME, secretKey, cred.Date, cred.Region, SERVICE, TERMINATOR)
-	scope := buildScope(cred.Date, cred.Region, SERVICE, TERMINATOR)
+	signingKey := buildSigningKey(SCHEME, secretKey, cred.Date, cred.Region, cred.Service, TERMINATOR)
+	scope := buildScope(cred.Date, cred.Region, cred.Service, TERMINATOR)
 
 	var timestamp = getStartTime(headers)
 	stringToSign := buildStringToSign(SignatureV4Algorithm, timestamp, scope, canonicalRequest)
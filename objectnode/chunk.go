@@ -15,8 +15,27 @@
 package objectnode
 
 import (
+	"bufio"
+	"encoding/hex"
 	"io"
 	"net/http/httputil"
+	"strconv"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	chunkSignatureFlag = ";chunk-signature="
+
+	// The maximum length of the header line of a chunk, and the maximum size of the data of a chunk.
+	maxChunkHeaderLength = 4096
+	maxChunkSize         = 16 << 20
+)
+
+var (
+	errChunkSignatureMismatch = errors.New("chunk signature does not match")
+	errMalformedChunkedBody   = errors.New("malformed chunked body")
 )
 
 // ClosableChunkReader wraps the chunked reader from the "httputil" package provided by Go
@@ -42,3 +61,145 @@ func NewClosableChunkedReader(source io.ReadCloser) io.ReadCloser {
 		Reader: httputil.NewChunkedReader(source),
 	}
 }
+
+// signedChunkedReader decodes the payload of the request signed with the streaming signature V4
+// (aws-chunked), and verifies the signature of each chunk. The signature of a chunk is chained to
+// the signature of the previous chunk, and the signature of the first chunk is chained to the seed
+// signature, which is the signature in the authorization header. Each chunk is in the form of:
+//
+//	hex(chunk-size);chunk-signature=signature\r\n
+//	chunk-data\r\n
+//
+// The payload ends with a chunk with zero size.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+type signedChunkedReader struct {
+	src           io.ReadCloser
+	reader        *bufio.Reader
+	signingKey    []byte
+	timestamp     string
+	scope         string
+	prevSignature string
+	decodedLength int64
+	n             int64
+	chunk         []byte
+	offset        int
+	err           error
+}
+
+func newSignedChunkedReader(source io.ReadCloser, signingKey []byte, timestamp, scope, seedSignature string,
+	decodedLength int64) *signedChunkedReader {
+	return &signedChunkedReader{
+		src:           source,
+		reader:        bufio.NewReader(source),
+		signingKey:    signingKey,
+		timestamp:     timestamp,
+		scope:         scope,
+		prevSignature: seedSignature,
+		decodedLength: decodedLength,
+	}
+}
+
+func (r *signedChunkedReader) Read(p []byte) (n int, err error) {
+	for r.offset >= len(r.chunk) {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.readChunk()
+	}
+	n = copy(p, r.chunk[r.offset:])
+	r.offset += n
+	return
+}
+
+func (r *signedChunkedReader) Close() error {
+	return r.src.Close()
+}
+
+// readChunk reads and verifies the next chunk. It returns io.EOF after the last chunk, of which
+// the size is zero, is verified.
+func (r *signedChunkedReader) readChunk() error {
+	var line, err = r.readLine()
+	if err != nil {
+		return err
+	}
+	var index = strings.Index(line, chunkSignatureFlag)
+	if index < 0 {
+		return errMalformedChunkedBody
+	}
+	var size int64
+	if size, err = strconv.ParseInt(line[:index], 16, 64); err != nil || size < 0 || size > maxChunkSize {
+		return errMalformedChunkedBody
+	}
+	var signature = line[index+len(chunkSignatureFlag):]
+
+	if int64(cap(r.chunk)) < size {
+		r.chunk = make([]byte, size)
+	}
+	r.chunk, r.offset = r.chunk[:size], 0
+	if _, err = io.ReadFull(r.reader, r.chunk); err != nil {
+		r.chunk = r.chunk[:0]
+		return errMalformedChunkedBody
+	}
+	if line, err = r.readLine(); err != nil || line != "" {
+		r.chunk = r.chunk[:0]
+		return errMalformedChunkedBody
+	}
+
+	var stringToSign = strings.Join([]string{
+		SignatureV4ChunkAlgorithm,
+		r.timestamp,
+		r.scope,
+		r.prevSignature,
+		calcHash(""),
+		calcHash(string(r.chunk)),
+	}, "\n")
+	if expected := hex.EncodeToString(sign(stringToSign, r.signingKey)); signature != expected {
+		r.chunk = r.chunk[:0]
+		return errChunkSignatureMismatch
+	}
+	r.prevSignature = signature
+
+	if r.n += size; r.n > r.decodedLength {
+		r.chunk = r.chunk[:0]
+		return errMalformedChunkedBody
+	}
+	if size == 0 {
+		if r.n != r.decodedLength {
+			return errMalformedChunkedBody
+		}
+		return io.EOF
+	}
+	return nil
+}
+
+// readLine reads a line, and returns the line without the line ending.
+func (r *signedChunkedReader) readLine() (string, error) {
+	var line []byte
+	for {
+		var fragment, isPrefix, err = r.reader.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				err = errMalformedChunkedBody
+			}
+			return "", err
+		}
+		if line = append(line, fragment...); len(line) > maxChunkHeaderLength {
+			return "", errMalformedChunkedBody
+		}
+		if !isPrefix {
+			break
+		}
+	}
+	return string(line), nil
+}
+
+// chunkErrorCode returns the error code of the errors of decoding the chunked payload.
+func chunkErrorCode(err error) *ErrorCode {
+	switch err {
+	case errChunkSignatureMismatch:
+		return SignatureDoesNotMatch
+	case errMalformedChunkedBody:
+		return IncompleteBody
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// The example of the streaming signature V4 in the document of Amazon S3.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+const (
+	testChunkSecretKey     = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	testChunkTimestamp     = "20130524T000000Z"
+	testChunkSeedSignature = "4f232c4386841ef735655705268965c44a0e4690baa4adea153f7db9fa80a0a9"
+)
+
+func testChunkedBody(data []byte) string {
+	return "10000;chunk-signature=ad80c730a21e5b8d04586a2213dd63b9a0e99e0e2307b0ade35a65485a288648\r\n" +
+		string(data[:65536]) + "\r\n" +
+		"400;chunk-signature=0055627c9e194cb4542bae2aa5492e3c1575bbb81b612b7d234b86a503ef5497\r\n" +
+		string(data[65536:]) + "\r\n" +
+		"0;chunk-signature=b6c6ea8a5354eaf15b3cb7646744f4275b71ea724fed81ceb9323e279d449df9\r\n\r\n"
+}
+
+func newTestSignedChunkedReader(body string, decodedLength int64) *signedChunkedReader {
+	var signingKey = buildSigningKey(SCHEME, testChunkSecretKey, "20130524", "us-east-1", SERVICE, TERMINATOR)
+	var scope = buildScope("20130524", "us-east-1", SERVICE, TERMINATOR)
+	return newSignedChunkedReader(ioutil.NopCloser(strings.NewReader(body)), signingKey, testChunkTimestamp,
+		scope, testChunkSeedSignature, decodedLength)
+}
+
+func TestSignedChunkedReader(t *testing.T) {
+	var data = bytes.Repeat([]byte{'a'}, 66560)
+	var decoded, err = ioutil.ReadAll(newTestSignedChunkedReader(testChunkedBody(data), int64(len(data))))
+	if err != nil {
+		t.Fatalf("read signed chunked body fail: err(%v)", err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatalf("decoded data mismatch: length(%v)", len(decoded))
+	}
+
+	// The data of the chunk is modified.
+	var modified = append([]byte{}, data...)
+	modified[65537] = 'b'
+	if _, err = ioutil.ReadAll(newTestSignedChunkedReader(testChunkedBody(modified), int64(len(data)))); err != errChunkSignatureMismatch {
+		t.Fatalf("modified chunk should be rejected: err(%v)", err)
+	}
+
+	// The chunks are truncated or mismatch the decoded content length.
+	var body = testChunkedBody(data)
+	if _, err = ioutil.ReadAll(newTestSignedChunkedReader(body[:66000], int64(len(data)))); err != errMalformedChunkedBody {
+		t.Fatalf("truncated body should be rejected: err(%v)", err)
+	}
+	if _, err = ioutil.ReadAll(newTestSignedChunkedReader(body, int64(len(data))+1)); err != errMalformedChunkedBody {
+		t.Fatalf("body mismatched with decoded length should be rejected: err(%v)", err)
+	}
+	if _, err = ioutil.ReadAll(newTestSignedChunkedReader("10000\r\n"+body[strings.Index(body, "\r\n")+2:], int64(len(data)))); err != errMalformedChunkedBody {
+		t.Fatalf("chunk without signature should be rejected: err(%v)", err)
+	}
+}
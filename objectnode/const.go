@@ -14,7 +14,11 @@
 
 package objectnode
 
-import "os"
+import (
+	"os"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
 
 const (
 	MaxRetry = 3
@@ -59,6 +63,7 @@ const (
 	HeaderNameXAmzCopyNoneMatch       = "x-amz-copy-source-if-none-match"
 	HeaderNameXAmzCopyModified        = "x-amz-copy-source-if-modified-since"
 	HeaderNameXAmzCopyUnModified      = "x-amz-copy-source-if-unmodified-since"
+	HeaderNameXAmzCopySourceRange     = "x-amz-copy-source-range"
 	HeaderNameXAmzDecodeContentLength = "x-amz-decoded-content-length"
 	HeaderNameXAmzTagging             = "x-amz-tagging"
 	HeaderNameXAmzMetaPrefix          = "x-amz-meta-"
@@ -66,6 +71,24 @@ const (
 	HeaderNameXAmzMetadataDirective   = "x-amz-metadata-directive"
 	HeaderNameXAmzBucketRegion        = "x-amz-bucket-region"
 	HeaderNameXAmzTaggingCount        = "x-amz-tagging-count"
+	HeaderNameXAmzVersionId           = "x-amz-version-id"
+	HeaderNameXAmzDeleteMarker        = "x-amz-delete-marker"
+
+	HeaderNameXAmzServerSideEncryption           = "x-amz-server-side-encryption"
+	HeaderNameXAmzSSECustomerAlgorithm           = "x-amz-server-side-encryption-customer-algorithm"
+	HeaderNameXAmzSSECustomerKey                 = "x-amz-server-side-encryption-customer-key"
+	HeaderNameXAmzSSECustomerKeyMD5              = "x-amz-server-side-encryption-customer-key-MD5"
+	HeaderNameXAmzCopySourceSSECustomerAlgorithm = "x-amz-copy-source-server-side-encryption-customer-algorithm"
+	HeaderNameXAmzCopySourceSSECustomerKey       = "x-amz-copy-source-server-side-encryption-customer-key"
+	HeaderNameXAmzCopySourceSSECustomerKeyMD5    = "x-amz-copy-source-server-side-encryption-customer-key-MD5"
+
+	HeaderNameXAmzObjectLockMode            = "x-amz-object-lock-mode"
+	HeaderNameXAmzObjectLockRetainUntilDate = "x-amz-object-lock-retain-until-date"
+	HeaderNameXAmzObjectLockLegalHold       = "x-amz-object-lock-legal-hold"
+	HeaderNameXAmzBypassGovernanceRetention = "x-amz-bypass-governance-retention"
+	HeaderNameXAmzBucketObjectLockEnabled   = "x-amz-bucket-object-lock-enabled"
+
+	HeaderNameXAmzReplicationStatus = "x-amz-replication-status"
 
 	HeaderNameIfMatch           = "If-Match"
 	HeaderNameIfNoneMatch       = "If-None-Match"
@@ -78,6 +101,7 @@ const (
 	HeaderValueAcceptRange          = "bytes"
 	HeaderValueTypeStream           = "application/octet-stream"
 	HeaderValueContentTypeXML       = "application/xml"
+	HeaderValueContentTypeJSON      = "application/json"
 	HeaderValueContentTypeDirectory = "application/directory"
 )
 
@@ -97,13 +121,15 @@ const (
 	ParamMaxKeys    = "max-keys"
 	ParamStartAfter = "start-after"
 	ParamKey        = "key"
+	ParamVersionId  = "versionId"
 
-	ParamMaxParts       = "max-parts"
-	ParamUploadIdMarker = "upload-id-marker"
-	ParamPartNoMarker   = "part-number-marker"
-	ParamPartMaxUploads = "max-uploads"
-	ParamPartDelimiter  = "delimiter"
-	ParamEncodingType   = "encoding-type"
+	ParamMaxParts        = "max-parts"
+	ParamUploadIdMarker  = "upload-id-marker"
+	ParamPartNoMarker    = "part-number-marker"
+	ParamPartMaxUploads  = "max-uploads"
+	ParamPartDelimiter   = "delimiter"
+	ParamEncodingType    = "encoding-type"
+	ParamVersionIdMarker = "version-id-marker"
 
 	ParamResponseCacheControl       = "response-cache-control"
 	ParamResponseContentType        = "response-content-type"
@@ -117,6 +143,11 @@ const (
 	MaxUploads = 1000
 )
 
+const (
+	MinPartNumber = 1
+	MaxPartNumber = 10000
+)
+
 const (
 	StorageClassStandard = "Standard"
 )
@@ -132,6 +163,21 @@ const (
 	XAttrKeyOSSCORS         = "oss:cors"
 	XAttrKeyOSSCacheControl = "oss:cache"
 	XAttrKeyOSSExpires      = "oss:expires"
+	XAttrKeyOSSVersioning   = "oss:versioning"
+	XAttrKeyOSSVersionID    = "oss:version-id"
+	XAttrKeyOSSDeleteMarker = "oss:delete-marker"
+	XAttrKeyOSSLifecycle    = "oss:lifecycle"
+	XAttrKeyOSSEncryption   = "oss:encryption"
+	XAttrKeyOSSSSE          = "oss:sse"
+	XAttrKeyOSSObjectLock   = "oss:object-lock"
+	XAttrKeyOSSWebsite      = "oss:website"
+	XAttrKeyOSSReplication  = "oss:replication"
+	XAttrKeyOSSReplStatus   = "oss:replication-status"
+	XAttrKeyOSSNotification = "oss:notification"
+	XAttrKeyOSSLogging      = "oss:logging"
+	XAttrKeyOSSAccessBlock  = "oss:public-access-block"
+	XAttrKeyOSSRetention    = proto.XAttrKeyOSSRetention
+	XAttrKeyOSSLegalHold    = proto.XAttrKeyOSSLegalHold
 
 	// Deprecated
 	XAttrKeyOSSETagDeprecated = "oss:tag"
@@ -25,14 +25,42 @@ type FSFileInfo struct {
 	Size         int64
 	Mode         os.FileMode
 	ModifyTime   time.Time
-	CreateTime time.Time
+	CreateTime   time.Time
 	ETag         string
 	Inode        uint64
 	MIMEType     string
 	Disposition  string
 	CacheControl string
 	Expires      string
-	Metadata   map[string]string `graphql:"-"` // User-defined metadata
+	VersionID    string
+	SSEAlgorithm string            // server side encryption algorithm, empty if not encrypted
+	SSEKeyMD5    string            // MD5 of the customer key for SSE-C objects
+	Metadata     map[string]string `graphql:"-"` // User-defined metadata
+
+	ReplicationStatus string // replication status, empty if the object is not replicated
+}
+
+type FSVersionInfo struct {
+	Key          string
+	VersionID    string
+	IsLatest     bool
+	DeleteMarker bool
+	Size         int64
+	ModifyTime   time.Time
+	ETag         string
+	Inode        uint64
+}
+
+// newerThan reports whether the version is more recent than the other version of the same key.
+// Inode modification time is accurate to the second, so ties are broken by version ID.
+func (info *FSVersionInfo) newerThan(other *FSVersionInfo) bool {
+	if !info.ModifyTime.Equal(other.ModifyTime) {
+		return info.ModifyTime.After(other.ModifyTime)
+	}
+	if info.VersionID == NullVersionID || other.VersionID == NullVersionID {
+		return other.VersionID == NullVersionID && info.VersionID != NullVersionID
+	}
+	return info.VersionID > other.VersionID
 }
 
 type Prefixes []string
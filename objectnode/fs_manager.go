@@ -41,6 +41,8 @@ type VolumeLoader struct {
 	closeOnce  sync.Once
 	closeCh    chan struct{}
 	metaStrict bool
+	keyStore   *SSEKeyStore
+	lockKey    []byte
 }
 
 func (loader *VolumeLoader) blacklistCleanup() {
@@ -136,6 +138,8 @@ func (loader *VolumeLoader) loadVolume(volName string) (*Volume, error) {
 			Store:            loader.store,
 			OnAsyncTaskError: onAsyncTaskError,
 			MetaStrict:       loader.metaStrict,
+			KeyStore:         loader.keyStore,
+			ObjectLockKey:    loader.lockKey,
 		}
 		if volume, err = NewVolume(config); err != nil {
 			if err != proto.ErrVolNotExists {
@@ -173,13 +177,15 @@ func (loader *VolumeLoader) Close() {
 	})
 }
 
-func NewVolumeLoader(masters []string, store Store, strict bool) *VolumeLoader {
+func NewVolumeLoader(masters []string, store Store, strict bool, keyStore *SSEKeyStore, lockKey []byte) *VolumeLoader {
 	loader := &VolumeLoader{
 		masters:    masters,
 		store:      store,
 		volumes:    make(map[string]*Volume),
 		closeCh:    make(chan struct{}),
 		metaStrict: strict,
+		keyStore:   keyStore,
+		lockKey:    lockKey,
 	}
 	go loader.blacklistCleanup()
 	return loader
@@ -191,6 +197,8 @@ type VolumeManager struct {
 	loaders    [volumeLoaderNum]*VolumeLoader
 	store      Store
 	metaStrict bool
+	keyStore   *SSEKeyStore
+	lockKey    []byte
 	closeOnce  sync.Once
 	closeCh    chan struct{}
 }
@@ -222,15 +230,24 @@ func (m *VolumeManager) init() {
 		vm: m,
 	}
 	for i := 0; i < len(m.loaders); i++ {
-		m.loaders[i] = NewVolumeLoader(m.masters, m.store, m.metaStrict)
+		m.loaders[i] = NewVolumeLoader(m.masters, m.store, m.metaStrict, m.keyStore, m.lockKey)
 	}
 }
 
 func NewVolumeManager(masters []string, strict bool) *VolumeManager {
+	return NewVolumeManagerWithKeyStore(masters, strict, nil, nil)
+}
+
+// NewVolumeManagerWithKeyStore creates a VolumeManager whose volumes encrypt objects with the
+// master keys of the specified key store when server side encryption is requested, and sign the
+// object lock changes with the key shared with the meta nodes.
+func NewVolumeManagerWithKeyStore(masters []string, strict bool, keyStore *SSEKeyStore, lockKey []byte) *VolumeManager {
 	manager := &VolumeManager{
 		masters:    masters,
 		closeCh:    make(chan struct{}),
 		metaStrict: strict,
+		keyStore:   keyStore,
+		lockKey:    lockKey,
 	}
 	manager.init()
 	return manager
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"math"
 	"os"
 	"sort"
 	"strings"
@@ -76,6 +77,14 @@ type VolumeConfig struct {
 
 	// Get OSSMeta from the MetaNode every time if it is set true.
 	MetaStrict bool
+
+	// Master keys used to encrypt objects with server side encryption (SSE-S3).
+	// This is a optional configuration item.
+	KeyStore *SSEKeyStore
+
+	// Key shared with the meta nodes to sign the object lock changes.
+	// This is a optional configuration item.
+	ObjectLockKey []byte
 }
 
 type PutFileOption struct {
@@ -85,6 +94,11 @@ type PutFileOption struct {
 	Metadata     map[string]string
 	CacheControl string
 	Expires      string
+	Encryption   *SSEOption
+	Retention    *proto.ObjectRetention
+	LegalHold    string
+	// Replication status of the new object, which is empty if the object is not replicated.
+	ReplicationStatus string
 }
 
 type ListFilesV1Option struct {
@@ -127,6 +141,7 @@ type Volume struct {
 	store      Store // Storage for ACP management
 	name       string
 	metaLoader ossMetaLoader
+	keyStore   *SSEKeyStore // Master keys for server side encryption
 	ticker     *time.Ticker
 	createTime int64
 
@@ -174,6 +189,60 @@ func (v *Volume) loadOSSMeta() {
 		return
 	}
 	v.metaLoader.storeCors(cors)
+
+	var versioning *VersioningConfiguration
+	if versioning, err = v.loadBucketVersioning(); err != nil {
+		return
+	}
+	v.metaLoader.storeVersioning(versioning)
+
+	var lifecycle *LifecycleConfiguration
+	if lifecycle, err = v.loadBucketLifecycle(); err != nil {
+		return
+	}
+	v.metaLoader.storeLifecycle(lifecycle)
+
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = v.loadBucketEncryption(); err != nil {
+		return
+	}
+	v.metaLoader.storeEncryption(encryption)
+
+	var objectLock *ObjectLockConfiguration
+	if objectLock, err = v.loadBucketObjectLock(); err != nil {
+		return
+	}
+	v.metaLoader.storeObjectLock(objectLock)
+
+	var website *WebsiteConfiguration
+	if website, err = v.loadBucketWebsite(); err != nil {
+		return
+	}
+	v.metaLoader.storeWebsite(website)
+
+	var replication *ReplicationConfiguration
+	if replication, err = v.loadBucketReplication(); err != nil {
+		return
+	}
+	v.metaLoader.storeReplication(replication)
+
+	var notification *NotificationConfiguration
+	if notification, err = v.loadBucketNotification(); err != nil {
+		return
+	}
+	v.metaLoader.storeNotification(notification)
+
+	var logging *BucketLoggingStatus
+	if logging, err = v.loadBucketLogging(); err != nil {
+		return
+	}
+	v.metaLoader.storeLogging(logging)
+
+	var block *PublicAccessBlockConfiguration
+	if block, err = v.loadBucketPublicAccessBlock(); err != nil {
+		return
+	}
+	v.metaLoader.storePublicAccessBlock(block)
 }
 
 func (v *Volume) Name() string {
@@ -236,6 +305,141 @@ func (v *Volume) loadBucketCors() (configuration *CORSConfiguration, err error)
 	return configuration, nil
 }
 
+func (v *Volume) loadBucketVersioning() (configuration *VersioningConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSVersioning); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &VersioningConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketLifecycle() (configuration *LifecycleConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSLifecycle); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &LifecycleConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketEncryption() (configuration *ServerSideEncryptionConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSEncryption); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ServerSideEncryptionConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketObjectLock() (configuration *ObjectLockConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSObjectLock); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ObjectLockConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketWebsite() (configuration *WebsiteConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSWebsite); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &WebsiteConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketReplication() (configuration *ReplicationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSReplication); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ReplicationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketNotification() (configuration *NotificationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &NotificationConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketLogging() (configuration *BucketLoggingStatus, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSLogging); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &BucketLoggingStatus{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketPublicAccessBlock() (configuration *PublicAccessBlockConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSAccessBlock); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &PublicAccessBlockConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
 func (v *Volume) getInodeFromPath(path string) (inode uint64, err error) {
 	if path == "/" {
 		return volumeRootInode, nil
@@ -334,6 +538,11 @@ func (v *Volume) OSSSecure() (accessKey, secretKey string) {
 	return v.mw.OSSSecure()
 }
 
+// QoS returns the limits on the requests to the volume, which are refreshed with the volume view.
+func (v *Volume) QoS() *proto.QoSLimit {
+	return v.mw.QoS()
+}
+
 // ListFilesV1 returns file and directory entry list information that meets the parameters.
 // It supports parameters such as prefix, delimiter, and paging.
 // It is a data plane logical encapsulation of the object storage interface ListObjectsV1.
@@ -486,12 +695,13 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	// This file has only inode but no dentry. In this way, this temporary file can be made invisible
 	// in the true sense. In order to avoid the adverse impact of other user operations on temporary data.
 	var invisibleTempDataInode *proto.InodeInfo
-	if invisibleTempDataInode, err = v.mw.InodeCreate_ll(DefaultFileMode, 0, 0, nil); err != nil {
+	if invisibleTempDataInode, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil); err != nil {
 		return
 	}
+	var bound bool
 	defer func() {
 		// An error has caused the entire process to fail. Delete the inode and release the written data.
-		if err != nil {
+		if err != nil && !bound {
 			log.LogWarnf("PutObject: unlink temp inode: volume(%v) path(%v) inode(%v)",
 				v.name, path, invisibleTempDataInode.Inode)
 			_, _ = v.mw.InodeUnlink_ll(invisibleTempDataInode.Inode)
@@ -511,10 +721,26 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	}()
 
 	var (
-		md5Hash  = md5.New()
-		md5Value string
+		md5Hash   = md5.New()
+		md5Value  string
+		writeHash hash.Hash = md5Hash
 	)
-	if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash); err != nil {
+	// If server side encryption is requested, the encrypted stream is written to the inode while
+	// the MD5 is still computed from the plaintext.
+	var encryption *objectEncryption
+	if opt != nil && opt.Encryption != nil {
+		var dataKey []byte
+		if encryption, dataKey, err = newObjectEncryption(opt.Encryption, v.keyStore); err != nil {
+			log.LogErrorf("PutObject: new object encryption fail: volume(%v) path(%v) err(%v)",
+				v.name, path, err)
+			return
+		}
+		if reader, err = newSSEEncryptReader(io.TeeReader(reader, md5Hash), dataKey); err != nil {
+			return
+		}
+		writeHash = nil
+	}
+	if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, writeHash); err != nil {
 		return
 	}
 	// compute file md5
@@ -545,6 +771,14 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 			v.name, path, invisibleTempDataInode.Inode, XAttrKeyOSSETag, md5Value, err)
 		return nil, err
 	}
+	// Save encryption information
+	if encryption != nil {
+		if err = v.mw.XAttrSet_ll(finalInode.Inode, []byte(XAttrKeyOSSSSE), encryption.encode()); err != nil {
+			log.LogErrorf("PutObject: store encryption fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, invisibleTempDataInode.Inode, err)
+			return nil, err
+		}
+	}
 	// If MIME information is valid, use extended attributes for storage.
 	if opt != nil && opt.MIMEType != "" {
 		if err = v.mw.XAttrSet_ll(invisibleTempDataInode.Inode, []byte(XAttrKeyOSSMIME), []byte(opt.MIMEType)); err != nil {
@@ -599,6 +833,14 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 				v.name, path, invisibleTempDataInode.Inode, name, value)
 		}
 	}
+	// The replication status is stored with the object, so the pending replication survives restarts.
+	if opt != nil && len(opt.ReplicationStatus) > 0 {
+		if err = v.mw.XAttrSet_ll(invisibleTempDataInode.Inode, []byte(XAttrKeyOSSReplStatus), []byte(opt.ReplicationStatus)); err != nil {
+			log.LogErrorf("PutObject: store replication status fail: volume(%v) path(%v) inode(%v) status(%v) err(%v)",
+				v.name, path, invisibleTempDataInode.Inode, opt.ReplicationStatus, err)
+			return nil, err
+		}
+	}
 
 	// create file info
 	fsInfo = &FSFileInfo{
@@ -610,20 +852,39 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
 	}
+	if encryption != nil {
+		fsInfo.applyEncryption(encryption, finalInode.Size)
+	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode)
+	fsInfo.VersionID, err = v.applyInodeToDEntry(path, parentId, lastPathItem.Name, invisibleTempDataInode.Inode)
 	if err != nil {
 		log.LogErrorf("PutObject: apply new inode to dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 			parentId, lastPathItem.Name, invisibleTempDataInode.Inode, err)
 		return
 	}
+	bound = true
+	if opt != nil {
+		if err = v.lockObject(path, invisibleTempDataInode.Inode, opt.Retention, opt.LegalHold); err != nil {
+			return nil, err
+		}
+	}
 	return fsInfo, nil
 }
 
-func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64) (err error) {
+// applyInodeToDEntry binds the inode to the dentry of the specified path. If versioning has been
+// configured on the bucket, the inode will be assigned a version ID which is returned to the caller,
+// and the inode previously bound to the dentry is kept as a non-current version.
+func (v *Volume) applyInodeToDEntry(path string, parentId uint64, name string, inode uint64) (versionID string, err error) {
+	if versionID, err = v.assignVersionID(path, inode); err != nil {
+		log.LogErrorf("applyInodeToDEntry: assign version ID fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+
+	var existInode uint64
 	var existMode uint32
-	_, existMode, err = v.mw.Lookup_ll(parentId, name)
+	existInode, existMode, err = v.mw.Lookup_ll(parentId, name)
 	if err != nil && err != syscall.ENOENT {
 		log.LogErrorf("applyInodeToDEntry: meta lookup fail: parentID(%v) name(%v) err(%v)", parentId, name, err)
 		return
@@ -644,7 +905,7 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64)
 			err = syscall.EINVAL
 			return
 		}
-		if err = v.applyInodeToExistDentry(parentId, name, inode); err != nil {
+		if err = v.applyInodeToExistDentry(path, parentId, name, inode, existInode, existMode); err != nil {
 			log.LogErrorf("applyInodeToDEntry: apply inode to exist dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 				parentId, name, inode, err)
 			return
@@ -686,13 +947,15 @@ func (v *Volume) DeletePath(path string) (err error) {
 	if mode.IsDir() {
 		// Check if the directory is empty and cannot delete non-empty directories.
 		var dentries []proto.Dentry
-		dentries, err = v.mw.ReadDir_ll(ino)
+		dentries, err = v.mw.ReadDirLimit_ll(ino, "", 1)
 		if err != nil || len(dentries) > 0 {
 			return
 		}
 	}
 	log.LogWarnf("DeletePath: delete: volume(%v) path(%v) inode(%v)", v.name, path, ino)
-	if _, err = v.mw.Delete_ll(parent, name, mode.IsDir()); err != nil {
+	var info *proto.InodeInfo
+	if info, err = v.mw.Delete_ll(parent, name, mode.IsDir()); err != nil || info == nil {
+		// the object is moved into the trash, or deleted by another request
 		return
 	}
 
@@ -741,6 +1004,26 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 		var encoded = opt.Tagging.Encode()
 		extend[XAttrKeyOSSTagging] = encoded
 	}
+	// If server side encryption is requested, the data key shared by all parts is generated here.
+	if opt != nil && opt.Encryption != nil {
+		var encryption *objectEncryption
+		if encryption, _, err = newObjectEncryption(opt.Encryption, v.keyStore); err != nil {
+			log.LogErrorf("InitMultipart: new object encryption fail: volume(%v) path(%v) err(%v)",
+				v.name, path, err)
+			return "", err
+		}
+		extend[XAttrKeyOSSSSE] = string(encryption.encode())
+	}
+	// The object lock is kept with the upload and applied after the upload is completed.
+	if opt != nil && opt.Retention != nil {
+		extend[XAttrKeyOSSRetention] = string(opt.Retention.Encode())
+	}
+	if opt != nil && opt.LegalHold == proto.LegalHoldStatusOn {
+		extend[XAttrKeyOSSLegalHold] = opt.LegalHold
+	}
+	if opt != nil && len(opt.ReplicationStatus) > 0 {
+		extend[XAttrKeyOSSReplStatus] = opt.ReplicationStatus
+	}
 
 	// Iterate all the meta partition to create multipart id
 	multipartID, err = v.mw.InitMultipart_ll(path, extend)
@@ -751,7 +1034,9 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	return multipartID, nil
 }
 
-func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader) (*FSFileInfo, error) {
+// WritePart writes the data of a part of the multipart upload. If the upload is encrypted, the part
+// is encrypted with the data key of the upload, and the customer key must be specified for SSE-C.
+func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader, sse *SSEOption) (*FSFileInfo, error) {
 	var exist bool
 	var err error
 	defer func() {
@@ -763,9 +1048,30 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	var fInfo *FSFileInfo
 	_, fileName := splitPath(path)
 
-	// create temp file (inode only, invisible for user)
+	// check the encryption of the multipart upload
+	var multipartInfo *proto.MultipartInfo
+	if multipartInfo, err = v.mw.GetMultipart_ll(path, multipartId); err != nil {
+		log.LogErrorf("WritePart: meta get multipart fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartId, err)
+		return nil, err
+	}
+	var dataKey []byte
+	if raw, ok := multipartInfo.Extend[XAttrKeyOSSSSE]; ok {
+		var encryption *objectEncryption
+		if encryption, err = parseObjectEncryption([]byte(raw)); err != nil {
+			log.LogErrorf("WritePart: parse encryption fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+				v.name, path, multipartId, err)
+			return nil, err
+		}
+		if dataKey, err = encryption.dataKey(sse, v.keyStore); err != nil {
+			return nil, err
+		}
+	}
+
+	// create temp file (inode only, invisible for user), whose extents are moved to the complete
+	// inode under the quotas of the directory
 	var tempInodeInfo *proto.InodeInfo
-	if tempInodeInfo, err = v.mw.InodeCreate_ll(DefaultFileMode, 0, 0, nil); err != nil {
+	if tempInodeInfo, err = v.mw.InodeCreate_ll(0, DefaultFileMode, 0, 0, nil); err != nil {
 		log.LogErrorf("WritePart: meta create inode fail: multipartID(%v) partID(%v) err(%v)",
 			multipartId, partId, err)
 		return nil, err
@@ -798,13 +1104,24 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	}()
 	// Write data to data node
 	var (
-		size    uint64
-		etag    string
-		md5Hash = md5.New()
+		size      uint64
+		etag      string
+		md5Hash             = md5.New()
+		writeHash hash.Hash = md5Hash
 	)
-	if size, err = v.streamWrite(tempInodeInfo.Inode, reader, md5Hash); err != nil {
+	if dataKey != nil {
+		if reader, err = newSSEEncryptReader(io.TeeReader(reader, md5Hash), dataKey); err != nil {
+			return nil, err
+		}
+		writeHash = nil
+	}
+	if size, err = v.streamWrite(tempInodeInfo.Inode, reader, writeHash); err != nil {
 		return nil, err
 	}
+	// The size of the part recorded in the session is always the plaintext size.
+	if dataKey != nil {
+		size = sseDecryptedSize(size)
+	}
 	// compute file md5
 	etag = hex.EncodeToString(md5Hash.Sum(nil))
 
@@ -840,6 +1157,41 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	return fInfo, nil
 }
 
+// CopyPart writes the part of the multipart upload with the data in the range [offset, offset+size)
+// of the source object.
+// The extent keys of the source are not shared with the part, because the extents are released
+// along with the source object once it is deleted, and the ETag of the part is the MD5 of the copied
+// range anyway, so the data is streamed through ObjectNode as WritePart does.
+func (v *Volume) CopyPart(sv *Volume, sourcePath, path, multipartID string, partID uint16, offset, size uint64,
+	sourceSSE, sse *SSEOption) (info *FSFileInfo, err error) {
+	defer func() {
+		log.LogInfof("Audit: CopyPart: volume(%v) path(%v) multipartID(%v) partID(%v) source volume(%v) source path(%v) offset(%v) size(%v) err(%v)",
+			v.name, path, multipartID, partID, sv.name, sourcePath, offset, size, err)
+	}()
+
+	var sInode uint64
+	var sMode os.FileMode
+	if _, sInode, _, sMode, err = sv.recursiveLookupTarget(sourcePath); err != nil {
+		log.LogErrorf("CopyPart: look up source path fail: volume(%v) source path(%v) err(%v)", sv.name, sourcePath, err)
+		return
+	}
+	if sMode.IsDir() {
+		return nil, syscall.EINVAL
+	}
+	if size > MaxCopyObjectSize {
+		return nil, syscall.EFBIG
+	}
+
+	var pr, pw = io.Pipe()
+	defer func() {
+		_ = pr.Close()
+	}()
+	go func() {
+		_ = pw.CloseWithError(sv.readInode(sourcePath, sInode, pw, offset, size, sourceSSE))
+	}()
+	return v.WritePart(path, multipartID, partID, pr, sse)
+}
+
 func (v *Volume) AbortMultipart(path string, multipartID string) (err error) {
 	defer func() {
 		log.LogInfof("Audit: AbortMultipart: volume(%v) path(%v) multipartID(%v) err(%v)",
@@ -890,17 +1242,29 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	parts := multipartInfo.Parts
 	sort.SliceStable(parts, func(i, j int) bool { return parts[i].ID < parts[j].ID })
 
-	// create inode for complete data
+	var (
+		pathItems = NewPathIterator(path).ToSlice()
+		filename  = pathItems[len(pathItems)-1].Name
+		parentId  uint64
+	)
+	if parentId, err = v.recursiveMakeDirectory(path); err != nil {
+		log.LogErrorf("CompleteMultipart: recursive make directory fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+			v.name, path, multipartID, err)
+		return
+	}
+
+	// create inode for complete data under the directory, which the inode inherits the quotas from
 	var completeInodeInfo *proto.InodeInfo
-	if completeInodeInfo, err = v.mw.InodeCreate_ll(DefaultFileMode, 0, 0, nil); err != nil {
+	if completeInodeInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil); err != nil {
 		log.LogErrorf("CompleteMultipart: meta inode create fail: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
 		return
 	}
 	log.LogDebugf("CompleteMultipart: meta inode create: volume(%v) path(%v) multipartID(%v) inode(%v)",
 		v.name, path, multipartID, completeInodeInfo.Inode)
+	var bound bool
 	defer func() {
-		if err != nil {
+		if err != nil && !bound {
 			log.LogWarnf("CompleteMultipart: destroy inode: volume(%v) path(%v) multipartID(%v) inode(%v)",
 				v.name, path, multipartID, completeInodeInfo.Inode)
 			if deleteErr := v.mw.InodeDelete_ll(completeInodeInfo.Inode); deleteErr != nil {
@@ -950,17 +1314,6 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		return
 	}
 
-	var (
-		pathItems = NewPathIterator(path).ToSlice()
-		filename  = pathItems[len(pathItems)-1].Name
-		parentId  uint64
-	)
-	if parentId, err = v.recursiveMakeDirectory(path); err != nil {
-		log.LogErrorf("PutObject: recursive make directory fail: volume(%v) path(%v) err(%v)",
-			v.name, path, err)
-		return
-	}
-
 	var finalInode *proto.InodeInfo
 	if finalInode, err = v.mw.InodeGet_ll(completeInodeInfo.Inode); err != nil {
 		log.LogErrorf("CompleteMultipart: get inode fail: volume(%v) inode(%v) err(%v)",
@@ -982,6 +1335,9 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	extend := multipartInfo.Extend
 	if len(extend) > 0 {
 		for key, value := range extend {
+			if key == XAttrKeyOSSRetention || key == XAttrKeyOSSLegalHold {
+				continue
+			}
 			if err = v.mw.XAttrSet_ll(completeInodeInfo.Inode, []byte(key), []byte(value)); err != nil {
 				log.LogErrorf("CompleteMultipart: store multipart extend fail: volume(%v) path(%v) inode(%v) key(%v) value(%v) err(%v)",
 					v.name, path, completeInodeInfo.Inode, key, value, err)
@@ -989,6 +1345,25 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 			}
 		}
 	}
+	// The parts of an encrypted object are encrypted streams, so the plaintext size of each part
+	// is recorded to locate the streams while reading.
+	var encryption *objectEncryption
+	if raw, ok := extend[XAttrKeyOSSSSE]; ok {
+		if encryption, err = parseObjectEncryption([]byte(raw)); err != nil {
+			log.LogErrorf("CompleteMultipart: parse encryption fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+				v.name, path, multipartID, err)
+			return nil, err
+		}
+		encryption.Parts = make([]uint64, 0, len(parts))
+		for _, part := range parts {
+			encryption.Parts = append(encryption.Parts, part.Size)
+		}
+		if err = v.mw.XAttrSet_ll(completeInodeInfo.Inode, []byte(XAttrKeyOSSSSE), encryption.encode()); err != nil {
+			log.LogErrorf("CompleteMultipart: store encryption fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, completeInodeInfo.Inode, err)
+			return nil, err
+		}
+	}
 
 	// remove multipart
 	err = v.mw.RemoveMultipart_ll(path, multipartID)
@@ -1024,12 +1399,29 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
 	}
+	if encryption != nil {
+		fInfo.applyEncryption(encryption, finalInode.Size)
+	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode)
+	fInfo.VersionID, err = v.applyInodeToDEntry(path, parentId, filename, completeInodeInfo.Inode)
 	if err != nil {
-		log.LogErrorf("CompleteMultipart: apply new inode to dentry fail, parent id (%v), file name(%v), inode(%v)",
-			parentId, filename, completeInodeInfo.Inode)
+		log.LogErrorf("CompleteMultipart: apply new inode to dentry fail: volume(%v) parentID(%v) name(%v) inode(%v) err(%v)",
+			v.name, parentId, filename, completeInodeInfo.Inode, err)
+		return nil, err
+	}
+	bound = true
+	// apply object lock
+	var retention *proto.ObjectRetention
+	if raw, ok := extend[XAttrKeyOSSRetention]; ok {
+		if retention, err = proto.ParseObjectRetention([]byte(raw)); err != nil {
+			log.LogErrorf("CompleteMultipart: parse retention fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+				v.name, path, multipartID, err)
+			return nil, err
+		}
+	}
+	if err = v.lockObject(path, completeInodeInfo.Inode, retention, extend[XAttrKeyOSSLegalHold]); err != nil {
+		return nil, err
 	}
 	return fInfo, nil
 }
@@ -1126,28 +1518,26 @@ func (v *Volume) applyInodeToNewDentry(parentID uint64, name string, inode uint6
 	return
 }
 
-func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uint64) (err error) {
-	var oldInode uint64
-	oldInode, err = v.mw.DentryUpdate_ll(parentID, name, inode)
-	if err != nil {
-		log.LogErrorf("applyInodeToExistDentry: meta update dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
-			parentID, name, inode, err)
+// applyInodeToExistDentry points the dentry from the old inode to the new one. The old inode is
+// kept as a non-current version before it is unbound from the dentry, otherwise it is unlinked
+// together with the unbinding, which fails with EPERM if the old inode is locked.
+func (v *Volume) applyInodeToExistDentry(path string, parentID uint64, name string, inode, oldInode uint64, mode uint32) (err error) {
+	// keep old inode as a non-current version if versioning is configured
+	var retained bool
+	if retained, err = v.retainNoncurrentVersion(path, oldInode); err != nil {
+		log.LogErrorf("applyInodeToExistDentry: retain non-current version fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, oldInode, err)
 		return
 	}
 
-	// unlink and evict old inode
-	log.LogWarnf("applyInodeToExistDentry: unlink inode: volume(%v) inode(%v)", v.name, oldInode)
-	if _, err = v.mw.InodeUnlink_ll(oldInode); err != nil {
-		log.LogWarnf("applyInodeToExistDentry: unlink inode fail: volume(%v) inode(%v) err(%v)",
-			v.name, oldInode, err)
-	}
-
-	log.LogWarnf("applyInodeToExistDentry: evict inode: volume(%v) inode(%v)", v.name, oldInode)
-	if err = v.mw.Evict(oldInode); err != nil {
-		log.LogWarnf("applyInodeToExistDentry: evict inode fail: volume(%v) inode(%v) err(%v)",
-			v.name, oldInode, err)
+	if err = v.mw.DentryReplace_ll(parentID, name, mode, inode, oldInode); err != nil {
+		log.LogErrorf("applyInodeToExistDentry: meta replace dentry fail: parentID(%v) name(%v) inode(%v) oldInode(%v) err(%v)",
+			parentID, name, inode, oldInode, err)
+		if retained {
+			v.discardNoncurrentVersion(path, oldInode)
+		}
+		return
 	}
-	err = nil
 	return
 }
 
@@ -1181,6 +1571,10 @@ func (v *Volume) loadUserDefinedMetadata(inode uint64) (metadata map[string]stri
 }
 
 func (v *Volume) ReadFile(path string, writer io.Writer, offset, size uint64) error {
+	return v.readFile(path, writer, offset, size, nil)
+}
+
+func (v *Volume) readFile(path string, writer io.Writer, offset, size uint64, sse *SSEOption) error {
 	var err error
 
 	var ino uint64
@@ -1191,6 +1585,13 @@ func (v *Volume) ReadFile(path string, writer io.Writer, offset, size uint64) er
 	if mode.IsDir() {
 		return nil
 	}
+	return v.readInode(path, ino, writer, offset, size, sse)
+}
+
+// readInode reads data of the inode. If the data is encrypted, it is decrypted with the data key
+// unwrapped by the master key or the customer key specified by the SSE option.
+func (v *Volume) readInode(path string, ino uint64, writer io.Writer, offset, size uint64, sse *SSEOption) error {
+	var err error
 
 	// read file data
 	var inoInfo *proto.InodeInfo
@@ -1198,6 +1599,19 @@ func (v *Volume) ReadFile(path string, writer io.Writer, offset, size uint64) er
 		return err
 	}
 
+	var encryption *objectEncryption
+	if encryption, err = v.loadObjectEncryption(ino); err != nil {
+		log.LogErrorf("ReadFile: load encryption fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, ino, err)
+		return err
+	}
+	var dataKey []byte
+	if encryption != nil {
+		if dataKey, err = encryption.dataKey(sse, v.keyStore); err != nil {
+			return err
+		}
+	}
+
 	if err = v.ec.OpenStream(ino); err != nil {
 		log.LogErrorf("ReadFile: data open stream fail, Inode(%v) err(%v)", ino, err)
 		return err
@@ -1208,6 +1622,10 @@ func (v *Volume) ReadFile(path string, writer io.Writer, offset, size uint64) er
 		}
 	}()
 
+	if encryption != nil {
+		return v.readEncryptedInode(path, inoInfo, encryption, dataKey, writer, offset, size)
+	}
+
 	var upper = size + offset
 	if upper > inoInfo.Size {
 		upper = inoInfo.Size - offset
@@ -1269,13 +1687,20 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 		}
 		break
 	}
+	return v.inodeMeta(path, inoInfo, mode)
+}
 
+func (v *Volume) inodeMeta(path string, inoInfo *proto.InodeInfo, mode os.FileMode) (info *FSFileInfo, err error) {
+	var inode = inoInfo.Inode
 	var (
 		etagValue    ETagValue
 		mimeType     string
 		disposition  string
 		cacheControl string
 		expires      string
+		versionID    string
+		replStatus   string
+		encryption   *objectEncryption
 	)
 
 	if mode.IsDir() {
@@ -1289,7 +1714,7 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 		// 2. MIME type
 		var xattrs []*proto.XAttrInfo
 		var xattrKeys = []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated, XAttrKeyOSSMIME, XAttrKeyOSSDISPOSITION,
-			XAttrKeyOSSCacheControl, XAttrKeyOSSExpires}
+			XAttrKeyOSSCacheControl, XAttrKeyOSSExpires, XAttrKeyOSSVersionID, XAttrKeyOSSSSE, XAttrKeyOSSReplStatus}
 		if xattrs, err = v.mw.BatchGetXAttr([]uint64{inode}, xattrKeys); err != nil {
 			log.LogErrorf("ObjectMeta: meta get xattr fail, volume(%v) inode(%v) path(%v) keys(%v) err(%v)",
 				v.name, inode, path, strings.Join(xattrKeys, ","), err)
@@ -1309,6 +1734,15 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 			disposition = string(xattr.Get(XAttrKeyOSSDISPOSITION))
 			cacheControl = string(xattr.Get(XAttrKeyOSSCacheControl))
 			expires = string(xattr.Get(XAttrKeyOSSExpires))
+			versionID = string(xattr.Get(XAttrKeyOSSVersionID))
+			replStatus = string(xattr.Get(XAttrKeyOSSReplStatus))
+			if raw := xattr.Get(XAttrKeyOSSSSE); len(raw) > 0 {
+				if encryption, err = parseObjectEncryption(raw); err != nil {
+					log.LogErrorf("ObjectMeta: parse encryption fail: volume(%v) inode(%v) path(%v) err(%v)",
+						v.name, inode, path, err)
+					return
+				}
+			}
 		}
 	}
 
@@ -1343,7 +1777,13 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, err error) {
 		Disposition:  disposition,
 		CacheControl: cacheControl,
 		Expires:      expires,
+		VersionID:    versionID,
 		Metadata:     metadata,
+
+		ReplicationStatus: replStatus,
+	}
+	if encryption != nil {
+		info.applyEncryption(encryption, inoInfo.Size)
 	}
 	return
 }
@@ -1357,6 +1797,34 @@ func (v *Volume) Close() error {
 	return nil
 }
 
+// rangeDir calls fn for each dentry of the directory in the order of their names, starting from
+// the dentry named from, until fn returns false. The dentries are read page by page, so that a
+// large directory is not replied in one packet.
+func (v *Volume) rangeDir(dir uint64, from string, fn func(dentry *proto.Dentry) bool) (err error) {
+	var first = true
+	for {
+		var children []proto.Dentry
+		if children, err = v.mw.ReadDirLimit_ll(dir, from, proto.ReadDirPageSize); err != nil {
+			return
+		}
+		more := len(children) == proto.ReadDirPageSize
+		// the first dentry of a page is the last one of the previous page
+		if !first && len(children) > 0 && children[0].Name == from {
+			children = children[1:]
+		}
+		first = false
+		for i := range children {
+			if !fn(&children[i]) {
+				return
+			}
+		}
+		if !more || len(children) == 0 {
+			return
+		}
+		from = children[len(children)-1].Name
+	}
+}
+
 // Find the path recursively and return the exact inode information.
 // When a path conflict is found, for example, a given path is a directory,
 // and the actual search result is a non-directory, an ENOENT error is returned.
@@ -1373,6 +1841,10 @@ func (v *Volume) recursiveLookupTarget(path string) (parent uint64, ino uint64,
 	}
 	for pathIterator.HasNext() {
 		var pathItem = pathIterator.Next()
+		if parent == rootIno && (pathItem.Name == VersionsDirName || pathItem.Name == proto.TrashDirName) {
+			err = syscall.ENOENT
+			return
+		}
 		var curIno uint64
 		var curMode uint32
 		curIno, curMode, err = v.mw.Lookup_ll(parent, pathItem.Name)
@@ -1415,6 +1887,10 @@ func (v *Volume) recursiveMakeDirectory(path string) (ino uint64, err error) {
 		if !pathItem.IsDirectory {
 			break
 		}
+		if ino == rootIno && (pathItem.Name == VersionsDirName || pathItem.Name == proto.TrashDirName) {
+			err = syscall.EINVAL
+			return
+		}
 		var curIno uint64
 		var curMode uint32
 		curIno, curMode, err = v.mw.Lookup_ll(ino, pathItem.Name)
@@ -1505,7 +1981,7 @@ func (v *Volume) lookupDirectories(dirs []string, autoCreate bool) (inode uint64
 }
 
 func (v *Volume) listFilesV1(prefix, marker, delimiter string, maxKeys uint64) (infos []*FSFileInfo, prefixes Prefixes, nextMarker string, err error) {
-	var prefixMap = PrefixMap(make(map[string]struct{}))
+	var prefixMap PrefixMap
 
 	parentId, dirs, err := v.findParentId(prefix)
 
@@ -1523,12 +1999,7 @@ func (v *Volume) listFilesV1(prefix, marker, delimiter string, maxKeys uint64) (
 
 	log.LogDebugf("listFilesV1: find parent ID, prefix(%v) marker(%v) delimiter(%v) parentId(%v) dirs(%v)", prefix, marker, delimiter, parentId, len(dirs))
 
-	// Init the value that queried result count.
-	// Check this value when adding key to contents or common prefix,
-	// return if it reach to max keys
-	var rc uint64
-	// recursion scan
-	infos, prefixMap, nextMarker, _, err = v.recursiveScan(infos, prefixMap, parentId, maxKeys, rc, dirs, prefix, marker, delimiter)
+	infos, prefixMap, nextMarker, err = v.listPrefix(parentId, dirs, prefix, marker, delimiter, maxKeys)
 	if err != nil {
 		log.LogErrorf("listFilesV1: volume list dir fail: Volume(%v) err(%v)", v.name, err)
 		return
@@ -1549,7 +2020,7 @@ func (v *Volume) listFilesV1(prefix, marker, delimiter string, maxKeys uint64) (
 }
 
 func (v *Volume) listFilesV2(prefix, startAfter, contToken, delimiter string, maxKeys uint64) (infos []*FSFileInfo, prefixes Prefixes, nextMarker string, err error) {
-	var prefixMap = PrefixMap(make(map[string]struct{}))
+	var prefixMap PrefixMap
 
 	var marker string
 	if startAfter != "" {
@@ -1574,12 +2045,7 @@ func (v *Volume) listFilesV2(prefix, startAfter, contToken, delimiter string, ma
 
 	log.LogDebugf("listFilesV2: find parent ID, prefix(%v) marker(%v) delimiter(%v) parentId(%v) dirs(%v)", prefix, marker, delimiter, parentId, len(dirs))
 
-	// Init the value that queried result count.
-	// Check this value when adding key to contents or common prefix,
-	// return if it reach to max keys
-	var rc uint64
-	// recursion scan
-	infos, prefixMap, nextMarker, _, err = v.recursiveScan(infos, prefixMap, parentId, maxKeys, rc, dirs, prefix, marker, delimiter)
+	infos, prefixMap, nextMarker, err = v.listPrefix(parentId, dirs, prefix, marker, delimiter, maxKeys)
 	if err != nil {
 		log.LogErrorf("listFilesV2: Volume list dir fail, Volume(%v) err(%v)", v.name, err)
 		return
@@ -1648,118 +2114,65 @@ func (v *Volume) findParentId(prefix string) (inode uint64, prefixDirs []string,
 	return
 }
 
-// Recursive scan of the directory starting from the given parentID. Match files and directories
-// that match the prefix and delimiter criteria. Stop when the number of matches reaches a threshold
-// or all files and directories are scanned.
-func (v *Volume) recursiveScan(fileInfos []*FSFileInfo, prefixMap PrefixMap, parentId, maxKeys, rc uint64,
-	dirs []string, prefix, marker, delimiter string) ([]*FSFileInfo, PrefixMap, string, uint64, error) {
-	var err error
-	var nextMarker string
+// listPrefix lists the files and directories under the directory found by findParentId which match
+// the prefix and delimiter criteria, in the order of scanning the directory tree depth-first.
+// The directory tree is scanned by the meta nodes, and the scan stops once the number of matches
+// exceeds maxKeys, and the first match beyond maxKeys is returned as the next marker.
+func (v *Volume) listPrefix(parentId uint64, dirs []string, prefix, marker, delimiter string,
+	maxKeys uint64) (fileInfos []*FSFileInfo, prefixMap PrefixMap, nextMarker string, err error) {
+	prefixMap = PrefixMap(make(map[string]struct{}))
 
-	var currentPath = strings.Join(dirs, pathSep) + pathSep
-
-	if len(dirs) > 0 && prefix != "" && strings.HasSuffix(currentPath, prefix) {
-		// When the current scanning position is not the root directory, a prefix matching
-		// check is performed on the current directory first.
-		//
-		// The reason for this is that according to the definition of Amazon S3's ListObjects
-		// interface, directory entries that meet the exact prefix match will be returned as
-		// a Content result, not as a CommonPrefix.
-
-		// Add check to marker, if request contain marker, current path must greater than marker,
-		// otherwise can not put it to prefix map
-		if (len(marker) > 0 && currentPath > marker) || len(marker) == 0 {
-			fileInfo := &FSFileInfo{
-				Inode: parentId,
-				Path:  currentPath,
-			}
-			// If the number of matches reaches the threshold given by maxKey,
-			// stop scanning and return results.
-			// To get the next marker, first compare the result counts with the max key
-			// it means that when result count reach the amx key, continue to find the next key as the next marker
-			if rc >= maxKeys {
-				return fileInfos, prefixMap, currentPath, rc, nil
-			}
-			fileInfos = append(fileInfos, fileInfo)
-			rc++
+	var parentPath string
+	if len(dirs) > 0 {
+		parentPath = strings.Join(dirs, pathSep) + pathSep
+	}
+
+	// According to the definition of Amazon S3's ListObjects interface, the directory which
+	// matches the prefix exactly is returned as a Content result, not as a CommonPrefix.
+	var rc uint64
+	if len(dirs) > 0 && parentPath == prefix && (marker == "" || parentPath > marker) {
+		if rc >= maxKeys {
+			return fileInfos, prefixMap, parentPath, nil
 		}
+		fileInfos = append(fileInfos, &FSFileInfo{
+			Inode: parentId,
+			Path:  parentPath,
+		})
+		rc++
 	}
 
-	// During the process of scanning the child nodes of the current directory, there may be other
-	// parallel operations that may delete the current directory.
-	// If got the syscall.ENOENT error when invoke readdir, it means that the above situation has occurred.
-	// At this time, stops process and returns success.
-	var children []proto.Dentry
-	children, err = v.mw.ReadDir_ll(parentId)
-	if err != nil && err != syscall.ENOENT {
-		return fileInfos, prefixMap, "", 0, err
+	// One more match than maxKeys is listed to find the next marker.
+	var limit = maxKeys - rc
+	if limit < math.MaxUint64 {
+		limit++
 	}
+
+	// The directory which keeps non-current versions and the trash are not a part of the object namespace.
+	// During the scan, there may be other parallel operations that may delete the directory.
+	// If got the syscall.ENOENT error, stops process and returns success.
+	var items []*proto.ListPrefixItem
+	items, err = v.mw.ListPrefix_ll(parentId, parentPath, prefix, marker, delimiter, []string{VersionsDirName, proto.TrashDirName}, limit)
 	if err == syscall.ENOENT {
-		return fileInfos, prefixMap, "", 0, nil
+		return fileInfos, prefixMap, "", nil
 	}
-
-	for _, child := range children {
-		var path = strings.Join(append(dirs, child.Name), pathSep)
-		if os.FileMode(child.Type).IsDir() {
-			path += pathSep
-		}
-		if prefix != "" && !strings.HasPrefix(path, prefix) {
-			continue
-		}
-
-		if marker != "" {
-			if !os.FileMode(child.Type).IsDir() && path < marker {
-				continue
-			}
-			if os.FileMode(child.Type).IsDir() && path < marker {
-				fileInfos, prefixMap, nextMarker, rc, err = v.recursiveScan(fileInfos, prefixMap, child.Inode, maxKeys, rc, append(dirs, child.Name), prefix, marker, delimiter)
-				if err != nil {
-					return fileInfos, prefixMap, nextMarker, rc, err
-				}
-				if rc >= maxKeys && nextMarker != "" {
-					return fileInfos, prefixMap, nextMarker, rc, err
-				}
-				continue
-			}
-		}
-
-		if delimiter != "" {
-			var nonPrefixPart = strings.Replace(path, prefix, "", 1)
-			if idx := strings.Index(nonPrefixPart, delimiter); idx >= 0 {
-				var commonPrefix = prefix + util.SubString(nonPrefixPart, 0, idx) + delimiter
-				if prefixMap.contain(commonPrefix) {
-					continue
-				}
-				if rc >= maxKeys {
-					return fileInfos, prefixMap, commonPrefix, rc, nil
-				}
-				prefixMap.AddPrefix(commonPrefix)
-				rc++
-				continue
-			}
-		}
-
-		fileInfo := &FSFileInfo{
-			Inode: child.Inode,
-			Path:  path,
-		}
+	if err != nil {
+		return fileInfos, prefixMap, "", err
+	}
+	for _, item := range items {
 		if rc >= maxKeys {
-			return fileInfos, prefixMap, path, rc, nil
+			return fileInfos, prefixMap, item.Path, nil
 		}
-		fileInfos = append(fileInfos, fileInfo)
-		rc++
-
-		if os.FileMode(child.Type).IsDir() {
-			fileInfos, prefixMap, nextMarker, rc, err = v.recursiveScan(fileInfos, prefixMap, child.Inode, maxKeys, rc, append(dirs, child.Name), prefix, marker, delimiter)
-			if err != nil {
-				return fileInfos, prefixMap, nextMarker, rc, err
-			}
-			if rc >= maxKeys && nextMarker != "" {
-				return fileInfos, prefixMap, nextMarker, rc, err
-			}
+		if item.IsPrefix {
+			prefixMap.AddPrefix(item.Path)
+		} else {
+			fileInfos = append(fileInfos, &FSFileInfo{
+				Inode: item.Inode,
+				Path:  item.Path,
+			})
 		}
+		rc++
 	}
-	return fileInfos, prefixMap, nextMarker, rc, nil
+	return fileInfos, prefixMap, "", nil
 }
 
 // This method is used to supplement file metadata. Supplement the specified file
@@ -1788,7 +2201,7 @@ func (v *Volume) supplyListFileInfo(fileInfos []*FSFileInfo) (err error) {
 	}
 
 	// Get MD5 information in batches, then update to fileInfos
-	keys := []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated}
+	keys := []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated, XAttrKeyOSSSSE, XAttrKeyOSSReplStatus}
 	xattrs, err := v.mw.BatchGetXAttr(inodes, keys)
 	if err != nil {
 		log.LogErrorf("supplyListFileInfo: batch get xattr fail, inodes(%v), err(%v)", inodes, err)
@@ -1815,6 +2228,12 @@ func (v *Volume) supplyListFileInfo(fileInfos []*FSFileInfo) (err error) {
 			if len(rawETag) > 0 {
 				etagValue = ParseETagValue(rawETag)
 			}
+			if raw := xattr.Get(XAttrKeyOSSSSE); len(raw) > 0 {
+				if encryption, parseErr := parseObjectEncryption(raw); parseErr == nil {
+					fileInfo.applyEncryption(encryption, uint64(fileInfo.Size))
+				}
+			}
+			fileInfo.ReplicationStatus = string(xattr.Get(XAttrKeyOSSReplStatus))
 		}
 		if !etagValue.Valid() || etagValue.TS.Before(fileInfo.ModifyTime) {
 			// The ETag is invalid or outdated then generate a new ETag and make update.
@@ -1944,7 +2363,9 @@ func (v *Volume) ListParts(path, uploadId string, maxParts, partNumberMarker uin
 	return parts, nextMarker, isTruncated, nil
 }
 
-func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective string, opt *PutFileOption) (info *FSFileInfo, err error) {
+// CopyFile copies the source object to the target path. If the source object is encrypted, it is
+// decrypted with the SSE option of the source, and the copy is encrypted as the target option requests.
+func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective string, opt *PutFileOption, sourceSSE *SSEOption) (info *FSFileInfo, err error) {
 	defer func() {
 		log.LogInfof("Audit: copy file: source path(%v) target path(%v) err(%v)",
 			sourcePath, targetPath, err)
@@ -1968,6 +2389,16 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		log.LogErrorf("CopyFile: copy source path file size greater than 5GB, source path(%v), target path(%v)", sourcePath, targetPath)
 		return nil, syscall.EFBIG
 	}
+	var sourceEncryption *objectEncryption
+	if sourceEncryption, err = sv.loadObjectEncryption(sInode); err != nil {
+		log.LogErrorf("CopyFile: load source encryption fail, source path(%v) err(%v)", sourcePath, err)
+		return
+	}
+	if sourceEncryption != nil {
+		if _, err = sourceEncryption.dataKey(sourceSSE, sv.keyStore); err != nil {
+			return
+		}
+	}
 	if err = sv.ec.OpenStream(sInode); err != nil {
 		log.LogErrorf("CopyFile: open source path stream fail, source path(%v) source path inode(%v) err(%v)",
 			sourcePath, sInode, err)
@@ -2098,12 +2529,13 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	tLastName = pathItems[len(pathItems)-1].Name
 
 	// create target file inode and set target inode to be source file inode
-	if tInodeInfo, err = v.mw.InodeCreate_ll(uint32(sMode), 0, 0, nil); err != nil {
+	if tInodeInfo, err = v.mw.InodeCreate_ll(tParentId, uint32(sMode), 0, 0, nil); err != nil {
 		return
 	}
+	var bound bool
 	defer func() {
 		// An error has caused the entire process to fail. Delete the inode and release the written data.
-		if err != nil {
+		if err != nil && !bound {
 			log.LogWarnf("CopyFile: unlink target temp inode: volume(%v) path(%v) inode(%v) ",
 				v.name, targetPath, tInodeInfo.Inode)
 			_, _ = v.mw.InodeUnlink_ll(tInodeInfo.Inode)
@@ -2124,44 +2556,76 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 
 	// write data to invisibleTempDataInode from source object
 	var (
-		fileSize    = sInodeInfo.Size
-		md5Hash     = md5.New()
-		md5Value    string
-		readN       int
-		writeN      int
-		readOffset  int
-		writeOffset int
-		readSize    int
-		buf         = make([]byte, 2*util.BlockSize)
-		hashBuf     = make([]byte, 2*util.BlockSize)
+		fileSize         = sInodeInfo.Size
+		md5Hash          = md5.New()
+		md5Value         string
+		readN            int
+		writeN           int
+		readOffset       int
+		writeOffset      int
+		readSize         int
+		buf              = make([]byte, 2*util.BlockSize)
+		hashBuf          = make([]byte, 2*util.BlockSize)
+		targetEncryption *objectEncryption
 	)
-	for {
-		readSize = len(buf)
-		if (int(fileSize) - readOffset) <= 0 {
-			break
-		}
-		if (int(fileSize) - readOffset) < len(buf) {
-			readSize = int(fileSize) - readOffset
+	if sourceEncryption != nil || (opt != nil && opt.Encryption != nil) {
+		// The plaintext of the source is read through a pipe and re-encrypted for the target if requested.
+		if sourceEncryption != nil {
+			fileSize = sourceEncryption.plainSize(sInodeInfo.Size)
+		}
+		var pr, pw = io.Pipe()
+		defer func() {
+			_ = pr.Close()
+		}()
+		go func() {
+			_ = pw.CloseWithError(sv.readInode(sourcePath, sInode, pw, 0, fileSize, sourceSSE))
+		}()
+		var reader io.Reader = pr
+		var writeHash hash.Hash = md5Hash
+		if opt != nil && opt.Encryption != nil {
+			var dataKey []byte
+			if targetEncryption, dataKey, err = newObjectEncryption(opt.Encryption, v.keyStore); err != nil {
+				return
+			}
+			if reader, err = newSSEEncryptReader(io.TeeReader(reader, md5Hash), dataKey); err != nil {
+				return
+			}
+			writeHash = nil
 		}
-		readN, err = sv.ec.Read(sInode, buf, readOffset, readSize)
-		if err != nil && err != io.EOF {
+		if _, err = v.streamWrite(tInodeInfo.Inode, reader, writeHash); err != nil {
+			log.LogErrorf("CopyFile: write target path from source fail, volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, targetPath, tInodeInfo.Inode, err)
 			return
 		}
-		if readN > 0 {
-			if writeN, err = v.ec.Write(tInodeInfo.Inode, writeOffset, buf[:readN], 0); err != nil {
-				log.LogErrorf("CopyFile: write target path from source fail, volume(%v) path(%v) inode(%v) target offset(%v) err(%v)",
-					v.name, targetPath, tInodeInfo.Inode, writeOffset, err)
+	} else {
+		for {
+			readSize = len(buf)
+			if (int(fileSize) - readOffset) <= 0 {
+				break
+			}
+			if (int(fileSize) - readOffset) < len(buf) {
+				readSize = int(fileSize) - readOffset
+			}
+			readN, err = sv.ec.Read(sInode, buf, readOffset, readSize)
+			if err != nil && err != io.EOF {
 				return
 			}
-			readOffset += readN
-			writeOffset += writeN
-			// copy to md5 buffer, and then write to md5
-			copy(hashBuf, buf[:readN])
-			md5Hash.Write(hashBuf[:readN])
-		}
-		if err == io.EOF {
-			err = nil
-			break
+			if readN > 0 {
+				if writeN, err = v.ec.Write(tInodeInfo.Inode, writeOffset, buf[:readN], 0); err != nil {
+					log.LogErrorf("CopyFile: write target path from source fail, volume(%v) path(%v) inode(%v) target offset(%v) err(%v)",
+						v.name, targetPath, tInodeInfo.Inode, writeOffset, err)
+					return
+				}
+				readOffset += readN
+				writeOffset += writeN
+				// copy to md5 buffer, and then write to md5
+				copy(hashBuf, buf[:readN])
+				md5Hash.Write(hashBuf[:readN])
+			}
+			if err == io.EOF {
+				err = nil
+				break
+			}
 		}
 	}
 	// flush
@@ -2191,6 +2655,15 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		return
 	}
 
+	// Save target file encryption information
+	if targetEncryption != nil {
+		if err = v.mw.XAttrSet_ll(finalInode.Inode, []byte(XAttrKeyOSSSSE), targetEncryption.encode()); err != nil {
+			log.LogErrorf("CopyFile: store target file encryption fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, targetPath, tInodeInfo.Inode, err)
+			return
+		}
+	}
+
 	// copy source file metadata to write target file metadata
 	if metaDirective != MetadataDirectiveReplace {
 		// get source file xattr keys
@@ -2215,7 +2688,8 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		// set tar xattr
 		if len(xattrs) > 0 {
 			for xk, xv := range xattrs[0].XAttrs {
-				if xk == XAttrKeyOSSETag {
+				if xk == XAttrKeyOSSETag || xk == XAttrKeyOSSVersionID || xk == XAttrKeyOSSSSE ||
+					xk == XAttrKeyOSSRetention || xk == XAttrKeyOSSLegalHold || xk == XAttrKeyOSSReplStatus {
 					continue
 				}
 				if err = v.mw.XAttrSet_ll(tInodeInfo.Inode, []byte(xk), []byte(xv)); err != nil {
@@ -2266,6 +2740,13 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			}
 		}
 	}
+	if opt != nil && len(opt.ReplicationStatus) > 0 {
+		if err = v.mw.XAttrSet_ll(tInodeInfo.Inode, []byte(XAttrKeyOSSReplStatus), []byte(opt.ReplicationStatus)); err != nil {
+			log.LogErrorf("CopyFile: store replication status fail: volume(%v) target path(%v) inode(%v) status(%v) err(%v)",
+				v.name, targetPath, tInodeInfo.Inode, opt.ReplicationStatus, err)
+			return nil, err
+		}
+	}
 
 	// create file info
 	info = &FSFileInfo{
@@ -2277,12 +2758,22 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		ETag:       md5Value,
 		Inode:      tInodeInfo.Inode,
 	}
+	if targetEncryption != nil {
+		info.applyEncryption(targetEncryption, finalInode.Size)
+	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode)
+	info.VersionID, err = v.applyInodeToDEntry(targetPath, tParentId, tLastName, tInodeInfo.Inode)
 	if err != nil {
 		log.LogErrorf("CopyFile: apply inode to new dentry fail: path(%v) parentID(%v) name(%v) inode(%v) err(%v)",
 			targetPath, tParentId, tLastName, tInodeInfo.Inode, err)
+		return
+	}
+	bound = true
+	if opt != nil {
+		if err = v.lockObject(targetPath, tInodeInfo.Inode, opt.Retention, opt.LegalHold); err != nil {
+			return nil, err
+		}
 	}
 	return
 }
@@ -2305,6 +2796,7 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		Masters:       config.Masters,
 		Authenticate:  false,
 		ValidateOwner: false,
+		ObjectLockKey: config.ObjectLockKey,
 		OnAsyncTaskError: func(err error) {
 			config.OnAsyncTaskError.OnError(err)
 		},
@@ -2337,6 +2829,7 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		ec:         extentClient,
 		name:       config.Volume,
 		store:      config.Store,
+		keyStore:   config.KeyStore,
 		createTime: metaWrapper.VolCreateTime(),
 		closeCh:    make(chan struct{}),
 		onAsyncTaskError: func(err error) {
@@ -2349,6 +2842,9 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		v.metaLoader = &strictMetaLoader{v: v}
 	} else {
 		v.metaLoader = &cacheMetaLoader{om: new(OSSMeta)}
+		// Load OSS meta once before serving, otherwise bucket settings such as versioning
+		// are invisible until the first periodic update.
+		v.loadOSSMeta()
 		go v.syncOSSMeta()
 	}
 
@@ -0,0 +1,133 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// Number of objects or multipart uploads fetched from the meta partitions per batch while
+// the lifecycle rules are applied.
+const lifecycleBatchSize = 1000
+
+// ExpireObjects deletes the objects matched by the specified lifecycle rule whose expiration
+// time is earlier than now. On a bucket with versioning configured, the expired object is
+// replaced by a delete marker and the non-current versions are kept.
+// It returns the number of the expired objects.
+func (v *Volume) ExpireObjects(rule *LifecycleRule, now time.Time) (expired int, err error) {
+	if rule.Expiration == nil {
+		return
+	}
+	var tags = rule.tags()
+	var option = &ListFilesV2Option{
+		Prefix:  rule.prefix(),
+		MaxKeys: lifecycleBatchSize,
+	}
+	for {
+		var result *ListFilesV2Result
+		if result, err = v.ListFilesV2(option); err != nil {
+			return
+		}
+		for _, info := range result.Files {
+			if info.Mode.IsDir() || !rule.Expiration.expired(info.ModifyTime, now) {
+				continue
+			}
+			if len(tags) > 0 {
+				var tagging *Tagging
+				if tagging, err = v.loadObjectTagging(info.Inode); err != nil {
+					log.LogErrorf("ExpireObjects: load tagging fail: volume(%v) path(%v) inode(%v) err(%v)",
+						v.name, info.Path, info.Inode, err)
+					continue
+				}
+				if !rule.matchTags(tagging) {
+					continue
+				}
+			}
+			if _, _, err = v.DeleteObject(info.Path, ""); err != nil {
+				log.LogErrorf("ExpireObjects: delete object fail: volume(%v) path(%v) rule(%v) err(%v)",
+					v.name, info.Path, rule.ID, err)
+				continue
+			}
+			expired++
+		}
+		if !result.Truncated {
+			break
+		}
+		option.ContToken = result.NextToken
+	}
+	err = nil
+	return
+}
+
+// AbortExpiredMultiparts aborts the incomplete multipart uploads matched by the specified lifecycle
+// rule which were initiated more than the configured days before now.
+// It returns the number of the aborted multipart uploads.
+func (v *Volume) AbortExpiredMultiparts(rule *LifecycleRule, now time.Time) (aborted int, err error) {
+	if rule.AbortIncompleteMultipartUpload == nil {
+		return
+	}
+	var expiration = time.Duration(rule.AbortIncompleteMultipartUpload.DaysAfterInitiation) * 24 * time.Hour
+	var prefix = rule.prefix()
+	var keyMarker, multipartIDMarker string
+	for {
+		var sessions []*proto.MultipartInfo
+		if sessions, err = v.mw.ListMultipart_ll(prefix, "", keyMarker, multipartIDMarker, lifecycleBatchSize); err != nil {
+			return
+		}
+		// The markers are inclusive, so the first session of the next batch is used as the markers.
+		var next *proto.MultipartInfo
+		if len(sessions) > lifecycleBatchSize {
+			next = sessions[lifecycleBatchSize]
+			sessions = sessions[:lifecycleBatchSize]
+		}
+		for _, session := range sessions {
+			if now.Sub(session.InitTime) < expiration {
+				continue
+			}
+			if err = v.AbortMultipart(session.Path, session.ID); err != nil {
+				log.LogErrorf("AbortExpiredMultiparts: abort multipart fail: volume(%v) path(%v) multipartID(%v) rule(%v) err(%v)",
+					v.name, session.Path, session.ID, rule.ID, err)
+				continue
+			}
+			aborted++
+		}
+		if next == nil {
+			break
+		}
+		keyMarker = next.Path
+		multipartIDMarker = next.ID
+	}
+	err = nil
+	return
+}
+
+func (v *Volume) loadObjectTagging(inode uint64) (tagging *Tagging, err error) {
+	var xattrInfo *proto.XAttrInfo
+	if xattrInfo, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSTagging); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	var raw = xattrInfo.Get(XAttrKeyOSSTagging)
+	if len(raw) == 0 {
+		return
+	}
+	return ParseTagging(string(raw))
+}
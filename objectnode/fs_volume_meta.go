@@ -20,9 +20,27 @@ type ossMetaLoader interface {
 	loadPolicy() (p *Policy, err error)
 	loadACL() (p *AccessControlPolicy, err error)
 	loadCors() (cors *CORSConfiguration, err error)
+	loadVersioning() (versioning *VersioningConfiguration, err error)
+	loadLifecycle() (lifecycle *LifecycleConfiguration, err error)
+	loadEncryption() (encryption *ServerSideEncryptionConfiguration, err error)
+	loadObjectLock() (objectLock *ObjectLockConfiguration, err error)
+	loadWebsite() (website *WebsiteConfiguration, err error)
+	loadReplication() (replication *ReplicationConfiguration, err error)
+	loadNotification() (notification *NotificationConfiguration, err error)
+	loadLogging() (logging *BucketLoggingStatus, err error)
+	loadPublicAccessBlock() (block *PublicAccessBlockConfiguration, err error)
 	storePolicy(p *Policy)
 	storeACL(p *AccessControlPolicy)
 	storeCors(cors *CORSConfiguration)
+	storeVersioning(versioning *VersioningConfiguration)
+	storeLifecycle(lifecycle *LifecycleConfiguration)
+	storeEncryption(encryption *ServerSideEncryptionConfiguration)
+	storeObjectLock(objectLock *ObjectLockConfiguration)
+	storeWebsite(website *WebsiteConfiguration)
+	storeReplication(replication *ReplicationConfiguration)
+	storeNotification(notification *NotificationConfiguration)
+	storeLogging(logging *BucketLoggingStatus)
+	storePublicAccessBlock(block *PublicAccessBlockConfiguration)
 }
 
 type strictMetaLoader struct {
@@ -38,9 +56,27 @@ type OSSMeta struct {
 	policy     *Policy
 	acl        *AccessControlPolicy
 	corsConfig *CORSConfiguration
+	versioning *VersioningConfiguration
+	lifecycle  *LifecycleConfiguration
+	encryption *ServerSideEncryptionConfiguration
+	objectLock *ObjectLockConfiguration
+	website    *WebsiteConfiguration
+	repl       *ReplicationConfiguration
+	notify     *NotificationConfiguration
+	logging    *BucketLoggingStatus
+	pab        *PublicAccessBlockConfiguration
 	policyLock sync.RWMutex
 	aclLock    sync.RWMutex
 	corsLock   sync.RWMutex
+	verLock    sync.RWMutex
+	lcLock     sync.RWMutex
+	sseLock    sync.RWMutex
+	lockLock   sync.RWMutex
+	webLock    sync.RWMutex
+	replLock   sync.RWMutex
+	notifyLock sync.RWMutex
+	logLock    sync.RWMutex
+	pabLock    sync.RWMutex
 }
 
 func (c *cacheMetaLoader) loadPolicy() (p *Policy, err error) {
@@ -85,6 +121,132 @@ func (c *cacheMetaLoader) storeCors(cors *CORSConfiguration) {
 	return
 }
 
+func (c *cacheMetaLoader) loadVersioning() (versioning *VersioningConfiguration, err error) {
+	c.om.verLock.RLock()
+	versioning = c.om.versioning
+	c.om.verLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeVersioning(versioning *VersioningConfiguration) {
+	c.om.verLock.Lock()
+	c.om.versioning = versioning
+	c.om.verLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadLifecycle() (lifecycle *LifecycleConfiguration, err error) {
+	c.om.lcLock.RLock()
+	lifecycle = c.om.lifecycle
+	c.om.lcLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeLifecycle(lifecycle *LifecycleConfiguration) {
+	c.om.lcLock.Lock()
+	c.om.lifecycle = lifecycle
+	c.om.lcLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadEncryption() (encryption *ServerSideEncryptionConfiguration, err error) {
+	c.om.sseLock.RLock()
+	encryption = c.om.encryption
+	c.om.sseLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeEncryption(encryption *ServerSideEncryptionConfiguration) {
+	c.om.sseLock.Lock()
+	c.om.encryption = encryption
+	c.om.sseLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadObjectLock() (objectLock *ObjectLockConfiguration, err error) {
+	c.om.lockLock.RLock()
+	objectLock = c.om.objectLock
+	c.om.lockLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeObjectLock(objectLock *ObjectLockConfiguration) {
+	c.om.lockLock.Lock()
+	c.om.objectLock = objectLock
+	c.om.lockLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadWebsite() (website *WebsiteConfiguration, err error) {
+	c.om.webLock.RLock()
+	website = c.om.website
+	c.om.webLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeWebsite(website *WebsiteConfiguration) {
+	c.om.webLock.Lock()
+	c.om.website = website
+	c.om.webLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadReplication() (replication *ReplicationConfiguration, err error) {
+	c.om.replLock.RLock()
+	replication = c.om.repl
+	c.om.replLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeReplication(replication *ReplicationConfiguration) {
+	c.om.replLock.Lock()
+	c.om.repl = replication
+	c.om.replLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadNotification() (notification *NotificationConfiguration, err error) {
+	c.om.notifyLock.RLock()
+	notification = c.om.notify
+	c.om.notifyLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeNotification(notification *NotificationConfiguration) {
+	c.om.notifyLock.Lock()
+	c.om.notify = notification
+	c.om.notifyLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadLogging() (logging *BucketLoggingStatus, err error) {
+	c.om.logLock.RLock()
+	logging = c.om.logging
+	c.om.logLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storeLogging(logging *BucketLoggingStatus) {
+	c.om.logLock.Lock()
+	c.om.logging = logging
+	c.om.logLock.Unlock()
+	return
+}
+
+func (c *cacheMetaLoader) loadPublicAccessBlock() (block *PublicAccessBlockConfiguration, err error) {
+	c.om.pabLock.RLock()
+	block = c.om.pab
+	c.om.pabLock.RUnlock()
+	return
+}
+
+func (c *cacheMetaLoader) storePublicAccessBlock(block *PublicAccessBlockConfiguration) {
+	c.om.pabLock.Lock()
+	c.om.pab = block
+	c.om.pabLock.Unlock()
+	return
+}
+
 func (s *strictMetaLoader) loadPolicy() (p *Policy, err error) {
 	return s.v.loadBucketPolicy()
 }
@@ -102,3 +264,57 @@ func (s *strictMetaLoader) loadCors() (cors *CORSConfiguration, err error) {
 }
 
 func (s *strictMetaLoader) storeCors(cors *CORSConfiguration) {}
+
+func (s *strictMetaLoader) loadVersioning() (versioning *VersioningConfiguration, err error) {
+	return s.v.loadBucketVersioning()
+}
+
+func (s *strictMetaLoader) storeVersioning(versioning *VersioningConfiguration) {}
+
+func (s *strictMetaLoader) loadLifecycle() (lifecycle *LifecycleConfiguration, err error) {
+	return s.v.loadBucketLifecycle()
+}
+
+func (s *strictMetaLoader) storeLifecycle(lifecycle *LifecycleConfiguration) {}
+
+func (s *strictMetaLoader) loadEncryption() (encryption *ServerSideEncryptionConfiguration, err error) {
+	return s.v.loadBucketEncryption()
+}
+
+func (s *strictMetaLoader) storeEncryption(encryption *ServerSideEncryptionConfiguration) {}
+
+func (s *strictMetaLoader) loadObjectLock() (objectLock *ObjectLockConfiguration, err error) {
+	return s.v.loadBucketObjectLock()
+}
+
+func (s *strictMetaLoader) storeObjectLock(objectLock *ObjectLockConfiguration) {}
+
+func (s *strictMetaLoader) loadWebsite() (website *WebsiteConfiguration, err error) {
+	return s.v.loadBucketWebsite()
+}
+
+func (s *strictMetaLoader) storeWebsite(website *WebsiteConfiguration) {}
+
+func (s *strictMetaLoader) loadReplication() (replication *ReplicationConfiguration, err error) {
+	return s.v.loadBucketReplication()
+}
+
+func (s *strictMetaLoader) storeReplication(replication *ReplicationConfiguration) {}
+
+func (s *strictMetaLoader) loadNotification() (notification *NotificationConfiguration, err error) {
+	return s.v.loadBucketNotification()
+}
+
+func (s *strictMetaLoader) storeNotification(notification *NotificationConfiguration) {}
+
+func (s *strictMetaLoader) loadLogging() (logging *BucketLoggingStatus, err error) {
+	return s.v.loadBucketLogging()
+}
+
+func (s *strictMetaLoader) storeLogging(logging *BucketLoggingStatus) {}
+
+func (s *strictMetaLoader) loadPublicAccessBlock() (block *PublicAccessBlockConfiguration, err error) {
+	return s.v.loadBucketPublicAccessBlock()
+}
+
+func (s *strictMetaLoader) storePublicAccessBlock(block *PublicAccessBlockConfiguration) {}
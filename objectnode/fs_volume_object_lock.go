@@ -0,0 +1,156 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The object lock state is kept in the extended attributes of the inode, and the meta node
+// refuses to destroy, truncate or append the locked inodes, and to shorten the retention in
+// COMPLIANCE mode. The legal hold and the retention in GOVERNANCE mode can only be weakened by
+// the ObjectNode after checking the permission of the requester. Thus the lock can not be
+// bypassed by the clients mounting the volume.
+
+// lookupObjectLockTarget finds the inode of the specified version of the object.
+// If the version ID is empty, the current version is used.
+func (v *Volume) lookupObjectLockTarget(path, versionID string) (ino uint64, deleteMarker bool, err error) {
+	if versionID == "" {
+		_, ino, _, _, err = v.recursiveLookupTarget(path)
+		return
+	}
+	ino, _, deleteMarker, err = v.lookupObjectVersion(path, versionID)
+	return
+}
+
+func (v *Volume) getObjectLockXAttr(inode uint64, key string) (value []byte, err error) {
+	var xattrInfo *proto.XAttrInfo
+	if xattrInfo, err = v.mw.XAttrGet_ll(inode, key); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	return xattrInfo.Get(key), nil
+}
+
+// GetObjectRetention returns the retention of the object, or nil if the object has no retention.
+func (v *Volume) GetObjectRetention(path, versionID string) (retention *proto.ObjectRetention, deleteMarker bool, err error) {
+	var ino uint64
+	if ino, deleteMarker, err = v.lookupObjectLockTarget(path, versionID); err != nil || deleteMarker {
+		return
+	}
+	var raw []byte
+	if raw, err = v.getObjectLockXAttr(ino, XAttrKeyOSSRetention); err != nil || len(raw) == 0 {
+		return
+	}
+	retention, err = proto.ParseObjectRetention(raw)
+	return
+}
+
+// PutObjectRetention replaces the retention of the object, and a nil retention removes it.
+// The meta node returns EPERM if the active retention in COMPLIANCE mode would be weakened.
+// The caller checks the permission to weaken the retention in GOVERNANCE mode.
+func (v *Volume) PutObjectRetention(path, versionID string, retention *proto.ObjectRetention) (deleteMarker bool, err error) {
+	defer func() {
+		log.LogInfof("Audit: PutObjectRetention: volume(%v) path(%v) versionID(%v) retention(%v) err(%v)",
+			v.name, path, versionID, retention, err)
+	}()
+	var ino uint64
+	if ino, deleteMarker, err = v.lookupObjectLockTarget(path, versionID); err != nil || deleteMarker {
+		return
+	}
+	if retention == nil {
+		err = v.mw.ObjectLockXAttrDel_ll(ino, XAttrKeyOSSRetention)
+		return
+	}
+	err = v.mw.ObjectLockXAttrSet_ll(ino, []byte(XAttrKeyOSSRetention), retention.Encode())
+	return
+}
+
+// GetObjectLegalHold returns the legal hold status of the object.
+func (v *Volume) GetObjectLegalHold(path, versionID string) (status string, deleteMarker bool, err error) {
+	var ino uint64
+	if ino, deleteMarker, err = v.lookupObjectLockTarget(path, versionID); err != nil || deleteMarker {
+		return
+	}
+	var raw []byte
+	if raw, err = v.getObjectLockXAttr(ino, XAttrKeyOSSLegalHold); err != nil {
+		return
+	}
+	if string(raw) == proto.LegalHoldStatusOn {
+		return proto.LegalHoldStatusOn, false, nil
+	}
+	return proto.LegalHoldStatusOff, false, nil
+}
+
+// PutObjectLegalHold places or releases the legal hold of the object.
+func (v *Volume) PutObjectLegalHold(path, versionID, status string) (deleteMarker bool, err error) {
+	defer func() {
+		log.LogInfof("Audit: PutObjectLegalHold: volume(%v) path(%v) versionID(%v) status(%v) err(%v)",
+			v.name, path, versionID, status, err)
+	}()
+	var ino uint64
+	if ino, deleteMarker, err = v.lookupObjectLockTarget(path, versionID); err != nil || deleteMarker {
+		return
+	}
+	if status != proto.LegalHoldStatusOn {
+		err = v.mw.ObjectLockXAttrDel_ll(ino, XAttrKeyOSSLegalHold)
+		return
+	}
+	err = v.mw.XAttrSet_ll(ino, []byte(XAttrKeyOSSLegalHold), []byte(proto.LegalHoldStatusOn))
+	return
+}
+
+// lockObject applies the object lock requested on writing to the inode. It must be called after
+// the inode has been bound to the object path, since a locked inode can not be destroyed any more
+// if the writing fails.
+func (v *Volume) lockObject(path string, inode uint64, retention *proto.ObjectRetention, legalHold string) (err error) {
+	if retention != nil {
+		if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSRetention), retention.Encode()); err != nil {
+			log.LogErrorf("lockObject: store retention fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, err)
+			return
+		}
+	}
+	if legalHold == proto.LegalHoldStatusOn {
+		if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSLegalHold), []byte(legalHold)); err != nil {
+			log.LogErrorf("lockObject: store legal hold fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, err)
+			return
+		}
+	}
+	return
+}
+
+// DeleteObjectBypassGovernance permanently deletes the specified version of the object, whose
+// retention in GOVERNANCE mode is removed before the deletion. The version is still protected
+// if it is under legal hold or retained in COMPLIANCE mode.
+func (v *Volume) DeleteObjectBypassGovernance(path, versionID string) (deletedVersionID string, deleteMarker bool, err error) {
+	log.LogInfof("Audit: DeleteObjectBypassGovernance: volume(%v) path(%v) versionID(%v)", v.name, path, versionID)
+	var retention *proto.ObjectRetention
+	if retention, _, err = v.GetObjectRetention(path, versionID); err != nil {
+		return
+	}
+	if retention != nil && retention.Mode == proto.ObjectLockModeGovernance {
+		if _, err = v.PutObjectRetention(path, versionID, nil); err != nil {
+			return
+		}
+	}
+	return v.DeleteObject(path, versionID)
+}
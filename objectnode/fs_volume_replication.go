@@ -0,0 +1,78 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// Number of objects fetched from the meta partitions per batch while the pending objects are scanned.
+const replicationBatchSize = 1000
+
+// PendingReplications returns the paths of the objects under the prefix which are waiting for
+// replication, including the objects whose last replication failed.
+func (v *Volume) PendingReplications(prefix string) (paths []string, err error) {
+	var option = &ListFilesV2Option{
+		Prefix:  prefix,
+		MaxKeys: replicationBatchSize,
+	}
+	for {
+		var result *ListFilesV2Result
+		if result, err = v.ListFilesV2(option); err != nil {
+			return
+		}
+		for _, info := range result.Files {
+			// the objects encrypted with customer keys can not be replicated
+			if info.Mode.IsDir() || info.SSEKeyMD5 != "" {
+				continue
+			}
+			if info.ReplicationStatus == ReplicationStatusPending || info.ReplicationStatus == ReplicationStatusFailed {
+				paths = append(paths, info.Path)
+			}
+		}
+		if !result.Truncated {
+			break
+		}
+		option.ContToken = result.NextToken
+	}
+	return
+}
+
+// SetReplicationStatus updates the replication status of the object stored in the inode.
+// The status is removed if it is empty.
+func (v *Volume) SetReplicationStatus(inode uint64, status string) (err error) {
+	if status == "" {
+		if err = v.mw.XAttrDel_ll(inode, XAttrKeyOSSReplStatus); err == syscall.ENOENT {
+			err = nil
+		}
+	} else {
+		err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSReplStatus), []byte(status))
+	}
+	if err != nil {
+		log.LogErrorf("SetReplicationStatus: set replication status fail: volume(%v) inode(%v) status(%v) err(%v)",
+			v.name, inode, status, err)
+	}
+	return
+}
+
+// ReadInode writes the data of the object stored in the inode to the writer. Unlike ReadFile,
+// the object is not looked up again, so the data always matches the meta information of the inode
+// even if the object is overwritten meanwhile.
+func (v *Volume) ReadInode(path string, inode uint64, writer io.Writer, size uint64) error {
+	return v.readInode(path, inode, writer, 0, size, nil)
+}
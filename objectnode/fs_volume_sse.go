@@ -0,0 +1,83 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// applyEncryption replaces the size of the file info, which is the size of the encrypted data
+// stored in the inode, with the plaintext size, and fills the encryption attributes.
+func (info *FSFileInfo) applyEncryption(enc *objectEncryption, encryptedSize uint64) {
+	info.Size = int64(enc.plainSize(encryptedSize))
+	info.SSEAlgorithm = enc.Algorithm
+	info.SSEKeyMD5 = enc.CustomerKeyMD5
+}
+
+// loadObjectEncryption returns the encryption information of the inode, or nil if the data
+// of the inode is not encrypted.
+func (v *Volume) loadObjectEncryption(inode uint64) (enc *objectEncryption, err error) {
+	var xattrInfo *proto.XAttrInfo
+	if xattrInfo, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSSSE); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	var raw = xattrInfo.Get(XAttrKeyOSSSSE)
+	if len(raw) == 0 {
+		return
+	}
+	return parseObjectEncryption(raw)
+}
+
+// readEncryptedInode decrypts the plaintext range [offset, offset+size) of the encrypted inode and
+// writes it to writer. Only the chunks covering the range are read from the data nodes.
+// The stream of the inode must have been opened by the caller.
+func (v *Volume) readEncryptedInode(path string, inoInfo *proto.InodeInfo, enc *objectEncryption, dataKey []byte,
+	writer io.Writer, offset, size uint64) (err error) {
+	var ino = inoInfo.Inode
+	var plainSize = enc.plainSize(inoInfo.Size)
+	if offset >= plainSize {
+		return nil
+	}
+	if size > plainSize-offset {
+		size = plainSize - offset
+	}
+	var readAt = func(p []byte, off uint64) error {
+		for len(p) > 0 {
+			n, readErr := v.ec.Read(ino, p, int(off), len(p))
+			if readErr != nil && readErr != io.EOF {
+				return readErr
+			}
+			if n == 0 {
+				return io.ErrUnexpectedEOF
+			}
+			p = p[n:]
+			off += uint64(n)
+		}
+		return nil
+	}
+	if err = sseDecryptRange(dataKey, enc.streams(inoInfo.Size), readAt, writer, offset, size); err != nil {
+		log.LogErrorf("ReadFile: decrypt data fail: volume(%v) path(%v) inode(%v) offset(%v) size(%v) err(%v)",
+			v.name, path, ino, offset, size, err)
+		return
+	}
+	return
+}
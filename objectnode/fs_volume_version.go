@@ -0,0 +1,790 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The current version of an object is the file bound to the dentry of the object path, just like
+// an object of a bucket without versioning. The non-current versions and delete markers of an
+// object are kept as files under a hidden directory:
+//
+//	/.oss_versions/<hex encoded object key>/<version ID>
+//
+// Every version carries its version ID in the 'oss:version-id' extended attribute, and delete
+// markers are empty files tagged with the 'oss:delete-marker' extended attribute. Files written
+// before versioning was enabled have no version ID attribute and are treated as the null version.
+
+type ListFileVersionsOption struct {
+	Prefix          string
+	Delimiter       string
+	KeyMarker       string
+	VersionIDMarker string
+	MaxKeys         uint64
+}
+
+type ListFileVersionsResult struct {
+	Versions            []*FSVersionInfo
+	CommonPrefixes      []string
+	NextKeyMarker       string
+	NextVersionIDMarker string
+	Truncated           bool
+}
+
+// versioningStatus returns the versioning status of the bucket.
+// An empty status means that versioning has never been configured on the bucket.
+func (v *Volume) versioningStatus() (status string, err error) {
+	var versioning *VersioningConfiguration
+	if versioning, err = v.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("versioningStatus: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if versioning != nil {
+		status = versioning.Status
+	}
+	return
+}
+
+// versionsDirName encodes the object key in hex, which keeps the order of the keys, so that the
+// versions directories are scanned in the order of the keys.
+func versionsDirName(path string) string {
+	return hex.EncodeToString([]byte(path))
+}
+
+func (v *Volume) lookupOrCreateDirectory(parentID uint64, name string, autoCreate bool) (ino uint64, err error) {
+	var mode uint32
+	ino, mode, err = v.mw.Lookup_ll(parentID, name)
+	if err == syscall.ENOENT && autoCreate {
+		var info *proto.InodeInfo
+		info, err = v.mw.Create_ll(parentID, name, uint32(DefaultDirMode), 0, 0, nil)
+		if err == syscall.EEXIST {
+			ino, mode, err = v.mw.Lookup_ll(parentID, name)
+		} else if err == nil {
+			ino, mode = info.Inode, info.Mode
+		}
+	}
+	if err != nil {
+		return
+	}
+	if !os.FileMode(mode).IsDir() {
+		err = syscall.ENOTDIR
+	}
+	return
+}
+
+// versionsDir returns the inode of the directory which keeps the non-current versions of the object.
+func (v *Volume) versionsDir(path string, autoCreate bool) (ino uint64, err error) {
+	var rootDir uint64
+	if rootDir, err = v.lookupOrCreateDirectory(rootIno, VersionsDirName, autoCreate); err != nil {
+		return
+	}
+	return v.lookupOrCreateDirectory(rootDir, versionsDirName(path), autoCreate)
+}
+
+// getVersionInfo returns the version ID of the inode and whether it is a delete marker.
+func (v *Volume) getVersionInfo(inode uint64) (versionID string, deleteMarker bool, err error) {
+	var xattrs []*proto.XAttrInfo
+	var xattrKeys = []string{XAttrKeyOSSVersionID, XAttrKeyOSSDeleteMarker}
+	if xattrs, err = v.mw.BatchGetXAttr([]uint64{inode}, xattrKeys); err != nil {
+		log.LogErrorf("getVersionInfo: meta get xattr fail: volume(%v) inode(%v) err(%v)", v.name, inode, err)
+		return
+	}
+	if len(xattrs) > 0 && xattrs[0].Inode == inode {
+		versionID = string(xattrs[0].Get(XAttrKeyOSSVersionID))
+		deleteMarker = len(xattrs[0].Get(XAttrKeyOSSDeleteMarker)) > 0
+	}
+	if versionID == "" {
+		versionID = NullVersionID
+	}
+	return
+}
+
+// assignVersionID assigns a version ID to the new inode which is going to be the current version
+// of the object. It returns an empty version ID if versioning has never been configured.
+func (v *Volume) assignVersionID(path string, inode uint64) (versionID string, err error) {
+	var status string
+	if status, err = v.versioningStatus(); err != nil || status == "" {
+		return
+	}
+	if status == VersioningStatusEnabled {
+		versionID = newVersionID(inode)
+	} else {
+		// A null version written while versioning is suspended replaces the existing null version.
+		versionID = NullVersionID
+		if err = v.deleteArchivedVersion(path, NullVersionID); err != nil {
+			return
+		}
+	}
+	if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSVersionID), []byte(versionID)); err != nil {
+		log.LogErrorf("assignVersionID: store version ID fail: volume(%v) path(%v) inode(%v) versionID(%v) err(%v)",
+			v.name, path, inode, versionID, err)
+		return
+	}
+	return
+}
+
+// retainNoncurrentVersion links the inode, which is going to be unbound from the object path,
+// to the versions directory of the object. It returns false if the inode should be destroyed.
+func (v *Volume) retainNoncurrentVersion(path string, inode uint64) (retained bool, err error) {
+	var status string
+	if status, err = v.versioningStatus(); err != nil || status == "" {
+		return
+	}
+	var versionID string
+	if versionID, _, err = v.getVersionInfo(inode); err != nil {
+		return
+	}
+	if versionID == NullVersionID && status == VersioningStatusSuspended {
+		return
+	}
+	var dir uint64
+	if dir, err = v.versionsDir(path, true); err != nil {
+		return
+	}
+	// The extra link keeps the inode alive when it is unbound from the object path, which is
+	// required by the meta node if the inode is locked.
+	if _, err = v.mw.InodeLink_ll(inode); err != nil {
+		log.LogErrorf("retainNoncurrentVersion: meta link inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	err = v.mw.DentryCreate_ll(dir, versionID, inode, DefaultFileMode)
+	if err == syscall.EEXIST && versionID == NullVersionID {
+		if err = v.deleteArchivedVersion(path, NullVersionID); err != nil {
+			return
+		}
+		err = v.mw.DentryCreate_ll(dir, versionID, inode, DefaultFileMode)
+	}
+	if err != nil {
+		log.LogErrorf("retainNoncurrentVersion: meta dentry create fail: volume(%v) path(%v) inode(%v) versionID(%v) err(%v)",
+			v.name, path, inode, versionID, err)
+		_, _ = v.mw.InodeUnlink_ll(inode)
+		return
+	}
+	log.LogDebugf("retainNoncurrentVersion: retain version: volume(%v) path(%v) inode(%v) versionID(%v)",
+		v.name, path, inode, versionID)
+	retained = true
+	return
+}
+
+// discardNoncurrentVersion undoes retainNoncurrentVersion if the inode fails to be unbound from
+// the object path.
+func (v *Volume) discardNoncurrentVersion(path string, inode uint64) {
+	versionID, _, err := v.getVersionInfo(inode)
+	if err != nil {
+		log.LogErrorf("discardNoncurrentVersion: get version info fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	var dir uint64
+	if dir, err = v.versionsDir(path, false); err != nil {
+		log.LogErrorf("discardNoncurrentVersion: get versions dir fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+		return
+	}
+	if _, err = v.mw.DeletePermanently_ll(dir, versionID, false); err != nil {
+		log.LogErrorf("discardNoncurrentVersion: meta delete fail: volume(%v) path(%v) inode(%v) versionID(%v) err(%v)",
+			v.name, path, inode, versionID, err)
+	}
+}
+
+func (v *Volume) evictInode(path string, inode uint64) {
+	if err := v.ec.EvictStream(inode); err != nil {
+		log.LogWarnf("evictInode: evict stream fail: volume(%v) path(%v) inode(%v) err(%v)", v.name, path, inode, err)
+	}
+	if err := v.mw.Evict(inode); err != nil {
+		log.LogWarnf("evictInode: evict fail: volume(%v) path(%v) inode(%v) err(%v)", v.name, path, inode, err)
+	}
+}
+
+// deleteArchivedVersion permanently deletes the non-current version of the object.
+// It returns success if the version does not exist.
+func (v *Volume) deleteArchivedVersion(path, versionID string) (err error) {
+	var dir uint64
+	if dir, err = v.versionsDir(path, false); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.DeletePermanently_ll(dir, versionID, false); err != nil {
+		log.LogErrorf("deleteArchivedVersion: meta delete fail: volume(%v) path(%v) versionID(%v) err(%v)",
+			v.name, path, versionID, err)
+		return
+	}
+	if info != nil {
+		log.LogWarnf("deleteArchivedVersion: delete: volume(%v) path(%v) versionID(%v) inode(%v)",
+			v.name, path, versionID, info.Inode)
+		v.evictInode(path, info.Inode)
+	}
+	return
+}
+
+// listArchivedVersions returns the non-current versions and delete markers of the object.
+func (v *Volume) listArchivedVersions(path string) (versions []*FSVersionInfo, err error) {
+	var dir uint64
+	if dir, err = v.versionsDir(path, false); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	err = v.rangeDir(dir, "", func(child *proto.Dentry) bool {
+		versions = append(versions, &FSVersionInfo{Key: path, VersionID: child.Name, Inode: child.Inode})
+		return true
+	})
+	return
+}
+
+// restoreLatestVersion makes the latest non-current version the current version of the object
+// if the object has no current version and the latest version is not a delete marker.
+func (v *Volume) restoreLatestVersion(path string) (err error) {
+	if _, _, _, _, err = v.recursiveLookupTarget(path); err != syscall.ENOENT {
+		return
+	}
+	var versions []*FSVersionInfo
+	if versions, err = v.listArchivedVersions(path); err != nil || len(versions) == 0 {
+		return
+	}
+	if err = v.supplyVersionInfo(versions); err != nil {
+		return
+	}
+	var latest = versions[0]
+	for _, version := range versions[1:] {
+		if version.newerThan(latest) {
+			latest = version
+		}
+	}
+	if latest.DeleteMarker {
+		return
+	}
+	var pathItems = NewPathIterator(path).ToSlice()
+	if len(pathItems) == 0 {
+		return
+	}
+	var parentID uint64
+	if parentID, err = v.recursiveMakeDirectory(path); err != nil {
+		return
+	}
+	var dir uint64
+	if dir, err = v.versionsDir(path, false); err != nil {
+		return
+	}
+	if err = v.mw.Rename_ll(dir, latest.VersionID, parentID, pathItems[len(pathItems)-1].Name); err != nil {
+		log.LogErrorf("restoreLatestVersion: meta rename fail: volume(%v) path(%v) versionID(%v) err(%v)",
+			v.name, path, latest.VersionID, err)
+		return
+	}
+	log.LogDebugf("restoreLatestVersion: restore version: volume(%v) path(%v) versionID(%v) inode(%v)",
+		v.name, path, latest.VersionID, latest.Inode)
+	return
+}
+
+// lookupObjectVersion finds the inode of the specified version of the object.
+func (v *Volume) lookupObjectVersion(path, versionID string) (ino uint64, mode os.FileMode, deleteMarker bool, err error) {
+	if _, ino, _, mode, err = v.recursiveLookupTarget(path); err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil {
+		var currentVersionID string
+		if currentVersionID, _, err = v.getVersionInfo(ino); err != nil {
+			return
+		}
+		if currentVersionID == versionID {
+			return
+		}
+	}
+	var dir uint64
+	if dir, err = v.versionsDir(path, false); err != nil {
+		return
+	}
+	var lookupMode uint32
+	if ino, lookupMode, err = v.mw.Lookup_ll(dir, versionID); err != nil {
+		return
+	}
+	mode = os.FileMode(lookupMode)
+	_, deleteMarker, err = v.getVersionInfo(ino)
+	return
+}
+
+// ObjectVersionMeta returns the meta information of the specified version of the object.
+// If the version ID is empty, the current version is used.
+// If the version is a delete marker, only the path and version ID of the returned info are valid.
+func (v *Volume) ObjectVersionMeta(path, versionID string) (info *FSFileInfo, deleteMarker bool, err error) {
+	if versionID == "" {
+		info, err = v.ObjectMeta(path)
+		return
+	}
+	var ino uint64
+	var mode os.FileMode
+	if ino, mode, deleteMarker, err = v.lookupObjectVersion(path, versionID); err != nil {
+		return
+	}
+	if deleteMarker {
+		info = &FSFileInfo{Path: path, VersionID: versionID}
+		return
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(ino); err != nil {
+		log.LogErrorf("ObjectVersionMeta: get inode fail: volume(%v) path(%v) versionID(%v) inode(%v) err(%v)",
+			v.name, path, versionID, ino, err)
+		return
+	}
+	if info, err = v.inodeMeta(path, inoInfo, mode); err != nil {
+		return
+	}
+	info.VersionID = versionID
+	return
+}
+
+// ReadFileVersion reads data of the specified version of the object.
+// If the version ID is empty, the current version is read.
+// The SSE option carries the customer key if the object is encrypted with SSE-C.
+func (v *Volume) ReadFileVersion(path, versionID string, writer io.Writer, offset, size uint64, sse *SSEOption) error {
+	if versionID == "" {
+		return v.readFile(path, writer, offset, size, sse)
+	}
+	ino, mode, deleteMarker, err := v.lookupObjectVersion(path, versionID)
+	if err != nil {
+		return err
+	}
+	if deleteMarker {
+		return syscall.ENOENT
+	}
+	if mode.IsDir() {
+		return nil
+	}
+	return v.readInode(path, ino, writer, offset, size, sse)
+}
+
+// DeleteObject deletes the object in the semantic of versioning.
+//
+// Without version ID, the current version of the object is deleted if versioning has never been
+// configured on the bucket, otherwise a delete marker is placed as the current version.
+// With version ID, the specified version is permanently deleted.
+//
+// It returns the version ID of the deleted version or the placed delete marker, and whether
+// it is a delete marker.
+func (v *Volume) DeleteObject(path, versionID string) (deletedVersionID string, deleteMarker bool, err error) {
+	defer func() {
+		// Audit behavior
+		log.LogInfof("Audit: DeleteObject: volume(%v) path(%v) versionID(%v) deletedVersionID(%v) deleteMarker(%v) err(%v)",
+			v.name, path, versionID, deletedVersionID, deleteMarker, err)
+	}()
+	var status string
+	if status, err = v.versioningStatus(); err != nil {
+		return
+	}
+	// Directories are not versioned.
+	if strings.HasSuffix(path, pathSep) || (status == "" && versionID == "") {
+		err = v.DeletePath(path)
+		return
+	}
+	if versionID == "" {
+		return v.placeDeleteMarker(path, status)
+	}
+	return v.deleteObjectVersion(path, versionID)
+}
+
+func (v *Volume) placeDeleteMarker(path, status string) (markerVersionID string, deleteMarker bool, err error) {
+	var dir uint64
+	if dir, err = v.versionsDir(path, true); err != nil {
+		return
+	}
+
+	// move current version into the versions directory
+	var parent, ino uint64
+	var name string
+	if parent, ino, name, _, err = v.recursiveLookupTarget(path); err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil {
+		var currentVersionID string
+		if currentVersionID, _, err = v.getVersionInfo(ino); err != nil {
+			return
+		}
+		if currentVersionID == NullVersionID && status == VersioningStatusSuspended {
+			// The null version is replaced by the delete marker.
+			if _, err = v.mw.DeletePermanently_ll(parent, name, false); err != nil {
+				return
+			}
+			v.evictInode(path, ino)
+		} else if err = v.mw.Rename_ll(parent, name, dir, currentVersionID); err != nil {
+			log.LogErrorf("placeDeleteMarker: meta rename fail: volume(%v) path(%v) versionID(%v) err(%v)",
+				v.name, path, currentVersionID, err)
+			return
+		}
+	}
+
+	var markerInode *proto.InodeInfo
+	if markerInode, err = v.mw.InodeCreate_ll(dir, DefaultFileMode, 0, 0, nil); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_, _ = v.mw.InodeUnlink_ll(markerInode.Inode)
+			_ = v.mw.Evict(markerInode.Inode)
+		}
+	}()
+	if status == VersioningStatusEnabled {
+		markerVersionID = newVersionID(markerInode.Inode)
+	} else {
+		markerVersionID = NullVersionID
+		if err = v.deleteArchivedVersion(path, NullVersionID); err != nil {
+			return
+		}
+	}
+	if err = v.mw.XAttrSet_ll(markerInode.Inode, []byte(XAttrKeyOSSVersionID), []byte(markerVersionID)); err != nil {
+		return
+	}
+	if err = v.mw.XAttrSet_ll(markerInode.Inode, []byte(XAttrKeyOSSDeleteMarker), []byte("true")); err != nil {
+		return
+	}
+	if err = v.mw.DentryCreate_ll(dir, markerVersionID, markerInode.Inode, DefaultFileMode); err != nil {
+		log.LogErrorf("placeDeleteMarker: meta dentry create fail: volume(%v) path(%v) versionID(%v) err(%v)",
+			v.name, path, markerVersionID, err)
+		return
+	}
+	deleteMarker = true
+	return
+}
+
+func (v *Volume) deleteObjectVersion(path, versionID string) (deletedVersionID string, deleteMarker bool, err error) {
+	deletedVersionID = versionID
+
+	var parent, ino uint64
+	var name string
+	if parent, ino, name, _, err = v.recursiveLookupTarget(path); err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil {
+		var currentVersionID string
+		if currentVersionID, _, err = v.getVersionInfo(ino); err != nil {
+			return
+		}
+		if currentVersionID == versionID {
+			log.LogWarnf("deleteObjectVersion: delete current version: volume(%v) path(%v) versionID(%v) inode(%v)",
+				v.name, path, versionID, ino)
+			if _, err = v.mw.DeletePermanently_ll(parent, name, false); err != nil {
+				return
+			}
+			v.evictInode(path, ino)
+			err = v.restoreLatestVersion(path)
+			return
+		}
+	}
+
+	var dir uint64
+	if dir, err = v.versionsDir(path, false); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	if ino, _, err = v.mw.Lookup_ll(dir, versionID); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	if _, deleteMarker, err = v.getVersionInfo(ino); err != nil {
+		return
+	}
+	if err = v.deleteArchivedVersion(path, versionID); err != nil {
+		return
+	}
+	err = v.restoreLatestVersion(path)
+	return
+}
+
+// supplyVersionInfo supplements the version information with size, modification time, ETag
+// and delete marker flag.
+func (v *Volume) supplyVersionInfo(versions []*FSVersionInfo) (err error) {
+	var inodes = make([]uint64, 0, len(versions))
+	for _, version := range versions {
+		inodes = append(inodes, version.Inode)
+	}
+
+	var inodeInfos = make(map[uint64]*proto.InodeInfo)
+	for _, inodeInfo := range v.mw.BatchInodeGet(inodes) {
+		inodeInfos[inodeInfo.Inode] = inodeInfo
+	}
+
+	var xattrs []*proto.XAttrInfo
+	var xattrKeys = []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated, XAttrKeyOSSVersionID, XAttrKeyOSSDeleteMarker,
+		XAttrKeyOSSSSE}
+	if xattrs, err = v.mw.BatchGetXAttr(inodes, xattrKeys); err != nil {
+		log.LogErrorf("supplyVersionInfo: batch get xattr fail: volume(%v) inodes(%v) err(%v)", v.name, inodes, err)
+		return
+	}
+	var xattrInfos = make(map[uint64]*proto.XAttrInfo)
+	for _, xattr := range xattrs {
+		xattrInfos[xattr.Inode] = xattr
+	}
+
+	for _, version := range versions {
+		if inodeInfo, exist := inodeInfos[version.Inode]; exist {
+			version.Size = int64(inodeInfo.Size)
+			version.ModifyTime = inodeInfo.ModifyTime
+		}
+		var etagValue ETagValue
+		if xattr, exist := xattrInfos[version.Inode]; exist {
+			var rawETag = string(xattr.Get(XAttrKeyOSSETag))
+			if len(rawETag) == 0 {
+				rawETag = string(xattr.Get(XAttrKeyOSSETagDeprecated))
+			}
+			if len(rawETag) > 0 {
+				etagValue = ParseETagValue(rawETag)
+			}
+			if versionID := string(xattr.Get(XAttrKeyOSSVersionID)); len(versionID) > 0 {
+				version.VersionID = versionID
+			}
+			version.DeleteMarker = len(xattr.Get(XAttrKeyOSSDeleteMarker)) > 0
+			if raw := xattr.Get(XAttrKeyOSSSSE); len(raw) > 0 {
+				if encryption, parseErr := parseObjectEncryption(raw); parseErr == nil {
+					version.Size = int64(encryption.plainSize(uint64(version.Size)))
+				}
+			}
+		}
+		if version.VersionID == "" {
+			version.VersionID = NullVersionID
+		}
+		if version.DeleteMarker {
+			continue
+		}
+		if !etagValue.Valid() || etagValue.TS.Before(version.ModifyTime) {
+			if etagValue, err = v.updateETag(version.Inode, version.Size, version.ModifyTime); err != nil {
+				log.LogErrorf("supplyVersionInfo: update ETag fail: volume(%v) path(%v) inode(%v) err(%v)",
+					v.name, version.Key, version.Inode, err)
+			}
+		}
+		version.ETag = etagValue.ETag()
+	}
+	return nil
+}
+
+// ListFileVersions lists all versions of the objects which match the prefix, including
+// the current versions, non-current versions and delete markers.
+// Versions are ordered by key, and versions of the same key are ordered from the newest to the oldest.
+// The versions are collected batch by batch from the key marker, so that the cost of a request
+// depends on the number of the keys listed rather than the size of the bucket.
+func (v *Volume) ListFileVersions(opt *ListFileVersionsOption) (result *ListFileVersionsResult, err error) {
+	var scanner *versionScanner
+	if scanner, err = v.newVersionScanner(opt); err != nil {
+		return
+	}
+
+	result = &ListFileVersionsResult{}
+	var prefixMap = PrefixMap(make(map[string]struct{}))
+	var rc uint64
+	var versionMarkerPassed bool
+	for !result.Truncated && !scanner.done() {
+		var versions []*FSVersionInfo
+		if versions, err = scanner.next(); err != nil {
+			return
+		}
+		for _, version := range versions {
+			if version.Key < opt.KeyMarker {
+				continue
+			}
+			if version.Key == opt.KeyMarker {
+				// Without version ID marker, all versions of the key marker are skipped.
+				if opt.VersionIDMarker == "" || !versionMarkerPassed {
+					versionMarkerPassed = version.VersionID == opt.VersionIDMarker
+					continue
+				}
+			}
+			if opt.Delimiter != "" {
+				var nonPrefixPart = strings.TrimPrefix(version.Key, opt.Prefix)
+				if idx := strings.Index(nonPrefixPart, opt.Delimiter); idx >= 0 {
+					var commonPrefix = opt.Prefix + nonPrefixPart[:idx] + opt.Delimiter
+					if prefixMap.contain(commonPrefix) || commonPrefix <= opt.KeyMarker {
+						continue
+					}
+					if rc >= opt.MaxKeys {
+						result.Truncated = true
+						break
+					}
+					prefixMap.AddPrefix(commonPrefix)
+					result.NextKeyMarker, result.NextVersionIDMarker = commonPrefix, ""
+					rc++
+					continue
+				}
+			}
+			if rc >= opt.MaxKeys {
+				result.Truncated = true
+				break
+			}
+			result.Versions = append(result.Versions, version)
+			result.NextKeyMarker, result.NextVersionIDMarker = version.Key, version.VersionID
+			rc++
+		}
+	}
+	if !result.Truncated {
+		result.NextKeyMarker, result.NextVersionIDMarker = "", ""
+	}
+	result.CommonPrefixes = prefixMap.Prefixes()
+
+	log.LogDebugf("ListFileVersions: volume(%v) prefix(%v) keyMarker(%v) versionIDMarker(%v) delimiter(%v) maxKeys(%v) versions(%v) prefixes(%v) truncated(%v)",
+		v.name, opt.Prefix, opt.KeyMarker, opt.VersionIDMarker, opt.Delimiter, opt.MaxKeys, len(result.Versions), len(result.CommonPrefixes), result.Truncated)
+	return
+}
+
+// versionScanBatch is the maximum number of keys whose versions are collected at a time.
+const versionScanBatch = 1000
+
+// versionScanner collects the versions of the keys which match the prefix batch by batch in the
+// order of the keys, merging the current versions and the versions directories.
+type versionScanner struct {
+	v            *Volume
+	prefix       string
+	batch        uint64
+	parentID     uint64
+	dirs         []string
+	versionsRoot uint64
+	from         string // the least key which has not been collected
+	curDone      bool
+	archivedDone bool
+}
+
+func (v *Volume) newVersionScanner(opt *ListFileVersionsOption) (s *versionScanner, err error) {
+	s = &versionScanner{v: v, prefix: opt.Prefix, from: opt.KeyMarker}
+	if s.from < opt.Prefix {
+		s.from = opt.Prefix
+	}
+	s.batch = opt.MaxKeys
+	if s.batch == 0 || s.batch > versionScanBatch {
+		s.batch = versionScanBatch
+	}
+	s.parentID, s.dirs, err = v.findParentId(opt.Prefix)
+	if err == syscall.ENOENT {
+		s.curDone = true
+	} else if err != nil {
+		log.LogErrorf("ListFileVersions: find parent ID fail: volume(%v) prefix(%v) err(%v)", v.name, opt.Prefix, err)
+		return
+	}
+	s.versionsRoot, err = v.lookupOrCreateDirectory(rootIno, VersionsDirName, false)
+	if err == syscall.ENOENT {
+		s.archivedDone = true
+	} else if err != nil {
+		return
+	}
+	err = nil
+	return
+}
+
+func (s *versionScanner) done() bool {
+	return s.curDone && s.archivedDone
+}
+
+// next returns the versions of the next batch of keys, ordered by key, and the versions of the
+// same key are ordered from the newest to the oldest.
+func (s *versionScanner) next() (versions []*FSVersionInfo, err error) {
+	v := s.v
+	// The keys of both sources are collected up to the least key which is not collected by
+	// either of them, so that the versions of a key are never split into two batches.
+	var bound string
+	var infos []*FSFileInfo
+	var curTruncated, archivedTruncated bool
+	if !s.curDone {
+		if infos, _, bound, err = v.listPrefix(s.parentID, s.dirs, s.prefix, s.from, "", s.batch); err != nil {
+			log.LogErrorf("ListFileVersions: scan current versions fail: volume(%v) prefix(%v) err(%v)", v.name, s.prefix, err)
+			return
+		}
+		curTruncated = bound != ""
+	}
+	var archived = make([]*proto.Dentry, 0)
+	var archivedKeys = make([]string, 0)
+	if !s.archivedDone {
+		err = v.rangeDir(s.versionsRoot, versionsDirName(s.from), func(child *proto.Dentry) bool {
+			raw, decodeErr := hex.DecodeString(child.Name)
+			if decodeErr != nil {
+				log.LogWarnf("ListFileVersions: invalid versions directory: volume(%v) name(%v)", v.name, child.Name)
+				return true
+			}
+			key := string(raw)
+			if !strings.HasPrefix(key, s.prefix) {
+				// the keys which match the prefix are contiguous
+				return key < s.prefix
+			}
+			if uint64(len(archivedKeys)) >= s.batch {
+				archivedTruncated = true
+				if bound == "" || key < bound {
+					bound = key
+				}
+				return false
+			}
+			dentry := *child
+			archived = append(archived, &dentry)
+			archivedKeys = append(archivedKeys, key)
+			return true
+		})
+		if err != nil && err != syscall.ENOENT {
+			return
+		}
+		err = nil
+	}
+	// A source is done if it is not truncated and all its keys are less than the bound.
+	s.curDone = s.curDone || !curTruncated && (bound == "" || len(infos) == 0 || infos[len(infos)-1].Path < bound)
+	s.archivedDone = s.archivedDone || !archivedTruncated &&
+		(bound == "" || len(archivedKeys) == 0 || archivedKeys[len(archivedKeys)-1] < bound)
+	s.from = bound
+
+	for _, info := range infos {
+		if bound == "" || info.Path < bound {
+			versions = append(versions, &FSVersionInfo{Key: info.Path, Inode: info.Inode, IsLatest: true})
+		}
+	}
+	for i, dentry := range archived {
+		var key = archivedKeys[i]
+		if bound != "" && key >= bound {
+			break
+		}
+		if err = v.rangeDir(dentry.Inode, "", func(child *proto.Dentry) bool {
+			versions = append(versions, &FSVersionInfo{Key: key, VersionID: child.Name, Inode: child.Inode})
+			return true
+		}); err != nil && err != syscall.ENOENT {
+			return
+		}
+		err = nil
+	}
+
+	if err = v.supplyVersionInfo(versions); err != nil {
+		return
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Key != versions[j].Key {
+			return versions[i].Key < versions[j].Key
+		}
+		if versions[i].IsLatest != versions[j].IsLatest {
+			return versions[i].IsLatest
+		}
+		return versions[i].newerThan(versions[j])
+	})
+	for i, version := range versions {
+		version.IsLatest = i == 0 || versions[i-1].Key != version.Key
+	}
+	return
+}
@@ -0,0 +1,269 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/object-lifecycle-mgmt.html
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	LifecycleStatusEnabled  = "Enabled"
+	LifecycleStatusDisabled = "Disabled"
+
+	maxLifecycleRules     = 1000
+	maxLifecycleRuleIDLen = 255
+)
+
+type LifecycleConfiguration struct {
+	XMLName xml.Name         `xml:"LifecycleConfiguration" json:"-"`
+	Xmlns   string           `xml:"xmlns,attr,omitempty" json:"-"`
+	Rules   []*LifecycleRule `xml:"Rule" json:"rules"`
+}
+
+type LifecycleRule struct {
+	ID     string           `xml:"ID,omitempty" json:"id,omitempty"`
+	Status string           `xml:"Status" json:"status"`
+	Prefix string           `xml:"Prefix,omitempty" json:"prefix,omitempty"` // deprecated, replaced by Filter
+	Filter *LifecycleFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+
+	Expiration                     *LifecycleExpiration            `xml:"Expiration,omitempty" json:"expiration,omitempty"`
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty" json:"abort_mpu,omitempty"`
+}
+
+type LifecycleFilter struct {
+	Prefix string                `xml:"Prefix,omitempty" json:"prefix,omitempty"`
+	Tag    *Tag                  `xml:"Tag,omitempty" json:"tag,omitempty"`
+	And    *LifecycleAndOperator `xml:"And,omitempty" json:"and,omitempty"`
+}
+
+type LifecycleAndOperator struct {
+	Prefix string `xml:"Prefix,omitempty" json:"prefix,omitempty"`
+	Tags   []Tag  `xml:"Tag" json:"tags,omitempty"`
+}
+
+type LifecycleExpiration struct {
+	Days int    `xml:"Days,omitempty" json:"days,omitempty"`
+	Date string `xml:"Date,omitempty" json:"date,omitempty"`
+}
+
+type AbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `xml:"DaysAfterInitiation" json:"days_after_initiation"`
+}
+
+func (filter *LifecycleFilter) validate() bool {
+	var conditions int
+	if filter.Prefix != "" {
+		conditions++
+	}
+	if filter.Tag != nil {
+		conditions++
+	}
+	if filter.And != nil {
+		conditions++
+	}
+	if conditions > 1 {
+		return false
+	}
+	if filter.Tag != nil && filter.Tag.Key == "" {
+		return false
+	}
+	if filter.And != nil {
+		for _, tag := range filter.And.Tags {
+			if tag.Key == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (expiration *LifecycleExpiration) validate() bool {
+	if (expiration.Days == 0) == (expiration.Date == "") {
+		return false
+	}
+	if expiration.Days < 0 {
+		return false
+	}
+	if expiration.Date != "" {
+		date, err := parseLifecycleDate(expiration.Date)
+		if err != nil {
+			return false
+		}
+		// The date value must conform to the ISO 8601 format and the time is always midnight UTC.
+		if !date.Equal(date.Truncate(24 * time.Hour)) {
+			return false
+		}
+	}
+	return true
+}
+
+// expired reports whether an object last modified at the specified time has expired at now.
+// Like S3, the expiration time of a days based rule is rounded up to the next midnight UTC.
+func (expiration *LifecycleExpiration) expired(modifyTime, now time.Time) bool {
+	if expiration.Date != "" {
+		date, err := parseLifecycleDate(expiration.Date)
+		if err != nil {
+			return false
+		}
+		return !now.Before(date)
+	}
+	var expireTime = modifyTime.UTC().Add(time.Duration(expiration.Days) * 24 * time.Hour)
+	if midnight := expireTime.Truncate(24 * time.Hour); midnight.Before(expireTime) {
+		expireTime = midnight.Add(24 * time.Hour)
+	}
+	return !now.Before(expireTime)
+}
+
+func (rule *LifecycleRule) validate() bool {
+	if len(rule.ID) > maxLifecycleRuleIDLen {
+		return false
+	}
+	if rule.Status != LifecycleStatusEnabled && rule.Status != LifecycleStatusDisabled {
+		return false
+	}
+	if rule.Prefix != "" && rule.Filter != nil {
+		return false
+	}
+	if rule.Filter != nil && !rule.Filter.validate() {
+		return false
+	}
+	if rule.Expiration == nil && rule.AbortIncompleteMultipartUpload == nil {
+		return false
+	}
+	if rule.Expiration != nil && !rule.Expiration.validate() {
+		return false
+	}
+	if rule.AbortIncompleteMultipartUpload != nil {
+		if rule.AbortIncompleteMultipartUpload.DaysAfterInitiation <= 0 {
+			return false
+		}
+		// Multipart uploads have no tags, so the action can not be combined with a tag based filter.
+		if len(rule.tags()) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule *LifecycleRule) enabled() bool {
+	return rule.Status == LifecycleStatusEnabled
+}
+
+// prefix returns the key prefix which the rule applies to.
+func (rule *LifecycleRule) prefix() string {
+	if rule.Filter == nil {
+		return rule.Prefix
+	}
+	if rule.Filter.And != nil {
+		return rule.Filter.And.Prefix
+	}
+	return rule.Filter.Prefix
+}
+
+// tags returns the object tags which the rule applies to.
+func (rule *LifecycleRule) tags() []Tag {
+	if rule.Filter == nil {
+		return nil
+	}
+	if rule.Filter.And != nil {
+		return rule.Filter.And.Tags
+	}
+	if rule.Filter.Tag != nil {
+		return []Tag{*rule.Filter.Tag}
+	}
+	return nil
+}
+
+// matchTags reports whether the tagging of an object contains all tags of the rule filter.
+func (rule *LifecycleRule) matchTags(tagging *Tagging) bool {
+	return matchTagSet(rule.tags(), tagging)
+}
+
+// matchTagSet reports whether the tagging of an object contains all the specified tags.
+func matchTagSet(tags []Tag, tagging *Tagging) bool {
+	for _, tag := range tags {
+		if tagging == nil {
+			return false
+		}
+		var matched bool
+		for _, objectTag := range tagging.TagSet {
+			if objectTag.Key == tag.Key && objectTag.Value == tag.Value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (config *LifecycleConfiguration) validate() bool {
+	if len(config.Rules) == 0 || len(config.Rules) > maxLifecycleRules {
+		return false
+	}
+	var ids = make(map[string]struct{})
+	for _, rule := range config.Rules {
+		if rule == nil || !rule.validate() {
+			return false
+		}
+		if rule.ID == "" {
+			continue
+		}
+		if _, exist := ids[rule.ID]; exist {
+			return false
+		}
+		ids[rule.ID] = struct{}{}
+	}
+	return true
+}
+
+func parseLifecycleDate(value string) (date time.Time, err error) {
+	if date, err = time.Parse(time.RFC3339, value); err != nil {
+		return
+	}
+	return date.UTC(), nil
+}
+
+func parseLifecycleConfig(bytes []byte) (config *LifecycleConfiguration, err error) {
+	config = &LifecycleConfiguration{}
+	if err = xml.Unmarshal(bytes, config); err != nil {
+		return
+	}
+	if ok := config.validate(); !ok {
+		return nil, errors.New("invalid lifecycle configuration")
+	}
+	return
+}
+
+func storeBucketLifecycle(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLifecycle, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketLifecycle(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSLifecycle); err != nil {
+		return err
+	}
+	return nil
+}
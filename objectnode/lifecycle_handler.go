@@ -0,0 +1,142 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLifecycleConfiguration.html
+func (o *ObjectNode) getBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var lifecycle *LifecycleConfiguration
+	if lifecycle, err = vol.metaLoader.loadLifecycle(); err != nil {
+		log.LogErrorf("getBucketLifecycleHandler: load lifecycle fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if lifecycle == nil {
+		_ = NoSuchLifecycleConfiguration.ServeResponse(w, r)
+		return
+	}
+
+	var output = LifecycleConfiguration{
+		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
+		Rules: lifecycle.Rules,
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycleConfiguration.html
+func (o *ObjectNode) putBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var lifecycle *LifecycleConfiguration
+	if lifecycle, err = parseLifecycleConfig(bytes); err != nil {
+		log.LogErrorf("putBucketLifecycleHandler: parse lifecycle fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(lifecycle); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketLifecycle(newBytes, vol); err != nil {
+		log.LogErrorf("putBucketLifecycleHandler: store lifecycle fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeLifecycle(lifecycle)
+
+	log.LogInfof("Audit: put bucket lifecycle: requestID(%v) remote(%v) volume(%v) rules(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), len(lifecycle.Rules))
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketLifecycle.html
+func (o *ObjectNode) deleteBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	if err = deleteBucketLifecycle(vol); err != nil {
+		log.LogErrorf("deleteBucketLifecycleHandler: delete lifecycle fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeLifecycle(nil)
+
+	log.LogInfof("Audit: delete bucket lifecycle: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLifecycleConfig(t *testing.T) {
+	var valid = `<LifecycleConfiguration>
+	<Rule>
+		<ID>expire-logs</ID>
+		<Status>Enabled</Status>
+		<Filter><And><Prefix>logs/</Prefix><Tag><Key>type</Key><Value>log</Value></Tag></And></Filter>
+		<Expiration><Days>7</Days></Expiration>
+	</Rule>
+	<Rule>
+		<ID>abort-uploads</ID>
+		<Status>Enabled</Status>
+		<Filter><Prefix></Prefix></Filter>
+		<AbortIncompleteMultipartUpload><DaysAfterInitiation>3</DaysAfterInitiation></AbortIncompleteMultipartUpload>
+	</Rule>
+</LifecycleConfiguration>`
+	config, err := parseLifecycleConfig([]byte(valid))
+	if err != nil {
+		t.Fatalf("parse lifecycle config fail: err(%v)", err)
+	}
+	if len(config.Rules) != 2 {
+		t.Fatalf("rule count mismatch: expect(2) actual(%v)", len(config.Rules))
+	}
+	if prefix := config.Rules[0].prefix(); prefix != "logs/" {
+		t.Fatalf("rule prefix mismatch: expect(logs/) actual(%v)", prefix)
+	}
+	if !config.Rules[0].matchTags(&Tagging{TagSet: []Tag{{Key: "type", Value: "log"}, {Key: "k", Value: "v"}}}) {
+		t.Fatalf("rule should match object tagging")
+	}
+	if config.Rules[0].matchTags(nil) {
+		t.Fatalf("rule should not match object without tagging")
+	}
+
+	var invalids = []string{
+		// no action
+		`<LifecycleConfiguration><Rule><Status>Enabled</Status></Rule></LifecycleConfiguration>`,
+		// both days and date
+		`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Days>1</Days><Date>2020-01-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`,
+		// date is not midnight
+		`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Date>2020-01-01T08:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`,
+		// abort multipart upload with tag filter
+		`<LifecycleConfiguration><Rule><Status>Enabled</Status><Filter><Tag><Key>k</Key><Value>v</Value></Tag></Filter><AbortIncompleteMultipartUpload><DaysAfterInitiation>1</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule></LifecycleConfiguration>`,
+		// duplicated rule ID
+		`<LifecycleConfiguration><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>2</Days></Expiration></Rule></LifecycleConfiguration>`,
+	}
+	for _, invalid := range invalids {
+		if _, err = parseLifecycleConfig([]byte(invalid)); err == nil {
+			t.Fatalf("parse invalid lifecycle config should fail: %v", invalid)
+		}
+	}
+}
+
+func TestLifecycleExpiration_Expired(t *testing.T) {
+	var modifyTime = time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	var days = &LifecycleExpiration{Days: 1}
+	// The expiration time is rounded up to the next midnight UTC.
+	if days.expired(modifyTime, time.Date(2020, 1, 2, 23, 59, 59, 0, time.UTC)) {
+		t.Fatalf("object should not expire before midnight")
+	}
+	if !days.expired(modifyTime, time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("object should expire at midnight")
+	}
+
+	var date = &LifecycleExpiration{Date: "2020-02-01T00:00:00Z"}
+	if date.expired(modifyTime, time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("object should not expire before date")
+	}
+	if !date.expired(modifyTime, time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("object should expire at date")
+	}
+}
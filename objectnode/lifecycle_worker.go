@@ -0,0 +1,124 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	defaultLifecycleInterval = time.Hour
+)
+
+// LifecycleWorker periodically applies the lifecycle configuration of every bucket in the cluster.
+// Each round it lists all volumes from the master, and for each volume with a lifecycle configuration
+// it expires the matched objects and aborts the matched incomplete multipart uploads.
+type LifecycleWorker struct {
+	mc        *master.MasterClient
+	vm        *VolumeManager
+	interval  time.Duration
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func (w *LifecycleWorker) Start() {
+	go w.run()
+}
+
+func (w *LifecycleWorker) Stop() {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+	})
+}
+
+func (w *LifecycleWorker) run() {
+	t := time.NewTimer(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-w.closeCh:
+			return
+		}
+		w.scan()
+		t.Reset(w.interval)
+	}
+}
+
+func (w *LifecycleWorker) scan() {
+	var err error
+	var volInfos []*proto.VolInfo
+	if volInfos, err = w.mc.AdminAPI().ListVols(""); err != nil {
+		log.LogErrorf("LifecycleWorker: list volumes fail: err(%v)", err)
+		return
+	}
+	var start = time.Now()
+	for _, volInfo := range volInfos {
+		select {
+		case <-w.closeCh:
+			return
+		default:
+		}
+		w.scanVolume(volInfo.Name, time.Now())
+	}
+	log.LogInfof("LifecycleWorker: scan finish: volumes(%v) elapsed(%v)", len(volInfos), time.Since(start))
+}
+
+func (w *LifecycleWorker) scanVolume(volName string, now time.Time) {
+	var err error
+	var vol *Volume
+	if vol, err = w.vm.Volume(volName); err != nil {
+		log.LogWarnf("LifecycleWorker: load volume fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	var lifecycle *LifecycleConfiguration
+	if lifecycle, err = vol.metaLoader.loadLifecycle(); err != nil {
+		log.LogErrorf("LifecycleWorker: load lifecycle fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	if lifecycle == nil {
+		return
+	}
+	for _, rule := range lifecycle.Rules {
+		if !rule.enabled() {
+			continue
+		}
+		var expired, aborted int
+		if expired, err = vol.ExpireObjects(rule, now); err != nil {
+			log.LogErrorf("LifecycleWorker: expire objects fail: volume(%v) rule(%v) err(%v)",
+				volName, rule.ID, err)
+		}
+		if aborted, err = vol.AbortExpiredMultiparts(rule, now); err != nil {
+			log.LogErrorf("LifecycleWorker: abort multipart uploads fail: volume(%v) rule(%v) err(%v)",
+				volName, rule.ID, err)
+		}
+		log.LogInfof("LifecycleWorker: apply rule: volume(%v) rule(%v) expiredObjects(%v) abortedUploads(%v)",
+			volName, rule.ID, expired, aborted)
+	}
+}
+
+func NewLifecycleWorker(mc *master.MasterClient, vm *VolumeManager, interval time.Duration) *LifecycleWorker {
+	return &LifecycleWorker{
+		mc:       mc,
+		vm:       vm,
+		interval: interval,
+		closeCh:  make(chan struct{}),
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/ServerLogs.html
+
+import (
+	"encoding/xml"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	maxLoggingTargetPrefixLength = 512
+)
+
+// BucketLoggingStatus is the server access logging configuration of the bucket. The access logs
+// of the bucket are written as objects into the target bucket under the target prefix.
+// Target grants are not supported, and the log objects are owned by the owner of the target bucket.
+type BucketLoggingStatus struct {
+	XMLName        xml.Name        `xml:"BucketLoggingStatus" json:"-"`
+	Xmlns          string          `xml:"xmlns,attr,omitempty" json:"-"`
+	LoggingEnabled *LoggingEnabled `xml:"LoggingEnabled,omitempty" json:"enabled,omitempty"`
+}
+
+type LoggingEnabled struct {
+	TargetBucket string    `xml:"TargetBucket" json:"bucket"`
+	TargetPrefix string    `xml:"TargetPrefix" json:"prefix"`
+	TargetGrants *struct{} `xml:"TargetGrants" json:"-"`
+}
+
+func (status *BucketLoggingStatus) isEmpty() bool {
+	return status.LoggingEnabled == nil
+}
+
+func (status *BucketLoggingStatus) validate() bool {
+	if status.isEmpty() {
+		return true
+	}
+	var enabled = status.LoggingEnabled
+	if enabled.TargetBucket == "" || len(enabled.TargetPrefix) > maxLoggingTargetPrefixLength {
+		return false
+	}
+	return true
+}
+
+func parseLoggingConfig(bytes []byte) (status *BucketLoggingStatus, err error) {
+	status = &BucketLoggingStatus{}
+	if err = xml.Unmarshal(bytes, status); err != nil {
+		return
+	}
+	if ok := status.validate(); !ok {
+		return nil, errors.New("invalid logging configuration")
+	}
+	return
+}
+
+func storeBucketLogging(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLogging, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketLogging(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSLogging); err != nil {
+		return
+	}
+	return nil
+}
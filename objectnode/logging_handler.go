@@ -0,0 +1,160 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/gorilla/mux"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLogging.html
+func (o *ObjectNode) getBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var logging *BucketLoggingStatus
+	if logging, err = vol.metaLoader.loadLogging(); err != nil {
+		log.LogErrorf("getBucketLoggingHandler: load logging fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	// An empty status is returned if the logging is not enabled.
+	var output = BucketLoggingStatus{}
+	if logging != nil {
+		output = *logging
+	}
+	output.Xmlns = "http://doc.s3.amazonaws.com/2006-03-01"
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLogging.html
+func (o *ObjectNode) putBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var logging *BucketLoggingStatus
+	if logging, err = parseLoggingConfig(bytes); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: parse logging fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+
+	// An empty status turns off the logging of the bucket.
+	if logging.isEmpty() {
+		if err = deleteBucketLogging(vol); err != nil {
+			log.LogErrorf("putBucketLoggingHandler: delete logging fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			_ = InternalErrorCode(err).ServeResponse(w, r)
+			return
+		}
+		vol.metaLoader.storeLogging(nil)
+		log.LogInfof("Audit: delete bucket logging: requestID(%v) remote(%v) volume(%v)",
+			GetRequestID(r), getRequestIP(r), vol.Name())
+		return
+	}
+
+	// The access logs are written into the target bucket by ObjectNode, so the target bucket
+	// must be owned by the owner of the source bucket to keep the logs from being written into
+	// the buckets of others.
+	var targetVol *Volume
+	if targetVol, err = o.vm.Volume(logging.LoggingEnabled.TargetBucket); err != nil || targetVol.Owner() != vol.Owner() {
+		log.LogWarnf("putBucketLoggingHandler: invalid target bucket: requestID(%v) volume(%v) target(%v) err(%v)",
+			GetRequestID(r), vol.Name(), logging.LoggingEnabled.TargetBucket, err)
+		_ = InvalidTargetBucketForLogging.ServeResponse(w, r)
+		return
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(logging); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketLogging(newBytes, vol); err != nil {
+		log.LogErrorf("putBucketLoggingHandler: store logging fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeLogging(logging)
+
+	log.LogInfof("Audit: put bucket logging: requestID(%v) remote(%v) volume(%v) target(%v) prefix(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), logging.LoggingEnabled.TargetBucket,
+		logging.LoggingEnabled.TargetPrefix)
+	return
+}
+
+// loggingTarget returns the logging target of the bucket of the request, or nil if the request
+// is not on a bucket or the logging of the bucket is not enabled.
+func (o *ObjectNode) loggingTarget(r *http.Request) (vol *Volume, target *LoggingEnabled) {
+	if o.logWorker == nil {
+		return
+	}
+	var bucket = mux.Vars(r)["bucket"]
+	if bucket == "" {
+		return
+	}
+	var err error
+	if vol, err = o.vm.Volume(bucket); err != nil {
+		return nil, nil
+	}
+	var logging *BucketLoggingStatus
+	if logging, err = vol.metaLoader.loadLogging(); err != nil || logging == nil || logging.isEmpty() {
+		return nil, nil
+	}
+	return vol, logging.LoggingEnabled
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestParseLoggingConfig(t *testing.T) {
+	var status, err = parseLoggingConfig([]byte(`
+<BucketLoggingStatus xmlns="http://doc.s3.amazonaws.com/2006-03-01">
+	<LoggingEnabled>
+		<TargetBucket>logs</TargetBucket>
+		<TargetPrefix>access/</TargetPrefix>
+	</LoggingEnabled>
+</BucketLoggingStatus>`))
+	if err != nil {
+		t.Fatalf("parse logging config fail: err(%v)", err)
+	}
+	if status.isEmpty() || status.LoggingEnabled.TargetBucket != "logs" || status.LoggingEnabled.TargetPrefix != "access/" {
+		t.Fatalf("parsed logging config mismatch: status(%v)", status)
+	}
+
+	if status, err = parseLoggingConfig([]byte(`<BucketLoggingStatus/>`)); err != nil || !status.isEmpty() {
+		t.Fatalf("empty logging config should turn off logging: status(%v) err(%v)", status, err)
+	}
+
+	var invalids = []string{
+		``,
+		`<BucketLoggingStatus><LoggingEnabled><TargetPrefix>access/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`,
+		`<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs</TargetBucket><TargetPrefix>` +
+			strings.Repeat("a", maxLoggingTargetPrefixLength+1) + `</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`,
+	}
+	for _, invalid := range invalids {
+		if _, err = parseLoggingConfig([]byte(invalid)); err == nil {
+			t.Fatalf("invalid logging config should be rejected: config(%v)", invalid)
+		}
+	}
+}
+
+func TestLoggingOperation(t *testing.T) {
+	var cases = []struct {
+		method    string
+		url       string
+		vars      map[string]string
+		operation string
+	}{
+		{http.MethodGet, "/bucket/a", map[string]string{"bucket": "bucket", "object": "a"}, "REST.GET.OBJECT"},
+		{http.MethodGet, "/bucket", map[string]string{"bucket": "bucket"}, "REST.GET.BUCKET"},
+		{http.MethodPut, "/bucket?acl", map[string]string{"bucket": "bucket"}, "REST.PUT.ACL"},
+		{http.MethodGet, "/bucket?object-lock", map[string]string{"bucket": "bucket"}, "REST.GET.OBJECT_LOCK"},
+		{http.MethodPost, "/bucket/a?uploads", map[string]string{"bucket": "bucket", "object": "a"}, "REST.POST.UPLOADS"},
+		{http.MethodPut, "/bucket/a?uploadId=1&partNumber=1", map[string]string{"bucket": "bucket", "object": "a"}, "REST.PUT.PART"},
+		{http.MethodPost, "/bucket/a?uploadId=1", map[string]string{"bucket": "bucket", "object": "a"}, "REST.POST.UPLOAD"},
+	}
+	for _, c := range cases {
+		var r = mux.SetURLVars(httptest.NewRequest(c.method, c.url, nil), c.vars)
+		if operation := loggingOperation(r); operation != c.operation {
+			t.Fatalf("operation mismatch: method(%v) url(%v) expect(%v) actual(%v)", c.method, c.url, c.operation, operation)
+		}
+	}
+}
+
+func TestFormatAccessLog(t *testing.T) {
+	var r = httptest.NewRequest(http.MethodGet, "/bucket/dir/a%20b?versionId=v1", nil)
+	r.Header.Set("User-Agent", "aws-cli/1.18")
+	r = mux.SetURLVars(r, map[string]string{"bucket": "bucket", "object": "dir/a b"})
+	SetRequestID(r, "requestID")
+
+	var w = &loggingResponseWriter{ResponseWriter: httptest.NewRecorder()}
+	w.Header().Set(HeaderNameContentRange, "bytes 0-1/10")
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write([]byte("ab"))
+
+	var startTime = time.Date(2019, 2, 6, 0, 0, 38, 0, time.UTC)
+	var line = string(formatAccessLog(r, w, "owner", "", startTime, 15*time.Millisecond))
+	var expect = `owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.1 - requestID REST.GET.OBJECT dir%2Fa+b ` +
+		`"GET /bucket/dir/a%20b?versionId=v1 HTTP/1.1" 206 - 2 10 15 - - "aws-cli/1.18" v1 - - - - example.com -`
+	if line != expect {
+		t.Fatalf("access log mismatch:\nexpect(%v)\nactual(%v)", expect, line)
+	}
+
+	// The error code of the failed request is logged.
+	r = mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/bucket?acl", nil), map[string]string{"bucket": "bucket"})
+	w = &loggingResponseWriter{ResponseWriter: httptest.NewRecorder()}
+	_ = AccessDenied.ServeResponse(w, r)
+	var fields = strings.Fields(string(formatAccessLog(r, w, "owner", "user", startTime, 0)))
+	if fields[5] != "user" || fields[7] != "REST.PUT.ACL" || fields[8] != "-" || fields[12] != "403" || fields[13] != AccessDenied.ErrorCode {
+		t.Fatalf("access log of failed request mismatch: fields(%v)", fields)
+	}
+}
+
+func TestLoggingWorkerBuffer(t *testing.T) {
+	var w = NewLoggingWorker(nil, time.Minute)
+	var target = &LoggingEnabled{TargetBucket: "logs", TargetPrefix: "access/"}
+	w.Submit(target, []byte("line1"))
+	w.Submit(target, []byte("line2"))
+	w.Submit(&LoggingEnabled{TargetBucket: "logs", TargetPrefix: "other/"}, []byte("line3"))
+	if len(w.buffers) != 2 {
+		t.Fatalf("buffers of targets mismatch: count(%v)", len(w.buffers))
+	}
+	var key = "logs/access/"
+	var buffer = w.buffers[key]
+	if string(buffer.data) != "line1\nline2\n" {
+		t.Fatalf("buffered logs mismatch: data(%v)", string(buffer.data))
+	}
+
+	// The logs which fail to be written are put back before the newer logs.
+	delete(w.buffers, key)
+	w.Submit(target, []byte("line4"))
+	w.restore(key, buffer)
+	if data := string(w.buffers[key].data); data != "line1\nline2\nline4\n" {
+		t.Fatalf("restored logs mismatch: data(%v)", data)
+	}
+
+	// The flush is triggered early by the large buffer.
+	w.buffers[key].data = make([]byte, loggingFlushSize)
+	w.Submit(target, []byte("line5"))
+	select {
+	case <-w.flushCh:
+	default:
+		t.Fatalf("flush should be triggered by the large buffer")
+	}
+
+	// The logs are dropped when too many logs of the target are pending.
+	w.buffers[key].data = make([]byte, loggingMaxPendingSize)
+	w.Submit(target, []byte("line6"))
+	if len(w.buffers[key].data) != loggingMaxPendingSize {
+		t.Fatalf("logs beyond the pending size should be dropped: size(%v)", len(w.buffers[key].data))
+	}
+}
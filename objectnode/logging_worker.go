@@ -0,0 +1,336 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultLoggingFlushInterval = 5 * time.Minute
+
+	loggingFlushSize      = 4 * 1024 * 1024  // bytes of logs which trigger an early flush of the target
+	loggingMaxPendingSize = 64 * 1024 * 1024 // bytes of logs kept for a target which can not be written
+
+	loggingTimeFormat       = "02/Jan/2006:15:04:05 -0700"
+	loggingObjectTimeFormat = "2006-01-02-15-04-05"
+)
+
+// The sub-resources which name the operation of the access log, such as "REST.GET.ACL".
+var loggingSubResources = []string{
+	"acl", "cors", "delete", "encryption", "legal-hold", "lifecycle", "location", "logging",
+	"notification", "object-lock", "policy", "replication", "retention", "select", "tagging",
+	"uploads", "versioning", "versions", "website",
+}
+
+type loggingBuffer struct {
+	bucket string
+	prefix string
+	data   []byte
+}
+
+// LoggingWorker batches the access logs of the buckets whose logging is enabled, and periodically
+// writes them as objects into the target buckets. The logs of all buckets with the same target
+// bucket and prefix are written into the same objects, which are named by the target prefix, the
+// time of writing and a random string, in the same way as Amazon S3.
+// The logs buffered in memory are lost if ObjectNode exits unexpectedly.
+type LoggingWorker struct {
+	vm        *VolumeManager
+	interval  time.Duration
+	mutex     sync.Mutex
+	buffers   map[string]*loggingBuffer // mapping: target bucket and prefix -> buffered logs
+	flushCh   chan struct{}
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func NewLoggingWorker(vm *VolumeManager, interval time.Duration) *LoggingWorker {
+	return &LoggingWorker{
+		vm:       vm,
+		interval: interval,
+		buffers:  make(map[string]*loggingBuffer),
+		flushCh:  make(chan struct{}, 1),
+		closeCh:  make(chan struct{}),
+	}
+}
+
+func (w *LoggingWorker) Start() {
+	go w.run()
+}
+
+// Stop stops the worker and writes the buffered logs.
+func (w *LoggingWorker) Stop() {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+		w.flush()
+	})
+}
+
+// Submit buffers the log line for the target. The line is dropped if the logs of the target
+// can not be written and have piled up.
+func (w *LoggingWorker) Submit(target *LoggingEnabled, line []byte) {
+	var key = target.TargetBucket + "/" + target.TargetPrefix
+	w.mutex.Lock()
+	var buffer, exist = w.buffers[key]
+	if !exist {
+		buffer = &loggingBuffer{bucket: target.TargetBucket, prefix: target.TargetPrefix}
+		w.buffers[key] = buffer
+	}
+	if len(buffer.data) >= loggingMaxPendingSize {
+		w.mutex.Unlock()
+		log.LogWarnf("Submit: access log dropped: target(%v) prefix(%v)", target.TargetBucket, target.TargetPrefix)
+		return
+	}
+	buffer.data = append(append(buffer.data, line...), '\n')
+	var full = len(buffer.data) >= loggingFlushSize
+	w.mutex.Unlock()
+
+	if full {
+		select {
+		case w.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *LoggingWorker) run() {
+	var t = time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-w.flushCh:
+		case <-w.closeCh:
+			return
+		}
+		w.flush()
+	}
+}
+
+func (w *LoggingWorker) flush() {
+	w.mutex.Lock()
+	var buffers = w.buffers
+	w.buffers = make(map[string]*loggingBuffer)
+	w.mutex.Unlock()
+
+	for key, buffer := range buffers {
+		if len(buffer.data) == 0 {
+			continue
+		}
+		if err := w.write(buffer); err != nil {
+			log.LogErrorf("flush: write access log fail: target(%v) prefix(%v) size(%v) err(%v)",
+				buffer.bucket, buffer.prefix, len(buffer.data), err)
+			w.restore(key, buffer)
+		}
+	}
+}
+
+// restore puts back the logs which fail to be written, so that they are written by the next flush.
+func (w *LoggingWorker) restore(key string, buffer *loggingBuffer) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if current, exist := w.buffers[key]; exist {
+		if len(buffer.data)+len(current.data) > loggingMaxPendingSize {
+			log.LogWarnf("restore: access log dropped: target(%v) prefix(%v) size(%v)",
+				buffer.bucket, buffer.prefix, len(buffer.data))
+			return
+		}
+		buffer.data = append(buffer.data, current.data...)
+	}
+	w.buffers[key] = buffer
+}
+
+func (w *LoggingWorker) write(buffer *loggingBuffer) (err error) {
+	var vol *Volume
+	if vol, err = w.vm.Volume(buffer.bucket); err != nil {
+		return
+	}
+	var random = make([]byte, 8)
+	if _, err = rand.Read(random); err != nil {
+		return
+	}
+	var name = buffer.prefix + time.Now().UTC().Format(loggingObjectTimeFormat) + "-" +
+		strings.ToUpper(hex.EncodeToString(random))
+	var opt = &PutFileOption{MIMEType: "text/plain"}
+	if _, err = vol.PutObject(name, bytes.NewReader(buffer.data), opt); err != nil {
+		return
+	}
+	log.LogDebugf("write: access log written: target(%v) object(%v) size(%v)", buffer.bucket, name, len(buffer.data))
+	return
+}
+
+// loggingResponseWriter records the status code and the bytes of the response for the access log.
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	bytesSent  int64
+}
+
+func (w *loggingResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *loggingResponseWriter) Write(p []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	var n, err = w.ResponseWriter.Write(p)
+	w.bytesSent += int64(n)
+	return n, err
+}
+
+func (w *loggingResponseWriter) Flush() {
+	if flusher, is := w.ResponseWriter.(http.Flusher); is {
+		flusher.Flush()
+	}
+}
+
+// loggingOperation returns the operation of the access log in the form of "REST.METHOD.TYPE".
+func loggingOperation(r *http.Request) string {
+	var resource = "BUCKET"
+	if mux.Vars(r)["object"] != "" {
+		resource = "OBJECT"
+	}
+	var query = r.URL.Query()
+	for _, subResource := range loggingSubResources {
+		if _, exist := query[subResource]; exist {
+			resource = strings.ToUpper(strings.ReplaceAll(subResource, "-", "_"))
+			break
+		}
+	}
+	if _, exist := query[ParamUploadId]; exist {
+		resource = "UPLOAD"
+		if _, exist = query[ParamPartNumber]; exist {
+			resource = "PART"
+		}
+	}
+	return "REST." + r.Method + "." + resource
+}
+
+// loggingObjectSize returns the size of the object which is written or read by the request.
+func loggingObjectSize(r *http.Request, w *loggingResponseWriter, operation string) string {
+	if !strings.HasSuffix(operation, ".OBJECT") && !strings.HasSuffix(operation, ".PART") {
+		return "-"
+	}
+	switch r.Method {
+	case http.MethodPut:
+		if decoded := r.Header.Get(HeaderNameXAmzDecodeContentLength); decoded != "" {
+			return decoded
+		}
+		if r.ContentLength >= 0 {
+			return strconv.FormatInt(r.ContentLength, 10)
+		}
+	case http.MethodGet, http.MethodHead:
+		if contentRange := w.Header().Get(HeaderNameContentRange); contentRange != "" {
+			if index := strings.LastIndex(contentRange, "/"); index >= 0 && contentRange[index+1:] != "*" {
+				return contentRange[index+1:]
+			}
+		}
+		if contentLength := w.Header().Get(HeaderNameContentLength); contentLength != "" {
+			return contentLength
+		}
+	}
+	return "-"
+}
+
+// formatAccessLog formats the request as an access log line of Amazon S3.
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/LogFormat.html
+func formatAccessLog(r *http.Request, w *loggingResponseWriter, bucketOwner, requester string,
+	startTime time.Time, totalTime time.Duration) []byte {
+	var valueOrDash = func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+	var quote = func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return strconv.Quote(value)
+	}
+
+	var vars = mux.Vars(r)
+	var operation = loggingOperation(r)
+	var key = strings.TrimPrefix(vars["object"], "/")
+	if key != "" {
+		key = url.QueryEscape(key)
+	}
+	var versionID = w.Header().Get(HeaderNameXAmzVersionId)
+	if versionID == "" {
+		versionID = r.URL.Query().Get(ParamVersionId)
+	}
+	var signatureVersion, authType string
+	switch parseRequestAuthInfo(r).authType {
+	case SignatrueV2:
+		signatureVersion, authType = "SigV2", "AuthHeader"
+	case SignatrueV4:
+		signatureVersion, authType = "SigV4", "AuthHeader"
+	case PresignedV2:
+		signatureVersion, authType = "SigV2", "QueryString"
+	case PresignedV4:
+		signatureVersion, authType = "SigV4", "QueryString"
+	}
+	var statusCode = w.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	var bytesSent = "-"
+	if w.bytesSent > 0 {
+		bytesSent = strconv.FormatInt(w.bytesSent, 10)
+	}
+
+	var fields = []string{
+		valueOrDash(bucketOwner),
+		valueOrDash(vars["bucket"]),
+		"[" + startTime.UTC().Format(loggingTimeFormat) + "]",
+		valueOrDash(getRequestIP(r)),
+		valueOrDash(requester),
+		valueOrDash(GetRequestID(r)),
+		operation,
+		valueOrDash(key),
+		strconv.Quote(r.Method + " " + r.RequestURI + " " + r.Proto),
+		strconv.Itoa(statusCode),
+		valueOrDash(getResponseErrorCode(r)),
+		bytesSent,
+		loggingObjectSize(r, w, operation),
+		strconv.FormatInt(int64(totalTime/time.Millisecond), 10),
+		"-", // turn-around time
+		quote(r.Referer()),
+		quote(r.UserAgent()),
+		valueOrDash(versionID),
+		"-", // host ID
+		valueOrDash(signatureVersion),
+		"-", // cipher suite
+		valueOrDash(authType),
+		valueOrDash(r.Host),
+		"-", // TLS version
+	}
+	return []byte(strings.Join(fields, " "))
+}
@@ -0,0 +1,240 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/NotificationHowTo.html
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	EventObjectCreatedAll                     = "s3:ObjectCreated:*"
+	EventObjectCreatedPut                     = "s3:ObjectCreated:Put"
+	EventObjectCreatedPost                    = "s3:ObjectCreated:Post"
+	EventObjectCreatedCopy                    = "s3:ObjectCreated:Copy"
+	EventObjectCreatedCompleteMultipartUpload = "s3:ObjectCreated:CompleteMultipartUpload"
+	EventObjectRemovedAll                     = "s3:ObjectRemoved:*"
+	EventObjectRemovedDelete                  = "s3:ObjectRemoved:Delete"
+	EventObjectRemovedDeleteMarkerCreated     = "s3:ObjectRemoved:DeleteMarkerCreated"
+
+	NotificationFilterRulePrefix = "prefix"
+	NotificationFilterRuleSuffix = "suffix"
+
+	maxNotificationConfigurations = 100
+)
+
+var supportedEvents = map[string]struct{}{
+	EventObjectCreatedAll:                     {},
+	EventObjectCreatedPut:                     {},
+	EventObjectCreatedPost:                    {},
+	EventObjectCreatedCopy:                    {},
+	EventObjectCreatedCompleteMultipartUpload: {},
+	EventObjectRemovedAll:                     {},
+	EventObjectRemovedDelete:                  {},
+	EventObjectRemovedDeleteMarkerCreated:     {},
+}
+
+// NotificationConfiguration is the event notification configuration of the bucket. The events are
+// published to the targets configured on ObjectNode, which are referred to by the 'Queue' element
+// of the queue configurations. Topic and cloud function configurations are not supported.
+type NotificationConfiguration struct {
+	XMLName                     xml.Name              `xml:"NotificationConfiguration" json:"-"`
+	Xmlns                       string                `xml:"xmlns,attr,omitempty" json:"-"`
+	QueueConfigurations         []*QueueConfiguration `xml:"QueueConfiguration" json:"queues"`
+	TopicConfigurations         []struct{}            `xml:"TopicConfiguration" json:"-"`
+	CloudFunctionConfigurations []struct{}            `xml:"CloudFunctionConfiguration" json:"-"`
+}
+
+type QueueConfiguration struct {
+	ID     string              `xml:"Id,omitempty" json:"id,omitempty"`
+	Filter *NotificationFilter `xml:"Filter,omitempty" json:"filter,omitempty"`
+	Queue  string              `xml:"Queue" json:"queue"`
+	Events []string            `xml:"Event" json:"events"`
+}
+
+type NotificationFilter struct {
+	S3Key NotificationKeyFilter `xml:"S3Key" json:"key"`
+}
+
+type NotificationKeyFilter struct {
+	FilterRules []*NotificationFilterRule `xml:"FilterRule" json:"rules"`
+}
+
+type NotificationFilterRule struct {
+	Name  string `xml:"Name" json:"name"`
+	Value string `xml:"Value" json:"value"`
+}
+
+func (filter *NotificationFilter) validate() bool {
+	var names = make(map[string]struct{})
+	for _, rule := range filter.S3Key.FilterRules {
+		var name = strings.ToLower(rule.Name)
+		if name != NotificationFilterRulePrefix && name != NotificationFilterRuleSuffix {
+			return false
+		}
+		if _, exist := names[name]; exist {
+			return false
+		}
+		names[name] = struct{}{}
+	}
+	return true
+}
+
+// matchKey checks whether the key matches the prefix and suffix of the filter.
+func (filter *NotificationFilter) matchKey(key string) bool {
+	for _, rule := range filter.S3Key.FilterRules {
+		switch strings.ToLower(rule.Name) {
+		case NotificationFilterRulePrefix:
+			if !strings.HasPrefix(key, rule.Value) {
+				return false
+			}
+		case NotificationFilterRuleSuffix:
+			if !strings.HasSuffix(key, rule.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (config *QueueConfiguration) validate() bool {
+	if config.Queue == "" || len(config.Events) == 0 {
+		return false
+	}
+	for _, event := range config.Events {
+		if _, exist := supportedEvents[event]; !exist {
+			return false
+		}
+	}
+	if config.Filter != nil && !config.Filter.validate() {
+		return false
+	}
+	return true
+}
+
+// match checks whether the event of the object is published by the configuration. An event type
+// ending with '*' matches all the events of its kind.
+func (config *QueueConfiguration) match(eventName, key string) bool {
+	if config.Filter != nil && !config.Filter.matchKey(key) {
+		return false
+	}
+	for _, event := range config.Events {
+		if event == eventName ||
+			(strings.HasSuffix(event, "*") && strings.HasPrefix(eventName, strings.TrimSuffix(event, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (config *NotificationConfiguration) validate() bool {
+	if len(config.TopicConfigurations) > 0 || len(config.CloudFunctionConfigurations) > 0 {
+		return false
+	}
+	if len(config.QueueConfigurations) > maxNotificationConfigurations {
+		return false
+	}
+	var ids = make(map[string]struct{})
+	for _, queue := range config.QueueConfigurations {
+		if !queue.validate() {
+			return false
+		}
+		if queue.ID == "" {
+			continue
+		}
+		if _, exist := ids[queue.ID]; exist {
+			return false
+		}
+		ids[queue.ID] = struct{}{}
+	}
+	return true
+}
+
+func (config *NotificationConfiguration) isEmpty() bool {
+	return len(config.QueueConfigurations) == 0
+}
+
+func parseNotificationConfig(bytes []byte) (config *NotificationConfiguration, err error) {
+	config = &NotificationConfiguration{}
+	if err = xml.Unmarshal(bytes, config); err != nil {
+		return
+	}
+	if ok := config.validate(); !ok {
+		return nil, errors.New("invalid notification configuration")
+	}
+	return
+}
+
+func storeBucketNotification(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSNotification, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketNotification(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return
+	}
+	return nil
+}
+
+// NotificationEvent is the message published to the notification targets, which has the same
+// JSON format as the event messages of Amazon S3.
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/notification-content-structure.html
+type NotificationEvent struct {
+	Records []*EventRecord `json:"Records"`
+}
+
+type EventRecord struct {
+	EventVersion      string            `json:"eventVersion"`
+	EventSource       string            `json:"eventSource"`
+	AwsRegion         string            `json:"awsRegion"`
+	EventTime         string            `json:"eventTime"`
+	EventName         string            `json:"eventName"`
+	UserIdentity      EventIdentity     `json:"userIdentity"`
+	RequestParameters map[string]string `json:"requestParameters"`
+	ResponseElements  map[string]string `json:"responseElements"`
+	S3                EventS3           `json:"s3"`
+}
+
+type EventIdentity struct {
+	PrincipalID string `json:"principalId"`
+}
+
+type EventS3 struct {
+	SchemaVersion   string      `json:"s3SchemaVersion"`
+	ConfigurationID string      `json:"configurationId"`
+	Bucket          EventBucket `json:"bucket"`
+	Object          EventObject `json:"object"`
+}
+
+type EventBucket struct {
+	Name          string        `json:"name"`
+	OwnerIdentity EventIdentity `json:"ownerIdentity"`
+	Arn           string        `json:"arn"`
+}
+
+type EventObject struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size,omitempty"`
+	ETag      string `json:"eTag,omitempty"`
+	VersionID string `json:"versionId,omitempty"`
+	Sequencer string `json:"sequencer"`
+}
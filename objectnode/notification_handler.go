@@ -0,0 +1,209 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+func (o *ObjectNode) getBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var notification *NotificationConfiguration
+	if notification, err = vol.metaLoader.loadNotification(); err != nil {
+		log.LogErrorf("getBucketNotificationHandler: load notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	// An empty configuration is returned if the notification is not configured.
+	var output = NotificationConfiguration{}
+	if notification != nil {
+		output = *notification
+	}
+	output.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+func (o *ObjectNode) putBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var notification *NotificationConfiguration
+	if notification, err = parseNotificationConfig(bytes); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: parse notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+	for _, queue := range notification.QueueConfigurations {
+		if _, exist := o.notificationTargets[queue.Queue]; !exist {
+			log.LogWarnf("putBucketNotificationHandler: notification target not found: requestID(%v) volume(%v) target(%v)",
+				GetRequestID(r), vol.Name(), queue.Queue)
+			_ = InvalidNotificationDestination.ServeResponse(w, r)
+			return
+		}
+	}
+
+	// An empty configuration turns off the notification of the bucket.
+	if notification.isEmpty() {
+		if err = deleteBucketNotification(vol); err != nil {
+			log.LogErrorf("putBucketNotificationHandler: delete notification fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			_ = InternalErrorCode(err).ServeResponse(w, r)
+			return
+		}
+		vol.metaLoader.storeNotification(nil)
+		log.LogInfof("Audit: delete bucket notification: requestID(%v) remote(%v) volume(%v)",
+			GetRequestID(r), getRequestIP(r), vol.Name())
+		return
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(notification); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketNotification(newBytes, vol); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: store notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeNotification(notification)
+
+	log.LogInfof("Audit: put bucket notification: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	return
+}
+
+// notifyEvent publishes the event of the object to the targets of the matched notification
+// configurations of the bucket. Failing to publish the event does not fail the request.
+func (o *ObjectNode) notifyEvent(r *http.Request, vol *Volume, eventName string, object EventObject) {
+	notification, err := vol.metaLoader.loadNotification()
+	if err != nil {
+		log.LogErrorf("notifyEvent: load notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if notification == nil {
+		return
+	}
+	for _, queue := range notification.QueueConfigurations {
+		if !queue.match(eventName, object.Key) {
+			continue
+		}
+		target, exist := o.notificationTargets[queue.Queue]
+		if !exist {
+			log.LogWarnf("notifyEvent: notification target not found: requestID(%v) volume(%v) target(%v)",
+				GetRequestID(r), vol.Name(), queue.Queue)
+			continue
+		}
+		var record = o.newEventRecord(r, vol, eventName, object)
+		record.S3.ConfigurationID = queue.ID
+		if err = target.Send(&NotificationEvent{Records: []*EventRecord{record}}); err != nil {
+			log.LogErrorf("notifyEvent: send event fail: requestID(%v) volume(%v) path(%v) event(%v) target(%v) err(%v)",
+				GetRequestID(r), vol.Name(), object.Key, eventName, queue.Queue, err)
+		}
+	}
+}
+
+func (o *ObjectNode) newEventRecord(r *http.Request, vol *Volume, eventName string, object EventObject) *EventRecord {
+	var now = time.Now()
+	// The key is URL encoded in the event as Amazon S3 does.
+	object.Key = url.QueryEscape(object.Key)
+	object.Sequencer = fmt.Sprintf("%016X", now.UnixNano())
+	return &EventRecord{
+		EventVersion: "2.1",
+		EventSource:  "aws:s3",
+		AwsRegion:    o.region,
+		EventTime:    now.UTC().Format("2006-01-02T15:04:05.000Z"),
+		EventName:    strings.TrimPrefix(eventName, "s3:"),
+		UserIdentity: EventIdentity{PrincipalID: ParseRequestParam(r).AccessKey()},
+		RequestParameters: map[string]string{
+			"sourceIPAddress": getRequestIP(r),
+		},
+		ResponseElements: map[string]string{
+			"x-amz-request-id": GetRequestID(r),
+		},
+		S3: EventS3{
+			SchemaVersion: "1.0",
+			Bucket: EventBucket{
+				Name:          vol.Name(),
+				OwnerIdentity: EventIdentity{PrincipalID: vol.Owner()},
+				Arn:           "arn:aws:s3:::" + vol.Name(),
+			},
+			Object: object,
+		},
+	}
+}
+
+// notifyDeleteEvent publishes the event of the deleted object. A delete marker is created if the
+// object is deleted without a version ID on a bucket with versioning enabled.
+func (o *ObjectNode) notifyDeleteEvent(r *http.Request, vol *Volume, key, versionID string, deleteMarker bool) {
+	var eventName = EventObjectRemovedDelete
+	if deleteMarker {
+		eventName = EventObjectRemovedDeleteMarkerCreated
+	}
+	o.notifyEvent(r, vol, eventName, EventObject{Key: key, VersionID: versionID})
+}
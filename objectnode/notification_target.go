@@ -0,0 +1,395 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	NotificationTargetWebhook = "webhook"
+	NotificationTargetQueue   = "queue"
+
+	defaultNotificationQueueLimit = 100000
+
+	webhookTimeout          = 10 * time.Second
+	webhookMinRetryInterval = time.Second
+	webhookMaxRetryInterval = time.Minute
+
+	eventFileSuffix = ".event"
+)
+
+// NotificationTarget receives the events of buckets. The events are persisted to the queue directory
+// of the target before Send returns, so they survive the restart of ObjectNode.
+type NotificationTarget interface {
+	// ARN returns the ARN which the notification configuration of buckets refers to the target by.
+	ARN() string
+	Send(event *NotificationEvent) error
+	Start()
+	Stop()
+}
+
+// notificationTargetARN builds the ARN of the target, such as "arn:cfs:sqs::1:webhook".
+func notificationTargetARN(id, targetType string) string {
+	return "arn:cfs:sqs::" + id + ":" + targetType
+}
+
+// eventStore persists the events in a directory, one file per event. The files are named after the
+// time they are created, so they are listed in the order of the events.
+type eventStore struct {
+	dir   string
+	limit int
+	seq   uint64
+	count int
+	mu    sync.Mutex
+}
+
+func newEventStore(dir string, limit int) (store *eventStore, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	store = &eventStore{dir: dir, limit: limit}
+	var names []string
+	if names, err = store.List(); err != nil {
+		return nil, err
+	}
+	store.count = len(names)
+	return
+}
+
+// Put writes the event to a temporary file and then renames it, so that the readers of the
+// directory never see a partially written event.
+func (s *eventStore) Put(event *NotificationEvent) (err error) {
+	var data []byte
+	if data, err = json.Marshal(event); err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count >= s.limit {
+		// The events may have been removed by the consumers of the directory.
+		var names []string
+		if names, err = s.List(); err != nil {
+			return
+		}
+		if s.count = len(names); s.count >= s.limit {
+			return errors.NewErrorf("event queue is full: dir(%v) limit(%v)", s.dir, s.limit)
+		}
+	}
+	s.seq++
+	var name = fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), s.seq, eventFileSuffix)
+	var tmpPath = filepath.Join(s.dir, "."+name)
+	if err = writeFileSync(tmpPath, data); err != nil {
+		_ = os.Remove(tmpPath)
+		return
+	}
+	if err = os.Rename(tmpPath, filepath.Join(s.dir, name)); err != nil {
+		_ = os.Remove(tmpPath)
+		return
+	}
+	// persist the rename, otherwise the event may be lost on power failure after it is acknowledged
+	if err = syncDir(s.dir); err != nil {
+		return
+	}
+	s.count++
+	return
+}
+
+// writeFileSync writes the data to the file and flushes it to the disk.
+func writeFileSync(path string, data []byte) (err error) {
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+		return
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return
+}
+
+func syncDir(dir string) (err error) {
+	var f *os.File
+	if f, err = os.Open(dir); err != nil {
+		return
+	}
+	err = f.Sync()
+	_ = f.Close()
+	return
+}
+
+// List returns the names of the events in the order they are stored.
+func (s *eventStore) List() (names []string, err error) {
+	var infos []os.FileInfo
+	if infos, err = ioutil.ReadDir(s.dir); err != nil {
+		return
+	}
+	for _, info := range infos {
+		if info.Mode().IsRegular() && !strings.HasPrefix(info.Name(), ".") && strings.HasSuffix(info.Name(), eventFileSuffix) {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+func (s *eventStore) Get(name string) (data []byte, err error) {
+	return ioutil.ReadFile(filepath.Join(s.dir, name))
+}
+
+func (s *eventStore) Del(name string) (err error) {
+	if err = os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	s.mu.Lock()
+	if s.count > 0 {
+		s.count--
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// queueTarget writes the events to the local queue directory, which is consumed by other processes.
+// The consumers read the events in the order of the file names and remove the files when done.
+type queueTarget struct {
+	arn   string
+	store *eventStore
+}
+
+func (t *queueTarget) ARN() string {
+	return t.arn
+}
+
+func (t *queueTarget) Send(event *NotificationEvent) error {
+	return t.store.Put(event)
+}
+
+func (t *queueTarget) Start() {}
+
+func (t *queueTarget) Stop() {}
+
+// webhookTarget posts the events to the HTTP endpoint in order. The events are persisted in the
+// queue directory first and removed once the endpoint responds with a 2xx status code. The failed
+// events are retried with an exponential backoff.
+type webhookTarget struct {
+	arn       string
+	endpoint  string
+	authToken string
+	store     *eventStore
+	client    *http.Client
+	notifyCh  chan struct{}
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func (t *webhookTarget) ARN() string {
+	return t.arn
+}
+
+func (t *webhookTarget) Send(event *NotificationEvent) (err error) {
+	if err = t.store.Put(event); err != nil {
+		return
+	}
+	select {
+	case t.notifyCh <- struct{}{}:
+	default:
+	}
+	return
+}
+
+func (t *webhookTarget) Start() {
+	go t.run()
+}
+
+func (t *webhookTarget) Stop() {
+	t.closeOnce.Do(func() {
+		close(t.closeCh)
+	})
+}
+
+func (t *webhookTarget) run() {
+	var retryInterval = webhookMinRetryInterval
+	var timer = time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-t.notifyCh:
+		case <-t.closeCh:
+			return
+		}
+		if err := t.sendAll(); err != nil {
+			log.LogWarnf("webhookTarget: send events fail: target(%v) endpoint(%v) retryInterval(%v) err(%v)",
+				t.arn, t.endpoint, retryInterval, err)
+			retryInterval *= 2
+			if retryInterval > webhookMaxRetryInterval {
+				retryInterval = webhookMaxRetryInterval
+			}
+		} else {
+			retryInterval = webhookMinRetryInterval
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(retryInterval)
+	}
+}
+
+// sendAll posts the stored events in order, and stops at the first failure to keep the order.
+func (t *webhookTarget) sendAll() (err error) {
+	var names []string
+	if names, err = t.store.List(); err != nil {
+		return
+	}
+	for _, name := range names {
+		select {
+		case <-t.closeCh:
+			return nil
+		default:
+		}
+		var data []byte
+		if data, err = t.store.Get(name); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return
+		}
+		if err = t.post(data); err != nil {
+			return
+		}
+		if err = t.store.Del(name); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (t *webhookTarget) post(data []byte) (err error) {
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(data)); err != nil {
+		return
+	}
+	req.Header.Set(HeaderNameContentType, HeaderValueContentTypeJSON)
+	if t.authToken != "" {
+		req.Header.Set(HeaderNameAuthorization, "Bearer "+t.authToken)
+	}
+	var resp *http.Response
+	if resp, err = t.client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.NewErrorf("unexpected status code: code(%v)", resp.StatusCode)
+	}
+	return nil
+}
+
+// LoadNotificationTargets loads the notification targets from the JSON file in the following format:
+//
+//	{
+//		"targets": [
+//			{
+//				"id": "1",
+//				"type": "webhook",
+//				"endpoint": "http://ingest.chubao.io/events",
+//				"authToken": "...",
+//				"queueDir": "/cfs/events/webhook_1"
+//			},
+//			{
+//				"id": "2",
+//				"type": "queue",
+//				"queueDir": "/cfs/events/queue_2",
+//				"queueLimit": 100000
+//			}
+//		]
+//	}
+//
+// The ARNs of the targets in the example are "arn:cfs:sqs::1:webhook" and "arn:cfs:sqs::2:queue".
+func LoadNotificationTargets(filename string) (targets map[string]NotificationTarget, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+	var raw = struct {
+		Targets []struct {
+			ID         string `json:"id"`
+			Type       string `json:"type"`
+			Endpoint   string `json:"endpoint"`
+			AuthToken  string `json:"authToken"`
+			QueueDir   string `json:"queueDir"`
+			QueueLimit int    `json:"queueLimit"`
+		} `json:"targets"`
+	}{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	targets = make(map[string]NotificationTarget)
+	for _, config := range raw.Targets {
+		if config.ID == "" || strings.Contains(config.ID, ":") || config.QueueDir == "" {
+			return nil, errors.NewErrorf("invalid notification target: id(%v)", config.ID)
+		}
+		var arn = notificationTargetARN(config.ID, config.Type)
+		if _, exist := targets[arn]; exist {
+			return nil, errors.NewErrorf("duplicate notification target: id(%v) type(%v)", config.ID, config.Type)
+		}
+		var limit = config.QueueLimit
+		if limit <= 0 {
+			limit = defaultNotificationQueueLimit
+		}
+		var store *eventStore
+		if store, err = newEventStore(config.QueueDir, limit); err != nil {
+			return nil, err
+		}
+		switch config.Type {
+		case NotificationTargetWebhook:
+			if config.Endpoint == "" {
+				return nil, errors.NewErrorf("invalid notification target: id(%v)", config.ID)
+			}
+			targets[arn] = &webhookTarget{
+				arn:       arn,
+				endpoint:  config.Endpoint,
+				authToken: config.AuthToken,
+				store:     store,
+				client:    &http.Client{Timeout: webhookTimeout},
+				notifyCh:  make(chan struct{}, 1),
+				closeCh:   make(chan struct{}),
+			}
+		case NotificationTargetQueue:
+			targets[arn] = &queueTarget{arn: arn, store: store}
+		default:
+			return nil, errors.NewErrorf("unknown notification target type: id(%v) type(%v)", config.ID, config.Type)
+		}
+	}
+	return
+}
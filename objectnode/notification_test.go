@@ -0,0 +1,172 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseNotificationConfig(t *testing.T) {
+	var valid = `<NotificationConfiguration>
+	<QueueConfiguration>
+		<Id>images</Id>
+		<Queue>arn:cfs:sqs::1:webhook</Queue>
+		<Event>s3:ObjectCreated:*</Event>
+		<Event>s3:ObjectRemoved:Delete</Event>
+		<Filter><S3Key>
+			<FilterRule><Name>prefix</Name><Value>images/</Value></FilterRule>
+			<FilterRule><Name>suffix</Name><Value>.jpg</Value></FilterRule>
+		</S3Key></Filter>
+	</QueueConfiguration>
+</NotificationConfiguration>`
+	config, err := parseNotificationConfig([]byte(valid))
+	if err != nil {
+		t.Fatalf("parse notification config fail: err(%v)", err)
+	}
+	if len(config.QueueConfigurations) != 1 || len(config.QueueConfigurations[0].Events) != 2 {
+		t.Fatalf("notification config mismatch: config(%v)", config)
+	}
+	var queue = config.QueueConfigurations[0]
+	if !queue.match(EventObjectCreatedPut, "images/a.jpg") || !queue.match(EventObjectCreatedCopy, "images/b.jpg") {
+		t.Fatalf("created event should match")
+	}
+	if !queue.match(EventObjectRemovedDelete, "images/a.jpg") {
+		t.Fatalf("removed event should match")
+	}
+	if queue.match(EventObjectRemovedDeleteMarkerCreated, "images/a.jpg") {
+		t.Fatalf("delete marker event should not match")
+	}
+	if queue.match(EventObjectCreatedPut, "images/a.png") || queue.match(EventObjectCreatedPut, "docs/a.jpg") {
+		t.Fatalf("event of filtered key should not match")
+	}
+
+	if config, err = parseNotificationConfig([]byte(`<NotificationConfiguration></NotificationConfiguration>`)); err != nil || !config.isEmpty() {
+		t.Fatalf("empty notification config should be accepted: err(%v)", err)
+	}
+
+	var invalids = []string{
+		`<NotificationConfiguration><QueueConfiguration><Queue>arn:cfs:sqs::1:webhook</Queue></QueueConfiguration></NotificationConfiguration>`,
+		`<NotificationConfiguration><QueueConfiguration><Queue>arn:cfs:sqs::1:webhook</Queue><Event>s3:ObjectRestore:Post</Event></QueueConfiguration></NotificationConfiguration>`,
+		`<NotificationConfiguration><QueueConfiguration><Queue>arn:cfs:sqs::1:webhook</Queue><Event>s3:ObjectCreated:*</Event><Filter><S3Key><FilterRule><Name>infix</Name><Value>a</Value></FilterRule></S3Key></Filter></QueueConfiguration></NotificationConfiguration>`,
+		`<NotificationConfiguration><TopicConfiguration><Topic>arn:aws:sns:us-east-1:123456789012:topic</Topic><Event>s3:ObjectCreated:*</Event></TopicConfiguration></NotificationConfiguration>`,
+	}
+	for _, invalid := range invalids {
+		if _, err = parseNotificationConfig([]byte(invalid)); err == nil {
+			t.Fatalf("invalid notification config should be rejected: config(%v)", invalid)
+		}
+	}
+}
+
+func TestEventStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event_store")
+	if err != nil {
+		t.Fatalf("create temp dir fail: err(%v)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var store *eventStore
+	if store, err = newEventStore(dir, 2); err != nil {
+		t.Fatalf("new event store fail: err(%v)", err)
+	}
+	for _, key := range []string{"a", "b"} {
+		var event = &NotificationEvent{Records: []*EventRecord{{S3: EventS3{Object: EventObject{Key: key}}}}}
+		if err = store.Put(event); err != nil {
+			t.Fatalf("put event fail: err(%v)", err)
+		}
+	}
+	if err = store.Put(&NotificationEvent{}); err == nil {
+		t.Fatalf("put event should fail if the store is full")
+	}
+
+	// The events survive reopening the store.
+	if store, err = newEventStore(dir, 2); err != nil {
+		t.Fatalf("reopen event store fail: err(%v)", err)
+	}
+	var names []string
+	if names, err = store.List(); err != nil || len(names) != 2 {
+		t.Fatalf("list events fail: names(%v) err(%v)", names, err)
+	}
+	var data []byte
+	if data, err = store.Get(names[0]); err != nil {
+		t.Fatalf("get event fail: err(%v)", err)
+	}
+	var event = &NotificationEvent{}
+	if err = json.Unmarshal(data, event); err != nil || event.Records[0].S3.Object.Key != "a" {
+		t.Fatalf("events are not in order: event(%v) err(%v)", string(data), err)
+	}
+	if err = store.Del(names[0]); err != nil {
+		t.Fatalf("delete event fail: err(%v)", err)
+	}
+	if err = store.Put(&NotificationEvent{}); err != nil {
+		t.Fatalf("put event fail after delete: err(%v)", err)
+	}
+}
+
+func TestWebhookTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook")
+	if err != nil {
+		t.Fatalf("create temp dir fail: err(%v)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var received = make(chan *NotificationEvent, 1)
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderNameAuthorization) != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event = &NotificationEvent{}
+		if err := json.NewDecoder(r.Body).Decode(event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	var targetFile = dir + "/targets.json"
+	var config = `{"targets": [{"id": "1", "type": "webhook", "endpoint": "` + server.URL + `", "authToken": "token", "queueDir": "` + dir + `/queue"}]}`
+	if err = ioutil.WriteFile(targetFile, []byte(config), 0644); err != nil {
+		t.Fatalf("write target file fail: err(%v)", err)
+	}
+	var targets map[string]NotificationTarget
+	if targets, err = LoadNotificationTargets(targetFile); err != nil {
+		t.Fatalf("load notification targets fail: err(%v)", err)
+	}
+	var target, exist = targets["arn:cfs:sqs::1:webhook"]
+	if !exist {
+		t.Fatalf("webhook target not found: targets(%v)", targets)
+	}
+	target.Start()
+	defer target.Stop()
+
+	if err = target.Send(&NotificationEvent{Records: []*EventRecord{{EventName: "ObjectCreated:Put"}}}); err != nil {
+		t.Fatalf("send event fail: err(%v)", err)
+	}
+	select {
+	case event := <-received:
+		if len(event.Records) != 1 || event.Records[0].EventName != "ObjectCreated:Put" {
+			t.Fatalf("event mismatch: event(%v)", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event is not delivered")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/object-lock.html
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	ObjectLockEnabled = "Enabled"
+
+	// The maximum default retention period is 100 years.
+	maxDefaultRetentionDays  = 36500
+	maxDefaultRetentionYears = 100
+)
+
+type ObjectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration" json:"-"`
+	Xmlns             string          `xml:"xmlns,attr,omitempty" json:"-"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled" json:"enabled"`
+	Rule              *ObjectLockRule `xml:"Rule,omitempty" json:"rule,omitempty"`
+}
+
+type ObjectLockRule struct {
+	DefaultRetention *DefaultRetention `xml:"DefaultRetention" json:"default_retention"`
+}
+
+type DefaultRetention struct {
+	Mode  string `xml:"Mode" json:"mode"`
+	Days  int    `xml:"Days,omitempty" json:"days,omitempty"`
+	Years int    `xml:"Years,omitempty" json:"years,omitempty"`
+}
+
+// ObjectLockRetention is the XML representation of the retention of an object.
+type ObjectLockRetention struct {
+	XMLName         xml.Name `xml:"Retention"`
+	Xmlns           string   `xml:"xmlns,attr,omitempty"`
+	Mode            string   `xml:"Mode,omitempty"`
+	RetainUntilDate string   `xml:"RetainUntilDate,omitempty"`
+}
+
+// ObjectLockLegalHold is the XML representation of the legal hold of an object.
+type ObjectLockLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Status  string   `xml:"Status"`
+}
+
+func isValidObjectLockMode(mode string) bool {
+	return mode == proto.ObjectLockModeGovernance || mode == proto.ObjectLockModeCompliance
+}
+
+func (retention *DefaultRetention) validate() bool {
+	if !isValidObjectLockMode(retention.Mode) {
+		return false
+	}
+	// Either Days or Years must be specified, but not both.
+	if (retention.Days > 0) == (retention.Years > 0) {
+		return false
+	}
+	return retention.Days <= maxDefaultRetentionDays && retention.Years <= maxDefaultRetentionYears
+}
+
+func (config *ObjectLockConfiguration) validate() bool {
+	if config.ObjectLockEnabled != ObjectLockEnabled {
+		return false
+	}
+	if config.Rule != nil && (config.Rule.DefaultRetention == nil || !config.Rule.DefaultRetention.validate()) {
+		return false
+	}
+	return true
+}
+
+// defaultRetention returns the retention applied to the objects written at now without
+// retention specified, or nil if the configuration has no default retention.
+func (config *ObjectLockConfiguration) defaultRetention(now time.Time) *proto.ObjectRetention {
+	if config == nil || config.Rule == nil || config.Rule.DefaultRetention == nil {
+		return nil
+	}
+	var retention = config.Rule.DefaultRetention
+	return &proto.ObjectRetention{
+		Mode:        retention.Mode,
+		RetainUntil: now.AddDate(retention.Years, 0, retention.Days).Unix(),
+	}
+}
+
+func parseObjectLockConfig(bytes []byte) (config *ObjectLockConfiguration, err error) {
+	config = &ObjectLockConfiguration{}
+	if err = xml.Unmarshal(bytes, config); err != nil {
+		return
+	}
+	if ok := config.validate(); !ok {
+		return nil, errors.New("invalid object lock configuration")
+	}
+	return
+}
+
+func storeBucketObjectLock(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSObjectLock, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+// parseObjectRetention parses the retention of an object. An empty retention means the removal
+// of the retention.
+func parseObjectRetention(mode, retainUntilDate string) (retention *proto.ObjectRetention, err error) {
+	if mode == "" && retainUntilDate == "" {
+		return nil, nil
+	}
+	if !isValidObjectLockMode(mode) {
+		return nil, errors.New("invalid object lock mode")
+	}
+	var until time.Time
+	if until, err = time.Parse(time.RFC3339, retainUntilDate); err != nil {
+		return nil, err
+	}
+	return &proto.ObjectRetention{Mode: mode, RetainUntil: until.Unix()}, nil
+}
+
+func formatRetainUntilDate(retainUntil int64) string {
+	return time.Unix(retainUntil, 0).UTC().Format(time.RFC3339)
+}
+
+// isRetentionWeakened returns whether the new retention shortens or removes the old retention,
+// which requires the permission to bypass the GOVERNANCE mode.
+func isRetentionWeakened(old, new *proto.ObjectRetention, now int64) bool {
+	if old == nil || !old.Active(now) {
+		return false
+	}
+	if new == nil {
+		return true
+	}
+	return new.RetainUntil < old.RetainUntil || (old.Mode == proto.ObjectLockModeCompliance && new.Mode != old.Mode)
+}
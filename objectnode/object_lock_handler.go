@@ -0,0 +1,493 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLockConfiguration.html
+func (o *ObjectNode) getObjectLockConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var objectLock *ObjectLockConfiguration
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("getObjectLockConfigurationHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if objectLock == nil {
+		_ = NoSuchObjectLockConfiguration.ServeResponse(w, r)
+		return
+	}
+
+	var output = ObjectLockConfiguration{
+		Xmlns:             "http://s3.amazonaws.com/doc/2006-03-01/",
+		ObjectLockEnabled: objectLock.ObjectLockEnabled,
+		Rule:              objectLock.Rule,
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLockConfiguration.html
+//
+// Object lock can not be disabled once it is enabled on the bucket, and versioning is enabled
+// along with it, since the overwritten versions of the locked objects must be kept.
+func (o *ObjectNode) putObjectLockConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var objectLock *ObjectLockConfiguration
+	if objectLock, err = parseObjectLockConfig(bytes); err != nil {
+		log.LogErrorf("putObjectLockConfigurationHandler: parse object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+
+	var status string
+	if status, err = vol.versioningStatus(); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if status != VersioningStatusEnabled {
+		var versioning = &VersioningConfiguration{Status: VersioningStatusEnabled}
+		var versioningBytes []byte
+		if versioningBytes, err = json.Marshal(versioning); err != nil {
+			_ = InternalErrorCode(err).ServeResponse(w, r)
+			return
+		}
+		if err = storeBucketVersioning(versioningBytes, vol); err != nil {
+			log.LogErrorf("putObjectLockConfigurationHandler: store versioning fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			_ = InternalErrorCode(err).ServeResponse(w, r)
+			return
+		}
+		vol.metaLoader.storeVersioning(versioning)
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(objectLock); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketObjectLock(newBytes, vol); err != nil {
+		log.LogErrorf("putObjectLockConfigurationHandler: store object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeObjectLock(objectLock)
+
+	log.LogInfof("Audit: put object lock configuration: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectRetention.html
+func (o *ObjectNode) getObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var versionID = r.URL.Query().Get(ParamVersionId)
+	var retention *proto.ObjectRetention
+	var deleteMarker bool
+	if retention, deleteMarker, err = vol.GetObjectRetention(param.Object(), versionID); err != nil {
+		errorCode = objectLockErrorCode(err, versionID)
+		if errorCode == nil {
+			log.LogErrorf("getObjectRetentionHandler: get retention fail: requestID(%v) volume(%v) path(%v) versionID(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), versionID, err)
+			errorCode = InternalErrorCode(err)
+		}
+		return
+	}
+	if deleteMarker {
+		errorCode = MethodNotAllowed
+		return
+	}
+	if retention == nil {
+		errorCode = NoSuchObjectLockConfiguration
+		return
+	}
+
+	var output = ObjectLockRetention{
+		Xmlns:           "http://s3.amazonaws.com/doc/2006-03-01/",
+		Mode:            retention.Mode,
+		RetainUntilDate: formatRetainUntilDate(retention.RetainUntil),
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
+func (o *ObjectNode) putObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+	if errorCode = checkObjectLockEnabled(r, vol); errorCode != nil {
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	var input = ObjectLockRetention{}
+	if err = xml.Unmarshal(bytes, &input); err != nil {
+		errorCode = MalformedXML
+		return
+	}
+	var retention *proto.ObjectRetention
+	if retention, err = parseObjectRetention(input.Mode, input.RetainUntilDate); err != nil {
+		errorCode = MalformedXML
+		return
+	}
+	var now = time.Now().Unix()
+	if retention != nil && !retention.Active(now) {
+		errorCode = InvalidRetentionPeriod
+		return
+	}
+
+	var versionID = r.URL.Query().Get(ParamVersionId)
+	var old *proto.ObjectRetention
+	var deleteMarker bool
+	if old, deleteMarker, err = vol.GetObjectRetention(param.Object(), versionID); err != nil {
+		if errorCode = objectLockErrorCode(err, versionID); errorCode == nil {
+			errorCode = InternalErrorCode(err)
+		}
+		return
+	}
+	if deleteMarker {
+		errorCode = MethodNotAllowed
+		return
+	}
+	// Shortening or removing the retention in GOVERNANCE mode requires the special permission,
+	// and the retention in COMPLIANCE mode is protected by the meta node.
+	if isRetentionWeakened(old, retention, now) && old.Mode == proto.ObjectLockModeGovernance &&
+		!o.bypassGovernanceRetention(r, param) {
+		errorCode = ObjectLocked
+		return
+	}
+
+	if _, err = vol.PutObjectRetention(param.Object(), versionID, retention); err != nil {
+		if errorCode = objectLockErrorCode(err, versionID); errorCode == nil {
+			log.LogErrorf("putObjectRetentionHandler: put retention fail: requestID(%v) volume(%v) path(%v) versionID(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), versionID, err)
+			errorCode = InternalErrorCode(err)
+		}
+		return
+	}
+
+	log.LogInfof("Audit: put object retention: requestID(%v) remote(%v) volume(%v) path(%v) versionID(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionID)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
+func (o *ObjectNode) getObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var versionID = r.URL.Query().Get(ParamVersionId)
+	var status string
+	var deleteMarker bool
+	if status, deleteMarker, err = vol.GetObjectLegalHold(param.Object(), versionID); err != nil {
+		if errorCode = objectLockErrorCode(err, versionID); errorCode == nil {
+			log.LogErrorf("getObjectLegalHoldHandler: get legal hold fail: requestID(%v) volume(%v) path(%v) versionID(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), versionID, err)
+			errorCode = InternalErrorCode(err)
+		}
+		return
+	}
+	if deleteMarker {
+		errorCode = MethodNotAllowed
+		return
+	}
+
+	var output = ObjectLockLegalHold{
+		Xmlns:  "http://s3.amazonaws.com/doc/2006-03-01/",
+		Status: status,
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
+func (o *ObjectNode) putObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+	if errorCode = checkObjectLockEnabled(r, vol); errorCode != nil {
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	var input = ObjectLockLegalHold{}
+	if err = xml.Unmarshal(bytes, &input); err != nil {
+		errorCode = MalformedXML
+		return
+	}
+	if input.Status != proto.LegalHoldStatusOn && input.Status != proto.LegalHoldStatusOff {
+		errorCode = MalformedXML
+		return
+	}
+
+	var versionID = r.URL.Query().Get(ParamVersionId)
+	var deleteMarker bool
+	if deleteMarker, err = vol.PutObjectLegalHold(param.Object(), versionID, input.Status); err != nil {
+		if errorCode = objectLockErrorCode(err, versionID); errorCode == nil {
+			log.LogErrorf("putObjectLegalHoldHandler: put legal hold fail: requestID(%v) volume(%v) path(%v) versionID(%v) err(%v)",
+				GetRequestID(r), vol.Name(), param.Object(), versionID, err)
+			errorCode = InternalErrorCode(err)
+		}
+		return
+	}
+	if deleteMarker {
+		errorCode = MethodNotAllowed
+		return
+	}
+
+	log.LogInfof("Audit: put object legal hold: requestID(%v) remote(%v) volume(%v) path(%v) versionID(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionID, input.Status)
+	return
+}
+
+// checkObjectLockEnabled checks whether object lock is enabled on the bucket.
+func checkObjectLockEnabled(r *http.Request, vol *Volume) *ErrorCode {
+	objectLock, err := vol.metaLoader.loadObjectLock()
+	if err != nil {
+		log.LogErrorf("checkObjectLockEnabled: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return InternalErrorCode(err)
+	}
+	if objectLock == nil {
+		return InvalidObjectLockConfiguration
+	}
+	return nil
+}
+
+// parseObjectLockOption parses the object lock requested for the object to write. Without the
+// object lock headers, the default retention of the bucket applies.
+func parseObjectLockOption(r *http.Request, vol *Volume, opt *PutFileOption) *ErrorCode {
+	var mode = r.Header.Get(HeaderNameXAmzObjectLockMode)
+	var retainUntilDate = r.Header.Get(HeaderNameXAmzObjectLockRetainUntilDate)
+	var legalHold = r.Header.Get(HeaderNameXAmzObjectLockLegalHold)
+
+	objectLock, err := vol.metaLoader.loadObjectLock()
+	if err != nil {
+		log.LogErrorf("parseObjectLockOption: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return InternalErrorCode(err)
+	}
+	if objectLock == nil {
+		if mode != "" || retainUntilDate != "" || legalHold != "" {
+			return InvalidObjectLockConfiguration
+		}
+		return nil
+	}
+
+	if opt.Retention, err = parseObjectRetention(mode, retainUntilDate); err != nil {
+		return InvalidObjectLockArgument
+	}
+	if opt.Retention != nil && !opt.Retention.Active(time.Now().Unix()) {
+		return InvalidRetentionPeriod
+	}
+	if opt.Retention == nil {
+		opt.Retention = objectLock.defaultRetention(time.Now())
+	}
+	switch legalHold {
+	case "", proto.LegalHoldStatusOff:
+	case proto.LegalHoldStatusOn:
+		opt.LegalHold = legalHold
+	default:
+		return InvalidObjectLockArgument
+	}
+	return nil
+}
+
+// bypassGovernanceRetention returns whether the request asks to bypass the GOVERNANCE mode
+// and the requester has the permission to do so.
+func (o *ObjectNode) bypassGovernanceRetention(r *http.Request, param *RequestParam) bool {
+	if !strings.EqualFold(r.Header.Get(HeaderNameXAmzBypassGovernanceRetention), "true") {
+		return false
+	}
+	userInfo, err := o.getUserInfoByAccessKey(param.AccessKey())
+	if err != nil {
+		log.LogErrorf("bypassGovernanceRetention: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return false
+	}
+	return userInfo.Policy.IsAuthorized(param.Bucket(), "", proto.OSSBypassGovernanceRetentionAction)
+}
+
+// objectLockErrorCode returns the error code of the errors returned by the object lock operations,
+// or nil if the error is not expected.
+func objectLockErrorCode(err error, versionID string) *ErrorCode {
+	switch err {
+	case syscall.ENOENT:
+		if versionID != "" {
+			return NoSuchVersion
+		}
+		return NoSuchKey
+	case syscall.EPERM:
+		return ObjectLocked
+	}
+	return nil
+}
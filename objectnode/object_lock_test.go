@@ -0,0 +1,98 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestParseObjectLockConfig(t *testing.T) {
+	var valid = `<ObjectLockConfiguration>
+	<ObjectLockEnabled>Enabled</ObjectLockEnabled>
+	<Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Days>30</Days></DefaultRetention></Rule>
+</ObjectLockConfiguration>`
+	config, err := parseObjectLockConfig([]byte(valid))
+	if err != nil {
+		t.Fatalf("parse object lock config fail: err(%v)", err)
+	}
+	var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var retention = config.defaultRetention(now)
+	if retention == nil || retention.Mode != proto.ObjectLockModeCompliance ||
+		retention.RetainUntil != now.AddDate(0, 0, 30).Unix() {
+		t.Fatalf("default retention mismatch: retention(%v)", retention)
+	}
+
+	config, err = parseObjectLockConfig([]byte(`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`))
+	if err != nil {
+		t.Fatalf("parse object lock config without rule fail: err(%v)", err)
+	}
+	if config.defaultRetention(now) != nil {
+		t.Fatalf("default retention should be nil")
+	}
+
+	var invalids = []string{
+		`<ObjectLockConfiguration></ObjectLockConfiguration>`,
+		`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule></Rule></ObjectLockConfiguration>`,
+		`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>1</Days><Years>1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`,
+		`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>WORM</Mode><Days>1</Days></DefaultRetention></Rule></ObjectLockConfiguration>`,
+	}
+	for _, invalid := range invalids {
+		if _, err = parseObjectLockConfig([]byte(invalid)); err == nil {
+			t.Fatalf("invalid object lock config should be rejected: config(%v)", invalid)
+		}
+	}
+}
+
+func TestParseObjectRetention(t *testing.T) {
+	retention, err := parseObjectRetention(proto.ObjectLockModeGovernance, "2030-01-02T03:04:05Z")
+	if err != nil {
+		t.Fatalf("parse retention fail: err(%v)", err)
+	}
+	if formatRetainUntilDate(retention.RetainUntil) != "2030-01-02T03:04:05Z" {
+		t.Fatalf("retain until date mismatch: date(%v)", formatRetainUntilDate(retention.RetainUntil))
+	}
+	if retention, err = parseObjectRetention("", ""); err != nil || retention != nil {
+		t.Fatalf("empty retention should be nil: retention(%v) err(%v)", retention, err)
+	}
+	if _, err = parseObjectRetention(proto.ObjectLockModeGovernance, ""); err == nil {
+		t.Fatalf("retention without date should be rejected")
+	}
+	if _, err = parseObjectRetention("WORM", "2030-01-02T03:04:05Z"); err == nil {
+		t.Fatalf("retention with invalid mode should be rejected")
+	}
+}
+
+func TestIsRetentionWeakened(t *testing.T) {
+	var now int64 = 1000
+	var governance = &proto.ObjectRetention{Mode: proto.ObjectLockModeGovernance, RetainUntil: 2000}
+	var cases = []struct {
+		old, new *proto.ObjectRetention
+		expect   bool
+	}{
+		{nil, governance, false},
+		{governance, nil, true},
+		{governance, &proto.ObjectRetention{Mode: proto.ObjectLockModeGovernance, RetainUntil: 1500}, true},
+		{governance, &proto.ObjectRetention{Mode: proto.ObjectLockModeCompliance, RetainUntil: 3000}, false},
+		{&proto.ObjectRetention{Mode: proto.ObjectLockModeGovernance, RetainUntil: 500}, nil, false},
+	}
+	for i, c := range cases {
+		if weakened := isRetentionWeakened(c.old, c.new, now); weakened != c.expect {
+			t.Fatalf("case(%v) mismatch: expect(%v) actual(%v)", i, c.expect, weakened)
+		}
+	}
+}
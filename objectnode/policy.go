@@ -163,9 +163,21 @@ func (p Policy) Validate(bucket string) (bool, error) {
 	return true, nil
 }
 
+// IsPublic returns whether any statement of the policy allows everyone.
+func (p *Policy) IsPublic() bool {
+	for _, s := range p.Statements {
+		if s.IsPublic() {
+			return true
+		}
+	}
+	return false
+}
+
 // check policy is allowed for request
+// The statements which allow everyone are ignored if restrictPublic is set by the public access
+// block settings of the bucket.
 // https://docs.aws.amazon.com/zh_cn/IAM/latest/UserGuide/reference_policies_evaluation-logic.html
-func (p *Policy) IsAllowed(params *RequestParam, isOwner bool) bool {
+func (p *Policy) IsAllowed(params *RequestParam, isOwner, restrictPublic bool) bool {
 	for _, s := range p.Statements {
 		if s.Effect == Deny {
 			if !s.IsAllowed(params) {
@@ -182,6 +194,9 @@ func (p *Policy) IsAllowed(params *RequestParam, isOwner bool) bool {
 
 	for _, s := range p.Statements {
 		if s.Effect == Allow {
+			if restrictPublic && s.IsPublic() {
+				continue
+			}
 			if s.IsAllowed(params) {
 				log.LogDebugf("policy allow cause of %v, %v", s, params)
 				return true
@@ -214,6 +229,19 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 
 		param := ParseRequestParam(r)
 
+		// The request signed with temporary credentials is limited by the session policy besides
+		// the policies of the user who assumed the credentials.
+		if sessionAccessKey, sessionPolicy := GetSessionFromContext(r); sessionAccessKey != "" && sessionPolicy != "" {
+			var policy *SessionPolicy
+			if policy, err = ParseSessionPolicy([]byte(sessionPolicy)); err != nil || !policy.IsAllowed(param) {
+				log.LogDebugf("policyCheck: session policy denied: requestID(%v) sessionAccessKey(%v) resource(%v) action(%v)",
+					GetRequestID(r), sessionAccessKey, param.resource, param.Action())
+				err = nil
+				allowed = false
+				return
+			}
+		}
+
 		if param.Bucket() == "" {
 			log.LogDebugf("policyCheck: no bucket specified: requestID(%v)", GetRequestID(r))
 			allowed = true
@@ -273,6 +301,7 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 		var vol *Volume
 		var acl *AccessControlPolicy
 		var policy *Policy
+		var block *proto.PublicAccessBlock
 		var loadBucketMeta = func(bucket string) (err error) {
 			if vol, err = o.getVol(bucket); err != nil {
 				return
@@ -283,6 +312,9 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 			if policy, err = vol.metaLoader.loadPolicy(); err != nil {
 				return
 			}
+			if block, err = o.publicAccessBlock(vol); err != nil {
+				return
+			}
 			return
 		}
 		if err = loadBucketMeta(param.Bucket()); err != nil {
@@ -293,7 +325,7 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 		}
 
 		if vol != nil && policy != nil && !policy.IsEmpty() {
-			allowed = policy.IsAllowed(param, isOwner)
+			allowed = policy.IsAllowed(param, isOwner, block.RestrictPublicBuckets)
 			if !allowed {
 				log.LogWarnf("policyCheck: bucket policy not allowed: requestID(%v) userID(%v) accessKey(%v) volume(%v) action(%v)",
 					GetRequestID(r), userInfo, param.AccessKey(), param.Bucket(), param.Action())
@@ -302,7 +334,7 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 		}
 
 		if vol != nil && acl != nil && !acl.IsAclEmpty() {
-			allowed = acl.IsAllowed(param, isOwner)
+			allowed = acl.IsAllowed(param, isOwner, block.IgnorePublicAcls)
 			if !allowed {
 				log.LogWarnf("policyCheck: bucket ACL not allowed: requestID(%v) userID(%v) accessKey(%v) volume(%v) action(%v)",
 					GetRequestID(r), userInfo, param.AccessKey(), param.Bucket(), param.Action())
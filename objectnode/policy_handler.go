@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
@@ -99,6 +100,24 @@ func (o *ObjectNode) putBucketPolicyHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// The public policy is rejected if it is blocked by the public access block settings.
+	var block *proto.PublicAccessBlock
+	if block, err = o.publicAccessBlock(vol); err != nil {
+		log.LogErrorf("putBucketPolicyHandler: load public access block fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if block.BlockPublicPolicy {
+		var newPolicy = &Policy{}
+		if err = json.Unmarshal(bytes, newPolicy); err == nil && newPolicy.IsPublic() {
+			log.LogWarnf("putBucketPolicyHandler: public policy blocked: requestID(%v) volume(%v)",
+				GetRequestID(r), vol.Name())
+			_ = PublicAccessBlocked.ServeResponse(w, r)
+			return
+		}
+	}
+
 	var policy *Policy
 	policy, err = storeBucketPolicy(bytes, vol)
 	if err != nil {
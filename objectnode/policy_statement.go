@@ -62,6 +62,23 @@ func (s Statement) IsAllowed(p *RequestParam) bool {
 	return checked
 }
 
+// IsPublic returns whether the statement allows everyone without any condition.
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/access-control-block-public-access.html#access-control-block-public-access-policy-status
+func (s Statement) IsPublic() bool {
+	if s.Effect != Allow || len(s.Condition) > 0 {
+		return false
+	}
+	if len(s.Principal) == 0 {
+		return true
+	}
+	for _, principal := range s.Principal {
+		if principal.Contains("*") {
+			return true
+		}
+	}
+	return false
+}
+
 type CheckFuncs func(p *RequestParam) bool
 
 func (s Statement) check(p *RequestParam) bool {
@@ -0,0 +1,332 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	PostFormFieldKey                   = "key"
+	PostFormFieldPolicy                = "policy"
+	PostFormFieldFile                  = "file"
+	PostFormFieldTagging               = "tagging"
+	PostFormFieldSuccessActionStatus   = "success_action_status"
+	PostFormFieldSuccessActionRedirect = "success_action_redirect"
+
+	postFormFileNameVariable = "${filename}"
+
+	// The maximum total size of the form fields before the file.
+	maxPostFormSize = 1 << 20
+)
+
+var errPostFormTooLarge = errors.New("form fields exceed the maximum allowed size")
+
+type PostResponse struct {
+	XMLName  xml.Name `xml:"PostResponse"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+// parsePostForm reads the form fields until the file field. The names of the fields are converted
+// to lower case. The fields after the file field are ignored as Amazon S3 does.
+func parsePostForm(reader *multipart.Reader) (form map[string]string, file *multipart.Part, err error) {
+	form = make(map[string]string)
+	var remaining int64 = maxPostFormSize
+	for {
+		var part *multipart.Part
+		if part, err = reader.NextPart(); err == io.EOF {
+			return form, nil, nil
+		}
+		if err != nil {
+			return
+		}
+		var name = strings.ToLower(part.FormName())
+		if name == "" {
+			continue
+		}
+		if name == PostFormFieldFile {
+			return form, part, nil
+		}
+		var value []byte
+		if value, err = ioutil.ReadAll(io.LimitReader(part, remaining+1)); err != nil {
+			return
+		}
+		if remaining -= int64(len(value)); remaining < 0 {
+			return nil, nil, errPostFormTooLarge
+		}
+		form[name] = string(value)
+	}
+}
+
+// Post object
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html
+//
+// The request is not authenticated by the auth middleware. The signature of the policy in the form
+// is verified here, and then the policy check is performed as a put object request.
+func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("postObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var reader *multipart.Reader
+	if reader, err = r.MultipartReader(); err != nil {
+		errorCode = MalformedPOSTRequest
+		return
+	}
+	var form map[string]string
+	var file *multipart.Part
+	if form, file, err = parsePostForm(reader); err != nil {
+		log.LogWarnf("postObjectHandler: parse form fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		errorCode = MalformedPOSTRequest
+		return
+	}
+	if file == nil {
+		errorCode = IncorrectNumberOfFilesInPostRequest
+		return
+	}
+	// The bucket is checked by the conditions of the policy as a form field.
+	form["bucket"] = vol.Name()
+
+	var key = strings.Replace(form[PostFormFieldKey], postFormFileNameVariable, file.FileName(), -1)
+	if key == "" {
+		errorCode = InvalidKey
+		return
+	}
+
+	if form[PostFormFieldPolicy] == "" {
+		errorCode = AccessDenied
+		return
+	}
+	var accessKey string
+	var pass bool
+	if accessKey, pass, err = o.validatePostPolicySignatureV4(r, form); err != nil {
+		if err == proto.ErrVolNotExists {
+			errorCode = NoSuchBucket
+			return
+		}
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if !pass {
+		log.LogWarnf("postObjectHandler: signature does not match: requestID(%v) volume(%v) accessKey(%v)",
+			GetRequestID(r), vol.Name(), accessKey)
+		errorCode = SignatureDoesNotMatch
+		return
+	}
+
+	var policy *PostPolicy
+	var policyBytes []byte
+	if policyBytes, err = base64.StdEncoding.DecodeString(form[PostFormFieldPolicy]); err != nil {
+		errorCode = InvalidPolicyDocument
+		return
+	}
+	if policy, err = parsePostPolicy(policyBytes); err != nil {
+		log.LogWarnf("postObjectHandler: parse policy fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		errorCode = InvalidPolicyDocument
+		return
+	}
+	if policy.isExpired() {
+		errorCode = PostPolicyExpired
+		return
+	}
+	if err = policy.checkForm(form); err != nil {
+		log.LogWarnf("postObjectHandler: check policy fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		errorCode = PostPolicyConditionFailed
+		return
+	}
+
+	// The user policy, bucket policy and ACL are checked as a put object request of the key.
+	mux.Vars(r)["object"] = key
+	SetRequestAccessKey(r, accessKey)
+	SetRequestAction(r, proto.OSSPutObjectAction)
+	o.policyCheck(func(w http.ResponseWriter, r *http.Request) {
+		errorCode = o.postObject(w, r, vol, key, form, file, policy)
+	})(w, r)
+}
+
+func (o *ObjectNode) postObject(w http.ResponseWriter, r *http.Request, vol *Volume, key string,
+	form map[string]string, file *multipart.Part, policy *PostPolicy) *ErrorCode {
+	var err error
+	var errorCode *ErrorCode
+
+	// The form fields specify the metadata, encryption and object lock of the object as the
+	// request headers of the put object request do.
+	for name, value := range form {
+		if strings.HasPrefix(name, HeaderNameXAmzMetaPrefix) ||
+			strings.HasPrefix(name, strings.ToLower(HeaderNameXAmzServerSideEncryption)) ||
+			strings.HasPrefix(name, "x-amz-object-lock-") {
+			r.Header.Set(name, value)
+		}
+	}
+
+	var tagging *Tagging
+	if taggingXML := form[PostFormFieldTagging]; taggingXML != "" {
+		tagging = NewTagging()
+		if err = xml.Unmarshal([]byte(taggingXML), tagging); err != nil {
+			return InvalidArgument
+		}
+		var validateRes bool
+		if validateRes, errorCode = tagging.Validate(); !validateRes {
+			return errorCode
+		}
+	}
+
+	var contentType = form[strings.ToLower(HeaderNameContentType)]
+	if contentType == "" {
+		contentType = file.Header.Get(HeaderNameContentType)
+	}
+	var cacheControl = form[strings.ToLower(HeaderNameCacheControl)]
+	if len(cacheControl) > 0 && !ValidateCacheControl(cacheControl) {
+		return InvalidCacheArgument
+	}
+	var expires = form[strings.ToLower(HeaderNameExpires)]
+	if len(expires) > 0 && !ValidateCacheExpires(expires) {
+		return InvalidCacheArgument
+	}
+
+	// Audit file write
+	log.LogInfof("Audit: post object: requestID(%v) remote(%v) volume(%v) path(%v) type(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), key, contentType)
+
+	var opt = &PutFileOption{
+		MIMEType:     contentType,
+		Disposition:  form[strings.ToLower(HeaderNameContentDisposition)],
+		Tagging:      tagging,
+		Metadata:     ParseUserDefinedMetadata(r.Header),
+		CacheControl: cacheControl,
+		Expires:      expires,
+	}
+	if opt.Encryption, errorCode = parseSSEOption(r, vol); errorCode != nil {
+		return errorCode
+	}
+	if errorCode = parseObjectLockOption(r, vol, opt); errorCode != nil {
+		return errorCode
+	}
+	if errorCode = parseReplicationOption(r, vol, key, opt); errorCode != nil {
+		return errorCode
+	}
+
+	var fsFileInfo *FSFileInfo
+	fsFileInfo, err = vol.PutObject(key, policy.newContentReader(file), opt)
+	switch {
+	case err == errEntityTooSmall:
+		return EntityTooSmall
+	case err == errEntityTooLarge:
+		return EntityTooLarge
+	case err == syscall.EINVAL:
+		return ObjectModeConflict
+	case sseErrorCode(err) != nil:
+		return sseErrorCode(err)
+	case err == io.ErrUnexpectedEOF:
+		log.LogWarnf("postObject: post object fail cause unexpected EOF: requestID(%v) volume(%v) path(%v) remote(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, getRequestIP(r), err)
+		return MalformedPOSTRequest
+	case err != nil:
+		log.LogErrorf("postObject: post object fail: requestID(%v) volume(%v) path(%v) remote(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, getRequestIP(r), err)
+		return InternalErrorCode(err)
+	}
+
+	o.submitReplication(vol, key, opt.ReplicationStatus)
+	o.notifyEvent(r, vol, EventObjectCreatedPost, EventObject{Key: key, Size: fsFileInfo.Size,
+		ETag: fsFileInfo.ETag, VersionID: fsFileInfo.VersionID})
+
+	var etag = wrapUnescapedQuot(fsFileInfo.ETag)
+	w.Header()[HeaderNameETag] = []string{etag}
+	if len(fsFileInfo.VersionID) > 0 {
+		w.Header()[HeaderNameXAmzVersionId] = []string{fsFileInfo.VersionID}
+	}
+	setSSEResponseHeaders(w, fsFileInfo)
+	var location = postObjectLocation(r, vol.Name(), key)
+	w.Header()[HeaderNameLocation] = []string{location}
+
+	// The client is redirected to the URL with the bucket, key and ETag of the object in the query.
+	if redirect, parseErr := url.Parse(form[PostFormFieldSuccessActionRedirect]); parseErr == nil && redirect.IsAbs() {
+		var query = redirect.Query()
+		query.Set("bucket", vol.Name())
+		query.Set("key", key)
+		query.Set("etag", etag)
+		redirect.RawQuery = query.Encode()
+		w.Header()[HeaderNameLocation] = []string{redirect.String()}
+		w.WriteHeader(http.StatusSeeOther)
+		return nil
+	}
+
+	switch form[PostFormFieldSuccessActionStatus] {
+	case strconv.Itoa(http.StatusOK):
+		w.WriteHeader(http.StatusOK)
+	case strconv.Itoa(http.StatusCreated):
+		var data []byte
+		if data, err = MarshalXMLEntity(&PostResponse{Location: location, Bucket: vol.Name(), Key: key, ETag: etag}); err != nil {
+			return InternalErrorCode(err)
+		}
+		w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+		w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return nil
+}
+
+// postObjectLocation returns the URL of the object in the same style as the request.
+func postObjectLocation(r *http.Request, bucket, key string) string {
+	var location = &url.URL{Scheme: "http", Host: r.Host, Path: "/" + key}
+	if r.TLS != nil {
+		location.Scheme = "https"
+	}
+	if strings.HasPrefix(r.URL.Path, "/"+bucket) {
+		location.Path = "/" + bucket + "/" + key
+	}
+	return location.String()
+}
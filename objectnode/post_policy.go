@@ -0,0 +1,213 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	PostPolicyConditionEq                 = "eq"
+	PostPolicyConditionStartsWith         = "starts-with"
+	PostPolicyConditionContentLengthRange = "content-length-range"
+
+	postFormFieldIgnorePrefix = "x-ignore-"
+)
+
+var (
+	errEntityTooSmall = errors.New("entity is smaller than the minimum allowed size")
+	errEntityTooLarge = errors.New("entity exceeds the maximum allowed size")
+
+	// The form fields which need not to appear in the conditions of the policy.
+	postPolicyExemptFields = map[string]struct{}{
+		"policy":                       {},
+		"file":                         {},
+		strings.ToLower(XAmzSignature): {},
+	}
+)
+
+type postPolicyCondition struct {
+	operator string
+	field    string
+	value    string
+}
+
+// PostPolicy is the policy document of the POST Object request, which describes what is permitted
+// in the form of the request.
+type PostPolicy struct {
+	Expiration     time.Time
+	conditions     []*postPolicyCondition
+	hasLengthRange bool
+	minLength      int64
+	maxLength      int64
+}
+
+// parsePostPolicy parses the policy document, such as:
+//
+//	{
+//		"expiration": "2007-12-01T12:00:00.000Z",
+//		"conditions": [
+//			{"bucket": "johnsmith"},
+//			["starts-with", "$key", "user/eric/"],
+//			["content-length-range", 1, 10485760]
+//		]
+//	}
+func parsePostPolicy(data []byte) (policy *PostPolicy, err error) {
+	var raw = struct {
+		Expiration string        `json:"expiration"`
+		Conditions []interface{} `json:"conditions"`
+	}{}
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&raw); err != nil {
+		return
+	}
+	policy = &PostPolicy{}
+	if policy.Expiration, err = time.Parse(time.RFC3339Nano, raw.Expiration); err != nil {
+		return nil, errors.NewErrorf("invalid policy expiration: expiration(%v)", raw.Expiration)
+	}
+	for _, rawCondition := range raw.Conditions {
+		switch condition := rawCondition.(type) {
+		case map[string]interface{}:
+			// An exact match condition, such as {"acl": "public-read"}.
+			for field, value := range condition {
+				var str, ok = value.(string)
+				if !ok {
+					return nil, errors.NewErrorf("invalid policy condition: condition(%v)", condition)
+				}
+				policy.conditions = append(policy.conditions, &postPolicyCondition{
+					operator: PostPolicyConditionEq,
+					field:    strings.ToLower(strings.TrimPrefix(field, "$")),
+					value:    str,
+				})
+			}
+		case []interface{}:
+			if len(condition) != 3 {
+				return nil, errors.NewErrorf("invalid policy condition: condition(%v)", condition)
+			}
+			var operator, _ = condition[0].(string)
+			switch operator = strings.ToLower(operator); operator {
+			case PostPolicyConditionContentLengthRange:
+				var min, minErr = parsePostPolicyInt(condition[1])
+				var max, maxErr = parsePostPolicyInt(condition[2])
+				if minErr != nil || maxErr != nil || min < 0 || min > max {
+					return nil, errors.NewErrorf("invalid policy condition: condition(%v)", condition)
+				}
+				policy.hasLengthRange = true
+				policy.minLength, policy.maxLength = min, max
+			case PostPolicyConditionEq, PostPolicyConditionStartsWith:
+				var field, _ = condition[1].(string)
+				var value, ok = condition[2].(string)
+				if !strings.HasPrefix(field, "$") || !ok {
+					return nil, errors.NewErrorf("invalid policy condition: condition(%v)", condition)
+				}
+				policy.conditions = append(policy.conditions, &postPolicyCondition{
+					operator: operator,
+					field:    strings.ToLower(strings.TrimPrefix(field, "$")),
+					value:    value,
+				})
+			default:
+				return nil, errors.NewErrorf("unsupported policy condition: condition(%v)", condition)
+			}
+		default:
+			return nil, errors.NewErrorf("invalid policy condition: condition(%v)", condition)
+		}
+	}
+	return
+}
+
+func parsePostPolicyInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Int64()
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, errors.NewErrorf("invalid integer: value(%v)", value)
+	}
+}
+
+func (policy *PostPolicy) isExpired() bool {
+	return time.Now().After(policy.Expiration)
+}
+
+// checkForm checks whether the fields of the form meet the conditions of the policy. The names of
+// the form fields are in lower case. Each form field except the exempt ones must appear in the
+// conditions of the policy.
+func (policy *PostPolicy) checkForm(form map[string]string) error {
+	var conditionFields = make(map[string]struct{})
+	for _, condition := range policy.conditions {
+		var value = form[condition.field]
+		switch condition.operator {
+		case PostPolicyConditionEq:
+			if value != condition.value {
+				return errors.NewErrorf("policy condition failed: condition(%v %v %v)",
+					condition.operator, condition.field, condition.value)
+			}
+		case PostPolicyConditionStartsWith:
+			if !strings.HasPrefix(value, condition.value) {
+				return errors.NewErrorf("policy condition failed: condition(%v %v %v)",
+					condition.operator, condition.field, condition.value)
+			}
+		}
+		conditionFields[condition.field] = struct{}{}
+	}
+	for field := range form {
+		if _, exempt := postPolicyExemptFields[field]; exempt || strings.HasPrefix(field, postFormFieldIgnorePrefix) {
+			continue
+		}
+		if _, exist := conditionFields[field]; !exist {
+			return errors.NewErrorf("form field is not specified in the policy: field(%v)", field)
+		}
+	}
+	return nil
+}
+
+// lengthRangeReader fails the upload if the size of the content is out of the content-length-range
+// of the policy. The content is checked before the object is committed.
+type lengthRangeReader struct {
+	reader io.Reader
+	min    int64
+	max    int64
+	n      int64
+}
+
+func (r *lengthRangeReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.n += int64(n)
+	if r.n > r.max {
+		return n, errEntityTooLarge
+	}
+	if err == io.EOF && r.n < r.min {
+		return n, errEntityTooSmall
+	}
+	return
+}
+
+func (policy *PostPolicy) newContentReader(reader io.Reader) io.Reader {
+	if !policy.hasLengthRange {
+		return reader
+	}
+	return &lengthRangeReader{reader: reader, min: policy.minLength, max: policy.maxLength}
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/access-control-block-public-access.html
+
+import (
+	"encoding/xml"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	updatePublicAccessBlockInterval = time.Minute
+)
+
+// PublicAccessBlockConfiguration is the public access block configuration of the bucket.
+//   - BlockPublicAcls rejects the requests which put public ACLs.
+//   - IgnorePublicAcls ignores the grants to all users in the ACLs.
+//   - BlockPublicPolicy rejects the requests which put public bucket policies.
+//   - RestrictPublicBuckets ignores the statements of the bucket policy which allow everyone.
+type PublicAccessBlockConfiguration struct {
+	XMLName               xml.Name `xml:"PublicAccessBlockConfiguration" json:"-"`
+	Xmlns                 string   `xml:"xmlns,attr,omitempty" json:"-"`
+	BlockPublicAcls       bool     `xml:"BlockPublicAcls" json:"block_public_acls"`
+	IgnorePublicAcls      bool     `xml:"IgnorePublicAcls" json:"ignore_public_acls"`
+	BlockPublicPolicy     bool     `xml:"BlockPublicPolicy" json:"block_public_policy"`
+	RestrictPublicBuckets bool     `xml:"RestrictPublicBuckets" json:"restrict_public_buckets"`
+}
+
+func (c *PublicAccessBlockConfiguration) settings() *proto.PublicAccessBlock {
+	if c == nil {
+		return nil
+	}
+	return &proto.PublicAccessBlock{
+		BlockPublicAcls:       c.BlockPublicAcls,
+		IgnorePublicAcls:      c.IgnorePublicAcls,
+		BlockPublicPolicy:     c.BlockPublicPolicy,
+		RestrictPublicBuckets: c.RestrictPublicBuckets,
+	}
+}
+
+func parsePublicAccessBlockConfig(bytes []byte) (configuration *PublicAccessBlockConfiguration, err error) {
+	configuration = &PublicAccessBlockConfiguration{}
+	if err = xml.Unmarshal(bytes, configuration); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func storeBucketPublicAccessBlock(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSAccessBlock, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketPublicAccessBlock(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSAccessBlock); err != nil {
+		return
+	}
+	return nil
+}
+
+// ClusterPublicAccessBlock keeps the cluster-wide public access block settings, which are set
+// through the master and apply to all buckets. The settings are reloaded from the master
+// periodically, and the last loaded settings are kept if the master can not be reached.
+type ClusterPublicAccessBlock struct {
+	mc        *master.MasterClient
+	value     atomic.Value // *proto.PublicAccessBlock
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func NewClusterPublicAccessBlock(mc *master.MasterClient) *ClusterPublicAccessBlock {
+	var c = &ClusterPublicAccessBlock{
+		mc:      mc,
+		closeCh: make(chan struct{}),
+	}
+	c.value.Store((*proto.PublicAccessBlock)(nil))
+	return c
+}
+
+func (c *ClusterPublicAccessBlock) Start() {
+	go c.scheduleUpdate()
+}
+
+func (c *ClusterPublicAccessBlock) Stop() {
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+	})
+}
+
+// Load returns the cluster-wide settings, or nil if there are no cluster-wide settings.
+func (c *ClusterPublicAccessBlock) Load() *proto.PublicAccessBlock {
+	return c.value.Load().(*proto.PublicAccessBlock)
+}
+
+func (c *ClusterPublicAccessBlock) store(block *proto.PublicAccessBlock) {
+	c.value.Store(block)
+}
+
+func (c *ClusterPublicAccessBlock) scheduleUpdate() {
+	var t = time.NewTicker(updatePublicAccessBlockInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-c.closeCh:
+			return
+		}
+		var ci, err = c.mc.AdminAPI().GetClusterInfo()
+		if err != nil {
+			log.LogWarnf("scheduleUpdate: get cluster info fail: err(%v)", err)
+			continue
+		}
+		c.store(ci.PublicAccessBlock)
+	}
+}
+
+// publicAccessBlock returns the public access block settings which apply to the bucket. Each
+// setting is turned on if it is turned on either for the bucket or for the cluster.
+func (o *ObjectNode) publicAccessBlock(vol *Volume) (block *proto.PublicAccessBlock, err error) {
+	var configuration *PublicAccessBlockConfiguration
+	if configuration, err = vol.metaLoader.loadPublicAccessBlock(); err != nil {
+		return
+	}
+	var cluster *proto.PublicAccessBlock
+	if o.clusterBlock != nil {
+		cluster = o.clusterBlock.Load()
+	}
+	return cluster.Merge(configuration.settings()), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestParsePublicAccessBlockConfig(t *testing.T) {
+	var configuration, err = parsePublicAccessBlockConfig([]byte(`
+<PublicAccessBlockConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<BlockPublicAcls>true</BlockPublicAcls>
+	<IgnorePublicAcls>false</IgnorePublicAcls>
+	<RestrictPublicBuckets>true</RestrictPublicBuckets>
+</PublicAccessBlockConfiguration>`))
+	if err != nil {
+		t.Fatalf("parse public access block fail: err(%v)", err)
+	}
+	var expect = proto.PublicAccessBlock{BlockPublicAcls: true, RestrictPublicBuckets: true}
+	if *configuration.settings() != expect {
+		t.Fatalf("parsed public access block mismatch: expect(%v) actual(%v)", expect, *configuration.settings())
+	}
+	if _, err = parsePublicAccessBlockConfig([]byte(`<PublicAccessBlockConfiguration><BlockPublicAcls>yes`)); err == nil {
+		t.Fatalf("malformed public access block should be rejected")
+	}
+}
+
+func TestMergePublicAccessBlock(t *testing.T) {
+	var cluster = &proto.PublicAccessBlock{BlockPublicPolicy: true}
+	var bucket = &PublicAccessBlockConfiguration{IgnorePublicAcls: true}
+	var expect = proto.PublicAccessBlock{IgnorePublicAcls: true, BlockPublicPolicy: true}
+	if merged := cluster.Merge(bucket.settings()); *merged != expect {
+		t.Fatalf("merged settings mismatch: expect(%v) actual(%v)", expect, *merged)
+	}
+	// The bucket can not turn off the cluster-wide settings.
+	bucket = &PublicAccessBlockConfiguration{}
+	if merged := cluster.Merge(bucket.settings()); !merged.BlockPublicPolicy {
+		t.Fatalf("cluster-wide settings should not be turned off by the bucket: merged(%v)", *merged)
+	}
+	var none *proto.PublicAccessBlock
+	if merged := none.Merge(nil); !merged.IsEmpty() {
+		t.Fatalf("merged settings should be empty: merged(%v)", *merged)
+	}
+}
+
+func TestPolicyRestrictPublic(t *testing.T) {
+	var policy = &Policy{}
+	var err = json.Unmarshal([]byte(`{
+	"Version": "2012-10-17",
+	"Statement": [
+		{"Effect": "Allow", "Principal": {"AWS": ["*"]}},
+		{"Effect": "Allow", "Principal": {"AWS": ["ak1"]}}
+	]}`), policy)
+	if err != nil {
+		t.Fatalf("unmarshal policy fail: err(%v)", err)
+	}
+	if !policy.IsPublic() {
+		t.Fatalf("policy allowing everyone should be public")
+	}
+	var param = &RequestParam{action: proto.OSSGetObjectAction, resource: "bucket/a", accessKey: "ak2"}
+	if !policy.IsAllowed(param, false, false) {
+		t.Fatalf("public policy should allow everyone")
+	}
+	if policy.IsAllowed(param, false, true) {
+		t.Fatalf("public statements should be ignored when public buckets are restricted")
+	}
+	param.accessKey = "ak1"
+	if !policy.IsAllowed(param, false, true) {
+		t.Fatalf("non-public statements should not be ignored when public buckets are restricted")
+	}
+
+	// The statement with conditions is not public.
+	policy = &Policy{}
+	err = json.Unmarshal([]byte(`{
+	"Version": "2012-10-17",
+	"Statement": [{
+		"Effect": "Allow",
+		"Principal": {"AWS": ["*"]},
+		"Condition": {"IpAddress": {"aws:SourceIp": "192.168.0.0/16"}}
+	}]}`), policy)
+	if err != nil {
+		t.Fatalf("unmarshal policy fail: err(%v)", err)
+	}
+	if policy.IsPublic() {
+		t.Fatalf("policy with conditions should not be public")
+	}
+}
+
+func TestACLIgnorePublic(t *testing.T) {
+	var acl = &AccessControlPolicy{}
+	acl.Acl.Grants = []Grant{
+		{Grantee: Grantee{URI: aclRoleURIMap[allUsersRole]}, Permission: ReadPermission},
+		{Grantee: Grantee{Id: "ak1"}, Permission: ReadPermission},
+	}
+	if !acl.IsPublic() {
+		t.Fatalf("ACL granting all users should be public")
+	}
+	var param = &RequestParam{action: proto.OSSListObjectsAction, accessKey: "ak2"}
+	if !acl.IsAllowed(param, false, false) {
+		t.Fatalf("public ACL should allow all users")
+	}
+	if acl.IsAllowed(param, false, true) {
+		t.Fatalf("public grants should be ignored")
+	}
+	param.accessKey = "ak1"
+	if !acl.IsAllowed(param, false, true) {
+		t.Fatalf("non-public grants should not be ignored")
+	}
+}
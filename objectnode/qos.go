@@ -0,0 +1,232 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+)
+
+const (
+	qosCleanupInterval    = time.Minute
+	qosLimiterIdleTimeout = 10 * time.Minute
+	qosMinBandwidthBurst  = 64 * 1024 // bytes
+
+	qosUserKeyPrefix   = "user:"
+	qosBucketKeyPrefix = "bucket:"
+)
+
+// qosLimiter holds the rate limiters built from the QoS limit of a user or a volume. The QPS
+// limits are enforced by rejecting the requests, and the bandwidth limits are enforced by
+// slowing down the reading of request bodies and the writing of response bodies.
+type qosLimiter struct {
+	limit    *proto.QoSLimit
+	qps      *rate.Limiter
+	read     *rate.Limiter
+	write    *rate.Limiter
+	actions  map[string]*rate.Limiter // mapping: action name -> QPS limiter
+	lastUsed int64                    // unix time in seconds
+}
+
+func newQPSLimiter(qps uint64) *rate.Limiter {
+	if qps == 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(qps), int(qps))
+}
+
+func newBandwidthLimiter(bandwidth uint64) *rate.Limiter {
+	if bandwidth == 0 {
+		return nil
+	}
+	var burst = int(bandwidth)
+	if burst < qosMinBandwidthBurst {
+		burst = qosMinBandwidthBurst
+	}
+	return rate.NewLimiter(rate.Limit(bandwidth), burst)
+}
+
+func newQoSLimiter(limit *proto.QoSLimit) *qosLimiter {
+	var l = &qosLimiter{
+		limit:    limit,
+		qps:      newQPSLimiter(limit.QPS),
+		read:     newBandwidthLimiter(limit.ReadBandwidth),
+		write:    newBandwidthLimiter(limit.WriteBandwidth),
+		actions:  make(map[string]*rate.Limiter),
+		lastUsed: time.Now().Unix(),
+	}
+	for name, qps := range limit.ActionQPS {
+		if limiter := newQPSLimiter(qps); limiter != nil {
+			l.actions[name] = limiter
+		}
+	}
+	return l
+}
+
+// allow reports whether a request of the action is allowed by the QPS limits.
+func (l *qosLimiter) allow(action string) bool {
+	atomic.StoreInt64(&l.lastUsed, time.Now().Unix())
+	if l.qps != nil && !l.qps.Allow() {
+		return false
+	}
+	if limiter, exist := l.actions[action]; exist && !limiter.Allow() {
+		return false
+	}
+	return true
+}
+
+// QoSManager holds the rate limiters of the users and volumes which have QoS limits. The limiters
+// are rebuilt when the limits change, and released when they are not used for a while.
+type QoSManager struct {
+	limiters  sync.Map // mapping: user or bucket key -> *qosLimiter
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewQoSManager() *QoSManager {
+	var m = &QoSManager{
+		closeCh: make(chan struct{}),
+	}
+	go m.scheduleCleanup()
+	return m
+}
+
+// limiter returns the rate limiter of the key for the current limit, or nil if there is no limit.
+func (m *QoSManager) limiter(key string, limit *proto.QoSLimit) *qosLimiter {
+	if limit.IsEmpty() {
+		m.limiters.Delete(key)
+		return nil
+	}
+	if value, exist := m.limiters.Load(key); exist {
+		var l = value.(*qosLimiter)
+		if l.limit == limit || reflect.DeepEqual(l.limit, limit) {
+			return l
+		}
+	}
+	var l = newQoSLimiter(limit)
+	m.limiters.Store(key, l)
+	return l
+}
+
+func (m *QoSManager) scheduleCleanup() {
+	var t = time.NewTicker(qosCleanupInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-m.closeCh:
+			return
+		}
+		var expired = time.Now().Add(-qosLimiterIdleTimeout).Unix()
+		m.limiters.Range(func(key, value interface{}) bool {
+			if atomic.LoadInt64(&value.(*qosLimiter).lastUsed) < expired {
+				m.limiters.Delete(key)
+			}
+			return true
+		})
+	}
+}
+
+func (m *QoSManager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.closeCh)
+	})
+}
+
+// waitBandwidth waits until the bytes are allowed by all the bandwidth limiters. The bytes are
+// split by the burst of the limiter, which is the most bytes a single wait allows.
+func waitBandwidth(ctx context.Context, limiters []*rate.Limiter, n int) error {
+	for _, limiter := range limiters {
+		for remain := n; remain > 0; {
+			var size = remain
+			if size > limiter.Burst() {
+				size = limiter.Burst()
+			}
+			if err := limiter.WaitN(ctx, size); err != nil {
+				return err
+			}
+			remain -= size
+		}
+	}
+	return nil
+}
+
+// qosReader limits the bandwidth of reading the request body.
+type qosReader struct {
+	ctx      context.Context
+	reader   io.ReadCloser
+	limiters []*rate.Limiter
+}
+
+func (r *qosReader) Read(p []byte) (n int, err error) {
+	if n, err = r.reader.Read(p); n > 0 {
+		if waitErr := waitBandwidth(r.ctx, r.limiters, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return
+}
+
+func (r *qosReader) Close() error {
+	return r.reader.Close()
+}
+
+// qosResponseWriter limits the bandwidth of writing the response body.
+type qosResponseWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	limiters []*rate.Limiter
+}
+
+func (w *qosResponseWriter) Write(p []byte) (int, error) {
+	if err := waitBandwidth(w.ctx, w.limiters, len(p)); err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *qosResponseWriter) Flush() {
+	if flusher, is := w.ResponseWriter.(http.Flusher); is {
+		flusher.Flush()
+	}
+}
+
+// qosLimiters returns the rate limiters of the user and the volume of the request.
+func (o *ObjectNode) qosLimiters(r *http.Request) (limiters []*qosLimiter) {
+	if accessKey := parseRequestAuthInfo(r).accessKey; accessKey != "" {
+		if userInfo, err := o.getUserInfoByAccessKey(accessKey); err == nil {
+			if l := o.qosManager.limiter(qosUserKeyPrefix+accessKey, userInfo.QoS); l != nil {
+				limiters = append(limiters, l)
+			}
+		}
+	}
+	if bucket := mux.Vars(r)["bucket"]; bucket != "" {
+		if volume, err := o.vm.Volume(bucket); err == nil {
+			if l := o.qosManager.limiter(qosBucketKeyPrefix+bucket, volume.QoS()); l != nil {
+				limiters = append(limiters, l)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"golang.org/x/time/rate"
+)
+
+func TestQoSLimiter(t *testing.T) {
+	var m = NewQoSManager()
+	defer m.Close()
+
+	if l := m.limiter("user:ak", nil); l != nil {
+		t.Fatalf("limiter of no limit should be nil")
+	}
+	if l := m.limiter("user:ak", &proto.QoSLimit{ActionQPS: map[string]uint64{"ListObjects": 0}}); l != nil {
+		t.Fatalf("limiter of empty limit should be nil")
+	}
+
+	var limit = &proto.QoSLimit{QPS: 3, ActionQPS: map[string]uint64{"ListObjects": 1}}
+	var l = m.limiter("user:ak", limit)
+	if l == nil {
+		t.Fatalf("limiter should not be nil")
+	}
+	if !l.allow("ListObjects") || l.allow("ListObjects") {
+		t.Fatalf("the second ListObjects should be throttled")
+	}
+	if !l.allow("GetObject") || l.allow("GetObject") {
+		t.Fatalf("requests exceeding the QPS should be throttled")
+	}
+
+	// The limiter is kept for the same limit, and rebuilt when the limit changes.
+	if m.limiter("user:ak", &proto.QoSLimit{QPS: 3, ActionQPS: map[string]uint64{"ListObjects": 1}}) != l {
+		t.Fatalf("limiter should be kept for the same limit")
+	}
+	var rebuilt = m.limiter("user:ak", &proto.QoSLimit{QPS: 10})
+	if rebuilt == l || !rebuilt.allow("ListObjects") || !rebuilt.allow("ListObjects") {
+		t.Fatalf("limiter should be rebuilt for the changed limit")
+	}
+	if m.limiter("user:ak", &proto.QoSLimit{}) != nil {
+		t.Fatalf("limiter should be removed for the empty limit")
+	}
+	if _, exist := m.limiters.Load("user:ak"); exist {
+		t.Fatalf("limiter of the empty limit should be released")
+	}
+}
+
+func TestQoSBandwidth(t *testing.T) {
+	var data = bytes.Repeat([]byte{'a'}, 3*qosMinBandwidthBurst)
+	if burst := newBandwidthLimiter(1024).Burst(); burst != qosMinBandwidthBurst {
+		t.Fatalf("burst of low bandwidth mismatch: burst(%v)", burst)
+	}
+
+	// The read larger than the burst is split instead of failing.
+	var limiters = []*rate.Limiter{newBandwidthLimiter(1024 * 1024 * 1024), rate.NewLimiter(100*1024*1024, qosMinBandwidthBurst)}
+	var reader = &qosReader{ctx: context.Background(), reader: ioutil.NopCloser(bytes.NewReader(data)), limiters: limiters}
+	var read, err = ioutil.ReadAll(reader)
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("read through QoS reader fail: length(%v) err(%v)", len(read), err)
+	}
+
+	var recorder = httptest.NewRecorder()
+	var writer = &qosResponseWriter{ResponseWriter: recorder, ctx: context.Background(), limiters: limiters}
+	if _, err = writer.Write(data); err != nil || !bytes.Equal(recorder.Body.Bytes(), data) {
+		t.Fatalf("write through QoS writer fail: length(%v) err(%v)", recorder.Body.Len(), err)
+	}
+
+	// The wait is interrupted when the request is canceled.
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err = waitBandwidth(ctx, []*rate.Limiter{newBandwidthLimiter(1024)}, 2*qosMinBandwidthBurst); err == nil {
+		t.Fatalf("wait of canceled request should fail")
+	}
+}
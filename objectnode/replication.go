@@ -0,0 +1,218 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/replication.html
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	ReplicationRuleStatusEnabled  = "Enabled"
+	ReplicationRuleStatusDisabled = "Disabled"
+
+	// Replication status of objects, which is exposed by the 'x-amz-replication-status' header.
+	ReplicationStatusPending   = "PENDING"
+	ReplicationStatusCompleted = "COMPLETED"
+	ReplicationStatusFailed    = "FAILED"
+	ReplicationStatusReplica   = "REPLICA"
+
+	maxReplicationRules     = 1000
+	maxReplicationRuleIDLen = 255
+)
+
+type ReplicationConfiguration struct {
+	XMLName xml.Name           `xml:"ReplicationConfiguration" json:"-"`
+	Xmlns   string             `xml:"xmlns,attr,omitempty" json:"-"`
+	Role    string             `xml:"Role,omitempty" json:"role,omitempty"`
+	Rules   []*ReplicationRule `xml:"Rule" json:"rules"`
+}
+
+type ReplicationRule struct {
+	ID       string `xml:"ID,omitempty" json:"id,omitempty"`
+	Priority int    `xml:"Priority,omitempty" json:"priority,omitempty"`
+	Status   string `xml:"Status" json:"status"`
+	Prefix   string `xml:"Prefix,omitempty" json:"prefix,omitempty"` // deprecated, replaced by Filter
+	// The filter has the same form as the filter of lifecycle rules.
+	Filter                  *LifecycleFilter         `xml:"Filter,omitempty" json:"filter,omitempty"`
+	Destination             *ReplicationDestination  `xml:"Destination" json:"destination"`
+	DeleteMarkerReplication *DeleteMarkerReplication `xml:"DeleteMarkerReplication,omitempty" json:"delete_marker_replication,omitempty"`
+}
+
+// The destination bucket is specified by the ARN "arn:aws:s3:::<bucket>" for a bucket on the same
+// cluster, or by "arn:aws:s3:<region>::<bucket>" for a bucket on the cluster named by the region,
+// which must be configured as a replication target of ObjectNode.
+type ReplicationDestination struct {
+	Bucket       string `xml:"Bucket" json:"bucket"`
+	StorageClass string `xml:"StorageClass,omitempty" json:"storage_class,omitempty"`
+}
+
+// Delete markers are not replicated, so only the disabled status is accepted.
+type DeleteMarkerReplication struct {
+	Status string `xml:"Status" json:"status"`
+}
+
+// parseReplicationDestination parses the ARN of the destination bucket. The region is empty
+// if the destination bucket is on the same cluster.
+func parseReplicationDestination(arn string) (region, bucket string, err error) {
+	var items = strings.Split(arn, ArnSplitToken)
+	if len(items) != 6 || items[0] != "arn" || items[1] != "aws" || items[2] != "s3" || items[4] != "" || items[5] == "" {
+		return "", "", errors.New("invalid destination bucket arn")
+	}
+	return items[3], items[5], nil
+}
+
+func (rule *ReplicationRule) validate() bool {
+	if len(rule.ID) > maxReplicationRuleIDLen || rule.Priority < 0 {
+		return false
+	}
+	if rule.Status != ReplicationRuleStatusEnabled && rule.Status != ReplicationRuleStatusDisabled {
+		return false
+	}
+	if rule.Prefix != "" && rule.Filter != nil {
+		return false
+	}
+	if rule.Filter != nil && !rule.Filter.validate() {
+		return false
+	}
+	if rule.Destination == nil {
+		return false
+	}
+	if _, _, err := parseReplicationDestination(rule.Destination.Bucket); err != nil {
+		return false
+	}
+	if rule.DeleteMarkerReplication != nil && rule.DeleteMarkerReplication.Status != ReplicationRuleStatusDisabled {
+		return false
+	}
+	return true
+}
+
+func (rule *ReplicationRule) enabled() bool {
+	return rule.Status == ReplicationRuleStatusEnabled
+}
+
+// prefix returns the key prefix which the rule applies to.
+func (rule *ReplicationRule) prefix() string {
+	if rule.Filter == nil {
+		return rule.Prefix
+	}
+	if rule.Filter.And != nil {
+		return rule.Filter.And.Prefix
+	}
+	return rule.Filter.Prefix
+}
+
+// tags returns the object tags which the rule applies to.
+func (rule *ReplicationRule) tags() []Tag {
+	if rule.Filter == nil {
+		return nil
+	}
+	if rule.Filter.And != nil {
+		return rule.Filter.And.Tags
+	}
+	if rule.Filter.Tag != nil {
+		return []Tag{*rule.Filter.Tag}
+	}
+	return nil
+}
+
+func (config *ReplicationConfiguration) validate() bool {
+	if len(config.Rules) == 0 || len(config.Rules) > maxReplicationRules {
+		return false
+	}
+	var ids = make(map[string]struct{})
+	for _, rule := range config.Rules {
+		if rule == nil || !rule.validate() {
+			return false
+		}
+		if rule.ID == "" {
+			continue
+		}
+		if _, exist := ids[rule.ID]; exist {
+			return false
+		}
+		ids[rule.ID] = struct{}{}
+	}
+	return true
+}
+
+// matchRule returns the enabled rule which applies to the object, or nil if no rule applies.
+// If several rules apply, the one with the highest priority wins.
+func (config *ReplicationConfiguration) matchRule(key string, tagging *Tagging) (matched *ReplicationRule) {
+	for _, rule := range config.Rules {
+		if !rule.enabled() || !strings.HasPrefix(key, rule.prefix()) || !matchTagSet(rule.tags(), tagging) {
+			continue
+		}
+		if matched == nil || rule.Priority > matched.Priority {
+			matched = rule
+		}
+	}
+	return
+}
+
+// commonPrefix returns the longest prefix shared by all enabled rules, which limits the objects
+// to be scanned by the worker.
+func (config *ReplicationConfiguration) commonPrefix() string {
+	var prefix *string
+	for _, rule := range config.Rules {
+		if !rule.enabled() {
+			continue
+		}
+		var p = rule.prefix()
+		if prefix == nil {
+			prefix = &p
+			continue
+		}
+		var i = 0
+		for i < len(*prefix) && i < len(p) && (*prefix)[i] == p[i] {
+			i++
+		}
+		var common = (*prefix)[:i]
+		prefix = &common
+	}
+	if prefix == nil {
+		return ""
+	}
+	return *prefix
+}
+
+func parseReplicationConfig(bytes []byte) (config *ReplicationConfiguration, err error) {
+	config = &ReplicationConfiguration{}
+	if err = xml.Unmarshal(bytes, config); err != nil {
+		return
+	}
+	if ok := config.validate(); !ok {
+		return nil, errors.New("invalid replication configuration")
+	}
+	return
+}
+
+func storeBucketReplication(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSReplication, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketReplication(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSReplication); err != nil {
+		return
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
+func (o *ObjectNode) getBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var replication *ReplicationConfiguration
+	if replication, err = vol.metaLoader.loadReplication(); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: load replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if replication == nil {
+		_ = ReplicationConfigurationNotFound.ServeResponse(w, r)
+		return
+	}
+
+	var output = *replication
+	output.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
+func (o *ObjectNode) putBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var replication *ReplicationConfiguration
+	if replication, err = parseReplicationConfig(bytes); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: parse replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+	for _, rule := range replication.Rules {
+		if !o.isValidReplicationDestination(vol, rule.Destination) {
+			log.LogWarnf("putBucketReplicationHandler: invalid destination: requestID(%v) volume(%v) rule(%v) destination(%v)",
+				GetRequestID(r), vol.Name(), rule.ID, rule.Destination.Bucket)
+			_ = InvalidReplicationDestination.ServeResponse(w, r)
+			return
+		}
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(replication); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketReplication(newBytes, vol); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: store replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeReplication(replication)
+
+	log.LogInfof("Audit: put bucket replication: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
+func (o *ObjectNode) deleteBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	if err = deleteBucketReplication(vol); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: delete replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeReplication(nil)
+
+	log.LogInfof("Audit: delete bucket replication: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
+
+// isValidReplicationDestination checks whether the destination bucket is reachable. A destination
+// bucket on the same cluster must exist and differ from the source bucket, and a destination bucket
+// on another cluster must belong to a configured replication target.
+func (o *ObjectNode) isValidReplicationDestination(vol *Volume, destination *ReplicationDestination) bool {
+	region, bucket, err := parseReplicationDestination(destination.Bucket)
+	if err != nil {
+		return false
+	}
+	if region != "" {
+		_, exist := o.replTargets[region]
+		return exist
+	}
+	if bucket == vol.Name() {
+		return false
+	}
+	_, err = o.vm.Volume(bucket)
+	return err == nil
+}
+
+// parseReplicationOption decides the replication status of the object to write. The objects written
+// by the replication worker are marked as replicas and never replicated again. Other objects are
+// pending for replication if any enabled replication rule of the bucket applies to them, except the
+// objects encrypted with customer keys, which can not be read by the replication worker.
+func parseReplicationOption(r *http.Request, vol *Volume, key string, opt *PutFileOption) *ErrorCode {
+	if r.Header.Get(HeaderNameXAmzReplicationStatus) == ReplicationStatusReplica {
+		opt.ReplicationStatus = ReplicationStatusReplica
+		return nil
+	}
+	replication, err := vol.metaLoader.loadReplication()
+	if err != nil {
+		log.LogErrorf("parseReplicationOption: load replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return InternalErrorCode(err)
+	}
+	if replication == nil || (opt.Encryption != nil && opt.Encryption.isCustomer()) {
+		return nil
+	}
+	if replication.matchRule(key, opt.Tagging) != nil {
+		opt.ReplicationStatus = ReplicationStatusPending
+	}
+	return nil
+}
+
+// submitReplication submits the written object to the replication worker if it is pending for replication.
+func (o *ObjectNode) submitReplication(vol *Volume, key, status string) {
+	if o.replWorker != nil && status == ReplicationStatusPending {
+		o.replWorker.Submit(vol.Name(), key)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+)
+
+func TestParseReplicationConfig(t *testing.T) {
+	var valid = `<ReplicationConfiguration>
+	<Role>arn:aws:iam::123456789012:role/replication</Role>
+	<Rule>
+		<ID>logs</ID>
+		<Priority>1</Priority>
+		<Status>Enabled</Status>
+		<Filter><Prefix>logs/</Prefix></Filter>
+		<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination>
+		<DeleteMarkerReplication><Status>Disabled</Status></DeleteMarkerReplication>
+	</Rule>
+	<Rule>
+		<ID>dr</ID>
+		<Priority>2</Priority>
+		<Status>Enabled</Status>
+		<Filter><And><Prefix>logs/</Prefix><Tag><Key>dr</Key><Value>true</Value></Tag></And></Filter>
+		<Destination><Bucket>arn:aws:s3:cfs_dr::backup</Bucket></Destination>
+	</Rule>
+</ReplicationConfiguration>`
+	config, err := parseReplicationConfig([]byte(valid))
+	if err != nil {
+		t.Fatalf("parse replication config fail: err(%v)", err)
+	}
+	if len(config.Rules) != 2 || config.Rules[1].prefix() != "logs/" || len(config.Rules[1].tags()) != 1 {
+		t.Fatalf("replication config mismatch: config(%v)", config)
+	}
+
+	var invalids = []string{
+		`<ReplicationConfiguration></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>Enabled</Status></Rule></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>On</Status><Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination></Rule></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>Enabled</Status><Destination><Bucket>backup</Bucket></Destination></Rule></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination><DeleteMarkerReplication><Status>Enabled</Status></DeleteMarkerReplication></Rule></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><ID>a</ID><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::b1</Bucket></Destination></Rule><Rule><ID>a</ID><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::b2</Bucket></Destination></Rule></ReplicationConfiguration>`,
+	}
+	for _, invalid := range invalids {
+		if _, err = parseReplicationConfig([]byte(invalid)); err == nil {
+			t.Fatalf("invalid replication config should be rejected: config(%v)", invalid)
+		}
+	}
+}
+
+func TestParseReplicationDestination(t *testing.T) {
+	region, bucket, err := parseReplicationDestination("arn:aws:s3:::backup")
+	if err != nil || region != "" || bucket != "backup" {
+		t.Fatalf("parse local destination fail: region(%v) bucket(%v) err(%v)", region, bucket, err)
+	}
+	region, bucket, err = parseReplicationDestination("arn:aws:s3:cfs_dr::backup")
+	if err != nil || region != "cfs_dr" || bucket != "backup" {
+		t.Fatalf("parse remote destination fail: region(%v) bucket(%v) err(%v)", region, bucket, err)
+	}
+	for _, invalid := range []string{"backup", "arn:aws:s3:::", "arn:aws:iam:::backup", "arn:aws:s3:cfs_dr:123:backup"} {
+		if _, _, err = parseReplicationDestination(invalid); err == nil {
+			t.Fatalf("invalid destination should be rejected: arn(%v)", invalid)
+		}
+	}
+}
+
+func TestReplicationMatchRule(t *testing.T) {
+	var config = &ReplicationConfiguration{
+		Rules: []*ReplicationRule{
+			{
+				ID:          "all",
+				Priority:    1,
+				Status:      ReplicationRuleStatusEnabled,
+				Destination: &ReplicationDestination{Bucket: "arn:aws:s3:::backup"},
+			},
+			{
+				ID:       "tagged",
+				Priority: 2,
+				Status:   ReplicationRuleStatusEnabled,
+				Filter: &LifecycleFilter{And: &LifecycleAndOperator{
+					Prefix: "logs/",
+					Tags:   []Tag{{Key: "dr", Value: "true"}},
+				}},
+				Destination: &ReplicationDestination{Bucket: "arn:aws:s3:cfs_dr::backup"},
+			},
+			{
+				ID:          "disabled",
+				Priority:    3,
+				Status:      ReplicationRuleStatusDisabled,
+				Prefix:      "logs/",
+				Destination: &ReplicationDestination{Bucket: "arn:aws:s3:::archive"},
+			},
+		},
+	}
+	var tagging = &Tagging{TagSet: []Tag{{Key: "dr", Value: "true"}}}
+	if rule := config.matchRule("logs/a.log", tagging); rule == nil || rule.ID != "tagged" {
+		t.Fatalf("rule with the highest priority should match: rule(%v)", rule)
+	}
+	if rule := config.matchRule("logs/a.log", nil); rule == nil || rule.ID != "all" {
+		t.Fatalf("rule without tags should match: rule(%v)", rule)
+	}
+	if prefix := config.commonPrefix(); prefix != "" {
+		t.Fatalf("common prefix mismatch: prefix(%v)", prefix)
+	}
+
+	config.Rules[0].Status = ReplicationRuleStatusDisabled
+	if rule := config.matchRule("data/a.log", tagging); rule != nil {
+		t.Fatalf("no rule should match: rule(%v)", rule)
+	}
+	if prefix := config.commonPrefix(); prefix != "logs/" {
+		t.Fatalf("common prefix mismatch: prefix(%v)", prefix)
+	}
+}
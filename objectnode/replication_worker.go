@@ -0,0 +1,395 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	defaultReplicationInterval = time.Hour
+
+	replicationQueueSize   = 1024
+	replicationConcurrency = 4
+	replicationMaxRetries  = 3
+	replicationRetryDelay  = time.Second
+)
+
+// ReplicationTarget is another cluster which the objects can be replicated to. The destination
+// bucket of a replication rule refers to the target by its region, which is the cluster name.
+type ReplicationTarget struct {
+	Region    string `json:"region"`
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+
+	client *s3.S3
+}
+
+// LoadReplicationTargets loads the replication targets from the JSON file in the following format:
+//
+//	{
+//		"targets": [
+//			{
+//				"region": "cfs_dr",
+//				"endpoint": "http://object.dr.chubao.io",
+//				"accessKey": "...",
+//				"secretKey": "..."
+//			}
+//		]
+//	}
+func LoadReplicationTargets(filename string) (targets map[string]*ReplicationTarget, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+	var raw = struct {
+		Targets []*ReplicationTarget `json:"targets"`
+	}{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	var sess *session.Session
+	if sess, err = session.NewSession(); err != nil {
+		return
+	}
+	targets = make(map[string]*ReplicationTarget)
+	for _, target := range raw.Targets {
+		if target.Region == "" || target.Endpoint == "" || target.AccessKey == "" || target.SecretKey == "" {
+			return nil, errors.NewErrorf("invalid replication target: region(%v)", target.Region)
+		}
+		if _, exist := targets[target.Region]; exist {
+			return nil, errors.NewErrorf("duplicate replication target: region(%v)", target.Region)
+		}
+		var ac = aws.NewConfig()
+		ac.Endpoint = aws.String(target.Endpoint)
+		ac.Region = aws.String(target.Region)
+		ac.Credentials = credentials.NewStaticCredentials(target.AccessKey, target.SecretKey, "")
+		ac.S3ForcePathStyle = aws.Bool(true)
+		// The object data is streamed and can not be sent again, so the failed requests are not retried
+		// by the client but by the worker with a new stream.
+		ac.MaxRetries = aws.Int(0)
+		ac.S3DisableContentMD5Validation = aws.Bool(true)
+		target.client = s3.New(sess, ac)
+		targets[target.Region] = target
+	}
+	return
+}
+
+type replicationTask struct {
+	volume string
+	path   string
+}
+
+// ReplicationWorker copies the objects of buckets with a replication configuration to the destination
+// buckets asynchronously. The objects written through ObjectNode are submitted to the worker right
+// after they are written, and each round the worker also scans all volumes for the objects whose
+// replication is still pending or failed, so that no object is lost if the queue is full or
+// ObjectNode is restarted.
+type ReplicationWorker struct {
+	mc        *master.MasterClient
+	vm        *VolumeManager
+	targets   map[string]*ReplicationTarget
+	interval  time.Duration
+	taskCh    chan *replicationTask
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func (w *ReplicationWorker) Start() {
+	for i := 0; i < replicationConcurrency; i++ {
+		go w.replicateLoop()
+	}
+	go w.run()
+}
+
+func (w *ReplicationWorker) Stop() {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+	})
+}
+
+// Submit queues the object for replication. The object is dropped if the queue is full,
+// and it will be replicated by the next round of the worker.
+func (w *ReplicationWorker) Submit(volume, path string) {
+	select {
+	case w.taskCh <- &replicationTask{volume: volume, path: path}:
+	default:
+		log.LogWarnf("ReplicationWorker: queue is full: volume(%v) path(%v)", volume, path)
+	}
+}
+
+func (w *ReplicationWorker) run() {
+	t := time.NewTimer(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-w.closeCh:
+			return
+		}
+		w.scan()
+		t.Reset(w.interval)
+	}
+}
+
+func (w *ReplicationWorker) replicateLoop() {
+	for {
+		select {
+		case task := <-w.taskCh:
+			w.replicate(task)
+		case <-w.closeCh:
+			return
+		}
+	}
+}
+
+func (w *ReplicationWorker) scan() {
+	var err error
+	var volInfos []*proto.VolInfo
+	if volInfos, err = w.mc.AdminAPI().ListVols(""); err != nil {
+		log.LogErrorf("ReplicationWorker: list volumes fail: err(%v)", err)
+		return
+	}
+	var start = time.Now()
+	for _, volInfo := range volInfos {
+		select {
+		case <-w.closeCh:
+			return
+		default:
+		}
+		w.scanVolume(volInfo.Name)
+	}
+	log.LogInfof("ReplicationWorker: scan finish: volumes(%v) elapsed(%v)", len(volInfos), time.Since(start))
+}
+
+func (w *ReplicationWorker) scanVolume(volName string) {
+	var err error
+	var vol *Volume
+	if vol, err = w.vm.Volume(volName); err != nil {
+		log.LogWarnf("ReplicationWorker: load volume fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	var replication *ReplicationConfiguration
+	if replication, err = vol.metaLoader.loadReplication(); err != nil {
+		log.LogErrorf("ReplicationWorker: load replication fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	if replication == nil {
+		return
+	}
+	var paths []string
+	if paths, err = vol.PendingReplications(replication.commonPrefix()); err != nil {
+		log.LogErrorf("ReplicationWorker: list pending objects fail: volume(%v) err(%v)", volName, err)
+		return
+	}
+	for _, path := range paths {
+		select {
+		case <-w.closeCh:
+			return
+		default:
+		}
+		w.replicate(&replicationTask{volume: volName, path: path})
+	}
+	log.LogInfof("ReplicationWorker: scan volume: volume(%v) pendingObjects(%v)", volName, len(paths))
+}
+
+func (w *ReplicationWorker) replicate(task *replicationTask) {
+	var err error
+	var vol *Volume
+	if vol, err = w.vm.Volume(task.volume); err != nil {
+		log.LogWarnf("ReplicationWorker: load volume fail: volume(%v) err(%v)", task.volume, err)
+		return
+	}
+	var info *FSFileInfo
+	if info, err = vol.ObjectMeta(task.path); err != nil {
+		if err != syscall.ENOENT {
+			log.LogErrorf("ReplicationWorker: get object meta fail: volume(%v) path(%v) err(%v)",
+				task.volume, task.path, err)
+		}
+		return
+	}
+	if info.Mode.IsDir() ||
+		(info.ReplicationStatus != ReplicationStatusPending && info.ReplicationStatus != ReplicationStatusFailed) {
+		return
+	}
+
+	var replication *ReplicationConfiguration
+	if replication, err = vol.metaLoader.loadReplication(); err != nil {
+		log.LogErrorf("ReplicationWorker: load replication fail: volume(%v) err(%v)", task.volume, err)
+		return
+	}
+	var tagging *Tagging
+	if tagging, err = vol.loadObjectTagging(info.Inode); err != nil {
+		log.LogErrorf("ReplicationWorker: load tagging fail: volume(%v) path(%v) inode(%v) err(%v)",
+			task.volume, task.path, info.Inode, err)
+		return
+	}
+	var rule *ReplicationRule
+	if replication != nil {
+		rule = replication.matchRule(task.path, tagging)
+	}
+	if rule == nil {
+		// The replication configuration has been changed or deleted since the object was written.
+		_ = vol.SetReplicationStatus(info.Inode, "")
+		return
+	}
+
+	var status = ReplicationStatusCompleted
+	var start = time.Now()
+	if info.SSEKeyMD5 != "" {
+		// The data encrypted with the customer key can not be read without the key, so the object
+		// is marked as failed and skipped by the later rounds.
+		log.LogWarnf("ReplicationWorker: skip object encrypted with customer key: volume(%v) path(%v) inode(%v)",
+			task.volume, task.path, info.Inode)
+		status = ReplicationStatusFailed
+	} else if err = w.replicateObjectWithRetry(vol, info, tagging, rule.Destination); err != nil {
+		log.LogErrorf("ReplicationWorker: replicate object fail: volume(%v) path(%v) inode(%v) rule(%v) destination(%v) err(%v)",
+			task.volume, task.path, info.Inode, rule.ID, rule.Destination.Bucket, err)
+		status = ReplicationStatusFailed
+	}
+	if err = vol.SetReplicationStatus(info.Inode, status); err != nil {
+		return
+	}
+	log.LogInfof("Audit: replicate object: volume(%v) path(%v) inode(%v) rule(%v) destination(%v) status(%v) elapsed(%v)",
+		task.volume, task.path, info.Inode, rule.ID, rule.Destination.Bucket, status, time.Since(start))
+}
+
+// replicateObjectWithRetry replicates the object, and reads the data again for each retry since
+// the data of the failed attempt has been consumed.
+func (w *ReplicationWorker) replicateObjectWithRetry(vol *Volume, info *FSFileInfo, tagging *Tagging, destination *ReplicationDestination) (err error) {
+	for i := 0; i <= replicationMaxRetries; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(replicationRetryDelay * time.Duration(i)):
+			case <-w.closeCh:
+				return
+			}
+			log.LogWarnf("ReplicationWorker: retry replication: volume(%v) path(%v) inode(%v) retry(%v) err(%v)",
+				vol.Name(), info.Path, info.Inode, i, err)
+		}
+		if err = w.replicateObject(vol, info, tagging, destination); err == nil {
+			return
+		}
+	}
+	return
+}
+
+func (w *ReplicationWorker) replicateObject(vol *Volume, info *FSFileInfo, tagging *Tagging, destination *ReplicationDestination) (err error) {
+	var region, bucket string
+	if region, bucket, err = parseReplicationDestination(destination.Bucket); err != nil {
+		return
+	}
+	// The object data is streamed from the source volume to the destination through the pipe.
+	var reader, writer = io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(vol.ReadInode(info.Path, info.Inode, writer, uint64(info.Size)))
+	}()
+	defer func() {
+		_ = reader.Close()
+	}()
+	if region == "" {
+		return w.replicateLocal(vol, info, tagging, bucket, reader)
+	}
+	return w.replicateRemote(vol, info, tagging, region, bucket, reader)
+}
+
+// replicateLocal copies the object to the destination bucket on the same cluster.
+func (w *ReplicationWorker) replicateLocal(vol *Volume, info *FSFileInfo, tagging *Tagging, bucket string, reader io.Reader) (err error) {
+	var dstVol *Volume
+	if dstVol, err = w.vm.Volume(bucket); err != nil {
+		return
+	}
+	var opt = &PutFileOption{
+		MIMEType:          info.MIMEType,
+		Disposition:       info.Disposition,
+		Tagging:           tagging,
+		Metadata:          info.Metadata,
+		CacheControl:      info.CacheControl,
+		Expires:           info.Expires,
+		ReplicationStatus: ReplicationStatusReplica,
+	}
+	if info.SSEAlgorithm != "" && dstVol.keyStore != nil {
+		opt.Encryption = &SSEOption{Algorithm: SSEAlgorithmAES256}
+	}
+	_, err = dstVol.PutObject(info.Path, reader, opt)
+	return
+}
+
+// replicateRemote copies the object to the destination bucket on the cluster of the replication target
+// through the object storage interface.
+func (w *ReplicationWorker) replicateRemote(vol *Volume, info *FSFileInfo, tagging *Tagging, region, bucket string, reader io.Reader) (err error) {
+	var target, exist = w.targets[region]
+	if !exist {
+		return errors.NewErrorf("replication target not found: region(%v)", region)
+	}
+	var input = &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(info.Path),
+		Body:          aws.ReadSeekCloser(reader),
+		ContentLength: aws.Int64(info.Size),
+	}
+	if info.MIMEType != "" {
+		input.ContentType = aws.String(info.MIMEType)
+	}
+	if info.Disposition != "" {
+		input.ContentDisposition = aws.String(info.Disposition)
+	}
+	if info.CacheControl != "" {
+		input.CacheControl = aws.String(info.CacheControl)
+	}
+	if len(info.Metadata) > 0 {
+		input.Metadata = aws.StringMap(info.Metadata)
+	}
+	if tagging != nil && len(tagging.TagSet) > 0 {
+		input.Tagging = aws.String(tagging.Encode())
+	}
+	if info.SSEAlgorithm != "" {
+		input.ServerSideEncryption = aws.String(SSEAlgorithmAES256)
+	}
+	var req, _ = target.client.PutObjectRequest(input)
+	// The body is not seekable, so the payload is not signed.
+	req.HTTPRequest.Header.Set(XAmzContentSha256, UnsignedPayload)
+	req.HTTPRequest.Header.Set(HeaderNameXAmzReplicationStatus, ReplicationStatusReplica)
+	if info.Expires != "" {
+		req.HTTPRequest.Header.Set(HeaderNameExpires, info.Expires)
+	}
+	return req.Send()
+}
+
+func NewReplicationWorker(mc *master.MasterClient, vm *VolumeManager, targets map[string]*ReplicationTarget, interval time.Duration) *ReplicationWorker {
+	return &ReplicationWorker{
+		mc:       mc,
+		vm:       vm,
+		targets:  targets,
+		interval: interval,
+		taskCh:   make(chan *replicationTask, replicationQueueSize),
+		closeCh:  make(chan struct{}),
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"encoding/xml"
 	"net/http"
 	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
 )
 
 type ErrorCode struct {
@@ -31,9 +33,13 @@ func (code ErrorCode) ServeResponse(w http.ResponseWriter, r *http.Request) erro
 	// traceMiddleWare send exception request to prometheus via status code
 	SetResponseStatusCode(r, code)
 	SetResponseErrorMessage(r, code.ErrorMessage)
+	SetResponseErrorCode(r, code.ErrorCode)
 
 	var err error
 	var marshaled []byte
+	if GetActionFromContext(r) == proto.STSAssumeRoleAction {
+		return code.serveSTSResponse(w, r)
+	}
 	var xmlError = struct {
 		XMLName   xml.Name `xml:"Error"`
 		Code      string   `xml:"Code"`
@@ -57,6 +63,38 @@ func (code ErrorCode) ServeResponse(w http.ResponseWriter, r *http.Request) erro
 	return nil
 }
 
+// serveSTSResponse writes the error in the form of the responses of the security token service,
+// which is different from the one of S3.
+// Reference: https://docs.aws.amazon.com/STS/latest/APIReference/CommonErrors.html
+func (code ErrorCode) serveSTSResponse(w http.ResponseWriter, r *http.Request) error {
+	var errorType = "Sender"
+	if code.StatusCode >= http.StatusInternalServerError {
+		errorType = "Receiver"
+	}
+	var xmlError = struct {
+		XMLName   xml.Name `xml:"ErrorResponse"`
+		Xmlns     string   `xml:"xmlns,attr"`
+		Type      string   `xml:"Error>Type"`
+		Code      string   `xml:"Error>Code"`
+		Message   string   `xml:"Error>Message"`
+		RequestId string   `xml:"RequestId"`
+	}{
+		Xmlns:     STSXmlns,
+		Type:      errorType,
+		Code:      code.ErrorCode,
+		Message:   code.ErrorMessage,
+		RequestId: GetRequestID(r),
+	}
+	var marshaled, err = xml.Marshal(&xmlError)
+	if err != nil {
+		return err
+	}
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.WriteHeader(code.StatusCode)
+	_, err = w.Write(marshaled)
+	return err
+}
+
 func ServeInternalStaticErrorResponse(w http.ResponseWriter, r *http.Request) {
 	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
 	w.WriteHeader(http.StatusInternalServerError)
@@ -104,6 +142,45 @@ var (
 	TagsGreaterThen10                   = &ErrorCode{ErrorCode: "BadRequest", ErrorMessage: "Object tags cannot be greater than 10", StatusCode: http.StatusBadRequest}
 	InvalidTagKey                       = &ErrorCode{ErrorCode: "InvalidTag", ErrorMessage: "The TagKey you have provided is invalid", StatusCode: http.StatusBadRequest}
 	InvalidTagValue                     = &ErrorCode{ErrorCode: "InvalidTag", ErrorMessage: "The TagValue you have provided is invalid", StatusCode: http.StatusBadRequest}
+	NoSuchVersion                       = &ErrorCode{ErrorCode: "NoSuchVersion", ErrorMessage: "The specified version does not exist.", StatusCode: http.StatusNotFound}
+	MethodNotAllowed                    = &ErrorCode{ErrorCode: "MethodNotAllowed", ErrorMessage: "The specified method is not allowed against this resource.", StatusCode: http.StatusMethodNotAllowed}
+	IllegalVersioningConfiguration      = &ErrorCode{ErrorCode: "IllegalVersioningConfigurationException", ErrorMessage: "The versioning configuration specified in the request is invalid.", StatusCode: http.StatusBadRequest}
+	NoSuchLifecycleConfiguration        = &ErrorCode{ErrorCode: "NoSuchLifecycleConfiguration", ErrorMessage: "The lifecycle configuration does not exist.", StatusCode: http.StatusNotFound}
+	MalformedXML                        = &ErrorCode{ErrorCode: "MalformedXML", ErrorMessage: "The XML you provided was not well-formed or did not validate against our published schema.", StatusCode: http.StatusBadRequest}
+	NoSuchEncryptionConfiguration       = &ErrorCode{ErrorCode: "ServerSideEncryptionConfigurationNotFoundError", ErrorMessage: "The server side encryption configuration was not found.", StatusCode: http.StatusNotFound}
+	InvalidEncryptionAlgorithm          = &ErrorCode{ErrorCode: "InvalidEncryptionAlgorithmError", ErrorMessage: "The encryption request you specified is not valid. The valid value is AES256.", StatusCode: http.StatusBadRequest}
+	InvalidSSECustomerKey               = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The secret key or the MD5 of the key specified for server side encryption is invalid.", StatusCode: http.StatusBadRequest}
+	SSECustomerKeyRequired              = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.", StatusCode: http.StatusBadRequest}
+	SSENotConfigured                    = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "Server side encryption with ObjectNode managed keys is not configured.", StatusCode: http.StatusNotImplemented}
+	NoSuchObjectLockConfiguration       = &ErrorCode{ErrorCode: "ObjectLockConfigurationNotFoundError", ErrorMessage: "Object Lock configuration does not exist for this bucket.", StatusCode: http.StatusNotFound}
+	InvalidObjectLockConfiguration      = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Bucket is missing Object Lock Configuration.", StatusCode: http.StatusBadRequest}
+	InvalidRetentionPeriod              = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The retain until date must be in the future.", StatusCode: http.StatusBadRequest}
+	InvalidObjectLockArgument           = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "The object lock mode, retain until date or legal hold status you specified is invalid.", StatusCode: http.StatusBadRequest}
+	ObjectLocked                        = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "The object is protected by Object Lock.", StatusCode: http.StatusForbidden}
+	InvalidBucketState                  = &ErrorCode{ErrorCode: "InvalidBucketState", ErrorMessage: "The request is not valid with the current state of the bucket.", StatusCode: http.StatusConflict}
+	NoSuchWebsiteConfiguration          = &ErrorCode{ErrorCode: "NoSuchWebsiteConfiguration", ErrorMessage: "The specified bucket does not have a website configuration.", StatusCode: http.StatusNotFound}
+	ReplicationConfigurationNotFound    = &ErrorCode{ErrorCode: "ReplicationConfigurationNotFoundError", ErrorMessage: "The replication configuration was not found.", StatusCode: http.StatusNotFound}
+	InvalidReplicationDestination       = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The destination bucket of the replication rule does not exist or is not reachable.", StatusCode: http.StatusBadRequest}
+	InvalidNotificationDestination      = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Unable to validate the following destination configurations.", StatusCode: http.StatusBadRequest}
+	InvalidTargetBucketForLogging       = &ErrorCode{ErrorCode: "InvalidTargetBucketForLogging", ErrorMessage: "The target bucket for logging does not exist or is not owned by you.", StatusCode: http.StatusBadRequest}
+	NoSuchPublicAccessBlock             = &ErrorCode{ErrorCode: "NoSuchPublicAccessBlockConfiguration", ErrorMessage: "The public access block configuration was not found.", StatusCode: http.StatusNotFound}
+	PublicAccessBlocked                 = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "The request is blocked by the public access block settings of the bucket.", StatusCode: http.StatusForbidden}
+	MalformedPOSTRequest                = &ErrorCode{ErrorCode: "MalformedPOSTRequest", ErrorMessage: "The body of your POST request is not well-formed multipart/form-data.", StatusCode: http.StatusBadRequest}
+	InvalidPolicyDocument               = &ErrorCode{ErrorCode: "InvalidPolicyDocument", ErrorMessage: "The content of the form does not meet the conditions specified in the policy document.", StatusCode: http.StatusBadRequest}
+	PostPolicyExpired                   = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "Invalid according to Policy: Policy expired.", StatusCode: http.StatusForbidden}
+	PostPolicyConditionFailed           = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "Invalid according to Policy: Policy Condition failed.", StatusCode: http.StatusForbidden}
+	SignatureDoesNotMatch               = &ErrorCode{ErrorCode: "SignatureDoesNotMatch", ErrorMessage: "The request signature we calculated does not match the signature you provided.", StatusCode: http.StatusForbidden}
+	InvalidSelectRequest                = &ErrorCode{ErrorCode: "InvalidRequestParameter", ErrorMessage: "The value of a parameter in SelectRequest element is invalid.", StatusCode: http.StatusBadRequest}
+	InvalidSelectObjectType             = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "The select request is not supported on a directory.", StatusCode: http.StatusBadRequest}
+	IncompleteBody                      = &ErrorCode{ErrorCode: "IncompleteBody", ErrorMessage: "You did not provide the number of bytes specified by the Content-Length HTTP header.", StatusCode: http.StatusBadRequest}
+	SlowDown                            = &ErrorCode{ErrorCode: "SlowDown", ErrorMessage: "Please reduce your request rate.", StatusCode: http.StatusServiceUnavailable}
+	STSNotConfigured                    = &ErrorCode{ErrorCode: "NotImplemented", ErrorMessage: "The security token service is not configured.", StatusCode: http.StatusNotImplemented}
+	InvalidAction                       = &ErrorCode{ErrorCode: "InvalidAction", ErrorMessage: "The action or operation requested is invalid.", StatusCode: http.StatusBadRequest}
+	STSValidationError                  = &ErrorCode{ErrorCode: "ValidationError", ErrorMessage: "The input fails to satisfy the constraints specified by the security token service.", StatusCode: http.StatusBadRequest}
+	MalformedPolicyDocument             = &ErrorCode{ErrorCode: "MalformedPolicyDocument", ErrorMessage: "The request was rejected because the policy document was malformed.", StatusCode: http.StatusBadRequest}
+	PackedPolicyTooLarge                = &ErrorCode{ErrorCode: "PackedPolicyTooLarge", ErrorMessage: "The request was rejected because the total packed size of the session policies exceeds the limit.", StatusCode: http.StatusBadRequest}
+	ExpiredToken                        = &ErrorCode{ErrorCode: "ExpiredToken", ErrorMessage: "The provided token has expired.", StatusCode: http.StatusBadRequest}
+	InvalidToken                        = &ErrorCode{ErrorCode: "InvalidToken", ErrorMessage: "The provided token is malformed or otherwise invalid.", StatusCode: http.StatusBadRequest}
 )
 
 func HttpStatusErrorCode(code int) *ErrorCode {
@@ -0,0 +1,709 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	SelectExpressionTypeSQL = "SQL"
+
+	SelectCompressionNone  = "NONE"
+	SelectCompressionGZIP  = "GZIP"
+	SelectCompressionBZIP2 = "BZIP2"
+
+	SelectFileHeaderInfoNone   = "NONE"
+	SelectFileHeaderInfoUse    = "USE"
+	SelectFileHeaderInfoIgnore = "IGNORE"
+
+	SelectJSONTypeDocument = "DOCUMENT"
+	SelectJSONTypeLines    = "LINES"
+
+	SelectQuoteFieldsAlways   = "ALWAYS"
+	SelectQuoteFieldsAsNeeded = "ASNEEDED"
+
+	SelectErrorCSVParsingError  = "CSVParsingError"
+	SelectErrorJSONParsingError = "JSONParsingError"
+
+	SelectErrorInvalidCompressionFormat = "InvalidCompressionFormat"
+
+	// The records are sent in a message once the buffered records exceed the size.
+	selectRecordsMessageSize = 128 * 1024
+	// A continuation message is sent to keep the connection alive if no message is sent in the interval.
+	selectKeepAliveInterval = 10 * time.Second
+)
+
+type SelectObjectContentRequest struct {
+	XMLName             xml.Name                  `xml:"SelectObjectContentRequest"`
+	Expression          string                    `xml:"Expression"`
+	ExpressionType      string                    `xml:"ExpressionType"`
+	RequestProgress     *SelectRequestProgress    `xml:"RequestProgress"`
+	InputSerialization  SelectInputSerialization  `xml:"InputSerialization"`
+	OutputSerialization SelectOutputSerialization `xml:"OutputSerialization"`
+	ScanRange           *struct{}                 `xml:"ScanRange"`
+}
+
+type SelectRequestProgress struct {
+	Enabled bool `xml:"Enabled"`
+}
+
+type SelectInputSerialization struct {
+	CompressionType string          `xml:"CompressionType"`
+	CSV             *SelectCSVInput `xml:"CSV"`
+	JSON            *SelectJSON     `xml:"JSON"`
+	Parquet         *struct{}       `xml:"Parquet"`
+}
+
+type SelectCSVInput struct {
+	AllowQuotedRecordDelimiter bool   `xml:"AllowQuotedRecordDelimiter"`
+	Comments                   string `xml:"Comments"`
+	FieldDelimiter             string `xml:"FieldDelimiter"`
+	FileHeaderInfo             string `xml:"FileHeaderInfo"`
+	QuoteCharacter             string `xml:"QuoteCharacter"`
+	QuoteEscapeCharacter       string `xml:"QuoteEscapeCharacter"`
+	RecordDelimiter            string `xml:"RecordDelimiter"`
+}
+
+type SelectJSON struct {
+	Type            string `xml:"Type"`
+	RecordDelimiter string `xml:"RecordDelimiter"`
+}
+
+type SelectOutputSerialization struct {
+	CSV  *SelectCSVOutput `xml:"CSV"`
+	JSON *SelectJSON      `xml:"JSON"`
+}
+
+type SelectCSVOutput struct {
+	FieldDelimiter       string `xml:"FieldDelimiter"`
+	QuoteCharacter       string `xml:"QuoteCharacter"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter"`
+	QuoteFields          string `xml:"QuoteFields"`
+	RecordDelimiter      string `xml:"RecordDelimiter"`
+}
+
+// SelectStats is the payload of the progress and stats messages.
+type SelectStats struct {
+	XMLName        xml.Name
+	BytesScanned   int64 `xml:"BytesScanned"`
+	BytesProcessed int64 `xml:"BytesProcessed"`
+	BytesReturned  int64 `xml:"BytesReturned"`
+}
+
+func isSingleCharacter(s string) bool {
+	return utf8.RuneCountInString(s) == 1
+}
+
+// validate checks the request and fills the default values of the serialization.
+func (req *SelectObjectContentRequest) validate() error {
+	if !strings.EqualFold(req.ExpressionType, SelectExpressionTypeSQL) {
+		return errors.NewErrorf("unsupported expression type: type(%v)", req.ExpressionType)
+	}
+	if req.ScanRange != nil {
+		return errors.New("scan range is not supported")
+	}
+
+	var input = &req.InputSerialization
+	switch input.CompressionType = strings.ToUpper(input.CompressionType); input.CompressionType {
+	case "":
+		input.CompressionType = SelectCompressionNone
+	case SelectCompressionNone, SelectCompressionGZIP, SelectCompressionBZIP2:
+	default:
+		return errors.NewErrorf("unsupported compression type: type(%v)", input.CompressionType)
+	}
+	if input.Parquet != nil {
+		return errors.New("parquet input is not supported")
+	}
+	if (input.CSV == nil) == (input.JSON == nil) {
+		return errors.New("exactly one of CSV and JSON input serialization must be specified")
+	}
+	if csvInput := input.CSV; csvInput != nil {
+		if csvInput.FieldDelimiter == "" {
+			csvInput.FieldDelimiter = ","
+		}
+		if csvInput.QuoteCharacter == "" {
+			csvInput.QuoteCharacter = "\""
+		}
+		if csvInput.QuoteEscapeCharacter == "" {
+			csvInput.QuoteEscapeCharacter = "\""
+		}
+		if csvInput.RecordDelimiter == "" {
+			csvInput.RecordDelimiter = "\n"
+		}
+		switch csvInput.FileHeaderInfo = strings.ToUpper(csvInput.FileHeaderInfo); csvInput.FileHeaderInfo {
+		case "":
+			csvInput.FileHeaderInfo = SelectFileHeaderInfoNone
+		case SelectFileHeaderInfoNone, SelectFileHeaderInfoUse, SelectFileHeaderInfoIgnore:
+		default:
+			return errors.NewErrorf("invalid file header info: info(%v)", csvInput.FileHeaderInfo)
+		}
+		if !isSingleCharacter(csvInput.FieldDelimiter) || (csvInput.Comments != "" && !isSingleCharacter(csvInput.Comments)) {
+			return errors.New("field delimiter and comments must be a single character")
+		}
+		// The CSV reader only supports the standard quote character and record delimiter.
+		if csvInput.QuoteCharacter != "\"" || csvInput.QuoteEscapeCharacter != "\"" {
+			return errors.New("only '\"' is supported as the quote character and quote escape character")
+		}
+		if csvInput.RecordDelimiter != "\n" && csvInput.RecordDelimiter != "\r\n" {
+			return errors.New("only '\\n' and '\\r\\n' are supported as the record delimiter")
+		}
+	}
+	if jsonInput := input.JSON; jsonInput != nil {
+		switch jsonInput.Type = strings.ToUpper(jsonInput.Type); jsonInput.Type {
+		case SelectJSONTypeDocument, SelectJSONTypeLines:
+		default:
+			return errors.NewErrorf("invalid JSON type: type(%v)", jsonInput.Type)
+		}
+	}
+
+	var output = &req.OutputSerialization
+	if (output.CSV == nil) == (output.JSON == nil) {
+		return errors.New("exactly one of CSV and JSON output serialization must be specified")
+	}
+	if csvOutput := output.CSV; csvOutput != nil {
+		if csvOutput.FieldDelimiter == "" {
+			csvOutput.FieldDelimiter = ","
+		}
+		if csvOutput.QuoteCharacter == "" {
+			csvOutput.QuoteCharacter = "\""
+		}
+		if csvOutput.QuoteEscapeCharacter == "" {
+			csvOutput.QuoteEscapeCharacter = "\""
+		}
+		if csvOutput.RecordDelimiter == "" {
+			csvOutput.RecordDelimiter = "\n"
+		}
+		switch csvOutput.QuoteFields = strings.ToUpper(csvOutput.QuoteFields); csvOutput.QuoteFields {
+		case "":
+			csvOutput.QuoteFields = SelectQuoteFieldsAsNeeded
+		case SelectQuoteFieldsAlways, SelectQuoteFieldsAsNeeded:
+		default:
+			return errors.NewErrorf("invalid quote fields: fields(%v)", csvOutput.QuoteFields)
+		}
+	}
+	if jsonOutput := output.JSON; jsonOutput != nil && jsonOutput.RecordDelimiter == "" {
+		jsonOutput.RecordDelimiter = "\n"
+	}
+	return nil
+}
+
+// ----- input -----
+
+type selectRecordReader interface {
+	// Read returns the next record, or io.EOF if there are no more records.
+	Read() (sqlRecord, error)
+}
+
+func newSelectRecordReader(input *SelectInputSerialization, reader io.Reader) (selectRecordReader, error) {
+	var err error
+	if input.JSON != nil {
+		var decoder = json.NewDecoder(bufio.NewReader(reader))
+		decoder.UseNumber()
+		return &jsonRecordReader{decoder: decoder}, nil
+	}
+
+	var csvReader = csv.NewReader(bufio.NewReader(reader))
+	csvReader.Comma, _ = utf8.DecodeRuneInString(input.CSV.FieldDelimiter)
+	if input.CSV.Comments != "" {
+		csvReader.Comment, _ = utf8.DecodeRuneInString(input.CSV.Comments)
+	}
+	csvReader.FieldsPerRecord = -1
+	var recordReader = &csvRecordReader{reader: csvReader}
+	switch input.CSV.FileHeaderInfo {
+	case SelectFileHeaderInfoUse:
+		var header []string
+		if header, err = csvReader.Read(); err != nil && err != io.EOF {
+			return nil, csvParsingError(err)
+		}
+		recordReader.header = make(map[string]int)
+		recordReader.headerFold = make(map[string]int)
+		for i, name := range header {
+			recordReader.header[name] = i
+			recordReader.headerFold[strings.ToLower(name)] = i
+		}
+		recordReader.names = header
+	case SelectFileHeaderInfoIgnore:
+		if _, err = csvReader.Read(); err != nil && err != io.EOF {
+			return nil, csvParsingError(err)
+		}
+	}
+	return recordReader, nil
+}
+
+// csvParsingError converts the syntax errors of the data to CSVParsingError, and leaves the errors
+// of reading the object as they are.
+func csvParsingError(err error) error {
+	if _, is := err.(*csv.ParseError); is {
+		return newSQLError(SelectErrorCSVParsingError, "parse CSV record fail: %v", err)
+	}
+	return err
+}
+
+type csvRecordReader struct {
+	reader     *csv.Reader
+	names      []string
+	header     map[string]int
+	headerFold map[string]int
+}
+
+func (r *csvRecordReader) Read() (sqlRecord, error) {
+	var fields, err = r.reader.Read()
+	if err != nil {
+		return nil, csvParsingError(err)
+	}
+	return &csvRecord{fields: fields, reader: r}, nil
+}
+
+type csvRecord struct {
+	fields []string
+	reader *csvRecordReader
+}
+
+// value returns the field by position, such as '_1', or by the name in the header of the file. The
+// name is case-insensitive if no field matches it exactly.
+func (r *csvRecord) value(path []string) interface{} {
+	if len(path) != 1 {
+		return nil
+	}
+	var name = path[0]
+	if strings.HasPrefix(name, "_") {
+		if position, err := strconv.Atoi(name[1:]); err == nil {
+			if position < 1 || position > len(r.fields) {
+				return nil
+			}
+			return r.fields[position-1]
+		}
+	}
+	var index, exist = r.reader.header[name]
+	if !exist {
+		if index, exist = r.reader.headerFold[strings.ToLower(name)]; !exist {
+			return nil
+		}
+	}
+	if index >= len(r.fields) {
+		return nil
+	}
+	return r.fields[index]
+}
+
+func (r *csvRecord) columns() (names []string, values []interface{}) {
+	names = make([]string, len(r.fields))
+	values = make([]interface{}, len(r.fields))
+	for i, field := range r.fields {
+		if i < len(r.reader.names) {
+			names[i] = r.reader.names[i]
+		} else {
+			names[i] = "_" + strconv.Itoa(i+1)
+		}
+		values[i] = field
+	}
+	return
+}
+
+// jsonRecordReader reads the JSON objects one by one, which serves both the DOCUMENT and LINES type.
+type jsonRecordReader struct {
+	decoder *json.Decoder
+}
+
+func (r *jsonRecordReader) Read() (sqlRecord, error) {
+	var token, err = r.decoder.Token()
+	if err != nil {
+		return nil, jsonParsingError(err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, newSQLError(SelectErrorJSONParsingError, "parse JSON record fail: record is not an object")
+	}
+	// The keys are kept in order of the document for the output of all the columns.
+	var record = &jsonRecord{values: make(map[string]interface{})}
+	for r.decoder.More() {
+		if token, err = r.decoder.Token(); err != nil {
+			return nil, jsonParsingError(err)
+		}
+		var key, _ = token.(string)
+		var value interface{}
+		if err = r.decoder.Decode(&value); err != nil {
+			return nil, jsonParsingError(err)
+		}
+		if _, exist := record.values[key]; !exist {
+			record.keys = append(record.keys, key)
+		}
+		record.values[key] = value
+	}
+	if _, err = r.decoder.Token(); err != nil {
+		return nil, jsonParsingError(err)
+	}
+	return record, nil
+}
+
+// jsonParsingError converts the syntax errors of the data to JSONParsingError, and leaves the errors
+// of reading the object as they are. An unexpected EOF means the last record is truncated.
+func jsonParsingError(err error) error {
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		return newSQLError(SelectErrorJSONParsingError, "parse JSON record fail: %v", err)
+	}
+	if err == io.ErrUnexpectedEOF {
+		return newSQLError(SelectErrorJSONParsingError, "parse JSON record fail: %v", err)
+	}
+	return err
+}
+
+type jsonRecord struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// value returns the value at the path of nested objects. The keys are case-insensitive if no key
+// matches exactly.
+func (r *jsonRecord) value(path []string) interface{} {
+	var current interface{} = r.values
+	for _, name := range path {
+		var object, ok = current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var value, exist = object[name]
+		if !exist {
+			for key, v := range object {
+				if strings.EqualFold(key, name) {
+					value, exist = v, true
+					break
+				}
+			}
+			if !exist {
+				return nil
+			}
+		}
+		current = value
+	}
+	return jsonToSQLValue(current)
+}
+
+func (r *jsonRecord) columns() (names []string, values []interface{}) {
+	values = make([]interface{}, len(r.keys))
+	for i, key := range r.keys {
+		values[i] = jsonToSQLValue(r.values[key])
+	}
+	return r.keys, values
+}
+
+// jsonToSQLValue converts the numbers decoded from JSON to int64 or float64.
+func jsonToSQLValue(v interface{}) interface{} {
+	if number, ok := v.(json.Number); ok {
+		if i, err := number.Int64(); err == nil {
+			return i
+		}
+		if f, err := number.Float64(); err == nil {
+			return f
+		}
+		return number.String()
+	}
+	return v
+}
+
+// ----- output -----
+
+type selectRecordWriter interface {
+	write(buf *bytes.Buffer, names []string, values []interface{}) error
+}
+
+func newSelectRecordWriter(output *SelectOutputSerialization) selectRecordWriter {
+	if output.JSON != nil {
+		return &jsonRecordWriter{output: output.JSON}
+	}
+	return &csvRecordWriter{output: output.CSV}
+}
+
+type csvRecordWriter struct {
+	output *SelectCSVOutput
+}
+
+func (w *csvRecordWriter) write(buf *bytes.Buffer, _ []string, values []interface{}) error {
+	for i, value := range values {
+		if i > 0 {
+			buf.WriteString(w.output.FieldDelimiter)
+		}
+		var field string
+		switch v := value.(type) {
+		case map[string]interface{}, []interface{}:
+			var data, err = json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			field = string(data)
+		default:
+			field = sqlToString(v)
+		}
+		if w.output.QuoteFields == SelectQuoteFieldsAlways ||
+			strings.Contains(field, w.output.FieldDelimiter) ||
+			strings.Contains(field, w.output.QuoteCharacter) ||
+			strings.ContainsAny(field, "\r\n") ||
+			strings.Contains(field, w.output.RecordDelimiter) {
+			field = w.output.QuoteCharacter +
+				strings.Replace(field, w.output.QuoteCharacter, w.output.QuoteEscapeCharacter+w.output.QuoteCharacter, -1) +
+				w.output.QuoteCharacter
+		}
+		buf.WriteString(field)
+	}
+	buf.WriteString(w.output.RecordDelimiter)
+	return nil
+}
+
+type jsonRecordWriter struct {
+	output *SelectJSON
+}
+
+// write writes the record as a JSON object with the keys in order of the columns.
+func (w *jsonRecordWriter) write(buf *bytes.Buffer, names []string, values []interface{}) error {
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		var key, err = json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		var value []byte
+		if value, err = json.Marshal(values[i]); err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	buf.WriteString(w.output.RecordDelimiter)
+	return nil
+}
+
+// ----- event stream -----
+
+// selectEventWriter writes the messages of the response in the event stream encoding.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/RESTSelectObjectAppendix.html
+type selectEventWriter struct {
+	encoder  *eventstream.Encoder
+	flusher  http.Flusher
+	lastSent time.Time
+}
+
+func newSelectEventWriter(w io.Writer) *selectEventWriter {
+	var writer = &selectEventWriter{encoder: eventstream.NewEncoder(w), lastSent: time.Now()}
+	writer.flusher, _ = w.(http.Flusher)
+	return writer
+}
+
+func (w *selectEventWriter) send(headers eventstream.Headers, payload []byte) error {
+	if err := w.encoder.Encode(eventstream.Message{Headers: headers, Payload: payload}); err != nil {
+		return err
+	}
+	if w.flusher != nil {
+		w.flusher.Flush()
+	}
+	w.lastSent = time.Now()
+	return nil
+}
+
+func (w *selectEventWriter) sendEvent(eventType, contentType string, payload []byte) error {
+	var headers eventstream.Headers
+	headers.Set(":event-type", eventstream.StringValue(eventType))
+	if contentType != "" {
+		headers.Set(":content-type", eventstream.StringValue(contentType))
+	}
+	headers.Set(":message-type", eventstream.StringValue("event"))
+	return w.send(headers, payload)
+}
+
+func (w *selectEventWriter) sendRecords(payload []byte) error {
+	return w.sendEvent("Records", "application/octet-stream", payload)
+}
+
+func (w *selectEventWriter) sendStats(eventType string, stats SelectStats) error {
+	stats.XMLName = xml.Name{Local: eventType}
+	var payload, err = MarshalXMLEntity(&stats)
+	if err != nil {
+		return err
+	}
+	return w.sendEvent(eventType, "text/xml", payload)
+}
+
+func (w *selectEventWriter) sendContinuation() error {
+	return w.sendEvent("Cont", "", nil)
+}
+
+func (w *selectEventWriter) sendEnd() error {
+	return w.sendEvent("End", "", nil)
+}
+
+func (w *selectEventWriter) sendError(code, message string) error {
+	var headers eventstream.Headers
+	headers.Set(":error-code", eventstream.StringValue(code))
+	headers.Set(":error-message", eventstream.StringValue(message))
+	headers.Set(":message-type", eventstream.StringValue("error"))
+	return w.send(headers, nil)
+}
+
+// ----- execution -----
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.n += int64(n)
+	return
+}
+
+// selectProcessor runs the query over the records of the object, and sends the selected records,
+// the progress, the stats and the end of the response as events.
+type selectProcessor struct {
+	query     *SelectQuery
+	reader    selectRecordReader
+	writer    selectRecordWriter
+	events    *selectEventWriter
+	progress  bool
+	scanned   *countingReader // the data read from the object
+	processed *countingReader // the data after decompression
+	returned  int64
+	buf       bytes.Buffer
+}
+
+func newSelectProcessor(req *SelectObjectContentRequest, query *SelectQuery, reader io.Reader, w io.Writer) (p *selectProcessor, err error) {
+	p = &selectProcessor{
+		query:    query,
+		writer:   newSelectRecordWriter(&req.OutputSerialization),
+		events:   newSelectEventWriter(w),
+		progress: req.RequestProgress != nil && req.RequestProgress.Enabled,
+		scanned:  &countingReader{reader: reader},
+	}
+	reader = p.scanned
+	switch req.InputSerialization.CompressionType {
+	case SelectCompressionGZIP:
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return nil, newSQLError(SelectErrorInvalidCompressionFormat, "read GZIP data fail: %v", err)
+		}
+	case SelectCompressionBZIP2:
+		reader = bzip2.NewReader(reader)
+	}
+	if reader != p.scanned {
+		p.processed = &countingReader{reader: reader}
+		reader = p.processed
+	}
+	if p.reader, err = newSelectRecordReader(&req.InputSerialization, reader); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *selectProcessor) stats() SelectStats {
+	var stats = SelectStats{BytesScanned: p.scanned.n, BytesReturned: p.returned}
+	stats.BytesProcessed = stats.BytesScanned
+	if p.processed != nil {
+		stats.BytesProcessed = p.processed.n
+	}
+	return stats
+}
+
+func (p *selectProcessor) flush() error {
+	if p.buf.Len() == 0 {
+		return nil
+	}
+	p.returned += int64(p.buf.Len())
+	if err := p.events.sendRecords(p.buf.Bytes()); err != nil {
+		return err
+	}
+	p.buf.Reset()
+	if p.progress {
+		return p.events.sendStats("Progress", p.stats())
+	}
+	return nil
+}
+
+func (p *selectProcessor) writeRecord(record sqlRecord) error {
+	var names, values, err = p.query.project(record)
+	if err != nil {
+		return err
+	}
+	return p.writer.write(&p.buf, names, values)
+}
+
+func (p *selectProcessor) run() (err error) {
+	var count int64
+	for p.query.isAggregate() || p.query.limit < 0 || count < p.query.limit {
+		var record sqlRecord
+		if record, err = p.reader.Read(); err == io.EOF {
+			break
+		}
+		if err != nil {
+			return
+		}
+		var matched bool
+		if matched, err = p.query.match(record); err != nil {
+			return
+		}
+		if matched {
+			if p.query.isAggregate() {
+				err = p.query.accumulate(record)
+			} else {
+				err = p.writeRecord(record)
+				count++
+			}
+			if err != nil {
+				return
+			}
+		}
+		if p.buf.Len() >= selectRecordsMessageSize {
+			if err = p.flush(); err != nil {
+				return
+			}
+		} else if time.Since(p.events.lastSent) >= selectKeepAliveInterval {
+			if err = p.events.sendContinuation(); err != nil {
+				return
+			}
+		}
+	}
+	if p.query.isAggregate() {
+		if err = p.writeRecord(nil); err != nil {
+			return
+		}
+	}
+	if err = p.flush(); err != nil {
+		return
+	}
+	if err = p.events.sendStats("Stats", p.stats()); err != nil {
+		return
+	}
+	return p.events.sendEnd()
+}
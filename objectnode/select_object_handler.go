@@ -0,0 +1,166 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The maximum size of the select request, which is limited by the size of the expression.
+const maxSelectRequestSize = 256 * 1024
+
+// Select object content
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+//
+// The errors found before the response is started, such as an invalid request or expression, are
+// responded as usual. The errors found while the records are streamed, such as a malformed record,
+// are sent as error messages of the event stream.
+func (o *ObjectNode) selectObjectContentHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		log.LogErrorf("selectObjectContentHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var requestBody []byte
+	if requestBody, err = ioutil.ReadAll(io.LimitReader(r.Body, maxSelectRequestSize+1)); err != nil {
+		log.LogErrorf("selectObjectContentHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if len(requestBody) > maxSelectRequestSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var request = &SelectObjectContentRequest{}
+	if err = xml.Unmarshal(requestBody, request); err != nil {
+		log.LogWarnf("selectObjectContentHandler: decode request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		errorCode = MalformedXML
+		return
+	}
+	if err = request.validate(); err != nil {
+		log.LogWarnf("selectObjectContentHandler: invalid request: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		errorCode = InvalidSelectRequest
+		return
+	}
+	var query *SelectQuery
+	if query, err = parseSelectSQL(request.Expression); err != nil {
+		log.LogWarnf("selectObjectContentHandler: parse expression fail: requestID(%v) volume(%v) path(%v) expression(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), request.Expression, err)
+		errorCode = selectErrorCode(err)
+		return
+	}
+
+	var fileInfo *FSFileInfo
+	if fileInfo, err = vol.ObjectMeta(param.Object()); err == syscall.ENOENT {
+		errorCode = NoSuchKey
+		return
+	}
+	if err != nil {
+		log.LogErrorf("selectObjectContentHandler: get file meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if fileInfo.Mode.IsDir() {
+		errorCode = InvalidSelectObjectType
+		return
+	}
+	var sseOption *SSEOption
+	if sseOption, errorCode = parseSSECustomerKey(r, HeaderNameXAmzSSECustomerAlgorithm,
+		HeaderNameXAmzSSECustomerKey, HeaderNameXAmzSSECustomerKeyMD5); errorCode != nil {
+		return
+	}
+	if errorCode = checkSSECustomerKey(fileInfo, sseOption); errorCode != nil {
+		return
+	}
+
+	// The object is read into the pipe while the records are processed. Closing the reader side
+	// stops the reading once the processing finishes, such as the limit of the query is reached.
+	var reader, writer = io.Pipe()
+	defer func() {
+		_ = reader.Close()
+	}()
+	go func() {
+		var readErr error
+		if sseOption == nil {
+			readErr = vol.ReadFile(param.Object(), writer, 0, uint64(fileInfo.Size))
+		} else {
+			readErr = vol.ReadFileVersion(param.Object(), "", writer, 0, uint64(fileInfo.Size), sseOption)
+		}
+		_ = writer.CloseWithError(readErr)
+	}()
+
+	var processor *selectProcessor
+	if processor, err = newSelectProcessor(request, query, reader, w); err != nil {
+		log.LogWarnf("selectObjectContentHandler: start select fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		errorCode = selectErrorCode(err)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueTypeStream}
+	w.WriteHeader(http.StatusOK)
+	if err = processor.run(); err != nil {
+		log.LogWarnf("selectObjectContentHandler: select fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		var code = selectErrorCode(err)
+		_ = processor.events.sendError(code.ErrorCode, code.ErrorMessage)
+		return
+	}
+	log.LogDebugf("selectObjectContentHandler: select finished: requestID(%v) volume(%v) path(%v) stats(%v)",
+		GetRequestID(r), vol.Name(), param.Object(), processor.stats())
+}
+
+func selectErrorCode(err error) *ErrorCode {
+	if e, is := err.(*sqlError); is {
+		return &ErrorCode{ErrorCode: e.code, ErrorMessage: e.message, StatusCode: http.StatusBadRequest}
+	}
+	if err == syscall.ENOENT {
+		return NoSuchKey
+	}
+	if code := sseErrorCode(err); code != nil {
+		return code
+	}
+	return InternalErrorCode(err)
+}
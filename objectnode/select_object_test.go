@@ -0,0 +1,181 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+)
+
+// runSelect runs the select request over the data, and returns the records and the types of
+// the messages in the response.
+func runSelect(t *testing.T, requestXML string, data []byte) (records string, messages []string, stats *SelectStats) {
+	var request = &SelectObjectContentRequest{}
+	if err := xml.Unmarshal([]byte(requestXML), request); err != nil {
+		t.Fatalf("decode request fail: err(%v)", err)
+	}
+	if err := request.validate(); err != nil {
+		t.Fatalf("validate request fail: err(%v)", err)
+	}
+	var query, err = parseSelectSQL(request.Expression)
+	if err != nil {
+		t.Fatalf("parse expression fail: err(%v)", err)
+	}
+	var response = &bytes.Buffer{}
+	var processor *selectProcessor
+	if processor, err = newSelectProcessor(request, query, bytes.NewReader(data), response); err != nil {
+		t.Fatalf("new processor fail: err(%v)", err)
+	}
+	if err = processor.run(); err != nil {
+		_ = processor.events.sendError(selectErrorCode(err).ErrorCode, err.Error())
+	}
+
+	var decoder = eventstream.NewDecoder(response)
+	for {
+		var message eventstream.Message
+		if message, err = decoder.Decode(nil); err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("decode message fail: err(%v)", err)
+		}
+		if message.Headers.Get(":message-type").String() == "error" {
+			messages = append(messages, "error:"+message.Headers.Get(":error-code").String())
+			continue
+		}
+		var eventType = message.Headers.Get(":event-type").String()
+		messages = append(messages, eventType)
+		switch eventType {
+		case "Records":
+			records += string(message.Payload)
+		case "Stats":
+			stats = &SelectStats{}
+			if err = xml.Unmarshal(message.Payload, stats); err != nil {
+				t.Fatalf("decode stats fail: err(%v)", err)
+			}
+		}
+	}
+}
+
+func selectRequestXML(expression, input, output string) string {
+	return `<SelectObjectContentRequest>
+	<Expression>` + expression + `</Expression>
+	<ExpressionType>SQL</ExpressionType>
+	<InputSerialization>` + input + `</InputSerialization>
+	<OutputSerialization>` + output + `</OutputSerialization>
+</SelectObjectContentRequest>`
+}
+
+func TestSelectObjectCSV(t *testing.T) {
+	var data = "name,age,city\nAlice,30,Beijing\nBob,25,\"Shang,hai\"\nCarol,35,Beijing\n"
+	var request = selectRequestXML(
+		"SELECT name, city FROM S3Object WHERE CAST(age AS INT) &gt; 26",
+		"<CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV>",
+		"<CSV/>")
+	var records, messages, stats = runSelect(t, request, []byte(data))
+	if records != "Alice,Beijing\nCarol,Beijing\n" {
+		t.Fatalf("records mismatch: records(%q)", records)
+	}
+	if strings.Join(messages, ",") != "Records,Stats,End" {
+		t.Fatalf("messages mismatch: messages(%v)", messages)
+	}
+	if stats == nil || stats.BytesScanned != int64(len(data)) || stats.BytesProcessed != int64(len(data)) ||
+		stats.BytesReturned != int64(len(records)) {
+		t.Fatalf("stats mismatch: stats(%v)", stats)
+	}
+
+	// The fields are referred by position without the header, and quoted as needed in the output.
+	request = selectRequestXML(
+		"SELECT s._1, s._3 FROM S3Object s WHERE s._2 = '25'",
+		"<CSV><FileHeaderInfo>IGNORE</FileHeaderInfo></CSV>",
+		"<CSV><FieldDelimiter>|</FieldDelimiter></CSV>")
+	if records, _, _ = runSelect(t, request, []byte(data)); records != "Bob|Shang,hai\n" {
+		t.Fatalf("records mismatch: records(%q)", records)
+	}
+
+	request = selectRequestXML(
+		"SELECT COUNT(*), AVG(CAST(age AS INT)) FROM S3Object LIMIT 1",
+		"<CompressionType>GZIP</CompressionType><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV>",
+		"<JSON/>")
+	var compressed = &bytes.Buffer{}
+	var gzipWriter = gzip.NewWriter(compressed)
+	_, _ = gzipWriter.Write([]byte(data))
+	_ = gzipWriter.Close()
+	if records, _, stats = runSelect(t, request, compressed.Bytes()); records != "{\"_1\":3,\"_2\":30}\n" {
+		t.Fatalf("records mismatch: records(%q)", records)
+	}
+	if stats.BytesScanned != int64(compressed.Len()) || stats.BytesProcessed != int64(len(data)) {
+		t.Fatalf("stats of compressed data mismatch: stats(%v)", stats)
+	}
+}
+
+func TestSelectObjectJSON(t *testing.T) {
+	var data = `{"name": "Alice", "age": 30, "address": {"city": "Beijing"}}
+{"name": "Bob", "age": 25, "address": {"city": "Shanghai"}}
+{"name": "Carol", "age": 35, "address": {"city": "Beijing"}}
+`
+	var request = selectRequestXML(
+		"SELECT s.name, s.address.city FROM S3Object s WHERE s.age &gt;= 30 LIMIT 1",
+		"<JSON><Type>LINES</Type></JSON>",
+		"<JSON/>")
+	var records, _, _ = runSelect(t, request, []byte(data))
+	if records != "{\"name\":\"Alice\",\"city\":\"Beijing\"}\n" {
+		t.Fatalf("records mismatch: records(%q)", records)
+	}
+
+	request = selectRequestXML(
+		"SELECT * FROM S3Object WHERE name = 'Bob'",
+		"<JSON><Type>DOCUMENT</Type></JSON>",
+		"<CSV><QuoteFields>ALWAYS</QuoteFields></CSV>")
+	if records, _, _ = runSelect(t, request, []byte(data)); records != "\"Bob\",\"25\",\"{\"\"city\"\":\"\"Shanghai\"\"}\"\n" {
+		t.Fatalf("records mismatch: records(%q)", records)
+	}
+
+	// The malformed record is reported as an error message after the selected records.
+	request = selectRequestXML("SELECT name FROM S3Object", "<JSON><Type>LINES</Type></JSON>", "<CSV/>")
+	var messages []string
+	if records, messages, _ = runSelect(t, request, []byte(data+"{\"name\": ")); records != "" ||
+		strings.Join(messages, ",") != "error:"+SelectErrorJSONParsingError {
+		t.Fatalf("messages mismatch: records(%q) messages(%v)", records, messages)
+	}
+}
+
+func TestSelectObjectRequestValidate(t *testing.T) {
+	var invalids = []string{
+		`<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>XPATH</ExpressionType>
+			<InputSerialization><CSV/></InputSerialization><OutputSerialization><CSV/></OutputSerialization></SelectObjectContentRequest>`,
+		selectRequestXML("SELECT * FROM S3Object", "<Parquet/>", "<CSV/>"),
+		selectRequestXML("SELECT * FROM S3Object", "<CSV/><JSON><Type>LINES</Type></JSON>", "<CSV/>"),
+		selectRequestXML("SELECT * FROM S3Object", "<CSV/>", ""),
+		selectRequestXML("SELECT * FROM S3Object", "<CompressionType>ZIP</CompressionType><CSV/>", "<CSV/>"),
+		selectRequestXML("SELECT * FROM S3Object", "<CSV><FieldDelimiter>::</FieldDelimiter></CSV>", "<CSV/>"),
+		selectRequestXML("SELECT * FROM S3Object", "<JSON><Type>ARRAY</Type></JSON>", "<CSV/>"),
+	}
+	for _, invalid := range invalids {
+		var request = &SelectObjectContentRequest{}
+		if err := xml.Unmarshal([]byte(invalid), request); err != nil {
+			t.Fatalf("decode request fail: err(%v)", err)
+		}
+		if err := request.validate(); err == nil {
+			t.Fatalf("invalid request should be rejected: request(%v)", invalid)
+		}
+	}
+}
@@ -0,0 +1,1350 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// The SQL subset supported by S3 Select:
+//
+//	SELECT <* | expression [AS name], ...> FROM S3Object [[AS] alias] [WHERE condition] [LIMIT number]
+//
+// Expressions support column references, literals, arithmetic, comparison, logical operators,
+// LIKE, BETWEEN, IN, IS [NOT] NULL, CAST, the scalar functions LOWER, UPPER, CHAR_LENGTH, TRIM and
+// COALESCE, and the aggregate functions COUNT, SUM, AVG, MIN and MAX.
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/s3-glacier-select-sql-reference.html
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	SelectErrorParseUnexpectedToken      = "ParseUnexpectedToken"
+	SelectErrorParseUnsupportedSyntax    = "ParseUnsupportedSyntax"
+	SelectErrorCastFailed                = "CastFailed"
+	SelectErrorEvaluatorInvalidArguments = "EvaluatorInvalidArguments"
+)
+
+// sqlError is the error of parsing or evaluating the SQL expression, which is reported with the
+// error code of S3 Select.
+type sqlError struct {
+	code    string
+	message string
+}
+
+func (e *sqlError) Error() string {
+	return e.message
+}
+
+func newSQLError(code, format string, args ...interface{}) *sqlError {
+	return &sqlError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// ----- lexer -----
+
+type sqlTokenType int
+
+const (
+	sqlTokenEOF sqlTokenType = iota
+	sqlTokenIdent
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenOperator
+)
+
+type sqlToken struct {
+	typ   sqlTokenType
+	value string
+	pos   int
+}
+
+func isSQLIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSQLIdentPart(c byte) bool {
+	return isSQLIdentStart(c) || isSQLDigit(c)
+}
+
+func isSQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func tokenizeSQL(sql string) (tokens []sqlToken, err error) {
+	var i = 0
+	for i < len(sql) {
+		var c = sql[i]
+		var start = i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case isSQLIdentStart(c):
+			for i < len(sql) && isSQLIdentPart(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{typ: sqlTokenIdent, value: sql[start:i], pos: start})
+		case isSQLDigit(c) || (c == '.' && i+1 < len(sql) && isSQLDigit(sql[i+1])):
+			for i < len(sql) && isSQLDigit(sql[i]) {
+				i++
+			}
+			if i < len(sql) && sql[i] == '.' {
+				i++
+				for i < len(sql) && isSQLDigit(sql[i]) {
+					i++
+				}
+			}
+			if i < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+				i++
+				if i < len(sql) && (sql[i] == '+' || sql[i] == '-') {
+					i++
+				}
+				for i < len(sql) && isSQLDigit(sql[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, sqlToken{typ: sqlTokenNumber, value: sql[start:i], pos: start})
+		case c == '\'' || c == '"':
+			// A quote in the string or quoted identifier is escaped by doubling it.
+			var sb strings.Builder
+			var closed bool
+			for i++; i < len(sql); i++ {
+				if sql[i] == c {
+					if i+1 < len(sql) && sql[i+1] == c {
+						sb.WriteByte(c)
+						i++
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(sql[i])
+			}
+			if !closed {
+				return nil, newSQLError(SelectErrorParseUnexpectedToken, "unterminated quote at position %v", start)
+			}
+			var typ = sqlTokenString
+			if c == '"' {
+				typ = sqlTokenQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{typ: typ, value: sb.String(), pos: start})
+		default:
+			if i+1 < len(sql) {
+				switch op := sql[i : i+2]; op {
+				case "<=", ">=", "<>", "!=", "||":
+					tokens = append(tokens, sqlToken{typ: sqlTokenOperator, value: op, pos: start})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("=<>(),.*+-/%[]", rune(c)) {
+				return nil, newSQLError(SelectErrorParseUnexpectedToken, "unexpected character %q at position %v", c, start)
+			}
+			tokens = append(tokens, sqlToken{typ: sqlTokenOperator, value: string(c), pos: start})
+			i++
+		}
+	}
+	tokens = append(tokens, sqlToken{typ: sqlTokenEOF, pos: len(sql)})
+	return
+}
+
+// ----- expressions -----
+
+// sqlRecord is a record of the object which the expressions are evaluated against.
+type sqlRecord interface {
+	// value returns the value of the column at the path, or nil if the column is missing.
+	value(path []string) interface{}
+	// columns returns the names and values of all the columns in order.
+	columns() (names []string, values []interface{})
+}
+
+// The values of the expressions are nil (NULL or MISSING), bool, int64, float64, string, or the
+// nested values of JSON records.
+type sqlExpr interface {
+	eval(record sqlRecord) (interface{}, error)
+	children() []sqlExpr
+}
+
+type sqlLiteral struct {
+	value interface{}
+}
+
+func (e *sqlLiteral) eval(sqlRecord) (interface{}, error) { return e.value, nil }
+func (e *sqlLiteral) children() []sqlExpr                 { return nil }
+
+type sqlColumn struct {
+	path []string
+}
+
+func (e *sqlColumn) eval(record sqlRecord) (interface{}, error) {
+	if record == nil {
+		return nil, newSQLError(SelectErrorEvaluatorInvalidArguments, "column %v is used outside of aggregate functions",
+			strings.Join(e.path, "."))
+	}
+	return record.value(e.path), nil
+}
+func (e *sqlColumn) children() []sqlExpr { return nil }
+
+type sqlUnary struct {
+	op      string
+	operand sqlExpr
+}
+
+func (e *sqlUnary) eval(record sqlRecord) (interface{}, error) {
+	v, err := e.operand.eval(record)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "NOT":
+		var b, ok = sqlToBool(v)
+		if !ok {
+			return nil, nil
+		}
+		return !b, nil
+	default:
+		var n, ok = sqlToNumber(v)
+		if !ok {
+			return nil, newSQLError(SelectErrorEvaluatorInvalidArguments, "invalid operand of unary minus: %v", v)
+		}
+		if i, isInt := n.(int64); isInt {
+			return -i, nil
+		}
+		return -n.(float64), nil
+	}
+}
+func (e *sqlUnary) children() []sqlExpr { return []sqlExpr{e.operand} }
+
+type sqlBinary struct {
+	op          string
+	left, right sqlExpr
+}
+
+func (e *sqlBinary) eval(record sqlRecord) (interface{}, error) {
+	l, err := e.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	// AND and OR follow the three-valued logic of SQL.
+	switch e.op {
+	case "AND", "OR":
+		var lb, lok = sqlToBool(l)
+		if e.op == "AND" && lok && !lb {
+			return false, nil
+		}
+		if e.op == "OR" && lok && lb {
+			return true, nil
+		}
+		r, err := e.right.eval(record)
+		if err != nil {
+			return nil, err
+		}
+		var rb, rok = sqlToBool(r)
+		if rok && rb == (e.op == "OR") {
+			return rb, nil
+		}
+		if !lok || !rok {
+			return nil, nil
+		}
+		return e.op == "AND", nil
+	}
+	r, err := e.right.eval(record)
+	if err != nil || l == nil || r == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+		var cmp, ok = sqlCompare(l, r)
+		if !ok {
+			return nil, nil
+		}
+		switch e.op {
+		case "=":
+			return cmp == 0, nil
+		case "!=", "<>":
+			return cmp != 0, nil
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case "||":
+		return sqlToString(l) + sqlToString(r), nil
+	default:
+		return sqlArithmetic(e.op, l, r)
+	}
+}
+func (e *sqlBinary) children() []sqlExpr { return []sqlExpr{e.left, e.right} }
+
+type sqlIsNull struct {
+	operand sqlExpr
+	not     bool
+}
+
+func (e *sqlIsNull) eval(record sqlRecord) (interface{}, error) {
+	v, err := e.operand.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != e.not, nil
+}
+func (e *sqlIsNull) children() []sqlExpr { return []sqlExpr{e.operand} }
+
+type sqlBetween struct {
+	operand, lower, upper sqlExpr
+	not                   bool
+}
+
+func (e *sqlBetween) eval(record sqlRecord) (interface{}, error) {
+	var values = make([]interface{}, 3)
+	for i, expr := range []sqlExpr{e.operand, e.lower, e.upper} {
+		var err error
+		if values[i], err = expr.eval(record); err != nil || values[i] == nil {
+			return nil, err
+		}
+	}
+	var lower, lok = sqlCompare(values[0], values[1])
+	var upper, uok = sqlCompare(values[0], values[2])
+	if !lok || !uok {
+		return nil, nil
+	}
+	return (lower >= 0 && upper <= 0) != e.not, nil
+}
+func (e *sqlBetween) children() []sqlExpr { return []sqlExpr{e.operand, e.lower, e.upper} }
+
+type sqlIn struct {
+	operand sqlExpr
+	list    []sqlExpr
+	not     bool
+}
+
+func (e *sqlIn) eval(record sqlRecord) (interface{}, error) {
+	v, err := e.operand.eval(record)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	for _, expr := range e.list {
+		var item interface{}
+		if item, err = expr.eval(record); err != nil {
+			return nil, err
+		}
+		if cmp, ok := sqlCompare(v, item); ok && cmp == 0 {
+			return !e.not, nil
+		}
+	}
+	return e.not, nil
+}
+func (e *sqlIn) children() []sqlExpr { return append([]sqlExpr{e.operand}, e.list...) }
+
+type sqlLike struct {
+	operand, pattern sqlExpr
+	escape           string
+	not              bool
+
+	// The compiled regular expression of the last pattern.
+	lastPattern string
+	regexp      *regexp.Regexp
+}
+
+func (e *sqlLike) eval(record sqlRecord) (interface{}, error) {
+	v, err := e.operand.eval(record)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	p, err := e.pattern.eval(record)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	var pattern = sqlToString(p)
+	if e.regexp == nil || pattern != e.lastPattern {
+		if e.regexp, err = likePatternToRegexp(pattern, e.escape); err != nil {
+			return nil, err
+		}
+		e.lastPattern = pattern
+	}
+	return e.regexp.MatchString(sqlToString(v)) != e.not, nil
+}
+func (e *sqlLike) children() []sqlExpr { return []sqlExpr{e.operand, e.pattern} }
+
+// likePatternToRegexp converts the pattern of LIKE, in which '%' matches any sequence of
+// characters and '_' matches any single character, to a regular expression.
+func likePatternToRegexp(pattern, escape string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	var escaped bool
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case escape != "" && string(c) == escape:
+			escaped = true
+		case c == '%':
+			sb.WriteString(".*")
+		case c == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	var re, err = regexp.Compile(sb.String())
+	if err != nil {
+		return nil, newSQLError(SelectErrorEvaluatorInvalidArguments, "invalid LIKE pattern: %v", pattern)
+	}
+	return re, nil
+}
+
+type sqlCast struct {
+	operand sqlExpr
+	typ     string
+}
+
+func (e *sqlCast) eval(record sqlRecord) (interface{}, error) {
+	v, err := e.operand.eval(record)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	var result interface{}
+	var ok bool
+	switch e.typ {
+	case "INT", "INTEGER":
+		var n interface{}
+		if n, ok = sqlToNumber(v); ok {
+			if f, isFloat := n.(float64); isFloat {
+				n = int64(f)
+			}
+			result = n
+		}
+	case "FLOAT", "DECIMAL", "NUMERIC":
+		var n interface{}
+		if n, ok = sqlToNumber(v); ok {
+			if i, isInt := n.(int64); isInt {
+				n = float64(i)
+			}
+			result = n
+		}
+	case "STRING", "VARCHAR", "CHAR":
+		result, ok = sqlToString(v), true
+	case "BOOL", "BOOLEAN":
+		result, ok = sqlToBool(v)
+	}
+	if !ok {
+		return nil, newSQLError(SelectErrorCastFailed, "failed to cast %v to %v", v, e.typ)
+	}
+	return result, nil
+}
+func (e *sqlCast) children() []sqlExpr { return []sqlExpr{e.operand} }
+
+type sqlFunction struct {
+	name string
+	args []sqlExpr
+}
+
+var sqlScalarFunctions = map[string]int{
+	"LOWER":            1,
+	"UPPER":            1,
+	"CHAR_LENGTH":      1,
+	"CHARACTER_LENGTH": 1,
+	"TRIM":             1,
+	"COALESCE":         -1,
+}
+
+func (e *sqlFunction) eval(record sqlRecord) (interface{}, error) {
+	var args = make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		var err error
+		if args[i], err = arg.eval(record); err != nil {
+			return nil, err
+		}
+	}
+	if e.name == "COALESCE" {
+		for _, arg := range args {
+			if arg != nil {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	var str = sqlToString(args[0])
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(str), nil
+	case "UPPER":
+		return strings.ToUpper(str), nil
+	case "TRIM":
+		return strings.TrimSpace(str), nil
+	default:
+		return int64(len([]rune(str))), nil
+	}
+}
+func (e *sqlFunction) children() []sqlExpr { return e.args }
+
+// sqlAggregate is the aggregate function. The records are accumulated by update, and the result is
+// returned by eval after all the records are accumulated.
+type sqlAggregate struct {
+	name string
+	arg  sqlExpr // nil for COUNT(*)
+
+	count  int64
+	sumInt int64
+	sumF   float64
+	float  bool
+	result interface{} // MIN or MAX
+}
+
+func (e *sqlAggregate) update(record sqlRecord) error {
+	if e.arg == nil {
+		e.count++
+		return nil
+	}
+	v, err := e.arg.eval(record)
+	if err != nil || v == nil {
+		return err
+	}
+	e.count++
+	switch e.name {
+	case "SUM", "AVG":
+		var n, ok = sqlToNumber(v)
+		if !ok {
+			return newSQLError(SelectErrorEvaluatorInvalidArguments, "invalid argument of %v: %v", e.name, v)
+		}
+		if i, isInt := n.(int64); isInt && !e.float {
+			e.sumInt += i
+		} else {
+			if !e.float {
+				e.float = true
+				e.sumF = float64(e.sumInt)
+			}
+			e.sumF += sqlToFloat(n)
+		}
+	case "MIN", "MAX":
+		if n, ok := sqlToNumber(v); ok {
+			v = n
+		}
+		if e.result == nil {
+			e.result = v
+			return nil
+		}
+		var cmp, ok = sqlCompare(v, e.result)
+		if !ok {
+			return newSQLError(SelectErrorEvaluatorInvalidArguments, "incomparable arguments of %v: %v, %v", e.name, v, e.result)
+		}
+		if (e.name == "MIN" && cmp < 0) || (e.name == "MAX" && cmp > 0) {
+			e.result = v
+		}
+	}
+	return nil
+}
+
+func (e *sqlAggregate) eval(sqlRecord) (interface{}, error) {
+	switch e.name {
+	case "COUNT":
+		return e.count, nil
+	case "SUM":
+		if e.count == 0 {
+			return nil, nil
+		}
+		if e.float {
+			return e.sumF, nil
+		}
+		return e.sumInt, nil
+	case "AVG":
+		if e.count == 0 {
+			return nil, nil
+		}
+		if e.float {
+			return e.sumF / float64(e.count), nil
+		}
+		return float64(e.sumInt) / float64(e.count), nil
+	default:
+		return e.result, nil
+	}
+}
+
+func (e *sqlAggregate) children() []sqlExpr {
+	if e.arg == nil {
+		return nil
+	}
+	return []sqlExpr{e.arg}
+}
+
+var sqlAggregateFunctions = map[string]struct{}{
+	"COUNT": {},
+	"SUM":   {},
+	"AVG":   {},
+	"MIN":   {},
+	"MAX":   {},
+}
+
+func walkSQLExpr(expr sqlExpr, fn func(expr sqlExpr)) {
+	fn(expr)
+	for _, child := range expr.children() {
+		walkSQLExpr(child, fn)
+	}
+}
+
+// ----- value conversion -----
+
+// sqlToNumber converts the value to int64 or float64. Strings are parsed, since all the values of
+// CSV records are strings.
+func sqlToNumber(v interface{}) (interface{}, bool) {
+	switch n := v.(type) {
+	case int64, float64:
+		return n, true
+	case string:
+		var s = strings.TrimSpace(n)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func sqlToFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+func sqlToBool(v interface{}) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		if parsed, err := strconv.ParseBool(strings.TrimSpace(b)); err == nil {
+			return parsed, true
+		}
+	}
+	return false, false
+}
+
+func sqlToString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case int64:
+		return strconv.FormatInt(s, 10)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(s)
+	default:
+		return fmt.Sprintf("%v", s)
+	}
+}
+
+// sqlCompare compares two non-null values. A number is compared with a string by parsing the
+// string. The result is not ok if the values are incomparable.
+func sqlCompare(l, r interface{}) (int, bool) {
+	_, lIsString := l.(string)
+	_, rIsString := r.(string)
+	if lIsString && rIsString {
+		return strings.Compare(l.(string), r.(string)), true
+	}
+	if lb, ok := l.(bool); ok {
+		if rb, ok := r.(bool); ok {
+			switch {
+			case lb == rb:
+				return 0, true
+			case !lb:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+		return 0, false
+	}
+	ln, lok := sqlToNumber(l)
+	rn, rok := sqlToNumber(r)
+	if !lok || !rok {
+		return 0, false
+	}
+	li, lIsInt := ln.(int64)
+	ri, rIsInt := rn.(int64)
+	if lIsInt && rIsInt {
+		switch {
+		case li < ri:
+			return -1, true
+		case li > ri:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+	var lf, rf = sqlToFloat(ln), sqlToFloat(rn)
+	switch {
+	case lf < rf:
+		return -1, true
+	case lf > rf:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func sqlArithmetic(op string, l, r interface{}) (interface{}, error) {
+	ln, lok := sqlToNumber(l)
+	rn, rok := sqlToNumber(r)
+	if !lok || !rok {
+		return nil, newSQLError(SelectErrorEvaluatorInvalidArguments, "invalid operands of %v: %v, %v", op, l, r)
+	}
+	li, lIsInt := ln.(int64)
+	ri, rIsInt := rn.(int64)
+	if lIsInt && rIsInt {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		}
+		if ri == 0 {
+			return nil, newSQLError(SelectErrorEvaluatorInvalidArguments, "division by zero")
+		}
+		if op == "%" {
+			return li % ri, nil
+		}
+		if li%ri == 0 {
+			return li / ri, nil
+		}
+		return float64(li) / float64(ri), nil
+	}
+	var lf, rf = sqlToFloat(ln), sqlToFloat(rn)
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	if rf == 0 {
+		return nil, newSQLError(SelectErrorEvaluatorInvalidArguments, "division by zero")
+	}
+	if op == "%" {
+		return math.Mod(lf, rf), nil
+	}
+	return lf / rf, nil
+}
+
+// ----- parser -----
+
+type sqlProjection struct {
+	expr sqlExpr
+	name string
+}
+
+// SelectQuery is the parsed SQL expression of the select request.
+type SelectQuery struct {
+	projections []*sqlProjection // nil if all the columns are selected
+	alias       string
+	where       sqlExpr
+	limit       int64 // negative if no limit
+	aggregates  []*sqlAggregate
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	var token = p.tokens[p.pos]
+	if token.typ != sqlTokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// isKeyword checks whether the next token is the keyword, which is case-insensitive.
+func (p *sqlParser) isKeyword(keyword string) bool {
+	var token = p.peek()
+	return token.typ == sqlTokenIdent && strings.EqualFold(token.value, keyword)
+}
+
+func (p *sqlParser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) isOperator(op string) bool {
+	var token = p.peek()
+	return token.typ == sqlTokenOperator && token.value == op
+}
+
+func (p *sqlParser) acceptOperator(op string) bool {
+	if p.isOperator(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) unexpected() error {
+	var token = p.peek()
+	if token.typ == sqlTokenEOF {
+		return newSQLError(SelectErrorParseUnexpectedToken, "unexpected end of expression")
+	}
+	return newSQLError(SelectErrorParseUnexpectedToken, "unexpected token %q at position %v", token.value, token.pos)
+}
+
+func (p *sqlParser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *sqlParser) expectOperator(op string) error {
+	if !p.acceptOperator(op) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+var sqlReservedKeywords = map[string]struct{}{
+	"SELECT": {}, "FROM": {}, "WHERE": {}, "LIMIT": {}, "AS": {}, "AND": {}, "OR": {}, "NOT": {},
+	"IS": {}, "NULL": {}, "MISSING": {}, "LIKE": {}, "ESCAPE": {}, "BETWEEN": {}, "IN": {},
+	"TRUE": {}, "FALSE": {}, "CAST": {},
+}
+
+func (p *sqlParser) isName() bool {
+	var token = p.peek()
+	if token.typ == sqlTokenQuotedIdent {
+		return true
+	}
+	if token.typ != sqlTokenIdent {
+		return false
+	}
+	_, reserved := sqlReservedKeywords[strings.ToUpper(token.value)]
+	return !reserved
+}
+
+// parseSelectSQL parses the SQL expression of the select request.
+func parseSelectSQL(sql string) (query *SelectQuery, err error) {
+	var tokens []sqlToken
+	if tokens, err = tokenizeSQL(sql); err != nil {
+		return
+	}
+	var p = &sqlParser{tokens: tokens}
+	query = &SelectQuery{limit: -1}
+
+	if err = p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	if p.acceptOperator("*") {
+		// SELECT *
+	} else if p.peek().typ == sqlTokenIdent && p.tokens[p.pos+1].value == "." && p.tokens[p.pos+2].value == "*" {
+		// SELECT s.*
+		p.pos += 3
+	} else {
+		for {
+			var projection = &sqlProjection{}
+			if projection.expr, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+			if p.acceptKeyword("AS") && !p.isName() {
+				return nil, p.unexpected()
+			}
+			if p.isName() {
+				projection.name = p.next().value
+			}
+			query.projections = append(query.projections, projection)
+			if !p.acceptOperator(",") {
+				break
+			}
+		}
+	}
+
+	if err = p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	if err = p.expectKeyword("S3Object"); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("AS") && !p.isName() {
+		return nil, p.unexpected()
+	}
+	if p.isName() {
+		query.alias = p.next().value
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if query.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		var token = p.next()
+		if token.typ != sqlTokenNumber {
+			return nil, newSQLError(SelectErrorParseUnexpectedToken, "invalid LIMIT at position %v", token.pos)
+		}
+		if query.limit, err = strconv.ParseInt(token.value, 10, 64); err != nil {
+			return nil, newSQLError(SelectErrorParseUnexpectedToken, "invalid LIMIT at position %v", token.pos)
+		}
+	}
+	if p.peek().typ != sqlTokenEOF {
+		return nil, p.unexpected()
+	}
+
+	if err = query.resolve(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// resolve removes the alias of the table from the column references, and checks the usage of the
+// aggregate functions.
+func (query *SelectQuery) resolve() error {
+	var resolveColumns = func(expr sqlExpr) {
+		if column, ok := expr.(*sqlColumn); ok && len(column.path) > 0 {
+			if strings.EqualFold(column.path[0], "S3Object") ||
+				(query.alias != "" && strings.EqualFold(column.path[0], query.alias)) {
+				column.path = column.path[1:]
+			}
+		}
+	}
+	for _, projection := range query.projections {
+		walkSQLExpr(projection.expr, resolveColumns)
+	}
+	if query.where != nil {
+		walkSQLExpr(query.where, resolveColumns)
+		var err error
+		walkSQLExpr(query.where, func(expr sqlExpr) {
+			if _, ok := expr.(*sqlAggregate); ok {
+				err = newSQLError(SelectErrorParseUnsupportedSyntax, "aggregate functions are not allowed in WHERE clause")
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// All the projections must be aggregated if any of them is an aggregate function.
+	var columnsOutsideAggregate bool
+	for i, projection := range query.projections {
+		if projection.name == "" {
+			if column, ok := projection.expr.(*sqlColumn); ok && len(column.path) > 0 {
+				projection.name = column.path[len(column.path)-1]
+			} else {
+				projection.name = "_" + strconv.Itoa(i+1)
+			}
+		}
+		var err error
+		var collect func(expr sqlExpr, inAggregate bool)
+		collect = func(expr sqlExpr, inAggregate bool) {
+			if aggregate, ok := expr.(*sqlAggregate); ok {
+				if inAggregate {
+					err = newSQLError(SelectErrorParseUnsupportedSyntax, "nested aggregate functions are not allowed")
+				}
+				query.aggregates = append(query.aggregates, aggregate)
+				inAggregate = true
+			}
+			if _, ok := expr.(*sqlColumn); ok && !inAggregate {
+				columnsOutsideAggregate = true
+			}
+			for _, child := range expr.children() {
+				collect(child, inAggregate)
+			}
+		}
+		collect(projection.expr, false)
+		if err != nil {
+			return err
+		}
+	}
+	if len(query.aggregates) > 0 && columnsOutsideAggregate {
+		return newSQLError(SelectErrorParseUnsupportedSyntax, "columns must be used in aggregate functions if any aggregate function is selected")
+	}
+	return nil
+}
+
+func (p *sqlParser) parseExpr() (sqlExpr, error) {
+	return p.parseOr()
+}
+
+func (p *sqlParser) parseOr() (sqlExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		var right sqlExpr
+		if right, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		var right sqlExpr
+		if right, err = p.parseNot(); err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.acceptKeyword("NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "NOT", operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *sqlParser) parseComparison() (sqlExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "!=", "<>", "<=", ">=", "<", ">"} {
+		if p.acceptOperator(op) {
+			var right sqlExpr
+			if right, err = p.parseAdditive(); err != nil {
+				return nil, err
+			}
+			return &sqlBinary{op: op, left: left, right: right}, nil
+		}
+	}
+	if p.acceptKeyword("IS") {
+		var not = p.acceptKeyword("NOT")
+		if !p.acceptKeyword("NULL") && !p.acceptKeyword("MISSING") {
+			return nil, p.unexpected()
+		}
+		return &sqlIsNull{operand: left, not: not}, nil
+	}
+	var not = p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("LIKE"):
+		var like = &sqlLike{operand: left, not: not}
+		if like.pattern, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		if p.acceptKeyword("ESCAPE") {
+			var token = p.next()
+			if token.typ != sqlTokenString || len([]rune(token.value)) != 1 {
+				return nil, newSQLError(SelectErrorParseUnexpectedToken, "invalid ESCAPE at position %v", token.pos)
+			}
+			like.escape = token.value
+		}
+		return like, nil
+	case p.acceptKeyword("BETWEEN"):
+		var between = &sqlBetween{operand: left, not: not}
+		if between.lower, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		if between.upper, err = p.parseAdditive(); err != nil {
+			return nil, err
+		}
+		return between, nil
+	case p.acceptKeyword("IN"):
+		var in = &sqlIn{operand: left, not: not}
+		if err = p.expectOperator("("); err != nil {
+			return nil, err
+		}
+		for {
+			var item sqlExpr
+			if item, err = p.parseAdditive(); err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, item)
+			if !p.acceptOperator(",") {
+				break
+			}
+		}
+		if err = p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+	case not:
+		return nil, p.unexpected()
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAdditive() (sqlExpr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op = p.peek().value
+		if p.peek().typ != sqlTokenOperator || (op != "+" && op != "-" && op != "||") {
+			return left, nil
+		}
+		p.next()
+		var right sqlExpr
+		if right, err = p.parseMultiplicative(); err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *sqlParser) parseMultiplicative() (sqlExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op = p.peek().value
+		if p.peek().typ != sqlTokenOperator || (op != "*" && op != "/" && op != "%") {
+			return left, nil
+		}
+		p.next()
+		var right sqlExpr
+		if right, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *sqlParser) parseUnary() (sqlExpr, error) {
+	if p.acceptOperator("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "-", operand: operand}, nil
+	}
+	if p.acceptOperator("+") {
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	var token = p.peek()
+	switch token.typ {
+	case sqlTokenNumber:
+		p.next()
+		if i, err := strconv.ParseInt(token.value, 10, 64); err == nil {
+			return &sqlLiteral{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, newSQLError(SelectErrorParseUnexpectedToken, "invalid number %q at position %v", token.value, token.pos)
+		}
+		return &sqlLiteral{value: f}, nil
+	case sqlTokenString:
+		p.next()
+		return &sqlLiteral{value: token.value}, nil
+	case sqlTokenQuotedIdent:
+		return p.parseColumn()
+	case sqlTokenOperator:
+		if p.acceptOperator("(") {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expectOperator(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+		}
+		return nil, p.unexpected()
+	case sqlTokenIdent:
+		var name = strings.ToUpper(token.value)
+		switch name {
+		case "TRUE", "FALSE":
+			p.next()
+			return &sqlLiteral{value: name == "TRUE"}, nil
+		case "NULL", "MISSING":
+			p.next()
+			return &sqlLiteral{value: nil}, nil
+		case "CAST":
+			return p.parseCast()
+		}
+		if p.tokens[p.pos+1].typ == sqlTokenOperator && p.tokens[p.pos+1].value == "(" {
+			return p.parseFunction()
+		}
+		if _, reserved := sqlReservedKeywords[name]; reserved {
+			return nil, p.unexpected()
+		}
+		return p.parseColumn()
+	}
+	return nil, p.unexpected()
+}
+
+// parseColumn parses the column reference, such as 's._1', 's.name', 'name' or 's."first name"'.
+func (p *sqlParser) parseColumn() (sqlExpr, error) {
+	var column = &sqlColumn{}
+	for {
+		var token = p.next()
+		if token.typ != sqlTokenIdent && token.typ != sqlTokenQuotedIdent {
+			p.pos--
+			return nil, p.unexpected()
+		}
+		column.path = append(column.path, token.value)
+		if !p.acceptOperator(".") {
+			return column, nil
+		}
+	}
+}
+
+func (p *sqlParser) parseCast() (sqlExpr, error) {
+	p.next()
+	var err error
+	var cast = &sqlCast{}
+	if err = p.expectOperator("("); err != nil {
+		return nil, err
+	}
+	if cast.operand, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if err = p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	var token = p.next()
+	cast.typ = strings.ToUpper(token.value)
+	switch cast.typ {
+	case "INT", "INTEGER", "FLOAT", "DECIMAL", "NUMERIC", "STRING", "VARCHAR", "CHAR", "BOOL", "BOOLEAN":
+	default:
+		return nil, newSQLError(SelectErrorParseUnsupportedSyntax, "unsupported type %q at position %v", token.value, token.pos)
+	}
+	if err = p.expectOperator(")"); err != nil {
+		return nil, err
+	}
+	return cast, nil
+}
+
+func (p *sqlParser) parseFunction() (sqlExpr, error) {
+	var token = p.next()
+	var name = strings.ToUpper(token.value)
+	p.next() // (
+	var err error
+	if _, isAggregate := sqlAggregateFunctions[name]; isAggregate {
+		var aggregate = &sqlAggregate{name: name}
+		if name == "COUNT" && p.acceptOperator("*") {
+			// COUNT(*)
+		} else if aggregate.arg, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if err = p.expectOperator(")"); err != nil {
+			return nil, err
+		}
+		return aggregate, nil
+	}
+	var argc, supported = sqlScalarFunctions[name]
+	if !supported {
+		return nil, newSQLError(SelectErrorParseUnsupportedSyntax, "unsupported function %q at position %v", token.value, token.pos)
+	}
+	var function = &sqlFunction{name: name}
+	for !p.isOperator(")") {
+		var arg sqlExpr
+		if arg, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		function.args = append(function.args, arg)
+		if !p.acceptOperator(",") {
+			break
+		}
+	}
+	if err = p.expectOperator(")"); err != nil {
+		return nil, err
+	}
+	if (argc >= 0 && len(function.args) != argc) || len(function.args) == 0 {
+		return nil, newSQLError(SelectErrorParseUnsupportedSyntax, "invalid number of arguments of %v at position %v", name, token.pos)
+	}
+	return function, nil
+}
+
+// ----- execution -----
+
+// isAggregate checks whether the query returns a single record of the aggregate functions.
+func (query *SelectQuery) isAggregate() bool {
+	return len(query.aggregates) > 0
+}
+
+// match checks whether the record satisfies the WHERE condition.
+func (query *SelectQuery) match(record sqlRecord) (bool, error) {
+	if query.where == nil {
+		return true, nil
+	}
+	v, err := query.where.eval(record)
+	if err != nil {
+		return false, err
+	}
+	b, ok := sqlToBool(v)
+	return ok && b, nil
+}
+
+// accumulate updates the aggregate functions with the record.
+func (query *SelectQuery) accumulate(record sqlRecord) error {
+	for _, aggregate := range query.aggregates {
+		if err := aggregate.update(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// project returns the names and values of the selected columns of the record. The record is nil
+// for the result of the aggregate functions.
+func (query *SelectQuery) project(record sqlRecord) (names []string, values []interface{}, err error) {
+	if query.projections == nil {
+		names, values = record.columns()
+		return
+	}
+	names = make([]string, len(query.projections))
+	values = make([]interface{}, len(query.projections))
+	for i, projection := range query.projections {
+		names[i] = projection.name
+		if values[i], err = projection.expr.eval(record); err != nil {
+			return nil, nil, err
+		}
+	}
+	return
+}
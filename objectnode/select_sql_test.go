@@ -0,0 +1,149 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testSQLRecord map[string]interface{}
+
+func (r testSQLRecord) value(path []string) interface{} {
+	return r[path[len(path)-1]]
+}
+
+func (r testSQLRecord) columns() (names []string, values []interface{}) {
+	for _, name := range []string{"name", "age", "city"} {
+		names = append(names, name)
+		values = append(values, r[name])
+	}
+	return
+}
+
+func TestSelectSQLMatch(t *testing.T) {
+	var record = testSQLRecord{"name": "Alice", "age": "30", "city": "Beijing", "score": int64(90)}
+	var cases = []struct {
+		sql   string
+		match bool
+	}{
+		{"SELECT * FROM S3Object", true},
+		{"SELECT * FROM S3Object WHERE age > 20", true},
+		{"SELECT * FROM S3Object s WHERE s.age > 20 AND s.city = 'Shanghai'", false},
+		{"SELECT * FROM S3Object WHERE age >= 30 OR city = 'Shanghai'", true},
+		{"SELECT * FROM S3Object WHERE NOT (name <> 'Alice')", true},
+		{"SELECT * FROM S3Object WHERE CAST(age AS INT) + 1 = 31", true},
+		{"SELECT * FROM S3Object WHERE age BETWEEN 20 AND 29", false},
+		{"SELECT * FROM S3Object WHERE city IN ('Beijing', 'Shanghai')", true},
+		{"SELECT * FROM S3Object WHERE name LIKE 'A%e'", true},
+		{"SELECT * FROM S3Object WHERE name LIKE 'a%'", false},
+		{"SELECT * FROM S3Object WHERE LOWER(name) LIKE 'a_ice'", true},
+		{"SELECT * FROM S3Object WHERE missing IS NULL", true},
+		{"SELECT * FROM S3Object WHERE missing = 1", false},
+		{"SELECT * FROM S3Object WHERE score % 7 = 6 AND name || city = 'AliceBeijing'", true},
+	}
+	for _, c := range cases {
+		var query, err = parseSelectSQL(c.sql)
+		if err != nil {
+			t.Fatalf("parse SQL fail: sql(%v) err(%v)", c.sql, err)
+		}
+		var match bool
+		if match, err = query.match(record); err != nil || match != c.match {
+			t.Fatalf("match result mismatch: sql(%v) expect(%v) actual(%v) err(%v)", c.sql, c.match, match, err)
+		}
+	}
+}
+
+func TestSelectSQLProject(t *testing.T) {
+	var record = testSQLRecord{"name": "Alice", "age": "30", "city": "Beijing"}
+	var query, err = parseSelectSQL("select s.name, UPPER(city) AS c, CAST(age AS INT) * 2 from s3object s limit 10")
+	if err != nil {
+		t.Fatalf("parse SQL fail: err(%v)", err)
+	}
+	if query.limit != 10 || query.isAggregate() {
+		t.Fatalf("query mismatch: limit(%v) aggregate(%v)", query.limit, query.isAggregate())
+	}
+	names, values, err := query.project(record)
+	if err != nil {
+		t.Fatalf("project fail: err(%v)", err)
+	}
+	if !reflect.DeepEqual(names, []string{"name", "c", "_3"}) ||
+		!reflect.DeepEqual(values, []interface{}{"Alice", "BEIJING", int64(60)}) {
+		t.Fatalf("projection mismatch: names(%v) values(%v)", names, values)
+	}
+
+	if query, err = parseSelectSQL("SELECT * FROM S3Object"); err != nil {
+		t.Fatalf("parse SQL fail: err(%v)", err)
+	}
+	if names, _, _ = query.project(record); !reflect.DeepEqual(names, []string{"name", "age", "city"}) {
+		t.Fatalf("projection of all columns mismatch: names(%v)", names)
+	}
+}
+
+func TestSelectSQLAggregate(t *testing.T) {
+	var query, err = parseSelectSQL("SELECT COUNT(*), SUM(age), AVG(age), MIN(name), MAX(age) FROM S3Object WHERE age > 10")
+	if err != nil {
+		t.Fatalf("parse SQL fail: err(%v)", err)
+	}
+	if !query.isAggregate() {
+		t.Fatalf("query should be aggregate")
+	}
+	for _, record := range []testSQLRecord{
+		{"name": "Bob", "age": "20"},
+		{"name": "Alice", "age": "40"},
+		{"name": "Carol", "age": "5"},
+	} {
+		var match bool
+		if match, err = query.match(record); err != nil {
+			t.Fatalf("match fail: err(%v)", err)
+		}
+		if match {
+			if err = query.accumulate(record); err != nil {
+				t.Fatalf("accumulate fail: err(%v)", err)
+			}
+		}
+	}
+	_, values, err := query.project(nil)
+	if err != nil {
+		t.Fatalf("project aggregate fail: err(%v)", err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{int64(2), int64(60), float64(30), "Alice", int64(40)}) {
+		t.Fatalf("aggregate result mismatch: values(%v)", values)
+	}
+}
+
+func TestSelectSQLInvalid(t *testing.T) {
+	var invalids = []string{
+		"",
+		"SELECT FROM S3Object",
+		"SELECT * FROM S3Object WHERE",
+		"SELECT * FROM Table",
+		"SELECT * FROM S3Object WHERE name = 'Alice",
+		"SELECT * FROM S3Object WHERE COUNT(*) > 1",
+		"SELECT name, COUNT(*) FROM S3Object",
+		"SELECT SUM(COUNT(*)) FROM S3Object",
+		"SELECT * FROM S3Object LIMIT -1",
+		"SELECT * FROM S3Object ORDER BY name",
+	}
+	for _, sql := range invalids {
+		var _, err = parseSelectSQL(sql)
+		if err == nil {
+			t.Fatalf("invalid SQL should be rejected: sql(%v)", sql)
+		}
+		if _, is := err.(*sqlError); !is {
+			t.Fatalf("error of invalid SQL should be SQL error: sql(%v) err(%v)", sql, err)
+		}
+	}
+}
@@ -15,13 +15,16 @@
 package objectnode
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/exporter"
@@ -77,8 +80,132 @@ const (
 	// The configuration in the example will allow ObjectNode to automatically resolve "* .object.chubao.io".
 	configDomains = "domains"
 
+	// The character creation array configuration item is used to configure the domain name bound to the static
+	// website endpoint of buckets. Requests sent to "<bucket>.<domain>" are served anonymously with the website
+	// configuration of the bucket, and are allowed only by the bucket policy. The website domains must differ
+	// from the domains of the object storage interface.
+	// Example:
+	//		{
+	//			"websiteDomains": [
+	//				"website.chubao.io"
+	//			]
+	//		}
+	// The configuration in the example will allow ObjectNode to serve the website of bucket "docs" at
+	// "docs.website.chubao.io".
+	configWebsiteDomains = "websiteDomains"
+
 	disabledActions               = "disabledActions"
 	configSignatureIgnoredActions = "signatureIgnoredActions"
+
+	// A bool type configuration item used to enable the background worker which applies the lifecycle
+	// configuration of buckets, such as expiring objects and aborting incomplete multipart uploads.
+	// The worker scans all volumes of the cluster, so it should be enabled on only one ObjectNode of
+	// the cluster, otherwise the objects are expired by several workers concurrently.
+	// Default: false
+	// Example:
+	//		{
+	//			"enableLifecycle": true
+	//		}
+	configEnableLifecycle = "enableLifecycle"
+
+	// Integer type configuration item used to configure the interval in seconds between two rounds
+	// of the lifecycle worker.
+	// Default: 3600
+	// Example:
+	//		{
+	//			"lifecycleInterval": 3600
+	//		}
+	configLifecycleInterval = "lifecycleInterval"
+
+	// String type configuration item used to specify the key file which holds the master keys of
+	// server side encryption with ObjectNode managed keys (SSE-S3). Requests for SSE-S3 are rejected
+	// if it is not configured. All ObjectNodes of a cluster must be configured with the same keys.
+	// Example:
+	//		{
+	//			"sseKeyFile": "/cfs/conf/sse_keys.json"
+	//		}
+	configSSEKeyFile = "sseKeyFile"
+
+	// String type configuration item used to specify the key file which holds the keys signing the
+	// session tokens of temporary credentials issued by AssumeRole. AssumeRole is not supported and
+	// requests signed with temporary credentials are rejected if it is not configured. All ObjectNodes
+	// of a cluster must be configured with the same keys.
+	// Example:
+	//		{
+	//			"stsKeyFile": "/cfs/conf/sts_keys.json"
+	//		}
+	configSTSKeyFile = "stsKeyFile"
+
+	// String type configuration item used to specify the file which holds the key shared with the
+	// meta nodes, which verify that the releases of legal holds and the bypasses of GOVERNANCE
+	// retentions are checked by the ObjectNode. These changes are refused if it is not configured.
+	// The ObjectNodes and the meta nodes of a cluster must be configured with the same key.
+	// Example:
+	//		{
+	//			"objectLockKeyFile": "/cfs/conf/object_lock.key"
+	//		}
+	configObjectLockKeyFile = "objectLockKeyFile"
+
+	// A bool type configuration item used to enable the background worker which replicates objects
+	// to the destination buckets of the replication configuration of buckets.
+	// When several ObjectNodes serve the same cluster, the objects written through each of them are
+	// replicated by itself, and the pending objects are rescanned by every enabled worker.
+	// The replication configuration of buckets is accepted even if the worker is disabled, and
+	// the objects stay pending until a worker is enabled on any ObjectNode.
+	// Default: false
+	// Example:
+	//		{
+	//			"enableReplication": true
+	//		}
+	configEnableReplication = "enableReplication"
+
+	// Integer type configuration item used to configure the interval in seconds between two rounds
+	// of the replication worker rescanning the objects whose replication is pending or failed.
+	// Default: 3600
+	// Example:
+	//		{
+	//			"replicationInterval": 3600
+	//		}
+	configReplicationInterval = "replicationInterval"
+
+	// String type configuration item used to specify the file which holds the endpoints and credentials
+	// of the other clusters which objects can be replicated to. Only the destination buckets on the same
+	// cluster are allowed if it is not configured.
+	// Example:
+	//		{
+	//			"replicationTargetFile": "/cfs/conf/replication_targets.json"
+	//		}
+	configReplicationTargetFile = "replicationTargetFile"
+
+	// String type configuration item used to specify the file which holds the targets of bucket event
+	// notifications, such as HTTP webhooks and local queue directories. The notification configuration
+	// of buckets refers to the targets by their ARNs. Event notifications are unavailable if it is not
+	// configured.
+	// Example:
+	//		{
+	//			"notificationTargetFile": "/cfs/conf/notification_targets.json"
+	//		}
+	configNotificationTargetFile = "notificationTargetFile"
+
+	// A bool type configuration item used to enable the server access logging of buckets. The access
+	// logs of the requests served by each ObjectNode are batched and written into the target buckets
+	// by itself.
+	// Default: true
+	// Example:
+	//		{
+	//			"enableAccessLog": false
+	//		}
+	configEnableAccessLog = "enableAccessLog"
+
+	// Integer type configuration item used to configure the interval in seconds between two writes
+	// of the batched access logs into the target buckets. The logs of a target are written earlier
+	// if they grow large.
+	// Default: 300
+	// Example:
+	//		{
+	//			"accessLogInterval": 300
+	//		}
+	configAccessLogInterval = "accessLogInterval"
 )
 
 // Default of configuration value
@@ -93,16 +220,26 @@ var (
 )
 
 type ObjectNode struct {
-	domains    []string
-	wildcards  Wildcards
-	listen     string
-	region     string
-	httpServer *http.Server
-	vm         *VolumeManager
-	mc         *master.MasterClient
-	state      uint32
-	wg         sync.WaitGroup
-	userStore  UserInfoStore
+	domains        []string
+	websiteDomains []string
+	wildcards      Wildcards
+	listen         string
+	region         string
+	httpServer     *http.Server
+	vm             *VolumeManager
+	mc             *master.MasterClient
+	state          uint32
+	wg             sync.WaitGroup
+	userStore      UserInfoStore
+	qosManager     *QoSManager
+	clusterBlock   *ClusterPublicAccessBlock
+	lcWorker       *LifecycleWorker
+	replWorker     *ReplicationWorker
+	replTargets    map[string]*ReplicationTarget // replication targets by region
+	logWorker      *LoggingWorker
+
+	notificationTargets map[string]NotificationTarget // notification targets by ARN
+	stsKeys             *STSKeyStore                  // keys signing the session tokens of temporary credentials
 
 	signatureIgnoredActions proto.Actions // signature ignored actions
 	disabledActions         proto.Actions // disabled actions
@@ -145,6 +282,10 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	}
 	log.LogInfof("loadConfig: setup config: %v(%v)", configDomains, domains)
 
+	// parse website domain
+	o.websiteDomains = cfg.GetStringSlice(configWebsiteDomains)
+	log.LogInfof("loadConfig: setup config: %v(%v)", configWebsiteDomains, o.websiteDomains)
+
 	// parse master config
 	masters := cfg.GetStringSlice(configMasterAddr)
 	if len(masters) == 0 {
@@ -176,9 +317,95 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
 
+	// parse server side encryption key file
+	var keyStore *SSEKeyStore
+	if keyFile := cfg.GetString(configSSEKeyFile); keyFile != "" {
+		if keyStore, err = LoadSSEKeyStore(keyFile); err != nil {
+			log.LogErrorf("loadConfig: load SSE key file fail: file(%v) err(%v)", keyFile, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configSSEKeyFile, keyFile)
+	}
+
+	// parse security token service key file
+	if keyFile := cfg.GetString(configSTSKeyFile); keyFile != "" {
+		if o.stsKeys, err = LoadSTSKeyStore(keyFile); err != nil {
+			log.LogErrorf("loadConfig: load STS key file fail: file(%v) err(%v)", keyFile, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configSTSKeyFile, keyFile)
+	}
+
+	// parse object lock key file
+	var objectLockKey []byte
+	if keyFile := cfg.GetString(configObjectLockKeyFile); keyFile != "" {
+		var data []byte
+		if data, err = ioutil.ReadFile(keyFile); err != nil {
+			log.LogErrorf("loadConfig: load object lock key file fail: file(%v) err(%v)", keyFile, err)
+			return
+		}
+		if objectLockKey = bytes.TrimSpace(data); len(objectLockKey) == 0 {
+			err = fmt.Errorf("empty object lock key: file(%v)", keyFile)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configObjectLockKeyFile, keyFile)
+	}
+
 	o.mc = master.NewMasterClient(masters, false)
-	o.vm = NewVolumeManager(masters, strict)
+	o.vm = NewVolumeManagerWithKeyStore(masters, strict, keyStore, objectLockKey)
 	o.userStore = NewUserInfoStore(masters, strict)
+	o.qosManager = NewQoSManager()
+	o.clusterBlock = NewClusterPublicAccessBlock(o.mc)
+
+	// parse lifecycle config
+	if cfg.GetBool(configEnableLifecycle) {
+		var interval = defaultLifecycleInterval
+		if seconds := cfg.GetInt64(configLifecycleInterval); seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		o.lcWorker = NewLifecycleWorker(o.mc, o.vm, interval)
+		log.LogInfof("loadConfig: lifecycle worker enabled: interval(%v)", interval)
+	}
+
+	// parse replication config
+	o.replTargets = make(map[string]*ReplicationTarget)
+	if targetFile := cfg.GetString(configReplicationTargetFile); targetFile != "" {
+		if o.replTargets, err = LoadReplicationTargets(targetFile); err != nil {
+			log.LogErrorf("loadConfig: load replication target file fail: file(%v) err(%v)", targetFile, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v) targets(%v)", configReplicationTargetFile, targetFile,
+			len(o.replTargets))
+	}
+	if cfg.GetBool(configEnableReplication) {
+		var interval = defaultReplicationInterval
+		if seconds := cfg.GetInt64(configReplicationInterval); seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		o.replWorker = NewReplicationWorker(o.mc, o.vm, o.replTargets, interval)
+		log.LogInfof("loadConfig: replication worker enabled: interval(%v)", interval)
+	}
+
+	// parse notification targets
+	o.notificationTargets = make(map[string]NotificationTarget)
+	if targetFile := cfg.GetString(configNotificationTargetFile); targetFile != "" {
+		if o.notificationTargets, err = LoadNotificationTargets(targetFile); err != nil {
+			log.LogErrorf("loadConfig: load notification target file fail: file(%v) err(%v)", targetFile, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v) targets(%v)", configNotificationTargetFile, targetFile,
+			len(o.notificationTargets))
+	}
+
+	// parse access log config
+	if cfg.GetBoolWithDefault(configEnableAccessLog, true) {
+		var interval = defaultLoggingFlushInterval
+		if seconds := cfg.GetInt64(configAccessLogInterval); seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+		o.logWorker = NewLoggingWorker(o.vm, interval)
+		log.LogInfof("loadConfig: access log worker enabled: interval(%v)", interval)
+	}
 
 	return
 }
@@ -206,6 +433,8 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 	}
 	o.updateRegion(ci.Cluster)
 	log.LogInfof("handleStart: get cluster information: region(%v)", o.region)
+	o.clusterBlock.store(ci.PublicAccessBlock)
+	o.clusterBlock.Start()
 
 	// start rest api
 	if err = o.startMuxRestAPI(); err != nil {
@@ -213,6 +442,26 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 		return
 	}
 
+	// start lifecycle worker
+	if o.lcWorker != nil {
+		o.lcWorker.Start()
+	}
+
+	// start replication worker
+	if o.replWorker != nil {
+		o.replWorker.Start()
+	}
+
+	// start notification targets
+	for _, target := range o.notificationTargets {
+		target.Start()
+	}
+
+	// start access log worker
+	if o.logWorker != nil {
+		o.logWorker.Start()
+	}
+
 	exporter.Init(cfg.GetString("role"), cfg)
 	exporter.RegistConsul(ci.Cluster, cfg.GetString("role"), cfg)
 
@@ -226,6 +475,24 @@ func handleShutdown(s common.Server) {
 		return
 	}
 	o.shutdownRestAPI()
+	if o.qosManager != nil {
+		o.qosManager.Close()
+	}
+	if o.clusterBlock != nil {
+		o.clusterBlock.Stop()
+	}
+	if o.lcWorker != nil {
+		o.lcWorker.Stop()
+	}
+	if o.replWorker != nil {
+		o.replWorker.Stop()
+	}
+	for _, target := range o.notificationTargets {
+		target.Stop()
+	}
+	if o.logWorker != nil {
+		o.logWorker.Stop()
+	}
 }
 
 func (o *ObjectNode) startMuxRestAPI() (err error) {
@@ -236,13 +503,23 @@ func (o *ObjectNode) startMuxRestAPI() (err error) {
 		o.corsMiddleware,
 		o.traceMiddleware,
 		o.authMiddleware,
+		o.qosMiddleware,
 		o.policyCheckMiddleware,
 		o.contentMiddleware,
 	)
 
+	// The requests sent to the website endpoints are dispatched before the object storage interface.
+	var handler http.Handler = router
+	if len(o.websiteDomains) > 0 {
+		root := mux.NewRouter().SkipClean(true)
+		o.registerWebsiteRouters(root)
+		root.PathPrefix("/").Handler(router)
+		handler = root
+	}
+
 	var server = &http.Server{
 		Addr:    ":" + o.listen,
-		Handler: router,
+		Handler: handler,
 	}
 
 	go func() {
@@ -0,0 +1,319 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/serv-side-encryption.html
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	SSEAlgorithmAES256 = "AES256"
+
+	sseKeySize = 32
+)
+
+var (
+	ErrSSENotConfigured       = errors.New("server side encryption is not configured")
+	ErrSSECustomerKeyRequired = errors.New("server side encryption customer key is required")
+	ErrSSECustomerKeyMismatch = errors.New("server side encryption customer key mismatch")
+	ErrSSEMasterKeyNotFound   = errors.New("server side encryption master key not found")
+)
+
+// ServerSideEncryptionConfiguration is the default encryption configuration of bucket.
+type ServerSideEncryptionConfiguration struct {
+	XMLName xml.Name   `xml:"ServerSideEncryptionConfiguration" json:"-"`
+	Xmlns   string     `xml:"xmlns,attr,omitempty" json:"-"`
+	Rules   []*SSERule `xml:"Rule" json:"rules"`
+}
+
+type SSERule struct {
+	ApplyServerSideEncryptionByDefault *SSEByDefault `xml:"ApplyServerSideEncryptionByDefault" json:"default"`
+}
+
+type SSEByDefault struct {
+	SSEAlgorithm string `xml:"SSEAlgorithm" json:"algorithm"`
+}
+
+func (config *ServerSideEncryptionConfiguration) validate() bool {
+	if len(config.Rules) != 1 {
+		return false
+	}
+	var rule = config.Rules[0]
+	if rule == nil || rule.ApplyServerSideEncryptionByDefault == nil {
+		return false
+	}
+	// Only the keys managed by ObjectNode are supported, there is no KMS integration.
+	return rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm == SSEAlgorithmAES256
+}
+
+// defaultOption returns the encryption option which applies to the objects put without any
+// encryption headers.
+func (config *ServerSideEncryptionConfiguration) defaultOption() *SSEOption {
+	return &SSEOption{Algorithm: config.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm}
+}
+
+func parseEncryptionConfig(bytes []byte) (config *ServerSideEncryptionConfiguration, err error) {
+	config = &ServerSideEncryptionConfiguration{}
+	if err = xml.Unmarshal(bytes, config); err != nil {
+		return
+	}
+	if ok := config.validate(); !ok {
+		return nil, errors.New("invalid encryption configuration")
+	}
+	return
+}
+
+func storeBucketEncryption(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSEncryption, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketEncryption(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSEncryption); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SSEOption is the server side encryption requested for an object.
+// The customer key is only specified for SSE-C, otherwise the object is encrypted
+// with a master key of ObjectNode (SSE-S3).
+type SSEOption struct {
+	Algorithm      string
+	CustomerKey    []byte
+	CustomerKeyMD5 string
+}
+
+func (opt *SSEOption) isCustomer() bool {
+	return opt != nil && len(opt.CustomerKey) > 0
+}
+
+// NewSSECustomerOption validates the SSE-C parameters of a request and returns the option.
+// The key and the MD5 of the key are both base64 encoded.
+func NewSSECustomerOption(algorithm, encodedKey, encodedKeyMD5 string) (opt *SSEOption, err error) {
+	if algorithm != SSEAlgorithmAES256 {
+		return nil, errors.New("invalid encryption algorithm")
+	}
+	var key []byte
+	if key, err = base64.StdEncoding.DecodeString(encodedKey); err != nil || len(key) != sseKeySize {
+		return nil, errors.New("invalid customer key")
+	}
+	var sum = md5.Sum(key)
+	var keyMD5 = base64.StdEncoding.EncodeToString(sum[:])
+	if encodedKeyMD5 != "" && encodedKeyMD5 != keyMD5 {
+		return nil, errors.New("customer key MD5 mismatch")
+	}
+	opt = &SSEOption{
+		Algorithm:      algorithm,
+		CustomerKey:    key,
+		CustomerKeyMD5: keyMD5,
+	}
+	return
+}
+
+// SSEKeyStore holds the master keys which wrap the data keys of SSE-S3 objects.
+// The keys are loaded from a JSON file, for example:
+//
+//	{
+//		"active": "key-2",
+//		"keys": {
+//			"key-1": "<base64 encoded 32 bytes key>",
+//			"key-2": "<base64 encoded 32 bytes key>"
+//		}
+//	}
+//
+// New objects are always encrypted with the active key, and the retired keys are kept
+// to decrypt the objects written before the rotation.
+type SSEKeyStore struct {
+	active string
+	keys   map[string][]byte
+}
+
+func (ks *SSEKeyStore) activeKey() (id string, key []byte) {
+	return ks.active, ks.keys[ks.active]
+}
+
+func (ks *SSEKeyStore) key(id string) (key []byte, err error) {
+	var exist bool
+	if key, exist = ks.keys[id]; !exist {
+		return nil, ErrSSEMasterKeyNotFound
+	}
+	return
+}
+
+func LoadSSEKeyStore(filename string) (ks *SSEKeyStore, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+	var raw = struct {
+		Active string            `json:"active"`
+		Keys   map[string]string `json:"keys"`
+	}{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	ks = &SSEKeyStore{
+		active: raw.Active,
+		keys:   make(map[string][]byte),
+	}
+	for id, encoded := range raw.Keys {
+		var key []byte
+		if key, err = base64.StdEncoding.DecodeString(encoded); err != nil || len(key) != sseKeySize {
+			return nil, errors.NewErrorf("invalid master key: id(%v)", id)
+		}
+		ks.keys[id] = key
+	}
+	if _, exist := ks.keys[ks.active]; !exist {
+		return nil, errors.NewErrorf("active master key not found: id(%v)", ks.active)
+	}
+	return
+}
+
+// objectEncryption is the encryption information of an object stored in the 'oss:sse' extended attribute.
+// The data key of the object is wrapped by a master key for SSE-S3 or by the customer key for SSE-C.
+type objectEncryption struct {
+	Algorithm      string   `json:"alg"`
+	KeyID          string   `json:"kid,omitempty"`
+	CustomerKeyMD5 string   `json:"ckmd5,omitempty"`
+	WrappedKey     []byte   `json:"key"`
+	Parts          []uint64 `json:"parts,omitempty"` // plaintext size of each part of multipart objects
+}
+
+func (enc *objectEncryption) isCustomer() bool {
+	return enc.CustomerKeyMD5 != ""
+}
+
+func (enc *objectEncryption) encode() []byte {
+	data, _ := json.Marshal(enc)
+	return data
+}
+
+func parseObjectEncryption(raw []byte) (enc *objectEncryption, err error) {
+	enc = &objectEncryption{}
+	if err = json.Unmarshal(raw, enc); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// streams returns the plaintext size of each encrypted stream of the object.
+// Objects put at once consist of a single stream, while the multipart objects consist of
+// a stream for each part.
+func (enc *objectEncryption) streams(encryptedSize uint64) []uint64 {
+	if len(enc.Parts) > 0 {
+		return enc.Parts
+	}
+	return []uint64{sseDecryptedSize(encryptedSize)}
+}
+
+// plainSize returns the plaintext size of the object.
+func (enc *objectEncryption) plainSize(encryptedSize uint64) (size uint64) {
+	for _, partSize := range enc.streams(encryptedSize) {
+		size += partSize
+	}
+	return
+}
+
+// newObjectEncryption generates a new data key and wraps it with the key specified by the option.
+func newObjectEncryption(opt *SSEOption, ks *SSEKeyStore) (enc *objectEncryption, dataKey []byte, err error) {
+	enc = &objectEncryption{Algorithm: SSEAlgorithmAES256}
+	var kek []byte
+	if opt.isCustomer() {
+		kek = opt.CustomerKey
+		enc.CustomerKeyMD5 = opt.CustomerKeyMD5
+	} else {
+		if ks == nil {
+			return nil, nil, ErrSSENotConfigured
+		}
+		enc.KeyID, kek = ks.activeKey()
+	}
+	dataKey = make([]byte, sseKeySize)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return
+	}
+	if enc.WrappedKey, err = sealSSEKey(kek, dataKey); err != nil {
+		return
+	}
+	return
+}
+
+// dataKey unwraps the data key of the object.
+func (enc *objectEncryption) dataKey(opt *SSEOption, ks *SSEKeyStore) (dataKey []byte, err error) {
+	var kek []byte
+	if enc.isCustomer() {
+		if !opt.isCustomer() {
+			return nil, ErrSSECustomerKeyRequired
+		}
+		if opt.CustomerKeyMD5 != enc.CustomerKeyMD5 {
+			return nil, ErrSSECustomerKeyMismatch
+		}
+		kek = opt.CustomerKey
+	} else {
+		if ks == nil {
+			return nil, ErrSSENotConfigured
+		}
+		if kek, err = ks.key(enc.KeyID); err != nil {
+			return
+		}
+	}
+	return openSSEKey(kek, enc.WrappedKey)
+}
+
+func sealSSEKey(kek, dataKey []byte) (wrapped []byte, err error) {
+	var aead cipher.AEAD
+	if aead, err = newSSEAEAD(kek); err != nil {
+		return
+	}
+	var nonce = make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+	return aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func openSSEKey(kek, wrapped []byte) (dataKey []byte, err error) {
+	var aead cipher.AEAD
+	if aead, err = newSSEAEAD(kek); err != nil {
+		return
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("invalid wrapped data key")
+	}
+	var nonce = wrapped[:aead.NonceSize()]
+	return aead.Open(nil, nonce, wrapped[aead.NonceSize():], nil)
+}
+
+func newSSEAEAD(key []byte) (aead cipher.AEAD, err error) {
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,235 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketEncryption.html
+func (o *ObjectNode) getBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = vol.metaLoader.loadEncryption(); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: load encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if encryption == nil {
+		_ = NoSuchEncryptionConfiguration.ServeResponse(w, r)
+		return
+	}
+
+	var output = ServerSideEncryptionConfiguration{
+		Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/",
+		Rules: encryption.Rules,
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketEncryption.html
+func (o *ObjectNode) putBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	// The default encryption uses the master keys of ObjectNode.
+	if vol.keyStore == nil {
+		_ = SSENotConfigured.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = parseEncryptionConfig(bytes); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: parse encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(encryption); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketEncryption(newBytes, vol); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: store encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeEncryption(encryption)
+
+	log.LogInfof("Audit: put bucket encryption: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketEncryption.html
+func (o *ObjectNode) deleteBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	if err = deleteBucketEncryption(vol); err != nil {
+		log.LogErrorf("deleteBucketEncryptionHandler: delete encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeEncryption(nil)
+
+	log.LogInfof("Audit: delete bucket encryption: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
+
+// parseSSEOption parses the server side encryption requested for the object to write.
+// Without any encryption headers, the default encryption of the bucket applies, and nil is
+// returned if the bucket has no default encryption either.
+func parseSSEOption(r *http.Request, vol *Volume) (opt *SSEOption, errorCode *ErrorCode) {
+	if opt, errorCode = parseSSECustomerKey(r, HeaderNameXAmzSSECustomerAlgorithm,
+		HeaderNameXAmzSSECustomerKey, HeaderNameXAmzSSECustomerKeyMD5); errorCode != nil || opt != nil {
+		return
+	}
+	if algorithm := r.Header.Get(HeaderNameXAmzServerSideEncryption); algorithm != "" {
+		if algorithm != SSEAlgorithmAES256 {
+			return nil, InvalidEncryptionAlgorithm
+		}
+		return &SSEOption{Algorithm: algorithm}, nil
+	}
+	var encryption *ServerSideEncryptionConfiguration
+	var err error
+	if encryption, err = vol.metaLoader.loadEncryption(); err != nil {
+		log.LogErrorf("parseSSEOption: load encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return nil, InternalErrorCode(err)
+	}
+	if encryption != nil {
+		opt = encryption.defaultOption()
+	}
+	return
+}
+
+// parseSSECustomerKey parses the customer key specified by the SSE-C headers, and returns nil
+// if the headers are absent.
+func parseSSECustomerKey(r *http.Request, algorithmHeader, keyHeader, keyMD5Header string) (opt *SSEOption, errorCode *ErrorCode) {
+	var algorithm = r.Header.Get(algorithmHeader)
+	var key = r.Header.Get(keyHeader)
+	if algorithm == "" && key == "" {
+		return nil, nil
+	}
+	if algorithm != SSEAlgorithmAES256 {
+		return nil, InvalidEncryptionAlgorithm
+	}
+	var err error
+	if opt, err = NewSSECustomerOption(algorithm, key, r.Header.Get(keyMD5Header)); err != nil {
+		log.LogWarnf("parseSSECustomerKey: invalid customer key: requestID(%v) err(%v)", GetRequestID(r), err)
+		return nil, InvalidSSECustomerKey
+	}
+	return
+}
+
+// checkSSECustomerKey checks whether the customer key of the request matches the key which
+// encrypted the object. It always passes for the objects not encrypted with SSE-C.
+func checkSSECustomerKey(fileInfo *FSFileInfo, opt *SSEOption) *ErrorCode {
+	if fileInfo.SSEKeyMD5 == "" {
+		return nil
+	}
+	if !opt.isCustomer() {
+		return SSECustomerKeyRequired
+	}
+	if opt.CustomerKeyMD5 != fileInfo.SSEKeyMD5 {
+		return AccessDenied
+	}
+	return nil
+}
+
+// sseErrorCode returns the error code of the server side encryption error, or nil if the
+// error is not caused by server side encryption.
+func sseErrorCode(err error) *ErrorCode {
+	switch err {
+	case ErrSSENotConfigured, ErrSSEMasterKeyNotFound:
+		return SSENotConfigured
+	case ErrSSECustomerKeyRequired:
+		return SSECustomerKeyRequired
+	case ErrSSECustomerKeyMismatch:
+		return AccessDenied
+	}
+	return nil
+}
+
+// setSSEResponseHeaders sets the response headers which describe the encryption of the object.
+func setSSEResponseHeaders(w http.ResponseWriter, fileInfo *FSFileInfo) {
+	if fileInfo.SSEAlgorithm == "" {
+		return
+	}
+	if fileInfo.SSEKeyMD5 != "" {
+		w.Header()[HeaderNameXAmzSSECustomerAlgorithm] = []string{fileInfo.SSEAlgorithm}
+		w.Header()[HeaderNameXAmzSSECustomerKeyMD5] = []string{fileInfo.SSEKeyMD5}
+		return
+	}
+	w.Header()[HeaderNameXAmzServerSideEncryption] = []string{fileInfo.SSEAlgorithm}
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+// The plaintext of an encrypted stream is split into chunks which are sealed separately with
+// AES-GCM, so that a ranged read only needs to decrypt the chunks covering the range.
+// The layout of an encrypted stream is:
+//
+//	| salt (8 bytes) | sealed chunk 0 | sealed chunk 1 | ... | sealed chunk N |
+//
+// Each sealed chunk holds up to 64KB plaintext followed by a 16 bytes authentication tag, and
+// only the last chunk may be shorter. The nonce of a chunk is the random salt of the stream
+// followed by the big-endian chunk index, so nonces never repeat under the same data key even
+// if several streams, such as the parts of a multipart upload, are encrypted with it.
+const (
+	sseChunkSize  = 64 * 1024
+	sseSaltSize   = 8
+	sseTagSize    = 16
+	sseSealedSize = sseChunkSize + sseTagSize
+)
+
+// sseEncryptedSize returns the size of the encrypted stream of the specified plaintext size.
+func sseEncryptedSize(size uint64) uint64 {
+	var chunks = (size + sseChunkSize - 1) / sseChunkSize
+	return sseSaltSize + size + chunks*sseTagSize
+}
+
+// sseDecryptedSize returns the plaintext size of the specified encrypted stream size.
+func sseDecryptedSize(size uint64) uint64 {
+	if size <= sseSaltSize {
+		return 0
+	}
+	size -= sseSaltSize
+	var plain = size / sseSealedSize * sseChunkSize
+	if rest := size % sseSealedSize; rest > sseTagSize {
+		plain += rest - sseTagSize
+	}
+	return plain
+}
+
+func sseChunkNonce(salt []byte, index uint64) []byte {
+	var nonce = make([]byte, sseSaltSize+4)
+	copy(nonce, salt)
+	binary.BigEndian.PutUint32(nonce[sseSaltSize:], uint32(index))
+	return nonce
+}
+
+// sseEncryptReader encrypts the data read from the underlying reader into an encrypted stream.
+type sseEncryptReader struct {
+	reader io.Reader
+	aead   cipher.AEAD
+	salt   []byte
+	index  uint64
+	plain  []byte
+	out    []byte
+	eof    bool
+}
+
+func (r *sseEncryptReader) Read(p []byte) (n int, err error) {
+	for len(r.out) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		var readN int
+		readN, err = io.ReadFull(r.reader, r.plain)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			r.eof = true
+			err = nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if readN > 0 {
+			r.out = r.aead.Seal(r.out[:0], sseChunkNonce(r.salt, r.index), r.plain[:readN], nil)
+			r.index++
+		}
+	}
+	n = copy(p, r.out)
+	r.out = r.out[n:]
+	return
+}
+
+func newSSEEncryptReader(reader io.Reader, dataKey []byte) (r *sseEncryptReader, err error) {
+	var aead cipher.AEAD
+	if aead, err = newSSEAEAD(dataKey); err != nil {
+		return
+	}
+	var salt = make([]byte, sseSaltSize)
+	if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+		return
+	}
+	r = &sseEncryptReader{
+		reader: reader,
+		aead:   aead,
+		salt:   salt,
+		plain:  make([]byte, sseChunkSize),
+		out:    make([]byte, 0, sseSealedSize),
+	}
+	// The salt heads the encrypted stream.
+	r.out = append(r.out, salt...)
+	return
+}
+
+// sseDecryptRange decrypts the plaintext range [offset, offset+size) of an object which consists
+// of the encrypted streams with the specified plaintext sizes, and writes the plaintext to writer.
+// The readAt function reads the encrypted data of the object at the specified offset.
+func sseDecryptRange(dataKey []byte, streams []uint64, readAt func(p []byte, offset uint64) error,
+	writer io.Writer, offset, size uint64) (err error) {
+	var aead cipher.AEAD
+	if aead, err = newSSEAEAD(dataKey); err != nil {
+		return
+	}
+	var (
+		end         = offset + size
+		plainStart  uint64
+		streamStart uint64
+		salt        = make([]byte, sseSaltSize)
+		sealed      = make([]byte, sseSealedSize)
+		plain       = make([]byte, 0, sseChunkSize)
+	)
+	for _, streamSize := range streams {
+		var plainEnd = plainStart + streamSize
+		if offset < plainEnd && plainStart < end {
+			if err = readAt(salt, streamStart); err != nil {
+				return
+			}
+			var lower = offset
+			if lower < plainStart {
+				lower = plainStart
+			}
+			var upper = end
+			if upper > plainEnd {
+				upper = plainEnd
+			}
+			for index := (lower - plainStart) / sseChunkSize; index*sseChunkSize+plainStart < upper; index++ {
+				var chunkStart = plainStart + index*sseChunkSize
+				var chunkSize = plainEnd - chunkStart
+				if chunkSize > sseChunkSize {
+					chunkSize = sseChunkSize
+				}
+				var buf = sealed[:chunkSize+sseTagSize]
+				if err = readAt(buf, streamStart+sseSaltSize+index*sseSealedSize); err != nil {
+					return
+				}
+				if plain, err = aead.Open(plain[:0], sseChunkNonce(salt, index), buf, nil); err != nil {
+					return
+				}
+				var from, to = uint64(0), chunkSize
+				if lower > chunkStart {
+					from = lower - chunkStart
+				}
+				if upper < chunkStart+chunkSize {
+					to = upper - chunkStart
+				}
+				if _, err = writer.Write(plain[from:to]); err != nil {
+					return
+				}
+			}
+		}
+		plainStart = plainEnd
+		streamStart += sseEncryptedSize(streamSize)
+		if plainStart >= end {
+			break
+		}
+	}
+	return
+}
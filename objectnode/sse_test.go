@@ -0,0 +1,170 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestSSESize(t *testing.T) {
+	var sizes = []uint64{0, 1, sseChunkSize - 1, sseChunkSize, sseChunkSize + 1, 3*sseChunkSize + 17}
+	for _, size := range sizes {
+		var encrypted = sseEncryptedSize(size)
+		if decrypted := sseDecryptedSize(encrypted); decrypted != size {
+			t.Fatalf("size mismatch: plain(%v) encrypted(%v) decrypted(%v)", size, encrypted, decrypted)
+		}
+	}
+}
+
+func TestSSEDecryptRange(t *testing.T) {
+	var dataKey = make([]byte, sseKeySize)
+	_, _ = io.ReadFull(rand.Reader, dataKey)
+
+	// An object consists of several encrypted streams, just like a multipart object.
+	var streams = []uint64{2*sseChunkSize + 100, sseChunkSize, 10}
+	var plain, encrypted []byte
+	for _, streamSize := range streams {
+		var part = make([]byte, streamSize)
+		_, _ = io.ReadFull(rand.Reader, part)
+		reader, err := newSSEEncryptReader(bytes.NewReader(part), dataKey)
+		if err != nil {
+			t.Fatalf("new encrypt reader fail: err(%v)", err)
+		}
+		sealed, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("encrypt fail: err(%v)", err)
+		}
+		if uint64(len(sealed)) != sseEncryptedSize(streamSize) {
+			t.Fatalf("encrypted size mismatch: expect(%v) actual(%v)", sseEncryptedSize(streamSize), len(sealed))
+		}
+		plain = append(plain, part...)
+		encrypted = append(encrypted, sealed...)
+	}
+	var readAt = func(p []byte, offset uint64) error {
+		if offset+uint64(len(p)) > uint64(len(encrypted)) {
+			return io.ErrUnexpectedEOF
+		}
+		copy(p, encrypted[offset:])
+		return nil
+	}
+
+	var ranges = [][2]uint64{
+		{0, uint64(len(plain))},
+		{0, 1},
+		{sseChunkSize - 1, 2},
+		{2*sseChunkSize + 90, 20},
+		{uint64(len(plain)) - 5, 5},
+		{100, sseChunkSize * 2},
+	}
+	for _, rng := range ranges {
+		var buf = bytes.NewBuffer(nil)
+		if err := sseDecryptRange(dataKey, streams, readAt, buf, rng[0], rng[1]); err != nil {
+			t.Fatalf("decrypt range fail: offset(%v) size(%v) err(%v)", rng[0], rng[1], err)
+		}
+		if !bytes.Equal(buf.Bytes(), plain[rng[0]:rng[0]+rng[1]]) {
+			t.Fatalf("decrypted data mismatch: offset(%v) size(%v)", rng[0], rng[1])
+		}
+	}
+
+	// tampered data must be rejected
+	encrypted[sseSaltSize+10] ^= 0xff
+	if err := sseDecryptRange(dataKey, streams, readAt, ioutil.Discard, 0, 1); err == nil {
+		t.Fatalf("decrypt tampered data should fail")
+	}
+}
+
+func TestObjectEncryptionDataKey(t *testing.T) {
+	var masterKey = make([]byte, sseKeySize)
+	_, _ = io.ReadFull(rand.Reader, masterKey)
+	var ks = &SSEKeyStore{active: "k1", keys: map[string][]byte{"k1": masterKey}}
+
+	// SSE-S3
+	enc, dataKey, err := newObjectEncryption(&SSEOption{Algorithm: SSEAlgorithmAES256}, ks)
+	if err != nil {
+		t.Fatalf("new object encryption fail: err(%v)", err)
+	}
+	parsed, err := parseObjectEncryption(enc.encode())
+	if err != nil {
+		t.Fatalf("parse object encryption fail: err(%v)", err)
+	}
+	unwrapped, err := parsed.dataKey(nil, ks)
+	if err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("unwrap data key fail: err(%v)", err)
+	}
+	if _, err = parsed.dataKey(nil, nil); err != ErrSSENotConfigured {
+		t.Fatalf("unwrap without key store should fail: err(%v)", err)
+	}
+
+	// SSE-C
+	var customerKey = make([]byte, sseKeySize)
+	_, _ = io.ReadFull(rand.Reader, customerKey)
+	var sum = md5.Sum(customerKey)
+	opt, err := NewSSECustomerOption(SSEAlgorithmAES256, base64.StdEncoding.EncodeToString(customerKey),
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("new customer option fail: err(%v)", err)
+	}
+	if enc, dataKey, err = newObjectEncryption(opt, nil); err != nil {
+		t.Fatalf("new object encryption fail: err(%v)", err)
+	}
+	if unwrapped, err = enc.dataKey(opt, nil); err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("unwrap customer data key fail: err(%v)", err)
+	}
+	if _, err = enc.dataKey(nil, ks); err != ErrSSECustomerKeyRequired {
+		t.Fatalf("unwrap without customer key should fail: err(%v)", err)
+	}
+	var otherKey = make([]byte, sseKeySize)
+	_, _ = io.ReadFull(rand.Reader, otherKey)
+	other, _ := NewSSECustomerOption(SSEAlgorithmAES256, base64.StdEncoding.EncodeToString(otherKey), "")
+	if _, err = enc.dataKey(other, nil); err != ErrSSECustomerKeyMismatch {
+		t.Fatalf("unwrap with other customer key should fail: err(%v)", err)
+	}
+
+	if _, err = NewSSECustomerOption(SSEAlgorithmAES256, base64.StdEncoding.EncodeToString(customerKey[:16]), ""); err == nil {
+		t.Fatalf("short customer key should be rejected")
+	}
+	if _, err = NewSSECustomerOption(SSEAlgorithmAES256, base64.StdEncoding.EncodeToString(customerKey), "invalid"); err == nil {
+		t.Fatalf("customer key with mismatched MD5 should be rejected")
+	}
+}
+
+func TestParseEncryptionConfig(t *testing.T) {
+	var valid = `<ServerSideEncryptionConfiguration>
+	<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule>
+</ServerSideEncryptionConfiguration>`
+	config, err := parseEncryptionConfig([]byte(valid))
+	if err != nil {
+		t.Fatalf("parse encryption config fail: err(%v)", err)
+	}
+	if opt := config.defaultOption(); opt.Algorithm != SSEAlgorithmAES256 || opt.isCustomer() {
+		t.Fatalf("default option mismatch: opt(%v)", opt)
+	}
+
+	var invalids = []string{
+		`<ServerSideEncryptionConfiguration></ServerSideEncryptionConfiguration>`,
+		`<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>aws:kms</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`,
+	}
+	for _, invalid := range invalids {
+		if _, err = parseEncryptionConfig([]byte(invalid)); err == nil {
+			t.Fatalf("invalid encryption config should be rejected: config(%v)", invalid)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRole.html
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	STSVersion = "2011-06-15"
+	STSService = "sts"
+	STSXmlns   = "https://sts.amazonaws.com/doc/2011-06-15/"
+
+	STSActionAssumeRole = "AssumeRole"
+
+	XAmzSecurityToken = "X-Amz-Security-Token"
+
+	stsMinDuration     = 15 * time.Minute
+	stsMaxDuration     = 12 * time.Hour
+	stsDefaultDuration = time.Hour
+	stsMaxPolicySize   = 2048
+	stsMaxRequestSize  = 64 * 1024
+	stsKeySize         = 32
+
+	// The access keys of temporary credentials are prefixed as the ones issued by AWS STS.
+	stsAccessKeyPrefix = "ASIA"
+	stsAccessKeyLength = 20
+	stsSecretKeyLength = 40
+)
+
+var (
+	errInvalidToken = errors.New("invalid session token")
+	errExpiredToken = errors.New("session token expired")
+)
+
+// STSKeyStore holds the keys which sign the session tokens of temporary credentials. The session
+// tokens are signed by the active key, and verified by the key recorded in the token, so that the
+// active key can be rotated without invalidating the issued tokens. The key file is in the form of:
+//
+//	{
+//		"active": "key1",
+//		"keys": {
+//			"key1": "base64 encoded 32 bytes key"
+//		}
+//	}
+type STSKeyStore struct {
+	active string
+	keys   map[string][]byte
+}
+
+func LoadSTSKeyStore(filename string) (ks *STSKeyStore, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return
+	}
+	var raw = struct {
+		Active string            `json:"active"`
+		Keys   map[string]string `json:"keys"`
+	}{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+	ks = &STSKeyStore{
+		active: raw.Active,
+		keys:   make(map[string][]byte),
+	}
+	for id, encoded := range raw.Keys {
+		var key []byte
+		if key, err = base64.StdEncoding.DecodeString(encoded); err != nil || len(key) != stsKeySize {
+			return nil, errors.NewErrorf("invalid STS key: id(%v)", id)
+		}
+		ks.keys[id] = key
+	}
+	if _, exist := ks.keys[ks.active]; !exist {
+		return nil, errors.NewErrorf("active STS key not found: id(%v)", ks.active)
+	}
+	return
+}
+
+// sessionClaims is the content of the session token of temporary credentials. The temporary
+// credentials act as the user of the parent access key, limited by the session policy.
+type sessionClaims struct {
+	KeyID           string `json:"kid"`
+	AccessKey       string `json:"ak"`
+	ParentAccessKey string `json:"pak"`
+	Expiration      int64  `json:"exp"`
+	SessionName     string `json:"name,omitempty"`
+	Policy          string `json:"pol,omitempty"`
+}
+
+func (c *sessionClaims) expiration() time.Time {
+	return time.Unix(c.Expiration, 0).UTC()
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	var mac = hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// sessionSecretKey derives the secret key of temporary credentials from the claims, so that the
+// secret key needs not to be stored or carried by the session token.
+func sessionSecretKey(key, claims []byte) string {
+	var derived = hmacSHA256(key, append([]byte("secret\n"), claims...))
+	return base64.StdEncoding.EncodeToString(derived)[:stsSecretKeyLength]
+}
+
+// issue signs the claims with the active key, and returns the secret key and session token.
+func (ks *STSKeyStore) issue(claims *sessionClaims) (secretKey, token string, err error) {
+	claims.KeyID = ks.active
+	var data []byte
+	if data, err = json.Marshal(claims); err != nil {
+		return
+	}
+	var key = ks.keys[ks.active]
+	token = base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(hmacSHA256(key, data))
+	return sessionSecretKey(key, data), token, nil
+}
+
+// parse verifies the session token, and returns the claims and the secret key of it.
+func (ks *STSKeyStore) parse(token string) (claims *sessionClaims, secretKey string, err error) {
+	var parts = strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, "", errInvalidToken
+	}
+	var data, signature []byte
+	if data, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
+		return nil, "", errInvalidToken
+	}
+	if signature, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return nil, "", errInvalidToken
+	}
+	claims = &sessionClaims{}
+	if err = json.Unmarshal(data, claims); err != nil {
+		return nil, "", errInvalidToken
+	}
+	var key, exist = ks.keys[claims.KeyID]
+	if !exist || !hmac.Equal(signature, hmacSHA256(key, data)) {
+		return nil, "", errInvalidToken
+	}
+	if time.Now().After(claims.expiration()) {
+		return nil, "", errExpiredToken
+	}
+	return claims, sessionSecretKey(key, data), nil
+}
+
+// getSecurityToken returns the session token of the request signed with temporary credentials,
+// which is carried by the header or by the URL parameter of presigned requests.
+func getSecurityToken(r *http.Request) string {
+	if token := r.Header.Get(XAmzSecurityToken); token != "" {
+		return token
+	}
+	var query = r.URL.Query()
+	if token := query.Get(XAmzSecurityToken); token != "" {
+		return token
+	}
+	return query.Get(strings.ToLower(XAmzSecurityToken))
+}
+
+// getSTSPayloadHash returns the hash of the body of the STS request, which is not carried by the
+// X-Amz-Content-Sha256 header as the S3 requests do. The body is restored to be read again.
+func getSTSPayloadHash(r *http.Request) string {
+	if r.Body == nil {
+		return calcHash("")
+	}
+	var body, err = ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, stsMaxRequestSize))
+	if err != nil {
+		return ""
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return calcHash(string(body))
+}
+
+// SessionPolicy is the inline policy specified on AssumeRole which limits the permissions of the
+// temporary credentials. The actions are the names of ObjectNode actions with the 's3:' prefix,
+// such as 's3:GetObject', and the resources are the ARNs of the buckets and objects, such as
+// 'arn:aws:s3:::bucket/prefix/*'. The wildcards '*' and '?' are supported in both of them.
+//
+//	{
+//		"Version": "2012-10-17",
+//		"Statement": [
+//			{
+//				"Effect": "Allow",
+//				"Action": ["s3:GetObject", "s3:PutObject"],
+//				"Resource": ["arn:aws:s3:::bucket/ci/*"]
+//			}
+//		]
+//	}
+type SessionPolicy struct {
+	Version    string                   `json:"Version"`
+	Statements []SessionPolicyStatement `json:"Statement"`
+}
+
+type SessionPolicyStatement struct {
+	Sid       string    `json:"Sid,omitempty"`
+	Effect    Effect    `json:"Effect"`
+	Actions   StringSet `json:"Action"`
+	Resources StringSet `json:"Resource"`
+}
+
+func ParseSessionPolicy(data []byte) (policy *SessionPolicy, err error) {
+	policy = &SessionPolicy{}
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(policy); err != nil {
+		return nil, err
+	}
+	if policy.Version == "" {
+		return nil, errors.New("policy version cannot be empty")
+	}
+	if len(policy.Statements) == 0 {
+		return nil, errors.New("policy statement cannot be empty")
+	}
+	for _, statement := range policy.Statements {
+		if statement.Effect != Allow && statement.Effect != Deny {
+			return nil, errors.NewErrorf("invalid policy effect: effect(%v)", statement.Effect)
+		}
+		if statement.Actions.Empty() || statement.Resources.Empty() {
+			return nil, errors.New("policy action and resource cannot be empty")
+		}
+	}
+	return
+}
+
+// matchSessionPolicyPattern matches the value with the pattern in which '*' matches any sequence
+// of characters and '?' matches any single character.
+func matchSessionPolicyPattern(pattern, value string) bool {
+	var p, v, starP, starV = 0, 0, -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			starP, starV = p, v
+			p++
+		case starP >= 0:
+			starV++
+			p, v = starP+1, starV
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+func matchSessionPolicyPatterns(patterns StringSet, values ...string) bool {
+	for pattern := range patterns.values {
+		for _, value := range values {
+			if matchSessionPolicyPattern(pattern, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsAllowed checks if the request is allowed by the policy. The request is allowed only if it is
+// allowed by any statement and not denied by any statement.
+func (p *SessionPolicy) IsAllowed(param *RequestParam) bool {
+	var action = param.Action()
+	var actionNames = []string{"s3:" + action.Name(), action.String()}
+	var resource = "arn:aws:s3:::" + param.resource
+	var allowed bool
+	for i := range p.Statements {
+		var statement = &p.Statements[i]
+		if !matchSessionPolicyPatterns(statement.Actions, actionNames...) ||
+			!matchSessionPolicyPatterns(statement.Resources, resource) {
+			continue
+		}
+		if statement.Effect == Deny {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
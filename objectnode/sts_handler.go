@@ -0,0 +1,197 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+var roleSessionNameRegexp = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+type AssumeRoleCredentials struct {
+	AccessKeyId     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+	Expiration      string `xml:"Expiration"`
+}
+
+type AssumedRoleUser struct {
+	Arn           string `xml:"Arn"`
+	AssumedRoleId string `xml:"AssumedRoleId"`
+}
+
+type AssumeRoleResponse struct {
+	XMLName         xml.Name              `xml:"AssumeRoleResponse"`
+	Xmlns           string                `xml:"xmlns,attr"`
+	Credentials     AssumeRoleCredentials `xml:"AssumeRoleResult>Credentials"`
+	AssumedRoleUser AssumedRoleUser       `xml:"AssumeRoleResult>AssumedRoleUser"`
+	RequestId       string                `xml:"ResponseMetadata>RequestId"`
+}
+
+// newSessionAccessKey generates the access key of temporary credentials.
+func newSessionAccessKey() (string, error) {
+	var random = make([]byte, (stsAccessKeyLength-len(stsAccessKeyPrefix))*5/8)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return stsAccessKeyPrefix + base32.StdEncoding.EncodeToString(random), nil
+}
+
+// Assume role
+// Returns temporary credentials which act as the requester and are limited by the session policy.
+// The RoleArn parameter is accepted for compatibility and ignored, since ObjectNode has no roles.
+// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRole.html
+func (o *ObjectNode) assumeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	if o.stsKeys == nil {
+		errorCode = STSNotConfigured
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, stsMaxRequestSize)
+	if err = r.ParseForm(); err != nil {
+		log.LogErrorf("assumeRoleHandler: parse form fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = STSValidationError
+		return
+	}
+	if r.Form.Get("Action") != STSActionAssumeRole {
+		errorCode = InvalidAction
+		return
+	}
+	if version := r.Form.Get("Version"); version != "" && version != STSVersion {
+		errorCode = InvalidAction
+		return
+	}
+
+	var duration = stsDefaultDuration
+	if durationStr := r.Form.Get("DurationSeconds"); durationStr != "" {
+		var seconds int64
+		if seconds, err = strconv.ParseInt(durationStr, 10, 64); err != nil {
+			errorCode = STSValidationError
+			return
+		}
+		duration = time.Duration(seconds) * time.Second
+		if duration < stsMinDuration || duration > stsMaxDuration {
+			errorCode = STSValidationError
+			return
+		}
+	}
+
+	var policy = r.Form.Get("Policy")
+	if len(policy) > stsMaxPolicySize {
+		errorCode = PackedPolicyTooLarge
+		return
+	}
+	if policy != "" {
+		if _, err = ParseSessionPolicy([]byte(policy)); err != nil {
+			log.LogDebugf("assumeRoleHandler: parse session policy fail: requestID(%v) err(%v)", GetRequestID(r), err)
+			errorCode = MalformedPolicyDocument
+			return
+		}
+	}
+
+	var sessionName = r.Form.Get("RoleSessionName")
+	if sessionName != "" && !roleSessionNameRegexp.MatchString(sessionName) {
+		errorCode = STSValidationError
+		return
+	}
+
+	// Temporary credentials cannot be used to assume new credentials, otherwise the session
+	// could be extended without limit.
+	if sessionAccessKey, _ := GetSessionFromContext(r); sessionAccessKey != "" {
+		errorCode = AccessDenied
+		return
+	}
+	var parentAccessKey = parseRequestAuthInfo(r).accessKey
+	var userInfo *proto.UserInfo
+	if userInfo, err = o.getUserInfoByAccessKey(parentAccessKey); err != nil {
+		log.LogErrorf("assumeRoleHandler: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), parentAccessKey, err)
+		if err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists {
+			errorCode = AccessDenied
+			return
+		}
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	var claims = &sessionClaims{
+		ParentAccessKey: parentAccessKey,
+		Expiration:      time.Now().Add(duration).Unix(),
+		SessionName:     sessionName,
+		Policy:          policy,
+	}
+	if claims.AccessKey, err = newSessionAccessKey(); err != nil {
+		log.LogErrorf("assumeRoleHandler: generate access key fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	var secretKey, token string
+	if secretKey, token, err = o.stsKeys.issue(claims); err != nil {
+		log.LogErrorf("assumeRoleHandler: issue session token fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	var roleId = userInfo.UserID
+	if sessionName != "" {
+		roleId = userInfo.UserID + ":" + sessionName
+	}
+	var response = AssumeRoleResponse{
+		Xmlns: STSXmlns,
+		Credentials: AssumeRoleCredentials{
+			AccessKeyId:     claims.AccessKey,
+			SecretAccessKey: secretKey,
+			SessionToken:    token,
+			Expiration:      claims.expiration().Format(time.RFC3339),
+		},
+		AssumedRoleUser: AssumedRoleUser{
+			Arn:           "arn:aws:sts:::assumed-role/" + roleId,
+			AssumedRoleId: claims.AccessKey + ":" + roleId,
+		},
+		RequestId: GetRequestID(r),
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(&response); err != nil {
+		log.LogErrorf("assumeRoleHandler: marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	log.LogInfof("assumeRoleHandler: issue temporary credentials: requestID(%v) userID(%v) accessKey(%v) sessionAccessKey(%v) expiration(%v)",
+		GetRequestID(r), userInfo.UserID, parentAccessKey, claims.AccessKey, claims.expiration())
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	if _, err = w.Write(data); err != nil {
+		log.LogErrorf("assumeRoleHandler: write response body fail: requestID(%v) err(%v)", GetRequestID(r), err)
+	}
+	return
+}
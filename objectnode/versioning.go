@@ -0,0 +1,73 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/Versioning.html
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	VersioningStatusEnabled   = "Enabled"
+	VersioningStatusSuspended = "Suspended"
+
+	// NullVersionID is the version ID of objects written while versioning is not enabled.
+	NullVersionID = "null"
+
+	// VersionsDirName is the name of the hidden directory under the volume root in which
+	// non-current versions and delete markers are kept. Each object key owns a sub directory
+	// named by the escaped key, and every entry in it is named by its version ID.
+	VersionsDirName = ".oss_versions"
+)
+
+type VersioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration" json:"-"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty" json:"-"`
+	Status  string   `xml:"Status,omitempty" json:"status"`
+}
+
+func (config *VersioningConfiguration) validate() bool {
+	return config.Status == VersioningStatusEnabled || config.Status == VersioningStatusSuspended
+}
+
+func parseVersioningConfig(bytes []byte) (config *VersioningConfiguration, err error) {
+	config = &VersioningConfiguration{}
+	if err = xml.Unmarshal(bytes, config); err != nil {
+		return
+	}
+	if ok := config.validate(); !ok {
+		return nil, errors.New("invalid versioning configuration")
+	}
+	return
+}
+
+func storeBucketVersioning(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSVersioning, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+// Version IDs start with the hex encoded creation time in nanoseconds, so the lexical
+// order of two IDs is also their chronological order. The inode number is appended
+// to keep IDs unique when several versions are written within the same nanosecond.
+func newVersionID(inode uint64) string {
+	return fmt.Sprintf("%016x%016x", time.Now().UnixNano(), inode)
+}
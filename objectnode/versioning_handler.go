@@ -0,0 +1,260 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
+func (o *ObjectNode) getBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var output = VersioningConfiguration{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+
+	var versioning *VersioningConfiguration
+	if versioning, err = vol.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if versioning != nil {
+		output.Status = versioning.Status
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
+func (o *ObjectNode) putBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var versioning *VersioningConfiguration
+	if versioning, err = parseVersioningConfig(bytes); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: parse versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = IllegalVersioningConfiguration.ServeResponse(w, r)
+		return
+	}
+	// Versioning can not be suspended on the bucket with object lock enabled.
+	if versioning.Status != VersioningStatusEnabled {
+		var objectLock *ObjectLockConfiguration
+		if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+			_ = InternalErrorCode(err).ServeResponse(w, r)
+			return
+		}
+		if objectLock != nil {
+			_ = InvalidBucketState.ServeResponse(w, r)
+			return
+		}
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(versioning); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketVersioning(newBytes, vol); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: store versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeVersioning(versioning)
+
+	log.LogInfof("Audit: put bucket versioning: requestID(%v) remote(%v) volume(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), versioning.Status)
+	return
+}
+
+// List object versions
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+func (o *ObjectNode) listObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+			return
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	// get options
+	prefix := r.URL.Query().Get(ParamPrefix)
+	delimiter := r.URL.Query().Get(ParamPartDelimiter)
+	keyMarker := r.URL.Query().Get(ParamKeyMarker)
+	versionIdMarker := r.URL.Query().Get(ParamVersionIdMarker)
+	maxKeys := r.URL.Query().Get(ParamMaxKeys)
+	encodingType := r.URL.Query().Get(ParamEncodingType)
+
+	var maxKeysInt uint64
+	if maxKeys != "" {
+		maxKeysInt, err = strconv.ParseUint(maxKeys, 10, 16)
+		if err != nil {
+			log.LogErrorf("listObjectVersionsHandler: parse max key fail: requestID(%v) err(%v)", GetRequestID(r), err)
+			errorCode = InvalidArgument
+			return
+		}
+		if maxKeysInt > MaxKeys {
+			maxKeysInt = MaxKeys
+		}
+	} else {
+		maxKeysInt = uint64(MaxKeys)
+	}
+
+	// Validate encoding type option
+	if encodingType != "" && encodingType != "url" {
+		errorCode = InvalidArgument
+		return
+	}
+	// A version ID marker is only valid with a key marker
+	if versionIdMarker != "" && keyMarker == "" {
+		errorCode = InvalidArgument
+		return
+	}
+
+	var option = &ListFileVersionsOption{
+		Prefix:          prefix,
+		Delimiter:       delimiter,
+		KeyMarker:       keyMarker,
+		VersionIDMarker: versionIdMarker,
+		MaxKeys:         maxKeysInt,
+	}
+
+	var result *ListFileVersionsResult
+	if result, err = vol.ListFileVersions(option); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: list file versions fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	var bucketOwner = NewBucketOwner(vol)
+	var versions = make([]*ObjectVersion, 0)
+	var deleteMarkers = make([]*DeleteMarkerEntry, 0)
+	for _, version := range result.Versions {
+		if version.DeleteMarker {
+			deleteMarkers = append(deleteMarkers, &DeleteMarkerEntry{
+				Key:          encodeKey(version.Key, encodingType),
+				VersionId:    version.VersionID,
+				IsLatest:     version.IsLatest,
+				LastModified: formatTimeISO(version.ModifyTime),
+				Owner:        bucketOwner,
+			})
+			continue
+		}
+		versions = append(versions, &ObjectVersion{
+			Key:          encodeKey(version.Key, encodingType),
+			VersionId:    version.VersionID,
+			IsLatest:     version.IsLatest,
+			LastModified: formatTimeISO(version.ModifyTime),
+			ETag:         wrapUnescapedQuot(version.ETag),
+			Size:         int(version.Size),
+			StorageClass: StorageClassStandard,
+			Owner:        bucketOwner,
+		})
+	}
+
+	var commonPrefixes = make([]*CommonPrefix, 0)
+	for _, prefix := range result.CommonPrefixes {
+		commonPrefixes = append(commonPrefixes, &CommonPrefix{
+			Prefix: encodeKey(prefix, encodingType),
+		})
+	}
+
+	listVersionsResult := &ListVersionsResult{
+		Bucket:              param.Bucket(),
+		Prefix:              prefix,
+		KeyMarker:           keyMarker,
+		VersionIdMarker:     versionIdMarker,
+		NextKeyMarker:       encodeKey(result.NextKeyMarker, encodingType),
+		NextVersionIdMarker: result.NextVersionIDMarker,
+		MaxKeys:             int(maxKeysInt),
+		Delimiter:           delimiter,
+		EncodingType:        encodingType,
+		IsTruncated:         result.Truncated,
+		Versions:            versions,
+		DeleteMarkers:       deleteMarkers,
+		CommonPrefixes:      commonPrefixes,
+	}
+
+	var bytes []byte
+	if bytes, err = MarshalXMLEntity(listVersionsResult); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	// set response header
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+	_, _ = w.Write(bytes)
+	return
+}
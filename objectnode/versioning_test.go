@@ -0,0 +1,49 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseVersioningConfig(t *testing.T) {
+	var valid = `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>Enabled</Status></VersioningConfiguration>`
+	config, err := parseVersioningConfig([]byte(valid))
+	if err != nil {
+		t.Fatalf("parse versioning config fail: err(%v)", err)
+	}
+	if config.Status != VersioningStatusEnabled {
+		t.Fatalf("status mismatch: expect(%v) actual(%v)", VersioningStatusEnabled, config.Status)
+	}
+
+	var invalid = `<VersioningConfiguration><Status>Disabled</Status></VersioningConfiguration>`
+	if _, err = parseVersioningConfig([]byte(invalid)); err == nil {
+		t.Fatalf("parse invalid versioning config should fail")
+	}
+}
+
+func TestFSVersionInfo_NewerThan(t *testing.T) {
+	var now = time.Now()
+	var older = &FSVersionInfo{VersionID: newVersionID(1), ModifyTime: now}
+	var newer = &FSVersionInfo{VersionID: newVersionID(2), ModifyTime: now}
+	if !newer.newerThan(older) || older.newerThan(newer) {
+		t.Fatalf("versions created in the same second should be ordered by version ID")
+	}
+	var null = &FSVersionInfo{VersionID: NullVersionID, ModifyTime: now.Add(time.Second)}
+	if !null.newerThan(newer) {
+		t.Fatalf("versions should be ordered by modify time first")
+	}
+}
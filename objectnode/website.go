@@ -0,0 +1,220 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/WebsiteHosting.html
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+)
+
+const (
+	WebsiteProtocolHTTP  = "http"
+	WebsiteProtocolHTTPS = "https"
+
+	maxWebsiteRoutingRules = 50
+)
+
+type WebsiteConfiguration struct {
+	XMLName               xml.Name               `xml:"WebsiteConfiguration" json:"-"`
+	Xmlns                 string                 `xml:"xmlns,attr,omitempty" json:"-"`
+	IndexDocument         *IndexDocument         `xml:"IndexDocument,omitempty" json:"index,omitempty"`
+	ErrorDocument         *ErrorDocument         `xml:"ErrorDocument,omitempty" json:"error,omitempty"`
+	RedirectAllRequestsTo *RedirectAllRequestsTo `xml:"RedirectAllRequestsTo,omitempty" json:"redirect_all,omitempty"`
+	RoutingRules          []*RoutingRule         `xml:"RoutingRules>RoutingRule,omitempty" json:"routing_rules,omitempty"`
+}
+
+type IndexDocument struct {
+	Suffix string `xml:"Suffix" json:"suffix"`
+}
+
+type ErrorDocument struct {
+	Key string `xml:"Key" json:"key"`
+}
+
+type RedirectAllRequestsTo struct {
+	HostName string `xml:"HostName" json:"host"`
+	Protocol string `xml:"Protocol,omitempty" json:"protocol,omitempty"`
+}
+
+type RoutingRule struct {
+	Condition *RoutingRuleCondition `xml:"Condition,omitempty" json:"condition,omitempty"`
+	Redirect  *RoutingRuleRedirect  `xml:"Redirect" json:"redirect"`
+}
+
+type RoutingRuleCondition struct {
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty" json:"prefix,omitempty"`
+	HttpErrorCodeReturnedEquals string `xml:"HttpErrorCodeReturnedEquals,omitempty" json:"error_code,omitempty"`
+}
+
+type RoutingRuleRedirect struct {
+	HostName             string  `xml:"HostName,omitempty" json:"host,omitempty"`
+	HttpRedirectCode     string  `xml:"HttpRedirectCode,omitempty" json:"redirect_code,omitempty"`
+	Protocol             string  `xml:"Protocol,omitempty" json:"protocol,omitempty"`
+	ReplaceKeyPrefixWith *string `xml:"ReplaceKeyPrefixWith,omitempty" json:"replace_prefix,omitempty"`
+	ReplaceKeyWith       *string `xml:"ReplaceKeyWith,omitempty" json:"replace_key,omitempty"`
+}
+
+func isValidWebsiteProtocol(protocol string) bool {
+	return protocol == "" || protocol == WebsiteProtocolHTTP || protocol == WebsiteProtocolHTTPS
+}
+
+func isStatusCodeInRange(value string, min, max int) bool {
+	code, err := strconv.Atoi(value)
+	return err == nil && code >= min && code <= max
+}
+
+func (rule *RoutingRule) validate() bool {
+	if rule.Redirect == nil || !isValidWebsiteProtocol(rule.Redirect.Protocol) {
+		return false
+	}
+	if rule.Redirect.ReplaceKeyPrefixWith != nil && rule.Redirect.ReplaceKeyWith != nil {
+		return false
+	}
+	if rule.Redirect.HttpRedirectCode != "" && !isStatusCodeInRange(rule.Redirect.HttpRedirectCode, 300, 399) {
+		return false
+	}
+	if rule.Condition != nil && rule.Condition.HttpErrorCodeReturnedEquals != "" &&
+		!isStatusCodeInRange(rule.Condition.HttpErrorCodeReturnedEquals, 400, 599) {
+		return false
+	}
+	return true
+}
+
+func (config *WebsiteConfiguration) validate() bool {
+	if redirect := config.RedirectAllRequestsTo; redirect != nil {
+		// Other elements are not allowed if all requests are redirected.
+		if config.IndexDocument != nil || config.ErrorDocument != nil || len(config.RoutingRules) > 0 {
+			return false
+		}
+		return redirect.HostName != "" && isValidWebsiteProtocol(redirect.Protocol)
+	}
+	if config.IndexDocument == nil || config.IndexDocument.Suffix == "" ||
+		strings.Contains(config.IndexDocument.Suffix, pathSep) {
+		return false
+	}
+	if config.ErrorDocument != nil && config.ErrorDocument.Key == "" {
+		return false
+	}
+	if len(config.RoutingRules) > maxWebsiteRoutingRules {
+		return false
+	}
+	for _, rule := range config.RoutingRules {
+		if !rule.validate() {
+			return false
+		}
+	}
+	return true
+}
+
+func parseWebsiteConfig(bytes []byte) (config *WebsiteConfiguration, err error) {
+	config = &WebsiteConfiguration{}
+	if err = xml.Unmarshal(bytes, config); err != nil {
+		return
+	}
+	if ok := config.validate(); !ok {
+		return nil, errors.New("invalid website configuration")
+	}
+	return
+}
+
+func storeBucketWebsite(bytes []byte, vol *Volume) (err error) {
+	if err = vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSWebsite, bytes); err != nil {
+		return
+	}
+	return nil
+}
+
+func deleteBucketWebsite(vol *Volume) (err error) {
+	if err = vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSWebsite); err != nil {
+		return
+	}
+	return nil
+}
+
+// indexKey returns the key of the index document if the key refers to a directory, which is
+// the root of the bucket or ends with a slash. Otherwise the key itself is returned.
+func (config *WebsiteConfiguration) indexKey(key string) string {
+	if key == "" || strings.HasSuffix(key, pathSep) {
+		return key + config.IndexDocument.Suffix
+	}
+	return key
+}
+
+// matchRoutingRule returns the first routing rule applying to the key, or nil if none applies.
+// The rules with an error code condition apply only if the request fails with the status code,
+// and the other rules apply before the object is looked up, in which case statusCode is 0.
+func (config *WebsiteConfiguration) matchRoutingRule(key string, statusCode int) *RoutingRule {
+	for _, rule := range config.RoutingRules {
+		var condition = rule.Condition
+		if condition == nil {
+			condition = &RoutingRuleCondition{}
+		}
+		if !strings.HasPrefix(key, condition.KeyPrefixEquals) {
+			continue
+		}
+		if condition.HttpErrorCodeReturnedEquals == "" {
+			if statusCode == 0 {
+				return rule
+			}
+			continue
+		}
+		if condition.HttpErrorCodeReturnedEquals == strconv.Itoa(statusCode) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// redirectLocation returns the status code and the location of the redirection for the key.
+func (rule *RoutingRule) redirectLocation(r *http.Request, key string) (statusCode int, location string) {
+	var redirect = rule.Redirect
+	var newKey = key
+	if redirect.ReplaceKeyWith != nil {
+		newKey = *redirect.ReplaceKeyWith
+	} else if redirect.ReplaceKeyPrefixWith != nil {
+		var prefix string
+		if rule.Condition != nil {
+			prefix = rule.Condition.KeyPrefixEquals
+		}
+		newKey = *redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, prefix)
+	}
+	statusCode = http.StatusMovedPermanently
+	if redirect.HttpRedirectCode != "" {
+		statusCode, _ = strconv.Atoi(redirect.HttpRedirectCode)
+	}
+	location = websiteURL(r, redirect.Protocol, redirect.HostName, newKey)
+	return
+}
+
+// websiteURL builds the URL of the key. The protocol and the host of the request are used
+// if they are not specified.
+func websiteURL(r *http.Request, protocol, host, key string) string {
+	if protocol == "" {
+		protocol = WebsiteProtocolHTTP
+		if r.TLS != nil {
+			protocol = WebsiteProtocolHTTPS
+		}
+	}
+	if host == "" {
+		host = r.Host
+	}
+	return protocol + "://" + host + pathSep + key
+}
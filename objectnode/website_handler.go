@@ -0,0 +1,308 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketWebsite.html
+func (o *ObjectNode) getBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var website *WebsiteConfiguration
+	if website, err = vol.metaLoader.loadWebsite(); err != nil {
+		log.LogErrorf("getBucketWebsiteHandler: load website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if website == nil {
+		_ = NoSuchWebsiteConfiguration.ServeResponse(w, r)
+		return
+	}
+
+	var output = *website
+	output.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	var data []byte
+	if data, err = MarshalXMLEntity(output); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	_, _ = w.Write(data)
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketWebsite.html
+func (o *ObjectNode) putBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil && err != io.EOF {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+
+	var website *WebsiteConfiguration
+	if website, err = parseWebsiteConfig(bytes); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: parse website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = MalformedXML.ServeResponse(w, r)
+		return
+	}
+
+	var newBytes []byte
+	if newBytes, err = json.Marshal(website); err != nil {
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	if err = storeBucketWebsite(newBytes, vol); err != nil {
+		log.LogErrorf("putBucketWebsiteHandler: store website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeWebsite(website)
+
+	log.LogInfof("Audit: put bucket website: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	return
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketWebsite.html
+func (o *ObjectNode) deleteBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		_ = NoSuchBucket.ServeResponse(w, r)
+		return
+	}
+
+	if err = deleteBucketWebsite(vol); err != nil {
+		log.LogErrorf("deleteBucketWebsiteHandler: delete website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		_ = InternalErrorCode(err).ServeResponse(w, r)
+		return
+	}
+	vol.metaLoader.storeWebsite(nil)
+
+	log.LogInfof("Audit: delete bucket website: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
+
+// websiteHandler serves the anonymous GET and HEAD requests sent to the website endpoint of the bucket.
+// The requests are not signed, so the objects are only served if the bucket policy allows anonymous
+// users to get them.
+// https://docs.aws.amazon.com/AmazonS3/latest/dev/WebsiteEndpoints.html
+func (o *ObjectNode) websiteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+			return
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	var vol *Volume
+	if vol, err = o.vm.Volume(param.Bucket()); err != nil {
+		errorCode = NoSuchBucket
+		return
+	}
+	var website *WebsiteConfiguration
+	if website, err = vol.metaLoader.loadWebsite(); err != nil {
+		log.LogErrorf("websiteHandler: load website fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+	if website == nil {
+		errorCode = NoSuchWebsiteConfiguration
+		return
+	}
+
+	var key = param.Object()
+	if redirect := website.RedirectAllRequestsTo; redirect != nil {
+		http.Redirect(w, r, websiteURL(r, redirect.Protocol, redirect.HostName, key), http.StatusMovedPermanently)
+		return
+	}
+	if rule := website.matchRoutingRule(key, 0); rule != nil {
+		statusCode, location := rule.redirectLocation(r, key)
+		http.Redirect(w, r, location, statusCode)
+		return
+	}
+
+	if errorCode = o.serveWebsiteObject(w, r, vol, website.indexKey(key), http.StatusOK); errorCode == nil {
+		return
+	}
+
+	// A key without the trailing slash is redirected to the directory if it has an index document.
+	if errorCode == NoSuchKey && key != "" && !strings.HasSuffix(key, pathSep) {
+		if _, ec := o.lookupWebsiteObject(r, vol, website.indexKey(key+pathSep)); ec == nil {
+			errorCode = nil
+			http.Redirect(w, r, pathSep+key+pathSep, http.StatusFound)
+			return
+		}
+	}
+	if rule := website.matchRoutingRule(key, errorCode.StatusCode); rule != nil {
+		errorCode = nil
+		statusCode, location := rule.redirectLocation(r, key)
+		http.Redirect(w, r, location, statusCode)
+		return
+	}
+	if website.ErrorDocument != nil {
+		if ec := o.serveWebsiteObject(w, r, vol, website.ErrorDocument.Key, errorCode.StatusCode); ec == nil {
+			errorCode = nil
+		}
+	}
+	return
+}
+
+// websiteMethodNotAllowedHandler rejects the requests sent to the website endpoint other than GET and HEAD.
+func (o *ObjectNode) websiteMethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	_ = MethodNotAllowed.ServeResponse(w, r)
+}
+
+// isWebsiteObjectAllowed checks whether the bucket policy allows anonymous users to get the object.
+// Nothing is allowed without a bucket policy, or if the public access of the bucket is restricted.
+func (o *ObjectNode) isWebsiteObjectAllowed(r *http.Request, vol *Volume, key string) (allowed bool, err error) {
+	var policy *Policy
+	if policy, err = vol.metaLoader.loadPolicy(); err != nil {
+		return
+	}
+	if policy == nil || policy.IsEmpty() {
+		return false, nil
+	}
+	var block *proto.PublicAccessBlock
+	if block, err = o.publicAccessBlock(vol); err != nil {
+		return
+	}
+	var param = ParseRequestParam(r)
+	param.object = key
+	param.resource = vol.Name() + pathSep + key
+	param.action = proto.OSSGetObjectAction
+	param.accessKey = ""
+	return policy.IsAllowed(param, false, block.RestrictPublicBuckets), nil
+}
+
+func (o *ObjectNode) lookupWebsiteObject(r *http.Request, vol *Volume, key string) (fileInfo *FSFileInfo, errorCode *ErrorCode) {
+	var err error
+	var allowed bool
+	if allowed, err = o.isWebsiteObjectAllowed(r, vol, key); err != nil {
+		log.LogErrorf("lookupWebsiteObject: load policy fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return nil, InternalErrorCode(err)
+	}
+	if !allowed {
+		return nil, AccessDenied
+	}
+	var deleteMarker bool
+	fileInfo, deleteMarker, err = vol.ObjectVersionMeta(key, "")
+	if err == syscall.ENOENT || (err == nil && (deleteMarker || fileInfo.Mode.IsDir())) {
+		return nil, NoSuchKey
+	}
+	if err != nil {
+		log.LogErrorf("lookupWebsiteObject: get file meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		return nil, InternalErrorCode(err)
+	}
+	// The objects encrypted with customer keys can not be read by anonymous users.
+	if fileInfo.SSEKeyMD5 != "" {
+		return nil, AccessDenied
+	}
+	return
+}
+
+// serveWebsiteObject writes the object to the response with the specified status code.
+func (o *ObjectNode) serveWebsiteObject(w http.ResponseWriter, r *http.Request, vol *Volume, key string, statusCode int) (errorCode *ErrorCode) {
+	var fileInfo *FSFileInfo
+	if fileInfo, errorCode = o.lookupWebsiteObject(r, vol, key); errorCode != nil {
+		return
+	}
+
+	w.Header()[HeaderNameLastModified] = []string{formatTimeRFC1123(fileInfo.ModifyTime)}
+	if len(fileInfo.MIMEType) > 0 {
+		w.Header()[HeaderNameContentType] = []string{fileInfo.MIMEType}
+	} else {
+		w.Header()[HeaderNameContentType] = []string{HeaderValueTypeStream}
+	}
+	if len(fileInfo.Disposition) > 0 {
+		w.Header()[HeaderNameContentDisposition] = []string{fileInfo.Disposition}
+	}
+	if len(fileInfo.CacheControl) > 0 {
+		w.Header()[HeaderNameCacheControl] = []string{fileInfo.CacheControl}
+	}
+	if len(fileInfo.Expires) > 0 {
+		w.Header()[HeaderNameExpires] = []string{fileInfo.Expires}
+	}
+	if len(fileInfo.ETag) > 0 {
+		w.Header()[HeaderNameETag] = []string{wrapUnescapedQuot(fileInfo.ETag)}
+	}
+	w.Header()[HeaderNameContentLength] = []string{strconv.FormatInt(fileInfo.Size, 10)}
+	w.WriteHeader(statusCode)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	var err error
+	if err = vol.ReadFile(key, w, 0, uint64(fileInfo.Size)); err != nil {
+		log.LogErrorf("serveWebsiteObject: read from Volume fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+	}
+	return
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The ChubaoFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseWebsiteConfig(t *testing.T) {
+	var valid = `<WebsiteConfiguration>
+	<IndexDocument><Suffix>index.html</Suffix></IndexDocument>
+	<ErrorDocument><Key>error.html</Key></ErrorDocument>
+	<RoutingRules>
+		<RoutingRule>
+			<Condition><KeyPrefixEquals>docs/</KeyPrefixEquals></Condition>
+			<Redirect><ReplaceKeyPrefixWith>documents/</ReplaceKeyPrefixWith></Redirect>
+		</RoutingRule>
+		<RoutingRule>
+			<Condition><HttpErrorCodeReturnedEquals>404</HttpErrorCodeReturnedEquals></Condition>
+			<Redirect><HostName>example.com</HostName><HttpRedirectCode>302</HttpRedirectCode></Redirect>
+		</RoutingRule>
+	</RoutingRules>
+</WebsiteConfiguration>`
+	config, err := parseWebsiteConfig([]byte(valid))
+	if err != nil {
+		t.Fatalf("parse website config fail: err(%v)", err)
+	}
+	if config.IndexDocument.Suffix != "index.html" || config.ErrorDocument.Key != "error.html" || len(config.RoutingRules) != 2 {
+		t.Fatalf("website config mismatch: config(%v)", config)
+	}
+
+	if _, err = parseWebsiteConfig([]byte(`<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName><Protocol>https</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>`)); err != nil {
+		t.Fatalf("parse website config redirecting all requests fail: err(%v)", err)
+	}
+
+	var invalids = []string{
+		`<WebsiteConfiguration></WebsiteConfiguration>`,
+		`<WebsiteConfiguration><IndexDocument><Suffix>a/index.html</Suffix></IndexDocument></WebsiteConfiguration>`,
+		`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RedirectAllRequestsTo><HostName>example.com</HostName></RedirectAllRequestsTo></WebsiteConfiguration>`,
+		`<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName><Protocol>ftp</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>`,
+		`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules><RoutingRule><Condition><KeyPrefixEquals>a</KeyPrefixEquals></Condition></RoutingRule></RoutingRules></WebsiteConfiguration>`,
+		`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules><RoutingRule><Redirect><ReplaceKeyPrefixWith>a</ReplaceKeyPrefixWith><ReplaceKeyWith>b</ReplaceKeyWith></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`,
+		`<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><RoutingRules><RoutingRule><Redirect><HttpRedirectCode>200</HttpRedirectCode></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`,
+	}
+	for _, invalid := range invalids {
+		if _, err = parseWebsiteConfig([]byte(invalid)); err == nil {
+			t.Fatalf("invalid website config should be rejected: config(%v)", invalid)
+		}
+	}
+}
+
+func TestWebsiteRoutingRule(t *testing.T) {
+	var replacePrefix = "documents/"
+	var config = &WebsiteConfiguration{
+		IndexDocument: &IndexDocument{Suffix: "index.html"},
+		RoutingRules: []*RoutingRule{
+			{
+				Condition: &RoutingRuleCondition{KeyPrefixEquals: "docs/"},
+				Redirect:  &RoutingRuleRedirect{ReplaceKeyPrefixWith: &replacePrefix},
+			},
+			{
+				Condition: &RoutingRuleCondition{HttpErrorCodeReturnedEquals: "404"},
+				Redirect:  &RoutingRuleRedirect{HostName: "example.com", Protocol: "https", HttpRedirectCode: "302"},
+			},
+		},
+	}
+
+	if key := config.indexKey(""); key != "index.html" {
+		t.Fatalf("index key of root mismatch: key(%v)", key)
+	}
+	if key := config.indexKey("a/"); key != "a/index.html" {
+		t.Fatalf("index key of directory mismatch: key(%v)", key)
+	}
+	if key := config.indexKey("a/b.html"); key != "a/b.html" {
+		t.Fatalf("index key of object mismatch: key(%v)", key)
+	}
+
+	var r, _ = http.NewRequest(http.MethodGet, "http://bucket.website.chubao.io/docs/a.html", nil)
+	var rule = config.matchRoutingRule("docs/a.html", 0)
+	if rule == nil {
+		t.Fatalf("routing rule of prefix should match")
+	}
+	if code, location := rule.redirectLocation(r, "docs/a.html"); code != http.StatusMovedPermanently ||
+		location != "http://bucket.website.chubao.io/documents/a.html" {
+		t.Fatalf("redirect mismatch: code(%v) location(%v)", code, location)
+	}
+
+	if rule = config.matchRoutingRule("a.html", 0); rule != nil {
+		t.Fatalf("routing rule of error code should not match before lookup")
+	}
+	if rule = config.matchRoutingRule("a.html", http.StatusForbidden); rule != nil {
+		t.Fatalf("routing rule of another error code should not match")
+	}
+	if rule = config.matchRoutingRule("a.html", http.StatusNotFound); rule == nil {
+		t.Fatalf("routing rule of error code should match")
+	}
+	if code, location := rule.redirectLocation(r, "a.html"); code != http.StatusFound ||
+		location != "https://example.com/a.html" {
+		t.Fatalf("redirect mismatch: code(%v) location(%v)", code, location)
+	}
+}
@@ -27,6 +27,13 @@ const (
 	AdminAddDataReplica            = "/dataReplica/add"
 	AdminDeleteVol                 = "/vol/delete"
 	AdminUpdateVol                 = "/vol/update"
+	AdminSetVolQoS                 = "/vol/setQoS"
+	AdminSetQuota                  = "/quota/set"
+	AdminDeleteQuota               = "/quota/delete"
+	AdminListQuota                 = "/quota/list"
+	AdminCreateVolSnapshot         = "/vol/snapshot/create"
+	AdminDeleteVolSnapshot         = "/vol/snapshot/delete"
+	AdminListVolSnapshot           = "/vol/snapshot/list"
 	AdminVolShrink                 = "/vol/shrink"
 	AdminVolExpand                 = "/vol/expand"
 	AdminCreateVol                 = "/admin/createVol"
@@ -39,6 +46,7 @@ const (
 	AdminListVols                  = "/vol/list"
 	AdminSetNodeInfo               = "/admin/setNodeInfo"
 	AdminGetNodeInfo               = "/admin/getNodeInfo"
+	AdminSetPublicAccessBlock      = "/admin/setPublicAccessBlock"
 
 	//graphql master api
 	AdminClusterAPI = "/api/cluster"
@@ -134,6 +142,7 @@ type ClusterInfo struct {
 	MetaNodeDeleteWorkerSleepMs uint64
 	DataNodeDeleteLimitRate     uint64
 	DataNodeAutoRepairLimitRate uint64
+	PublicAccessBlock           *PublicAccessBlock `json:",omitempty"` // cluster-wide settings of ObjectNode
 }
 
 // CreateDataPartitionRequest defines the request to create a data partition.
@@ -242,8 +251,10 @@ type LoadMetaPartitionMetricResponse struct {
 
 // HeartBeatRequest define the heartbeat request.
 type HeartBeatRequest struct {
-	CurrTime   int64
-	MasterAddr string
+	CurrTime    int64
+	MasterAddr  string
+	Quotas      map[string][]*QuotaHeartbeatInfo `json:",omitempty"` // quotas of the volumes, sent to the meta nodes
+	QuotaIdMark uint64                           `json:",omitempty"` // the quotas with the IDs up to it are deleted if not sent
 }
 
 // PartitionReport defines the partition report.
@@ -286,6 +297,7 @@ type MetaPartitionReport struct {
 	VolName     string
 	InodeCnt    uint64
 	DentryCnt   uint64
+	QuotaUsages []*QuotaUsage `json:",omitempty"`
 }
 
 // MetaNodeHeartbeatResponse defines the response to the meta node heartbeat request.
@@ -424,6 +436,8 @@ type VolView struct {
 	DataPartitions []*DataPartitionResponse
 	OSSSecure      *OSSSecure
 	CreateTime     int64
+	QoS            *QoSLimit `json:",omitempty"`
+	TrashInterval  uint32    `json:",omitempty"` // minutes the deleted files stay in the trash, 0 disables the trash
 }
 
 func (v *VolView) SetOwner(owner string) {
@@ -480,6 +494,8 @@ type SimpleVolView struct {
 	Description        string
 	DpSelectorName     string
 	DpSelectorParm     string
+	QoS                *QoSLimit `json:",omitempty" graphql:"-"`
+	TrashInterval      uint32
 }
 
 // MasterAPIAccessResp defines the response for getting meta partition
@@ -0,0 +1,142 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Types of the metadata changes recorded in the changelog.
+const (
+	ChangelogCreateInode uint8 = iota + 1
+	ChangelogLinkInode
+	ChangelogUnlinkInode
+	ChangelogEvictInode
+	ChangelogCreateDentry
+	ChangelogDeleteDentry
+	ChangelogUpdateDentry
+	ChangelogSetAttr
+	ChangelogSetXAttr
+	ChangelogRemoveXAttr
+	ChangelogAppendExtents
+	ChangelogTruncate
+)
+
+var changelogTypeNames = map[uint8]string{
+	ChangelogCreateInode:   "CreateInode",
+	ChangelogLinkInode:     "LinkInode",
+	ChangelogUnlinkInode:   "UnlinkInode",
+	ChangelogEvictInode:    "EvictInode",
+	ChangelogCreateDentry:  "CreateDentry",
+	ChangelogDeleteDentry:  "DeleteDentry",
+	ChangelogUpdateDentry:  "UpdateDentry",
+	ChangelogSetAttr:       "SetAttr",
+	ChangelogSetXAttr:      "SetXAttr",
+	ChangelogRemoveXAttr:   "RemoveXAttr",
+	ChangelogAppendExtents: "AppendExtents",
+	ChangelogTruncate:      "Truncate",
+}
+
+// ChangelogTypeString returns the name of the changelog event type.
+func ChangelogTypeString(t uint8) string {
+	if name, ok := changelogTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%v)", t)
+}
+
+const (
+	// ChangelogReadDefaultLimit is the number of events replied if the request sets no limit.
+	ChangelogReadDefaultLimit = 1000
+	// ChangelogReadMaxLimit is the maximum number of events replied by a request.
+	ChangelogReadMaxLimit = 10000
+)
+
+// ChangelogEvent is a metadata change applied by a meta partition. The sequence numbers of the
+// events of a partition are increasing and start from 1.
+type ChangelogEvent struct {
+	Seq      uint64   `json:"seq"`
+	Time     int64    `json:"time"`
+	Type     uint8    `json:"type"`
+	Inode    uint64   `json:"ino"`
+	ParentId uint64   `json:"pid,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Mode     uint32   `json:"mode,omitempty"`
+	Size     uint64   `json:"size,omitempty"` // size of the file after the extents are appended or truncated
+	Keys     []string `json:"keys,omitempty"` // names of the extended attributes
+}
+
+// ReadChangelogRequest asks for the changelog events of the meta partition from the cursor.
+type ReadChangelogRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Cursor      uint64 `json:"cursor"` // sequence number of the first event to read
+	Limit       int    `json:"limit"`
+}
+
+// ReadChangelogResponse replies the events from the cursor. The events before FirstSeq have been
+// discarded, and LastSeq is the sequence number of the latest event. The sequence numbers of the
+// events are increasing but not consecutive, since an append-extents event replaces the previous
+// one of the inode.
+type ReadChangelogResponse struct {
+	Events   []*ChangelogEvent `json:"events"`
+	FirstSeq uint64            `json:"first"`
+	LastSeq  uint64            `json:"last"`
+}
+
+// ChangelogCursor is the position of a consumer in the changelog of a volume, which is the
+// sequence number of the next event to read in each meta partition.
+type ChangelogCursor map[uint64]uint64
+
+// String encodes the cursor as "partitionID:seq" pairs separated by commas.
+func (c ChangelogCursor) String() string {
+	ids := make([]uint64, 0, len(c))
+	for id := range c {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	pairs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		pairs = append(pairs, fmt.Sprintf("%v:%v", id, c[id]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseChangelogCursor decodes the cursor encoded by ChangelogCursor.String.
+func ParseChangelogCursor(s string) (ChangelogCursor, error) {
+	c := make(ChangelogCursor)
+	if s == "" {
+		return c, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.Split(pair, ":")
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid changelog cursor: %v", pair)
+		}
+		id, err := strconv.ParseUint(kv[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid changelog cursor: %v", pair)
+		}
+		seq, err := strconv.ParseUint(kv[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid changelog cursor: %v", pair)
+		}
+		c[id] = seq
+	}
+	return c, nil
+}
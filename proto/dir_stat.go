@@ -0,0 +1,46 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// The virtual extended attributes of a directory, which are the recursive statistics of the
+// directory and are read only.
+const (
+	XAttrKeyRBytes   = "user.cfs.rbytes"
+	XAttrKeyRFiles   = "user.cfs.rfiles"
+	XAttrKeyRSubdirs = "user.cfs.rsubdirs"
+)
+
+// DirStat is the statistics of the files and the sub directories under a directory, or the
+// changes to them.
+type DirStat struct {
+	Files   int64 `json:"files"`   // count of the non-directory dentries
+	Subdirs int64 `json:"subdirs"` // count of the sub directories
+	Bytes   int64 `json:"bytes"`   // bytes of the regular files
+}
+
+func (s *DirStat) Add(other *DirStat) {
+	s.Files += other.Files
+	s.Subdirs += other.Subdirs
+	s.Bytes += other.Bytes
+}
+
+// Neg returns the changes which cancel the statistics.
+func (s DirStat) Neg() DirStat {
+	return DirStat{Files: -s.Files, Subdirs: -s.Subdirs, Bytes: -s.Bytes}
+}
+
+func (s DirStat) IsZero() bool {
+	return s == DirStat{}
+}
@@ -81,6 +81,12 @@ var (
 	ErrInvalidAccessKey                = errors.New("invalid access key")
 	ErrInvalidSecretKey                = errors.New("invalid secret key")
 	ErrIsOwner                         = errors.New("user owns the volume")
+	ErrInvalidQoSLimit                 = errors.New("invalid QoS limit")
+	ErrQuotaNotExists                  = errors.New("quota does not exist")
+	ErrUserQuotaExceeded               = errors.New("user quota exceeded")
+	ErrVolSnapshotNotExists            = errors.New("volume snapshot does not exist")
+	ErrVolSnapshotNotReady             = errors.New("volume snapshot is not ready")
+	ErrVolSnapshotExists               = errors.New("volume snapshot exists")
 )
 
 // http response error code and error message definitions
@@ -144,6 +150,12 @@ const (
 	ErrCodeInvalidAccessKey
 	ErrCodeInvalidSecretKey
 	ErrCodeIsOwner
+	ErrCodeInvalidQoSLimit
+	ErrCodeQuotaNotExists
+	ErrCodeUserQuotaExceeded
+	ErrCodeVolSnapshotNotExists
+	ErrCodeVolSnapshotNotReady
+	ErrCodeVolSnapshotExists
 )
 
 // Err2CodeMap error map to code
@@ -205,6 +217,12 @@ var Err2CodeMap = map[error]int32{
 	ErrInvalidAccessKey:                ErrCodeInvalidAccessKey,
 	ErrInvalidSecretKey:                ErrCodeInvalidSecretKey,
 	ErrIsOwner:                         ErrCodeIsOwner,
+	ErrInvalidQoSLimit:                 ErrCodeInvalidQoSLimit,
+	ErrQuotaNotExists:                  ErrCodeQuotaNotExists,
+	ErrUserQuotaExceeded:               ErrCodeUserQuotaExceeded,
+	ErrVolSnapshotNotExists:            ErrCodeVolSnapshotNotExists,
+	ErrVolSnapshotNotReady:             ErrCodeVolSnapshotNotReady,
+	ErrVolSnapshotExists:               ErrCodeVolSnapshotExists,
 }
 
 func ParseErrorCode(code int32) error {
@@ -273,6 +291,12 @@ var code2ErrMap = map[int32]error{
 	ErrCodeInvalidAccessKey:                ErrInvalidAccessKey,
 	ErrCodeInvalidSecretKey:                ErrInvalidSecretKey,
 	ErrCodeIsOwner:                         ErrIsOwner,
+	ErrCodeInvalidQoSLimit:                 ErrInvalidQoSLimit,
+	ErrCodeQuotaNotExists:                  ErrQuotaNotExists,
+	ErrCodeUserQuotaExceeded:               ErrUserQuotaExceeded,
+	ErrCodeVolSnapshotNotExists:            ErrVolSnapshotNotExists,
+	ErrCodeVolSnapshotNotReady:             ErrVolSnapshotNotReady,
+	ErrCodeVolSnapshotExists:               ErrVolSnapshotExists,
 }
 
 type GeneralResp struct {
@@ -138,6 +138,7 @@ type CreateInodeRequest struct {
 	Uid         uint32 `json:"uid"`
 	Gid         uint32 `json:"gid"`
 	Target      []byte `json:"tgt"`
+	ParentId    uint64 `json:"pino,omitempty"` // directory to create the inode under, whose quotas the inode inherits
 }
 
 // CreateInodeResponse defines the response to the request of creating an inode.
@@ -256,6 +257,7 @@ type LookupRequest struct {
 	PartitionID uint64 `json:"pid"`
 	ParentID    uint64 `json:"pino"`
 	Name        string `json:"name"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 // LookupResponse defines the response for the loopup request.
@@ -269,6 +271,7 @@ type InodeGetRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 // InodeGetResponse defines the response to the InodeGetRequest.
@@ -281,6 +284,7 @@ type BatchInodeGetRequest struct {
 	VolName     string   `json:"vol"`
 	PartitionID uint64   `json:"pid"`
 	Inodes      []uint64 `json:"inos"`
+	SnapshotId  uint64   `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 // BatchInodeGetResponse defines the response to the request of getting the inode in batch.
@@ -293,6 +297,7 @@ type ReadDirRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	ParentID    uint64 `json:"pino"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 // ReadDirResponse defines the response to the request of reading dir.
@@ -300,6 +305,94 @@ type ReadDirResponse struct {
 	Children []Dentry `json:"children"`
 }
 
+const (
+	// ReadDirLimitMax is the maximum number of dentries replied by a request of reading dir by page.
+	ReadDirLimitMax = 10000
+	// ReadDirPageSize is the number of dentries read by the clients at a time.
+	ReadDirPageSize = 1000
+)
+
+// ReadDirLimitRequest defines the request to read a page of the dentries of a dir in the order of
+// their names, starting from the dentry named Marker.
+type ReadDirLimitRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	ParentID    uint64 `json:"pino"`
+	Marker      string `json:"marker"` // name of the first dentry to read, empty reads from the beginning
+	Limit       uint64 `json:"limit"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
+}
+
+// ReadDirLimitResponse defines the response to the request of reading dir by page.
+type ReadDirLimitResponse struct {
+	Children []Dentry `json:"children"`
+}
+
+// ListPrefixFrame defines a directory which is being scanned by the prefix listing. The scan of
+// the directory resumes from the dentry after the one named After.
+type ListPrefixFrame struct {
+	Inode uint64 `json:"ino"`
+	Path  string `json:"path"` // path of the directory with a trailing slash, empty for the root
+	After string `json:"after"`
+}
+
+// ListPrefixItem defines an entry found by the prefix listing, which is either a dentry matching
+// the prefix or a common prefix rolled up by the delimiter.
+type ListPrefixItem struct {
+	Inode    uint64 `json:"ino"`
+	Type     uint32 `json:"type"`
+	Path     string `json:"path"`
+	IsPrefix bool   `json:"prefix,omitempty"`
+}
+
+// ListPrefixRequest defines the request to list the dentries under the directories by prefix.
+// The directories are scanned depth-first from the last frame, and all the frames must be
+// directories of the partition.
+type ListPrefixRequest struct {
+	VolName     string             `json:"vol"`
+	PartitionID uint64             `json:"pid"`
+	Frames      []*ListPrefixFrame `json:"frames"`
+	Prefix      string             `json:"prefix"`
+	Marker      string             `json:"marker"`
+	Delimiter   string             `json:"delimiter"`
+	Skipped     []string           `json:"skipped"` // names of the dentries under the root directory which are not listed
+	Limit       uint64             `json:"limit"`
+}
+
+// ListPrefixResponse defines the response to the request of listing by prefix. The remaining
+// frames are empty if the scan is finished, otherwise the scan continues from the last frame,
+// which may be a directory of another partition.
+type ListPrefixResponse struct {
+	Items  []*ListPrefixItem  `json:"items"`
+	Frames []*ListPrefixFrame `json:"frames"`
+}
+
+// GetDirStatRequest defines the request to get the statistics of the direct children of a directory.
+type GetDirStatRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
+}
+
+// GetDirStatResponse defines the response to the request of getting the statistics of a directory.
+// The statistics are nil if the directory is not counted.
+type GetDirStatResponse struct {
+	Stat *DirStat `json:"stat,omitempty"`
+}
+
+// AddDirStatRequest defines the request of a meta partition to add the changes under a directory
+// to the statistics of the directory kept by another partition. The changes from the same partition
+// are applied once by the sequence.
+type AddDirStatRequest struct {
+	VolName     string  `json:"vol"`
+	PartitionID uint64  `json:"pid"`
+	Inode       uint64  `json:"ino"`
+	Stat        DirStat `json:"stat"`
+	Source      uint64  `json:"src"` // partition the changes are sent from
+	Seq         uint64  `json:"seq"`
+}
+
 // AppendExtentKeyRequest defines the request to append an extent key.
 type AppendExtentKeyRequest struct {
 	VolName     string    `json:"vol"`
@@ -321,6 +414,7 @@ type GetExtentsRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 // GetExtentsResponse defines the response to the request of getting extents.
@@ -387,6 +481,11 @@ type SetXAttrRequest struct {
 	Inode       uint64 `json:"ino"`
 	Key         string `json:"key"`
 	Value       string `json:"val"`
+	// LockChangeSign allows releasing the legal hold and shortening the retention in GOVERNANCE
+	// mode. It is signed by the ObjectNode at LockChangeTime after checking the permission of the
+	// requester, see SignObjectLockChange.
+	LockChangeSign string `json:"lcs,omitempty"`
+	LockChangeTime int64  `json:"lct,omitempty"`
 }
 
 type GetXAttrRequest struct {
@@ -394,6 +493,7 @@ type GetXAttrRequest struct {
 	PartitionId uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
 	Key         string `json:"key"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 type GetXAttrResponse struct {
@@ -405,16 +505,19 @@ type GetXAttrResponse struct {
 }
 
 type RemoveXAttrRequest struct {
-	VolName     string `json:"vol"`
-	PartitionId uint64 `json:"pid"`
-	Inode       uint64 `json:"ino"`
-	Key         string `json:"key"`
+	VolName        string `json:"vol"`
+	PartitionId    uint64 `json:"pid"`
+	Inode          uint64 `json:"ino"`
+	Key            string `json:"key"`
+	LockChangeSign string `json:"lcs,omitempty"` // see SetXAttrRequest
+	LockChangeTime int64  `json:"lct,omitempty"`
 }
 
 type ListXAttrRequest struct {
 	VolName     string `json:"vol"`
 	PartitionId uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
+	SnapshotId  uint64 `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 type ListXAttrResponse struct {
@@ -429,6 +532,7 @@ type BatchGetXAttrRequest struct {
 	PartitionId uint64   `json:"pid"`
 	Inodes      []uint64 `json:"inos"`
 	Keys        []string `json:"keys"`
+	SnapshotId  uint64   `json:"snap,omitempty"` // ID of the volume snapshot to read, 0 reads the live volume
 }
 
 type BatchGetXAttrResponse struct {
@@ -0,0 +1,91 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import "math"
+
+// Types of a file lock.
+const (
+	LockTypeRead uint8 = iota + 1
+	LockTypeWrite
+	LockTypeUnlock
+)
+
+const (
+	// LockRangeMax is the end of a lock which reaches the end of the file.
+	LockRangeMax uint64 = math.MaxInt64
+
+	// LockLeaseTimeout is the seconds the locks of a client session are kept without heartbeats.
+	LockLeaseTimeout = 30
+
+	// LockHeartbeatInterval is the seconds between the heartbeats of a client session.
+	LockHeartbeatInterval = 10
+)
+
+// LockInfo describes a lock of the byte range [Start, End] of an inode. The locks of the same
+// session and owner never conflict, and flock locks never conflict with POSIX locks.
+type LockInfo struct {
+	Inode   uint64 `json:"ino"`
+	Session string `json:"session"`
+	Owner   uint64 `json:"owner"`
+	Flock   bool   `json:"flock,omitempty"`
+	Start   uint64 `json:"start"`
+	End     uint64 `json:"end"`
+	Type    uint8  `json:"type"`
+	Pid     uint32 `json:"pid"`
+}
+
+// Conflict returns true if the locks are held by different owners, overlap and one of them is
+// a write lock.
+func (l *LockInfo) Conflict(o *LockInfo) bool {
+	if l.Inode != o.Inode || l.Flock != o.Flock || l.SameOwner(o) {
+		return false
+	}
+	if l.Start > o.End || o.Start > l.End {
+		return false
+	}
+	return l.Type == LockTypeWrite || o.Type == LockTypeWrite
+}
+
+// SameOwner returns true if the locks are held by the same owner of the same client session.
+func (l *LockInfo) SameOwner(o *LockInfo) bool {
+	return l.Session == o.Session && l.Owner == o.Owner
+}
+
+// SetLockRequest asks the meta partition of the inode to acquire or release the lock.
+type SetLockRequest struct {
+	VolName     string    `json:"vol"`
+	PartitionID uint64    `json:"pid"`
+	Lock        *LockInfo `json:"lock"`
+}
+
+// GetLockRequest asks the meta partition of the inode for a lock conflicting with the given lock.
+type GetLockRequest struct {
+	VolName     string    `json:"vol"`
+	PartitionID uint64    `json:"pid"`
+	Lock        *LockInfo `json:"lock"`
+}
+
+// GetLockResponse replies the conflicting lock, nil if there is none.
+type GetLockResponse struct {
+	Lock *LockInfo `json:"lock"`
+}
+
+// LockHeartbeatRequest renews the leases of the locks held by the client session.
+type LockHeartbeatRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Session     string `json:"session"`
+}
@@ -107,6 +107,7 @@ type ClusterView struct {
 	BadMetaPartitionIDs []BadPartitionView
 	MetaNodes           []NodeView
 	DataNodes           []NodeView
+	PublicAccessBlock   *PublicAccessBlock `json:",omitempty" graphql:"-"`
 }
 
 // NodeView provides the view of the data or meta node.
@@ -0,0 +1,122 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// The object lock state of an inode is stored in its extended attributes, so that the meta node
+// is able to protect the locked inodes from being destroyed or modified by any client.
+const (
+	XAttrKeyOSSRetention = "oss:retention"
+	XAttrKeyOSSLegalHold = "oss:legal-hold"
+
+	ObjectLockModeGovernance = "GOVERNANCE"
+	ObjectLockModeCompliance = "COMPLIANCE"
+
+	LegalHoldStatusOn  = "ON"
+	LegalHoldStatusOff = "OFF"
+)
+
+// ObjectLockChangeExpiration is the time in seconds in which the signature of an object lock
+// change is accepted by the meta node.
+const ObjectLockChangeExpiration = 300
+
+// ObjectRetention is the retention of an inode. The inode is locked until the retain-until time.
+type ObjectRetention struct {
+	Mode        string `json:"mode"`
+	RetainUntil int64  `json:"until"` // unix time in seconds
+}
+
+func (r *ObjectRetention) Encode() []byte {
+	data, _ := json.Marshal(r)
+	return data
+}
+
+// Active returns whether the retention period has not expired at now.
+func (r *ObjectRetention) Active(now int64) bool {
+	return r.RetainUntil > now
+}
+
+func ParseObjectRetention(raw []byte) (r *ObjectRetention, err error) {
+	r = &ObjectRetention{}
+	if err = json.Unmarshal(raw, r); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// IsObjectLocked returns whether the inode with the specified legal hold and retention values is locked.
+func IsObjectLocked(legalHold, retention []byte, now int64) bool {
+	if string(legalHold) == LegalHoldStatusOn {
+		return true
+	}
+	if len(retention) == 0 {
+		return false
+	}
+	r, err := ParseObjectRetention(retention)
+	if err != nil {
+		// Keep the inode locked rather than destroy it by mistake.
+		return true
+	}
+	return r.Active(now)
+}
+
+// AllowRetentionUpdate returns whether the retention of an inode can be replaced by the new one,
+// and an empty new retention means the removal of the retention.
+// The active retention can only be extended, and the mode can only be changed from GOVERNANCE to
+// COMPLIANCE. The retention in GOVERNANCE mode can also be shortened or removed if bypassGovernance
+// is set by the users with the special permission, which is checked by the ObjectNode.
+func AllowRetentionUpdate(old, new []byte, now int64, bypassGovernance bool) bool {
+	if len(old) == 0 {
+		return true
+	}
+	oldRetention, err := ParseObjectRetention(old)
+	if err != nil {
+		return false
+	}
+	if !oldRetention.Active(now) {
+		return true
+	}
+	if oldRetention.Mode != ObjectLockModeCompliance && bypassGovernance {
+		return true
+	}
+	if len(new) == 0 {
+		return false
+	}
+	newRetention, err := ParseObjectRetention(new)
+	if err != nil {
+		return false
+	}
+	if oldRetention.Mode == ObjectLockModeCompliance && newRetention.Mode != ObjectLockModeCompliance {
+		return false
+	}
+	return newRetention.RetainUntil >= oldRetention.RetainUntil
+}
+
+// SignObjectLockChange signs the update of the object lock attribute of the inode, which releases
+// the legal hold or shortens the retention in GOVERNANCE mode. The key is shared by the ObjectNodes
+// and the meta nodes only, so the signature proves that the ObjectNode has checked the permission
+// of the requester. An empty value means the removal of the attribute.
+func SignObjectLockChange(key []byte, volName string, inode uint64, name, value string, timestamp int64) string {
+	var mac = hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(mac, "%v\n%v\n%v\n%v\n%v", volName, inode, name, value, timestamp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -99,6 +99,11 @@ const (
 	OpMetaListXAttr          uint8 = 0x38
 	OpMetaBatchGetXAttr      uint8 = 0x39
 	OpMetaExtentAddWithCheck uint8 = 0x3A // Append extent key with discard extents check
+	OpMetaListPrefix         uint8 = 0x3B // List dentries by prefix with a depth-first scan
+	OpMetaGetDirStat         uint8 = 0x3C // Get recursive statistics of a directory
+	OpMetaAddDirStat         uint8 = 0x3D // Add changes from another meta partition to the statistics of a directory
+	OpMetaReadDirLimit       uint8 = 0x3E // Read a page of dentries of a directory from the marker
+	OpMetaTagQuota           uint8 = 0x3F // Tag an inode with a directory quota
 
 	// Operations: Master -> MetaNode
 	OpCreateMetaPartition           uint8 = 0x40
@@ -110,6 +115,8 @@ const (
 	OpAddMetaPartitionRaftMember    uint8 = 0x46
 	OpRemoveMetaPartitionRaftMember uint8 = 0x47
 	OpMetaPartitionTryToLeader      uint8 = 0x48
+	OpCreateMetaSnapshot            uint8 = 0x49 // Freeze the meta partition into a volume snapshot
+	OpDeleteMetaSnapshot            uint8 = 0x4A
 
 	// Operations: Master -> DataNode
 	OpCreateDataPartition           uint8 = 0x60
@@ -132,6 +139,20 @@ const (
 
 	OpBatchDeleteExtent uint8 = 0x75 // SDK to MetaNode
 
+	// Operations: metadata transactions across meta partitions
+	OpMetaTxPrepare  uint8 = 0x80
+	OpMetaTxCommit   uint8 = 0x81
+	OpMetaTxRollback uint8 = 0x82
+	OpMetaTxGetState uint8 = 0x83 // Query the coordinator for the state of the transaction
+
+	// Operations: file locks held by the meta partition of the inode
+	OpMetaSetLock       uint8 = 0x84
+	OpMetaGetLock       uint8 = 0x85
+	OpMetaLockHeartbeat uint8 = 0x86 // Renew the leases of the locks held by the client session
+
+	// Operations: metadata changelog
+	OpMetaReadChangelog uint8 = 0x87
+
 	//Operations: MetaNode Leader -> MetaNode Follower
 	OpMetaBatchDeleteInode  uint8 = 0x90
 	OpMetaBatchDeleteDentry uint8 = 0x91
@@ -139,6 +160,10 @@ const (
 	OpMetaBatchEvictInode   uint8 = 0x93
 
 	// Commons
+	OpQuotaCrossErr      uint8 = 0xED
+	OpLockConflictErr    uint8 = 0xEE
+	OpTxAbortedErr       uint8 = 0xEF
+	OpQuotaExceededErr   uint8 = 0xF1
 	OpConflictExtentsErr uint8 = 0xF2
 	OpIntraGroupNetErr   uint8 = 0xF3
 	OpArgMismatchErr     uint8 = 0xF4
@@ -257,6 +282,14 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpNotifyReplicasToRepair"
 	case OpExtentRepairRead:
 		m = "OpExtentRepairRead"
+	case OpQuotaExceededErr:
+		m = "QuotaExceededErr"
+	case OpQuotaCrossErr:
+		m = "QuotaCrossErr"
+	case OpLockConflictErr:
+		m = "LockConflictErr"
+	case OpTxAbortedErr:
+		m = "TxAbortedErr"
 	case OpConflictExtentsErr:
 		m = "ConflictExtentsErr"
 	case OpIntraGroupNetErr:
@@ -279,6 +312,16 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaLookup"
 	case OpMetaReadDir:
 		m = "OpMetaReadDir"
+	case OpMetaListPrefix:
+		m = "OpMetaListPrefix"
+	case OpMetaGetDirStat:
+		m = "OpMetaGetDirStat"
+	case OpMetaAddDirStat:
+		m = "OpMetaAddDirStat"
+	case OpMetaReadDirLimit:
+		m = "OpMetaReadDirLimit"
+	case OpMetaTagQuota:
+		m = "OpMetaTagQuota"
 	case OpMetaInodeGet:
 		m = "OpMetaInodeGet"
 	case OpMetaBatchInodeGet:
@@ -357,6 +400,10 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpRemoveMetaPartitionRaftMember"
 	case OpMetaPartitionTryToLeader:
 		m = "OpMetaPartitionTryToLeader"
+	case OpCreateMetaSnapshot:
+		m = "OpCreateMetaSnapshot"
+	case OpDeleteMetaSnapshot:
+		m = "OpDeleteMetaSnapshot"
 	case OpDataPartitionTryToLeader:
 		m = "OpDataPartitionTryToLeader"
 	case OpMetaDeleteInode:
@@ -387,6 +434,22 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpListMultiparts"
 	case OpBatchDeleteExtent:
 		m = "OpBatchDeleteExtent"
+	case OpMetaTxPrepare:
+		m = "OpMetaTxPrepare"
+	case OpMetaTxCommit:
+		m = "OpMetaTxCommit"
+	case OpMetaTxRollback:
+		m = "OpMetaTxRollback"
+	case OpMetaTxGetState:
+		m = "OpMetaTxGetState"
+	case OpMetaSetLock:
+		m = "OpMetaSetLock"
+	case OpMetaGetLock:
+		m = "OpMetaGetLock"
+	case OpMetaLockHeartbeat:
+		m = "OpMetaLockHeartbeat"
+	case OpMetaReadChangelog:
+		m = "OpMetaReadChangelog"
 	}
 	return
 }
@@ -426,6 +489,12 @@ func (p *Packet) GetResultMsg() (m string) {
 		m = "NotPerm"
 	case OpNotEmtpy:
 		m = "DirNotEmpty"
+	case OpTxAbortedErr:
+		m = "TxAbortedErr"
+	case OpLockConflictErr:
+		m = "LockConflictErr"
+	case OpQuotaCrossErr:
+		m = "QuotaCrossErr"
 	default:
 		return fmt.Sprintf("Unknown ResultCode(%v)", p.ResultCode)
 	}
@@ -21,8 +21,8 @@ import (
 )
 
 var (
-	actionRegexp       = regexp.MustCompile("^action:((oss:(\\w+))|(posix:(\\w)+))$")
-	actionPrefixRegexp = regexp.MustCompile("^action:((oss)|(posix)):")
+	actionRegexp       = regexp.MustCompile("^action:((oss:(\\w+))|(posix:(\\w)+)|(sts:(\\w+)))$")
+	actionPrefixRegexp = regexp.MustCompile("^action:((oss)|(posix)|(sts)):")
 )
 
 type Action string
@@ -47,10 +47,12 @@ const (
 	ActionPrefix      = "action:"
 	OSSActionPrefix   = ActionPrefix + "oss:"
 	POSIXActionPrefix = ActionPrefix + "posix:"
+	STSActionPrefix   = ActionPrefix + "sts:"
 
 	// Object actions
 	OSSGetObjectAction     Action = OSSActionPrefix + "GetObject"
 	OSSPutObjectAction     Action = OSSActionPrefix + "PutObject"
+	OSSPostObjectAction    Action = OSSActionPrefix + "PostObject"
 	OSSCopyObjectAction    Action = OSSActionPrefix + "CopyObject"
 	OSSListObjectsAction   Action = OSSActionPrefix + "ListObjects"
 	OSSDeleteObjectAction  Action = OSSActionPrefix + "DeleteObject"
@@ -90,7 +92,7 @@ const (
 	OSSCreateMultipartUploadAction   Action = OSSActionPrefix + "CreateMultipartUpload"
 	OSSListMultipartUploadsAction    Action = OSSActionPrefix + "ListMultipartUploads"
 	OSSUploadPartAction              Action = OSSActionPrefix + "UploadPart"
-	OSSUploadPartCopyAction          Action = OSSActionPrefix + "UploadPartCopy" // unsupported
+	OSSUploadPartCopyAction          Action = OSSActionPrefix + "UploadPartCopy"
 	OSSListPartsAction               Action = OSSActionPrefix + "ListParts"
 	OSSCompleteMultipartUploadAction Action = OSSActionPrefix + "CompleteMultipartUpload"
 	OSSAbortMultipartUploadAction    Action = OSSActionPrefix + "AbortMultipartUpload"
@@ -115,49 +117,68 @@ const (
 	OSSDeleteBucketTaggingAction Action = OSSActionPrefix + "DeleteBucketTagging"
 
 	// Bucket lifecycle actions
-	OSSGetBucketLifecycleAction    Action = OSSActionPrefix + "GetBucketLifecycle"    // unsupported
-	OSSPutBucketLifecycleAction    Action = OSSActionPrefix + "PutBucketLifecycle"    // unsupported
-	OSSDeleteBucketLifecycleAction Action = OSSActionPrefix + "DeleteBucketLifecycle" // unsupported
+	OSSGetBucketLifecycleAction    Action = OSSActionPrefix + "GetBucketLifecycle"
+	OSSPutBucketLifecycleAction    Action = OSSActionPrefix + "PutBucketLifecycle"
+	OSSDeleteBucketLifecycleAction Action = OSSActionPrefix + "DeleteBucketLifecycle"
 
 	// Object storage version actions
-	OSSGetBucketVersioningAction Action = OSSActionPrefix + "GetBucketVersioning" // unsupported
-	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning" // unsupported
-	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"  // unsupported
+	OSSGetBucketVersioningAction Action = OSSActionPrefix + "GetBucketVersioning"
+	OSSPutBucketVersioningAction Action = OSSActionPrefix + "PutBucketVersioning"
+	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"
 
 	// Object legal hold actions
-	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold" // unsupported
-	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold" // unsupported
+	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold"
+	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold"
 
 	// Object retention actions
-	OSSGetObjectRetentionAction Action = OSSActionPrefix + "GetObjectRetention" // unsupported
-	OSSPutObjectRetentionAction Action = OSSActionPrefix + "PutObjectRetention" // unsupported
+	OSSGetObjectRetentionAction        Action = OSSActionPrefix + "GetObjectRetention"
+	OSSPutObjectRetentionAction        Action = OSSActionPrefix + "PutObjectRetention"
+	OSSBypassGovernanceRetentionAction Action = OSSActionPrefix + "BypassGovernanceRetention"
+
+	// Object lock configuration actions
+	OSSGetObjectLockConfigurationAction Action = OSSActionPrefix + "GetObjectLockConfiguration"
+	OSSPutObjectLockConfigurationAction Action = OSSActionPrefix + "PutObjectLockConfiguration"
 
 	// Bucket encryption actions
-	OSSGetBucketEncryptionAction    Action = OSSActionPrefix + "GetBucketEncryption"    // unsupported
-	OSSPutBucketEncryptionAction    Action = OSSActionPrefix + "PutBucketEncryption"    // unsupported
-	OSSDeleteBucketEncryptionAction Action = OSSActionPrefix + "DeleteBucketEncryption" // unsupported
+	OSSGetBucketEncryptionAction    Action = OSSActionPrefix + "GetBucketEncryption"
+	OSSPutBucketEncryptionAction    Action = OSSActionPrefix + "PutBucketEncryption"
+	OSSDeleteBucketEncryptionAction Action = OSSActionPrefix + "DeleteBucketEncryption"
 
 	// Bucket website actions
-	OSSGetBucketWebsiteAction    Action = OSSActionPrefix + "GetBucketWebsite"    // unsupported
-	OSSPutBucketWebsiteAction    Action = OSSActionPrefix + "PutBucketWebsite"    // unsupported
-	OSSDeleteBucketWebsiteAction Action = OSSActionPrefix + "DeleteBucketWebsite" // unsupported
+	OSSGetBucketWebsiteAction    Action = OSSActionPrefix + "GetBucketWebsite"
+	OSSPutBucketWebsiteAction    Action = OSSActionPrefix + "PutBucketWebsite"
+	OSSDeleteBucketWebsiteAction Action = OSSActionPrefix + "DeleteBucketWebsite"
+
+	// Bucket notification actions
+	OSSGetBucketNotificationAction Action = OSSActionPrefix + "GetBucketNotification"
+	OSSPutBucketNotificationAction Action = OSSActionPrefix + "PutBucketNotification"
+
+	// Bucket logging actions
+	OSSGetBucketLoggingAction Action = OSSActionPrefix + "GetBucketLogging"
+	OSSPutBucketLoggingAction Action = OSSActionPrefix + "PutBucketLogging"
+
+	// Object select actions
+	OSSSelectObjectContentAction Action = OSSActionPrefix + "SelectObjectContent"
 
 	// Object restore actions
 	OSSRestoreObjectAction Action = OSSActionPrefix + "RestoreObject" // unsupported
 
 	// Public access block actions
-	OSSGetPublicAccessBlockAction    Action = OSSActionPrefix + "GetPublicAccessBlock"   // unsupported
-	OSSPutPublicAccessBlockAction    Action = OSSActionPrefix + "PutPublicAccessBlock"   // unsupported
-	OSSDeletePublicAccessBlockAction Action = OSSActionPrefix + "DeletePulicAccessBlock" // unuspported
+	OSSGetPublicAccessBlockAction    Action = OSSActionPrefix + "GetPublicAccessBlock"
+	OSSPutPublicAccessBlockAction    Action = OSSActionPrefix + "PutPublicAccessBlock"
+	OSSDeletePublicAccessBlockAction Action = OSSActionPrefix + "DeletePublicAccessBlock"
 
 	// Bucket request payment actions
 	OSSGetBucketRequestPaymentAction Action = OSSActionPrefix + "GetBucketRequestPayment" // unsupported
 	OSSPutBucketRequestPaymentAction Action = OSSActionPrefix + "PutBucketRequestPayment" // unsupported
 
 	// Bucket replication actions
-	OSSGetBucketReplicationAction    Action = OSSActionPrefix + "GetBucketReplicationAction"    // unsupported
-	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"    // unsupported
-	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction" // unsupported
+	OSSGetBucketReplicationAction    Action = OSSActionPrefix + "GetBucketReplicationAction"
+	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"
+	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction"
+
+	// constants for security token service interface
+	STSAssumeRoleAction Action = STSActionPrefix + "AssumeRole"
 
 	// constants for POSIX file system interface
 	POSIXReadAction  Action = POSIXActionPrefix + "Read"
@@ -171,6 +192,7 @@ var (
 		// Object storage interface actions
 		OSSGetObjectAction,
 		OSSPutObjectAction,
+		OSSPostObjectAction,
 		OSSCopyObjectAction,
 		OSSListObjectsAction,
 		OSSDeleteObjectAction,
@@ -217,6 +239,9 @@ var (
 		OSSPutObjectLegalHoldAction,
 		OSSGetObjectRetentionAction,
 		OSSPutObjectRetentionAction,
+		OSSBypassGovernanceRetentionAction,
+		OSSGetObjectLockConfigurationAction,
+		OSSPutObjectLockConfigurationAction,
 		OSSGetBucketEncryptionAction,
 		OSSPutBucketEncryptionAction,
 		OSSDeleteBucketEncryptionAction,
@@ -226,6 +251,11 @@ var (
 		OSSGetBucketWebsiteAction,
 		OSSPutBucketWebsiteAction,
 		OSSDeleteBucketWebsiteAction,
+		OSSGetBucketNotificationAction,
+		OSSPutBucketNotificationAction,
+		OSSGetBucketLoggingAction,
+		OSSPutBucketLoggingAction,
+		OSSSelectObjectContentAction,
 		OSSRestoreObjectAction,
 		OSSGetPublicAccessBlockAction,
 		OSSPutPublicAccessBlockAction,
@@ -237,6 +267,9 @@ var (
 		OSSDeleteBucketReplicationAction,
 		OSSOptionsObjectAction,
 
+		// Security token service interface actions
+		STSAssumeRoleAction,
+
 		// POSIX file system interface actions
 		POSIXReadAction,
 		POSIXWriteAction,
@@ -392,13 +425,20 @@ var (
 			OSSHeadBucketAction,
 			OSSGetObjectTorrentAction,
 			OSSGetObjectAclAction,
+			OSSSelectObjectContentAction,
 			OSSListPartsAction,
 			OSSGetBucketLocationAction,
 			OSSGetObjectTaggingAction,
 			OSSListObjectVersionsAction,
 			OSSGetObjectLegalHoldAction,
 			OSSGetObjectRetentionAction,
+			OSSGetObjectLockConfigurationAction,
 			OSSGetBucketEncryptionAction,
+			OSSGetBucketWebsiteAction,
+			OSSGetBucketReplicationAction,
+			OSSGetBucketNotificationAction,
+			OSSGetBucketLoggingAction,
+			OSSGetPublicAccessBlockAction,
 
 			// file system interface
 			POSIXReadAction,
@@ -416,6 +456,7 @@ var (
 			OSSGetObjectTorrentAction,
 			OSSGetObjectAclAction,
 			OSSPutObjectAclAction,
+			OSSSelectObjectContentAction,
 			OSSCreateMultipartUploadAction,
 			OSSListMultipartUploadsAction,
 			OSSUploadPartAction,
@@ -432,7 +473,13 @@ var (
 			OSSPutObjectLegalHoldAction,
 			OSSGetObjectRetentionAction,
 			OSSPutObjectRetentionAction,
+			OSSGetObjectLockConfigurationAction,
 			OSSGetBucketEncryptionAction,
+			OSSGetBucketWebsiteAction,
+			OSSGetBucketReplicationAction,
+			OSSGetBucketNotificationAction,
+			OSSGetBucketLoggingAction,
+			OSSGetPublicAccessBlockAction,
 
 			// POSIX file system interface actions
 			POSIXReadAction,
@@ -0,0 +1,45 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// PublicAccessBlock is the settings which block the public access to the buckets of ObjectNode.
+// The cluster-wide settings are set through the master and apply to all buckets in addition to
+// the settings of each bucket, so that they can not be turned off by the bucket owners.
+type PublicAccessBlock struct {
+	BlockPublicAcls       bool `json:"block_public_acls,omitempty"`       // reject the requests which put public ACLs
+	IgnorePublicAcls      bool `json:"ignore_public_acls,omitempty"`      // ignore the public grants in ACLs
+	BlockPublicPolicy     bool `json:"block_public_policy,omitempty"`     // reject the requests which put public bucket policies
+	RestrictPublicBuckets bool `json:"restrict_public_buckets,omitempty"` // ignore the public statements in bucket policies
+}
+
+func (b *PublicAccessBlock) IsEmpty() bool {
+	return b == nil || (!b.BlockPublicAcls && !b.IgnorePublicAcls && !b.BlockPublicPolicy && !b.RestrictPublicBuckets)
+}
+
+// Merge returns the settings in which each setting is turned on if it is turned on in either of
+// the two settings.
+func (b *PublicAccessBlock) Merge(other *PublicAccessBlock) *PublicAccessBlock {
+	var merged = &PublicAccessBlock{}
+	for _, block := range []*PublicAccessBlock{b, other} {
+		if block == nil {
+			continue
+		}
+		merged.BlockPublicAcls = merged.BlockPublicAcls || block.BlockPublicAcls
+		merged.IgnorePublicAcls = merged.IgnorePublicAcls || block.IgnorePublicAcls
+		merged.BlockPublicPolicy = merged.BlockPublicPolicy || block.BlockPublicPolicy
+		merged.RestrictPublicBuckets = merged.RestrictPublicBuckets || block.RestrictPublicBuckets
+	}
+	return merged
+}
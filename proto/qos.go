@@ -0,0 +1,47 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// QoSLimit is the limits on the requests which ObjectNode serves for a user or to a volume. The
+// limits are set through the master, and ObjectNodes pick them up when refreshing the user and
+// volume information. Zero means unlimited.
+type QoSLimit struct {
+	QPS            uint64            `json:"qps,omitempty"`
+	ReadBandwidth  uint64            `json:"read_bandwidth,omitempty"`  // bytes per second of response bodies
+	WriteBandwidth uint64            `json:"write_bandwidth,omitempty"` // bytes per second of request bodies
+	ActionQPS      map[string]uint64 `json:"action_qps,omitempty"`      // QPS by action name, such as "ListObjects"
+}
+
+func (l *QoSLimit) IsEmpty() bool {
+	if l == nil {
+		return true
+	}
+	for _, qps := range l.ActionQPS {
+		if qps != 0 {
+			return false
+		}
+	}
+	return l.QPS == 0 && l.ReadBandwidth == 0 && l.WriteBandwidth == 0
+}
+
+// Validate checks that the action names are the names of object storage actions.
+func (l *QoSLimit) Validate() error {
+	for name := range l.ActionQPS {
+		if ParseAction(OSSActionPrefix + name).IsNone() {
+			return ErrInvalidQoSLimit
+		}
+	}
+	return nil
+}
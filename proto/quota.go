@@ -0,0 +1,129 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The inodes under a directory with quotas are tagged with the IDs of the quotas in their extended
+// attributes. The IDs are in the form of "1,3". The meta nodes tag new inodes with the IDs of the
+// parent directory, and retag the files moved into another directory. The attribute cannot be
+// changed through the extended attribute requests.
+const (
+	XAttrKeyQuota = "cfs.quota"
+)
+
+// QuotaInfo is the quota on the bytes and the inode count of a directory. The quota is set through
+// the master and enforced by the meta nodes. Zero means unlimited.
+type QuotaInfo struct {
+	QuotaId   uint64 `json:"id"`
+	Inode     uint64 `json:"ino"`            // inode of the directory
+	Path      string `json:"path,omitempty"` // path of the directory when the quota is set
+	MaxBytes  uint64 `json:"max_bytes,omitempty"`
+	MaxFiles  uint64 `json:"max_files,omitempty"`
+	UsedBytes uint64 `json:"used_bytes"`
+	UsedFiles uint64 `json:"used_files"`
+}
+
+// LimitedBytes returns whether the used bytes reach the limit.
+func (q *QuotaInfo) LimitedBytes() bool {
+	return q.MaxBytes > 0 && q.UsedBytes >= q.MaxBytes
+}
+
+// LimitedFiles returns whether the used inode count reaches the limit.
+func (q *QuotaInfo) LimitedFiles() bool {
+	return q.MaxFiles > 0 && q.UsedFiles >= q.MaxFiles
+}
+
+// QuotaUsage is the usage of a quota, which is reported by the leader of each meta partition and
+// summed up by the master.
+type QuotaUsage struct {
+	QuotaId   uint64
+	UsedBytes uint64
+	UsedFiles uint64
+}
+
+// QuotaHeartbeatInfo tells the meta nodes whether the usage of the quota reaches its limits.
+type QuotaHeartbeatInfo struct {
+	QuotaId      uint64
+	Inode        uint64 // inode of the directory
+	LimitedBytes bool
+	LimitedFiles bool
+}
+
+// TagQuotaRequest asks the meta partition to tag the inode with the quota, which is used to apply
+// a new quota to the inodes already under the directory.
+type TagQuotaRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	QuotaId     uint64 `json:"qid"`
+}
+
+// EncodeQuotaIds encodes the quota IDs into the value of the extended attribute.
+func EncodeQuotaIds(ids []uint64) []byte {
+	var parts = make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(id, 10))
+	}
+	return []byte(strings.Join(parts, ","))
+}
+
+// DecodeQuotaIds decodes the quota IDs from the value of the extended attribute. Malformed IDs
+// are ignored.
+func DecodeQuotaIds(value []byte) (ids []uint64) {
+	if len(value) == 0 {
+		return nil
+	}
+	for _, part := range strings.Split(string(value), ",") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
+// EqualQuotaIds returns whether the two lists have the same IDs regardless of the order.
+func EqualQuotaIds(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !ContainsQuotaId(b, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsQuotaId returns whether the ID is in the IDs.
+func ContainsQuotaId(ids []uint64, id uint64) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeQuotaIds returns the IDs with the new ID appended if it is not in the IDs.
+func MergeQuotaIds(ids []uint64, id uint64) []uint64 {
+	if ContainsQuotaId(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
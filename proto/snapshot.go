@@ -0,0 +1,50 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// Status of the volume snapshots.
+const (
+	VolSnapshotCreating = "creating"
+	VolSnapshotReady    = "ready"
+)
+
+// VolSnapshotInfo is a read-only point-in-time snapshot of the metadata of a volume. The snapshot
+// is recorded by the master, and each meta partition of the volume keeps a frozen copy of its
+// inodes and dentries. The extents referenced by the snapshot are kept until the snapshot is deleted.
+type VolSnapshotInfo struct {
+	SnapshotId uint64 `json:"id"`
+	Name       string `json:"name"`
+	CreateTime int64  `json:"create_time"`
+	Status     string `json:"status"`
+}
+
+// IsReady returns whether all the meta partitions of the volume have frozen the snapshot.
+func (s *VolSnapshotInfo) IsReady() bool {
+	return s.Status == VolSnapshotReady
+}
+
+// CreateMetaSnapshotRequest asks the leader of the meta partition to freeze the snapshot.
+type CreateMetaSnapshotRequest struct {
+	PartitionID uint64
+	VolName     string
+	SnapshotId  uint64
+}
+
+// DeleteMetaSnapshotRequest asks the leader of the meta partition to drop the snapshot.
+type DeleteMetaSnapshotRequest struct {
+	PartitionID uint64
+	VolName     string
+	SnapshotId  uint64
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// Types of the operations of a metadata transaction.
+const (
+	TxOpCreateDentry uint8 = iota + 1
+	TxOpUpdateDentry
+	TxOpDeleteDentry
+	TxOpLinkInode
+	TxOpUnlinkInode
+	TxOpMoveInode // the inode is moved into another directory by a rename
+)
+
+// States of a metadata transaction.
+const (
+	TxStatePrepared uint8 = iota + 1
+	TxStateCommitted
+	TxStateRolledBack
+)
+
+// TxDefaultTimeout is the seconds a prepared transaction waits for the decision, after which the
+// coordinator rolls it back.
+const TxDefaultTimeout = 10
+
+// TxOp is an operation of a metadata transaction applied by one meta partition.
+type TxOp struct {
+	Type      uint8    `json:"t"`
+	ParentId  uint64   `json:"pid,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Inode     uint64   `json:"ino"`
+	Mode      uint32   `json:"mode,omitempty"`
+	OldInode  uint64   `json:"old,omitempty"`   // inode the dentry is expected to refer to before the update
+	QuotaIds  []uint64 `json:"qids,omitempty"`  // quotas of the directory the inode is moved into, resolved by the meta node
+	KeepQuota bool     `json:"keepq,omitempty"` // the inode moved into the trash keeps its quotas
+}
+
+// TxParticipant is a meta partition which takes part in a metadata transaction.
+type TxParticipant struct {
+	PartitionID uint64   `json:"pid"`
+	Members     []string `json:"members"`
+}
+
+// TxInfo describes a metadata transaction across meta partitions. The first participant is the
+// coordinator, which keeps the decision of the transaction and drives the other participants.
+type TxInfo struct {
+	TxId         string           `json:"id"`
+	Timeout      int64            `json:"timeout"`
+	Participants []*TxParticipant `json:"participants"`
+}
+
+// Coordinator returns the ID of the meta partition which coordinates the transaction.
+func (tx *TxInfo) Coordinator() uint64 {
+	if len(tx.Participants) == 0 {
+		return 0
+	}
+	return tx.Participants[0].PartitionID
+}
+
+// TxPrepareRequest asks the meta partition to validate and lock the operations of the transaction.
+type TxPrepareRequest struct {
+	VolName     string  `json:"vol"`
+	PartitionID uint64  `json:"pid"`
+	Tx          *TxInfo `json:"tx"`
+	Ops         []*TxOp `json:"ops"`
+}
+
+// TxPrepareResponse replies the inodes linked by the prepared operations.
+type TxPrepareResponse struct {
+	Inodes []*InodeInfo `json:"inodes"`
+}
+
+// TxCommitRequest asks the meta partition to commit the transaction.
+type TxCommitRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	TxId        string `json:"id"`
+	Coordinator uint64 `json:"tm"`
+}
+
+// TxRollbackRequest asks the meta partition to roll back the transaction.
+type TxRollbackRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	TxId        string `json:"id"`
+	Coordinator uint64 `json:"tm"`
+}
+
+// TxGetStateRequest asks the coordinator for the state of the transaction.
+type TxGetStateRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	TxId        string `json:"id"`
+}
+
+// TxGetStateResponse replies the state of the transaction, zero if the coordinator does not know it.
+type TxGetStateResponse struct {
+	State uint8 `json:"state"`
+}
@@ -0,0 +1,36 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import "strings"
+
+// The deleted files and directories of the volume with the trash enabled are moved into the trash
+// directory under the root of the volume, instead of being unlinked. The entries deleted from a
+// directory are kept in the directory of the trash named by the inode of the original parent, so
+// that they can be restored, and a deleted directory takes back the entries deleted from it. The
+// entries are tagged with the deletion time in their extended attributes, and are removed from the
+// trash by the leader of the masters after the trash interval of the volume.
+const (
+	TrashDirName = ".Trash"
+
+	XAttrKeyTrashName = "cfs.trash.name" // original name, if the entry is renamed to avoid overwriting another one
+	XAttrKeyTrashTime = "cfs.trash.time" // deletion time in unix seconds
+)
+
+// IsTrashPath returns true if the path in the volume is the trash directory or under it.
+func IsTrashPath(path string) bool {
+	trashPath := "/" + TrashDirName
+	return path == trashPath || strings.HasPrefix(path, trashPath+"/")
+}
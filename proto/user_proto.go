@@ -101,6 +101,9 @@ type UserInfo struct {
 	UserType    UserType     `json:"user_type" graphql:"user_type"`
 	CreateTime  string       `json:"create_time" graphql:"create_time"`
 	Description string       `json:"description" graphql:"description"`
+	QoS         *QoSLimit    `json:"qos,omitempty" graphql:"-"` // limits on the requests served by ObjectNode
+	Quota       *UserQuota   `json:"quota,omitempty" graphql:"-"`
+	Usage       *UserUsage   `json:"usage,omitempty" graphql:"-"` // only in the reply of UserGetInfo
 	Mu          sync.RWMutex `json:"-" graphql:"-"`
 	EMPTY       bool         //graphql need ???
 }
@@ -340,10 +343,32 @@ type UserTransferVolParam struct {
 }
 
 type UserUpdateParam struct {
-	UserID      string   `json:"user_id"`
-	AccessKey   string   `json:"access_key"`
-	SecretKey   string   `json:"secret_key"`
-	Type        UserType `json:"type"`
-	Password    string   `json:"password"`
-	Description string   `json:"description"`
+	UserID      string     `json:"user_id"`
+	AccessKey   string     `json:"access_key"`
+	SecretKey   string     `json:"secret_key"`
+	Type        UserType   `json:"type"`
+	Password    string     `json:"password"`
+	Description string     `json:"description"`
+	QoS         *QoSLimit  `json:"qos,omitempty" graphql:"-"`   // nil means no change, and an empty limit removes the limits
+	Quota       *UserQuota `json:"quota,omitempty" graphql:"-"` // nil means no change, and an empty quota removes the quota
+}
+
+// UserQuota is the limits on the total consumption of the volumes which a user owns, so that the
+// users can not get around the capacity of a volume by creating more volumes. The quota is checked
+// when the user creates, expands or is transferred volumes. Zero means unlimited.
+type UserQuota struct {
+	MaxCapacity uint64 `json:"max_capacity,omitempty"` // GB, the sum of the capacities of the owned volumes
+	MaxInodes   uint64 `json:"max_inodes,omitempty"`   // the sum of the inode counts of the owned volumes
+	MaxVolCount uint64 `json:"max_vol_count,omitempty"`
+}
+
+func (q *UserQuota) IsEmpty() bool {
+	return q == nil || (q.MaxCapacity == 0 && q.MaxInodes == 0 && q.MaxVolCount == 0)
+}
+
+// UserUsage is the total consumption of the volumes which a user owns.
+type UserUsage struct {
+	Capacity uint64 `json:"capacity"` // GB
+	Inodes   uint64 `json:"inodes"`
+	VolCount uint64 `json:"vol_count"`
 }
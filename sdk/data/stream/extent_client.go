@@ -32,6 +32,7 @@ type AppendExtentKeyFunc func(inode uint64, key proto.ExtentKey, discard []proto
 type GetExtentsFunc func(inode uint64) (uint64, uint64, []proto.ExtentKey, error)
 type TruncateFunc func(inode, size uint64) error
 type EvictIcacheFunc func(inode uint64)
+type CheckOverwriteFunc func(inode uint64) error
 
 const (
 	MaxMountRetryLimit = 5
@@ -87,6 +88,7 @@ type ExtentConfig struct {
 	OnGetExtents      GetExtentsFunc
 	OnTruncate        TruncateFunc
 	OnEvictIcache     EvictIcacheFunc
+	OnCheckOverwrite  CheckOverwriteFunc
 }
 
 // ExtentClient defines the struct of the extent client.
@@ -101,7 +103,8 @@ type ExtentClient struct {
 	appendExtentKey AppendExtentKeyFunc
 	getExtents      GetExtentsFunc
 	truncate        TruncateFunc
-	evictIcache     EvictIcacheFunc //May be null, must check before using
+	evictIcache     EvictIcacheFunc    //May be null, must check before using
+	checkOverwrite  CheckOverwriteFunc //May be null, must check before using
 }
 
 // NewExtentClient returns a new extent client.
@@ -126,6 +129,7 @@ retry:
 	client.getExtents = config.OnGetExtents
 	client.truncate = config.OnTruncate
 	client.evictIcache = config.OnEvictIcache
+	client.checkOverwrite = config.OnCheckOverwrite
 	client.dataWrapper.InitFollowerRead(config.FollowerRead)
 	client.dataWrapper.SetNearRead(config.NearRead)
 
@@ -284,6 +284,12 @@ func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err e
 		if req.ExtentKey == nil {
 			continue
 		}
+		// the overwrite goes to the data nodes directly, so the lock of the inode is checked here
+		if s.client.checkOverwrite != nil {
+			if err = s.client.checkOverwrite(s.inode); err != nil {
+				return
+			}
+		}
 		err = s.flush()
 		if err != nil {
 			return
@@ -207,7 +207,7 @@ func (api *AdminAPI) DeleteVolume(volName, authKey string) (err error) {
 	return
 }
 
-func (api *AdminAPI) UpdateVolume(volName string, capacity uint64, replicas int, followerRead, authenticate, enableToken bool, authKey, zoneName string) (err error) {
+func (api *AdminAPI) UpdateVolume(volName string, capacity uint64, replicas int, followerRead, authenticate, enableToken bool, authKey, zoneName string, trashInterval uint32) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminUpdateVol)
 	request.addParam("name", volName)
 	request.addParam("authKey", authKey)
@@ -217,6 +217,7 @@ func (api *AdminAPI) UpdateVolume(volName string, capacity uint64, replicas int,
 	request.addParam("enableToken", strconv.FormatBool(enableToken))
 	request.addParam("authenticate", strconv.FormatBool(authenticate))
 	request.addParam("zoneName", zoneName)
+	request.addParam("trashInterval", strconv.FormatUint(uint64(trashInterval), 10))
 	if _, err = api.mc.serveRequest(request); err != nil {
 		return
 	}
@@ -286,6 +287,89 @@ func (api *AdminAPI) GetVolumeSimpleInfo(volName string) (vv *proto.SimpleVolVie
 	return
 }
 
+func (api *AdminAPI) SetQuota(volName, authKey string, inode uint64, path string, maxBytes, maxFiles uint64) (quota *proto.QuotaInfo, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminSetQuota)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("inode", strconv.FormatUint(inode, 10))
+	request.addParam("path", path)
+	request.addParam("maxBytes", strconv.FormatUint(maxBytes, 10))
+	request.addParam("maxFiles", strconv.FormatUint(maxFiles, 10))
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	quota = &proto.QuotaInfo{}
+	if err = json.Unmarshal(buf, quota); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) DeleteQuota(volName, authKey string, quotaId uint64) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteQuota)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("id", strconv.FormatUint(quotaId, 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) ListQuota(volName string) (quotas []*proto.QuotaInfo, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminListQuota)
+	request.addParam("name", volName)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	if err = json.Unmarshal(buf, &quotas); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) CreateVolSnapshot(volName, authKey, snapshotName string) (snapshot *proto.VolSnapshotInfo, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminCreateVolSnapshot)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("snapshotName", snapshotName)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	snapshot = &proto.VolSnapshotInfo{}
+	if err = json.Unmarshal(buf, snapshot); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) DeleteVolSnapshot(volName, authKey string, snapshotId uint64) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteVolSnapshot)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	request.addParam("id", strconv.FormatUint(snapshotId, 10))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *AdminAPI) ListVolSnapshot(volName string) (snapshots []*proto.VolSnapshotInfo, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminListVolSnapshot)
+	request.addParam("name", volName)
+	var buf []byte
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	if err = json.Unmarshal(buf, &snapshots); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) GetClusterInfo() (ci *proto.ClusterInfo, err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminGetIP)
 	var buf []byte
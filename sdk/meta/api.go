@@ -41,6 +41,10 @@ const (
 	OpenRetryLimit    = 1000
 )
 
+const (
+	RenameRetryLimit = 3
+)
+
 func (mw *MetaWrapper) GetRootIno(subdir string) (uint64, error) {
 	rootIno, err := mw.LookupPath(subdir)
 	if err != nil {
@@ -127,10 +131,13 @@ func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid ui
 	for i := 0; i < length; i++ {
 		index := (int(epoch) + i) % length
 		mp = rwPartitions[index]
-		status, info, err = mw.icreate(mp, mode, uid, gid, target)
+		status, info, err = mw.icreate(mp, parentID, mode, uid, gid, target)
 		if err == nil && status == statusOK {
 			goto create_dentry
 		}
+		if err == nil && status == statusQuotaExceeded {
+			return nil, statusToErrno(status)
+		}
 	}
 	return nil, syscall.ENOMEM
 
@@ -254,6 +261,29 @@ func (mw *MetaWrapper) InodeDelete_ll(inode uint64) error {
 	return nil
 }
 
+// CheckObjectLock returns EPERM if the inode is under legal hold or its retention period has not
+// expired. The meta node refuses to change the extents of a locked inode, and the clients check
+// the lock before overwriting the existing extents in place on the data nodes.
+func (mw *MetaWrapper) CheckObjectLock(inode uint64) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("CheckObjectLock: no such partition, inode(%v)", inode)
+		return syscall.ENOENT
+	}
+	xattrs, err := mw.batchGetXAttr(mp, []uint64{inode}, []string{proto.XAttrKeyOSSLegalHold, proto.XAttrKeyOSSRetention})
+	if err != nil {
+		return syscall.EIO
+	}
+	for _, xattr := range xattrs {
+		legalHold, retention := xattr.XAttrs[proto.XAttrKeyOSSLegalHold], xattr.XAttrs[proto.XAttrKeyOSSRetention]
+		if xattr.Inode == inode && proto.IsObjectLocked([]byte(legalHold), []byte(retention), time.Now().Unix()) {
+			log.LogWarnf("CheckObjectLock: inode(%v) is locked", inode)
+			return syscall.EPERM
+		}
+	}
+	return nil
+}
+
 func (mw *MetaWrapper) BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.XAttrInfo, error) {
 	// Collect meta partitions
 	var (
@@ -310,11 +340,23 @@ func (mw *MetaWrapper) BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.X
 	return xattrs, nil
 }
 
+// Delete_ll deletes the dentry, or moves the entry into the trash if the trash of the volume is
+// enabled, in which case no inode is unlinked and the returned InodeInfo is nil. The entries
+// directly under the trash directory are deleted permanently, and the callers which know the
+// path of the entry use DeletePermanently_ll for the entries deeper in the trash.
+func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
+	trashed, err := mw.trash(parentID, name, isDir)
+	if trashed || err != nil {
+		return nil, err
+	}
+	return mw.DeletePermanently_ll(parentID, name, isDir)
+}
+
 /*
  * Note that the return value of InodeInfo might be nil without error,
  * and the caller should make sure InodeInfo is valid before using it.
  */
-func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
+func (mw *MetaWrapper) DeletePermanently_ll(parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
 	var (
 		status int
 		inode  uint64
@@ -326,7 +368,7 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
-		log.LogErrorf("Delete_ll: No parent partition, parentID(%v) name(%v)", parentID, name)
+		log.LogErrorf("DeletePermanently_ll: No parent partition, parentID(%v) name(%v)", parentID, name)
 		return nil, syscall.ENOENT
 	}
 
@@ -340,7 +382,7 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 		}
 		mp = mw.getPartitionByInode(inode)
 		if mp == nil {
-			log.LogErrorf("Delete_ll: No inode partition, parentID(%v) name(%v) ino(%v)", parentID, name, inode)
+			log.LogErrorf("DeletePermanently_ll: No inode partition, parentID(%v) name(%v) ino(%v)", parentID, name, inode)
 			return nil, syscall.EAGAIN
 		}
 		status, info, err = mw.iget(mp, inode)
@@ -352,6 +394,21 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 		}
 	}
 
+	if !isDir {
+		status, inode, mode, err = mw.lookup(parentMP, parentID, name)
+		if err != nil || status != statusOK {
+			if status == statusNoent {
+				return nil, nil
+			}
+			return nil, statusToErrno(status)
+		}
+		// The partition of a dentry can not tell whether its inode of another partition is locked,
+		// so they are deleted together in a transaction, which is refused by the inode partition.
+		if mp = mw.getPartitionByInode(inode); mp != nil && mp.PartitionID != parentMP.PartitionID && proto.IsRegular(mode) {
+			return mw.deleteWithTx(parentMP, parentID, name, mp, inode)
+		}
+	}
+
 	status, inode, err = mw.ddelete(parentMP, parentID, name)
 	if err != nil || status != statusOK {
 		if status == statusNoent {
@@ -363,95 +420,159 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 	// dentry is deleted successfully but inode is not, still returns success.
 	mp = mw.getPartitionByInode(inode)
 	if mp == nil {
-		log.LogErrorf("Delete_ll: No inode partition, parentID(%v) name(%v) ino(%v)", parentID, name, inode)
+		log.LogErrorf("DeletePermanently_ll: No inode partition, parentID(%v) name(%v) ino(%v)", parentID, name, inode)
 		return nil, nil
 	}
 
 	status, info, err = mw.iunlink(mp, inode)
+	if err == nil && status == statusNotPerm {
+		// The inode is protected by object lock, so put the dentry back.
+		mw.restoreDentry(parentMP, parentID, name, mp, inode)
+		return nil, syscall.EPERM
+	}
 	if err != nil || status != statusOK {
 		return nil, nil
 	}
 	return info, nil
 }
 
+func (mw *MetaWrapper) deleteWithTx(parentMP *MetaPartition, parentID uint64, name string, mp *MetaPartition, inode uint64) (*proto.InodeInfo, error) {
+	tx := mw.newMetaTx()
+	tx.addOp(parentMP, &proto.TxOp{Type: proto.TxOpDeleteDentry, ParentId: parentID, Name: name, Inode: inode})
+	tx.addOp(mp, &proto.TxOp{Type: proto.TxOpUnlinkInode, Inode: inode})
+	status, _, err := tx.run()
+	if err != nil {
+		return nil, syscall.EAGAIN
+	}
+	switch status {
+	case statusOK:
+	case statusNoent:
+		return nil, nil
+	default:
+		return nil, statusToErrno(status)
+	}
+	// the dentry and the inode are deleted successfully, but the inode info is not returned
+	status, info, err := mw.iget(mp, inode)
+	if err != nil || status != statusOK {
+		return nil, nil
+	}
+	return info, nil
+}
+
+func (mw *MetaWrapper) restoreDentry(parentMP *MetaPartition, parentID uint64, name string, mp *MetaPartition, inode uint64) {
+	status, info, err := mw.iget(mp, inode)
+	if err != nil || status != statusOK {
+		log.LogErrorf("restoreDentry: iget failed, parentID(%v) name(%v) ino(%v) status(%v) err(%v)",
+			parentID, name, inode, status, err)
+		return
+	}
+	status, err = mw.dcreate(parentMP, parentID, name, inode, info.Mode)
+	if err != nil || status != statusOK {
+		log.LogErrorf("restoreDentry: dcreate failed, parentID(%v) name(%v) ino(%v) status(%v) err(%v)",
+			parentID, name, inode, status, err)
+	}
+}
+
+// Rename_ll renames the dentry with a transaction, so that the dentry is moved atomically even if
+// the parent directories and the overwritten inode belong to different meta partitions.
 func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string) (err error) {
-	var oldInode uint64
+	return mw.rename(srcParentID, srcName, dstParentID, dstName, false, false)
+}
 
+// rename renames the dentry. It fails with EEXIST instead of overwriting the dst dentry if
+// noReplace is set, and the inode keeps its quotas if keepQuota is set. The dentries are looked
+// up again if they are changed before the transaction is prepared.
+func (mw *MetaWrapper) rename(srcParentID uint64, srcName string, dstParentID uint64, dstName string, noReplace, keepQuota bool) (err error) {
+	var retry bool
+	for i := 0; i < RenameRetryLimit; i++ {
+		if retry, err = mw.tryRename(srcParentID, srcName, dstParentID, dstName, noReplace, keepQuota); !retry {
+			return
+		}
+		log.LogWarnf("rename: dentries changed, retry: srcParentID(%v) srcName(%v) dstParentID(%v) dstName(%v) err(%v)",
+			srcParentID, srcName, dstParentID, dstName, err)
+	}
+	return
+}
+
+// tryRename renames the dentry with the inodes looked up, and returns whether to retry if the
+// transaction fails since the dentries are changed after the lookups.
+func (mw *MetaWrapper) tryRename(srcParentID uint64, srcName string, dstParentID uint64, dstName string, noReplace, keepQuota bool) (retry bool, err error) {
 	srcParentMP := mw.getPartitionByInode(srcParentID)
 	if srcParentMP == nil {
-		return syscall.ENOENT
+		return false, syscall.ENOENT
 	}
 	dstParentMP := mw.getPartitionByInode(dstParentID)
 	if dstParentMP == nil {
-		return syscall.ENOENT
+		return false, syscall.ENOENT
 	}
 
 	// look up for the src ino
 	status, inode, mode, err := mw.lookup(srcParentMP, srcParentID, srcName)
 	if err != nil || status != statusOK {
-		return statusToErrno(status)
-	}
-	srcMP := mw.getPartitionByInode(inode)
-	if srcMP == nil {
-		return syscall.ENOENT
-	}
-
-	status, _, err = mw.ilink(srcMP, inode)
-	if err != nil || status != statusOK {
-		return statusToErrno(status)
+		return false, statusToErrno(status)
 	}
 
-	// create dentry in dst parent
-	status, err = mw.dcreate(dstParentMP, dstParentID, dstName, inode, mode)
+	// look up for the dst ino to overwrite
+	status, oldInode, oldMode, err := mw.lookup(dstParentMP, dstParentID, dstName)
 	if err != nil {
-		return syscall.EAGAIN
+		return false, syscall.EAGAIN
 	}
 
-	// Note that only regular files are allowed to be overwritten.
-	if status == statusExist && proto.IsRegular(mode) {
-		status, oldInode, err = mw.dupdate(dstParentMP, dstParentID, dstName, inode)
-		if err != nil {
-			return syscall.EAGAIN
+	var (
+		tx         = mw.newMetaTx()
+		oldInodeMP *MetaPartition
+	)
+	switch status {
+	case statusNoent:
+		oldInode = 0
+		tx.addOp(dstParentMP, &proto.TxOp{Type: proto.TxOpCreateDentry, ParentId: dstParentID, Name: dstName, Inode: inode, Mode: mode})
+	case statusOK:
+		if oldInode == inode {
+			// both are the links of the same inode
+			return false, nil
 		}
+		if noReplace {
+			return false, syscall.EEXIST
+		}
+		// Note that only regular files are allowed to be overwritten.
+		if proto.OsModeType(oldMode) != proto.OsModeType(mode) {
+			return false, syscall.EINVAL
+		}
+		if !proto.IsRegular(mode) {
+			return false, syscall.EEXIST
+		}
+		if oldInodeMP = mw.getPartitionByInode(oldInode); oldInodeMP == nil {
+			return false, syscall.ENOENT
+		}
+		tx.addOp(dstParentMP, &proto.TxOp{Type: proto.TxOpUpdateDentry, ParentId: dstParentID, Name: dstName, Inode: inode, Mode: mode, OldInode: oldInode})
+		tx.addOp(oldInodeMP, &proto.TxOp{Type: proto.TxOpUnlinkInode, Inode: oldInode})
+	default:
+		return false, statusToErrno(status)
 	}
-
-	if status != statusOK {
-		mw.iunlink(srcMP, inode)
-		return statusToErrno(status)
-	}
-
 	// delete dentry from src parent
-	status, _, err = mw.ddelete(srcParentMP, srcParentID, srcName)
-	if err != nil {
-		return statusToErrno(status)
-	} else if status != statusOK {
-		var (
-			sts int
-			e   error
-		)
-		if oldInode == 0 {
-			sts, _, e = mw.ddelete(dstParentMP, dstParentID, dstName)
-		} else {
-			sts, _, e = mw.dupdate(dstParentMP, dstParentID, dstName, oldInode)
-		}
-		if e == nil && sts == statusOK {
-			mw.iunlink(srcMP, inode)
+	tx.addOp(srcParentMP, &proto.TxOp{Type: proto.TxOpDeleteDentry, ParentId: srcParentID, Name: srcName, Inode: inode})
+	if srcParentID != dstParentID {
+		// the partition of the inode retags it with the quotas of the dst parent
+		inodeMP := mw.getPartitionByInode(inode)
+		if inodeMP == nil {
+			return false, syscall.ENOENT
 		}
-		return statusToErrno(status)
+		tx.addOp(inodeMP, &proto.TxOp{Type: proto.TxOpMoveInode, ParentId: dstParentID, Inode: inode, Mode: mode, KeepQuota: keepQuota})
 	}
 
-	mw.iunlink(srcMP, inode)
-
-	if oldInode != 0 {
-		inodeMP := mw.getPartitionByInode(oldInode)
-		if inodeMP != nil {
-			mw.iunlink(inodeMP, oldInode)
-			// evict oldInode to avoid oldInode becomes orphan inode
-			mw.ievict(inodeMP, oldInode)
-		}
+	if status, _, err = tx.run(); err != nil {
+		return false, syscall.EAGAIN
+	} else if status != statusOK {
+		// the dst dentry is created, removed or replaced, or the src dentry is removed or replaced
+		retry = status == statusExist || status == statusNoent || status == statusInval
+		return retry, statusToErrno(status)
 	}
 
-	return nil
+	if oldInodeMP != nil {
+		// evict oldInode to avoid oldInode becomes orphan inode
+		mw.ievict(oldInodeMP, oldInode)
+	}
+	return false, nil
 }
 
 func (mw *MetaWrapper) ReadDir_ll(parentID uint64) ([]proto.Dentry, error) {
@@ -467,6 +588,84 @@ func (mw *MetaWrapper) ReadDir_ll(parentID uint64) ([]proto.Dentry, error) {
 	return children, nil
 }
 
+// ReadDirLimit_ll reads at most limit dentries of the directory in the order of their names,
+// starting from the dentry named from, which is included if it exists. To read the next page,
+// pass the name of the last dentry of the page and skip it in the result.
+func (mw *MetaWrapper) ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error) {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return nil, syscall.ENOENT
+	}
+
+	status, children, err := mw.readdirlimit(parentMP, parentID, from, limit)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return children, nil
+}
+
+// GetDirStat_ll returns the recursive statistics of the directory kept by its meta partition. It
+// returns ENODATA if the directory is not counted.
+func (mw *MetaWrapper) GetDirStat_ll(inode uint64) (*proto.DirStat, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("GetDirStat_ll: no such partition, ino(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	status, stat, err := mw.getDirStat(mp, inode)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	if stat == nil {
+		return nil, syscall.ENODATA
+	}
+	return stat, nil
+}
+
+// ListPrefix_ll lists at most limit entries whose paths match the prefix and are not less than
+// the marker, by scanning the directory tree under the parent depth-first in the order of dentry
+// names. The paths containing the delimiter after the prefix are rolled up into common prefixes.
+// The parent path is the path of the parent directory with a trailing slash, or empty for the
+// root directory, and the skipped names are the dentries under the root directory not to list.
+// Each meta partition scans as deep as its own directories go, and the scan continues on the
+// partitions of the other directories, so that it takes far fewer round trips than ReadDir_ll.
+func (mw *MetaWrapper) ListPrefix_ll(parentID uint64, parentPath, prefix, marker, delimiter string, skipped []string, limit uint64) ([]*proto.ListPrefixItem, error) {
+	var items = make([]*proto.ListPrefixItem, 0)
+	var prefixes = make(map[string]struct{})
+	var frames = []*proto.ListPrefixFrame{{Inode: parentID, Path: parentPath}}
+	for len(frames) > 0 && uint64(len(items)) < limit {
+		// The directories on the top of the stack which belong to the same partition are
+		// sent in one request.
+		mp := mw.getPartitionByInode(frames[len(frames)-1].Inode)
+		if mp == nil {
+			return nil, syscall.ENOENT
+		}
+		var bottom = len(frames) - 1
+		for bottom > 0 {
+			if lower := mw.getPartitionByInode(frames[bottom-1].Inode); lower == nil || lower.PartitionID != mp.PartitionID {
+				break
+			}
+			bottom--
+		}
+		status, resp, err := mw.listPrefix(mp, frames[bottom:], prefix, marker, delimiter, skipped, limit-uint64(len(items)))
+		if err != nil || status != statusOK {
+			return nil, statusToErrno(status)
+		}
+		frames = append(frames[:bottom], resp.Frames...)
+		for _, item := range resp.Items {
+			// The common prefix may be found again by the scan on another partition.
+			if item.IsPrefix {
+				if _, exist := prefixes[item.Path]; exist {
+					continue
+				}
+				prefixes[item.Path] = struct{}{}
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
 func (mw *MetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32) error {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
@@ -495,6 +694,31 @@ func (mw *MetaWrapper) DentryUpdate_ll(parentID uint64, name string, inode uint6
 	return
 }
 
+// DentryReplace_ll points the dentry from the old inode to the new one, and unlinks and evicts the
+// old inode in the same transaction. It fails with EINVAL if the dentry does not point to the old inode any more,
+// and with EPERM if the old inode is protected by object lock, leaving the dentry unchanged.
+func (mw *MetaWrapper) DentryReplace_ll(parentID uint64, name string, mode uint32, inode, oldInode uint64) error {
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return syscall.ENOENT
+	}
+	oldInodeMP := mw.getPartitionByInode(oldInode)
+	if oldInodeMP == nil {
+		return syscall.ENOENT
+	}
+	tx := mw.newMetaTx()
+	tx.addOp(parentMP, &proto.TxOp{Type: proto.TxOpUpdateDentry, ParentId: parentID, Name: name, Inode: inode, Mode: mode, OldInode: oldInode})
+	tx.addOp(oldInodeMP, &proto.TxOp{Type: proto.TxOpUnlinkInode, Inode: oldInode})
+	status, _, err := tx.run()
+	if err != nil {
+		return syscall.EAGAIN
+	} else if status != statusOK {
+		return statusToErrno(status)
+	}
+	mw.ievict(oldInodeMP, oldInode)
+	return nil
+}
+
 // Used as a callback by stream sdk
 func (mw *MetaWrapper) AppendExtentKey(inode uint64, ek proto.ExtentKey, discard []proto.ExtentKey) error {
 	mp := mw.getPartitionByInode(inode)
@@ -557,6 +781,7 @@ func (mw *MetaWrapper) Truncate(inode, size uint64) error {
 
 }
 
+// Link creates the dentry and increases the nlink of the inode with a transaction.
 func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64) (*proto.InodeInfo, error) {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
@@ -570,22 +795,26 @@ func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64) (*proto.In
 		return nil, syscall.ENOENT
 	}
 
-	// increase inode nlink
-	status, info, err := mw.ilink(mp, ino)
+	status, info, err := mw.iget(mp, ino)
 	if err != nil || status != statusOK {
 		return nil, statusToErrno(status)
 	}
 
-	// create new dentry and refer to the inode
-	status, err = mw.dcreate(parentMP, parentID, name, ino, info.Mode)
+	// increase inode nlink and create new dentry which refers to the inode
+	tx := mw.newMetaTx()
+	tx.addOp(mp, &proto.TxOp{Type: proto.TxOpLinkInode, ParentId: parentID, Inode: ino})
+	tx.addOp(parentMP, &proto.TxOp{Type: proto.TxOpCreateDentry, ParentId: parentID, Name: name, Inode: ino, Mode: info.Mode})
+	status, inodes, err := tx.run()
 	if err != nil {
-		return nil, statusToErrno(status)
+		return nil, syscall.EAGAIN
 	} else if status != statusOK {
-		if status != statusExist {
-			mw.iunlink(mp, ino)
-		}
 		return nil, statusToErrno(status)
 	}
+	for _, linked := range inodes {
+		if linked.Inode == ino {
+			return linked, nil
+		}
+	}
 	return info, nil
 }
 
@@ -620,7 +849,9 @@ func (mw *MetaWrapper) Setattr(inode uint64, valid, mode, uid, gid uint32, atime
 	return nil
 }
 
-func (mw *MetaWrapper) InodeCreate_ll(mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
+// InodeCreate_ll creates an inode without a dentry. The inode inherits the quotas of the parent
+// directory, which is the directory the dentry will be created under, or 0 if unknown.
+func (mw *MetaWrapper) InodeCreate_ll(parentID uint64, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
 	var (
 		status       int
 		err          error
@@ -635,10 +866,13 @@ func (mw *MetaWrapper) InodeCreate_ll(mode, uid, gid uint32, target []byte) (*pr
 	for i := 0; i < length; i++ {
 		index := (int(epoch) + i) % length
 		mp = rwPartitions[index]
-		status, info, err = mw.icreate(mp, mode, uid, gid, target)
+		status, info, err = mw.icreate(mp, parentID, mode, uid, gid, target)
 		if err == nil && status == statusOK {
 			return info, nil
 		}
+		if err == nil && status == statusQuotaExceeded {
+			return nil, statusToErrno(status)
+		}
 	}
 	return nil, syscall.ENOMEM
 }
@@ -843,6 +1077,17 @@ func (mw *MetaWrapper) ListMultipart_ll(prefix, delimiter, keyMarker string, mul
 }
 
 func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
+	return mw.xattrSet(inode, name, value, false)
+}
+
+// ObjectLockXAttrSet_ll sets the object lock attribute, and is allowed to release the legal hold
+// and to shorten the retention in GOVERNANCE mode if the wrapper is configured with the object lock
+// key of the meta nodes. The caller must have checked the permission.
+func (mw *MetaWrapper) ObjectLockXAttrSet_ll(inode uint64, name, value []byte) error {
+	return mw.xattrSet(inode, name, value, true)
+}
+
+func (mw *MetaWrapper) xattrSet(inode uint64, name, value []byte, allowLockChange bool) error {
 	var err error
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -850,7 +1095,7 @@ func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
 		return syscall.ENOENT
 	}
 	var status int
-	status, err = mw.setXAttr(mp, inode, name, value)
+	status, err = mw.setXAttr(mp, inode, name, value, allowLockChange)
 	if err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
@@ -886,6 +1131,15 @@ func (mw *MetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo,
 
 // XAttrDel_ll is a low-level meta api that deletes specified xattr.
 func (mw *MetaWrapper) XAttrDel_ll(inode uint64, name string) error {
+	return mw.xattrDel(inode, name, false)
+}
+
+// ObjectLockXAttrDel_ll removes the object lock attribute like ObjectLockXAttrSet_ll.
+func (mw *MetaWrapper) ObjectLockXAttrDel_ll(inode uint64, name string) error {
+	return mw.xattrDel(inode, name, true)
+}
+
+func (mw *MetaWrapper) xattrDel(inode uint64, name string, allowLockChange bool) error {
 	var err error
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -893,7 +1147,7 @@ func (mw *MetaWrapper) XAttrDel_ll(inode uint64, name string) error {
 		return syscall.ENOENT
 	}
 	var status int
-	status, err = mw.removeXAttr(mp, inode, name)
+	status, err = mw.removeXAttr(mp, inode, name, allowLockChange)
 	if err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
@@ -901,6 +1155,21 @@ func (mw *MetaWrapper) XAttrDel_ll(inode uint64, name string) error {
 	return nil
 }
 
+// TagQuota_ll tags the inode with the directory quota, which applies a new quota to the inodes
+// already under the directory.
+func (mw *MetaWrapper) TagQuota_ll(inode uint64, quotaId uint64) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("TagQuota_ll: no such partition, inode(%v)", inode)
+		return syscall.ENOENT
+	}
+	status, err := mw.tagQuota(mp, inode, quotaId)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
+
 func (mw *MetaWrapper) XAttrsList_ll(inode uint64) ([]string, error) {
 	var err error
 	mp := mw.getPartitionByInode(inode)
@@ -0,0 +1,97 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// ReadChangelog reads at most limit events of the changelog of the meta partition, starting
+// from the sequence number of the cursor. The events before resp.FirstSeq have been discarded
+// by the partition, so a consumer whose cursor is less than it has missed some changes.
+func (mw *MetaWrapper) ReadChangelog(partitionID uint64, cursor uint64, limit int) (*proto.ReadChangelogResponse, error) {
+	mp := mw.getPartitionByID(partitionID)
+	if mp == nil {
+		log.LogErrorf("ReadChangelog: No such partition, pid(%v)", partitionID)
+		return nil, syscall.ENOENT
+	}
+	status, resp, err := mw.readChangelog(mp, cursor, limit)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return resp, nil
+}
+
+// ChangelogPartitions returns the IDs of the meta partitions whose changelogs make up the
+// changelog of the volume.
+func (mw *MetaWrapper) ChangelogPartitions() []uint64 {
+	mw.RLock()
+	defer mw.RUnlock()
+	ids := make([]uint64, 0, len(mw.partitions))
+	for id := range mw.partitions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (mw *MetaWrapper) readChangelog(mp *MetaPartition, cursor uint64, limit int) (status int, resp *proto.ReadChangelogResponse, err error) {
+	req := &proto.ReadChangelogRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Cursor:      cursor,
+		Limit:       limit,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadChangelog
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readChangelog: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	log.LogDebugf("readChangelog enter: packet(%v) mp(%v) req(%v)", packet, mp, string(packet.Data))
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readChangelog: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("readChangelog: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.ReadChangelogResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("readChangelog: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("readChangelog exit: packet(%v) mp(%v) req(%v) events(%v)", packet, mp, *req, len(resp.Events))
+	return
+}
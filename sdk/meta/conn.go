@@ -67,6 +67,10 @@ func (mw *MetaWrapper) sendToMetaPartition(mp *MetaPartition, req *proto.Packet)
 		mc    *MetaConn
 		start time.Time
 	)
+	if mw.snapshotId != 0 && !volSnapshotReadOps[req.Opcode] {
+		return nil, ErrVolSnapshotReadOnly
+	}
+
 	errs := make(map[int]error, len(mp.Members))
 	var j int
 
@@ -0,0 +1,238 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// lockPartition is a meta partition which may hold the locks of the client session.
+type lockPartition struct {
+	mp       *MetaPartition
+	lockTime time.Time // last time a lock is acquired in the partition
+}
+
+// SetLock acquires or releases the lock of the inode on behalf of the client session. It fails
+// with EAGAIN if a conflicting lock is held.
+func (mw *MetaWrapper) SetLock(lock *proto.LockInfo) error {
+	mp := mw.getPartitionByInode(lock.Inode)
+	if mp == nil {
+		log.LogErrorf("SetLock: No such partition, ino(%v)", lock.Inode)
+		return syscall.ENOENT
+	}
+	mw.initLockSession()
+	lock.Session = mw.lockSession
+	if lock.Type != proto.LockTypeUnlock {
+		// register the partition before the lock is acquired, so that the heartbeats keep it
+		mw.lockMutex.Lock()
+		mw.lockParts[mp.PartitionID] = &lockPartition{mp: mp, lockTime: time.Now()}
+		mw.lockMutex.Unlock()
+	}
+	status, err := mw.setLock(mp, lock)
+	if err != nil || status == statusError {
+		// not to be taken as a conflict which blocking requests wait for
+		return syscall.EIO
+	}
+	if status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
+
+// GetLock returns a lock of the inode which conflicts with the given lock, nil if there is none.
+func (mw *MetaWrapper) GetLock(lock *proto.LockInfo) (*proto.LockInfo, error) {
+	mp := mw.getPartitionByInode(lock.Inode)
+	if mp == nil {
+		log.LogErrorf("GetLock: No such partition, ino(%v)", lock.Inode)
+		return nil, syscall.ENOENT
+	}
+	mw.initLockSession()
+	lock.Session = mw.lockSession
+	status, conflict, err := mw.getLock(mp, lock)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return conflict, nil
+}
+
+func (mw *MetaWrapper) initLockSession() {
+	mw.lockOnce.Do(func() {
+		mw.lockSession = fmt.Sprintf("%v_%v_%v", mw.localIP, os.Getpid(), time.Now().UnixNano())
+		go mw.lockHeartbeat()
+	})
+}
+
+// lockHeartbeat renews the leases of the locks held by the session in each partition, until the
+// partition replies that the session holds no locks.
+func (mw *MetaWrapper) lockHeartbeat() {
+	t := time.NewTicker(proto.LockHeartbeatInterval * time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-mw.closeCh:
+			return
+		case <-t.C:
+		}
+		mw.lockMutex.Lock()
+		parts := make([]*lockPartition, 0, len(mw.lockParts))
+		for _, part := range mw.lockParts {
+			parts = append(parts, part)
+		}
+		mw.lockMutex.Unlock()
+
+		for _, part := range parts {
+			start := time.Now()
+			status, err := mw.renewLock(part.mp)
+			if err != nil || (status != statusOK && status != statusNoent) {
+				log.LogWarnf("lockHeartbeat: mp(%v) session(%v) status(%v) err(%v)", part.mp, mw.lockSession, status, err)
+				continue
+			}
+			if status == statusNoent {
+				mw.lockMutex.Lock()
+				if cur, ok := mw.lockParts[part.mp.PartitionID]; ok && cur.lockTime.Before(start) {
+					delete(mw.lockParts, part.mp.PartitionID)
+				}
+				mw.lockMutex.Unlock()
+			}
+		}
+	}
+}
+
+func (mw *MetaWrapper) setLock(mp *MetaPartition, lock *proto.LockInfo) (status int, err error) {
+	req := &proto.SetLockRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Lock:        lock,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaSetLock
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("setLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	log.LogDebugf("setLock enter: packet(%v) mp(%v) req(%v)", packet, mp, string(packet.Data))
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("setLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		if status != statusLockConflict {
+			log.LogErrorf("setLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		}
+		return
+	}
+	log.LogDebugf("setLock exit: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
+
+func (mw *MetaWrapper) getLock(mp *MetaPartition, lock *proto.LockInfo) (status int, conflict *proto.LockInfo, err error) {
+	req := &proto.GetLockRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Lock:        lock,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetLock
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("getLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	log.LogDebugf("getLock enter: packet(%v) mp(%v) req(%v)", packet, mp, string(packet.Data))
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetLockResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("getLock: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	conflict = resp.Lock
+	log.LogDebugf("getLock exit: packet(%v) mp(%v) req(%v) conflict(%v)", packet, mp, *req, conflict)
+	return
+}
+
+func (mw *MetaWrapper) renewLock(mp *MetaPartition) (status int, err error) {
+	req := &proto.LockHeartbeatRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Session:     mw.lockSession,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaLockHeartbeat
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("renewLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	log.LogDebugf("renewLock enter: packet(%v) mp(%v) req(%v)", packet, mp, string(packet.Data))
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("renewLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	log.LogDebugf("renewLock exit: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
@@ -47,6 +47,9 @@ const (
 	statusInval
 	statusNotPerm
 	statusConflictExtents
+	statusQuotaExceeded
+	statusLockConflict
+	statusQuotaCross
 )
 
 const (
@@ -76,6 +79,8 @@ type MetaConfig struct {
 	TicketMess       auth.TicketMess
 	ValidateOwner    bool
 	OnAsyncTaskError AsyncTaskErrorFunc
+	Snapshot         string // name or ID of the volume snapshot to read, empty reads the live volume
+	ObjectLockKey    []byte // key shared with the meta nodes to sign the object lock changes
 }
 
 type MetaWrapper struct {
@@ -84,9 +89,13 @@ type MetaWrapper struct {
 	localIP         string
 	volname         string
 	ossSecure       *OSSSecure
+	qos             *proto.QoSLimit
+	trashInterval   uint32
+	snapshotId      uint64 // ID of the volume snapshot to read, the wrapper is read-only if it is set
 	volCreateTime   int64
 	owner           string
 	ownerValidation bool
+	objectLockKey   []byte
 	mc              *masterSDK.MasterClient
 	ac              *authSDK.AuthClient
 	conns           *util.ConnectPool
@@ -119,6 +128,12 @@ type MetaWrapper struct {
 	closeCh   chan struct{}
 	closeOnce sync.Once
 
+	// File locks held by the client session, whose leases are renewed by heartbeats
+	lockSession string
+	lockOnce    sync.Once
+	lockMutex   sync.Mutex
+	lockParts   map[uint64]*lockPartition
+
 	// Used to signal the go routines which are waiting for partition view update
 	partMutex sync.Mutex
 	partCond  *sync.Cond
@@ -140,6 +155,7 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	var err error
 	mw := new(MetaWrapper)
 	mw.closeCh = make(chan struct{}, 1)
+	mw.lockParts = make(map[uint64]*lockPartition)
 
 	if config.Authenticate {
 		var ticketMess = config.TicketMess
@@ -159,6 +175,7 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.volname = config.Volume
 	mw.owner = config.Owner
 	mw.ownerValidation = config.ValidateOwner
+	mw.objectLockKey = config.ObjectLockKey
 	mw.mc = masterSDK.NewMasterClient(config.Masters, false)
 	mw.onAsyncTaskError = config.OnAsyncTaskError
 	mw.conns = util.NewConnectPool()
@@ -169,6 +186,12 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.forceUpdate = make(chan struct{}, 1)
 	mw.forceUpdateLimit = rate.NewLimiter(1, MinForceUpdateMetaPartitionsInterval)
 
+	if config.Snapshot != "" {
+		if err = mw.resolveSnapshot(config.Snapshot); err != nil {
+			return nil, err
+		}
+	}
+
 	limit := MaxMountRetryLimit
 
 	for limit > 0 {
@@ -218,6 +241,11 @@ func (mw *MetaWrapper) OSSSecure() (accessKey, secretKey string) {
 	return mw.ossSecure.AccessKey, mw.ossSecure.SecretKey
 }
 
+// QoS returns the limits on the requests which ObjectNode serves to the volume.
+func (mw *MetaWrapper) QoS() *proto.QoSLimit {
+	return mw.qos
+}
+
 func (mw *MetaWrapper) VolCreateTime() int64 {
 	return mw.volCreateTime
 }
@@ -261,6 +289,12 @@ func parseStatus(result uint8) (status int) {
 		status = statusNotPerm
 	case proto.OpConflictExtentsErr:
 		status = statusConflictExtents
+	case proto.OpQuotaExceededErr:
+		status = statusQuotaExceeded
+	case proto.OpLockConflictErr:
+		status = statusLockConflict
+	case proto.OpQuotaCrossErr:
+		status = statusQuotaCross
 	default:
 		status = statusError
 	}
@@ -288,6 +322,12 @@ func statusToErrno(status int) error {
 		return syscall.EAGAIN
 	case statusConflictExtents:
 		return syscall.EIO
+	case statusQuotaExceeded:
+		return syscall.EDQUOT
+	case statusLockConflict:
+		return syscall.EAGAIN
+	case statusQuotaCross:
+		return syscall.EXDEV
 	default:
 	}
 	return syscall.EIO
@@ -17,6 +17,7 @@ package meta
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/chubaofs/chubaofs/util/errors"
 
@@ -28,7 +29,7 @@ import (
 // API implementations
 //
 
-func (mw *MetaWrapper) icreate(mp *MetaPartition, mode, uid, gid uint32, target []byte) (status int, info *proto.InodeInfo, err error) {
+func (mw *MetaWrapper) icreate(mp *MetaPartition, parentID uint64, mode, uid, gid uint32, target []byte) (status int, info *proto.InodeInfo, err error) {
 	req := &proto.CreateInodeRequest{
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
@@ -36,6 +37,7 @@ func (mw *MetaWrapper) icreate(mp *MetaPartition, mode, uid, gid uint32, target
 		Uid:         uid,
 		Gid:         gid,
 		Target:      target,
+		ParentId:    parentID,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -303,6 +305,7 @@ func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (
 		PartitionID: mp.PartitionID,
 		ParentID:    parentID,
 		Name:        name,
+		SnapshotId:  mw.snapshotId,
 	}
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaLookup
@@ -351,6 +354,7 @@ func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64) (status int, info *
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		Inode:       inode,
+		SnapshotId:  mw.snapshotId,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -397,6 +401,7 @@ func (mw *MetaWrapper) batchIget(wg *sync.WaitGroup, mp *MetaPartition, inodes [
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		Inodes:      inodes,
+		SnapshotId:  mw.snapshotId,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -446,6 +451,7 @@ func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64) (status int,
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		ParentID:    parentID,
+		SnapshotId:  mw.snapshotId,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -485,6 +491,145 @@ func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64) (status int,
 	return statusOK, resp.Children, nil
 }
 
+func (mw *MetaWrapper) readdirlimit(mp *MetaPartition, parentID uint64, from string, limit uint64) (status int, children []proto.Dentry, err error) {
+	req := &proto.ReadDirLimitRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		ParentID:    parentID,
+		Marker:      from,
+		Limit:       limit,
+		SnapshotId:  mw.snapshotId,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadDirLimit
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readdirlimit: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readdirlimit: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		children = make([]proto.Dentry, 0)
+		log.LogErrorf("readdirlimit: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.ReadDirLimitResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("readdirlimit: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("readdirlimit: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return statusOK, resp.Children, nil
+}
+
+func (mw *MetaWrapper) listPrefix(mp *MetaPartition, frames []*proto.ListPrefixFrame, prefix, marker, delimiter string, skipped []string, limit uint64) (status int, resp *proto.ListPrefixResponse, err error) {
+	req := &proto.ListPrefixRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Frames:      frames,
+		Prefix:      prefix,
+		Marker:      marker,
+		Delimiter:   delimiter,
+		Skipped:     skipped,
+		Limit:       limit,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaListPrefix
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("listPrefix: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("listPrefix: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("listPrefix: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.ListPrefixResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("listPrefix: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("listPrefix: packet(%v) mp(%v) req(%v) items(%v) frames(%v)", packet, mp, *req, len(resp.Items), len(resp.Frames))
+	return statusOK, resp, nil
+}
+
+func (mw *MetaWrapper) getDirStat(mp *MetaPartition, inode uint64) (status int, stat *proto.DirStat, err error) {
+	req := &proto.GetDirStatRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		SnapshotId:  mw.snapshotId,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetDirStat
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("getDirStat: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getDirStat: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("getDirStat: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetDirStatResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("getDirStat: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("getDirStat: packet(%v) mp(%v) req(%v) stat(%v)", packet, mp, *req, resp.Stat)
+	return statusOK, resp.Stat, nil
+}
+
 func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent proto.ExtentKey, discard []proto.ExtentKey) (status int, err error) {
 	req := &proto.AppendExtentKeyWithCheckRequest{
 		VolName:        mw.volname,
@@ -526,6 +671,7 @@ func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64) (status int,
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		Inode:       inode,
+		SnapshotId:  mw.snapshotId,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -947,7 +1093,7 @@ func (mw *MetaWrapper) appendExtentKeys(mp *MetaPartition, inode uint64, extents
 	return
 }
 
-func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, value []byte) (status int, err error) {
+func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, value []byte, allowLockChange bool) (status int, err error) {
 	req := &proto.SetXAttrRequest{
 		VolName:     mw.volname,
 		PartitionId: mp.PartitionID,
@@ -955,6 +1101,10 @@ func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, va
 		Key:         string(name),
 		Value:       string(value),
 	}
+	if allowLockChange && len(mw.objectLockKey) > 0 {
+		req.LockChangeTime = time.Now().Unix()
+		req.LockChangeSign = proto.SignObjectLockChange(mw.objectLockKey, mw.volname, inode, req.Key, req.Value, req.LockChangeTime)
+	}
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaSetXAttr
@@ -988,12 +1138,50 @@ func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, va
 	return
 }
 
+func (mw *MetaWrapper) tagQuota(mp *MetaPartition, inode uint64, quotaId uint64) (status int, err error) {
+	req := &proto.TagQuotaRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		QuotaId:     quotaId,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTagQuota
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("tagQuota: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("tagQuota: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("tagQuota: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("tagQuota: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
 func (mw *MetaWrapper) getXAttr(mp *MetaPartition, inode uint64, name string) (value string, status int, err error) {
 	req := &proto.GetXAttrRequest{
 		VolName:     mw.volname,
 		PartitionId: mp.PartitionID,
 		Inode:       inode,
 		Key:         name,
+		SnapshotId:  mw.snapshotId,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -1034,13 +1222,17 @@ func (mw *MetaWrapper) getXAttr(mp *MetaPartition, inode uint64, name string) (v
 	return
 }
 
-func (mw *MetaWrapper) removeXAttr(mp *MetaPartition, inode uint64, name string) (status int, err error) {
+func (mw *MetaWrapper) removeXAttr(mp *MetaPartition, inode uint64, name string, allowLockChange bool) (status int, err error) {
 	req := &proto.RemoveXAttrRequest{
 		VolName:     mw.volname,
 		PartitionId: mp.PartitionID,
 		Inode:       inode,
 		Key:         name,
 	}
+	if allowLockChange && len(mw.objectLockKey) > 0 {
+		req.LockChangeTime = time.Now().Unix()
+		req.LockChangeSign = proto.SignObjectLockChange(mw.objectLockKey, mw.volname, inode, req.Key, "", req.LockChangeTime)
+	}
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaRemoveXAttr
@@ -1076,6 +1268,7 @@ func (mw *MetaWrapper) listXAttr(mp *MetaPartition, inode uint64) (keys []string
 		VolName:     mw.volname,
 		PartitionId: mp.PartitionID,
 		Inode:       inode,
+		SnapshotId:  mw.snapshotId,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -1172,6 +1365,7 @@ func (mw *MetaWrapper) batchGetXAttr(mp *MetaPartition, inodes []uint64, keys []
 		PartitionId: mp.PartitionID,
 		Inodes:      inodes,
 		Keys:        keys,
+		SnapshotId:  mw.snapshotId,
 	}
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaBatchGetXAttr
@@ -0,0 +1,65 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+var ErrVolSnapshotReadOnly = errors.New("volume snapshot is read-only")
+
+// The operations served by the meta partitions at a volume snapshot.
+var volSnapshotReadOps = map[uint8]bool{
+	proto.OpMetaLookup:        true,
+	proto.OpMetaInodeGet:      true,
+	proto.OpMetaBatchInodeGet: true,
+	proto.OpMetaReadDir:       true,
+	proto.OpMetaReadDirLimit:  true,
+	proto.OpMetaGetDirStat:    true,
+	proto.OpMetaExtentsList:   true,
+	proto.OpMetaGetXAttr:      true,
+	proto.OpMetaBatchGetXAttr: true,
+	proto.OpMetaListXAttr:     true,
+}
+
+// SnapshotId returns the ID of the volume snapshot which the wrapper reads, 0 means the live volume.
+func (mw *MetaWrapper) SnapshotId() uint64 {
+	return mw.snapshotId
+}
+
+// resolveSnapshot finds the ready snapshot of the volume by its name or ID.
+func (mw *MetaWrapper) resolveSnapshot(snapshot string) (err error) {
+	var snapshots []*proto.VolSnapshotInfo
+	if snapshots, err = mw.mc.AdminAPI().ListVolSnapshot(mw.volname); err != nil {
+		log.LogWarnf("resolveSnapshot: list snapshots fail: volume(%v) err(%v)", mw.volname, err)
+		return
+	}
+	for _, info := range snapshots {
+		if info.Name != snapshot && strconv.FormatUint(info.SnapshotId, 10) != snapshot {
+			continue
+		}
+		if !info.IsReady() {
+			return proto.ErrVolSnapshotNotReady
+		}
+		mw.snapshotId = info.SnapshotId
+		log.LogInfof("resolveSnapshot: volume(%v) snapshot(%v)", mw.volname, *info)
+		return
+	}
+	return proto.ErrVolSnapshotNotExists
+}
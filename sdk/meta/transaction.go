@@ -0,0 +1,213 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+var txIdSeq uint64
+
+// metaTx changes the dentries and the inodes of several meta partitions atomically. The partition
+// of the first operation coordinates the transaction, and the operations of each partition are
+// prepared in the order they are added.
+type metaTx struct {
+	mw       *MetaWrapper
+	info     *proto.TxInfo
+	parts    []*MetaPartition
+	ops      map[uint64][]*proto.TxOp
+	prepared bool // the coordinator is prepared
+}
+
+func (mw *MetaWrapper) newMetaTx() *metaTx {
+	return &metaTx{
+		mw: mw,
+		info: &proto.TxInfo{
+			TxId:    fmt.Sprintf("%v_%v_%v_%v", mw.localIP, os.Getpid(), time.Now().UnixNano(), atomic.AddUint64(&txIdSeq, 1)),
+			Timeout: proto.TxDefaultTimeout,
+		},
+		ops: make(map[uint64][]*proto.TxOp),
+	}
+}
+
+func (tx *metaTx) addOp(mp *MetaPartition, op *proto.TxOp) {
+	if _, ok := tx.ops[mp.PartitionID]; !ok {
+		tx.parts = append(tx.parts, mp)
+		tx.info.Participants = append(tx.info.Participants, &proto.TxParticipant{
+			PartitionID: mp.PartitionID,
+			Members:     mp.Members,
+		})
+	}
+	tx.ops[mp.PartitionID] = append(tx.ops[mp.PartitionID], op)
+}
+
+// run prepares all the participants and commits the transaction, or rolls it back if any of
+// them fails to prepare.
+func (tx *metaTx) run() (status int, inodes []*proto.InodeInfo, err error) {
+	for _, mp := range tx.parts {
+		var resp *proto.TxPrepareResponse
+		status, resp, err = tx.mw.txPrepare(mp, tx.info, tx.ops[mp.PartitionID])
+		if err != nil || status != statusOK {
+			tx.rollback()
+			return
+		}
+		tx.prepared = true
+		inodes = append(inodes, resp.Inodes...)
+	}
+	status, err = tx.mw.txCommit(tx.parts[0], tx.info.TxId)
+	return
+}
+
+func (tx *metaTx) rollback() {
+	if !tx.prepared {
+		return
+	}
+	// the coordinator rolls back the expired transaction if it fails here
+	status, err := tx.mw.txRollback(tx.parts[0], tx.info.TxId)
+	if err != nil || status != statusOK {
+		log.LogWarnf("rollback: txID(%v) status(%v) err(%v)", tx.info.TxId, status, err)
+	}
+}
+
+func (mw *MetaWrapper) txPrepare(mp *MetaPartition, info *proto.TxInfo, ops []*proto.TxOp) (status int, resp *proto.TxPrepareResponse, err error) {
+	req := &proto.TxPrepareRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Tx:          info,
+		Ops:         ops,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxPrepare
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("txPrepare: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	log.LogDebugf("txPrepare enter: packet(%v) mp(%v) req(%v)", packet, mp, string(packet.Data))
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("txPrepare: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txPrepare: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.TxPrepareResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("txPrepare: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("txPrepare exit: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
+
+func (mw *MetaWrapper) txCommit(mp *MetaPartition, txId string) (status int, err error) {
+	req := &proto.TxCommitRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		TxId:        txId,
+		Coordinator: mp.PartitionID,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxCommit
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("txCommit: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	log.LogDebugf("txCommit enter: packet(%v) mp(%v) req(%v)", packet, mp, string(packet.Data))
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("txCommit: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txCommit: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("txCommit exit: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
+
+func (mw *MetaWrapper) txRollback(mp *MetaPartition, txId string) (status int, err error) {
+	req := &proto.TxRollbackRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		TxId:        txId,
+		Coordinator: mp.PartitionID,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxRollback
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("txRollback: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	log.LogDebugf("txRollback enter: packet(%v) mp(%v) req(%v)", packet, mp, string(packet.Data))
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("txRollback: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txRollback: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("txRollback exit: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
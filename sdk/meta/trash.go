@@ -0,0 +1,398 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	maxTrashNameLen = 255
+	trashRetryCount = 3
+)
+
+var trashXAttrKeys = []string{
+	proto.XAttrKeyTrashName,
+	proto.XAttrKeyTrashTime,
+}
+
+// TrashEntry is a deleted file or directory in the trash.
+type TrashEntry struct {
+	Name       string // path in the trash, "<inode of the original parent>/<name>"
+	Inode      uint64
+	Mode       uint32
+	Bucket     uint64 // inode of the directory in the trash which keeps the entry
+	ParentID   uint64 // inode of the original parent directory
+	OrigName   string
+	DeleteTime time.Time
+}
+
+// TrashInterval returns the minutes the deleted files stay in the trash, 0 means the trash is disabled.
+func (mw *MetaWrapper) TrashInterval() uint32 {
+	mw.RLock()
+	defer mw.RUnlock()
+	return mw.trashInterval
+}
+
+// LookupTrash_ll returns the inode of the trash directory, which is created if it does not exist
+// and create is true. The trash directory is sticky, so that the users can not delete the entries
+// of each other.
+func (mw *MetaWrapper) LookupTrash_ll(create bool) (uint64, error) {
+	ino, _, err := mw.Lookup_ll(proto.RootIno, proto.TrashDirName)
+	if err != syscall.ENOENT || !create {
+		return ino, err
+	}
+	info, err := mw.Create_ll(proto.RootIno, proto.TrashDirName, proto.Mode(os.ModeDir|os.ModeSticky|0777), 0, 0, nil)
+	if err == syscall.EEXIST {
+		ino, _, err = mw.Lookup_ll(proto.RootIno, proto.TrashDirName)
+		return ino, err
+	}
+	if err != nil {
+		log.LogErrorf("LookupTrash_ll: create trash failed, err(%v)", err)
+		return 0, err
+	}
+	return info.Inode, nil
+}
+
+// lookupTrashBucket returns the inode of the directory in the trash which keeps the entries deleted
+// from the parent. It is created with the owner and the permissions of the parent if parent is not
+// nil, so that the deleted entries are accessible to the same users.
+func (mw *MetaWrapper) lookupTrashBucket(trashIno, parentID uint64, parent *proto.InodeInfo) (uint64, error) {
+	name := strconv.FormatUint(parentID, 10)
+	ino, _, err := mw.Lookup_ll(trashIno, name)
+	if err != syscall.ENOENT || parent == nil {
+		return ino, err
+	}
+	info, err := mw.Create_ll(trashIno, name, parent.Mode, parent.Uid, parent.Gid, nil)
+	if err == syscall.EEXIST {
+		ino, _, err = mw.Lookup_ll(trashIno, name)
+		return ino, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Inode, nil
+}
+
+// trash moves the file or the empty directory into the trash instead of deleting it. It returns
+// false if the trash is disabled, or the entry is the trash directory, directly under it or does
+// not exist, in which case the entry is deleted permanently.
+func (mw *MetaWrapper) trash(parentID uint64, name string, isDir bool) (trashed bool, err error) {
+	if mw.TrashInterval() == 0 || mw.SnapshotId() != 0 || (parentID == proto.RootIno && name == proto.TrashDirName) {
+		return false, nil
+	}
+	trashIno, err := mw.LookupTrash_ll(true)
+	if err != nil || trashIno == parentID {
+		return false, err
+	}
+	ino, mode, err := mw.Lookup_ll(parentID, name)
+	if err == syscall.ENOENT || (err == nil && isDir != proto.IsDir(mode)) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if isDir {
+		info, err := mw.InodeGet_ll(ino)
+		if err != nil {
+			return false, err
+		}
+		if info.Nlink > 2 {
+			return false, syscall.ENOTEMPTY
+		}
+	}
+	parent, err := mw.InodeGet_ll(parentID)
+	if err != nil {
+		return false, err
+	}
+
+	// The entry is renamed if the name is taken in the trash by the entry deleted before, and the
+	// directory in the trash may be purged after it is looked up.
+	trashName := name
+	for i := 0; i < trashRetryCount; i++ {
+		var bucket uint64
+		if bucket, err = mw.lookupTrashBucket(trashIno, parentID, parent); err != nil {
+			break
+		}
+		err = mw.rename(parentID, name, bucket, trashName, true, true)
+		if err == syscall.EEXIST {
+			trashName = trashEntryName(name)
+			continue
+		}
+		if err != syscall.ENOENT {
+			break
+		}
+	}
+	if err != nil {
+		log.LogErrorf("trash: move into trash failed, parentID(%v) name(%v) ino(%v) err(%v)",
+			parentID, name, ino, err)
+		return false, err
+	}
+
+	// The deletion time is set after the entry is moved, and the entries without it are taken as
+	// deleted when the trash is purged next time.
+	var xattrs = map[string]string{proto.XAttrKeyTrashTime: strconv.FormatInt(time.Now().Unix(), 10)}
+	if trashName != name {
+		xattrs[proto.XAttrKeyTrashName] = name
+	}
+	for key, value := range xattrs {
+		if e := mw.XAttrSet_ll(ino, []byte(key), []byte(value)); e != nil {
+			log.LogWarnf("trash: set xattr failed, parentID(%v) name(%v) ino(%v) key(%v) err(%v)",
+				parentID, name, ino, key, e)
+		}
+	}
+	if isDir {
+		mw.mergeTrashBucket(trashIno, ino)
+	}
+	return true, nil
+}
+
+// trashEntryName returns a new name of the entry in the trash directory, which is unique unless
+// the entries of the same name are deleted from the same directory at the same time.
+func trashEntryName(name string) string {
+	suffix := fmt.Sprintf(".%v", time.Now().UnixNano())
+	if len(name)+len(suffix) > maxTrashNameLen {
+		name = name[:maxTrashNameLen-len(suffix)]
+	}
+	return name + suffix
+}
+
+// mergeTrashBucket moves the entries deleted from the directory back into it after the directory is
+// moved into the trash, so that the deleted tree is kept as it is.
+func (mw *MetaWrapper) mergeTrashBucket(trashIno, dirIno uint64) {
+	bucket, err := mw.lookupTrashBucket(trashIno, dirIno, nil)
+	if err != nil {
+		if err != syscall.ENOENT {
+			log.LogWarnf("mergeTrashBucket: lookup failed, ino(%v) err(%v)", dirIno, err)
+		}
+		return
+	}
+	children, err := mw.ReadDir_ll(bucket)
+	if err != nil {
+		log.LogWarnf("mergeTrashBucket: read dir failed, ino(%v) err(%v)", dirIno, err)
+		return
+	}
+	for _, child := range children {
+		if err = mw.rename(bucket, child.Name, dirIno, child.Name, true, true); err != nil {
+			log.LogWarnf("mergeTrashBucket: move failed, ino(%v) name(%v) err(%v)", dirIno, child.Name, err)
+			continue
+		}
+		mw.removeTrashXAttrs(child.Inode)
+	}
+	if _, err = mw.DeletePermanently_ll(trashIno, strconv.FormatUint(dirIno, 10), true); err != nil {
+		log.LogWarnf("mergeTrashBucket: delete failed, ino(%v) err(%v)", dirIno, err)
+	}
+}
+
+func (mw *MetaWrapper) removeTrashXAttrs(ino uint64) {
+	for _, key := range trashXAttrKeys {
+		if err := mw.XAttrDel_ll(ino, key); err != nil {
+			log.LogWarnf("removeTrashXAttrs: delete xattr failed, ino(%v) key(%v) err(%v)", ino, key, err)
+		}
+	}
+}
+
+// listTrashBuckets returns the directories in the trash which keep the deleted entries.
+func (mw *MetaWrapper) listTrashBuckets(trashIno uint64) ([]proto.Dentry, error) {
+	dentries, err := mw.ReadDir_ll(trashIno)
+	if err != nil {
+		return nil, err
+	}
+	buckets := dentries[:0]
+	for _, dentry := range dentries {
+		if _, err = strconv.ParseUint(dentry.Name, 10, 64); err == nil && proto.IsDir(dentry.Type) {
+			buckets = append(buckets, dentry)
+		}
+	}
+	return buckets, nil
+}
+
+// ListTrash_ll returns the entries in the trash. The deletion time of the entries which fail to
+// be tagged is zero.
+func (mw *MetaWrapper) ListTrash_ll() ([]*TrashEntry, error) {
+	trashIno, err := mw.LookupTrash_ll(false)
+	if err == syscall.ENOENT {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := mw.listTrashBuckets(trashIno)
+	if err != nil {
+		return nil, err
+	}
+	var entries = make([]*TrashEntry, 0)
+	var inodes = make([]uint64, 0)
+	for _, bucket := range buckets {
+		dentries, err := mw.ReadDir_ll(bucket.Inode)
+		if err != nil {
+			return nil, err
+		}
+		parentID, _ := strconv.ParseUint(bucket.Name, 10, 64)
+		for _, dentry := range dentries {
+			entries = append(entries, &TrashEntry{
+				Name:     path.Join(bucket.Name, dentry.Name),
+				Inode:    dentry.Inode,
+				Mode:     dentry.Type,
+				Bucket:   bucket.Inode,
+				ParentID: parentID,
+				OrigName: dentry.Name,
+			})
+			inodes = append(inodes, dentry.Inode)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	xattrInfos, err := mw.BatchGetXAttr(inodes, trashXAttrKeys)
+	if err != nil {
+		return nil, err
+	}
+	xattrs := make(map[uint64]map[string]string, len(xattrInfos))
+	for _, info := range xattrInfos {
+		xattrs[info.Inode] = info.XAttrs
+	}
+	for _, entry := range entries {
+		values, ok := xattrs[entry.Inode]
+		if !ok {
+			continue
+		}
+		if name := values[proto.XAttrKeyTrashName]; name != "" {
+			entry.OrigName = name
+		}
+		if sec, err := strconv.ParseInt(values[proto.XAttrKeyTrashTime], 10, 64); err == nil {
+			entry.DeleteTime = time.Unix(sec, 0)
+		}
+	}
+	return entries, nil
+}
+
+// RestoreTrash_ll moves the entry in the trash back to the original parent directory, with the
+// entries deleted from it if it is a directory. It fails with ENOENT if the parent directory is
+// deleted, and with EEXIST if the original name is taken.
+func (mw *MetaWrapper) RestoreTrash_ll(entry *TrashEntry) error {
+	if entry.ParentID == 0 || entry.Bucket == 0 {
+		return syscall.EINVAL
+	}
+	parent, err := mw.InodeGet_ll(entry.ParentID)
+	if err != nil {
+		return err
+	}
+	if !proto.IsDir(parent.Mode) {
+		return syscall.ENOTDIR
+	}
+	if err = mw.rename(entry.Bucket, path.Base(entry.Name), entry.ParentID, entry.OrigName, true, false); err != nil {
+		log.LogErrorf("RestoreTrash_ll: move out of trash failed, entry(%v) err(%v)", entry.Name, err)
+		return err
+	}
+	mw.removeTrashXAttrs(entry.Inode)
+	return nil
+}
+
+// PurgeTrash_ll deletes the entries which are moved into the trash before the time, and returns
+// the count of the deleted entries. The entries deleted by another purge at the same time are
+// skipped.
+func (mw *MetaWrapper) PurgeTrash_ll(before time.Time) (count int, err error) {
+	trashIno, err := mw.LookupTrash_ll(false)
+	if err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	return mw.purgeTrash(trashIno, before)
+}
+
+// purgeTrash deletes the expired entries, and the empty directories in the trash which kept them.
+// The entries without the deletion time are tagged with the current time.
+func (mw *MetaWrapper) purgeTrash(trashIno uint64, before time.Time) (count int, err error) {
+	entries, err := mw.ListTrash_ll()
+	if err != nil {
+		return 0, err
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	for _, entry := range entries {
+		if entry.DeleteTime.IsZero() {
+			if e := mw.XAttrSet_ll(entry.Inode, []byte(proto.XAttrKeyTrashTime), []byte(now)); e != nil {
+				log.LogWarnf("PurgeTrash_ll: set xattr failed, entry(%v) err(%v)", entry.Name, e)
+			}
+			continue
+		}
+		if !entry.DeleteTime.Before(before) {
+			continue
+		}
+		e := mw.deleteTree(entry.Bucket, path.Base(entry.Name), entry.Inode, entry.Mode)
+		if e == syscall.ENOENT {
+			// purged by another client at the same time
+			continue
+		}
+		if e != nil {
+			log.LogErrorf("PurgeTrash_ll: delete entry failed, entry(%v) err(%v)", entry.Name, e)
+			err = e
+			continue
+		}
+		count++
+	}
+
+	buckets, e := mw.listTrashBuckets(trashIno)
+	if e != nil {
+		return count, err
+	}
+	for _, bucket := range buckets {
+		// the directory modified lately may be taking a deleted entry
+		info, e := mw.InodeGet_ll(bucket.Inode)
+		if e != nil || info.Nlink > 2 || !info.ModifyTime.Before(before) {
+			continue
+		}
+		if _, e = mw.DeletePermanently_ll(trashIno, bucket.Name, true); e != nil && e != syscall.ENOTEMPTY {
+			log.LogWarnf("PurgeTrash_ll: delete directory failed, name(%v) err(%v)", bucket.Name, e)
+		}
+	}
+	return count, err
+}
+
+// deleteTree deletes the entry permanently, and the entries under it if it is a directory.
+func (mw *MetaWrapper) deleteTree(parentID uint64, name string, ino uint64, mode uint32) error {
+	isDir := proto.IsDir(mode)
+	if isDir {
+		children, err := mw.ReadDir_ll(ino)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if err = mw.deleteTree(ino, child.Name, child.Inode, child.Type); err != nil {
+				return err
+			}
+		}
+	}
+	info, err := mw.DeletePermanently_ll(parentID, name, isDir)
+	if err != nil {
+		return err
+	}
+	if info != nil && info.Nlink == 0 && !isDir {
+		if err = mw.Evict(info.Inode); err != nil {
+			log.LogWarnf("deleteTree: evict failed, ino(%v) err(%v)", info.Inode, err)
+		}
+	}
+	return nil
+}
@@ -43,6 +43,8 @@ type VolumeView struct {
 	MetaPartitions []*MetaPartition
 	OSSSecure      *OSSSecure
 	CreateTime     int64
+	QoS            *proto.QoSLimit
+	TrashInterval  uint32
 }
 
 type OSSSecure struct {
@@ -92,6 +94,8 @@ func (mw *MetaWrapper) fetchVolumeView() (view *VolumeView, err error) {
 			MetaPartitions: make([]*MetaPartition, len(volView.MetaPartitions)),
 			OSSSecure:      &OSSSecure{},
 			CreateTime:     volView.CreateTime,
+			QoS:            volView.QoS,
+			TrashInterval:  volView.TrashInterval,
 		}
 		if volView.OSSSecure != nil {
 			result.OSSSecure.AccessKey = volView.OSSSecure.AccessKey
@@ -173,7 +177,11 @@ func (mw *MetaWrapper) updateMetaPartitions() error {
 		}
 	}
 	mw.ossSecure = view.OSSSecure
+	mw.qos = view.QoS
 	mw.volCreateTime = view.CreateTime
+	mw.Lock()
+	mw.trashInterval = view.TrashInterval
+	mw.Unlock()
 
 	if len(rwPartitions) == 0 {
 		log.LogInfof("updateMetaPartition: no valid partitions")